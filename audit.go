@@ -0,0 +1,186 @@
+package xordb
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// An audit (DB.Audit) is a JSONL record of what the cache held at one
+// instant: a header line, then one line per live entry in key order with
+// the SHA-256 of its JSON-encoded value, when it was set and when it
+// expires. Every line ends in a "mac" field, the HMAC-SHA256 under the
+// WithAuditKey key of the previous line's MAC followed by the line's bytes
+// up to that field, so editing, dropping, reordering or inserting a line,
+// or the header's entry count, breaks every MAC after it. Values
+// themselves are not written: an audit proves what was cached without
+// disclosing it. VerifyAudit checks one.
+
+const auditFormat = "xordb-audit"
+
+// AuditHeader is the first line of an audit.
+type AuditHeader struct {
+	Format  string    `json:"format"` // "xordb-audit"
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	Entries int       `json:"entries"`
+
+	// Snapshot identifies the cache state audited, as Snapshot.ID does:
+	// SaveDelta(w, Snapshot) writes everything that changed since.
+	Snapshot uint64 `json:"snapshot"`
+}
+
+// AuditEntry is one entry line of an audit.
+type AuditEntry struct {
+	Key         string     `json:"key"`
+	ValueSHA256 string     `json:"value_sha256"` // hex, of the value's JSON encoding
+	SetAt       time.Time  `json:"set_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// ErrAuditTampered is wrapped by VerifyAudit's error when a line's MAC
+// does not match, or lines are missing.
+var ErrAuditTampered = errors.New("xordb: audit does not verify")
+
+// WithAuditKey sets the HMAC key DB.Audit signs with. Keep it out of
+// reach of whoever could alter the cache or its audits; 32 random bytes
+// will do.
+func WithAuditKey(key []byte) Option {
+	return func(o *dbOptions) { o.auditKey = bytes.Clone(key) }
+}
+
+// Audit writes a signed record of every live entry (see AuditHeader) to
+// w, taken under one lock like Snapshot, for regulated settings that must
+// show which answers the cache could serve at a point in time. Pair it
+// with a snapshot saved at the same time to recover the values. It fails
+// without WithAuditKey, or if a value cannot be encoded as JSON.
+func (db *DB) Audit(w io.Writer) error {
+	if len(db.auditKey) == 0 {
+		return errors.New("xordb: audit: no key; see WithAuditKey")
+	}
+	snap := db.c.Snapshot()
+	es := snap.Entries
+	sort.Slice(es, func(i, j int) bool { return es[i].Key < es[j].Key })
+
+	bw := bufio.NewWriter(w)
+	chain := auditChain{key: db.auditKey}
+	hdr := AuditHeader{Format: auditFormat, Version: 1, Time: time.Now().UTC(), Entries: len(es), Snapshot: snap.ID}
+	if err := chain.write(bw, hdr); err != nil {
+		return fmt.Errorf("xordb: audit: %w", err)
+	}
+	for _, e := range es {
+		value, err := json.Marshal(e.Value)
+		if err != nil {
+			return fmt.Errorf("xordb: audit: %q: %w", e.Key, err)
+		}
+		sum := sha256.Sum256(value)
+		rec := AuditEntry{Key: e.Key, ValueSHA256: hex.EncodeToString(sum[:]), SetAt: e.Ts.UTC()}
+		if !e.Deadline.IsZero() {
+			dl := e.Deadline.UTC()
+			rec.ExpiresAt = &dl
+		}
+		if err := chain.write(bw, rec); err != nil {
+			return fmt.Errorf("xordb: audit: %q: %w", e.Key, err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("xordb: audit: %w", err)
+	}
+	return nil
+}
+
+// VerifyAudit checks every MAC of an audit written by DB.Audit under key
+// and returns its header and entries. An altered, truncated or extended
+// audit fails with an error wrapping ErrAuditTampered.
+func VerifyAudit(r io.Reader, key []byte) (AuditHeader, []AuditEntry, error) {
+	var hdr AuditHeader
+	var entries []AuditEntry
+	chain := auditChain{key: key}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), maxRecordLine)
+	line := 0
+	for sc.Scan() {
+		line++
+		body, err := chain.verify(sc.Bytes())
+		if err != nil {
+			return hdr, nil, fmt.Errorf("xordb: audit line %d: %w", line, err)
+		}
+		if line == 1 {
+			if err := json.Unmarshal(body, &hdr); err != nil || hdr.Format != auditFormat {
+				return hdr, nil, fmt.Errorf("xordb: audit line 1: not an audit header")
+			}
+			if hdr.Version != 1 {
+				return hdr, nil, fmt.Errorf("xordb: audit: unsupported version %d", hdr.Version)
+			}
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal(body, &e); err != nil {
+			return hdr, nil, fmt.Errorf("xordb: audit line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return hdr, nil, fmt.Errorf("xordb: audit: %w", err)
+	}
+	if line == 0 {
+		return hdr, nil, fmt.Errorf("xordb: audit: empty")
+	}
+	if len(entries) != hdr.Entries {
+		return hdr, nil, fmt.Errorf("%w: header lists %d entries, found %d", ErrAuditTampered, hdr.Entries, len(entries))
+	}
+	return hdr, entries, nil
+}
+
+// auditChain signs or checks audit lines in order.
+type auditChain struct {
+	key  []byte
+	prev []byte // MAC of the previous line
+}
+
+// macPrefix separates a line's signed bytes from its MAC.
+var macPrefix = []byte(`,"mac":"`)
+
+func (c *auditChain) mac(body []byte) []byte {
+	h := hmac.New(sha256.New, c.key)
+	h.Write(c.prev)
+	h.Write(body)
+	c.prev = h.Sum(nil)
+	return c.prev
+}
+
+// write writes v as one line with its MAC appended.
+func (c *auditChain) write(w *bufio.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	body := data[:len(data)-1] // without the closing brace
+	w.Write(body)
+	w.Write(macPrefix)
+	w.WriteString(hex.EncodeToString(c.mac(body)))
+	_, err = w.WriteString("\"}\n")
+	return err
+}
+
+// verify checks line's MAC and returns the line without it, as JSON.
+func (c *auditChain) verify(line []byte) ([]byte, error) {
+	i := bytes.LastIndex(line, macPrefix)
+	if i < 0 || !bytes.HasSuffix(line, []byte(`"}`)) {
+		return nil, fmt.Errorf("%w: no mac", ErrAuditTampered)
+	}
+	body := line[:i]
+	got, err := hex.DecodeString(string(line[i+len(macPrefix) : len(line)-2]))
+	if err != nil || !hmac.Equal(got, c.mac(body)) {
+		return nil, fmt.Errorf("%w: bad mac", ErrAuditTampered)
+	}
+	return append(bytes.Clone(body), '}'), nil
+}
@@ -0,0 +1,61 @@
+package xordb_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestDB_Audit(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	db := xordb.New(xordb.WithAuditKey(key))
+	db.Set("what is the capital of india", "Delhi")
+	db.SetWithTTL("weather in paris", "rain", time.Hour)
+
+	var buf bytes.Buffer
+	if err := db.Audit(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Delhi") {
+		t.Fatal("audits must not disclose values")
+	}
+	hdr, entries, err := xordb.VerifyAudit(bytes.NewReader(buf.Bytes()), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr.Entries != 2 || len(entries) != 2 || entries[0].Key != "weather in paris" {
+		t.Fatalf("header %+v entries %+v", hdr, entries)
+	}
+	sum := sha256.Sum256([]byte(`"Delhi"`))
+	if e := entries[1]; e.ValueSHA256 != hex.EncodeToString(sum[:]) || e.ExpiresAt != nil {
+		t.Errorf("india entry %+v", e)
+	}
+	if entries[0].ExpiresAt == nil {
+		t.Error("the paris entry has a TTL")
+	}
+
+	lines := strings.SplitAfter(buf.String(), "\n")
+	for name, forged := range map[string]string{
+		"edited":    strings.Replace(buf.String(), "weather in paris", "weather in perth", 1),
+		"dropped":   lines[0] + lines[2],
+		"reordered": lines[0] + lines[2] + lines[1],
+		"truncated": lines[0] + lines[1],
+	} {
+		if _, _, err := xordb.VerifyAudit(strings.NewReader(forged), key); !errors.Is(err, xordb.ErrAuditTampered) {
+			t.Errorf("%s audit: err %v, want ErrAuditTampered", name, err)
+		}
+	}
+	if _, _, err := xordb.VerifyAudit(bytes.NewReader(buf.Bytes()), []byte("another key")); !errors.Is(err, xordb.ErrAuditTampered) {
+		t.Errorf("wrong key: err %v", err)
+	}
+
+	if err := xordb.New().Audit(&buf); err == nil {
+		t.Error("Audit without WithAuditKey must fail")
+	}
+}
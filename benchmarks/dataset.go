@@ -1,40 +1,35 @@
 package benchmarks
 
 import (
-	"encoding/json"
 	"os"
 	"path/filepath"
 	"runtime"
+
+	"github.com/Amansingh-afk/xordb/eval"
 )
 
 // QueryPair represents a cached entry and a lookup query.
 // ExpectHit indicates whether the lookup is semantically equivalent to the cached key.
-type QueryPair struct {
-	Cached    string `json:"cached"`
-	Lookup    string `json:"lookup"`
-	Answer    string `json:"answer"`
-	ExpectHit bool   `json:"expect_hit"`
-	Category  string `json:"category"`
-}
+type QueryPair = eval.Pair
 
 // Dataset contains 100 realistic LLM query pairs for benchmarking.
-// Loaded from data.json — the single source of truth shared with the Python benchmark.
+// Loaded from data.json — the single source of truth shared with the Python
+// benchmark — unless XORDB_BENCH_DATA points at a user-provided set
+// (.json, .jsonl or .csv; see eval.LoadFile).
 var Dataset = mustLoadDataset()
 
 func mustLoadDataset() []QueryPair {
-	// Resolve data.json relative to this source file so it works
-	// regardless of the working directory (go test, Docker, etc.).
-	_, src, _, _ := runtime.Caller(0)
-	path := filepath.Join(filepath.Dir(src), "data.json")
-
-	data, err := os.ReadFile(path)
-	if err != nil {
-		panic("benchmarks: cannot read data.json: " + err.Error())
+	path := os.Getenv("XORDB_BENCH_DATA")
+	if path == "" {
+		// Resolve data.json relative to this source file so it works
+		// regardless of the working directory (go test, Docker, etc.).
+		_, src, _, _ := runtime.Caller(0)
+		path = filepath.Join(filepath.Dir(src), "data.json")
 	}
 
-	var pairs []QueryPair
-	if err := json.Unmarshal(data, &pairs); err != nil {
-		panic("benchmarks: cannot parse data.json: " + err.Error())
+	pairs, err := eval.LoadFile(path)
+	if err != nil {
+		panic("benchmarks: cannot load " + path + ": " + err.Error())
 	}
 	return pairs
 }
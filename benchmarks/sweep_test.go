@@ -6,6 +6,7 @@ import (
 
 	hdc "github.com/Amansingh-afk/hdc-go"
 	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
 )
 
 // TestSweep_NGram_ThresholdAndDims runs a parameter sweep over threshold and
@@ -115,37 +116,13 @@ func runNGramSweep(dims, ngramSize int, threshold float64) (tp, fp, fn, tn int)
 		xordb.WithCapacity(1000),
 	)
 
-	for _, qp := range Dataset {
-		db.Set(qp.Cached, qp.Answer)
-	}
-
-	for _, qp := range Dataset {
-		_, ok, _ := db.Get(qp.Lookup)
-		switch {
-		case qp.ExpectHit && ok:
-			tp++
-		case !qp.ExpectHit && !ok:
-			tn++
-		case !qp.ExpectHit && ok:
-			fp++
-		case qp.ExpectHit && !ok:
-			fn++
-		}
-	}
-	return
+	c := eval.Run(db, Dataset).Confusion
+	return c.TP, c.FP, c.FN, c.TN
 }
 
 func metrics(tp, fp, fn int) (prec, rec, f1 float64) {
-	if tp+fp > 0 {
-		prec = float64(tp) / float64(tp+fp) * 100
-	}
-	if tp+fn > 0 {
-		rec = float64(tp) / float64(tp+fn) * 100
-	}
-	if prec+rec > 0 {
-		f1 = 2 * prec * rec / (prec + rec)
-	}
-	return
+	c := eval.Confusion{TP: tp, FP: fp, FN: fn}
+	return 100 * c.Precision(), 100 * c.Recall(), 100 * c.F1()
 }
 
 func minf(s []float64) float64 {
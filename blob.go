@@ -0,0 +1,127 @@
+package xordb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// WithMaxValueBytes caps the size of string and []byte values, so
+// multi-megabyte completions and transcripts do not live on the heap (other
+// value types are not measured). A larger value is spooled to a file in the
+// WithBlobDir directory and cached as a *Blob, or, without one, not cached.
+// Spooled and rejected values are counted in Stats.Spooled and
+// Stats.Oversize. 0, the default, means no limit.
+func WithMaxValueBytes(n int64) Option { return func(o *dbOptions) { o.maxValueBytes = n } }
+
+// WithBlobDir sets where WithMaxValueBytes spools large values; dir must
+// exist. Each value gets a file that is unlinked as soon as it is written,
+// so the data lives only as long as its Blob: once the entry is evicted,
+// expired or overwritten and no reader holds the Blob, the garbage
+// collector closes the file and the space is freed, and nothing is left
+// behind after a crash. On Windows, which cannot unlink open files, the
+// files stay in dir.
+func WithBlobDir(dir string) Option { return func(o *dbOptions) { o.blobDir = dir } }
+
+// Blob is a large value spooled to disk by WithMaxValueBytes. Lookups return
+// it as the Value; read it with ReadAt, Reader or Bytes. It is safe for
+// concurrent use. Snapshots and exports store its content inline, as a JSON
+// string, so a loaded snapshot holds it as a plain string again.
+type Blob struct {
+	f    *os.File
+	size int64
+	c    *valueCipher // the file holds sealed chunks; nil = plain
+}
+
+// spool writes data to a new Blob in dir, encrypted if c is not nil.
+func spool(dir string, data []byte, c *valueCipher) (*Blob, error) {
+	f, err := createUnlinked(dir, "xordb-blob-*")
+	if err != nil {
+		return nil, fmt.Errorf("xordb: spool value: %w", err)
+	}
+	stored := data
+	if c != nil {
+		stored = c.sealChunks(data)
+	}
+	if _, err := f.Write(stored); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("xordb: spool value: %w", err)
+	}
+	return &Blob{f: f, size: int64(len(data)), c: c}, nil
+}
+
+// Size returns the length of the value in bytes.
+func (b *Blob) Size() int64 { return b.size }
+
+// ReadAt implements io.ReaderAt.
+func (b *Blob) ReadAt(p []byte, off int64) (int, error) {
+	if off >= b.size {
+		return 0, io.EOF
+	}
+	if rest := b.size - off; int64(len(p)) > rest {
+		n, err := b.readAt(p[:rest], off)
+		if err == nil {
+			err = io.EOF
+		}
+		return n, err
+	}
+	return b.readAt(p, off)
+}
+
+// readAt reads p, which lies within the value, decrypting if need be.
+func (b *Blob) readAt(p []byte, off int64) (int, error) {
+	if b.c == nil {
+		return b.f.ReadAt(p, off)
+	}
+	stride := int64(blobChunk + b.c.overhead())
+	read := 0
+	for read < len(p) {
+		i := (off + int64(read)) / blobChunk
+		sealed := make([]byte, min(blobChunk, b.size-i*blobChunk)+int64(b.c.overhead()))
+		if _, err := b.f.ReadAt(sealed, i*stride); err != nil {
+			return read, err
+		}
+		chunk, err := b.c.open(sealed, chunkAD(i))
+		if err != nil {
+			return read, fmt.Errorf("xordb: read blob: %w", err)
+		}
+		read += copy(p[read:], chunk[off+int64(read)-i*blobChunk:])
+	}
+	return read, nil
+}
+
+// Reader returns a reader of the whole value, independent of other readers.
+func (b *Blob) Reader() *io.SectionReader { return io.NewSectionReader(b, 0, b.size) }
+
+// Bytes reads the whole value into memory.
+func (b *Blob) Bytes() ([]byte, error) {
+	buf := make([]byte, b.size)
+	if _, err := b.readAt(buf, 0); err != nil {
+		return nil, fmt.Errorf("xordb: read blob: %w", err)
+	}
+	return buf, nil
+}
+
+// MarshalJSON writes the content as a JSON string; content that is not
+// UTF-8 is written base64-encoded, as encoding/json writes a []byte.
+func (b *Blob) MarshalJSON() ([]byte, error) {
+	data, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if utf8.Valid(data) {
+		return json.Marshal(string(data))
+	}
+	return json.Marshal(data)
+}
+
+// spool returns the cache's Options.Spool for WithBlobDir, or nil.
+func (o *dbOptions) spool() func([]byte) (any, error) {
+	if o.blobDir == "" {
+		return nil
+	}
+	dir, c := o.blobDir, o.cipher
+	return func(data []byte) (any, error) { return spool(dir, data, c) }
+}
@@ -0,0 +1,70 @@
+package xordb_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestDB_WithMaxValueBytes_Spool(t *testing.T) {
+	dir := t.TempDir()
+	db := xordb.New(xordb.WithMaxValueBytes(16), xordb.WithBlobDir(dir))
+	long := strings.Repeat("a long completion ", 1000)
+	db.Set("summarize the report", long)
+	db.Set("short", "small value")
+
+	v, ok, _ := db.Get("summarize the report")
+	b, isBlob := v.(*xordb.Blob)
+	if !ok || !isBlob {
+		t.Fatalf("got %T, %v, want a *Blob hit", v, ok)
+	}
+	if b.Size() != int64(len(long)) {
+		t.Errorf("Size() = %d, want %d", b.Size(), len(long))
+	}
+	got, err := io.ReadAll(b.Reader())
+	if err != nil || string(got) != long {
+		t.Errorf("Reader: %d bytes, %v", len(got), err)
+	}
+	p := make([]byte, 8)
+	if n, err := b.ReadAt(p, int64(len(long))-4); n != 4 || err != io.EOF {
+		t.Errorf("ReadAt past the end = %d, %v, want 4, EOF", n, err)
+	}
+	if v, _, _ := db.Get("short"); v != "small value" {
+		t.Errorf("small value = %v, want it kept in memory", v)
+	}
+	if st := db.Stats(); st.Spooled != 1 || st.Oversize != 0 {
+		t.Errorf("spooled %d oversize %d", st.Spooled, st.Oversize)
+	}
+	if runtime.GOOS != "windows" {
+		if files, _ := os.ReadDir(dir); len(files) != 0 {
+			t.Errorf("%d files left in the blob dir, want them unlinked", len(files))
+		}
+	}
+
+	// Snapshots store the content inline.
+	path := filepath.Join(t.TempDir(), "snap.xrdb")
+	if err := db.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := xordb.New()
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if v, _, _ := loaded.Get("summarize the report"); v != long {
+		t.Errorf("loaded value is %T, want the content as a string", v)
+	}
+}
+
+func TestDB_WithMaxValueBytes_NoBlobDir(t *testing.T) {
+	db := xordb.New(xordb.WithMaxValueBytes(4))
+	db.Set("key", bytes.Repeat([]byte{1}, 5))
+	if db.Len() != 0 || db.Stats().Oversize != 1 {
+		t.Errorf("len %d oversize %d, want the value rejected", db.Len(), db.Stats().Oversize)
+	}
+}
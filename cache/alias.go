@@ -0,0 +1,43 @@
+package cache
+
+import "time"
+
+// Alias stores a copy of key's entry under alias, so a paraphrase that
+// scored just below the threshold hits directly next time. The copy keeps
+// the entry's value, source tag and deadline; it is a separate entry from
+// then on. Returns false if key is not cached or has expired.
+func (c *Cache) Alias(alias, key string) bool {
+	vec, ok := c.encode(alias)
+	if !ok {
+		return false // over the encode budget
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+	elem, ok := c.index.Get(key)
+	if !ok {
+		return false
+	}
+	now := time.Now()
+	src := elem.Value.(*entry)
+	if c.isExpired(src, now) {
+		return false
+	}
+	if alias == key {
+		return true
+	}
+	value, err := c.valueLocked(src)
+	if err != nil {
+		return false
+	}
+	source, dl, ttl := src.source, src.deadline, src.ttl
+	var remaining time.Duration
+	if !dl.IsZero() {
+		remaining = dl.Sub(now)
+	}
+	c.setLocked(alias, vec, value, remaining, source, now, true)
+	stored, _ := c.index.Get(alias)
+	e := stored.Value.(*entry)
+	e.deadline, e.ttl = dl, ttl // expire together, unjittered
+	return true
+}
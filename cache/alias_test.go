@@ -0,0 +1,40 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache_Alias(t *testing.T) {
+	c := newCacheWithTTL(0.75, 10, time.Hour)
+	c.SetWithSource("what is the capital of india", "Delhi", "gpt-4")
+	if !c.Alias("capital city of india", "what is the capital of india") {
+		t.Fatal("Alias of a cached key must succeed")
+	}
+	r := c.Lookup("capital city of india")
+	if !r.Hit || r.Value != "Delhi" || r.MatchedKey != "capital city of india" || r.EntrySource != "gpt-4" {
+		t.Fatalf("alias must hit with the original value and source, got %+v", r)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("want 2 entries, got %d", c.Len())
+	}
+}
+
+func TestCache_Alias_Missing(t *testing.T) {
+	c := newCache(0.75, 10)
+	if c.Alias("capital city of india", "what is the capital of india") {
+		t.Fatal("Alias of an uncached key must fail")
+	}
+	if c.Len() != 0 {
+		t.Fatalf("failed Alias must not store anything, got %d entries", c.Len())
+	}
+}
+
+func TestCache_Alias_Expired(t *testing.T) {
+	c := newCacheWithTTL(0.75, 10, 10*time.Millisecond)
+	c.Set("what is the capital of india", "Delhi")
+	time.Sleep(20 * time.Millisecond)
+	if c.Alias("capital city of india", "what is the capital of india") {
+		t.Fatal("Alias of an expired key must fail")
+	}
+}
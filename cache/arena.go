@@ -0,0 +1,70 @@
+package cache
+
+import "strings"
+
+// Key arena (Options.KeyArena): stored keys are copied into shared chunks
+// rather than each holding on to its own allocation — often a slice of a
+// much larger request body — so key memory is contiguous and one chunk
+// holds hundreds of keys for a single allocation.
+//
+// Keys escape as strings (MatchedKey, events, snapshots), so the bytes of
+// a removed key are never overwritten for reuse. Removals are counted
+// instead, and once dead bytes outweigh live ones the next insert copies
+// the live keys into fresh chunks and leaves the old ones to the garbage
+// collector.
+
+const (
+	arenaChunk = 64 << 10       // bytes per chunk
+	arenaLarge = arenaChunk / 8 // keys this long get their own allocation
+)
+
+type keyArena struct {
+	b    strings.Builder // current chunk; never grown, so earlier keys stay put
+	live int             // bytes of stored keys
+	dead int             // bytes of keys removed since the last compaction
+}
+
+// intern returns a copy of s in the arena.
+func (a *keyArena) intern(s string) string {
+	a.live += len(s)
+	if len(s) >= arenaLarge {
+		return strings.Clone(s)
+	}
+	if a.b.Cap()-a.b.Len() < len(s) {
+		a.b = strings.Builder{}
+		a.b.Grow(arenaChunk)
+	}
+	a.b.WriteString(s)
+	all := a.b.String()
+	return all[len(all)-len(s):]
+}
+
+// internLocked returns key as the cache should store it.
+func (c *Cache) internLocked(key string) string {
+	if c.keys == nil {
+		return key
+	}
+	if c.keys.dead >= arenaChunk && c.keys.dead > c.keys.live {
+		c.compactKeysLocked()
+	}
+	return c.keys.intern(key)
+}
+
+// compactKeysLocked moves every stored key into a new arena.
+func (c *Cache) compactKeysLocked() {
+	c.keys = new(keyArena)
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*entry)
+		c.index.Delete(e.key)
+		e.key = c.keys.intern(e.key)
+		c.index.Put(e.key, elem)
+	}
+}
+
+// releaseKeyLocked records that a stored key was removed.
+func (c *Cache) releaseKeyLocked(key string) {
+	if c.keys != nil {
+		c.keys.live -= len(key)
+		c.keys.dead += len(key)
+	}
+}
@@ -0,0 +1,57 @@
+package cache_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestCache_KeyArena_CopiesKeys(t *testing.T) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.75, Capacity: 16, KeyArena: true})
+	body := strings.Repeat("padding ", 1000) + "what is the capital of france"
+	key := body[len(body)-29:]
+	c.Set(key, "paris")
+	r := c.Lookup("what is the capital of france")
+	if !r.Hit || r.MatchedKey != key {
+		t.Fatalf("got %+v", r)
+	}
+	if unsafe.StringData(r.MatchedKey) == unsafe.StringData(key) {
+		t.Error("stored key shares the caller's memory")
+	}
+}
+
+func TestCache_KeyArena_Churn(t *testing.T) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.75, Capacity: 50, KeyArena: true})
+	// Enough evictions to compact the arena a few times over; SetVec skips
+	// encoding to keep that quick.
+	key := func(i int) string { return fmt.Sprintf("entry %d of the churn test, padded to a longer key", i) }
+	for i := 0; i < 4000; i++ {
+		c.SetVec(key(i), hdc.Random(c.Dims(), uint64(i)), i, 0)
+	}
+	c.Delete(key(3999))
+	c.SetMany([]cache.Item{{Key: "a late key", Value: -1}})
+
+	want := map[string]bool{"a late key": true}
+	for i := 3950; i < 3999; i++ {
+		want[key(i)] = true
+	}
+	snap := c.Snapshot()
+	if len(snap.Entries) != len(want) {
+		t.Fatalf("%d entries, want %d", len(snap.Entries), len(want))
+	}
+	for _, es := range snap.Entries {
+		if !want[es.Key] {
+			t.Errorf("unexpected key %q", es.Key)
+		}
+	}
+	if r := c.LookupVec(key(3998), hdc.Random(c.Dims(), 3998)); !r.Hit || r.MatchedKey != key(3998) || r.Value != 3998 {
+		t.Errorf("got %+v", r)
+	}
+	if !c.Delete("a late key") || c.Len() != 49 {
+		t.Error("exact-key index lost track of a key")
+	}
+}
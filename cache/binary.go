@@ -7,47 +7,110 @@ import (
 	"fmt"
 	"hash/crc32"
 	"io"
+	"math"
 	"time"
 
 	"github.com/Amansingh-afk/hdc-go"
 )
 
 const (
-	headerSize    = 32
 	formatMagic   = "XRDB"
-	formatVersion = 2
+	formatVersion = 3
+
+	// Version 2 files, with a 32-byte header and a CRC-32 (IEEE) of the
+	// payload, are still read. Version 3 adds the encoder fingerprint in
+	// a 16-byte header extension and checksums with CRC-32C. Version 4 is
+	// version 3 with compressed vectors (see vecpack.go), written only by
+	// EncodeSnapshotCompressed so that older readers reject it.
+	minFormatVersion        = 2
+	compressedFormatVersion = 4
+	baseHeaderSize          = 32
+	headerSize              = 48
 
 	maxKeyLen     = 1 << 20 // 1 MB
 	maxValLen     = 1 << 24 // 16 MB
 	maxEntryCount = 1 << 24 // ~16M entries
 	maxPayloadLen = 1 << 32 // 4 GB hard cap on payload read
+
+	// flagTombstones marks a file whose entries are followed by
+	// tombstones; their count is in hdr[24:28].
+	flagTombstones = 1 << 0
+
+	// flagCounters marks a file ending in the lifetime counters, after any
+	// tombstones.
+	flagCounters = 1 << 1
+	countersSize = (6 + NumSimBuckets) * 8
 )
 
-// EncodeSnapshot writes a binary-encoded snapshot to w.
-// Format: 32-byte header + entry payload.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// EncodeSnapshot writes a binary-encoded snapshot to w, always in the
+// current format version.
+// Format: 48-byte header + entry payload, then tombstones if any.
 // Values are JSON-encoded. Vectors are raw uint64 bytes (little-endian).
+// A tombstone is its key length, key and deletion time in Unix nanoseconds.
+// Counters are hits, misses, sets, expired, evictions, the float64 bits of
+// the hit similarity sum and the similarity histogram, as uint64s.
 func EncodeSnapshot(w io.Writer, s Snapshot) error {
+	return encodeSnapshot(w, s, false)
+}
+
+// EncodeSnapshotCompressed is EncodeSnapshot with each vector stored as
+// its difference from the snapshot's majority vector, where that is
+// smaller than the raw words. It shrinks snapshots of caches whose keys
+// encode alike — templated questions, near-duplicates — and costs a
+// byte per vector otherwise; independent random-looking vectors do not
+// compress. The file is format version 4, which DecodeSnapshot reads back
+// into ordinary vectors but OpenMapped and older releases cannot open.
+func EncodeSnapshotCompressed(w io.Writer, s Snapshot) error {
+	return encodeSnapshot(w, s, true)
+}
+
+func encodeSnapshot(w io.Writer, s Snapshot, compress bool) error {
 	// Encode entries into a buffer first to compute CRC.
 	var payload bytes.Buffer
+	var pk *vecPacker
+	if compress {
+		pk = newVecPacker(s)
+		pk.writeCentroid(&payload)
+	}
 	for _, e := range s.Entries {
-		if err := encodeEntry(&payload, e, s.Dims); err != nil {
+		if err := encodeEntry(&payload, e, s.Dims, pk); err != nil {
 			return err
 		}
 	}
+	var flags uint16
+	if len(s.Tombstones) > 0 {
+		flags |= flagTombstones
+		for _, t := range s.Tombstones {
+			encodeTombstone(&payload, t)
+		}
+	}
+	if s.Counters != nil {
+		flags |= flagCounters
+		encodeCounters(&payload, s.Counters)
+	}
 
 	payloadBytes := payload.Bytes()
-	crc := crc32.ChecksumIEEE(payloadBytes)
+	crc := crc32.Checksum(payloadBytes, castagnoli)
 
 	// Write header.
 	var hdr [headerSize]byte
 	copy(hdr[0:4], formatMagic)
-	binary.LittleEndian.PutUint16(hdr[4:6], formatVersion)
-	binary.LittleEndian.PutUint16(hdr[6:8], 0) // flags reserved
+	version := uint16(formatVersion)
+	if compress {
+		version = compressedFormatVersion
+	}
+	binary.LittleEndian.PutUint16(hdr[4:6], version)
+	binary.LittleEndian.PutUint16(hdr[6:8], flags)
 	binary.LittleEndian.PutUint32(hdr[8:12], uint32(s.Dims))
 	binary.LittleEndian.PutUint32(hdr[12:16], uint32(s.Capacity))
 	binary.LittleEndian.PutUint32(hdr[16:20], uint32(len(s.Entries)))
 	binary.LittleEndian.PutUint32(hdr[20:24], crc)
-	// hdr[24:32] reserved, zero
+	binary.LittleEndian.PutUint32(hdr[24:28], uint32(len(s.Tombstones)))
+	// hdr[28:32] reserved, zero
+	binary.LittleEndian.PutUint64(hdr[32:40], s.Encoder)
+	// hdr[40:48] reserved, zero
 
 	if _, err := w.Write(hdr[:]); err != nil {
 		return err
@@ -56,41 +119,31 @@ func EncodeSnapshot(w io.Writer, s Snapshot) error {
 	return err
 }
 
-// DecodeSnapshot reads a binary-encoded snapshot.
-// dims is the expected vector dimensionality — mismatches are rejected.
+// DecodeSnapshot reads a binary-encoded snapshot of any supported format
+// version. dims is the expected vector dimensionality — mismatches are
+// rejected. The encoder fingerprint is returned in Snapshot.Encoder for
+// LoadSnapshot to check; it is zero for version 2 files.
 func DecodeSnapshot(r io.Reader, dims int) (Snapshot, error) {
 	var hdr [headerSize]byte
-	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+	if _, err := io.ReadFull(r, hdr[:baseHeaderSize]); err != nil {
 		return Snapshot{}, fmt.Errorf("cache: read header: %w", err)
 	}
-
-	if string(hdr[0:4]) != formatMagic {
-		return Snapshot{}, fmt.Errorf("cache: invalid magic %q (want %q)", hdr[0:4], formatMagic)
-	}
-	version := binary.LittleEndian.Uint16(hdr[4:6])
-	if version != formatVersion {
-		return Snapshot{}, fmt.Errorf("cache: format version %d unsupported (want %d)", version, formatVersion)
-	}
-
-	fileDims := int(binary.LittleEndian.Uint32(hdr[8:12]))
-	if fileDims != dims {
-		return Snapshot{}, fmt.Errorf("cache: file dims %d does not match cache dims %d", fileDims, dims)
+	h, err := parseHeader(hdr[:baseHeaderSize], dims)
+	if err != nil {
+		return Snapshot{}, err
 	}
-
-	capacity := int(binary.LittleEndian.Uint32(hdr[12:16]))
-	count := int(binary.LittleEndian.Uint32(hdr[16:20]))
-	expectedCRC := binary.LittleEndian.Uint32(hdr[20:24])
-
-	if count < 0 || count > maxEntryCount {
-		return Snapshot{}, fmt.Errorf("cache: entry count %d out of range (max %d)", count, maxEntryCount)
+	if _, err := io.ReadFull(r, hdr[baseHeaderSize:h.size()]); err != nil {
+		return Snapshot{}, fmt.Errorf("cache: read header: %w", err)
 	}
+	h.parseExt(hdr[:h.size()])
+	count, tombCount := h.count, h.tombCount
 
 	// Compute upper bound on payload size to prevent unbounded reads.
 	// Use realistic per-entry sizes rather than maximum key/value lengths,
 	// which would make the limit effectively useless.
 	nw := hdc.NumWords(dims)
 	entryOverhead := int64(4 + 4096 + int64(nw)*8 + 16 + 4 + 1<<20)
-	maxPayload := int64(count) * entryOverhead
+	maxPayload := int64(count)*entryOverhead + int64(tombCount)*(4+4096+8) + countersSize + int64(nw)*8
 	if maxPayload > maxPayloadLen {
 		maxPayload = maxPayloadLen
 	}
@@ -103,35 +156,179 @@ func DecodeSnapshot(r io.Reader, dims int) (Snapshot, error) {
 		return Snapshot{}, fmt.Errorf("cache: payload size exceeds limit")
 	}
 
-	actualCRC := crc32.ChecksumIEEE(payloadBytes)
-	if actualCRC != expectedCRC {
-		return Snapshot{}, fmt.Errorf("cache: CRC mismatch (file=%08x computed=%08x)", expectedCRC, actualCRC)
+	if err := h.checkCRC(payloadBytes); err != nil {
+		return Snapshot{}, err
 	}
 
 	entries := make([]EntrySnapshot, 0, count)
 	buf := bytes.NewReader(payloadBytes)
 
+	var up *vecUnpacker
+	if h.compressed() {
+		if up, err = readCentroid(buf, dims); err != nil {
+			return Snapshot{}, fmt.Errorf("cache: centroid: %w", err)
+		}
+	}
 	for i := 0; i < count; i++ {
-		e, err := decodeEntry(buf, nw)
+		e, err := decodeEntry(buf, nw, up)
 		if err != nil {
 			return Snapshot{}, fmt.Errorf("cache: entry %d: %w", i, err)
 		}
 		entries = append(entries, e)
 	}
 
+	var tombs []Tombstone
+	for i := 0; i < tombCount; i++ {
+		t, err := decodeTombstone(buf)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("cache: tombstone %d: %w", i, err)
+		}
+		tombs = append(tombs, t)
+	}
+
+	var counters *Counters
+	if h.counters {
+		if counters, err = decodeCounters(buf); err != nil {
+			return Snapshot{}, fmt.Errorf("cache: counters: %w", err)
+		}
+	}
+
 	if buf.Len() != 0 {
 		return Snapshot{}, fmt.Errorf("cache: %d trailing bytes after %d entries", buf.Len(), count)
 	}
 
 	return Snapshot{
-		Version:  int(version),
-		Dims:     fileDims,
-		Capacity: capacity,
-		Entries:  entries,
+		Version:    snapshotVersion,
+		Encoder:    h.encoder,
+		Dims:       dims,
+		Capacity:   h.capacity,
+		Entries:    entries,
+		Tombstones: tombs,
+		Counters:   counters,
 	}, nil
 }
 
-func decodeEntry(r *bytes.Reader, numWords int) (EntrySnapshot, error) {
+// header is a parsed, validated snapshot file header.
+type header struct {
+	version   uint16
+	capacity  int
+	count     int
+	tombCount int
+	crc       uint32
+	encoder   uint64 // fingerprint, 0 before version 3
+	counters  bool   // lifetime counters follow the tombstones
+}
+
+// parseHeader parses the first baseHeaderSize bytes of a header, common to
+// every version; parseExt reads the rest, if size says there is more.
+func parseHeader(hdr []byte, dims int) (header, error) {
+	if string(hdr[0:4]) != formatMagic {
+		return header{}, fmt.Errorf("cache: invalid magic %q (want %q)", hdr[0:4], formatMagic)
+	}
+	h := header{version: binary.LittleEndian.Uint16(hdr[4:6])}
+	if h.version < minFormatVersion || h.version > compressedFormatVersion {
+		return header{}, fmt.Errorf("cache: %w: format version %d unsupported (want %d to %d)", ErrIncompatibleSnapshot, h.version, minFormatVersion, compressedFormatVersion)
+	}
+
+	fileDims := int(binary.LittleEndian.Uint32(hdr[8:12]))
+	if fileDims != dims {
+		return header{}, fmt.Errorf("cache: %w: %w", ErrIncompatibleSnapshot, &DimsError{What: "file", Got: fileDims, Want: dims})
+	}
+
+	h.capacity = int(binary.LittleEndian.Uint32(hdr[12:16]))
+	h.count = int(binary.LittleEndian.Uint32(hdr[16:20]))
+	h.crc = binary.LittleEndian.Uint32(hdr[20:24])
+
+	if h.count < 0 || h.count > maxEntryCount {
+		return header{}, fmt.Errorf("cache: entry count %d out of range (max %d)", h.count, maxEntryCount)
+	}
+	flags := binary.LittleEndian.Uint16(hdr[6:8])
+	h.counters = flags&flagCounters != 0
+	if flags&flagTombstones != 0 {
+		h.tombCount = int(binary.LittleEndian.Uint32(hdr[24:28]))
+		if h.tombCount > maxEntryCount {
+			return header{}, fmt.Errorf("cache: tombstone count %d out of range (max %d)", h.tombCount, maxEntryCount)
+		}
+	}
+	return h, nil
+}
+
+// size returns the full header length for h's version.
+func (h header) size() int {
+	if h.version < 3 {
+		return baseHeaderSize
+	}
+	return headerSize
+}
+
+// compressed reports whether vectors are packed (version 4).
+func (h header) compressed() bool { return h.version >= compressedFormatVersion }
+
+// parseExt reads the version 3 extension from the full header, hdr[:size].
+func (h *header) parseExt(hdr []byte) {
+	if h.version >= 3 {
+		h.encoder = binary.LittleEndian.Uint64(hdr[32:40])
+	}
+}
+
+func (h header) checkCRC(payload []byte) error {
+	table := castagnoli
+	if h.version < 3 {
+		table = crc32.IEEETable
+	}
+	if actual := crc32.Checksum(payload, table); actual != h.crc {
+		return fmt.Errorf("cache: CRC mismatch (file=%08x computed=%08x)", h.crc, actual)
+	}
+	return nil
+}
+
+func encodeCounters(w *bytes.Buffer, n *Counters) {
+	for _, v := range [...]uint64{n.Hits, n.Misses, n.Sets, n.Expired, n.Evictions, math.Float64bits(n.SimSum)} {
+		binary.Write(w, binary.LittleEndian, v)
+	}
+	binary.Write(w, binary.LittleEndian, n.HitSimilarity)
+}
+
+func decodeCounters(r *bytes.Reader) (*Counters, error) {
+	var v [6]uint64
+	n := &Counters{}
+	if err := binary.Read(r, binary.LittleEndian, &v); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &n.HitSimilarity); err != nil {
+		return nil, err
+	}
+	n.Hits, n.Misses, n.Sets, n.Expired, n.Evictions = v[0], v[1], v[2], v[3], v[4]
+	n.SimSum = math.Float64frombits(v[5])
+	return n, nil
+}
+
+func encodeTombstone(w *bytes.Buffer, t Tombstone) {
+	binary.Write(w, binary.LittleEndian, uint32(len(t.Key)))
+	w.WriteString(t.Key)
+	binary.Write(w, binary.LittleEndian, t.DeletedAt.UnixNano())
+}
+
+func decodeTombstone(r *bytes.Reader) (Tombstone, error) {
+	var keyLen uint32
+	if err := binary.Read(r, binary.LittleEndian, &keyLen); err != nil {
+		return Tombstone{}, err
+	}
+	if keyLen > maxKeyLen {
+		return Tombstone{}, fmt.Errorf("key length %d exceeds maximum %d", keyLen, maxKeyLen)
+	}
+	key := make([]byte, keyLen)
+	if _, err := io.ReadFull(r, key); err != nil {
+		return Tombstone{}, err
+	}
+	var at int64
+	if err := binary.Read(r, binary.LittleEndian, &at); err != nil {
+		return Tombstone{}, err
+	}
+	return Tombstone{Key: string(key), DeletedAt: time.Unix(0, at)}, nil
+}
+
+func decodeEntry(r *bytes.Reader, numWords int, up *vecUnpacker) (EntrySnapshot, error) {
 	var keyLen uint32
 	if err := binary.Read(r, binary.LittleEndian, &keyLen); err != nil {
 		return EntrySnapshot{}, err
@@ -144,11 +341,19 @@ func decodeEntry(r *bytes.Reader, numWords int) (EntrySnapshot, error) {
 		return EntrySnapshot{}, err
 	}
 
-	vecData := make([]uint64, numWords)
-	for i := range vecData {
-		if err := binary.Read(r, binary.LittleEndian, &vecData[i]); err != nil {
+	var vecData []uint64
+	if up != nil {
+		var err error
+		if vecData, err = up.read(r); err != nil {
 			return EntrySnapshot{}, err
 		}
+	} else {
+		vecData = make([]uint64, numWords)
+		for i := range vecData {
+			if err := binary.Read(r, binary.LittleEndian, &vecData[i]); err != nil {
+				return EntrySnapshot{}, err
+			}
+		}
 	}
 
 	var ts, deadline int64
@@ -190,7 +395,7 @@ func decodeEntry(r *bytes.Reader, numWords int) (EntrySnapshot, error) {
 	}, nil
 }
 
-func encodeEntry(w *bytes.Buffer, e EntrySnapshot, dims int) error {
+func encodeEntry(w *bytes.Buffer, e EntrySnapshot, dims int, pk *vecPacker) error {
 	if len(e.VecData) != hdc.NumWords(dims) {
 		return fmt.Errorf("entry %q: VecData length %d != expected %d", e.Key, len(e.VecData), hdc.NumWords(dims))
 	}
@@ -201,10 +406,14 @@ func encodeEntry(w *bytes.Buffer, e EntrySnapshot, dims int) error {
 	}
 	w.Write(keyBytes)
 
-	// VecData — raw uint64s as little-endian bytes
-	for _, word := range e.VecData {
-		if err := binary.Write(w, binary.LittleEndian, word); err != nil {
-			return err
+	// VecData — raw uint64s as little-endian bytes, or packed
+	if pk != nil {
+		pk.write(w, e.VecData)
+	} else {
+		for _, word := range e.VecData {
+			if err := binary.Write(w, binary.LittleEndian, word); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -3,6 +3,7 @@ package cache_test
 import (
 	"bytes"
 	"encoding/binary"
+	"hash/crc32"
 	"testing"
 	"time"
 
@@ -87,6 +88,32 @@ func TestBinary_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestBinary_Counters(t *testing.T) {
+	snap := cache.Snapshot{Version: 2, Dims: 64, Counters: &cache.Counters{
+		Hits: 7, Misses: 3, Sets: 5, Expired: 1, Evictions: 2, SimSum: 6.5,
+	}}
+	snap.Counters.HitSimilarity[19] = 7
+
+	var buf bytes.Buffer
+	if err := cache.EncodeSnapshot(&buf, snap); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cache.DecodeSnapshot(&buf, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Counters == nil || *got.Counters != *snap.Counters {
+		t.Fatalf("counters: got %+v", got.Counters)
+	}
+
+	buf.Reset()
+	snap.Counters = nil
+	cache.EncodeSnapshot(&buf, snap)
+	if got, err := cache.DecodeSnapshot(&buf, 64); err != nil || got.Counters != nil {
+		t.Fatalf("a file without counters must decode to nil: %+v, %v", got.Counters, err)
+	}
+}
+
 func TestDecodeSnapshot_BadMagic(t *testing.T) {
 	data := make([]byte, 32)
 	copy(data[0:4], "NOPE")
@@ -140,9 +167,9 @@ func TestDecodeSnapshot_CRCCorruption(t *testing.T) {
 	cache.EncodeSnapshot(&buf, snap)
 	data := buf.Bytes()
 
-	// Flip a byte in the payload (after 32-byte header)
-	if len(data) > 33 {
-		data[33] ^= 0xFF
+	// Flip a byte in the payload (after 48-byte header)
+	if len(data) > 49 {
+		data[49] ^= 0xFF
 	}
 
 	_, err := cache.DecodeSnapshot(bytes.NewReader(data), dims)
@@ -236,3 +263,63 @@ func TestBinary_JSONValueTypes(t *testing.T) {
 		})
 	}
 }
+
+// toV2 rewrites a current-format file as format version 2: 32-byte header,
+// CRC-32 (IEEE), no encoder fingerprint.
+func toV2(data []byte) []byte {
+	payload := data[48:]
+	out := append([]byte(nil), data[:32]...)
+	binary.LittleEndian.PutUint16(out[4:6], 2)
+	binary.LittleEndian.PutUint32(out[20:24], crc32.ChecksumIEEE(payload))
+	return append(out, payload...)
+}
+
+func TestDecodeSnapshot_Version2(t *testing.T) {
+	c := newTestCache(10, 0.99)
+	c.Set("alpha", "A")
+	var buf bytes.Buffer
+	if err := cache.EncodeSnapshot(&buf, c.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cache.DecodeSnapshot(bytes.NewReader(toV2(buf.Bytes())), c.Dims())
+	if err != nil {
+		t.Fatalf("version 2 files must still load: %v", err)
+	}
+	if len(got.Entries) != 1 || got.Entries[0].Key != "alpha" || got.Encoder != 0 {
+		t.Fatalf("decoded %+v", got)
+	}
+	if err := newTestCache(10, 0.99).LoadSnapshot(got); err != nil {
+		t.Fatalf("version 2 snapshot has no fingerprint to check: %v", err)
+	}
+}
+
+func TestEncodeSnapshot_Fingerprint(t *testing.T) {
+	c := newTestCache(10, 0.99)
+	c.Set("alpha", "A")
+	var buf bytes.Buffer
+	if err := cache.EncodeSnapshot(&buf, c.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if v := binary.LittleEndian.Uint16(data[4:6]); v != 3 {
+		t.Fatalf("want format version 3, got %d", v)
+	}
+	want := cache.Fingerprint(hdc.NewNGramEncoder(hdc.DefaultConfig()))
+	if fp := binary.LittleEndian.Uint64(data[32:40]); fp != want {
+		t.Fatalf("header fingerprint %016x, want %016x", fp, want)
+	}
+	if crc := binary.LittleEndian.Uint32(data[20:24]); crc != crc32.Checksum(data[48:], crc32.MakeTable(crc32.Castagnoli)) {
+		t.Fatal("payload must be checksummed with CRC-32C")
+	}
+
+	cfg := hdc.DefaultConfig()
+	cfg.Seed = 7
+	other := cache.New(hdc.NewNGramEncoder(cfg), cache.Options{Capacity: 10, Threshold: 0.99})
+	snap, err := cache.DecodeSnapshot(bytes.NewReader(data), c.Dims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.LoadSnapshot(snap); err == nil {
+		t.Fatal("a snapshot from a different encoder must be rejected")
+	}
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// ErrEncoder wraps the failures recorded under Options.RecoverEncoderPanics.
+var ErrEncoder = errors.New("cache: encoder failed")
+
+// encode runs the encoder under Options.EncodeBudget. On overrun it returns
+// the fallback encoder's vector, or ok=false if there is no fallback and the
+// key should not be cached or looked up. The overrunning encode is not
+// cancelled: it finishes in the background and its result is dropped.
+// ok is also false if the encoder failed under Options.RecoverEncoderPanics,
+// or Options.Faults failed it, or the encoder is a Pending one still
+// loading and there is no fallback.
+// With Options.EncodeCache, vectors from the encoder — not the fallback's —
+// are remembered, including one that finishes after an overrun.
+func (c *Cache) encode(key string) (vec hdc.Vector, ok bool) {
+	if start := c.lat.start(); !start.IsZero() {
+		defer c.lat.since(phaseEncode, start)
+	}
+	if c.faults != nil && c.encodeFault(key) {
+		return hdc.Vector{}, false
+	}
+	if c.loading() {
+		return c.callEnc(key) // nothing to wait for, nothing worth remembering
+	}
+	if vec, ok := c.encCache.get(key); ok {
+		c.stats.encodeCacheHits.Add(1)
+		return vec, true
+	}
+	if vec, ok, inTime := c.budgeted(func() (hdc.Vector, bool) { return c.encodeCached(key) }); inTime {
+		return vec, ok
+	}
+	if c.encFallback != nil {
+		return c.call(c.encFallback, key)
+	}
+	return hdc.Vector{}, false
+}
+
+// EncodeWith encodes key with enc, an encoder other than the cache's, under
+// Options.RecoverEncoderPanics and Options.EncodeBudget: ok is false if enc
+// failed or overran. The encode cache and the fallback encoder are not
+// used, as they stand in for the cache's encoder.
+func (c *Cache) EncodeWith(enc hdc.Encoder, key string) (vec hdc.Vector, ok bool) {
+	vec, ok, inTime := c.budgeted(func() (hdc.Vector, bool) { return c.call(enc, key) })
+	return vec, ok && inTime
+}
+
+// budgeted runs fn within Options.EncodeBudget, if any. On overrun it
+// counts a timeout and returns inTime=false; fn finishes in the background.
+func (c *Cache) budgeted(fn func() (hdc.Vector, bool)) (vec hdc.Vector, ok, inTime bool) {
+	if c.encBudget <= 0 {
+		vec, ok = fn()
+		return vec, ok, true
+	}
+	type encoded struct {
+		vec hdc.Vector
+		ok  bool
+	}
+	done := make(chan encoded, 1)
+	go func() {
+		vec, ok := fn()
+		done <- encoded{vec, ok}
+	}()
+	t := time.NewTimer(c.encBudget)
+	defer t.Stop()
+	select {
+	case r := <-done:
+		return r.vec, r.ok, true
+	case <-t.C:
+	}
+	c.stats.encodeTimeouts.Add(1)
+	return hdc.Vector{}, false, false
+}
+
+// encodeCached encodes key with the encoder and remembers the vector.
+func (c *Cache) encodeCached(key string) (hdc.Vector, bool) {
+	vec, ok := c.call(c.enc, key)
+	if ok {
+		c.encCache.put(key, vec)
+	}
+	return vec, ok
+}
+
+// call runs enc.Encode(key). Under Options.RecoverEncoderPanics a panic or
+// a vector of the wrong dims is recorded and reported as ok=false.
+func (c *Cache) call(enc hdc.Encoder, key string) (vec hdc.Vector, ok bool) {
+	if !c.encRecover {
+		return enc.Encode(key), true
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			c.encodeFailed(fmt.Errorf("%w on %q: panic: %v", ErrEncoder, key, r))
+			vec, ok = hdc.Vector{}, false
+		}
+	}()
+	vec = enc.Encode(key)
+	if vec.Dims() != c.dims {
+		c.encodeFailed(fmt.Errorf("%w on %q: %w", ErrEncoder, key, &DimsError{What: "vector", Got: vec.Dims(), Want: c.dims}))
+		return hdc.Vector{}, false
+	}
+	return vec, true
+}
+
+func (c *Cache) encodeFailed(err error) {
+	c.stats.encodeErrors.Add(1)
+	c.encErr.Store(&err)
+}
+
+// LastEncodeError returns the most recent encoder failure recovered under
+// Options.RecoverEncoderPanics, wrapping ErrEncoder, or nil.
+func (c *Cache) LastEncodeError() error {
+	if p := c.encErr.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
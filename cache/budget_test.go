@@ -0,0 +1,127 @@
+package cache_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// stallEncoder is the n-gram encoder, except keys starting with "slow"
+// take 200ms.
+type stallEncoder struct{ hdc.Encoder }
+
+func (e stallEncoder) Encode(key string) hdc.Vector {
+	if strings.HasPrefix(key, "slow") {
+		time.Sleep(200 * time.Millisecond)
+	}
+	return e.Encoder.Encode(key)
+}
+
+func TestEncodeBudget_Skip(t *testing.T) {
+	enc := stallEncoder{hdc.NewNGramEncoder(hdc.DefaultConfig())}
+	c := cache.New(enc, cache.Options{Threshold: 0.9, Capacity: 8, EncodeBudget: 20 * time.Millisecond})
+
+	start := time.Now()
+	c.Set("slow key", 1)
+	if _, ok, _ := c.Get("slow key"); ok {
+		t.Fatal("a key over budget must not be cached")
+	}
+	if d := time.Since(start); d > 150*time.Millisecond {
+		t.Fatalf("budget must bound latency, took %v", d)
+	}
+	c.Set("fast key", 2)
+	if _, ok, _ := c.Get("fast key"); !ok {
+		t.Fatal("keys within budget must cache normally")
+	}
+	if s := c.Stats(); s.EncodeTimeouts != 2 || c.Len() != 1 {
+		t.Fatalf("want 2 timeouts and 1 entry, got %d and %d", s.EncodeTimeouts, c.Len())
+	}
+}
+
+func TestEncodeBudget_Fallback(t *testing.T) {
+	ngram := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	c := cache.New(stallEncoder{ngram}, cache.Options{
+		Threshold:       0.9,
+		Capacity:        8,
+		EncodeBudget:    20 * time.Millisecond,
+		FallbackEncoder: ngram,
+	})
+	c.Set("slow key", 1)
+	if v, ok, _ := c.Get("slow key"); !ok || v != 1 {
+		t.Fatalf("fallback vectors must be cached and found, got %v %v", v, ok)
+	}
+	if s := c.Stats(); s.EncodeTimeouts != 2 {
+		t.Fatalf("want 2 timeouts, got %d", s.EncodeTimeouts)
+	}
+}
+
+func TestEncodeBudget_FallbackDimsMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("fallback with different dims must panic")
+		}
+	}()
+	cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+		Threshold:       0.9,
+		Capacity:        8,
+		EncodeBudget:    time.Millisecond,
+		FallbackEncoder: onesEncoder{},
+	})
+}
+
+// faultyEncoder panics on keys starting with "boom" and returns a short
+// vector for keys starting with "short".
+type faultyEncoder struct{ hdc.Encoder }
+
+func (e faultyEncoder) Encode(key string) hdc.Vector {
+	switch {
+	case strings.HasPrefix(key, "boom"):
+		panic("index out of range")
+	case strings.HasPrefix(key, "short"):
+		return hdc.New(64)
+	}
+	return e.Encoder.Encode(key)
+}
+
+func TestRecoverEncoderPanics(t *testing.T) {
+	enc := faultyEncoder{hdc.NewNGramEncoder(hdc.DefaultConfig())}
+	c := cache.New(enc, cache.Options{Threshold: 0.9, Capacity: 8, RecoverEncoderPanics: true})
+	c.Set("capital of india", "Delhi")
+
+	c.Set("boom key", 1)
+	if _, ok, _ := c.Get("boom key"); ok {
+		t.Fatal("a key the encoder panics on must miss")
+	}
+	if err := c.LastEncodeError(); !errors.Is(err, cache.ErrEncoder) || !strings.Contains(err.Error(), "index out of range") {
+		t.Fatalf("LastEncodeError: %v", err)
+	}
+	if c.Explain("boom key", 0) != nil {
+		t.Fatal("Explain must return nothing for a failed encode")
+	}
+	if _, ok, _ := c.Get("short key"); ok {
+		t.Fatal("a wrong-dims vector must miss")
+	}
+	if err := c.LastEncodeError(); !strings.Contains(err.Error(), "64 dims") {
+		t.Fatalf("LastEncodeError: %v", err)
+	}
+	if s := c.Stats(); s.EncodeErrors != 4 || c.Len() != 1 {
+		t.Fatalf("want 4 encode errors and 1 entry, got %d and %d", s.EncodeErrors, c.Len())
+	}
+	if _, ok, _ := c.Get("capital of india"); !ok {
+		t.Fatal("other keys must keep working")
+	}
+}
+
+func TestRecoverEncoderPanics_Off(t *testing.T) {
+	c := cache.New(faultyEncoder{hdc.NewNGramEncoder(hdc.DefaultConfig())}, cache.Options{Threshold: 0.9, Capacity: 8})
+	defer func() {
+		if recover() == nil {
+			t.Fatal("without RecoverEncoderPanics the panic must propagate")
+		}
+	}()
+	c.Set("boom key", 1)
+}
@@ -3,7 +3,10 @@ package cache
 
 import (
 	"container/list"
+	"fmt"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Amansingh-afk/hdc-go"
@@ -14,36 +17,263 @@ type Options struct {
 	Capacity  int           // max entries before LRU eviction
 	TTL       time.Duration // default TTL; zero = no expiry
 
+	// TTLJitter, in [0, 1), randomizes every entry's TTL by up to that
+	// fraction either way, spreading out expirations of entries written
+	// together. SlidingTTL restarts an entry's TTL each time it is hit.
+	TTLJitter  float64
+	SlidingTTL bool
+
 	LSHEnabled  *bool  // nil = auto (enabled if capacity >= 256)
 	LSHK        int    // override auto-computed k; 0 = auto
 	LSHL        int    // override auto-computed L; 0 = auto
 	LSHFallback *bool  // nil or true = fallback to linear scan on LSH miss
 	LSHSeed     uint64 // seed for LSH hash functions
+	LSHProbes   int    // extra buckets probed per table (multi-probe); 0 = off
+
+	// ParallelScanMin, if positive, splits linear scans of at least that
+	// many entries across ScanWorkers goroutines (0 = GOMAXPROCS).
+	ParallelScanMin int
+	ScanWorkers     int
+
+	// BitSliced keeps a transposed copy of the stored vectors, and linear
+	// scans compute all Hamming distances from it in one sequential pass
+	// instead of comparing entries one by one. It doubles vector memory and
+	// makes Sets slower by a pass over one bit column; ParallelScanMin is
+	// ignored, and it needs the default Similarity. See sliced.go.
+	BitSliced bool
+
+	// MissFilter keeps a count of entries by popcount so a lookup no entry
+	// could match by the popcount prefilter misses without a scan, counted
+	// in Stats.DefiniteMisses. It costs a few additions per Set and
+	// eviction and needs the default Similarity. See missfilter.go.
+	MissFilter bool
+
+	// CoarseBits, if positive, keeps that many evenly spaced bits of every
+	// vector (a multiple of 64, below the vector dims) and skips the full
+	// comparison for entries whose coarse copy is clearly too far from the
+	// query's, counting them in Stats.Pruned. Costs CoarseBits/8 bytes per
+	// entry; a true hit is missed with probability around 3·10⁻⁵. Needs the
+	// default Similarity. See coarse.go.
+	CoarseBits int
+
+	// LateInteraction, if positive, also keeps up to that many token
+	// vectors (at most 32) per entry from an encoder implementing
+	// TokenEncoder, and scores lookups by MaxSim over them, which tells
+	// long keys apart better than one pooled vector. New panics if a full
+	// cache would hold over 1 GiB of token vectors. Needs the default
+	// Similarity and no BitSliced, MissFilter or CoarseBits. See late.go.
+	LateInteraction int
+
+	// ScoreFusion, if positive, is the weight α of the encoder's
+	// similarity in a lookup's score, the rest going to the Jaccard overlap
+	// of the query's and the entry's words: score = α·semantic +
+	// (1−α)·lexical. Must be below 1. Costs 4 bytes per distinct word per
+	// entry. See fusion.go.
+	ScoreFusion float64
+
+	// DedupVectors stores one copy of identical vectors shared by every
+	// key that encodes to it. Vectors of 512 dims or fewer are stored in
+	// their entries (see small.go), leaving nothing to share.
+	DedupVectors bool
+
+	// DedupValues stores one copy of equal string and []byte values,
+	// reference counted, shared by every key that holds it: an LLM cache
+	// often keeps the same completion under dozens of paraphrases. Costs a
+	// hash of the value per Set. Shared []byte values must not be modified.
+	// See values.go.
+	DedupValues bool
+
+	// Index maps exact keys to entries; nil means a Go map (NewMapIndex).
+	// NewShardedIndex suits caches of millions of entries. An Index must
+	// not be shared between caches.
+	Index Index
+
+	// KeyArena copies stored keys into shared chunks instead of keeping
+	// the caller's strings, cutting per-entry allocations and the memory a
+	// key pins when it is a substring of a larger one. See arena.go.
+	KeyArena bool
+
+	// Similarity scores two vectors in [0, 1]; nil means hdc.Similarity.
+	// A custom metric turns off the popcount prefilter, which is only
+	// sound for Hamming similarity. LSH still selects candidates by
+	// sampled bits, so pair a metric that strays far from Hamming with a
+	// linear index or LSHFallback.
+	Similarity func(a, b hdc.Vector) float64
+
+	// MaxConcurrentScans, if positive, caps lookups running at once; up to
+	// MaxQueuedScans more wait for a turn and any beyond that fail fast
+	// (TryLookup returns ErrBusy, Get and Lookup report a miss). Bounds the
+	// goroutines piling up behind the lock during a traffic spike.
+	MaxConcurrentScans int
+	MaxQueuedScans     int
+
+	// EvictionSamples, if positive, replaces exact LRU with sampled
+	// eviction: hits only stamp the entry, and a full cache evicts the
+	// least recently used of EvictionSamples random entries (5 is a good
+	// start). Cuts per-hit bookkeeping for large read-heavy caches at the
+	// cost of sometimes evicting an entry that is not the very oldest.
+	// Entry order in Snapshot is then insertion order, not recency.
+	EvictionSamples int
+
+	// TombstoneTTL, if positive, remembers explicit deletes for that long
+	// and writes them into snapshots, so restoring a snapshot or syncing a
+	// replica removes entries deleted elsewhere instead of resurrecting
+	// them. Evictions and expiries are not recorded.
+	TombstoneTTL time.Duration
+
+	// EncodeBudget, if positive, bounds how long a Set or lookup waits for
+	// the encoder. On overrun the key is encoded with FallbackEncoder, if
+	// set, or else skipped: Set stores nothing and a lookup misses. Either
+	// way Stats.EncodeTimeouts counts it. FallbackEncoder must produce
+	// vectors comparable with the encoder's (same dims and space).
+	EncodeBudget    time.Duration
+	FallbackEncoder hdc.Encoder
+
+	// RecoverEncoderPanics contains encoder bugs: a panic in the encoder or
+	// FallbackEncoder, or a vector of the wrong dims, is recovered and
+	// recorded (Stats.EncodeErrors, LastEncodeError) and the operation is
+	// skipped — Set stores nothing, a lookup misses — instead of crashing
+	// the caller.
+	RecoverEncoderPanics bool
+
+	// EncodeCache, if positive, remembers the vectors of that many recently
+	// encoded keys, so a Set followed by lookups of the same string, or a
+	// repeated query, runs the encoder once. Keys over 4 KiB are not
+	// remembered. See encodecache.go.
+	EncodeCache int
+
+	// LatencySampleRate, if positive, is the fraction of calls whose encode
+	// time, and for lookups lock wait and scan time, are recorded for the
+	// percentiles in Stats.Latency (1 = every call). Sampling costs two
+	// clock reads per phase timed. See latency.go.
+	LatencySampleRate float64
+
+	// AcceptedSources, if set, limits lookups to entries tagged with one of
+	// these sources; see SetAcceptedSources.
+	AcceptedSources []string
+
+	// IgnoreSavedCounters makes LoadSnapshot skip the lifetime counters a
+	// snapshot carries, so Stats count from zero in every process.
+	IgnoreSavedCounters bool
+
+	// Margin, if positive, also requires a hit to lead the runner-up entry
+	// by at least that much similarity; otherwise the lookup misses and is
+	// counted in Stats.Ambiguous. This stops caches full of keys built from
+	// one template from serving whichever filled-in variant happens to
+	// score highest. With LSH only candidates are compared.
+	Margin float64
+
+	// SoftMiss, if positive, makes a miss whose best entry scored at least
+	// SoftMiss return that entry anyway, with Hit false and Result.SoftMiss
+	// set, for the caller to verify or to show a model as context. Entries
+	// down to SoftMiss are then scored rather than pruned. A soft miss is
+	// counted as a miss and in Stats.SoftMisses, and does not promote the
+	// entry. See softmiss.go.
+	SoftMiss float64
+
+	// EarlyExit, if positive, ends a lookup at the first hit scoring at
+	// least EarlyExit instead of scanning on for the best, most recently
+	// used entries first, and counts it in Stats.EarlyExits: 0.97 suits a
+	// workload that repeats itself. Scans become serial. Needs no Margin,
+	// which wants every entry's score. See earlyexit.go.
+	EarlyExit float64
+
+	// DecayHalfLife, if positive, scales every entry's similarity to a
+	// query by 2^(−age/DecayHalfLife), age being the time since the entry
+	// was set, so older answers must match more closely to be served: at
+	// the default threshold of 0.75 an entry stops hitting even exact
+	// repeats after 0.415 half-lives. Result.Similarity reports the decayed
+	// score. Pinned entries do not decay. See decay.go.
+	DecayHalfLife time.Duration
+
+	// LowWatermark, if positive, makes a full cache evict down to that
+	// many entries in one batch rather than one entry per Set, smoothing
+	// the latency of Sets on a full cache. BackgroundEviction moves the
+	// batch off the Set path: the cache may then exceed Capacity by up to
+	// Capacity−LowWatermark entries until it runs. See watermark.go.
+	LowWatermark       int
+	BackgroundEviction bool
+
+	// MaxValueBytes, if positive, caps the size of string and []byte values
+	// (other values are not measured). A larger value is passed to Spool,
+	// if set, and the value Spool returns — e.g. a handle to a file — is
+	// stored instead; without Spool, or if it fails, the value is not
+	// cached. Either way Stats.Spooled or Stats.Oversize counts it.
+	// Snapshots hold what was stored, so a spooled handle must marshal to
+	// JSON itself.
+	MaxValueBytes int64
+	Spool         func(data []byte) (any, error)
+
+	// ValueScrubber, if set, rewrites every value before it is measured
+	// and stored — e.g. to redact personal data. It must be safe for
+	// concurrent use. Values loaded from snapshots are not rewritten.
+	ValueScrubber func(any) any
+
+	// HotEntries, if positive, keeps the values of only that many most
+	// recently used entries on the heap; older entries' values are moved
+	// to ColdStore and read back when a lookup hits them. Vectors stay in
+	// memory, so matching is unaffected. Needs exact LRU, i.e. no
+	// EvictionSamples. See cold.go.
+	HotEntries int
+	ColdStore  ColdStore
+
+	// QueryExpander, if set, returns alternative phrasings of a lookup key,
+	// e.g. domain synonyms ("k8s" for "kubernetes"). Lookups encode the key
+	// and every expansion and take the best match of any; Set is not
+	// affected. It runs on every lookup, before the lock, so keep it cheap.
+	QueryExpander func(key string) []string
+
+	// GroupOf, if set, assigns every key to a group, e.g. a tenant, and
+	// Groups gives chosen groups reserved capacity with eviction isolated
+	// from the rest, and their own threshold; see groups.go. Needs exact
+	// LRU and no LowWatermark.
+	GroupOf func(key string) string
+	Groups  map[string]Group
+
+	// Faults, if set, injects encode failures, slow match searches and
+	// eviction storms for testing; see faults.go. Leave it nil in
+	// production.
+	Faults Faults
+
+	// OnEvent, if set, is called for every hit, miss, set, eviction and
+	// expiry, after the cache lock is released. Keep it fast: it runs on
+	// the caller's goroutine.
+	OnEvent func(Event)
 }
 
 func DefaultOptions() Options {
 	return Options{Threshold: 0.75, Capacity: 1024}
 }
 
-type Stats struct {
-	Entries       int
-	Hits          uint64
-	Misses        uint64
-	Sets          uint64
-	Expired       uint64
-	HitRate       float64
-	AvgSimOnHit   float64
-	LSHCandidates uint64
-	LSHFallbacks  uint64
-}
-
 type entry struct {
-	key      string
-	vec      hdc.Vector
-	value    any
-	ts       time.Time
-	deadline time.Time // zero = never expires
-	lshKeys  []uint64  // one per LSH table, nil if LSH disabled
+	key         string
+	vec         hdc.Vector         // unset when inlined
+	inline      [smallWords]uint64 // the vector at 512 dims or fewer, see small.go
+	inlined     bool               // inline holds the vector
+	value       any
+	ts          time.Time
+	deadline    time.Time     // zero = never expires
+	ttl         time.Duration // jittered TTL behind deadline, for SlidingTTL
+	pc          int           // popcount of vec, for the prefilter
+	vecHash     uint64        // key in Cache.vecs when interned
+	interned    bool          // vec is registered in Cache.vecs (dedup on)
+	valHash     uint64        // key in Cache.values when valInterned
+	valInterned bool          // value is registered in Cache.values
+	lshKeys     []uint64      // one per LSH table, nil if LSH disabled
+	pinned      bool          // exempt from LRU eviction and TTL expiry
+	access      uint64        // logical time of last use, for sampled eviction
+	slot        int           // index in Cache.slots, for sampled eviction
+	seq         uint64        // insertion sequence number, for Scan cursors
+	wrote       uint64        // write stamp, for DeltaSnapshot
+	source      string        // tag from SetWithSource, "" if none
+	cold        bool          // value is a ColdStore reference
+	lane        int           // 1 + column in Cache.sliced, 0 if none
+	coarse      []uint64      // sampled words of vec, for Options.CoarseBits
+	tokens      []hdc.Vector  // token vectors, for Options.LateInteraction
+	lex         []uint32      // key's word hashes, for Options.ScoreFusion
+	hits        uint64        // lookups answered since the last set
+	group       *groupState   // nil without Options.GroupOf
+	gelem       *list.Element // in group.lru
 }
 
 // Cache — thread-safe semantic cache. Keys are encoded to hypervectors;
@@ -53,32 +283,212 @@ type Cache struct {
 	enc       hdc.Encoder
 	dims      int // vector dimensionality, used for snapshot validation
 	lru       *list.List
-	index     map[string]*list.Element
+	index     Index
 	threshold float64
 	capacity  int
+	sim       func(a, b hdc.Vector) float64
+	customSim bool // sim is not hdc.Similarity; disables the prefilter
+	small     bool // vectors are stored inline, see small.go
 	ttl       time.Duration
 
+	ttlJitter  float64
+	slidingTTL bool
+
 	lsh         *lshIndex // nil if LSH disabled
 	lshFallback bool      // fallback to linear scan on LSH miss
+	lshProbes   int
+	queryKeys   []uint64 // Get's LSH keys, reused under the lock
+
+	parallelMin int // 0 = always scan serially
+	scanWorkers int
+	scanBuf     []*list.Element // reused by parallelScanLocked
+	sliced      *slicedCorpus   // nil unless Options.BitSliced
+	distBuf     []int           // reused by scanLocked when bit-sliced
+	missf       *missFilter     // nil unless Options.MissFilter
+	coarseWords int             // Options.CoarseBits / 64
+	late        int             // Options.LateInteraction
+	fusion      float64         // Options.ScoreFusion
+	limit       *scanLimiter    // nil unless Options.MaxConcurrentScans
+
+	samples int             // 0 = exact LRU, see sampled.go
+	slots   []*list.Element // every entry, for random sampling
+	clock   uint64          // advanced by each access when sampling
+
+	seq   uint64   // last sequence number handed out
+	order []seqRef // entries by seq, see cursor.go
+
+	stats      counters                // atomic; read by Stats without the lock
+	freshStats bool                    // Options.IgnoreSavedCounters
+	closed     atomic.Bool             // see close.go
+	free       []*entry                // removed entries kept for reuse, see newEntryLocked
+	vecs       map[uint64]*sharedVec   // nil unless Options.DedupVectors
+	values     map[uint64]*sharedValue // nil unless Options.DedupValues
+	keys       *keyArena               // nil unless Options.KeyArena
+
+	accepted map[string]bool // nil = every source, see SetAcceptedSources
+
+	encBudget   time.Duration
+	encFallback hdc.Encoder
+	encRecover  bool
+	encCache    *encodeCache          // nil unless Options.EncodeCache
+	lat         *latencies            // nil unless Options.LatencySampleRate
+	encErr      atomic.Pointer[error] // last recovered encoder failure
+	expand      func(string) []string // Options.QueryExpander
+	margin      float64               // Options.Margin
+	softMiss    float64               // Options.SoftMiss
+	earlyExit   float64               // Options.EarlyExit, see earlyexit.go
+	halfLife    time.Duration         // Options.DecayHalfLife, see decay.go
+	maxValue    int64                 // Options.MaxValueBytes, see valuesize.go
+	low         int                   // Options.LowWatermark, see watermark.go
+	background  bool                  // Options.BackgroundEviction
+	evicting    atomic.Bool           // a background eviction is running
+	spool       func([]byte) (any, error)
+	scrub       func(any) any // Options.ValueScrubber
+	hot         int           // Options.HotEntries, see cold.go
+	coldStore   ColdStore     // nil unless HotEntries
+	edge        *list.Element // most recently used cold entry
 
-	hits          uint64
-	misses        uint64
-	sets          uint64
-	expired       uint64
-	simSum        float64
-	lshCandidates uint64
-	lshFallbacks  uint64
+	tombTTL     time.Duration
+	tombs       map[string]tomb // see tombstone.go
+	tombSweepAt int
+
+	wclock uint64 // stamp of the last write, see delta.go
+
+	fpOnce sync.Once
+	fp     uint64 // encoder fingerprint, see fingerprint.go
+
+	faults Faults // Options.Faults, nil in production
+
+	pendingEnc Pending // the encoder, if it may still be loading; see pending.go
+
+	groupOf  func(string) string    // Options.GroupOf, see groups.go
+	groups   map[string]Group       // Options.Groups
+	reserved map[string]*groupState // groups with a Capacity
+	pool     *groupState            // every other entry; nil without groups
+
+	onEvent func(Event)
+	pending []Event // queued by emitLocked, delivered by unlock
 }
 
+// New creates a cache; it panics if opts are invalid (see TryNew).
 func New(enc hdc.Encoder, opts Options) *Cache {
+	c, err := TryNew(enc, opts)
+	if err != nil {
+		panic(err.Error())
+	}
+	return c
+}
+
+// check validates opts for enc, whose vectors have dims.
+func (opts Options) check(enc hdc.Encoder, dims int) error {
 	if opts.Capacity <= 0 {
-		panic("cache: Options.Capacity must be positive")
+		return invalidOption("Options.Capacity must be positive")
 	}
 	if opts.Threshold <= 0 || opts.Threshold > 1 {
-		panic("cache: Options.Threshold must be in (0, 1]")
+		return invalidOption("Options.Threshold must be in (0, 1]")
+	}
+	if opts.TTLJitter < 0 || opts.TTLJitter >= 1 {
+		return invalidOption("Options.TTLJitter must be in [0, 1)")
+	}
+	if opts.LowWatermark < 0 || opts.LowWatermark >= opts.Capacity {
+		return invalidOption("Options.LowWatermark must be in [0, Capacity)")
+	}
+	if opts.BackgroundEviction && opts.LowWatermark == 0 {
+		return invalidOption("Options.BackgroundEviction needs a LowWatermark")
+	}
+	if !(opts.LatencySampleRate >= 0 && opts.LatencySampleRate <= 1) {
+		return invalidOption("Options.LatencySampleRate must be in [0, 1]")
+	}
+	if opts.EncodeCache < 0 {
+		return invalidOption("Options.EncodeCache must not be negative")
+	}
+	if opts.MaxValueBytes < 0 {
+		return invalidOption("Options.MaxValueBytes must not be negative")
+	}
+	if opts.HotEntries < 0 {
+		return invalidOption("Options.HotEntries must not be negative")
+	}
+	if opts.HotEntries > 0 && opts.ColdStore == nil {
+		return invalidOption("Options.HotEntries needs a ColdStore")
+	}
+	if opts.HotEntries > 0 && opts.EvictionSamples > 0 {
+		return invalidOption("Options.HotEntries does not work with EvictionSamples")
+	}
+	if !(opts.Margin >= 0 && opts.Margin < 1) {
+		return invalidOption("Options.Margin must be in [0, 1)")
+	}
+	if !(opts.SoftMiss >= 0 && opts.SoftMiss <= 1) {
+		return invalidOption("Options.SoftMiss must be in [0, 1]")
+	}
+	if !(opts.EarlyExit >= 0 && opts.EarlyExit <= 1) {
+		return invalidOption("Options.EarlyExit must be in [0, 1]")
+	}
+	if opts.EarlyExit > 0 && opts.Margin > 0 {
+		return invalidOption("Options.EarlyExit does not work with Margin")
+	}
+	if opts.DecayHalfLife < 0 {
+		return invalidOption("Options.DecayHalfLife must not be negative")
 	}
+	if opts.LSHProbes < 0 {
+		return invalidOption("Options.LSHProbes must not be negative")
+	}
+	if opts.MaxConcurrentScans < 0 || opts.MaxQueuedScans < 0 {
+		return invalidOption("Options.MaxConcurrentScans and MaxQueuedScans must not be negative")
+	}
+	if opts.EvictionSamples < 0 {
+		return invalidOption("Options.EvictionSamples must not be negative")
+	}
+	if opts.TombstoneTTL < 0 {
+		return invalidOption("Options.TombstoneTTL must not be negative")
+	}
+	if opts.ParallelScanMin < 0 || opts.ScanWorkers < 0 {
+		return invalidOption("Options.ParallelScanMin and ScanWorkers must not be negative")
+	}
+	if opts.BitSliced && opts.Similarity != nil {
+		return invalidOption("Options.BitSliced needs the default Similarity")
+	}
+	if opts.MissFilter && opts.Similarity != nil {
+		return invalidOption("Options.MissFilter needs the default Similarity")
+	}
+	if opts.CoarseBits != 0 && opts.Similarity != nil {
+		return invalidOption("Options.CoarseBits needs the default Similarity")
+	}
+	if opts.CoarseBits < 0 || opts.CoarseBits%64 != 0 || (opts.CoarseBits > 0 && opts.CoarseBits >= dims) {
+		return invalidOption("Options.CoarseBits must be a multiple of 64 below the vector dims")
+	}
+	if err := checkLate(enc, opts, dims); err != nil {
+		return err
+	}
+	if err := checkFusion(opts.ScoreFusion); err != nil {
+		return err
+	}
+	if err := checkGroups(opts); err != nil {
+		return err
+	}
+	if opts.FallbackEncoder != nil {
+		if d := opts.FallbackEncoder.Encode("").Dims(); d != dims {
+			return fmt.Errorf("cache: %w: %w", ErrInvalidOption, &DimsError{What: "Options.FallbackEncoder", Got: d, Want: dims})
+		}
+	}
+	if opts.LSHK < 0 || opts.LSHK > 64 || opts.LSHL < 0 {
+		return invalidOption("Options.LSHK must be in [0, 64] and LSHL must not be negative")
+	}
+	return nil
+}
 
-	dims := enc.Encode("").Dims()
+// TryNew is New that returns an error wrapping ErrInvalidOption, rather
+// than panicking, when opts are invalid.
+func TryNew(enc hdc.Encoder, opts Options) (*Cache, error) {
+	pending, _ := enc.(Pending)
+	var dims int
+	if pending != nil {
+		dims = pending.Dims()
+	} else {
+		dims = enc.Encode("").Dims()
+	}
+	if err := opts.check(enc, dims); err != nil {
+		return nil, err
+	}
 
 	// LSH fallback defaults to true
 	fallback := true
@@ -90,11 +500,81 @@ func New(enc hdc.Encoder, opts Options) *Cache {
 		enc:         enc,
 		dims:        dims,
 		lru:         list.New(),
-		index:       make(map[string]*list.Element),
+		index:       opts.Index,
 		threshold:   opts.Threshold,
 		capacity:    opts.Capacity,
 		ttl:         opts.TTL,
+		ttlJitter:   opts.TTLJitter,
+		slidingTTL:  opts.SlidingTTL,
 		lshFallback: fallback,
+		lshProbes:   opts.LSHProbes,
+		parallelMin: opts.ParallelScanMin,
+		scanWorkers: opts.ScanWorkers,
+		samples:     opts.EvictionSamples,
+		accepted:    acceptSet(opts.AcceptedSources),
+		tombTTL:     opts.TombstoneTTL,
+		encBudget:   opts.EncodeBudget,
+		encFallback: opts.FallbackEncoder,
+		encRecover:  opts.RecoverEncoderPanics,
+		encCache:    newEncodeCache(opts.EncodeCache),
+		expand:      opts.QueryExpander,
+		margin:      opts.Margin,
+		softMiss:    opts.SoftMiss,
+		earlyExit:   opts.EarlyExit,
+		halfLife:    opts.DecayHalfLife,
+		maxValue:    opts.MaxValueBytes,
+		low:         opts.LowWatermark,
+		background:  opts.BackgroundEviction,
+		spool:       opts.Spool,
+		scrub:       opts.ValueScrubber,
+		coarseWords: opts.CoarseBits / 64,
+		late:        opts.LateInteraction,
+		fusion:      opts.ScoreFusion,
+		hot:         opts.HotEntries,
+		faults:      opts.Faults,
+		onEvent:     opts.OnEvent,
+		sim:         opts.Similarity,
+		customSim:   opts.Similarity != nil,
+		freshStats:  opts.IgnoreSavedCounters,
+		pendingEnc:  pending,
+	}
+	if c.sim == nil {
+		c.sim = hdc.Similarity
+	}
+	if c.index == nil {
+		c.index = NewMapIndex()
+	}
+	c.newGroups(opts)
+	if opts.LatencySampleRate > 0 {
+		c.lat = &latencies{rate: opts.LatencySampleRate}
+	}
+	if opts.HotEntries > 0 {
+		c.coldStore = opts.ColdStore
+	}
+	c.small = smallDims(dims, c.customSim)
+	if opts.DedupVectors && !c.small {
+		c.vecs = make(map[uint64]*sharedVec)
+	}
+	if opts.DedupValues {
+		c.values = make(map[uint64]*sharedValue)
+	}
+	if opts.KeyArena {
+		c.keys = new(keyArena)
+	}
+	if opts.BitSliced {
+		c.sliced = newSlicedCorpus(dims)
+	}
+	if opts.MissFilter {
+		c.missf = newMissFilter(dims)
+	}
+	if opts.TombstoneTTL > 0 {
+		c.tombs = make(map[string]tomb)
+	}
+	if opts.MaxConcurrentScans > 0 {
+		c.limit = newScanLimiter(opts.MaxConcurrentScans, opts.MaxQueuedScans)
+	}
+	if c.scanWorkers == 0 {
+		c.scanWorkers = runtime.GOMAXPROCS(0)
 	}
 
 	// Determine if LSH should be enabled
@@ -117,65 +597,143 @@ func New(enc hdc.Encoder, opts Options) *Cache {
 		c.lsh = newLSHIndex(dims, k, l, opts.LSHSeed)
 	}
 
-	return c
+	return c, nil
 }
 
 // Set stores value with the cache's default TTL.
 func (c *Cache) Set(key string, value any) {
-	c.setWithTTL(key, value, c.ttl)
+	c.setWithTTL(key, value, c.TTL(), "")
 }
 
 // SetWithTTL — per-entry TTL override. Zero = never expires.
 func (c *Cache) SetWithTTL(key string, value any, ttl time.Duration) {
-	c.setWithTTL(key, value, ttl)
+	c.setWithTTL(key, value, ttl, "")
 }
 
-func (c *Cache) setWithTTL(key string, value any, ttl time.Duration) {
+func (c *Cache) setWithTTL(key string, value any, ttl time.Duration, source string) {
 	if ttl < 0 {
 		panic("cache: TTL must not be negative")
 	}
-	vec := c.enc.Encode(key)
+	if c.closed.Load() {
+		return
+	}
+	value, ok := c.admit(value)
+	if !ok {
+		return // too large; not cached
+	}
+	vec, ok := c.encode(key)
+	if !ok {
+		return // over the encode budget; not cached
+	}
+	toks := c.encodeTokens(key)
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
+	c.setLocked(key, vec, value, ttl, source, time.Now(), true)
+	c.setTokensLocked(key, toks)
+}
+
+// Item is one entry for SetMany.
+type Item struct {
+	Key   string
+	Value any
+}
 
-	c.sets++
+// SetMany stores items with the default TTL, as if by Set in order, but
+// encodes them before taking the lock and makes room afterwards with a
+// single batched eviction.
+func (c *Cache) SetMany(items []Item) {
+	if c.closed.Load() {
+		return
+	}
+	vecs := make([]hdc.Vector, len(items))
+	toks := make([][]hdc.Vector, len(items))
+	values := make([]any, len(items))
+	skip := make([]bool, len(items))
+	for i, it := range items {
+		var ok bool
+		if values[i], ok = c.admit(it.Value); ok {
+			vecs[i], ok = c.encode(it.Key)
+		}
+		if ok {
+			toks[i] = c.encodeTokens(it.Key)
+		}
+		skip[i] = !ok
+	}
 
+	c.mu.Lock()
+	defer c.unlock()
 	now := time.Now()
+	for i, it := range items {
+		if !skip[i] {
+			c.setLocked(it.Key, vecs[i], values[i], c.ttl, "", now, false)
+			c.setTokensLocked(it.Key, toks[i])
+		}
+	}
+	c.trimLocked()
+}
+
+// setLocked inserts or updates key. A new key evicts one entry first if the
+// cache is full and evict is set; otherwise the caller makes room.
+func (c *Cache) setLocked(key string, vec hdc.Vector, value any, ttl time.Duration, source string, now time.Time, evict bool) {
+	c.stats.sets.Add(1)
+	c.emitLocked(Event{Kind: EventSet, Key: key})
+
+	ttl = c.jitterTTL(ttl)
 	dl := deadlineFrom(now, ttl)
+	delete(c.tombs, key) // a new write supersedes the delete
+	wrote := c.tickLocked(now)
 
 	// update if exact key exists
-	if elem, ok := c.index[key]; ok {
+	if elem, ok := c.index.Get(key); ok {
 		e := elem.Value.(*entry)
 		// Remove old LSH entries before updating vector
 		if c.lsh != nil && e.lshKeys != nil {
 			c.lsh.remove(elem, e.lshKeys)
 		}
-		e.value = value
-		e.vec = vec
+		c.forgetLocked(e)
+		c.setValueLocked(e, value)
+		e.source = source
+		e.hits = 0
+		c.setVecLocked(e, vec)
 		e.ts = now
-		e.deadline = dl
+		e.deadline, e.ttl = dl, ttl
+		e.wrote = wrote
 		if c.lsh != nil {
-			e.lshKeys = c.lsh.hashVec(vec.RawData())
+			e.lshKeys = c.lsh.hashVecInto(e.lshKeys, vec.RawData())
 			c.lsh.insert(elem, e.lshKeys)
 		}
-		c.lru.MoveToFront(elem)
+		c.touchLocked(elem)
+		c.coolLocked()
 		return
 	}
 
-	if c.lru.Len() >= c.capacity {
-		c.evictLocked()
+	if evict {
+		c.roomLocked(key)
+	}
+	if c.faults != nil {
+		c.evictionStormLocked()
 	}
 
-	e := &entry{key: key, vec: vec, value: value, ts: now, deadline: dl}
+	key = c.internLocked(key)
+	e := c.newEntryLocked()
+	e.key, e.ts, e.deadline, e.ttl = key, now, dl, ttl
+	e.source, e.wrote = source, wrote
+	c.setValueLocked(e, value)
+	c.setVecLocked(e, vec)
 	if c.lsh != nil {
-		e.lshKeys = c.lsh.hashVec(vec.RawData())
+		e.lshKeys = c.lsh.hashVecInto(e.lshKeys, vec.RawData())
 	}
 	elem := c.lru.PushFront(e)
-	c.index[key] = elem
+	c.trackLocked(elem)
+	c.joinLocked(elem)
+	c.orderLocked(e)
+	c.index.Put(key, elem)
+	c.stats.entries.Add(1)
 	if c.lsh != nil {
 		c.lsh.insert(elem, e.lshKeys)
 	}
+	c.coolLocked()
 }
 
 func deadlineFrom(now time.Time, ttl time.Duration) time.Time {
@@ -187,51 +745,234 @@ func deadlineFrom(now time.Time, ttl time.Duration) time.Time {
 
 // Get returns (value, true, similarity) on hit, (nil, false, 0) on miss.
 func (c *Cache) Get(key string) (any, bool, float64) {
-	vec := c.enc.Encode(key)
+	r := c.Lookup(key)
+	return r.Value, r.Hit, r.Similarity
+}
+
+// Source values report how Lookup found its match.
+const (
+	SourceLSH  = "lsh"  // among the LSH candidates
+	SourceScan = "scan" // by linear scan, including LSH fallback
+)
 
+// Result describes a Lookup. On a miss only Hit (false) is set.
+type Result struct {
+	Value      any
+	Hit        bool
+	Similarity float64
+	MatchedKey string        // key the matched entry was stored under
+	EntryAge   time.Duration // time since the matched entry was set
+	ExpiresIn  time.Duration // time until it expires, 0 if it has no TTL
+	Source     string        // SourceLSH or SourceScan
+
+	// Margin is how far Similarity leads the runner-up: the next best
+	// entry, or the threshold if no other entry reaches it. With LSH only
+	// candidates count. Confidence folds Margin and Similarity into [0, 1];
+	// see confidence.go.
+	Margin     float64
+	Confidence float64
+
+	EntrySource string // the matched entry's SetWithSource tag
+
+	// SoftMiss is set on a miss that still carries its best entry, under
+	// Options.SoftMiss: Value, Similarity, MatchedKey, the entry's age,
+	// expiry and tag are filled in, Hit, Source, Margin and Confidence
+	// are not.
+	SoftMiss bool
+}
+
+// Lookup is Get with details about the match. It counts, promotes and
+// emits events exactly like Get.
+func (c *Cache) Lookup(key string) Result {
+	r, _ := c.TryLookup(key)
+	return r
+}
+
+// TryLookup is Lookup that reports ErrBusy, rather than a miss, when the
+// Options.MaxConcurrentScans limit turns it away. Rejected lookups are
+// counted in Stats.Busy, not as misses, and emit no event.
+func (c *Cache) TryLookup(key string) (Result, error) {
+	return c.tryLookup(key, nil)
+}
+
+// LookupWhere is Lookup among the entries whose key match accepts; the
+// rest are skipped as if absent, so a closer entry match rejects cannot
+// hide one it accepts or stand in as the runner-up. match runs under the
+// cache lock, possibly from several scan workers at once, and must not
+// call back into the cache.
+func (c *Cache) LookupWhere(key string, match func(key string) bool) Result {
+	r, _ := c.tryLookup(key, match)
+	return r
+}
+
+func (c *Cache) tryLookup(key string, match func(string) bool) (Result, error) {
+	if c.closed.Load() {
+		return Result{}, ErrClosed
+	}
+	if c.limit != nil {
+		if !c.limit.acquire() {
+			c.stats.busy.Add(1)
+			return Result{}, ErrBusy
+		}
+		defer c.limit.release()
+	}
+	vec, ok := c.encode(key)
+	if !ok {
+		c.stats.misses.Add(1) // over the encode budget: answer from the backend
+		return Result{}, nil
+	}
+	return c.lookupVec(key, match, c.encodeTokens(key), c.lexical(key), c.queryVecs(key, vec)...), nil
+}
+
+// lookupVec finds the best entry for any of vecs — the key's vector and
+// those of its expansions — counting and reporting a single lookup; key
+// names the query in events and match, if set, limits the entries
+// considered. toks, if any, are the key's token vectors, scored with
+// vecs[0], and lex, if any, its words, scored with every vec.
+func (c *Cache) lookupVec(key string, match func(string) bool, toks []hdc.Vector, lex []uint32, vecs ...hdc.Vector) Result {
+	start := c.lat.start()
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
+	if !start.IsZero() {
+		c.lat.since(phaseLockWait, start)
+		start = time.Now()
+	}
+	threshold := c.thresholdLocked(key)
 
 	var bestElem *list.Element
 	var bestSim float64
-
-	if c.lsh != nil {
-		keys := c.lsh.hashVec(vec.RawData())
-		candidates := c.lsh.query(keys)
-		c.lshCandidates += uint64(len(candidates))
-
-		now := time.Now()
-		for _, elem := range candidates {
-			e := elem.Value.(*entry)
-			if c.isExpired(e, now) {
-				c.removeLocked(elem)
-				c.expired++
-				continue
-			}
-			if s := hdc.Similarity(vec, e.vec); s >= c.threshold && s > bestSim {
-				bestSim = s
-				bestElem = elem
-			}
+	var nearest nearMiss
+	var source string
+	var pruned, compared uint64
+	ruledOut := 0
+	for i, vec := range vecs {
+		q := c.newQueryLocked(vec, threshold)
+		q.lex, q.match = lex, match
+		if i == 0 {
+			q.tokens = toks
 		}
-
-		// Fallback to linear scan if LSH missed
-		if bestElem == nil && c.lshFallback {
-			c.lshFallbacks++
-			bestElem, bestSim = c.scanLocked(vec)
+		if c.missf != nil && !c.missf.plausible(&q) {
+			ruledOut++
+			continue
 		}
-	} else {
-		bestElem, bestSim = c.scanLocked(vec)
+		if elem, sim, src := c.bestLocked(&q, &nearest); elem != nil && sim > bestSim {
+			bestElem, bestSim, source = elem, sim, src
+		}
+		pruned += q.pruned
+		compared += q.compared
+	}
+	if c.faults != nil {
+		c.scanDelayLocked(key)
 	}
+	c.lat.since(phaseScan, start)
+	c.stats.pruned.Add(pruned)
+	c.stats.candidates.observe(pruned + compared)
+	c.stats.compared.observe(compared)
 
 	if bestElem == nil {
-		c.misses++
-		return nil, false, 0
+		c.stats.misses.Add(1)
+		if ruledOut == len(vecs) {
+			c.stats.definiteMisses.Add(1)
+		}
+		c.emitLocked(Event{Kind: EventMiss, Key: key, Match: nearest.key, Similarity: nearest.sim})
+		return c.softMissLocked(nearest.key, nearest.sim)
+	}
+
+	e := bestElem.Value.(*entry)
+	runnerUp := nearest.sim
+	if nearest.key == e.key {
+		runnerUp = nearest.second
+	}
+	if c.margin > 0 && bestSim-runnerUp < c.margin {
+		c.stats.misses.Add(1)
+		c.stats.ambiguous.Add(1)
+		c.emitLocked(Event{Kind: EventMiss, Key: key, Match: e.key, Similarity: bestSim})
+		return c.softMissLocked(e.key, bestSim)
 	}
 
-	c.lru.MoveToFront(bestElem)
-	c.hits++
-	c.simSum += bestSim
-	return bestElem.Value.(*entry).value, true, bestSim
+	if err := c.warmLocked(e); err != nil {
+		c.removeLocked(bestElem) // value lost; answer from the backend
+		c.stats.misses.Add(1)
+		c.emitLocked(Event{Kind: EventMiss, Key: key, Match: nearest.key, Similarity: nearest.sim})
+		return Result{}
+	}
+	c.touchLocked(bestElem)
+	c.coolLocked()
+	c.stats.hit(bestSim)
+	e.hits++
+	if e.key == key {
+		c.stats.exactHits.Add(1)
+	}
+	now := time.Now()
+	c.slideLocked(e, now)
+	c.emitLocked(Event{Kind: EventHit, Key: key, Match: e.key, Similarity: bestSim})
+	margin := max(bestSim-max(runnerUp, threshold), 0)
+	var expiresIn time.Duration
+	if !e.deadline.IsZero() {
+		expiresIn = max(e.deadline.Sub(now), 1)
+	}
+	return Result{
+		Value:      e.value,
+		Hit:        true,
+		Similarity: bestSim,
+		MatchedKey: e.key,
+		EntryAge:   now.Sub(e.ts),
+		ExpiresIn:  expiresIn,
+		Source:     source,
+		Margin:     margin,
+		Confidence: confidence(bestSim, margin, threshold),
+
+		EntrySource: e.source,
+	}
+}
+
+// bestLocked returns the best entry for q at or above the threshold, if
+// any, and how it was found, recording the closest entry overall in near
+// and the work done in q's tallies.
+func (c *Cache) bestLocked(q *query, near *nearMiss) (*list.Element, float64, string) {
+	if c.lsh == nil {
+		elem, sim := c.scanLocked(q, near)
+		return elem, sim, SourceScan
+	}
+
+	var bestElem *list.Element
+	var bestSim float64
+	c.queryKeys = c.lsh.hashVecInto(c.queryKeys, q.vec.RawData())
+	candidates := c.lsh.query(c.queryKeys, c.lshProbes)
+	c.stats.lshCandidates.Add(uint64(len(candidates)))
+
+	now := time.Now()
+	for _, elem := range candidates {
+		e := elem.Value.(*entry)
+		if c.isExpired(e, now) {
+			c.expireLocked(elem)
+			continue
+		}
+		if !c.accepts(e) || !q.matches(e) {
+			continue
+		}
+		if q.prunes(e) {
+			q.pruned++
+			continue
+		}
+		q.compared++
+		s := c.score(q, e)
+		near.observe(e.key, s)
+		if s >= q.threshold && s > bestSim {
+			bestSim = s
+			bestElem = elem
+			if c.goodEnough(s) {
+				break
+			}
+		}
+	}
+	if bestElem != nil || !c.lshFallback {
+		return bestElem, bestSim, SourceLSH
+	}
+	// Fallback to linear scan if LSH missed
+	c.stats.lshFallbacks.Add(1)
+	bestElem, bestSim = c.scanLocked(q, near)
+	return bestElem, bestSim, SourceScan
 }
 
 // Delete removes by exact key. Returns true if found.
@@ -239,57 +980,140 @@ func (c *Cache) Delete(key string) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	elem, ok := c.index[key]
+	elem, ok := c.index.Get(key)
 	if !ok {
 		return false
 	}
 	c.removeLocked(elem)
+	c.recordTombstoneLocked(key, time.Now())
 	return true
 }
 
-// Dims returns the vector dimensionality.
-func (c *Cache) Dims() int { return c.dims }
+// Pin exempts the entry stored under exactly key from LRU eviction and TTL
+// expiry until Unpin. Returns false if key is not cached. Pins are not saved
+// in snapshots. If every entry is pinned, new entries are still admitted and
+// the cache grows past capacity.
+func (c *Cache) Pin(key string) bool { return c.setPinned(key, true) }
 
-// Len returns the current number of cached entries.
-func (c *Cache) Len() int {
+// Unpin makes a pinned entry evictable again. Its TTL deadline, if any, is
+// unchanged, so an overdue entry is reaped on the next lookup.
+func (c *Cache) Unpin(key string) bool { return c.setPinned(key, false) }
+
+func (c *Cache) setPinned(key string, pinned bool) bool {
 	c.mu.Lock()
-	n := c.lru.Len()
-	c.mu.Unlock()
-	return n
+	defer c.mu.Unlock()
+	elem, ok := c.index.Get(key)
+	if ok {
+		elem.Value.(*entry).pinned = pinned
+	}
+	return ok
 }
 
-func (c *Cache) Stats() Stats {
+// DeleteFunc removes every entry whose key satisfies match and returns the
+// number removed, under one lock acquisition and with one pass over each
+// LSH bucket involved. match runs with the cache locked and must not call
+// back into it.
+func (c *Cache) DeleteFunc(match func(key string) bool) int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	var matched []*list.Element
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		if match(elem.Value.(*entry).key) {
+			matched = append(matched, elem)
+		}
+	}
+	if c.lsh != nil {
+		c.lsh.removeAll(matched) // one pass per touched bucket
+	}
+	now := time.Now()
+	for _, elem := range matched {
+		key := elem.Value.(*entry).key
+		c.dropLocked(elem)
+		c.recordTombstoneLocked(key, now)
+	}
+	return len(matched)
+}
 
-	total := c.hits + c.misses
-	hitRate := 0.0
-	if total > 0 {
-		hitRate = float64(c.hits) / float64(total)
+// SetThreshold changes the hit threshold for subsequent lookups. The LSH
+// parameters chosen at construction are kept.
+func (c *Cache) SetThreshold(t float64) {
+	if t <= 0 || t > 1 {
+		panic("cache: threshold must be in (0, 1]")
 	}
-	avgSim := 0.0
-	if c.hits > 0 {
-		avgSim = c.simSum / float64(c.hits)
+	c.mu.Lock()
+	c.threshold = t
+	c.mu.Unlock()
+}
+
+// SetCapacity changes the entry limit, evicting LRU entries if the cache is
+// now over it.
+func (c *Cache) SetCapacity(n int) {
+	if n <= 0 {
+		panic("cache: capacity must be positive")
+	}
+	c.mu.Lock()
+	defer c.unlock()
+	c.capacity = n
+	if c.pool != nil {
+		c.setPoolCapacityLocked(n)
+		c.trimGroupsLocked()
+		return
 	}
+	if over := c.lru.Len() - c.capacity; over > 0 {
+		c.evictLocked(over)
+	}
+}
 
-	return Stats{
-		Entries:       c.lru.Len(),
-		Hits:          c.hits,
-		Misses:        c.misses,
-		Sets:          c.sets,
-		Expired:       c.expired,
-		HitRate:       hitRate,
-		AvgSimOnHit:   avgSim,
-		LSHCandidates: c.lshCandidates,
-		LSHFallbacks:  c.lshFallbacks,
+// SetTTL changes the default TTL used by Set. Existing entries keep their
+// deadlines.
+func (c *Cache) SetTTL(d time.Duration) {
+	if d < 0 {
+		panic("cache: TTL must not be negative")
 	}
+	c.mu.Lock()
+	c.ttl = d
+	c.mu.Unlock()
 }
 
-// scanLocked — linear scan, returns best match above threshold.
-// Expired entries lazily removed during scan (background goroutine nahi chahiye).
-func (c *Cache) scanLocked(vec hdc.Vector) (*list.Element, float64) {
+func (c *Cache) Threshold() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.threshold
+}
+
+func (c *Cache) Capacity() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.capacity
+}
+
+func (c *Cache) TTL() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttl
+}
+
+// Dims returns the vector dimensionality.
+func (c *Cache) Dims() int { return c.dims }
+
+// Len returns the current number of cached entries.
+func (c *Cache) Len() int { return int(c.stats.entries.Load()) }
+
+// scanLocked — linear scan, returns best match above threshold and records
+// the closest entry overall in near. Expired entries lazily removed during
+// scan (background goroutine nahi chahiye).
+func (c *Cache) scanLocked(q *query, near *nearMiss) (*list.Element, float64) {
+	if c.sliced == nil && c.earlyExit == 0 && c.parallelMin > 0 && c.scanWorkers > 1 && c.lru.Len() >= c.parallelMin {
+		return c.parallelScanLocked(q, near)
+	}
 	var bestElem *list.Element
 	var bestSim float64
+	var dist []int              // by lane, if bit-sliced
+	var expired []*list.Element // reaped after the scan: removal moves lanes
+	if c.sliced != nil {
+		c.distBuf = c.sliced.distances(q.vec, c.distBuf)
+		dist = c.distBuf
+	}
 
 	now := time.Now()
 	for elem := c.lru.Front(); elem != nil; {
@@ -297,36 +1121,145 @@ func (c *Cache) scanLocked(vec hdc.Vector) (*list.Element, float64) {
 		next := elem.Next()
 
 		if c.isExpired(e, now) {
-			c.removeLocked(elem)
-			c.expired++
+			if dist != nil {
+				expired = append(expired, elem)
+			} else {
+				c.expireLocked(elem)
+			}
+			elem = next
+			continue
+		}
+		if !c.accepts(e) || !q.matches(e) {
+			elem = next
+			continue
+		}
+		if q.prunes(e) {
+			q.pruned++
 			elem = next
 			continue
 		}
 
-		if s := hdc.Similarity(vec, e.vec); s >= c.threshold && s > bestSim {
+		q.compared++
+		var s float64
+		if dist != nil {
+			s = c.decay(q, e, c.fuse(q, e, 1-float64(dist[e.lane-1])/float64(c.dims))) // as score
+		} else {
+			s = c.score(q, e)
+		}
+		near.observe(e.key, s)
+		if s >= q.threshold && s > bestSim {
 			bestSim = s
 			bestElem = elem
+			if c.goodEnough(s) {
+				break
+			}
 		}
 		elem = next
 	}
+	for _, elem := range expired {
+		c.expireLocked(elem)
+	}
 	return bestElem, bestSim
 }
 
 func (c *Cache) isExpired(e *entry, now time.Time) bool {
-	return !e.deadline.IsZero() && now.After(e.deadline)
+	return !e.pinned && !e.deadline.IsZero() && now.After(e.deadline)
+}
+
+// evictLocked removes up to n least recently used unpinned entries in one
+// walk from the back of the list, skipping pinned ones, and returns how many
+// it removed. Evict events are delivered together once the lock is released.
+// With sampled eviction each victim comes from sampleVictimLocked instead.
+func (c *Cache) evictLocked(n int) int {
+	removed := 0
+	if c.samples > 0 {
+		for ; removed < n; removed++ {
+			elem := c.sampleVictimLocked()
+			if elem == nil {
+				break
+			}
+			c.emitLocked(Event{Kind: EventEvict, Key: elem.Value.(*entry).key})
+			c.stats.evictions.Add(1)
+			c.removeLocked(elem)
+		}
+		return removed
+	}
+	for elem := c.lru.Back(); elem != nil && removed < n; {
+		prev := elem.Prev()
+		if e := elem.Value.(*entry); !e.pinned {
+			c.emitLocked(Event{Kind: EventEvict, Key: e.key})
+			c.stats.evictions.Add(1)
+			c.removeLocked(elem)
+			removed++
+		}
+		elem = prev
+	}
+	return removed
+}
+
+func (c *Cache) expireLocked(elem *list.Element) {
+	c.emitLocked(Event{Kind: EventExpire, Key: elem.Value.(*entry).key})
+	c.removeLocked(elem)
+	c.stats.expired.Add(1)
+}
+
+// nearMiss tracks the closest entry seen during a lookup, for miss events,
+// and the runner-up, for Result.Margin. An entry seen twice — once per
+// query vector, or by LSH and then the fallback scan — counts once.
+type nearMiss struct {
+	key       string
+	sim       float64
+	secondKey string
+	second    float64
 }
 
-func (c *Cache) evictLocked() {
-	if back := c.lru.Back(); back != nil {
-		c.removeLocked(back)
+func (n *nearMiss) observe(key string, sim float64) {
+	switch {
+	case key == n.key:
+		n.sim = max(n.sim, sim)
+	case sim > n.sim:
+		n.secondKey, n.second = n.key, n.sim
+		n.key, n.sim = key, sim
+	case key == n.secondKey:
+		n.second = max(n.second, sim)
+	case sim > n.second:
+		n.secondKey, n.second = key, sim
 	}
 }
 
+// merge folds in another scan's observations.
+func (n *nearMiss) merge(o nearMiss) {
+	n.observe(o.key, o.sim)
+	n.observe(o.secondKey, o.second)
+}
+
 func (c *Cache) removeLocked(elem *list.Element) {
 	e := elem.Value.(*entry)
 	if c.lsh != nil && e.lshKeys != nil {
 		c.lsh.remove(elem, e.lshKeys)
 	}
-	delete(c.index, e.key)
+	c.dropLocked(elem)
+}
+
+// dropLocked is removeLocked for an element already out of the LSH tables.
+func (c *Cache) dropLocked(elem *list.Element) {
+	e := elem.Value.(*entry)
+	c.index.Delete(e.key)
+	c.releaseKeyLocked(e.key)
+	c.untrackLocked(e)
+	e.seq = 0 // drops out of Scan even if not recycled
+	c.forgetLocked(e)
+	c.unedgeLocked(elem)
 	c.lru.Remove(elem)
+	if e.gelem != nil {
+		e.group.lru.Remove(e.gelem)
+	}
+	c.stats.entries.Add(-1)
+	if c.missf != nil {
+		c.missf.add(e.pc, -1)
+	}
+	c.releaseVecLocked(e)
+	c.releaseValueLocked(e)
+	c.unsliceLocked(e)
+	c.releaseLocked(e)
 }
@@ -1,6 +1,7 @@
 package cache_test
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -407,6 +408,63 @@ func TestNew_InvalidThresholdAboveOne_Panics(t *testing.T) {
 	cache.New(enc, cache.Options{Threshold: 1.1, Capacity: 16})
 }
 
+func TestTryNew_InvalidOptions(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	for _, opts := range []cache.Options{
+		{Threshold: 0.8, Capacity: 0},
+		{Threshold: 0.8, Capacity: 16, ScoreFusion: 1},
+		{Threshold: 0.8, Capacity: 16, LSHK: 65},
+		{Threshold: 0.8, Capacity: 16, GroupOf: func(string) string { return "" }, Groups: map[string]cache.Group{"a": {Capacity: 16}}},
+	} {
+		if c, err := cache.TryNew(enc, opts); c != nil || !errors.Is(err, cache.ErrInvalidOption) {
+			t.Fatalf("%+v: got %v, %v", opts, c, err)
+		}
+	}
+	if _, err := cache.TryNew(enc, cache.Options{Threshold: 0.8, Capacity: 16}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ── Lookup ────────────────────────────────────────────────────────────────────
+
+func TestCache_Lookup(t *testing.T) {
+	c := newCache(0.65, 100)
+	c.Set("what is the capital of india", "Delhi")
+	time.Sleep(5 * time.Millisecond)
+
+	r := c.Lookup("capital city of india")
+	if !r.Hit || r.Value != "Delhi" {
+		t.Fatalf("want hit Delhi, got %+v", r)
+	}
+	if r.MatchedKey != "what is the capital of india" {
+		t.Fatalf("MatchedKey = %q", r.MatchedKey)
+	}
+	if r.Similarity < 0.65 || r.Similarity >= 1 {
+		t.Fatalf("similarity %.4f out of range", r.Similarity)
+	}
+	if r.EntryAge < 5*time.Millisecond {
+		t.Fatalf("EntryAge %v must cover the time since Set", r.EntryAge)
+	}
+	if r.Source != cache.SourceScan {
+		t.Fatalf("linear cache must report scan, got %q", r.Source)
+	}
+
+	if r := c.Lookup("completely unrelated text about gardening"); r != (cache.Result{}) {
+		t.Fatalf("miss must return the zero Result, got %+v", r)
+	}
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 1 {
+		t.Fatalf("Lookup must count like Get: %+v", s)
+	}
+}
+
+func TestCache_Lookup_Source(t *testing.T) {
+	c := newLSHCache(0.82, 512)
+	c.Set("hello world", 1)
+	if r := c.Lookup("hello world"); r.Source != cache.SourceLSH {
+		t.Fatalf("exact key must be an LSH candidate, got %q", r.Source)
+	}
+}
+
 // ── LSH integration ──────────────────────────────────────────────────────────
 
 func boolPtr(v bool) *bool { return &v }
@@ -794,3 +852,443 @@ func TestCache_TTL_LRU_EvictsBeforeExpiry(t *testing.T) {
 		t.Fatal("alpha should have been evicted by LRU")
 	}
 }
+
+func TestCache_TTLJitter(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	c := cache.New(enc, cache.Options{Threshold: 0.9, Capacity: 64, TTL: time.Hour, TTLJitter: 0.5})
+	start := time.Now()
+	for i := 0; i < 50; i++ {
+		c.Set(fmt.Sprintf("entry %d", i), i)
+	}
+
+	distinct := make(map[time.Time]bool)
+	for _, e := range c.Snapshot().Entries {
+		ttl := e.Deadline.Sub(start)
+		if ttl < 30*time.Minute || ttl > 91*time.Minute {
+			t.Fatalf("jittered TTL %v outside ±50%% of 1h", ttl)
+		}
+		distinct[e.Deadline] = true
+	}
+	if len(distinct) < 40 {
+		t.Fatalf("jitter must spread deadlines, got %d distinct of 50", len(distinct))
+	}
+}
+
+func TestCache_TTLJitter_Invalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("TTLJitter >= 1 must panic")
+		}
+	}()
+	cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.9, Capacity: 8, TTLJitter: 1})
+}
+
+func TestCache_SlidingTTL(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	c := cache.New(enc, cache.Options{Threshold: 0.9, Capacity: 8, TTL: 100 * time.Millisecond, SlidingTTL: true})
+	c.Set("sliding", 1)
+	c.Set("fixed", 2) // never hit, so it expires on schedule
+
+	time.Sleep(60 * time.Millisecond)
+	if _, ok, _ := c.Get("sliding"); !ok {
+		t.Fatal("entry must be live before its TTL")
+	}
+	time.Sleep(60 * time.Millisecond) // 120ms since Set, 60ms since the hit
+	if _, ok, _ := c.Get("sliding"); !ok {
+		t.Fatal("hit must have restarted the TTL")
+	}
+	if _, ok, _ := c.Get("fixed"); ok {
+		t.Fatal("an entry that was not hit must still expire")
+	}
+}
+
+// ── events ────────────────────────────────────────────────────────────────────
+
+func TestCache_OnEvent(t *testing.T) {
+	var c *cache.Cache
+	var got []cache.Event
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	c = cache.New(enc, cache.Options{Threshold: 0.70, Capacity: 2, OnEvent: func(ev cache.Event) {
+		c.Len() // hooks run outside the lock
+		got = append(got, ev)
+	}})
+
+	c.Set("what is the capital of india", "Delhi")
+	c.Set("how to bake a cake", "oven")
+	c.Get("capital city of india")
+	c.Get("what is the capital of indiana state")
+	c.Set("who wrote ramayana", "Valmiki") // evicts the cake entry
+
+	kinds := make([]cache.EventKind, len(got))
+	for i, ev := range got {
+		kinds[i] = ev.Kind
+	}
+	want := []cache.EventKind{cache.EventSet, cache.EventSet, cache.EventHit, cache.EventHit, cache.EventSet, cache.EventEvict}
+	if fmt.Sprint(kinds) != fmt.Sprint(want) {
+		t.Fatalf("want %v, got %v", want, kinds)
+	}
+	if hit := got[2]; hit.Match != "what is the capital of india" || hit.Similarity < 0.70 {
+		t.Fatalf("hit event must carry match and similarity, got %+v", hit)
+	}
+	if ev := got[5]; ev.Key != "how to bake a cake" {
+		t.Fatalf("evict event for wrong key: %+v", ev)
+	}
+
+	got = nil
+	c.Get("capital of nepal")
+	if len(got) != 1 || got[0].Kind != cache.EventMiss {
+		t.Fatalf("want one miss event, got %+v", got)
+	}
+	if m := got[0]; m.Match == "" || m.Similarity <= 0 || m.Similarity >= 0.70 {
+		t.Fatalf("miss event must report the nearest below-threshold entry, got %+v", m)
+	}
+}
+
+func TestCache_OnEvent_Expire(t *testing.T) {
+	var got []cache.Event
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	c := cache.New(enc, cache.Options{Threshold: 0.82, Capacity: 16, TTL: time.Millisecond,
+		OnEvent: func(ev cache.Event) { got = append(got, ev) }})
+
+	c.Set("a", 1)
+	time.Sleep(5 * time.Millisecond)
+	c.Get("a")
+
+	if len(got) != 3 || got[1].Kind != cache.EventExpire || got[1].Key != "a" || got[2].Kind != cache.EventMiss {
+		t.Fatalf("want set, expire, miss; got %+v", got)
+	}
+}
+
+// ── runtime tuning ────────────────────────────────────────────────────────────
+
+func TestCache_Pin_SurvivesEvictionAndTTL(t *testing.T) {
+	c := newCacheWithTTL(0.99, 2, time.Millisecond)
+	c.Set("pinned", 1)
+	if !c.Pin("pinned") {
+		t.Fatal("Pin must find existing key")
+	}
+	if c.Pin("missing") {
+		t.Fatal("Pin must report missing key")
+	}
+
+	c.Set("b", 2)
+	c.Set("c", 3) // evicts b, the oldest unpinned entry
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok, _ := c.Get("pinned"); !ok {
+		t.Fatal("pinned entry must survive eviction and expiry")
+	}
+	if s := c.Stats(); s.Entries != 1 || s.Expired != 1 {
+		t.Fatalf("want only pinned left with c expired, got %+v", s)
+	}
+
+	c.Unpin("pinned")
+	if _, ok, _ := c.Get("pinned"); ok {
+		t.Fatal("unpinned overdue entry must expire")
+	}
+}
+
+func TestCache_Pin_AllPinnedGrowsPastCapacity(t *testing.T) {
+	c := newCache(0.99, 1)
+	c.Set("a", 1)
+	c.Pin("a")
+	c.Set("b", 2)
+	if c.Len() != 2 {
+		t.Fatalf("want 2 entries when all are pinned, got %d", c.Len())
+	}
+}
+
+func TestCache_SetCapacity_Shrinks(t *testing.T) {
+	c := newCache(0.99, 8)
+	for i := 0; i < 8; i++ {
+		c.Set(fmt.Sprintf("key-%d", i), i)
+	}
+	c.SetCapacity(3)
+	if c.Len() != 3 || c.Capacity() != 3 {
+		t.Fatalf("want 3 entries after shrink, got len=%d cap=%d", c.Len(), c.Capacity())
+	}
+	if _, ok, _ := c.Get("key-7"); !ok {
+		t.Fatal("shrink must keep most recent entries")
+	}
+}
+
+func TestCache_SetThreshold_AppliesToLookups(t *testing.T) {
+	c := newCache(0.99, 16)
+	c.Set("what is the capital of india", "Delhi")
+	if _, ok, _ := c.Get("capital city of india"); ok {
+		t.Fatal("must miss at 0.99")
+	}
+	c.SetThreshold(0.70)
+	if _, ok, _ := c.Get("capital city of india"); !ok {
+		t.Fatal("must hit after lowering threshold")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic for threshold=0")
+		}
+	}()
+	c.SetThreshold(0)
+}
+
+func TestCache_SetTTL_AppliesToLaterSets(t *testing.T) {
+	c := newCache(0.99, 16)
+	c.Set("old", 1)
+	c.SetTTL(time.Millisecond)
+	c.Set("new", 2)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, _ := c.Get("old"); !ok {
+		t.Fatal("existing entry must keep its deadline")
+	}
+	if _, ok, _ := c.Get("new"); ok {
+		t.Fatal("later Set must use the new TTL")
+	}
+}
+
+func TestCache_DeleteFunc(t *testing.T) {
+	c := newCache(0.99, 16)
+	c.Set("tenant-a:x", 1)
+	c.Set("tenant-a:y", 2)
+	c.Set("tenant-b:x", 3)
+	if n := c.DeleteFunc(func(k string) bool { return k[:8] == "tenant-a" }); n != 2 {
+		t.Fatalf("want 2 removed, got %d", n)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("want 1 left, got %d", c.Len())
+	}
+}
+
+// ── custom similarity ─────────────────────────────────────────────────────────
+
+func TestCache_CustomSimilarity(t *testing.T) {
+	calls := 0
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	c := cache.New(enc, cache.Options{
+		Threshold: 0.8,
+		Capacity:  16,
+		Similarity: func(a, b hdc.Vector) float64 {
+			calls++
+			return 0.9
+		},
+	})
+	c.Set("what is the capital of india", "Delhi")
+
+	// Under hdc.Similarity this is a clear miss; the custom metric says 0.9.
+	v, ok, sim := c.Get("how do you bake a chocolate cake")
+	if !ok || v != "Delhi" || sim != 0.9 {
+		t.Fatalf("custom metric must decide the hit: %v %v %f", v, ok, sim)
+	}
+	if calls == 0 {
+		t.Fatal("custom metric was not called")
+	}
+	if got := c.Explain("anything", 1); len(got) != 1 || got[0].Similarity != 0.9 {
+		t.Fatalf("Explain must use the custom metric, got %+v", got)
+	}
+	if s := c.Stats(); s.Pruned != 0 {
+		t.Fatalf("prefilter must be off for a custom metric, pruned %d", s.Pruned)
+	}
+}
+
+// ── concurrent scan limit ─────────────────────────────────────────────────────
+
+func TestCache_MaxConcurrentScans(t *testing.T) {
+	entered, unblock := make(chan struct{}), make(chan struct{})
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	c := cache.New(enc, cache.Options{
+		Threshold:          0.9,
+		Capacity:           8,
+		MaxConcurrentScans: 1,
+		OnEvent: func(ev cache.Event) {
+			if ev.Kind == cache.EventMiss && ev.Key == "blocker" {
+				close(entered)
+				<-unblock // events run inside the lookup, so this holds the slot
+			}
+		},
+	})
+	c.Set("hello world", 1)
+
+	done := make(chan struct{})
+	go func() { c.Get("blocker"); close(done) }()
+	<-entered
+
+	if _, err := c.TryLookup("hello world"); err != cache.ErrBusy {
+		t.Fatalf("want ErrBusy while the only slot is held, got %v", err)
+	}
+	if _, ok, _ := c.Get("hello world"); ok {
+		t.Fatal("Get must degrade to a miss when turned away")
+	}
+	close(unblock)
+	<-done
+
+	if r, err := c.TryLookup("hello world"); err != nil || !r.Hit {
+		t.Fatalf("after release: hit=%v err=%v", r.Hit, err)
+	}
+	if s := c.Stats(); s.Busy != 2 || s.Misses != 1 || s.Hits != 1 {
+		t.Fatalf("rejections must be counted as busy, not misses: %+v", s)
+	}
+}
+
+// ── batched eviction ──────────────────────────────────────────────────────────
+
+func TestCache_SetMany_BatchedEviction(t *testing.T) {
+	var evicted []string
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	c := cache.New(enc, cache.Options{
+		Threshold: 0.99,
+		Capacity:  3,
+		OnEvent: func(ev cache.Event) {
+			if ev.Kind == cache.EventEvict {
+				evicted = append(evicted, ev.Key)
+			}
+		},
+	})
+	c.Set("old entry", 0)
+
+	items := []cache.Item{{"alpha", 1}, {"bravo", 2}, {"charlie", 3}, {"delta", 4}}
+	c.SetMany(items)
+
+	if c.Len() != 3 {
+		t.Fatalf("want 3 entries, got %d", c.Len())
+	}
+	// Same end state as Setting one by one: the three newest survive.
+	for _, k := range []string{"bravo", "charlie", "delta"} {
+		if _, ok, _ := c.Get(k); !ok {
+			t.Fatalf("%q must survive", k)
+		}
+	}
+	if fmt.Sprint(evicted) != "[old entry alpha]" {
+		t.Fatalf("want LRU-first evictions [old entry alpha], got %v", evicted)
+	}
+	if s := c.Stats(); s.Sets != 5 {
+		t.Fatalf("SetMany must count every item as a set, got %d", s.Sets)
+	}
+}
+
+func TestCache_SetCapacity_SkipsPinnedInOnePass(t *testing.T) {
+	c := newCache(0.99, 8)
+	for _, k := range []string{"a1", "b2", "c3", "d4", "e5", "f6"} {
+		c.Set(k, k)
+	}
+	c.Pin("a1") // least recently used, but pinned
+	c.Pin("c3")
+
+	c.SetCapacity(3)
+	if c.Len() != 3 {
+		t.Fatalf("want 3 entries after shrink, got %d", c.Len())
+	}
+	for _, k := range []string{"a1", "c3", "f6"} {
+		if _, ok, _ := c.Get(k); !ok {
+			t.Fatalf("%q must survive the shrink", k)
+		}
+	}
+}
+
+// ── sampled eviction ──────────────────────────────────────────────────────────
+
+func newSampledCache(capacity, samples int) *cache.Cache {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	return cache.New(enc, cache.Options{Threshold: 0.99, Capacity: capacity, EvictionSamples: samples})
+}
+
+func TestCache_SampledEviction_KeepsHotEntries(t *testing.T) {
+	c := newSampledCache(100, 5)
+	for i := 0; i < 100; i++ {
+		c.Set(fmt.Sprintf("cold entry number %d", i), i)
+	}
+	for i := 0; i < 20; i++ {
+		c.Get(fmt.Sprintf("cold entry number %d", i)) // now hot
+	}
+	for i := 0; i < 40; i++ {
+		c.Set(fmt.Sprintf("fresh entry number %d", i), i)
+	}
+
+	if c.Len() != 100 {
+		t.Fatalf("capacity must hold, got %d entries", c.Len())
+	}
+	hot := 0
+	for _, ks := range c.Explain("", 0) {
+		var i int
+		if _, err := fmt.Sscanf(ks.Key, "cold entry number %d", &i); err == nil && i < 20 {
+			hot++
+		}
+	}
+	// 40 of 80 cold entries go; a hot one only when all 5 samples miss them.
+	if hot < 16 {
+		t.Fatalf("sampled LRU must mostly spare recently hit entries, %d/20 left", hot)
+	}
+}
+
+func TestCache_SampledEviction_Pinned(t *testing.T) {
+	c := newSampledCache(3, 1)
+	c.Set("alpha", 1)
+	c.Set("bravo", 2)
+	c.Set("charlie", 3)
+	c.Pin("alpha")
+	c.Pin("charlie")
+
+	c.Set("delta", 4)
+	if c.Len() != 3 {
+		t.Fatalf("want 3 entries, got %d", c.Len())
+	}
+	if c.Delete("bravo") {
+		t.Fatal("the only unpinned entry must be evicted even if no sample finds it")
+	}
+	for _, k := range []string{"alpha", "charlie", "delta"} {
+		if !c.Delete(k) {
+			t.Fatalf("%q must survive", k)
+		}
+	}
+}
+
+// ── margin ───────────────────────────────────────────────────────────────────
+
+func TestCache_Margin(t *testing.T) {
+	templated := []string{"weather in paris today", "weather in perth today", "weather in parma today"}
+	for _, lsh := range []bool{false, true} {
+		c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+			Threshold: 0.7, Capacity: 16, LSHEnabled: &lsh, Margin: 0.05,
+		})
+		for _, k := range templated {
+			c.Set(k, k)
+		}
+		// Equally close to two entries: no clear winner.
+		if r := c.Lookup("weather in pa today"); r.Hit {
+			t.Errorf("lsh=%v: ambiguous lookup hit %q (margin %.3f)", lsh, r.MatchedKey, r.Margin)
+		}
+		if st := c.Stats(); st.Ambiguous != 1 || st.Misses != 1 {
+			t.Errorf("lsh=%v: ambiguous %d misses %d, want 1 and 1", lsh, st.Ambiguous, st.Misses)
+		}
+		if r := c.Lookup("weather in paris today"); !r.Hit || r.MatchedKey != "weather in paris today" {
+			t.Errorf("lsh=%v: exact lookup = %+v, want a hit", lsh, r)
+		}
+	}
+}
+
+func TestCache_Margin_BelowThresholdRunnerUp(t *testing.T) {
+	// The runner-up misses the threshold but is within the margin of the
+	// hit, so it must be scored despite the prefilter.
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.9, Capacity: 16})
+	c.Set("what is the capital of india", 1)
+	c.Set("what is the capital of indonesia", 2)
+	a, _ := c.Similarity("what is the capital of indi", "what is the capital of india")
+	b, _ := c.Similarity("what is the capital of indi", "what is the capital of indonesia")
+	if !(a >= 0.9 && b < 0.9) {
+		t.Fatalf("fixture similarities %.3f, %.3f no longer straddle the threshold", a, b)
+	}
+
+	m := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.9, Capacity: 16, Margin: a - b + 0.01})
+	m.Set("what is the capital of india", 1)
+	m.Set("what is the capital of indonesia", 2)
+	if m.Lookup("what is the capital of indi").Hit {
+		t.Error("hit despite a runner-up within the margin")
+	}
+}
+
+func TestCache_Margin_Invalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for Margin 1")
+		}
+	}()
+	cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.8, Capacity: 16, Margin: 1})
+}
@@ -0,0 +1,15 @@
+package cache
+
+import "errors"
+
+// ErrClosed is returned by TryLookup and LoadSnapshot after Close.
+var ErrClosed = errors.New("cache: closed")
+
+// Close makes the cache refuse further traffic: sets are dropped, lookups
+// miss (TryLookup returns ErrClosed) and LoadSnapshot fails. Snapshot,
+// Delete and the other maintenance calls keep working, so the contents can
+// still be saved. The encoder is left open. Close is idempotent.
+func (c *Cache) Close() { c.closed.Store(true) }
+
+// Closed reports whether Close has been called.
+func (c *Cache) Closed() bool { return c.closed.Load() }
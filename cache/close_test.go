@@ -0,0 +1,36 @@
+package cache_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestClose(t *testing.T) {
+	c := newCache(0.75, 16)
+	c.Set("what is the capital of india", "Delhi")
+	c.Close()
+	c.Close()
+	if !c.Closed() {
+		t.Fatal("Closed must report true")
+	}
+
+	c.Set("who wrote ramayana", "Valmiki")
+	if c.Len() != 1 {
+		t.Fatalf("Set after Close must be dropped, len %d", c.Len())
+	}
+	if _, ok, _ := c.Get("what is the capital of india"); ok {
+		t.Fatal("Get after Close must miss")
+	}
+	if _, err := c.TryLookup("what is the capital of india"); !errors.Is(err, cache.ErrClosed) {
+		t.Fatalf("TryLookup: %v", err)
+	}
+	snap := c.Snapshot()
+	if len(snap.Entries) != 1 {
+		t.Fatalf("Snapshot after Close: %d entries", len(snap.Entries))
+	}
+	if err := c.LoadSnapshot(snap); !errors.Is(err, cache.ErrClosed) {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+}
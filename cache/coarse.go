@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// The coarse filter (Options.CoarseBits) keeps a low-resolution copy of
+// every vector — evenly spaced words of it, so its Hamming distance to the
+// query's copy estimates the full distance scaled down — and skips the
+// full comparison for entries whose coarse distance is too far above what
+// a hit would show. The cut-off sits coarseSigmas standard deviations of
+// sampling noise above the threshold's expected coarse distance, so a
+// true hit is rejected with probability around 3·10⁻⁵; like LSH, it trades
+// that for comparing a fraction of the words of most entries.
+
+const coarseSigmas = 4
+
+// coarseInto copies every stride-th word of vec into dst, reusing it.
+func coarseInto(dst []uint64, vec hdc.Vector, words int) []uint64 {
+	raw := vec.RawData()
+	dst = dst[:0]
+	for j := 0; j < words; j++ {
+		dst = append(dst, raw[j*len(raw)/words])
+	}
+	return dst
+}
+
+// coarseLimit returns the largest coarse distance, over words words, of
+// an entry that may reach similarity minSim.
+func coarseLimit(minSim float64, words int) int {
+	m := float64(words * 64)
+	p := min(max(1-minSim, 0), 1)
+	return int(math.Ceil(p*m + coarseSigmas*math.Sqrt(m*p*(1-p))))
+}
+
+// coarseFar reports whether e's coarse copy is beyond q's limit.
+func (q *query) coarseFar(e *entry) bool {
+	d := 0
+	for i, w := range q.coarse {
+		d += bits.OnesCount64(w ^ e.coarse[i])
+	}
+	return d > q.maxCoarse
+}
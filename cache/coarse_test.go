@@ -0,0 +1,50 @@
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestCache_CoarseBits(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	opts := cache.Options{Threshold: 0.8, Capacity: 500, LSHEnabled: new(bool)}
+	plain := cache.New(enc, opts)
+	opts.CoarseBits = 1024
+	coarse := cache.New(enc, opts)
+	for _, c := range []*cache.Cache{plain, coarse} {
+		for i := 0; i < 500; i++ {
+			c.Set(coarseKey(i), i)
+		}
+	}
+	for i := 0; i < 500; i += 25 {
+		key := coarseKey(i) + "!"
+		r1, r2 := plain.Lookup(key), coarse.Lookup(key)
+		if r1.Hit != r2.Hit || r1.MatchedKey != r2.MatchedKey || r1.Similarity != r2.Similarity {
+			t.Errorf("%q: plain %v %q %.3f, coarse %v %q %.3f", key, r1.Hit, r1.MatchedKey, r1.Similarity, r2.Hit, r2.MatchedKey, r2.Similarity)
+		}
+	}
+	if got, all := coarse.Stats().PerLookup.Compared.Mean, plain.Stats().PerLookup.Compared.Mean; got*2 > all {
+		t.Errorf("compared %.0f entries per lookup with the coarse filter, %.0f without", got, all)
+	}
+}
+
+// coarseKey returns unrelated keys for different i.
+func coarseKey(i int) string {
+	return fmt.Sprintf("%x %x %x", uint32(i)*2654435761, uint32(i)*40503, uint32(i)*97)
+}
+
+func TestNew_CoarseBits_Panics(t *testing.T) {
+	for _, n := range []int{-64, 100, 10048} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("CoarseBits %d: expected panic", n)
+				}
+			}()
+			cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.8, Capacity: 1, CoarseBits: n})
+		}()
+	}
+}
@@ -0,0 +1,97 @@
+package cache
+
+import "container/list"
+
+// Cold tier (Options.HotEntries): only the HotEntries most recently used
+// entries keep their values on the heap. Further back in the LRU list each
+// value is handed to Options.ColdStore and the entry holds the reference it
+// returns instead; vectors stay in memory, so scans and LSH still see every
+// entry. A hit on a cold entry reads its value back (a fault) and makes it
+// hot again, which pushes the least recently used hot entry out.
+//
+// c.edge is the most recently used cold entry: entries in front of it are
+// hot and those behind it cold, so keeping the hot set at size costs one
+// demotion per insert or fault. If the store refuses a value the entry
+// stays hot, in front of the edge, and is tried again on the next insert.
+
+// ColdStore holds the values of cold entries; see Options.HotEntries. Its
+// methods are called with the cache lock held.
+type ColdStore interface {
+	// Put stores v and returns a reference to it.
+	Put(v any) (ref any, err error)
+
+	// Get returns the value behind ref. If it fails, a lookup that matched
+	// the entry drops it and misses, and snapshots and scans skip it.
+	Get(ref any) (any, error)
+
+	// Delete releases ref once its entry is removed, overwritten or hot
+	// again.
+	Delete(ref any)
+}
+
+// coolLocked demotes the least recently used hot entries until at most
+// c.hot are left.
+func (c *Cache) coolLocked() {
+	if c.coldStore == nil {
+		return
+	}
+	for c.lru.Len()-int(c.stats.cold.Load()) > c.hot {
+		elem := c.lru.Back()
+		if c.edge != nil {
+			elem = c.edge.Prev()
+		}
+		e := elem.Value.(*entry)
+		ref, err := c.coldStore.Put(e.value)
+		if err != nil {
+			return
+		}
+		c.edge = elem
+		c.releaseValueLocked(e)
+		e.value, e.cold = ref, true
+		c.stats.cold.Add(1)
+	}
+}
+
+// warmLocked reads a cold entry's value back onto the heap.
+func (c *Cache) warmLocked(e *entry) error {
+	if !e.cold {
+		return nil
+	}
+	v, err := c.coldStore.Get(e.value)
+	if err != nil {
+		return err
+	}
+	c.coldStore.Delete(e.value)
+	e.cold = false
+	c.setValueLocked(e, v)
+	c.stats.cold.Add(-1)
+	c.stats.faults.Add(1)
+	return nil
+}
+
+// valueLocked returns e's value, reading a cold one from the store without
+// making it hot.
+func (c *Cache) valueLocked(e *entry) (any, error) {
+	if !e.cold {
+		return e.value, nil
+	}
+	return c.coldStore.Get(e.value)
+}
+
+// forgetLocked releases a cold entry's reference before its value is
+// replaced or the entry removed.
+func (c *Cache) forgetLocked(e *entry) {
+	if !e.cold {
+		return
+	}
+	c.coldStore.Delete(e.value)
+	e.value, e.cold = nil, false
+	c.stats.cold.Add(-1)
+}
+
+// unedgeLocked moves the edge off elem before elem is moved or removed.
+func (c *Cache) unedgeLocked(elem *list.Element) {
+	if elem == c.edge {
+		c.edge = elem.Next()
+	}
+}
@@ -0,0 +1,134 @@
+package cache_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// memStore is a ColdStore in a map, counting live references.
+type memStore struct {
+	vals map[int]any
+	next int
+	fail bool
+}
+
+func newMemStore() *memStore { return &memStore{vals: make(map[int]any)} }
+
+func (m *memStore) Put(v any) (any, error) {
+	if m.fail {
+		return nil, errors.New("disk full")
+	}
+	m.next++
+	m.vals[m.next] = v
+	return m.next, nil
+}
+
+func (m *memStore) Get(ref any) (any, error) {
+	v, ok := m.vals[ref.(int)]
+	if !ok {
+		return nil, errors.New("no such value")
+	}
+	return v, nil
+}
+
+func (m *memStore) Delete(ref any) { delete(m.vals, ref.(int)) }
+
+func TestCache_HotEntries(t *testing.T) {
+	store := newMemStore()
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+		Threshold: 0.75, Capacity: 10, HotEntries: 3, ColdStore: store,
+	})
+	for i := 0; i < 8; i++ {
+		c.Set(fmt.Sprintf("key number %d", i), i)
+	}
+	if st := c.Stats(); st.Cold != 5 || len(store.vals) != 5 {
+		t.Fatalf("%d cold entries, %d stored values, want 5 and 5", st.Cold, len(store.vals))
+	}
+
+	// A hit on a cold entry faults it in and demotes the oldest hot one.
+	if v, ok, _ := c.Get("key number 0"); !ok || v != 0 {
+		t.Fatalf("cold entry: got %v, %v", v, ok)
+	}
+	if st := c.Stats(); st.Cold != 5 || st.Faults != 1 || len(store.vals) != 5 {
+		t.Errorf("after a fault: %d cold, %d faults, %d stored, want 5, 1, 5", st.Cold, st.Faults, len(store.vals))
+	}
+	if _, ok, _ := c.Get("key number 7"); !ok || c.Stats().Faults != 1 {
+		t.Error("hot entry hit must not fault")
+	}
+
+	// Snapshots and scans read cold values without faulting them in.
+	for _, es := range c.Snapshot().Entries {
+		if want := int(es.Key[len(es.Key)-1] - '0'); es.Value != want {
+			t.Errorf("snapshot %q = %v, want %d", es.Key, es.Value, want)
+		}
+	}
+	if page, _ := c.Scan(0, 100); len(page) != 8 || page[1].Value != 1 {
+		t.Errorf("scan: %d entries, second = %v", len(page), page[1].Value)
+	}
+	if c.Stats().Faults != 1 {
+		t.Error("snapshot or scan faulted values in")
+	}
+
+	// Overwrites, deletes and evictions release references.
+	c.Set("key number 1", "new")
+	c.Delete("key number 2")
+	for i := 8; i < 12; i++ {
+		c.Set(fmt.Sprintf("key number %d", i), i)
+	}
+	if st := c.Stats(); st.Entries-st.Cold != 3 || st.Cold != len(store.vals) {
+		t.Errorf("%d entries, %d cold, %d stored: want 3 hot and a reference per cold entry", st.Entries, st.Cold, len(store.vals))
+	}
+	if v, _, _ := c.Get("key number 1"); v != "new" {
+		t.Errorf("overwritten cold entry = %v", v)
+	}
+}
+
+func TestCache_HotEntries_StoreErrors(t *testing.T) {
+	store := newMemStore()
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+		Threshold: 0.75, Capacity: 10, HotEntries: 1, ColdStore: store,
+	})
+	c.Set("first key", 1)
+	store.fail = true
+	c.Set("second key", 2)
+	if c.Stats().Cold != 0 {
+		t.Fatal("a refused value must stay hot")
+	}
+	store.fail = false
+	c.Set("third key", 3)
+	if c.Stats().Cold != 2 {
+		t.Errorf("%d cold entries, want both older keys demoted once the store recovers", c.Stats().Cold)
+	}
+
+	// A value lost by the store drops its entry and misses.
+	clear(store.vals)
+	if _, ok, _ := c.Get("second key"); ok {
+		t.Error("hit on a lost value")
+	}
+	if st := c.Stats(); st.Entries != 2 || st.Cold != 1 {
+		t.Errorf("%d entries, %d cold, want the lost entry gone", st.Entries, st.Cold)
+	}
+}
+
+func TestCache_HotEntries_Validation(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	for name, opts := range map[string]cache.Options{
+		"negative": {HotEntries: -1},
+		"no store": {HotEntries: 2},
+		"sampled":  {HotEntries: 2, ColdStore: newMemStore(), EvictionSamples: 5},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			opts.Threshold, opts.Capacity = 0.75, 10
+			cache.New(enc, opts)
+		})
+	}
+}
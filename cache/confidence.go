@@ -0,0 +1,18 @@
+package cache
+
+// confidence scores a hit in [0, 1] from two things a bare similarity
+// hides: how far it clears the threshold, and how far it leads the
+// runner-up (Result.Margin). Each is measured as a fraction of the band
+// between the threshold and 1, and the score is their mean. A lone exact
+// match scores 1; a hit just over the threshold, or tied with another
+// entry, scores at most 0.5 — the "barely hit" answers worth a second
+// look.
+func confidence(sim, margin, threshold float64) float64 {
+	band := 1 - threshold
+	if band <= 0 {
+		return 1 // threshold 1: only exact matches hit
+	}
+	above := min((sim-threshold)/band, 1)
+	lead := min(margin/band, 1)
+	return (above + lead) / 2
+}
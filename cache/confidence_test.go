@@ -0,0 +1,56 @@
+package cache_test
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCache_Confidence_LoneExactHit(t *testing.T) {
+	c := newCache(0.75, 16)
+	c.Set("what is the capital of india", "Delhi")
+	r := c.Lookup("what is the capital of india")
+	if r.Margin != 0.25 || r.Confidence != 1 {
+		t.Errorf("margin %.3f confidence %.3f, want 0.25 and 1", r.Margin, r.Confidence)
+	}
+}
+
+func TestCache_Confidence_Ambiguous(t *testing.T) {
+	c := newCache(0.6, 16)
+	c.Set("what is the capital of india", "Delhi")
+	c.Set("what is the capital of indonesia", "Jakarta")
+	c.Set("how tall is mount everest", "8849m")
+
+	r := c.Lookup("what is the capital of india")
+	if !r.Hit || r.Similarity != 1 {
+		t.Fatalf("want an exact hit, got %+v", r)
+	}
+	second, _ := c.Similarity("what is the capital of india", "what is the capital of indonesia")
+	if want := 1 - second; math.Abs(r.Margin-want) > 1e-9 {
+		t.Errorf("margin %.4f, want %.4f (lead over the runner-up)", r.Margin, want)
+	}
+	if r.Confidence >= 1 || r.Confidence <= 0.5 {
+		t.Errorf("confidence %.3f, want in (0.5, 1) for an exact hit with a close runner-up", r.Confidence)
+	}
+
+	near := c.Lookup("what is the capital of indi")
+	if !near.Hit || near.Confidence >= r.Confidence {
+		t.Errorf("inexact hit confidence %.3f, want below the exact hit's %.3f", near.Confidence, r.Confidence)
+	}
+}
+
+func TestCache_Confidence_Tie(t *testing.T) {
+	c := newCache(0.75, 16)
+	c.Set("hello world", 1)
+	c.Set("HELLO WORLD", 2) // same vector: the encoder folds case
+	r := c.Lookup("hello world")
+	if r.Margin != 0 || r.Confidence != 0.5 {
+		t.Errorf("margin %.3f confidence %.3f, want 0 and 0.5 for a tie", r.Margin, r.Confidence)
+	}
+}
+
+func TestCache_Confidence_Miss(t *testing.T) {
+	c := newCache(0.75, 16)
+	if r := c.Lookup("anything"); r.Margin != 0 || r.Confidence != 0 {
+		t.Errorf("miss: %+v", r)
+	}
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"sort"
+	"time"
+)
+
+// Every entry gets a sequence number at insertion, and Cache.order lists
+// entries by it. A cursor is the last sequence number returned, so a scan
+// walks entries in insertion order no matter how lookups reorder the LRU
+// list, and each page resumes strictly after the previous one.
+//
+// Removed entries are left in order and skipped (their entry is recycled or
+// zeroed, so its seq no longer matches); order is compacted once dead refs
+// outnumber live ones.
+
+type seqRef struct {
+	seq uint64
+	e   *entry
+}
+
+// orderLocked appends a newly inserted entry to the scan order.
+func (c *Cache) orderLocked(e *entry) {
+	c.seq++
+	e.seq = c.seq
+	c.order = append(c.order, seqRef{seq: e.seq, e: e})
+	if dead := len(c.order) - c.lru.Len(); dead > 64 && dead > c.lru.Len() {
+		live := c.order[:0]
+		for _, r := range c.order {
+			if r.e.seq == r.seq {
+				live = append(live, r)
+			}
+		}
+		clear(c.order[len(live):])
+		c.order = live
+	}
+}
+
+// Scan returns up to count live entries inserted after cursor (0 starts a
+// scan) in insertion order, and the cursor for the next page, 0 when the
+// scan is complete. Every entry present for the whole scan is returned
+// exactly once; entries updated in place are not revisited, deleted ones
+// stop being returned, and ones added during the scan are returned at the
+// end. Each call takes the lock once, so a full scan never blocks lookups
+// for long. Read-only, like Explain: expired entries are skipped, not
+// reaped. count <= 0 means 100.
+func (c *Cache) Scan(cursor uint64, count int) ([]EntrySnapshot, uint64) {
+	if count <= 0 {
+		count = 100
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := sort.Search(len(c.order), func(i int) bool { return c.order[i].seq > cursor })
+	out := make([]EntrySnapshot, 0, min(count, len(c.order)-i))
+	now := time.Now()
+	for ; i < len(c.order) && len(out) < count; i++ {
+		r := c.order[i]
+		if r.e.seq != r.seq || c.isExpired(r.e, now) {
+			continue
+		}
+		value, err := c.valueLocked(r.e)
+		if err != nil {
+			continue
+		}
+		out = append(out, EntrySnapshot{
+			Key:      r.e.key,
+			VecData:  c.vecData(r.e),
+			Value:    value,
+			Ts:       r.e.ts,
+			Deadline: r.e.deadline,
+		})
+		cursor = r.seq
+	}
+	for ; i < len(c.order); i++ {
+		if c.order[i].e.seq == c.order[i].seq {
+			return out, cursor // more to come
+		}
+	}
+	return out, 0
+}
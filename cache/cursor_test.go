@@ -0,0 +1,98 @@
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestScan_Pages(t *testing.T) {
+	c := newCache(0.99, 100)
+	for i := 0; i < 25; i++ {
+		c.Set(fmt.Sprintf("key %d", i), i)
+	}
+	c.Get("key 3") // LRU reordering must not affect the scan
+
+	var keys []string
+	var cursor uint64
+	pages := 0
+	for {
+		page, next := c.Scan(cursor, 10)
+		pages++
+		for _, es := range page {
+			keys = append(keys, es.Key)
+		}
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+	if pages != 3 || len(keys) != 25 {
+		t.Fatalf("want 25 keys in 3 pages, got %d in %d", len(keys), pages)
+	}
+	for i, k := range keys {
+		if want := fmt.Sprintf("key %d", i); k != want {
+			t.Fatalf("keys[%d] = %q, want %q (insertion order)", i, k, want)
+		}
+	}
+}
+
+func TestScan_ConcurrentMutation(t *testing.T) {
+	c := newCache(0.99, 1000)
+	for i := 0; i < 20; i++ {
+		c.Set(fmt.Sprintf("key %d", i), i)
+	}
+
+	seen := make(map[string]int)
+	page, cursor := c.Scan(0, 5)
+	for _, es := range page {
+		seen[es.Key]++
+	}
+	c.Delete("key 10")         // not yet returned: must be skipped
+	c.Set("key 2", "updated")  // already returned: must not come back
+	c.Set("key 15", "updated") // updated in place: returned once
+	c.Set("new key", 0)        // added during the scan: returned at the end
+	for cursor != 0 {
+		page, cursor = c.Scan(cursor, 5)
+		for _, es := range page {
+			seen[es.Key]++
+		}
+	}
+
+	if len(seen) != 20 {
+		t.Fatalf("want 19 original keys plus the new one, got %d", len(seen))
+	}
+	for k, n := range seen {
+		if n != 1 {
+			t.Fatalf("%q returned %d times", k, n)
+		}
+	}
+	if seen["key 10"] != 0 {
+		t.Fatal("deleted key must not be returned")
+	}
+	if seen["new key"] != 1 {
+		t.Fatal("key added during the scan must be returned")
+	}
+}
+
+func TestScan_SurvivesCompaction(t *testing.T) {
+	c := newCache(0.99, 10)
+	for i := 0; i < 10; i++ {
+		c.Set(fmt.Sprintf("key %d", i), i)
+	}
+	_, cursor := c.Scan(0, 3)
+	// Churn enough evictions to compact the scan order.
+	for i := 10; i < 300; i++ {
+		c.Set(fmt.Sprintf("key %d", i), i)
+	}
+	n := 0
+	for cursor != 0 {
+		var page []cache.EntrySnapshot
+		page, cursor = c.Scan(cursor, 4)
+		n += len(page)
+	}
+	if n != 10 {
+		t.Fatalf("want the 10 live entries after the cursor, got %d", n)
+	}
+}
@@ -0,0 +1,32 @@
+package cache
+
+import "math"
+
+// Similarity decay (Options.DecayHalfLife) blends freshness into the hit
+// decision. A TTL serves an answer unchanged until the moment it expires;
+// decay instead lowers an entry's score smoothly with its age,
+//
+//	score = sim · 2^(−age/halfLife)
+//
+// so a day-old answer may still be served for a near-verbatim repeat of
+// its question while a loose paraphrase has to go to the backend. An entry
+// scoring s undecayed keeps hitting until log2(s/threshold) half-lives
+// after it was set; setting it again restarts its age. Age is measured from
+// the entry's set time, which snapshots keep, not from its last hit.
+//
+// Decay only lowers scores, so the popcount prefilter, the coarse filter
+// and the miss filter, which bound the undecayed similarity, stay sound;
+// they just prune less than they could. Pinned entries, exempt from TTL
+// expiry, do not decay either.
+
+// decay ages sim, q's score for e, by the time since e was set.
+func (c *Cache) decay(q *query, e *entry, sim float64) float64 {
+	if c.halfLife == 0 || e.pinned {
+		return sim
+	}
+	age := q.now.Sub(e.ts)
+	if age <= 0 {
+		return sim
+	}
+	return sim * math.Exp2(-float64(age)/float64(c.halfLife))
+}
@@ -0,0 +1,77 @@
+package cache_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// agedCache returns a cache holding key, set age ago by way of a snapshot.
+func agedCache(t *testing.T, opts cache.Options, key string, age time.Duration) *cache.Cache {
+	t.Helper()
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	src := cache.New(enc, cache.Options{Threshold: 0.5, Capacity: 8})
+	src.Set(key, "Delhi")
+	snap := src.Snapshot()
+	snap.Entries[0].Ts = time.Now().Add(-age)
+	c := cache.New(enc, opts)
+	if err := c.LoadSnapshot(snap); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestDecayHalfLife(t *testing.T) {
+	lsh, on := false, true
+	for name, opts := range map[string]cache.Options{
+		"scan":     {LSHEnabled: &lsh},
+		"parallel": {LSHEnabled: &lsh, ParallelScanMin: 1, ScanWorkers: 2},
+		"sliced":   {LSHEnabled: &lsh, BitSliced: true},
+		"lsh":      {LSHEnabled: &on},
+	} {
+		t.Run(name, func(t *testing.T) {
+			opts.Threshold, opts.Capacity, opts.DecayHalfLife = 0.65, 8, time.Hour
+			// 20 minutes is a third of a half-life: a factor of 2^(−1/3) ≈ 0.794.
+			c := agedCache(t, opts, "what is the capital of india", 20*time.Minute)
+
+			r := c.Lookup("what is the capital of india")
+			if !r.Hit || math.Abs(r.Similarity-0.794) > 0.001 {
+				t.Fatalf("exact repeat: %+v", r)
+			}
+			if r := c.Lookup("capital city of india"); r.Hit { // 0.716 undecayed
+				t.Fatalf("a paraphrase of an aged entry must miss: %+v", r)
+			}
+			if got := c.MaxSimilarity("what is the capital of india"); math.Abs(got-0.794) > 0.001 {
+				t.Fatalf("MaxSimilarity %v", got)
+			}
+
+			c.Pin("what is the capital of india")
+			if r := c.Lookup("capital city of india"); !r.Hit {
+				t.Fatalf("pinned entries must not decay: %+v", r)
+			}
+			c.Unpin("what is the capital of india")
+
+			c.Set("what is the capital of india", "New Delhi") // restarts its age
+			if r := c.Lookup("capital city of india"); !r.Hit || r.Value != "New Delhi" {
+				t.Fatalf("after a new Set: %+v", r)
+			}
+		})
+	}
+}
+
+func TestDecayHalfLife_Off(t *testing.T) {
+	c := agedCache(t, cache.Options{Threshold: 0.65, Capacity: 8}, "what is the capital of india", 1000*time.Hour)
+	if r := c.Lookup("capital city of india"); !r.Hit {
+		t.Fatalf("without decay age must not matter: %+v", r)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("a negative DecayHalfLife must panic")
+		}
+	}()
+	cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.8, Capacity: 8, DecayHalfLife: -time.Hour})
+}
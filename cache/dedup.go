@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"slices"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// sharedVec is one stored vector and the number of entries using it.
+type sharedVec struct {
+	vec  hdc.Vector
+	refs int
+}
+
+// setVecLocked assigns vec to e. With dedup on, an identical vector already
+// stored for another key is shared instead, so keys that normalize to the
+// same encoding cost one vector between them. Vectors are immutable once
+// stored, which is what makes sharing safe. Hash collisions between
+// different vectors are left unshared.
+func (c *Cache) setVecLocked(e *entry, vec hdc.Vector) {
+	c.releaseVecLocked(e)
+	if c.missf != nil {
+		if e.inlined || e.vec.Dims() != 0 {
+			c.missf.add(e.pc, -1) // replacing a stored vector
+		}
+		c.missf.add(popcount(vec), 1)
+	}
+	e.pc = popcount(vec)
+	if c.small {
+		e.vec, e.inline, e.inlined = hdc.Vector{}, [smallWords]uint64{}, true
+		copy(e.inline[:], vec.RawData())
+	} else {
+		e.vec = vec
+	}
+	e.tokens = nil // a new vector; Set attaches the key's tokens again
+	if c.fusion > 0 && e.lex == nil {
+		e.lex = wordHashes(e.key) // the key, set before the first vector, never changes
+	}
+	if c.coarseWords > 0 {
+		e.coarse = coarseInto(e.coarse, vec, c.coarseWords)
+	}
+	c.sliceLocked(e)
+	if c.vecs == nil {
+		return
+	}
+	h := hashWords(vec.RawData())
+	sv, ok := c.vecs[h]
+	switch {
+	case !ok:
+		c.vecs[h] = &sharedVec{vec: vec, refs: 1}
+	case slices.Equal(sv.vec.RawData(), vec.RawData()):
+		sv.refs++
+		e.vec = sv.vec
+		c.stats.dedupShared.Add(1)
+	default:
+		return
+	}
+	e.vecHash, e.interned = h, true
+}
+
+// releaseVecLocked drops e's reference to a shared vector, if it holds one.
+func (c *Cache) releaseVecLocked(e *entry) {
+	if !e.interned {
+		return
+	}
+	e.interned = false
+	sv := c.vecs[e.vecHash]
+	if sv.refs--; sv.refs == 0 {
+		delete(c.vecs, e.vecHash)
+	} else {
+		c.stats.dedupShared.Add(-1)
+	}
+}
+
+// hashWords is FNV-1a over 64-bit words.
+func hashWords(ws []uint64) uint64 {
+	h := uint64(14695981039346656037)
+	for _, w := range ws {
+		h ^= w
+		h *= 1099511628211
+	}
+	return h
+}
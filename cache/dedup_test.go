@@ -0,0 +1,76 @@
+package cache_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func newDedupCache(capacity int) *cache.Cache {
+	lsh := true
+	return cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()),
+		cache.Options{Threshold: 0.80, Capacity: capacity, LSHEnabled: &lsh, DedupVectors: true})
+}
+
+func TestDedup_SharesIdenticalVectors(t *testing.T) {
+	c := newDedupCache(16)
+	c.Set("what is the capital of india", 1)
+	c.Set("What Is The Capital Of India", 2) // encoder lowercases: identical vector
+	c.Set("  what is the capital of india ", 3)
+	c.Set("how do you bake a chocolate cake", 4)
+
+	s := c.Stats()
+	words := hdc.NumWords(hdc.DefaultConfig().Dims)
+	if s.DedupShared != 2 || s.DedupBytesSaved != uint64(2*words*8) {
+		t.Fatalf("want 2 shared entries, got %d (%d bytes)", s.DedupShared, s.DedupBytesSaved)
+	}
+
+	c.Delete("What Is The Capital Of India")
+	if got := c.Stats().DedupShared; got != 1 {
+		t.Fatalf("delete must release its reference, shared=%d", got)
+	}
+	c.Set("  what is the capital of india ", "now different") // update keeps vector
+	if got := c.Stats().DedupShared; got != 1 {
+		t.Fatalf("value update must not change sharing, shared=%d", got)
+	}
+	c.Delete("what is the capital of india")
+	c.Delete("  what is the capital of india ")
+	if got := c.Stats().DedupShared; got != 0 {
+		t.Fatalf("all references released, shared=%d", got)
+	}
+	if _, ok, _ := c.Get("how do you bake a chocolate cake"); !ok {
+		t.Fatal("unrelated entry must survive")
+	}
+}
+
+func TestDedup_EvictionAndSnapshot(t *testing.T) {
+	c := newDedupCache(2)
+	c.Set("alpha beta", 1)
+	c.Set("ALPHA BETA", 2)
+	c.Set("gamma delta", 3) // evicts "alpha beta"
+	if got := c.Stats().DedupShared; got != 0 {
+		t.Fatalf("eviction must release its reference, shared=%d", got)
+	}
+
+	var buf bytes.Buffer
+	c.Set("Gamma Delta", 4) // evicts "ALPHA BETA"; shares with "gamma delta"
+	if err := cache.EncodeSnapshot(&buf, c.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := cache.DecodeSnapshot(&buf, hdc.DefaultConfig().Dims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := newDedupCache(4)
+	if err := d.LoadSnapshot(snap); err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Stats().DedupShared; got != 1 {
+		t.Fatalf("loaded identical vectors must be shared, shared=%d", got)
+	}
+	if _, ok, sim := d.Get("gamma delta"); !ok || sim != 1 {
+		t.Fatalf("got ok=%v sim=%f", ok, sim)
+	}
+}
@@ -0,0 +1,28 @@
+package cache
+
+import "time"
+
+// Every write (Set, a loaded snapshot entry, a recorded delete) is stamped
+// with the next value of a hybrid clock: the wall time in nanoseconds, or
+// the last stamp plus one if that is not later. Stamps therefore keep
+// increasing across restarts as long as the wall clock does, so a snapshot
+// ID from a previous process remains a valid delta base.
+
+// tickLocked advances the write clock and returns the new stamp.
+func (c *Cache) tickLocked(now time.Time) uint64 {
+	c.wclock = max(c.wclock+1, uint64(now.UnixNano()))
+	return c.wclock
+}
+
+// DeltaSnapshot returns the entries and tombstones written since the
+// snapshot with ID since was taken: added or updated entries, and deletes
+// if Options.TombstoneTTL is set and covers the interval. Loading the base
+// snapshot and then its deltas in order restores the cache. Evictions and
+// LRU reordering are not recorded, and entries merged by LoadSnapshot count
+// as new writes, so after a restore take a full snapshot as the next base.
+// since 0 is equivalent to Snapshot.
+func (c *Cache) DeltaSnapshot(since uint64) Snapshot {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.snapshotLocked(since)
+}
@@ -0,0 +1,70 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestCache_DeltaSnapshot(t *testing.T) {
+	c := newTombCache(time.Hour)
+	c.Set("alpha", "A")
+	c.Set("beta", "B")
+	c.Set("gamma", "C")
+	base := c.Snapshot()
+	if base.ID == 0 || len(base.Entries) != 3 {
+		t.Fatalf("base: id %d, %d entries", base.ID, len(base.Entries))
+	}
+
+	c.Set("beta", "B2") // updated
+	c.Set("delta", "D") // added
+	c.Delete("gamma")   // deleted
+	c.Get("alpha")      // read only: not a change
+	d := c.DeltaSnapshot(base.ID)
+	if d.ID <= base.ID {
+		t.Fatalf("delta ID %d must follow base %d", d.ID, base.ID)
+	}
+	got := map[string]bool{}
+	for _, e := range d.Entries {
+		got[e.Key] = true
+	}
+	if len(d.Entries) != 2 || !got["beta"] || !got["delta"] {
+		t.Fatalf("delta entries %v, want beta and delta", got)
+	}
+	if len(d.Tombstones) != 1 || d.Tombstones[0].Key != "gamma" {
+		t.Fatalf("delta tombstones %+v, want gamma", d.Tombstones)
+	}
+
+	if d2 := c.DeltaSnapshot(d.ID); len(d2.Entries) != 0 || len(d2.Tombstones) != 0 || d2.ID != d.ID {
+		t.Fatalf("no writes since the delta, got %+v", d2)
+	}
+}
+
+func TestCache_DeltaSnapshot_Chain(t *testing.T) {
+	src := newTombCache(time.Hour)
+	src.Set("alpha", "A")
+	src.Set("gamma", "C")
+	base := src.Snapshot()
+	src.Set("alpha", "A2")
+	src.Delete("gamma")
+	d1 := src.DeltaSnapshot(base.ID)
+	src.Set("beta", "B")
+	d2 := src.DeltaSnapshot(d1.ID)
+
+	dst := newTombCache(time.Hour)
+	for _, s := range []cache.Snapshot{base, d1, d2} {
+		if err := dst.LoadSnapshot(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dst.Len() != 2 {
+		t.Fatalf("want alpha and beta, got %d entries", dst.Len())
+	}
+	if v, ok, _ := dst.Get("alpha"); !ok || v != "A2" {
+		t.Fatalf("alpha = %v %v, want the updated value", v, ok)
+	}
+	if _, ok, _ := dst.Get("gamma"); ok {
+		t.Fatal("deleted key must not come back")
+	}
+}
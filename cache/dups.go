@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"sort"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// Cluster is a group of entries linked by pairwise similarity.
+type Cluster struct {
+	Canonical     string   // member most similar to the rest (the medoid)
+	Keys          []string // all members, most recently used first
+	AvgSimilarity float64  // mean pairwise similarity within the cluster
+}
+
+// Duplicates groups live entries whose similarity is at least minSim,
+// transitively (single linkage), and returns groups of two or more, largest
+// first. Vectors are copied under the lock and compared outside it; the
+// comparison is O(n²) in the number of entries. Read-only, like Explain.
+func (c *Cache) Duplicates(minSim float64) []Cluster {
+	if minSim <= 0 || minSim > 1 {
+		panic("cache: minSim must be in (0, 1]")
+	}
+
+	keys, vecs := c.Vectors()
+	n := len(keys)
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	sims := make(map[[2]int]float64)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			s := c.sim(vecs[i], vecs[j])
+			if s < minSim {
+				continue
+			}
+			sims[[2]int{i, j}] = s
+			if ri, rj := find(i), find(j); ri != rj {
+				parent[rj] = ri
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := 0; i < n; i++ {
+		r := find(i)
+		groups[r] = append(groups[r], i)
+	}
+
+	var out []Cluster
+	for _, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		out = append(out, cluster(keys, vecs, members, sims, c.sim))
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if len(out[i].Keys) != len(out[j].Keys) {
+			return len(out[i].Keys) > len(out[j].Keys)
+		}
+		return out[i].Canonical < out[j].Canonical
+	})
+	return out
+}
+
+// cluster builds a Cluster from member indexes (ascending, so MRU first).
+// Pairs linked only transitively are scored here rather than in sims.
+func cluster(keys []string, vecs []hdc.Vector, members []int, sims map[[2]int]float64, sim func(a, b hdc.Vector) float64) Cluster {
+	cl := Cluster{Keys: make([]string, len(members))}
+	totals := make([]float64, len(members))
+	var sum float64
+	for a, i := range members {
+		cl.Keys[a] = keys[i]
+		for b := a + 1; b < len(members); b++ {
+			j := members[b]
+			s, ok := sims[[2]int{i, j}]
+			if !ok {
+				s = sim(vecs[i], vecs[j])
+			}
+			totals[a] += s
+			totals[b] += s
+			sum += s
+		}
+	}
+	best := 0
+	for a := range totals {
+		if totals[a] > totals[best] {
+			best = a
+		}
+	}
+	cl.Canonical = cl.Keys[best]
+	pairs := len(members) * (len(members) - 1) / 2
+	cl.AvgSimilarity = sum / float64(pairs)
+	return cl
+}
@@ -0,0 +1,50 @@
+package cache_test
+
+import "testing"
+
+func TestDuplicates_Clusters(t *testing.T) {
+	c := newCache(0.70, 16)
+	c.Set("what is the capital of india", "Delhi")
+	c.Set("how do you bake a chocolate cake", "oven")
+	c.Set("capital city of india", "Delhi")
+	c.Set("what is the capital of india?", "Delhi")
+
+	got := c.Duplicates(0.70)
+	if len(got) != 1 {
+		t.Fatalf("want one cluster, got %+v", got)
+	}
+	cl := got[0]
+	if len(cl.Keys) != 3 {
+		t.Fatalf("want the three india keys, got %v", cl.Keys)
+	}
+	if cl.Keys[0] != "what is the capital of india?" {
+		t.Fatalf("keys must be MRU first, got %v", cl.Keys)
+	}
+	if cl.Canonical == "capital city of india" {
+		t.Fatal("canonical must be the medoid, not the outlying paraphrase")
+	}
+	if cl.AvgSimilarity < 0.70 || cl.AvgSimilarity > 1 {
+		t.Fatalf("bad avg similarity %f", cl.AvgSimilarity)
+	}
+	if s := c.Stats(); s.Hits+s.Misses != 0 {
+		t.Fatal("Duplicates must not touch stats")
+	}
+}
+
+func TestDuplicates_NoneAboveMinSim(t *testing.T) {
+	c := newCache(0.70, 16)
+	c.Set("alpha", 1)
+	c.Set("a completely different sentence", 2)
+	if got := c.Duplicates(0.99); len(got) != 0 {
+		t.Fatalf("want no clusters, got %+v", got)
+	}
+}
+
+func TestDuplicates_InvalidMinSim_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	newCache(0.70, 16).Duplicates(0)
+}
@@ -0,0 +1,27 @@
+package cache
+
+// Early exit (Options.EarlyExit) trades the best match for the first good
+// enough one. Workloads are often temporally clustered — a question asked
+// now was likely asked, in other words, a moment ago — and the LRU list
+// holds the most recently used entries first, so a scan that stops at the
+// first entry scoring EarlyExit often stops near the front. Scans are then
+// serial, whatever ParallelScanMin says, to keep that order: parallel
+// chunks would each find their own match. With LSH the candidates are
+// visited in bucket order, and the first good enough one ends the lookup
+// as well. Under EvictionSamples the list is in insertion order, so the
+// most recently set entries come first instead.
+//
+// A stopped scan skips the entries after the match, so the best match
+// among them, the near miss reported for a soft miss and the runner-up
+// behind Result.Margin and Confidence are only those of the entries
+// scanned.
+
+// goodEnough reports whether a hit at sim ends the lookup early, counting
+// it in Stats.EarlyExits if so.
+func (c *Cache) goodEnough(sim float64) bool {
+	if c.earlyExit == 0 || sim < c.earlyExit {
+		return false
+	}
+	c.stats.earlyExits.Add(1)
+	return true
+}
@@ -0,0 +1,53 @@
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestEarlyExit(t *testing.T) {
+	lsh := false
+	newCache := func(earlyExit float64) *cache.Cache {
+		c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+			Threshold: 0.7, Capacity: 64, LSHEnabled: &lsh, EarlyExit: earlyExit,
+			ParallelScanMin: 1, ScanWorkers: 4, // ignored under EarlyExit
+		})
+		c.Set("what is the capital of india", "best")
+		for i := 0; i < 30; i++ {
+			c.Set(fmt.Sprintf("unrelated entry %d about gardening tools", i), i)
+		}
+		c.Set("what is the capital of india today", "recent")
+		return c
+	}
+
+	full := newCache(0)
+	if r := full.Lookup("what is the capital of india"); r.Value != "best" || r.Similarity != 1 {
+		t.Fatalf("full scan: %+v", r)
+	}
+
+	c := newCache(0.7)
+	r := c.Lookup("what is the capital of india")
+	if !r.Hit || r.Value != "recent" {
+		t.Fatalf("early exit must take the most recent good enough entry, got %+v", r)
+	}
+	s := c.Stats()
+	if s.EarlyExits != 1 || s.PerLookup.Compared.Mean != 1 {
+		t.Fatalf("early exits %d, compared %v", s.EarlyExits, s.PerLookup.Compared.Mean)
+	}
+
+	if r := c.Lookup("how to bake a chocolate cake"); r.Hit || c.Stats().EarlyExits != 1 {
+		t.Fatalf("miss: %+v, early exits %d", r, c.Stats().EarlyExits)
+	}
+}
+
+func TestEarlyExit_NeedsNoMargin(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("EarlyExit with Margin must panic")
+		}
+	}()
+	cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.8, Capacity: 8, EarlyExit: 0.97, Margin: 0.05})
+}
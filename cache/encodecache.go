@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// maxEncodeCacheKey bounds the keys Options.EncodeCache remembers; longer
+// ones (documents, transcripts) would pin more memory than the encode
+// saves time.
+const maxEncodeCacheKey = 4 << 10
+
+// encodeCache is an LRU of key → vector in front of the encoder
+// (Options.EncodeCache), so a Set followed by Gets of the same string, or
+// a hot query repeated, encodes once. It has its own lock: encodes run
+// outside the cache's. Vectors are never modified, so they are shared with
+// the entries storing them.
+type encodeCache struct {
+	mu    sync.Mutex
+	max   int
+	lru   *list.List // of *cachedVec, most recent first
+	index map[string]*list.Element
+}
+
+type cachedVec struct {
+	key string
+	vec hdc.Vector
+}
+
+// newEncodeCache returns a cache of n vectors, or nil (no caching) if
+// n <= 0.
+func newEncodeCache(n int) *encodeCache {
+	if n <= 0 {
+		return nil
+	}
+	return &encodeCache{max: n, lru: list.New(), index: make(map[string]*list.Element, n)}
+}
+
+func (c *encodeCache) get(key string) (hdc.Vector, bool) {
+	if c == nil {
+		return hdc.Vector{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.index[key]
+	if !ok {
+		return hdc.Vector{}, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*cachedVec).vec, true
+}
+
+func (c *encodeCache) put(key string, vec hdc.Vector) {
+	if c == nil || len(key) > maxEncodeCacheKey {
+		return
+	}
+	key = strings.Clone(key) // don't pin the caller's buffer
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.index[key]; ok {
+		return // a concurrent encode got there first
+	}
+	if c.lru.Len() >= c.max {
+		old := c.lru.Back()
+		delete(c.index, old.Value.(*cachedVec).key)
+		c.lru.Remove(old)
+	}
+	c.index[key] = c.lru.PushFront(&cachedVec{key, vec})
+}
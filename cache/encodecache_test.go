@@ -0,0 +1,61 @@
+package cache_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// countingEncoder counts calls to the wrapped encoder.
+type countingEncoder struct {
+	hdc.Encoder
+	n *atomic.Int64
+}
+
+func (e countingEncoder) Encode(key string) hdc.Vector {
+	e.n.Add(1)
+	return e.Encoder.Encode(key)
+}
+
+func TestCache_EncodeCache(t *testing.T) {
+	var calls atomic.Int64
+	enc := countingEncoder{hdc.NewNGramEncoder(hdc.DefaultConfig()), &calls}
+	c := cache.New(enc, cache.Options{Threshold: 0.75, Capacity: 16, EncodeCache: 2})
+	base := calls.Load() // cache.New encodes a probe
+
+	c.Set("what is the capital of france", "paris")
+	c.Get("what is the capital of france")
+	c.Get("what is the capital of france")
+	if n := calls.Load() - base; n != 1 {
+		t.Errorf("%d encodes, want 1", n)
+	}
+	if st := c.Stats(); st.EncodeCacheHits != 2 {
+		t.Errorf("EncodeCacheHits = %d, want 2", st.EncodeCacheHits)
+	}
+
+	// Two newer keys push it out.
+	c.Get("how tall is everest")
+	c.Get("who wrote hamlet")
+	c.Get("what is the capital of france")
+	if n := calls.Load() - base; n != 4 {
+		t.Errorf("%d encodes, want 4 once the key was evicted", n)
+	}
+}
+
+func TestCache_EncodeCache_AfterOverrun(t *testing.T) {
+	c := cache.New(stallEncoder{hdc.NewNGramEncoder(hdc.DefaultConfig())}, cache.Options{
+		Threshold: 0.9, Capacity: 8, EncodeBudget: 20 * time.Millisecond, EncodeCache: 8,
+	})
+	c.Set("slow key", 1)
+	time.Sleep(300 * time.Millisecond) // the overrunning encode finishes
+	c.Set("slow key", 1)
+	if _, ok, _ := c.Get("slow key"); !ok {
+		t.Error("the finished encode was not remembered")
+	}
+	if st := c.Stats(); st.EncodeTimeouts != 1 || st.EncodeCacheHits != 2 {
+		t.Errorf("%d timeouts, %d encode cache hits, want 1 and 2", st.EncodeTimeouts, st.EncodeCacheHits)
+	}
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Failure causes shared by the errors this package and xordb return, for
+// callers to tell apart with errors.Is; ErrClosed, ErrBusy and ErrEncoder
+// are defined with the code that returns them. Their messages have no
+// package prefix: the errors wrapping them carry their own.
+var (
+	// ErrDimsMismatch is matched by every DimsError.
+	ErrDimsMismatch = errors.New("dims mismatch")
+
+	// ErrIncompatibleSnapshot is wrapped by LoadSnapshot, DecodeSnapshot
+	// and OpenMapped errors for snapshots this cache cannot use: another
+	// format version, dims or encoder. A corrupt file is not incompatible.
+	ErrIncompatibleSnapshot = errors.New("incompatible snapshot")
+
+	// ErrEncoderUnavailable is wrapped by errors for work that needs an
+	// encoder the cache does not have yet, such as ErrPending.
+	ErrEncoderUnavailable = errors.New("encoder unavailable")
+
+	// ErrInvalidOption is wrapped by TryNew errors, which New panics with.
+	ErrInvalidOption = errors.New("invalid option")
+)
+
+// DimsError reports vectors of the wrong size: What names their source —
+// a snapshot, a file, a caller's vector — Got its dims and Want the
+// cache's. It matches ErrDimsMismatch. Its message has no package prefix,
+// so callers wrap it with their own.
+type DimsError struct {
+	What      string
+	Got, Want int
+}
+
+func (e *DimsError) Error() string {
+	return fmt.Sprintf("%s has %d dims, want %d", e.What, e.Got, e.Want)
+}
+
+// Is makes errors.Is(err, ErrDimsMismatch) hold.
+func (e *DimsError) Is(target error) bool { return target == ErrDimsMismatch }
+
+// invalidOption returns an error wrapping ErrInvalidOption.
+func invalidOption(msg string) error {
+	return fmt.Errorf("cache: %w: %s", ErrInvalidOption, msg)
+}
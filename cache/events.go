@@ -0,0 +1,45 @@
+package cache
+
+import "time"
+
+type EventKind string
+
+const (
+	EventHit    EventKind = "hit"
+	EventMiss   EventKind = "miss"
+	EventSet    EventKind = "set"
+	EventEvict  EventKind = "evict"  // LRU eviction at capacity
+	EventExpire EventKind = "expire" // TTL reaped during a lookup
+)
+
+// Event describes one cache operation, delivered to Options.OnEvent.
+type Event struct {
+	Kind EventKind
+	Time time.Time
+	Key  string // query key for hit/miss, entry key otherwise
+
+	// Hit: the matched entry and its similarity. Miss: the closest entry
+	// looked at and its (below-threshold) similarity, if any.
+	Match      string
+	Similarity float64
+}
+
+// emitLocked queues ev for delivery once c.mu is released.
+func (c *Cache) emitLocked(ev Event) {
+	if c.onEvent == nil {
+		return
+	}
+	ev.Time = time.Now()
+	c.pending = append(c.pending, ev)
+}
+
+// unlock releases c.mu, then delivers queued events outside the lock so
+// hooks may call back into the cache.
+func (c *Cache) unlock() {
+	evs := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+	for _, ev := range evs {
+		c.onEvent(ev)
+	}
+}
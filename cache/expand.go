@@ -0,0 +1,22 @@
+package cache
+
+import "github.com/Amansingh-afk/hdc-go"
+
+// queryVecs returns vec, the vector of key, followed by the vectors of
+// key's Options.QueryExpander expansions. Expansions equal to key, or
+// over the encode budget, are skipped.
+func (c *Cache) queryVecs(key string, vec hdc.Vector) []hdc.Vector {
+	vecs := []hdc.Vector{vec}
+	if c.expand == nil {
+		return vecs
+	}
+	for _, x := range c.expand(key) {
+		if x == key {
+			continue
+		}
+		if v, ok := c.encode(x); ok {
+			vecs = append(vecs, v)
+		}
+	}
+	return vecs
+}
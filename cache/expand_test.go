@@ -0,0 +1,49 @@
+package cache_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// synonyms expands "k8s" to "kubernetes".
+func synonyms(key string) []string {
+	if strings.Contains(key, "k8s") {
+		return []string{strings.ReplaceAll(key, "k8s", "kubernetes")}
+	}
+	return nil
+}
+
+func TestCache_QueryExpander(t *testing.T) {
+	for _, lsh := range []bool{false, true} {
+		c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+			Threshold: 0.9, Capacity: 16, LSHEnabled: &lsh, QueryExpander: synonyms,
+		})
+		c.Set("how do I restart a kubernetes pod", "kubectl delete pod")
+		r := c.Lookup("how do I restart a k8s pod")
+		if !r.Hit || r.Similarity != 1 {
+			t.Errorf("lsh=%v: expanded lookup = %+v, want an exact hit", lsh, r)
+		}
+		if st := c.Stats(); st.Hits != 1 || st.Misses != 0 {
+			t.Errorf("lsh=%v: hits %d misses %d, want one lookup counted", lsh, st.Hits, st.Misses)
+		}
+		if c.Lookup("how do I restart a nomad job").Hit {
+			t.Errorf("lsh=%v: unrelated key hit", lsh)
+		}
+	}
+}
+
+func TestCache_QueryExpander_SetUnaffected(t *testing.T) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+		Threshold: 0.9, Capacity: 16, QueryExpander: synonyms,
+	})
+	c.Set("restart a k8s pod", 1)
+	if r := c.Lookup("restart a k8s pod"); r.MatchedKey != "restart a k8s pod" {
+		t.Errorf("MatchedKey = %q", r.MatchedKey)
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", c.Len())
+	}
+}
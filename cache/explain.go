@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// Candidate is one scored entry returned by Explain.
+type Candidate struct {
+	Key        string
+	Similarity float64
+	Hit        bool // similarity >= threshold
+}
+
+// Explain scores key against every live entry and returns the n most similar,
+// best first (n <= 0 returns all). Read-only: LRU order and hit/miss stats are
+// untouched, and expired entries are skipped rather than reaped.
+func (c *Cache) Explain(key string, n int) []Candidate {
+	vec, ok := c.callEnc(key)
+	if !ok {
+		return nil
+	}
+	q := query{vec: vec, tokens: c.encodeTokens(key), lex: c.lexical(key), now: time.Now()}
+
+	c.mu.Lock()
+	threshold := c.thresholdLocked(key)
+	out := make([]Candidate, 0, c.lru.Len())
+	now := time.Now()
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*entry)
+		if c.isExpired(e, now) {
+			continue
+		}
+		s := c.score(&q, e)
+		out = append(out, Candidate{Key: e.key, Similarity: s, Hit: s >= threshold})
+	}
+	c.mu.Unlock()
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Similarity > out[j].Similarity })
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// KeySim is a stored key and its similarity to a query.
+type KeySim struct {
+	Key        string
+	Similarity float64
+}
+
+// SimilarKeys returns up to n live keys with similarity at least minSim,
+// best first (n <= 0 returns all of them). Read-only, like Explain.
+func (c *Cache) SimilarKeys(key string, n int, minSim float64) []KeySim {
+	vec, ok := c.callEnc(key)
+	if !ok {
+		return nil
+	}
+	q := query{vec: vec, tokens: c.encodeTokens(key), lex: c.lexical(key), now: time.Now()}
+
+	c.mu.Lock()
+	var out []KeySim
+	now := time.Now()
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*entry)
+		if c.isExpired(e, now) {
+			continue
+		}
+		if s := c.score(&q, e); s >= minSim {
+			out = append(out, KeySim{Key: e.key, Similarity: s})
+		}
+	}
+	c.mu.Unlock()
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Similarity > out[j].Similarity })
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// KeyHits is a stored key and the lookups its entry answered.
+type KeyHits struct {
+	Key  string
+	Hits uint64
+}
+
+// TopKeys returns up to n live keys whose entries answered the most
+// lookups since they were last set, most first, ties in LRU order. Entries
+// without hits are left out. Read-only, like Explain.
+func (c *Cache) TopKeys(n int) []KeyHits {
+	if n <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []KeyHits
+	now := time.Now()
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*entry)
+		if e.hits == 0 || c.isExpired(e, now) {
+			continue
+		}
+		if len(out) == n && e.hits <= out[n-1].Hits {
+			continue
+		}
+		i := sort.Search(len(out), func(i int) bool { return out[i].Hits < e.hits })
+		if len(out) < n {
+			out = append(out, KeyHits{})
+		}
+		copy(out[i+1:], out[i:])
+		out[i] = KeyHits{Key: e.key, Hits: e.hits}
+	}
+	return out
+}
+
+// MaxSimilarity returns the best similarity between key and any live entry
+// from an accepted source, or 0 if there is none. It always scans every
+// entry, so it reports what a linear-scan Get would see even when LSH is
+// enabled. Read-only, like Explain.
+func (c *Cache) MaxSimilarity(key string) float64 {
+	vec, ok := c.callEnc(key)
+	if !ok {
+		return 0
+	}
+	q := query{vec: vec, tokens: c.encodeTokens(key), lex: c.lexical(key), now: time.Now()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	best, _ := c.maxSimilarityLocked(&q)
+	return best
+}
+
+// Contains reports whether key would hit at the current threshold, with the
+// best similarity found. Read-only, like MaxSimilarity.
+func (c *Cache) Contains(key string) (bool, float64) {
+	vec, ok := c.callEnc(key)
+	if !ok {
+		return false, 0
+	}
+	q := query{vec: vec, tokens: c.encodeTokens(key), lex: c.lexical(key), now: time.Now()}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	best, found := c.maxSimilarityLocked(&q)
+	return found && best >= c.thresholdLocked(key), best
+}
+
+func (c *Cache) maxSimilarityLocked(q *query) (best float64, found bool) {
+	now := time.Now()
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*entry)
+		if c.isExpired(e, now) || !c.accepts(e) {
+			continue
+		}
+		found = true
+		if s := c.score(q, e); s > best {
+			best = s
+		}
+	}
+	return best, found
+}
+
+// Similarity scores the query text against the stored entry key. ok is
+// false if key is not cached. Read-only, like Explain.
+func (c *Cache) Similarity(text, key string) (sim float64, ok bool) {
+	vec, ok := c.callEnc(text)
+	if !ok {
+		return 0, false
+	}
+	toks, lex := c.encodeTokens(text), c.lexical(text)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.index.Get(key)
+	if !ok {
+		return 0, false
+	}
+	return c.score(&query{vec: vec, tokens: toks, lex: lex, now: time.Now()}, elem.Value.(*entry)), true
+}
+
+// Vectors returns the keys and vectors of live entries, most recently used
+// first. Vectors are shared with the cache and must not be modified.
+// Read-only, like Explain.
+func (c *Cache) Vectors() (keys []string, vecs []hdc.Vector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys = make([]string, 0, c.lru.Len())
+	vecs = make([]hdc.Vector, 0, c.lru.Len())
+	now := time.Now()
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*entry)
+		if c.isExpired(e, now) {
+			continue
+		}
+		keys = append(keys, e.key)
+		vecs = append(vecs, c.vecOf(e))
+	}
+	return keys, vecs
+}
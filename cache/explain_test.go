@@ -0,0 +1,148 @@
+package cache_test
+
+import "testing"
+
+func TestExplain_RankedBestFirst(t *testing.T) {
+	c := newCache(0.70, 16)
+	c.Set("what is the capital of india", "Delhi")
+	c.Set("what is the capital of nepal", "Kathmandu")
+	c.Set("how do you bake a chocolate cake", "oven")
+
+	got := c.Explain("capital city of nepal", 2)
+	if len(got) != 2 {
+		t.Fatalf("want 2 candidates, got %d", len(got))
+	}
+	if got[0].Key != "what is the capital of nepal" {
+		t.Fatalf("best candidate must be the nepal entry, got %q", got[0].Key)
+	}
+	if got[0].Similarity < got[1].Similarity {
+		t.Fatal("candidates must be sorted by similarity, best first")
+	}
+	if !got[0].Hit {
+		t.Fatalf("best candidate (sim %.4f) must be marked as hit", got[0].Similarity)
+	}
+}
+
+func TestExplain_AllAndReadOnly(t *testing.T) {
+	c := newCache(0.70, 16)
+	c.Set("alpha", 1)
+	c.Set("beta", 2)
+
+	if got := c.Explain("alpha", 0); len(got) != 2 {
+		t.Fatalf("n=0 must return all entries, got %d", len(got))
+	}
+	s := c.Stats()
+	if s.Hits != 0 || s.Misses != 0 {
+		t.Fatalf("Explain must not touch hit/miss stats, got %+v", s)
+	}
+}
+
+func TestExplain_Empty(t *testing.T) {
+	c := newCache(0.70, 16)
+	if got := c.Explain("anything", 5); len(got) != 0 {
+		t.Fatalf("empty cache must return no candidates, got %d", len(got))
+	}
+}
+
+func TestSimilarity_StoredEntry(t *testing.T) {
+	c := newCache(0.70, 16)
+	c.Set("what is the capital of india", "Delhi")
+
+	if sim, ok := c.Similarity("what is the capital of india", "what is the capital of india"); !ok || sim < 0.999 {
+		t.Fatalf("exact key: sim=%f ok=%v", sim, ok)
+	}
+	if sim, ok := c.Similarity("capital city of india", "what is the capital of india"); !ok || sim >= 1 || sim < 0.5 {
+		t.Fatalf("paraphrase: sim=%f ok=%v", sim, ok)
+	}
+	if _, ok := c.Similarity("anything", "not cached"); ok {
+		t.Fatal("unknown key must report !ok")
+	}
+	if s := c.Stats(); s.Hits+s.Misses != 0 {
+		t.Fatal("Similarity must not touch stats")
+	}
+}
+
+func TestContains_ReadOnly(t *testing.T) {
+	c := newCache(0.65, 2)
+	c.Set("what is the capital of india", "Delhi")
+	c.Set("how do you bake a chocolate cake", "oven")
+
+	ok, sim := c.Contains("capital city of india")
+	if !ok || sim < 0.65 || sim >= 1 {
+		t.Fatalf("paraphrase must be contained: ok=%v sim=%f", ok, sim)
+	}
+	if ok, sim := c.Contains("quantum chromodynamics lecture notes"); ok || sim >= 0.65 {
+		t.Fatalf("unrelated key: ok=%v sim=%f", ok, sim)
+	}
+	if got := c.MaxSimilarity("what is the capital of india"); got != 1 {
+		t.Fatalf("exact key MaxSimilarity = %f, want 1", got)
+	}
+	if s := c.Stats(); s.Hits+s.Misses != 0 {
+		t.Fatalf("Contains must not touch stats, got %+v", s)
+	}
+
+	// Contains must not promote: india is still LRU and goes first.
+	c.Set("third entry", 3)
+	if _, ok, _ := c.Get("what is the capital of india"); ok {
+		t.Fatal("Contains must not change LRU order")
+	}
+}
+
+func TestContains_Empty(t *testing.T) {
+	c := newCache(0.65, 2)
+	if ok, sim := c.Contains("anything"); ok || sim != 0 {
+		t.Fatalf("empty cache: ok=%v sim=%f", ok, sim)
+	}
+	if got := c.MaxSimilarity("anything"); got != 0 {
+		t.Fatalf("empty cache MaxSimilarity = %f", got)
+	}
+}
+
+func TestSimilarKeys(t *testing.T) {
+	c := newCache(0.90, 16)
+	c.Set("what is the capital of india", "Delhi")
+	c.Set("what is the capital of nepal", "Kathmandu")
+	c.Set("how do you bake a chocolate cake", "oven")
+
+	got := c.SimilarKeys("capital city of india", 0, 0.6)
+	if len(got) != 2 {
+		t.Fatalf("want the two capital keys above 0.6, got %+v", got)
+	}
+	if got[0].Key != "what is the capital of india" || got[0].Similarity < got[1].Similarity {
+		t.Fatalf("want india first, best first: %+v", got)
+	}
+	if got := c.SimilarKeys("capital city of india", 1, 0.6); len(got) != 1 {
+		t.Fatalf("n=1 must cap the result, got %d", len(got))
+	}
+	if got := c.SimilarKeys("capital city of india", 5, 0.99); len(got) != 0 {
+		t.Fatalf("minSim must filter, got %+v", got)
+	}
+	if s := c.Stats(); s.Hits+s.Misses != 0 {
+		t.Fatalf("SimilarKeys must not touch stats, got %+v", s)
+	}
+}
+
+func TestTopKeys(t *testing.T) {
+	c := newCache(0.95, 16)
+	for _, k := range []string{"alpha beta gamma", "delta epsilon zeta", "eta theta iota", "kappa lambda mu"} {
+		c.Set(k, k)
+	}
+	for k, n := range map[string]int{"alpha beta gamma": 1, "delta epsilon zeta": 3, "eta theta iota": 2} {
+		for i := 0; i < n; i++ {
+			c.Get(k)
+		}
+	}
+	got := c.TopKeys(2)
+	if len(got) != 2 || got[0].Key != "delta epsilon zeta" || got[0].Hits != 3 || got[1].Key != "eta theta iota" {
+		t.Fatalf("TopKeys(2) = %+v", got)
+	}
+	if got := c.TopKeys(10); len(got) != 3 || got[2].Hits != 1 {
+		t.Fatalf("keys without hits must be left out: %+v", got)
+	}
+	if s := c.Stats(); s.Hits != 6 {
+		t.Fatalf("TopKeys must not count lookups: %d hits", s.Hits)
+	}
+	if got := c.TopKeys(0); got != nil {
+		t.Fatalf("TopKeys(0) = %+v", got)
+	}
+}
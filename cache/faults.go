@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// Faults injects failures at the cache's internal failure points, so code
+// that puts a cache on a critical path can test its fallbacks. Options.Faults
+// consults it on every encode, match search and insert; implementations
+// must be safe for concurrent use and cheap when they inject nothing.
+type Faults interface {
+	// EncodeFault, if it returns an error, fails the encode of key as an
+	// encoder panic would: under Options.RecoverEncoderPanics the failure
+	// is recorded and the operation skipped, otherwise the error is
+	// panicked with.
+	EncodeFault(key string) error
+
+	// ScanDelay stalls the match search of a lookup for key, with the
+	// cache lock held, as a large or contended cache would.
+	ScanDelay(key string) time.Duration
+
+	// EvictionStorm is how many entries an insert evicts beyond those it
+	// needs room for; pinned entries are spared as usual.
+	EvictionStorm() int
+}
+
+// encodeFault reports whether Options.Faults fails the encode of key.
+func (c *Cache) encodeFault(key string) bool {
+	err := c.faults.EncodeFault(key)
+	if err == nil {
+		return false
+	}
+	if !c.encRecover {
+		panic(err)
+	}
+	c.encodeFailed(fmt.Errorf("%w on %q: injected: %w", ErrEncoder, key, err))
+	return true
+}
+
+func (c *Cache) scanDelayLocked(key string) {
+	if d := c.faults.ScanDelay(key); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+func (c *Cache) evictionStormLocked() {
+	if n := c.faults.EvictionStorm(); n > 0 {
+		c.evictLocked(n)
+	}
+}
@@ -0,0 +1,84 @@
+package cache_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// scriptedFaults fails encodes of keys starting with "bad", stalls lookups
+// of keys starting with "slow" and makes every insert evict storm entries.
+type scriptedFaults struct{ storm int }
+
+var errScripted = errors.New("scripted")
+
+func (f scriptedFaults) EncodeFault(key string) error {
+	if strings.HasPrefix(key, "bad") {
+		return errScripted
+	}
+	return nil
+}
+
+func (f scriptedFaults) ScanDelay(key string) time.Duration {
+	if strings.HasPrefix(key, "slow") {
+		return 50 * time.Millisecond
+	}
+	return 0
+}
+
+func (f scriptedFaults) EvictionStorm() int { return f.storm }
+
+func TestFaults_Encode(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	c := cache.New(enc, cache.Options{Threshold: 0.9, Capacity: 8, RecoverEncoderPanics: true, Faults: scriptedFaults{}})
+	c.Set("bad key", 1)
+	if c.Len() != 0 {
+		t.Fatal("a failed encode must not store")
+	}
+	if err := c.LastEncodeError(); !errors.Is(err, cache.ErrEncoder) || !errors.Is(err, errScripted) {
+		t.Errorf("LastEncodeError() = %v", err)
+	}
+	if c.Stats().EncodeErrors != 1 {
+		t.Errorf("EncodeErrors = %d", c.Stats().EncodeErrors)
+	}
+
+	c = cache.New(enc, cache.Options{Threshold: 0.9, Capacity: 8, Faults: scriptedFaults{}})
+	defer func() {
+		if r := recover(); r != errScripted {
+			t.Errorf("recovered %v, want the injected error", r)
+		}
+	}()
+	c.Get("bad key")
+	t.Error("without recovery an injected failure must panic")
+}
+
+func TestFaults_ScanDelay(t *testing.T) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.9, Capacity: 8, Faults: scriptedFaults{}})
+	c.Set("slow query", 1)
+	start := time.Now()
+	if _, ok, _ := c.Get("slow query"); !ok {
+		t.Fatal("a delayed lookup must still hit")
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("lookup took %v", d)
+	}
+}
+
+func TestFaults_EvictionStorm(t *testing.T) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.9, Capacity: 8, Faults: scriptedFaults{storm: 3}})
+	for i := 0; i < 4; i++ {
+		c.Set(fmt.Sprintf("key number %d", i), i)
+	}
+	// Each insert evicts up to 3 before adding itself: 1, 1, 1, 1.
+	if c.Len() != 1 || c.Stats().Evictions != 3 {
+		t.Errorf("len %d, evictions %d", c.Len(), c.Stats().Evictions)
+	}
+	if _, ok, _ := c.Get("key number 3"); !ok {
+		t.Error("the new key must survive its own storm")
+	}
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"hash/fnv"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// fingerprintProbe is encoded to tell encoders apart: encoders that map it
+// to the same vector are, for persistence, treated as the same encoder.
+const fingerprintProbe = "xordb encoder fingerprint: the quick brown fox jumps over the lazy dog"
+
+// Fingerprint identifies enc by hashing its encoding of a fixed probe text.
+// Snapshots record it so that loading vectors produced by a different
+// model, seed or n-gram size fails instead of returning nonsense hits.
+// Never 0, which snapshots use for "unknown".
+func Fingerprint(enc hdc.Encoder) uint64 {
+	v := enc.Encode(fingerprintProbe)
+	h := fnv.New64a()
+	var b [8]byte
+	put := func(x uint64) {
+		for i := range b {
+			b[i] = byte(x >> (8 * i))
+		}
+		h.Write(b[:])
+	}
+	put(uint64(v.Dims()))
+	for _, w := range v.RawData() {
+		put(w)
+	}
+	return max(h.Sum64(), 1)
+}
+
+// EncoderFingerprint returns the Fingerprint of the cache's encoder, or 0
+// while a Pending encoder is loading.
+func (c *Cache) EncoderFingerprint() uint64 { return c.fingerprint() }
+
+// fingerprint returns Fingerprint(c.enc), computed on first use.
+func (c *Cache) fingerprint() uint64 {
+	if c.loading() {
+		return 0
+	}
+	c.fpOnce.Do(func() { c.fp = Fingerprint(c.enc) })
+	return c.fp
+}
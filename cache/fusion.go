@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"hash/fnv"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Score fusion (Options.ScoreFusion) blends the encoder's similarity with
+// the Jaccard overlap of the two keys' words:
+//
+//	score = α·semantic + (1−α)·|A ∩ B| / |A ∪ B|
+//
+// so two keys the encoder finds close but that share few words, such as
+// questions about different products, need more semantic similarity to
+// hit, and rewordings sharing most words less. Each entry keeps its key's
+// words as sorted 32-bit hashes, 4 bytes a distinct word; nothing is
+// encoded. The popcount prefilter still applies, loosened to the lowest
+// semantic similarity that a full word overlap could lift to the
+// threshold. Queries without text, from LookupVec, score semantically.
+
+// checkFusion reports whether alpha is a valid Options.ScoreFusion.
+func checkFusion(alpha float64) error {
+	if !(alpha >= 0 && alpha < 1) {
+		return invalidOption("Options.ScoreFusion must be in [0, 1)")
+	}
+	return nil
+}
+
+// lexical returns text's word fingerprint, or nil without score fusion.
+func (c *Cache) lexical(text string) []uint32 {
+	if c.fusion == 0 {
+		return nil
+	}
+	return wordHashes(text)
+}
+
+// wordHashes returns the sorted, distinct hashes of text's lowercased
+// words; empty but not nil for text without words.
+func wordHashes(text string) []uint32 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := make([]uint32, 0, len(words))
+	for _, w := range words {
+		h := fnv.New32a()
+		h.Write([]byte(w))
+		out = append(out, h.Sum32())
+	}
+	slices.Sort(out)
+	return slices.Clip(slices.Compact(out))
+}
+
+// jaccard is the overlap of two sorted hash sets, 1 if both are empty.
+func jaccard(a, b []uint32) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			shared++
+			i++
+			j++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// fuse blends sim, q's semantic similarity to e, with their word overlap.
+func (c *Cache) fuse(q *query, e *entry, sim float64) float64 {
+	if c.fusion == 0 || q.lex == nil {
+		return sim
+	}
+	return c.fusion*sim + (1-c.fusion)*jaccard(q.lex, e.lex)
+}
+
+// semanticFloor is the lowest semantic similarity that can still score
+// minSim: with fusion, the rest may come from a full word overlap.
+func (c *Cache) semanticFloor(minSim float64) float64 {
+	if c.fusion == 0 {
+		return minSim
+	}
+	return (minSim - (1 - c.fusion)) / c.fusion
+}
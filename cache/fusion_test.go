@@ -0,0 +1,64 @@
+package cache_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestCache_ScoreFusion(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	opts := cache.Options{Threshold: 0.99, Capacity: 100, LSHEnabled: new(bool)}
+	plain := cache.New(enc, opts)
+	opts.ScoreFusion = 0.5
+	fused := cache.New(enc, opts)
+
+	key := "rotate the signing keys of the staging cluster"
+	query := "of the staging cluster: rotate the signing keys"
+	for _, c := range []*cache.Cache{plain, fused} {
+		c.Set(key, 1)
+	}
+
+	// Same words, reordered: full overlap lifts the score halfway to 1.
+	ps, _ := plain.Similarity(query, key)
+	fs, _ := fused.Similarity(query, key)
+	if want := 0.5*ps + 0.5; math.Abs(fs-want) > 1e-9 {
+		t.Fatalf("fused similarity %.4f, want %.4f (semantic %.4f)", fs, want, ps)
+	}
+	if r := plain.Lookup(query); r.Hit {
+		t.Fatalf("plain lookup hit at %.3f", r.Similarity)
+	}
+	fused.SetThreshold(fs - 0.01)
+	if r := fused.Lookup(query); !r.Hit || r.Similarity != fs {
+		t.Errorf("fused lookup hit=%v sim=%.4f, Similarity %.4f", r.Hit, r.Similarity, fs)
+	}
+
+	// Two of ten distinct words shared.
+	other := "rotate signing certificates for production"
+	ps, _ = plain.Similarity(other, key)
+	fs, _ = fused.Similarity(other, key)
+	if want := 0.5*ps + 0.5*2.0/10; math.Abs(fs-want) > 1e-9 {
+		t.Errorf("partial overlap scores %.4f, want %.4f", fs, want)
+	}
+
+	// LookupVec has no query text and scores semantically.
+	if r := fused.LookupVec(key, enc.Encode(query)); r.Hit {
+		t.Errorf("LookupVec hit at %.3f, want the plain score", r.Similarity)
+	}
+}
+
+func TestCache_ScoreFusionBounds(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	for _, alpha := range []float64{-0.1, 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("ScoreFusion %v must panic", alpha)
+				}
+			}()
+			cache.New(enc, cache.Options{Threshold: 0.8, Capacity: 10, ScoreFusion: alpha})
+		}()
+	}
+}
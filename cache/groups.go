@@ -0,0 +1,155 @@
+package cache
+
+import "container/list"
+
+// Groups (Options.GroupOf, Options.Groups) let tenants share a cache
+// without sharing its eviction. GroupOf names the group of every key; a
+// group listed in Groups with a Capacity has that many entries reserved
+// and its own LRU list: its entries are evicted only to make room for its
+// own inserts. Entries of every other group share the rest of the
+// capacity, Capacity minus the reserved total, in a pool with an LRU list
+// of its own. So a tenant flooding the cache evicts its own oldest entries
+// and never another's. A group's Threshold applies to lookups of its keys.
+
+// Group is one group's override of the cache's settings.
+type Group struct {
+	Capacity  int     // entries reserved for the group; 0 = share the pool
+	Threshold float64 // for lookups of the group's keys; 0 = the cache's
+}
+
+// groupState is a group with reserved capacity, or the shared pool.
+type groupState struct {
+	capacity int
+	lru      *list.List // of the members' elements of Cache.lru, MRU first
+}
+
+// checkGroups reports whether opts.Groups are valid.
+func checkGroups(opts Options) error {
+	if opts.GroupOf == nil {
+		if opts.Groups != nil {
+			return invalidOption("Options.Groups needs GroupOf")
+		}
+		return nil
+	}
+	if opts.EvictionSamples > 0 || opts.LowWatermark > 0 {
+		return invalidOption("Options.Groups needs exact LRU without LowWatermark")
+	}
+	total := 0
+	for name, g := range opts.Groups {
+		if !(g.Capacity >= 0 && g.Threshold >= 0 && g.Threshold <= 1) {
+			return invalidOption("group " + name + ": Capacity must not be negative and Threshold must be in [0, 1]")
+		}
+		total += g.Capacity
+	}
+	if total >= opts.Capacity {
+		return invalidOption("Options.Groups reserve the whole Capacity; leave room for the shared pool")
+	}
+	return nil
+}
+
+// newGroups sets up the group lists of opts.Groups, checked by checkGroups.
+func (c *Cache) newGroups(opts Options) {
+	if opts.GroupOf == nil {
+		return
+	}
+	c.groupOf = opts.GroupOf
+	c.groups = make(map[string]Group, len(opts.Groups))
+	c.reserved = make(map[string]*groupState)
+	total := 0
+	for name, g := range opts.Groups {
+		c.groups[name] = g
+		if g.Capacity > 0 {
+			c.reserved[name] = &groupState{capacity: g.Capacity, lru: list.New()}
+			total += g.Capacity
+		}
+	}
+	c.pool = &groupState{capacity: opts.Capacity - total, lru: list.New()}
+}
+
+// groupStateLocked returns the group or pool a new key joins; nil without
+// groups.
+func (c *Cache) groupStateLocked(key string) *groupState {
+	if c.groupOf == nil {
+		return nil
+	}
+	if g, ok := c.reserved[c.groupOf(key)]; ok {
+		return g
+	}
+	return c.pool
+}
+
+// thresholdLocked returns the threshold for lookups of key.
+func (c *Cache) thresholdLocked(key string) float64 {
+	if c.groupOf != nil {
+		if t := c.groups[c.groupOf(key)].Threshold; t > 0 {
+			return t
+		}
+	}
+	return c.threshold
+}
+
+// joinLocked adds a newly inserted elem to its group's list.
+func (c *Cache) joinLocked(elem *list.Element) {
+	e := elem.Value.(*entry)
+	if g := c.groupStateLocked(e.key); g != nil {
+		e.group, e.gelem = g, g.lru.PushFront(elem)
+	}
+}
+
+// groupRoomLocked makes room in key's group or the pool for one new entry.
+func (c *Cache) groupRoomLocked(key string) {
+	g := c.groupStateLocked(key)
+	if over := g.lru.Len() - g.capacity + 1; over > 0 {
+		c.evictGroupLocked(g, over)
+	}
+}
+
+// trimGroupsLocked brings every group and the pool back within capacity.
+func (c *Cache) trimGroupsLocked() {
+	for _, g := range c.reserved {
+		if over := g.lru.Len() - g.capacity; over > 0 {
+			c.evictGroupLocked(g, over)
+		}
+	}
+	if over := c.pool.lru.Len() - c.pool.capacity; over > 0 {
+		c.evictGroupLocked(c.pool, over)
+	}
+}
+
+// evictGroupLocked is evictLocked within one group or the pool.
+func (c *Cache) evictGroupLocked(g *groupState, n int) int {
+	removed := 0
+	for ge := g.lru.Back(); ge != nil && removed < n; {
+		prev := ge.Prev()
+		elem := ge.Value.(*list.Element)
+		if e := elem.Value.(*entry); !e.pinned {
+			c.emitLocked(Event{Kind: EventEvict, Key: e.key})
+			c.stats.evictions.Add(1)
+			c.removeLocked(elem)
+			removed++
+		}
+		ge = prev
+	}
+	return removed
+}
+
+// setPoolCapacityLocked resizes the pool after SetCapacity; the pool keeps
+// at least one entry.
+func (c *Cache) setPoolCapacityLocked(capacity int) {
+	reserved := 0
+	for _, g := range c.reserved {
+		reserved += g.capacity
+	}
+	c.pool.capacity = max(capacity-reserved, 1)
+}
+
+// GroupLen returns the number of entries of a group with reserved
+// capacity, or -1 if group has none.
+func (c *Cache) GroupLen(group string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if g, ok := c.reserved[group]; ok {
+		return g.lru.Len()
+	}
+	return -1
+}
@@ -0,0 +1,91 @@
+package cache_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// tenantOf groups keys by their "tenant:" prefix.
+func tenantOf(key string) string {
+	tenant, _, _ := strings.Cut(key, ":")
+	return tenant
+}
+
+func TestGroups_EvictionIsolation(t *testing.T) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+		Threshold: 0.9,
+		Capacity:  10,
+		GroupOf:   tenantOf,
+		Groups:    map[string]cache.Group{"a": {Capacity: 3}, "b": {Capacity: 3}},
+	})
+	for i := 0; i < 3; i++ {
+		c.Set(fmt.Sprintf("b: stored answer number %d", i), i)
+	}
+	for i := 0; i < 50; i++ {
+		c.Set(fmt.Sprintf("a: flood of questions number %d", i), i)
+		c.Set(fmt.Sprintf("other: flood of questions number %d", i), i)
+	}
+	if n := c.GroupLen("b"); n != 3 {
+		t.Fatalf("b lost entries to other tenants: %d left", n)
+	}
+	if n := c.GroupLen("a"); n != 3 {
+		t.Errorf("a holds %d entries, want its capacity 3", n)
+	}
+	if c.Len() != 10 {
+		t.Errorf("Len() = %d, want 3 + 3 + a pool of 4", c.Len())
+	}
+	if _, ok, _ := c.Get("a: flood of questions number 49"); !ok {
+		t.Error("a's newest entry was evicted")
+	}
+	if _, ok, _ := c.Get("a: flood of questions number 0"); ok {
+		t.Error("a's oldest entry survived past its capacity")
+	}
+	if c.GroupLen("other") != -1 {
+		t.Error("a group without capacity has no length of its own")
+	}
+
+	c.SetCapacity(7) // pool shrinks to 1
+	if c.Len() != 7 || c.GroupLen("b") != 3 {
+		t.Errorf("after SetCapacity: len %d, b %d", c.Len(), c.GroupLen("b"))
+	}
+}
+
+func TestGroups_Threshold(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	c := cache.New(enc, cache.Options{
+		Threshold: 0.5,
+		Capacity:  10,
+		GroupOf:   tenantOf,
+		Groups:    map[string]cache.Group{"strict": {Threshold: 0.99}},
+	})
+	c.Set("strict: what is the capital of india", 1)
+	c.Set("loose: what is the capital of india", 2)
+	if _, ok, _ := c.Get("strict: what is the capital city of india"); ok {
+		t.Error("strict group hit below its threshold")
+	}
+	if _, ok, _ := c.Get("loose: what is the capital city of india"); !ok {
+		t.Error("ungrouped keys must use the cache's threshold")
+	}
+}
+
+func TestGroups_Validation(t *testing.T) {
+	for name, opts := range map[string]cache.Options{
+		"no GroupOf":   {Threshold: 0.8, Capacity: 10, Groups: map[string]cache.Group{"a": {Capacity: 1}}},
+		"no pool":      {Threshold: 0.8, Capacity: 10, GroupOf: tenantOf, Groups: map[string]cache.Group{"a": {Capacity: 10}}},
+		"sampled":      {Threshold: 0.8, Capacity: 10, GroupOf: tenantOf, EvictionSamples: 5},
+		"bad capacity": {Threshold: 0.8, Capacity: 10, GroupOf: tenantOf, Groups: map[string]cache.Group{"a": {Capacity: -1}}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: New did not panic", name)
+				}
+			}()
+			cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), opts)
+		}()
+	}
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// hist is a lock-free histogram of non-negative integers — nanoseconds,
+// entry counts. Buckets are powers of two split in histSub linear steps,
+// so a quantile read from them is within 1/histSub of the true value.
+type hist struct {
+	b   [62 * histSub]atomic.Uint64
+	sum atomic.Uint64
+}
+
+const histSub = 8 // sub-buckets per power of two
+
+// histBucket returns n's bucket: values below histSub count exactly,
+// larger ones by their top four bits.
+func histBucket(n uint64) int {
+	e := bits.Len64(n) - 1
+	if e < 3 {
+		return int(n)
+	}
+	return (e-2)*histSub + int(n>>(e-3))&(histSub-1)
+}
+
+// histUpper returns the upper bound of bucket i.
+func histUpper(i int) uint64 {
+	if i < histSub {
+		return uint64(i + 1)
+	}
+	e, s := i/histSub+2, i%histSub
+	return uint64(histSub+s+1) << (e - 3)
+}
+
+func (h *hist) observe(n uint64) {
+	h.b[histBucket(n)].Add(1)
+	h.sum.Add(n)
+}
+
+// quantiles returns the number of values observed, their sum, and upper
+// bounds on the values at quantiles qs, which must be ascending.
+func (h *hist) quantiles(qs ...float64) (count, sum uint64, at []uint64) {
+	var counts [len(h.b)]uint64
+	for i := range h.b {
+		counts[i] = h.b[i].Load()
+		count += counts[i]
+	}
+	at = make([]uint64, len(qs))
+	if count == 0 {
+		return 0, 0, at
+	}
+	var seen uint64
+	q := 0
+	for i, n := range counts {
+		seen += n
+		for q < len(qs) && float64(seen) >= qs[q]*float64(count) {
+			at[q] = histUpper(i)
+			q++
+		}
+	}
+	return count, h.sum.Load(), at
+}
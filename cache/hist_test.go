@@ -0,0 +1,30 @@
+package cache
+
+import "testing"
+
+func TestHistBuckets(t *testing.T) {
+	for _, n := range []uint64{0, 1, 7, 8, 9, 15, 16, 100, 1023, 1024, 12345, 1e6, 3e9} {
+		up := histUpper(histBucket(n))
+		if up <= n || float64(up) > float64(n)*1.125+1 {
+			t.Errorf("%d: bucket bound %d", n, up)
+		}
+		if i := histBucket(n); i > 0 && histUpper(i-1) > n {
+			t.Errorf("%d: previous bucket bound %d is above it", n, histUpper(i-1))
+		}
+	}
+}
+
+func TestHist_Quantiles(t *testing.T) {
+	var h hist
+	if n, sum, at := h.quantiles(0.5); n != 0 || sum != 0 || at[0] != 0 {
+		t.Error("empty histogram must report zeros")
+	}
+	for i := uint64(1); i <= 100; i++ {
+		h.observe(i * 1000)
+	}
+	n, sum, at := h.quantiles(0.50, 0.95, 0.99)
+	within := func(got, want uint64) bool { return got >= want && float64(got) <= float64(want)*1.125 }
+	if n != 100 || sum != 5050*1000 || !within(at[0], 50000) || !within(at[1], 95000) || !within(at[2], 99000) {
+		t.Errorf("n %d, sum %d, quantiles %v", n, sum, at)
+	}
+}
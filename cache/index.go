@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"container/list"
+	"hash/maphash"
+)
+
+// Index maps exact keys to their entries' list elements; see
+// Options.Index. Its methods are called with the cache lock held, and an
+// Index belongs to one cache.
+type Index interface {
+	Get(key string) (*list.Element, bool)
+	Put(key string, elem *list.Element)
+	Delete(key string)
+}
+
+// MapIndex is the default Index, a Go map. (From Go 1.24 maps are Swiss
+// tables.)
+type MapIndex map[string]*list.Element
+
+// NewMapIndex returns an empty MapIndex.
+func NewMapIndex() MapIndex { return make(MapIndex) }
+
+func (m MapIndex) Get(key string) (*list.Element, bool) {
+	elem, ok := m[key]
+	return elem, ok
+}
+
+func (m MapIndex) Put(key string, elem *list.Element) { m[key] = elem }
+func (m MapIndex) Delete(key string)                  { delete(m, key) }
+
+// ShardedIndex spreads keys over several maps by hash. Each map grows on
+// its own, so the occasional insert that resizes one copies a fraction of
+// the keys instead of all of them: flatter Set latency in caches of
+// millions of entries, for a hash per access.
+type ShardedIndex struct {
+	seed   maphash.Seed
+	shards []map[string]*list.Element
+}
+
+// NewShardedIndex returns an empty ShardedIndex of n shards, rounded up to
+// a power of two. Panics if n is not positive.
+func NewShardedIndex(n int) *ShardedIndex {
+	if n <= 0 {
+		panic("cache: index shard count must be positive")
+	}
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	s := &ShardedIndex{seed: maphash.MakeSeed(), shards: make([]map[string]*list.Element, size)}
+	for i := range s.shards {
+		s.shards[i] = make(map[string]*list.Element)
+	}
+	return s
+}
+
+func (s *ShardedIndex) shard(key string) map[string]*list.Element {
+	return s.shards[maphash.String(s.seed, key)&uint64(len(s.shards)-1)]
+}
+
+func (s *ShardedIndex) Get(key string) (*list.Element, bool) {
+	elem, ok := s.shard(key)[key]
+	return elem, ok
+}
+
+func (s *ShardedIndex) Put(key string, elem *list.Element) { s.shard(key)[key] = elem }
+func (s *ShardedIndex) Delete(key string)                  { delete(s.shard(key), key) }
@@ -0,0 +1,71 @@
+package cache_test
+
+import (
+	"container/list"
+	"fmt"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestShardedIndex(t *testing.T) {
+	idx := cache.NewShardedIndex(5) // rounded up to 8
+	l := list.New()
+	elems := make([]*list.Element, 100)
+	for i := range elems {
+		elems[i] = l.PushBack(i)
+		idx.Put(fmt.Sprint("key ", i), elems[i])
+	}
+	idx.Delete("key 7")
+	idx.Delete("never stored")
+	for i, want := range elems {
+		got, ok := idx.Get(fmt.Sprint("key ", i))
+		if i == 7 {
+			want = nil
+		}
+		if got != want || ok != (want != nil) {
+			t.Errorf("key %d: got %v, %v", i, got, ok)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for zero shards")
+		}
+	}()
+	cache.NewShardedIndex(0)
+}
+
+func TestCache_Index(t *testing.T) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+		Threshold: 0.75, Capacity: 4, Index: cache.NewShardedIndex(4),
+	})
+	for i := 0; i < 6; i++ {
+		c.Set(fmt.Sprintf("question number %d", i), i)
+	}
+	c.Set("question number 5", "updated")
+	if c.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", c.Len())
+	}
+	if c.Delete("question number 0") {
+		t.Error("evicted key still indexed")
+	}
+	if !c.Delete("question number 2") || c.Delete("question number 2") {
+		t.Error("Delete did not find the key exactly once")
+	}
+	if v, ok, _ := c.Get("question number 5"); !ok || v != "updated" {
+		t.Errorf("got %v, %v", v, ok)
+	}
+}
+
+func TestCache_ExactHits(t *testing.T) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.75, Capacity: 16})
+	c.Set("what is the capital of france", "paris")
+	c.Get("what is the capital of france")
+	c.Get("what is the capital of france?")
+	c.Get("something else entirely")
+	if st := c.Stats(); st.Hits != 2 || st.ExactHits != 1 {
+		t.Errorf("hits %d, exact %d, want 2 and 1", st.Hits, st.ExactHits)
+	}
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// Late interaction (Options.LateInteraction) keeps, besides the pooled
+// vector, up to LateInteraction token vectors per entry, and scores a
+// lookup against an entry by MaxSim: every query token is matched to its
+// most similar entry token and the similarities averaged, and the same
+// the other way round, so neither a key that is a prefix of the other nor
+// one padded with extra words scores 1. A long key keeps the detail that
+// pooling into one vector blurs. Keys with more tokens than the limit have
+// runs of adjacent tokens bundled into one vector.
+//
+// Scoring is LateInteraction² comparisons per entry instead of one, and
+// the popcount prefilter, whose bound holds for pooled vectors only, is
+// off; LSH still picks candidates by the pooled vector. Entries
+// without tokens — stored with SetVec or as aliases, or restored from a
+// snapshot, which carries no tokens — and query expansions are scored by
+// the pooled vectors as usual.
+
+const (
+	maxLateTokens = 32      // largest Options.LateInteraction
+	maxLateBytes  = 1 << 30 // token vectors a full cache may hold
+)
+
+// TokenEncoder is an encoder that can also encode a text as one vector per
+// token, in the space of its pooled vectors, for Options.LateInteraction.
+type TokenEncoder interface {
+	hdc.Encoder
+	EncodeTokens(text string) []hdc.Vector
+}
+
+// checkLate reports whether opts allow late interaction with enc at dims.
+func checkLate(enc hdc.Encoder, opts Options, dims int) error {
+	if opts.LateInteraction < 0 || opts.LateInteraction > maxLateTokens {
+		return invalidOption(fmt.Sprintf("Options.LateInteraction must be in [0, %d]", maxLateTokens))
+	}
+	if opts.LateInteraction == 0 {
+		return nil
+	}
+	if _, ok := enc.(TokenEncoder); !ok {
+		return invalidOption("Options.LateInteraction needs an encoder with EncodeTokens")
+	}
+	if opts.Similarity != nil || opts.BitSliced || opts.MissFilter || opts.CoarseBits != 0 {
+		return invalidOption("Options.LateInteraction needs the default Similarity and no BitSliced, MissFilter or CoarseBits")
+	}
+	if need := int64(opts.Capacity) * int64(opts.LateInteraction) * int64(hdc.NumWords(dims)) * 8; need > maxLateBytes {
+		return invalidOption(fmt.Sprintf("Options.LateInteraction needs %d MiB of token vectors at Capacity %d, over the %d MiB limit",
+			need>>20, opts.Capacity, maxLateBytes>>20))
+	}
+	return nil
+}
+
+// encodeTokens returns key's token vectors, at most c.late of them, or nil
+// without late interaction or if the encoder fails.
+func (c *Cache) encodeTokens(key string) (toks []hdc.Vector) {
+	if c.late == 0 {
+		return nil
+	}
+	if c.encRecover {
+		defer func() {
+			if r := recover(); r != nil {
+				c.encodeFailed(fmt.Errorf("%w on %q: EncodeTokens panic: %v", ErrEncoder, key, r))
+				toks = nil
+			}
+		}()
+	}
+	toks = c.enc.(TokenEncoder).EncodeTokens(key)
+	if len(toks) == 0 {
+		return nil
+	}
+	for _, v := range toks {
+		if v.Dims() != c.dims {
+			c.encodeFailed(fmt.Errorf("%w on %q: %w", ErrEncoder, key, &DimsError{What: "token vector", Got: v.Dims(), Want: c.dims}))
+			return nil
+		}
+	}
+	return squeezeTokens(toks, c.late)
+}
+
+// squeezeTokens bundles runs of adjacent vectors so at most n remain.
+func squeezeTokens(toks []hdc.Vector, n int) []hdc.Vector {
+	if len(toks) <= n {
+		return toks
+	}
+	out := make([]hdc.Vector, n)
+	for i := range out {
+		out[i] = hdc.Bundle(toks[i*len(toks)/n : (i+1)*len(toks)/n]...)
+	}
+	return out
+}
+
+// setTokensLocked attaches toks to key's entry, just stored.
+func (c *Cache) setTokensLocked(key string, toks []hdc.Vector) {
+	if toks == nil {
+		return
+	}
+	if elem, ok := c.index.Get(key); ok {
+		elem.Value.(*entry).tokens = toks
+	}
+}
+
+// score is q's similarity to e: MaxSim when both have token vectors, the
+// pooled similarity otherwise, fused with their word overlap under
+// Options.ScoreFusion and aged under Options.DecayHalfLife. Safe for the
+// parallel scan's workers.
+func (c *Cache) score(q *query, e *entry) float64 {
+	var sim float64
+	switch {
+	case q.tokens != nil && e.tokens != nil:
+		sim = (maxSim(q.tokens, e.tokens) + maxSim(e.tokens, q.tokens)) / 2
+	case e.inlined:
+		sim = q.smallSim(e, c.dims)
+	default:
+		sim = c.sim(q.vec, e.vec)
+	}
+	return c.decay(q, e, c.fuse(q, e, sim))
+}
+
+// maxSim is the mean, over a, of each vector's best similarity in b.
+func maxSim(a, b []hdc.Vector) float64 {
+	var sum float64
+	for _, x := range a {
+		best := 0.0
+		for _, y := range b {
+			best = max(best, hdc.Similarity(x, y))
+		}
+		sum += best
+	}
+	return sum / float64(len(a))
+}
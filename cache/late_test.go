@@ -0,0 +1,106 @@
+package cache_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// wordEncoder is an n-gram encoder whose tokens are the words of the text.
+type wordEncoder struct {
+	*hdc.NGramEncoder
+	calls int
+}
+
+func (w *wordEncoder) EncodeTokens(text string) []hdc.Vector {
+	w.calls++
+	var out []hdc.Vector
+	for _, word := range strings.Fields(text) {
+		out = append(out, w.Encode(word))
+	}
+	return out
+}
+
+func newWordEncoder() *wordEncoder {
+	return &wordEncoder{NGramEncoder: hdc.NewNGramEncoder(hdc.DefaultConfig())}
+}
+
+func TestCache_LateInteraction(t *testing.T) {
+	enc := newWordEncoder()
+	opts := cache.Options{Threshold: 0.8, Capacity: 100, LSHEnabled: new(bool)}
+	plain := cache.New(enc, opts)
+	opts.LateInteraction = 8
+	late := cache.New(enc, opts)
+
+	key := "how do I rotate the signing keys of the staging cluster"
+	for _, c := range []*cache.Cache{plain, late} {
+		c.Set(key, 1)
+	}
+	if r := late.Lookup(key); !r.Hit || r.Similarity != 1 {
+		t.Fatalf("exact key: hit=%v sim=%.3f", r.Hit, r.Similarity)
+	}
+
+	// The query shares most words but not their order: MaxSim matches the
+	// words wherever they are.
+	query := "for the staging cluster, how do I rotate the signing keys"
+	ps, _ := plain.Similarity(query, key)
+	ls, _ := late.Similarity(query, key)
+	if ls <= ps {
+		t.Errorf("reordered query scores %.3f by MaxSim, %.3f pooled", ls, ps)
+	}
+	if r := late.Lookup(query); !r.Hit || r.Similarity != ls {
+		t.Errorf("Lookup hit=%v sim=%.3f, Similarity %.3f", r.Hit, r.Similarity, ls)
+	}
+
+	// Scoring is symmetric, so a key padded with words is not a perfect match.
+	if s, _ := late.Similarity(key+" and then restart every ingress controller", key); s >= 1 {
+		t.Errorf("padded query scores %.3f", s)
+	}
+	if got := late.Explain(query, 1); len(got) != 1 || got[0].Similarity != ls {
+		t.Errorf("Explain = %v, want similarity %.3f", got, ls)
+	}
+}
+
+func TestCache_LateInteraction_Untokenized(t *testing.T) {
+	enc := newWordEncoder()
+	c := cache.New(enc, cache.Options{Threshold: 0.8, Capacity: 10, LateInteraction: 4})
+	c.Set("alpha beta gamma delta epsilon zeta", 1)
+	before := enc.calls
+	c.SetVec("alpha beta gamma delta epsilon zeta", enc.Encode("alpha beta gamma delta epsilon zeta"), 2, 0)
+	if enc.calls != before {
+		t.Fatal("SetVec must not encode tokens")
+	}
+	// The entry is scored by its pooled vector now, like any SetVec entry.
+	want := hdc.Similarity(enc.Encode("alpha beta gamma"), enc.Encode("alpha beta gamma delta epsilon zeta"))
+	if s, _ := c.Similarity("alpha beta gamma", "alpha beta gamma delta epsilon zeta"); s != want {
+		t.Errorf("similarity after SetVec %.3f, pooled %.3f", s, want)
+	}
+}
+
+func TestNew_LateInteraction_Panics(t *testing.T) {
+	cases := map[string]struct {
+		enc  hdc.Encoder
+		opts cache.Options
+	}{
+		"no EncodeTokens": {hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{LateInteraction: 4}},
+		"too many tokens": {newWordEncoder(), cache.Options{LateInteraction: 33}},
+		"bit-sliced":      {newWordEncoder(), cache.Options{LateInteraction: 4, BitSliced: true}},
+		"over the budget": {newWordEncoder(), cache.Options{LateInteraction: 32, Capacity: 30000}},
+	}
+	for name, tc := range cases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			tc.opts.Threshold = 0.8
+			if tc.opts.Capacity == 0 {
+				tc.opts.Capacity = 10
+			}
+			cache.New(tc.enc, tc.opts)
+		}()
+	}
+}
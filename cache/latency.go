@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Latency sampling (Options.LatencySampleRate) times a random fraction of
+// encodes, lock acquisitions and scans into per-phase histograms, so
+// Stats can say whether a slow lookup is waiting on the encoder, on other
+// callers or on the comparisons.
+
+// Latency summarizes a phase's sampled durations. Quantiles are bucket
+// upper bounds, within 12.5% of the true value.
+type Latency struct {
+	Samples uint64        `json:"samples"`
+	P50     time.Duration `json:"p50_ns"`
+	P95     time.Duration `json:"p95_ns"`
+	P99     time.Duration `json:"p99_ns"`
+}
+
+// latency summarizes h, a histogram of nanoseconds.
+func latency(h *hist) Latency {
+	n, _, at := h.quantiles(0.50, 0.95, 0.99)
+	return Latency{Samples: n, P50: time.Duration(at[0]), P95: time.Duration(at[1]), P99: time.Duration(at[2])}
+}
+
+// Phases timed by latency sampling.
+type phase int
+
+const (
+	phaseEncode   phase = iota // the encoder, or the encode cache
+	phaseLockWait              // waiting for the cache lock in a lookup
+	phaseScan                  // finding the best match, LSH or scan
+	numPhases
+)
+
+// latencies holds the per-phase histograms; nil if sampling is off.
+type latencies struct {
+	rate float64
+	h    [numPhases]hist
+}
+
+// start returns the current time if this call is sampled, and the zero
+// time otherwise.
+func (l *latencies) start() time.Time {
+	if l == nil || (l.rate < 1 && rand.Float64() >= l.rate) {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// since records the time elapsed from a sampled start.
+func (l *latencies) since(p phase, start time.Time) {
+	if !start.IsZero() {
+		l.h[p].observe(uint64(time.Since(start)))
+	}
+}
@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+func TestCache_LatencySampleRate(t *testing.T) {
+	c := New(hdc.NewNGramEncoder(hdc.DefaultConfig()), Options{Threshold: 0.75, Capacity: 64, LatencySampleRate: 1})
+	for i := 0; i < 20; i++ {
+		c.Set(fmt.Sprintf("question number %d", i), i)
+		c.Get(fmt.Sprintf("question number %d", i))
+	}
+	lat := c.Stats().Latency
+	if lat.Encode.Samples != 40 || lat.LockWait.Samples != 20 || lat.Scan.Samples != 20 {
+		t.Errorf("samples: encode %d, lock %d, scan %d; want 40, 20, 20", lat.Encode.Samples, lat.LockWait.Samples, lat.Scan.Samples)
+	}
+	if lat.Encode.P50 <= 0 || lat.Encode.P50 > lat.Encode.P95 || lat.Encode.P95 > lat.Encode.P99 {
+		t.Errorf("encode percentiles out of order: %+v", lat.Encode)
+	}
+
+	off := New(hdc.NewNGramEncoder(hdc.DefaultConfig()), Options{Threshold: 0.75, Capacity: 64})
+	off.Set("key", 1)
+	off.Get("key")
+	if off.Stats().Latency.Encode.Samples != 0 {
+		t.Error("sampled with sampling off")
+	}
+}
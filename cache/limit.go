@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrBusy is returned by TryLookup when Options.MaxConcurrentScans lookups
+// are running and the wait queue is full.
+var ErrBusy = errors.New("cache: too many concurrent lookups")
+
+// scanLimiter bounds concurrent lookups: up to cap(slots) run, up to
+// maxQueue more wait for a slot, and the rest are turned away.
+type scanLimiter struct {
+	slots    chan struct{}
+	queued   atomic.Int64
+	maxQueue int64
+}
+
+func newScanLimiter(running, queue int) *scanLimiter {
+	return &scanLimiter{slots: make(chan struct{}, running), maxQueue: int64(queue)}
+}
+
+// acquire takes a slot, waiting in the queue if there is room in it, and
+// reports false if the caller should fail fast instead.
+func (l *scanLimiter) acquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if l.queued.Add(1) > l.maxQueue {
+		l.queued.Add(-1)
+		return false
+	}
+	l.slots <- struct{}{}
+	l.queued.Add(-1)
+	return true
+}
+
+func (l *scanLimiter) release() { <-l.slots }
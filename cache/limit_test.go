@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScanLimiter_QueueThenFailFast(t *testing.T) {
+	l := newScanLimiter(1, 1)
+	if !l.acquire() {
+		t.Fatal("first acquire must take the free slot")
+	}
+
+	queued := make(chan bool)
+	go func() { queued <- l.acquire() }()
+	for l.queued.Load() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if l.acquire() {
+		t.Fatal("acquire with the slot taken and the queue full must fail")
+	}
+
+	l.release()
+	if !<-queued {
+		t.Fatal("queued acquire must get the slot once it is released")
+	}
+	l.release()
+	if !l.acquire() {
+		t.Fatal("slot must be free again")
+	}
+}
@@ -70,14 +70,22 @@ func autoParams(threshold float64) (k, l int) {
 
 // hashVec computes one hash key per table for the given raw vector data.
 func (idx *lshIndex) hashVec(data []uint64) []uint64 {
-	keys := make([]uint64, idx.l)
-	for i, h := range idx.hashes {
+	return idx.hashVecInto(nil, data)
+}
+
+// hashVecInto is hashVec reusing dst's storage when it is large enough.
+func (idx *lshIndex) hashVecInto(dst, data []uint64) []uint64 {
+	keys := dst[:0]
+	if cap(keys) < idx.l {
+		keys = make([]uint64, 0, idx.l)
+	}
+	for _, h := range idx.hashes {
 		var key uint64
 		for j, pos := range h.bitPositions {
 			bit := (data[pos/64] >> uint(pos%64)) & 1
 			key |= bit << uint(j)
 		}
-		keys[i] = key
+		keys = append(keys, key)
 	}
 	return keys
 }
@@ -110,18 +118,67 @@ func (idx *lshIndex) remove(elem *list.Element, keys []uint64) {
 	}
 }
 
-// query returns deduplicated candidate elements from all L tables.
-func (idx *lshIndex) query(keys []uint64) []*list.Element {
+// removeAll removes elems from all L tables, filtering each bucket they
+// share once rather than once per element.
+func (idx *lshIndex) removeAll(elems []*list.Element) {
+	gone := make(map[*list.Element]bool, len(elems))
+	touched := make([]map[uint64]bool, len(idx.tables))
+	for i := range touched {
+		touched[i] = make(map[uint64]bool)
+	}
+	for _, elem := range elems {
+		keys := elem.Value.(*entry).lshKeys
+		if keys == nil {
+			continue
+		}
+		gone[elem] = true
+		for i, key := range keys {
+			touched[i][key] = true
+		}
+	}
+	for i, keys := range touched {
+		buckets := idx.tables[i].buckets
+		for key := range keys {
+			bucket := buckets[key]
+			kept := bucket[:0]
+			for _, e := range bucket {
+				if !gone[e] {
+					kept = append(kept, e)
+				}
+			}
+			clear(bucket[len(kept):])
+			if len(kept) == 0 {
+				delete(buckets, key)
+			} else {
+				buckets[key] = kept
+			}
+		}
+	}
+}
+
+// query returns deduplicated candidate elements from all L tables. With
+// probes > 0 it also visits, in each table, the buckets whose key differs
+// from the query's in exactly one of the first probes sampled bits
+// (multi-probe LSH), catching near neighbours that disagree on one sampled
+// bit without adding tables. probes is capped at k.
+func (idx *lshIndex) query(keys []uint64, probes int) []*list.Element {
+	probes = min(probes, idx.k)
 	seen := make(map[*list.Element]struct{})
 	var candidates []*list.Element
-	for i, key := range keys {
-		for _, elem := range idx.tables[i].buckets[key] {
+	visit := func(bucket []*list.Element) {
+		for _, elem := range bucket {
 			if _, ok := seen[elem]; !ok {
 				seen[elem] = struct{}{}
 				candidates = append(candidates, elem)
 			}
 		}
 	}
+	for i, key := range keys {
+		visit(idx.tables[i].buckets[key])
+		for j := 0; j < probes; j++ {
+			visit(idx.tables[i].buckets[key^(1<<uint(j))])
+		}
+	}
 	return candidates
 }
 
@@ -35,7 +35,7 @@ func TestLSH_InsertAndQuery(t *testing.T) {
 	keys := idx.hashVec(v.RawData())
 	idx.insert(elem, keys)
 
-	candidates := idx.query(keys)
+	candidates := idx.query(keys, 0)
 	if len(candidates) != 1 {
 		t.Fatalf("expected 1 candidate, got %d", len(candidates))
 	}
@@ -56,19 +56,50 @@ func TestLSH_Remove(t *testing.T) {
 	idx.insert(elem, keys)
 	idx.remove(elem, keys)
 
-	candidates := idx.query(keys)
+	candidates := idx.query(keys, 0)
 	if len(candidates) != 0 {
 		t.Fatalf("expected 0 candidates after remove, got %d", len(candidates))
 	}
 }
 
+func TestLSH_RemoveAll(t *testing.T) {
+	dims := 1000
+	idx := newLSHIndex(dims, 6, 10, 42)
+
+	// Equal vectors share every bucket.
+	ll := list.New()
+	v := hdc.New(dims)
+	keys := idx.hashVec(v.RawData())
+	var elems []*list.Element
+	for i := 0; i < 5; i++ {
+		elem := ll.PushFront(&entry{key: string(rune('a' + i)), lshKeys: keys})
+		idx.insert(elem, keys)
+		elems = append(elems, elem)
+	}
+	idx.removeAll([]*list.Element{elems[0], elems[2], elems[4]})
+
+	candidates := idx.query(keys, 0)
+	if len(candidates) != 2 {
+		t.Fatalf("expected 2 candidates after removeAll, got %d", len(candidates))
+	}
+	for _, c := range candidates {
+		if c != elems[1] && c != elems[3] {
+			t.Fatalf("removed element %q still indexed", c.Value.(*entry).key)
+		}
+	}
+	idx.removeAll(elems[1:4:4])
+	if n := len(idx.tables[0].buckets); n != 0 {
+		t.Fatalf("empty buckets must be deleted, %d left", n)
+	}
+}
+
 func TestLSH_EmptyIndex(t *testing.T) {
 	dims := 1000
 	idx := newLSHIndex(dims, 14, 20, 42)
 
 	v := hdc.New(dims)
 	keys := idx.hashVec(v.RawData())
-	candidates := idx.query(keys)
+	candidates := idx.query(keys, 0)
 
 	if len(candidates) != 0 {
 		t.Fatalf("empty index must return 0 candidates, got %d", len(candidates))
@@ -86,12 +117,41 @@ func TestLSH_QueryDeduplicates(t *testing.T) {
 	keys := idx.hashVec(v.RawData())
 	idx.insert(elem, keys)
 
-	candidates := idx.query(keys)
+	candidates := idx.query(keys, 0)
 	if len(candidates) != 1 {
 		t.Fatalf("query must deduplicate: expected 1, got %d", len(candidates))
 	}
 }
 
+func TestLSH_MultiProbe(t *testing.T) {
+	dims := 1000
+	idx := newLSHIndex(dims, 6, 4, 42)
+
+	ll := list.New()
+	v := hdc.New(dims)
+	elem := ll.PushFront(v)
+	keys := idx.hashVec(v.RawData())
+	idx.insert(elem, keys)
+
+	// query lands one sampled bit (bit 2) away in every table
+	near := make([]uint64, len(keys))
+	for i, k := range keys {
+		near[i] = k ^ 1<<2
+	}
+	if got := idx.query(near, 0); len(got) != 0 {
+		t.Fatalf("exact probing must miss, got %d", len(got))
+	}
+	if got := idx.query(near, 2); len(got) != 0 {
+		t.Fatalf("probes on bits 0-1 must miss, got %d", len(got))
+	}
+	if got := idx.query(near, 3); len(got) != 1 || got[0] != elem {
+		t.Fatalf("probing bit 2 must find the element, got %d", len(got))
+	}
+	if got := idx.query(near, 100); len(got) != 1 {
+		t.Fatalf("probes above k must be capped, got %d", len(got))
+	}
+}
+
 func TestAutoParams_DefaultThreshold(t *testing.T) {
 	k, l := autoParams(0.82)
 	if k < 6 || k > 24 {
@@ -0,0 +1,228 @@
+package cache
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// Mapped is a read-only cache over a snapshot file mapped into memory.
+// Vectors are scored in place in the mapping, so a multi-GB corpus costs
+// page cache rather than Go heap, and opening it does no decoding beyond an
+// index of keys and offsets. Values are decoded from JSON on each hit.
+// Lookups do a linear scan with plain Hamming similarity; there is no LSH,
+// LRU or custom metric. Safe for concurrent use; Close unmaps the file.
+type Mapped struct {
+	enc       hdc.Encoder
+	dims      int
+	nw        int
+	threshold float64
+
+	mu      sync.RWMutex // guards data against Close
+	data    []byte
+	unmap   func() error
+	entries []mappedEntry
+	hdr     header // for Verify
+}
+
+type mappedEntry struct {
+	key      string
+	vec      int   // offset of the vector words in data
+	deadline int64 // Unix nanoseconds, 0 = never expires
+	val      int   // offset of the JSON value in data
+	valLen   int
+}
+
+// OpenMapped maps the snapshot at path (as written by EncodeSnapshot) and
+// indexes its entries; its dims must match enc's. The CRC is not checked,
+// since that reads every page of the file: call Verify for that. Entries
+// are bounds-checked, so corruption can only cost wrong answers, not a
+// crash. threshold is the minimum similarity for a hit, in (0, 1]. The file
+// must not be modified while mapped; replace it by rename instead.
+func OpenMapped(path string, enc hdc.Encoder, threshold float64) (*Mapped, error) {
+	if enc == nil {
+		panic("cache: encoder must not be nil")
+	}
+	if threshold <= 0 || threshold > 1 {
+		panic("cache: threshold must be in (0, 1]")
+	}
+	dims := enc.Encode("").Dims()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cache: open mapped: %w", err)
+	}
+	defer f.Close()
+	data, unmap, err := mapFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("cache: open mapped: %w", err)
+	}
+	m := &Mapped{enc: enc, dims: dims, nw: hdc.NumWords(dims), threshold: threshold, data: data, unmap: unmap}
+	if err := m.index(); err != nil {
+		unmap()
+		return nil, fmt.Errorf("cache: open mapped: %w", err)
+	}
+	return m, nil
+}
+
+// index validates the header and records where each entry lives.
+func (m *Mapped) index() error {
+	if len(m.data) < baseHeaderSize {
+		return fmt.Errorf("file too short for header (%d bytes)", len(m.data))
+	}
+	h, err := parseHeader(m.data[:baseHeaderSize], m.dims)
+	if err != nil {
+		return err
+	}
+	if len(m.data) < h.size() {
+		return fmt.Errorf("file too short for header (%d bytes)", len(m.data))
+	}
+	h.parseExt(m.data[:h.size()])
+	if h.compressed() {
+		return fmt.Errorf("cache: %w: compressed snapshots cannot be mapped; save with EncodeSnapshot", ErrIncompatibleSnapshot)
+	}
+	if fp := Fingerprint(m.enc); h.encoder != 0 && h.encoder != fp {
+		return fmt.Errorf("cache: %w: encoder fingerprint %016x does not match file's %016x", ErrIncompatibleSnapshot, fp, h.encoder)
+	}
+	m.hdr = h
+
+	m.entries = make([]mappedEntry, 0, h.count)
+	off := h.size()
+	u32 := func() (int, error) {
+		if off+4 > len(m.data) {
+			return 0, fmt.Errorf("truncated at offset %d", off)
+		}
+		v := binary.LittleEndian.Uint32(m.data[off:])
+		off += 4
+		return int(v), nil
+	}
+	skip := func(n int) (int, error) {
+		if n < 0 || off+n > len(m.data) {
+			return 0, fmt.Errorf("truncated at offset %d", off)
+		}
+		start := off
+		off += n
+		return start, nil
+	}
+	for i := 0; i < h.count; i++ {
+		var e mappedEntry
+		keyLen, err := u32()
+		if err == nil && keyLen > maxKeyLen {
+			err = fmt.Errorf("key length %d exceeds maximum %d", keyLen, maxKeyLen)
+		}
+		var keyOff, tsOff int
+		if err == nil {
+			keyOff, err = skip(keyLen)
+		}
+		if err == nil {
+			e.vec, err = skip(m.nw * 8)
+		}
+		if err == nil {
+			tsOff, err = skip(16)
+		}
+		if err == nil {
+			e.valLen, err = u32()
+		}
+		if err == nil && e.valLen > maxValLen {
+			err = fmt.Errorf("value length %d exceeds maximum %d", e.valLen, maxValLen)
+		}
+		if err == nil {
+			e.val, err = skip(e.valLen)
+		}
+		if err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+		e.key = string(m.data[keyOff : keyOff+keyLen])
+		e.deadline = int64(binary.LittleEndian.Uint64(m.data[tsOff+8:]))
+		m.entries = append(m.entries, e)
+	}
+	return nil
+}
+
+// Get returns the value of the most similar live entry if it reaches the
+// threshold: (value, true, similarity) on a hit, (nil, false, 0) on a miss.
+func (m *Mapped) Get(key string) (any, bool, float64) {
+	_, value, ok, sim := m.GetMatch(key)
+	return value, ok, sim
+}
+
+// GetMatch is Get that also returns the key of the entry matched.
+func (m *Mapped) GetMatch(key string) (string, any, bool, float64) {
+	q := m.enc.Encode(key).RawData()
+	maxHam := int(math.Floor((1-m.threshold)*float64(m.dims) + 1e-9))
+	now := time.Now().UnixNano()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.data == nil {
+		return "", nil, false, 0
+	}
+	best, bestHam := -1, maxHam+1
+	for i := range m.entries {
+		e := &m.entries[i]
+		if e.deadline != 0 && now > e.deadline {
+			continue
+		}
+		if ham := m.hamming(q, e.vec, bestHam); ham < bestHam {
+			best, bestHam = i, ham
+		}
+	}
+	if best < 0 {
+		return "", nil, false, 0
+	}
+	e := &m.entries[best]
+	var value any
+	if err := json.Unmarshal(m.data[e.val:e.val+e.valLen], &value); err != nil {
+		return "", nil, false, 0 // corrupt; Verify would have failed
+	}
+	return e.key, value, true, 1 - float64(bestHam)/float64(m.dims)
+}
+
+// hamming counts the bits where q differs from the vector at off, giving up
+// once the count reaches limit.
+func (m *Mapped) hamming(q []uint64, off, limit int) int {
+	ham := 0
+	for i, w := range q {
+		ham += bits.OnesCount64(w ^ binary.LittleEndian.Uint64(m.data[off+8*i:]))
+		if ham >= limit && i%16 == 15 {
+			return ham
+		}
+	}
+	return ham
+}
+
+// Verify checks the file against its CRC, reading all of it: call it at
+// open when a corrupt file must not serve, or in the background after a
+// fast open. It returns ErrClosed after Close.
+func (m *Mapped) Verify() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.data == nil {
+		return ErrClosed
+	}
+	return m.hdr.checkCRC(m.data[m.hdr.size():])
+}
+
+// Len returns the number of entries in the file, expired or not.
+func (m *Mapped) Len() int { return len(m.entries) }
+
+// Dims returns the vector dimensionality.
+func (m *Mapped) Dims() int { return m.dims }
+
+// Close unmaps the file. Lookups after Close miss.
+func (m *Mapped) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.data == nil {
+		return nil
+	}
+	m.data = nil
+	return m.unmap()
+}
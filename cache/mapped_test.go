@@ -0,0 +1,130 @@
+package cache_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func writeSnapshotFile(t *testing.T, c *cache.Cache) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "snap.xrdb")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := cache.EncodeSnapshot(f, c.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMapped_MatchesCache(t *testing.T) {
+	c := newTestCache(10, 0.65)
+	c.Set("what is the capital of india", "Delhi")
+	c.Set("how do I reset my password", map[string]any{"answer": "Settings"})
+	c.Set("recipe for chocolate cake", 3.5)
+	m, err := cache.OpenMapped(writeSnapshotFile(t, c), hdc.NewNGramEncoder(hdc.DefaultConfig()), 0.65)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if m.Len() != 3 {
+		t.Fatalf("want 3 entries, got %d", m.Len())
+	}
+	for _, q := range []string{"capital city of india", "how to reset my password", "recipe for chocolate cake", "weather in tokyo"} {
+		wv, wok, wsim := c.Get(q)
+		v, ok, sim := m.Get(q)
+		if ok != wok || sim != wsim {
+			t.Fatalf("%q: mapped (%v, %v), cache (%v, %v)", q, ok, sim, wok, wsim)
+		}
+		if ok && !equalJSON(v, wv) {
+			t.Fatalf("%q: mapped value %v, cache %v", q, v, wv)
+		}
+	}
+}
+
+// equalJSON compares a value with its JSON round trip, as Mapped returns it.
+func equalJSON(got, want any) bool {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		return ok && len(g) == len(w) && g["answer"] == w["answer"]
+	default:
+		return got == want
+	}
+}
+
+func TestMapped_SkipsExpired(t *testing.T) {
+	c := newTestCache(10, 0.75)
+	c.SetWithTTL("what is the capital of india", "Delhi", time.Hour)
+	c.SetWithTTL("how to bake a chocolate cake", "Oven", time.Hour)
+	snap := c.Snapshot()
+	for i := range snap.Entries {
+		if snap.Entries[i].Key == "how to bake a chocolate cake" {
+			snap.Entries[i].Deadline = time.Now().Add(-time.Minute) // expired since it was written
+		}
+	}
+	path := filepath.Join(t.TempDir(), "snap.xrdb")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.EncodeSnapshot(f, snap); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	m, err := cache.OpenMapped(path, hdc.NewNGramEncoder(hdc.DefaultConfig()), 0.75)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if _, ok, _ := m.Get("what is the capital of india"); !ok {
+		t.Fatal("live entry must hit")
+	}
+	if _, ok, _ := m.Get("how to bake a chocolate cake"); ok {
+		t.Fatal("expired entry must miss")
+	}
+}
+
+func TestMapped_Corrupt(t *testing.T) {
+	c := newTestCache(10, 0.75)
+	c.Set("alpha", "A")
+	path := writeSnapshotFile(t, c)
+	b, _ := os.ReadFile(path)
+	b[len(b)-1] ^= 0xff
+	os.WriteFile(path, b, 0o644)
+	m, err := cache.OpenMapped(path, hdc.NewNGramEncoder(hdc.DefaultConfig()), 0.75)
+	if err != nil {
+		t.Fatalf("open must not read the whole file: %v", err)
+	}
+	defer m.Close()
+	if err := m.Verify(); err == nil {
+		t.Fatal("corrupt file must fail Verify")
+	}
+}
+
+func TestMapped_Close(t *testing.T) {
+	c := newTestCache(10, 0.75)
+	c.Set("alpha", "A")
+	m, err := cache.OpenMapped(writeSnapshotFile(t, c), hdc.NewNGramEncoder(hdc.DefaultConfig()), 0.75)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := m.Get("alpha"); ok {
+		t.Fatal("lookups after Close must miss")
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package cache
+
+// The miss filter (Options.MissFilter) answers lookups that the popcount
+// prefilter would reject for every entry without visiting any of them.
+// An entry can only match a query whose popcount is within maxHam of its
+// own (see prefilter.go), so a count of entries by popcount tells, in
+// O(log dims), whether any entry is even plausible. Keys that encode far
+// from everything stored — other languages, very short or very long
+// inputs — then miss without a scan, and with LSH without the fallback
+// scan either. Expired and filtered-out entries still count, so the
+// filter never reports a miss that a scan would have hit.
+
+// missFilter counts entries by popcount in a Fenwick tree. It is updated
+// and read under the cache lock.
+type missFilter struct {
+	tree []int // tree[i] sums a power-of-two range of popcounts ending at i-1
+}
+
+func newMissFilter(dims int) *missFilter {
+	return &missFilter{tree: make([]int, dims+2)}
+}
+
+// add counts d more entries (d may be negative) with popcount pc.
+func (f *missFilter) add(pc, d int) {
+	for i := pc + 1; i < len(f.tree); i += i & -i {
+		f.tree[i] += d
+	}
+}
+
+// below returns the number of entries with popcount less than pc.
+func (f *missFilter) below(pc int) int {
+	n := 0
+	for i := min(pc, len(f.tree)-1); i > 0; i -= i & -i {
+		n += f.tree[i]
+	}
+	return n
+}
+
+// plausible reports whether any entry may be within q's prefilter bound.
+func (f *missFilter) plausible(q *query) bool {
+	return f.below(q.pc+q.maxHam+1) > f.below(max(q.pc-q.maxHam, 0))
+}
@@ -0,0 +1,40 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestCache_MissFilter(t *testing.T) {
+	enc := onesEncoder{"dense": 1024, "dense2": 1000, "empty": 0, "sparse": 100, "boundary": 1024 - 256}
+	for _, lsh := range []bool{false, true} {
+		c := cache.New(enc, cache.Options{Threshold: 0.75, Capacity: 16, LSHEnabled: &lsh, MissFilter: true})
+		c.Set("dense", 1)
+		c.Set("dense2", 2)
+
+		if _, ok, _ := c.Get("empty"); ok {
+			t.Fatal("empty vs dense must miss")
+		}
+		if st := c.Stats(); st.DefiniteMisses != 1 || st.PerLookup.Candidates.Mean != 0 {
+			t.Fatalf("lsh=%v: %d definite misses after looking at %v entries, want 1 and 0",
+				lsh, st.DefiniteMisses, st.PerLookup.Candidates.Mean)
+		}
+		if _, ok, sim := c.Get("boundary"); !ok || sim < 0.75 {
+			t.Fatalf("lsh=%v: plausible hit ruled out, ok=%v sim=%f", lsh, ok, sim)
+		}
+
+		// Replaced and removed vectors leave the filter.
+		c.Delete("dense2")
+		c.SetVec("dense", enc.Encode("sparse"), 1, 0)
+		if _, ok, _ := c.Get("empty"); !ok {
+			t.Fatalf("lsh=%v: the new vector must be found", lsh)
+		}
+		if _, ok, _ := c.Get("dense"); ok {
+			t.Fatalf("lsh=%v: the replaced vector still matched", lsh)
+		}
+		if st := c.Stats(); st.DefiniteMisses != 2 {
+			t.Fatalf("lsh=%v: %d definite misses, want 2", lsh, st.DefiniteMisses)
+		}
+	}
+}
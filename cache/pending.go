@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"fmt"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// ErrPending is returned by LoadSnapshot while a Pending encoder is still
+// loading: the snapshot's encoder fingerprint cannot be checked yet.
+var ErrPending = fmt.Errorf("cache: %w: still loading", ErrEncoderUnavailable)
+
+// Pending is implemented by encoders still being set up, e.g. a model read
+// from disk in the background (xordb.NewAsync). New takes the vector dims
+// from Dims rather than by encoding a probe, and until Ready reports true
+// the encoder is not called: keys are encoded by Options.FallbackEncoder
+// if there is one, and otherwise Set stores nothing and lookups miss.
+// Snapshots taken meanwhile record no encoder fingerprint.
+type Pending interface {
+	hdc.Encoder
+	Dims() int
+	Ready() bool
+}
+
+// loading reports whether the encoder is a Pending one not yet ready.
+func (c *Cache) loading() bool { return c.pendingEnc != nil && !c.pendingEnc.Ready() }
+
+// callEnc is call(c.enc, key), or the fallback's call while the encoder is
+// loading.
+func (c *Cache) callEnc(key string) (hdc.Vector, bool) {
+	if !c.loading() {
+		return c.call(c.enc, key)
+	}
+	if c.encFallback != nil {
+		return c.call(c.encFallback, key)
+	}
+	return hdc.Vector{}, false
+}
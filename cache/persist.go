@@ -17,23 +17,50 @@ type EntrySnapshot struct {
 	Value    any
 	Ts       time.Time
 	Deadline time.Time // zero = never expires
+
+	// Not saved in snapshots: the SetWithSource tag, and the lookups the
+	// entry answered since it was last set.
+	Source string
+	Hits   uint64
 }
 
 // Snapshot is a serializable point-in-time copy of the cache state.
 // Use Cache.Snapshot() to create and Cache.LoadSnapshot() to restore.
 type Snapshot struct {
-	Version  int
+	Version int
+
+	// ID identifies the cache state the snapshot captured; DeltaSnapshot
+	// takes it as a base. It is not written by EncodeSnapshot.
+	ID uint64
+
+	// Encoder is the Fingerprint of the encoder that produced the vectors;
+	// LoadSnapshot rejects a mismatch. 0 = unknown, not checked.
+	Encoder uint64
+
 	Dims     int
 	Capacity int
 	Entries  []EntrySnapshot // MRU order — index 0 is most recently used
+
+	// Tombstones are recent explicit deletes (Options.TombstoneTTL),
+	// applied before Entries on load.
+	Tombstones []Tombstone
+
+	// Counters are the cache's lifetime stats when the snapshot was taken,
+	// restored by LoadSnapshot; nil in files written before they existed.
+	Counters *Counters
 }
 
 // Snapshot returns a point-in-time serializable copy of the cache.
 // Expired entries are skipped.
 func (c *Cache) Snapshot() Snapshot {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
+	return c.snapshotLocked(0)
+}
 
+// snapshotLocked copies the entries and tombstones written after stamp
+// since (0 = all), reaping expired entries on the way.
+func (c *Cache) snapshotLocked(since uint64) Snapshot {
 	now := time.Now()
 	var expired []*list.Element
 	entries := make([]EntrySnapshot, 0, c.lru.Len())
@@ -43,42 +70,77 @@ func (c *Cache) Snapshot() Snapshot {
 			expired = append(expired, elem)
 			continue
 		}
+		if e.wrote <= since {
+			continue
+		}
+		value, err := c.valueLocked(e)
+		if err != nil {
+			continue
+		}
 		entries = append(entries, EntrySnapshot{
 			Key:      e.key,
-			VecData:  e.vec.Data(),
-			Value:    e.value,
+			VecData:  c.vecData(e),
+			Value:    value,
 			Ts:       e.ts,
 			Deadline: e.deadline,
+			Source:   e.source,
+			Hits:     e.hits,
 		})
 	}
 
 	for _, elem := range expired {
-		c.removeLocked(elem)
-		c.expired++
+		c.expireLocked(elem)
 	}
 
 	return Snapshot{
-		Version:  snapshotVersion,
-		Dims:     c.dims,
-		Capacity: c.capacity,
-		Entries:  entries,
+		Version:    snapshotVersion,
+		ID:         c.wclock,
+		Encoder:    c.fingerprint(),
+		Dims:       c.dims,
+		Capacity:   c.capacity,
+		Entries:    entries,
+		Tombstones: c.tombstonesLocked(now, since),
+		Counters:   c.stats.lifetime(),
 	}
 }
 
 // LoadSnapshot merges a snapshot into the live cache.
-// Entries that are already expired at load time are skipped.
-// Existing keys are overwritten. Returns an error on version or dims mismatch.
+// Entries that are already expired at load time are skipped. Tombstones in
+// the snapshot remove older cached copies of their keys, and an entry is not
+// loaded if either side recorded a delete of its key after it was written.
+// Existing keys are overwritten. Saved lifetime counters raise the live ones
+// unless Options.IgnoreSavedCounters is set. A snapshot of another version,
+// dims or encoder fails with ErrIncompatibleSnapshot.
 func (c *Cache) LoadSnapshot(s Snapshot) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
 	if s.Version != snapshotVersion {
-		return fmt.Errorf("cache: snapshot version %d unsupported (want %d)", s.Version, snapshotVersion)
+		return fmt.Errorf("cache: %w: version %d unsupported (want %d)", ErrIncompatibleSnapshot, s.Version, snapshotVersion)
 	}
 	if s.Dims != 0 && s.Dims != c.dims {
-		return fmt.Errorf("cache: snapshot dims %d does not match cache dims %d", s.Dims, c.dims)
+		return fmt.Errorf("cache: %w: %w", ErrIncompatibleSnapshot, &DimsError{What: "snapshot", Got: s.Dims, Want: c.dims})
+	}
+	if s.Encoder != 0 && c.loading() {
+		return ErrPending
+	}
+	if s.Encoder != 0 && s.Encoder != c.fingerprint() {
+		return fmt.Errorf("cache: %w: encoder fingerprint %016x does not match cache encoder %016x", ErrIncompatibleSnapshot, s.Encoder, c.fingerprint())
 	}
 
 	now := time.Now()
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
+
+	if s.Counters != nil && !c.freshStats {
+		c.stats.restore(s.Counters)
+	}
+
+	deleted := make(map[string]time.Time, len(s.Tombstones))
+	for _, t := range s.Tombstones {
+		c.applyTombstoneLocked(t, now)
+		deleted[t.Key] = t.DeletedAt
+	}
 
 	// Inject in reverse (LRU-first) so that the MRU entry ends up at the
 	// front of the list after all inserts.
@@ -87,39 +149,54 @@ func (c *Cache) LoadSnapshot(s Snapshot) error {
 		if !es.Deadline.IsZero() && now.After(es.Deadline) {
 			continue // already expired
 		}
+		if at, ok := deleted[es.Key]; ok && es.Ts.Before(at) {
+			continue // deleted after this copy was written
+		}
+		if t, ok := c.tombs[es.Key]; ok && es.Ts.Before(t.at) {
+			continue // deleted here since the snapshot was taken
+		}
 		if len(es.VecData) != hdc.NumWords(c.dims) {
 			return fmt.Errorf("cache: entry %q: VecData length %d != expected %d",
 				es.Key, len(es.VecData), hdc.NumWords(c.dims))
 		}
-		c.injectLocked(es)
+		c.injectLocked(es, now)
 	}
 	return nil
 }
 
 // injectLocked inserts an EntrySnapshot directly, bypassing the encoder.
 // Must be called with c.mu held.
-func (c *Cache) injectLocked(es EntrySnapshot) {
+func (c *Cache) injectLocked(es EntrySnapshot, now time.Time) {
 	// Overwrite if key already exists.
-	if elem, ok := c.index[es.Key]; ok {
+	if elem, ok := c.index.Get(es.Key); ok {
 		c.removeLocked(elem)
 	}
-	if c.lru.Len() >= c.capacity {
-		c.evictLocked()
+	if c.pool != nil {
+		c.groupRoomLocked(es.Key)
+	} else if c.lru.Len() >= c.capacity {
+		c.evictLocked(1)
 	}
 	vec := hdc.FromWords(c.dims, es.VecData)
-	e := &entry{
-		key:      es.Key,
-		vec:      vec,
-		value:    es.Value,
-		ts:       es.Ts,
-		deadline: es.Deadline,
+	key := c.internLocked(es.Key)
+	e := c.newEntryLocked()
+	e.key, e.ts, e.deadline = key, es.Ts, es.Deadline
+	c.setValueLocked(e, es.Value)
+	e.wrote = c.tickLocked(now) // loaded since any earlier snapshot of this cache
+	if !e.deadline.IsZero() {
+		e.ttl = c.ttl // snapshots keep deadlines, not TTLs; slide by the default
 	}
+	c.setVecLocked(e, vec)
 	if c.lsh != nil {
-		e.lshKeys = c.lsh.hashVec(vec.RawData())
+		e.lshKeys = c.lsh.hashVecInto(e.lshKeys, e.words())
 	}
 	elem := c.lru.PushFront(e)
-	c.index[es.Key] = elem
+	c.trackLocked(elem)
+	c.joinLocked(elem)
+	c.orderLocked(e)
+	c.index.Put(key, elem)
+	c.stats.entries.Add(1)
 	if c.lsh != nil {
 		c.lsh.insert(elem, e.lshKeys)
 	}
+	c.coolLocked()
 }
@@ -1,6 +1,7 @@
 package cache_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -131,8 +132,8 @@ func TestSnapshot_VersionMismatch(t *testing.T) {
 	snap.Version = 99
 
 	c2 := newTestCache(10, 0.99)
-	if err := c2.LoadSnapshot(snap); err == nil {
-		t.Fatal("expected error on version mismatch")
+	if err := c2.LoadSnapshot(snap); !errors.Is(err, cache.ErrIncompatibleSnapshot) {
+		t.Fatalf("expected ErrIncompatibleSnapshot on version mismatch, got %v", err)
 	}
 }
 
@@ -144,8 +145,10 @@ func TestSnapshot_DimsMismatch(t *testing.T) {
 
 	enc2 := hdc.NewNGramEncoder(hdc.Config{Dims: 2000, NGramSize: 3, LongTextThresh: 200, ChunkSize: 128})
 	c2 := cache.New(enc2, cache.Options{Capacity: 10, Threshold: 0.99})
-	if err := c2.LoadSnapshot(snap); err == nil {
-		t.Fatal("expected error on dims mismatch")
+	err := c2.LoadSnapshot(snap)
+	var de *cache.DimsError
+	if !errors.Is(err, cache.ErrIncompatibleSnapshot) || !errors.As(err, &de) || de.Got != 1000 || de.Want != 2000 {
+		t.Fatalf("expected a DimsError on dims mismatch, got %v", err)
 	}
 }
 
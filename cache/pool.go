@@ -0,0 +1,28 @@
+package cache
+
+// maxFreeEntries bounds the entry free list. In a full cache every insert
+// follows an eviction, so even a short list absorbs steady churn.
+const maxFreeEntries = 64
+
+// newEntryLocked returns a zeroed entry, reusing a removed one when
+// available. A reused entry keeps its lshKeys buffer for hashVecInto.
+func (c *Cache) newEntryLocked() *entry {
+	if n := len(c.free); n > 0 {
+		e := c.free[n-1]
+		c.free[n-1] = nil
+		c.free = c.free[:n-1]
+		c.stats.entryReuses.Add(1)
+		return e
+	}
+	c.stats.entryAllocs.Add(1)
+	return new(entry)
+}
+
+// releaseLocked recycles e once it is out of the list, index and LSH tables.
+func (c *Cache) releaseLocked(e *entry) {
+	if len(c.free) >= maxFreeEntries {
+		return
+	}
+	*e = entry{lshKeys: e.lshKeys[:0], coarse: e.coarse[:0]} // drop key, vector and value for the GC
+	c.free = append(c.free, e)
+}
@@ -0,0 +1,46 @@
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestPool_ChurnReusesEntries(t *testing.T) {
+	for _, lsh := range []bool{false, true} {
+		c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.95, Capacity: 8, LSHEnabled: &lsh})
+		for i := 0; i < 100; i++ {
+			c.Set(fmt.Sprintf("churn key number %d", i), i)
+		}
+		s := c.Stats()
+		if s.EntryAllocs != 8 || s.EntryReuses != 92 {
+			t.Fatalf("lsh=%v: want 8 allocs and 92 reuses, got %d/%d", lsh, s.EntryAllocs, s.EntryReuses)
+		}
+
+		// recycled entries must not leak old state into lookups
+		for i := 92; i < 100; i++ {
+			k := fmt.Sprintf("churn key number %d", i)
+			if v, ok, _ := c.Get(k); !ok || v != i {
+				t.Fatalf("lsh=%v: %q: got %v %v", lsh, k, v, ok)
+			}
+		}
+		if _, ok, sim := c.Get("churn key number 3"); ok && sim == 1 {
+			t.Fatalf("lsh=%v: evicted key must not hit exactly", lsh)
+		}
+	}
+}
+
+func BenchmarkCache_Set_Churn(b *testing.B) {
+	c := newCache(0.82, 256)
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("churn key %d", i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Set(keys[i%len(keys)], i)
+	}
+}
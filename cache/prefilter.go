@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"math"
+	"math/bits"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// query is an encoded lookup plus what the popcount prefilter needs.
+//
+// Two vectors differ in at least |popcount(a) - popcount(b)| bits, so an
+// entry whose popcount is further than maxHam from the query's cannot reach
+// the threshold and is skipped without a full comparison. Skipped entries
+// are not considered for the miss event's nearest key.
+type query struct {
+	vec       hdc.Vector
+	pc        int
+	maxHam    int     // most differing bits a hit may have at the threshold
+	threshold float64 // a hit's minimum score
+
+	coarse    []uint64          // the query's coarse copy; nil without Options.CoarseBits
+	tokens    []hdc.Vector      // the key's token vectors, see late.go
+	lex       []uint32          // the key's word hashes, see fusion.go
+	maxCoarse int               // see coarseLimit
+	now       time.Time         // the lookup's time, for Options.DecayHalfLife
+	match     func(string) bool // see LookupWhere; nil considers every entry
+
+	inline [smallWords]uint64 // vec's words when the cache stores vectors inline
+
+	// Tallies for Stats.PerLookup: entries the prefilter skipped and
+	// entries it passed on to a full comparison.
+	pruned, compared uint64
+}
+
+func (c *Cache) newQueryLocked(vec hdc.Vector, threshold float64) query {
+	// sim = 1 - ham/dims >= threshold  ⇔  ham <= (1-threshold)·dims; the
+	// epsilon keeps float rounding from pruning an exact-threshold hit.
+	// With a Margin, runner-ups down to threshold-margin must be scored too,
+	// as must entries down to Options.SoftMiss, and with score fusion
+	// whatever word overlap could make up for.
+	minSim := threshold - c.margin
+	if c.softMiss > 0 {
+		minSim = min(minSim, c.softMiss)
+	}
+	floor := c.semanticFloor(minSim)
+	maxHam := min(int(math.Floor((1-floor)*float64(c.dims)+1e-9)), c.dims)
+	if c.customSim || c.late > 0 {
+		maxHam = c.dims // no bound holds for an arbitrary metric or MaxSim
+	}
+	q := query{vec: vec, pc: popcount(vec), maxHam: maxHam, threshold: threshold}
+	if c.small {
+		copy(q.inline[:], vec.RawData())
+	}
+	if c.halfLife > 0 {
+		q.now = time.Now()
+	}
+	if c.coarseWords > 0 {
+		q.coarse = coarseInto(nil, vec, c.coarseWords)
+		q.maxCoarse = coarseLimit(floor, c.coarseWords)
+	}
+	return q
+}
+
+// matches reports whether the query may return e.
+func (q *query) matches(e *entry) bool {
+	return q.match == nil || q.match(e.key)
+}
+
+// prunes reports whether e is certainly below the threshold, or, with the
+// coarse filter, almost certainly.
+func (q *query) prunes(e *entry) bool {
+	d := e.pc - q.pc
+	if d < 0 {
+		d = -d
+	}
+	return d > q.maxHam || (q.coarse != nil && q.coarseFar(e))
+}
+
+func popcount(v hdc.Vector) int {
+	n := 0
+	for _, w := range v.RawData() {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
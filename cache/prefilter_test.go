@@ -0,0 +1,59 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// onesEncoder maps a key to a 1024-bit vector whose first n bits are set,
+// where n is looked up in the map.
+type onesEncoder map[string]int
+
+func (e onesEncoder) Encode(key string) hdc.Vector {
+	words := make([]uint64, hdc.NumWords(1024))
+	for i := 0; i < e[key]; i++ {
+		words[i/64] |= 1 << (i % 64)
+	}
+	return hdc.FromWords(1024, words)
+}
+
+func TestPrefilter_PrunesAndKeepsBoundaryHit(t *testing.T) {
+	enc := onesEncoder{"dense": 1024, "empty": 0, "boundary": 1024 - 256}
+	for _, lsh := range []bool{false, true} {
+		c := cache.New(enc, cache.Options{Threshold: 0.75, Capacity: 16, LSHEnabled: &lsh})
+		c.Set("dense", 1)
+
+		if _, ok, _ := c.Get("empty"); ok {
+			t.Fatal("empty vs dense must miss")
+		}
+		if c.Stats().Pruned == 0 {
+			t.Fatalf("lsh=%v: popcount gap 1024 > 256 must be pruned", lsh)
+		}
+
+		// differs in exactly 256 of 1024 bits: sim = 0.75, exactly the threshold
+		_, ok, sim := c.Get("boundary")
+		if !ok || sim != 0.75 {
+			t.Fatalf("lsh=%v: exact-threshold hit must not be pruned, ok=%v sim=%f", lsh, ok, sim)
+		}
+	}
+}
+
+func TestCache_PerLookup(t *testing.T) {
+	enc := onesEncoder{"dense": 1024, "dense2": 1000, "empty": 0, "almost": 1010}
+	lsh := false
+	c := cache.New(enc, cache.Options{Threshold: 0.75, Capacity: 16, LSHEnabled: &lsh})
+	c.Set("dense", 1)
+	c.Set("dense2", 2)
+
+	c.Get("empty")  // both pruned
+	c.Get("almost") // both compared
+	per := c.Stats().PerLookup
+	if per.Candidates.Mean != 2 || per.Candidates.P99 != 2 {
+		t.Errorf("candidates %+v, want mean 2, p99 2", per.Candidates)
+	}
+	if per.Compared.Mean != 1 || per.Compared.P99 != 2 {
+		t.Errorf("compared %+v, want mean 1, p99 2", per.Compared)
+	}
+}
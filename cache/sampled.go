@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"container/list"
+	"math/rand/v2"
+)
+
+// Sampled eviction (Options.EvictionSamples) approximates LRU the way Redis
+// does: a hit stamps the entry with a logical clock instead of moving it in
+// the list, and eviction removes the stalest of a few randomly sampled
+// entries. The list then keeps insertion order and is only used for scans.
+
+// touchLocked records an access to elem.
+func (c *Cache) touchLocked(elem *list.Element) {
+	if c.samples == 0 {
+		c.unedgeLocked(elem)
+		c.lru.MoveToFront(elem)
+		if e := elem.Value.(*entry); e.gelem != nil {
+			e.group.lru.MoveToFront(e.gelem)
+		}
+		return
+	}
+	c.clock++
+	elem.Value.(*entry).access = c.clock
+}
+
+// trackLocked registers a newly inserted elem for sampling.
+func (c *Cache) trackLocked(elem *list.Element) {
+	if c.samples == 0 {
+		return
+	}
+	e := elem.Value.(*entry)
+	e.slot = len(c.slots)
+	c.slots = append(c.slots, elem)
+	c.touchLocked(elem)
+}
+
+// untrackLocked drops e from the sample set by swapping in the last slot.
+func (c *Cache) untrackLocked(e *entry) {
+	if c.samples == 0 {
+		return
+	}
+	last := len(c.slots) - 1
+	moved := c.slots[last]
+	c.slots[e.slot] = moved
+	moved.Value.(*entry).slot = e.slot
+	c.slots[last] = nil
+	c.slots = c.slots[:last]
+}
+
+// sampleVictimLocked returns the least recently accessed unpinned entry
+// among c.samples random ones. If every sample is pinned it falls back to
+// the first unpinned entry in the list, and returns nil if there is none.
+func (c *Cache) sampleVictimLocked() *list.Element {
+	var victim *list.Element
+	var oldest uint64
+	for i := 0; i < c.samples && len(c.slots) > 0; i++ {
+		elem := c.slots[rand.IntN(len(c.slots))]
+		e := elem.Value.(*entry)
+		if !e.pinned && (victim == nil || e.access < oldest) {
+			victim, oldest = elem, e.access
+		}
+	}
+	if victim != nil {
+		return victim
+	}
+	for elem := c.lru.Back(); elem != nil; elem = elem.Prev() {
+		if !elem.Value.(*entry).pinned {
+			return elem
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// minScanChunk keeps chunks large enough that goroutine handoff stays small
+// next to the similarity work.
+const minScanChunk = 256
+
+type scanResult struct {
+	best     *list.Element
+	bestSim  float64
+	near     nearMiss
+	pruned   uint64
+	compared uint64
+	expired  []*list.Element
+}
+
+// parallelScanLocked is scanLocked split across workers. The list is
+// flattened (MRU first) and each worker scores one contiguous chunk; the
+// lock stays held, so workers only read entries. Results are merged in chunk
+// order, which picks the same entry as a serial scan on ties, and expired
+// entries are reaped afterwards in list order.
+func (c *Cache) parallelScanLocked(q *query, near *nearMiss) (*list.Element, float64) {
+	c.scanBuf = c.scanBuf[:0]
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		c.scanBuf = append(c.scanBuf, elem)
+	}
+	elems := c.scanBuf
+
+	workers := min(c.scanWorkers, (len(elems)+minScanChunk-1)/minScanChunk)
+	chunk := (len(elems) + workers - 1) / workers
+	results := make([]scanResult, workers)
+	threshold := q.threshold
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		lo, hi := w*chunk, min((w+1)*chunk, len(elems))
+		wg.Add(1)
+		go func(r *scanResult, part []*list.Element) {
+			defer wg.Done()
+			for _, elem := range part {
+				e := elem.Value.(*entry)
+				if c.isExpired(e, now) {
+					r.expired = append(r.expired, elem)
+					continue
+				}
+				if !c.accepts(e) || !q.matches(e) {
+					continue
+				}
+				if q.prunes(e) {
+					r.pruned++
+					continue
+				}
+				r.compared++
+				s := c.score(q, e)
+				if s >= threshold && s > r.bestSim {
+					r.bestSim = s
+					r.best = elem
+				}
+				r.near.observe(e.key, s)
+			}
+		}(&results[w], elems[lo:hi])
+	}
+	wg.Wait()
+	clear(c.scanBuf) // drop references to removed elements
+
+	var bestElem *list.Element
+	var bestSim float64
+	for i := range results {
+		r := &results[i]
+		if r.best != nil && r.bestSim > bestSim {
+			bestElem, bestSim = r.best, r.bestSim
+		}
+		near.merge(r.near)
+		q.pruned += r.pruned
+		q.compared += r.compared
+		for _, elem := range r.expired {
+			c.expireLocked(elem)
+		}
+	}
+	return bestElem, bestSim
+}
@@ -0,0 +1,87 @@
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func newScanCaches(capacity int) (serial, parallel *cache.Cache) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	serial = cache.New(enc, cache.Options{Threshold: 0.70, Capacity: capacity, LSHEnabled: new(bool)})
+	parallel = cache.New(enc, cache.Options{Threshold: 0.70, Capacity: capacity, LSHEnabled: new(bool),
+		ParallelScanMin: 1, ScanWorkers: 4})
+	return serial, parallel
+}
+
+func TestParallelScan_MatchesSerial(t *testing.T) {
+	serial, parallel := newScanCaches(2000)
+	for i := 0; i < 1500; i++ {
+		k := fmt.Sprintf("question number %d about topic %d", i, i%37)
+		serial.Set(k, i)
+		parallel.Set(k, i)
+	}
+
+	for _, q := range []string{
+		"question number 42 about topic 5",
+		"question number 1499 about topic 19",
+		"question 7 about topic 7",
+		"something unrelated entirely",
+	} {
+		sv, sok, ssim := serial.Get(q)
+		pv, pok, psim := parallel.Get(q)
+		if sv != pv || sok != pok || ssim != psim {
+			t.Fatalf("%q: serial (%v %v %.4f) != parallel (%v %v %.4f)", q, sv, sok, ssim, pv, pok, psim)
+		}
+	}
+}
+
+func TestParallelScan_ReapsExpired(t *testing.T) {
+	_, c := newScanCaches(2000)
+	for i := 0; i < 600; i++ {
+		ttl := time.Duration(0)
+		if i%2 == 0 {
+			ttl = time.Millisecond
+		}
+		c.SetWithTTL(fmt.Sprintf("entry %d", i), i, ttl)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if v, ok, _ := c.Get("entry 598"); ok && v.(int)%2 == 0 {
+		t.Fatalf("expired entry must not be returned, got %v", v)
+	}
+	if c.Len() != 300 || c.Stats().Expired != 300 {
+		t.Fatalf("want 300 reaped, got len=%d expired=%d", c.Len(), c.Stats().Expired)
+	}
+	if _, ok, _ := c.Get("entry 599"); !ok {
+		t.Fatal("live entry must still hit")
+	}
+}
+
+func TestNew_NegativeScanWorkers_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.7, Capacity: 1, ScanWorkers: -1})
+}
+
+func BenchmarkCache_Get_10000Entries_Serial(b *testing.B)   { benchScan(b, 0) }
+func BenchmarkCache_Get_10000Entries_Parallel(b *testing.B) { benchScan(b, 1) }
+
+func benchScan(b *testing.B, parallelMin int) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+		Threshold: 0.82, Capacity: 10000, LSHEnabled: new(bool), ParallelScanMin: parallelMin,
+	})
+	for i := 0; i < 10000; i++ {
+		c.Set(fmt.Sprintf("entry number %d in the cache benchmark", i), i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Get("entry number 5000 in the cache benchmark")
+	}
+}
@@ -0,0 +1,44 @@
+package cache
+
+// Split divides s into n snapshots of consecutive entries, for encoding and
+// decoding in parallel; JoinSnapshots restores s from them. Tombstones and
+// counters go to the first part. n is capped at the number of entries (but is at least 1).
+func (s Snapshot) Split(n int) []Snapshot {
+	n = min(n, len(s.Entries))
+	n = max(n, 1)
+	parts := make([]Snapshot, n)
+	for i := range parts {
+		lo, hi := i*len(s.Entries)/n, (i+1)*len(s.Entries)/n
+		parts[i] = Snapshot{
+			Version:  s.Version,
+			ID:       s.ID,
+			Encoder:  s.Encoder,
+			Dims:     s.Dims,
+			Capacity: s.Capacity,
+			Entries:  s.Entries[lo:hi:hi],
+		}
+	}
+	parts[0].Tombstones, parts[0].Counters = s.Tombstones, s.Counters
+	return parts
+}
+
+// JoinSnapshots concatenates parts split by Snapshot.Split, in order.
+// Version, ID, Encoder, Dims, Capacity and Counters come from the first part.
+func JoinSnapshots(parts []Snapshot) Snapshot {
+	if len(parts) == 0 {
+		return Snapshot{Version: snapshotVersion}
+	}
+	var entries, tombs int
+	for _, p := range parts {
+		entries += len(p.Entries)
+		tombs += len(p.Tombstones)
+	}
+	s := parts[0]
+	s.Entries = make([]EntrySnapshot, 0, entries)
+	s.Tombstones = make([]Tombstone, 0, tombs)
+	for _, p := range parts {
+		s.Entries = append(s.Entries, p.Entries...)
+		s.Tombstones = append(s.Tombstones, p.Tombstones...)
+	}
+	return s
+}
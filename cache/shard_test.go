@@ -0,0 +1,41 @@
+package cache_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestSnapshot_SplitJoin(t *testing.T) {
+	s := cache.Snapshot{Version: 2, Dims: 64, Capacity: 10}
+	for i := 0; i < 7; i++ {
+		s.Entries = append(s.Entries, cache.EntrySnapshot{Key: fmt.Sprint(i), VecData: []uint64{uint64(i)}})
+	}
+	s.Tombstones = []cache.Tombstone{{Key: "gone", DeletedAt: time.Unix(1, 0)}}
+
+	parts := s.Split(3)
+	if len(parts) != 3 {
+		t.Fatalf("want 3 parts, got %d", len(parts))
+	}
+	for _, p := range parts {
+		if n := len(p.Entries); n < 2 || n > 3 {
+			t.Fatalf("unbalanced part of %d entries", n)
+		}
+	}
+	if got := cache.JoinSnapshots(parts); !reflect.DeepEqual(got, s) {
+		t.Fatalf("join(split(s)) != s:\n got %+v\nwant %+v", got, s)
+	}
+}
+
+func TestSnapshot_Split_Small(t *testing.T) {
+	s := cache.Snapshot{Version: 2, Entries: []cache.EntrySnapshot{{Key: "a"}}}
+	if n := len(s.Split(8)); n != 1 {
+		t.Fatalf("parts are capped at the entry count, got %d", n)
+	}
+	if n := len(cache.Snapshot{}.Split(8)); n != 1 {
+		t.Fatalf("an empty snapshot still has one part, got %d", n)
+	}
+}
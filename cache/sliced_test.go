@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"fmt"
+	"math/bits"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+func hamming(a, b hdc.Vector) int {
+	d := 0
+	for i, w := range a.RawData() {
+		d += bits.OnesCount64(w ^ b.RawData()[i])
+	}
+	return d
+}
+
+func TestSlicedCorpus_Distances(t *testing.T) {
+	const dims = 1000 // not a multiple of 64
+	s := newSlicedCorpus(dims)
+	entries := make([]*entry, 150)
+	for i := range entries {
+		entries[i] = &entry{vec: hdc.Random(dims, uint64(i))}
+		s.put(entries[i])
+	}
+	// Remove some, including the last lane, and rewrite one in place.
+	for _, i := range []int{3, 149, 64, 0} {
+		s.remove(entries[i])
+		entries[i] = nil
+	}
+	entries[10].vec = hdc.Random(dims, 999)
+	s.put(entries[10])
+
+	q := hdc.Random(dims, 12345)
+	dist := s.distances(q, nil)
+	if len(dist) != 146 || len(s.blocks) != 3 {
+		t.Fatalf("%d distances in %d blocks, want 146 in 3", len(dist), len(s.blocks))
+	}
+	for i, e := range entries {
+		if e == nil {
+			continue
+		}
+		if got, want := dist[e.lane-1], hamming(q, e.vec); got != want {
+			t.Errorf("entry %d: distance %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestCache_BitSliced(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	opts := Options{Threshold: 0.8, Capacity: 100, LSHEnabled: new(bool)}
+	plain := New(enc, opts)
+	opts.BitSliced = true
+	sliced := New(enc, opts)
+	for _, c := range []*Cache{plain, sliced} {
+		for i := 0; i < 150; i++ { // evicts 50
+			c.Set(fmt.Sprintf("how do I reset password number %d", i), i)
+		}
+		c.Set("how do I reset password number 120", "updated")
+		c.Delete("how do I reset password number 77")
+	}
+	for i := 0; i < 150; i++ {
+		key := fmt.Sprintf("how do I reset password number %d?", i)
+		r1 := plain.Lookup(key)
+		r2 := sliced.Lookup(key)
+		if r1.Hit != r2.Hit || r1.MatchedKey != r2.MatchedKey || r1.Similarity != r2.Similarity || r1.Value != r2.Value {
+			t.Errorf("%q: plain %+v, bit-sliced %+v", key, r1, r2)
+		}
+	}
+}
+
+func BenchmarkCache_Get_10000Entries_BitSliced(b *testing.B) {
+	c := New(hdc.NewNGramEncoder(hdc.DefaultConfig()), Options{
+		Threshold: 0.82, Capacity: 10000, LSHEnabled: new(bool), BitSliced: true,
+	})
+	for i := 0; i < 10000; i++ {
+		c.Set(fmt.Sprintf("entry number %d in the cache benchmark", i), i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Get("entry number 5000 in the cache benchmark")
+	}
+}
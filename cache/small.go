@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"math/bits"
+	"slices"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// Small vectors: at 512 dims or fewer with the default Similarity, an entry
+// keeps its vector in a fixed array inside the entry (entry.inline) rather
+// than in an hdc.Vector whose words live in a separate allocation. A scan
+// then reads the prefilter popcount and the vector from the entry itself,
+// without following a slice header to another cache line, and scores it by
+// Hamming distance over a fixed number of words the compiler unrolls. Such
+// caches are the ones deliberately made tiny for edge devices, where the
+// scan dominates and the extra pointer hop shows. Words past the dims are
+// zero in both the entry and the query, so they add no distance.
+//
+// The array costs every entry smallWords·8 bytes, which bigger vectors
+// leave unused. Paths off the hot one — snapshots, Vectors, Explain — get
+// a copy of the words as an hdc.Vector (vecOf).
+
+// smallWords is the most words a vector may have to be stored inline.
+const smallWords = 8
+
+// smallDims reports whether vectors of dims bits are stored inline, which
+// needs the default metric.
+func smallDims(dims int, customSim bool) bool {
+	return dims <= smallWords*64 && !customSim
+}
+
+// words returns e's vector words: inline ones, padded with zeros to
+// smallWords, or the hdc.Vector's.
+func (e *entry) words() []uint64 {
+	if e.inlined {
+		return e.inline[:]
+	}
+	return e.vec.RawData()
+}
+
+// vecOf returns e's vector, copied out of the entry if stored inline.
+func (c *Cache) vecOf(e *entry) hdc.Vector {
+	if e.inlined {
+		return hdc.FromWords(c.dims, e.inline[:hdc.NumWords(c.dims)])
+	}
+	return e.vec
+}
+
+// vecData returns a copy of e's vector words, as a snapshot stores them.
+func (c *Cache) vecData(e *entry) []uint64 {
+	return slices.Clone(e.words()[:hdc.NumWords(c.dims)])
+}
+
+// smallSim is hdc.Similarity of the query and an inline entry, computed
+// the same way so the two agree to the last bit.
+func (q *query) smallSim(e *entry, dims int) float64 {
+	a, b := &q.inline, &e.inline
+	diff := 0
+	for i := 0; i < smallWords; i++ {
+		diff += bits.OnesCount64(a[i] ^ b[i])
+	}
+	return 1.0 - float64(diff)/float64(dims)
+}
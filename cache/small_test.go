@@ -0,0 +1,92 @@
+package cache_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func smallEncoder(dims int) hdc.Encoder {
+	cfg := hdc.DefaultConfig()
+	cfg.Dims = dims
+	return hdc.NewNGramEncoder(cfg)
+}
+
+// The inline path must score exactly as hdc.Similarity, which a custom
+// Similarity of the same function forces the cache to call.
+func TestSmallDims_MatchesSimilarity(t *testing.T) {
+	for _, dims := range []int{64, 200, 512, 513} {
+		lsh := false
+		opts := cache.Options{Threshold: 0.5, Capacity: 64, LSHEnabled: &lsh, BitSliced: dims == 200}
+		fast := cache.New(smallEncoder(dims), opts)
+		opts.BitSliced = false
+		opts.Similarity = hdc.Similarity
+		slow := cache.New(smallEncoder(dims), opts)
+		for i := 0; i < 40; i++ {
+			key := fmt.Sprintf("how do I configure service number %d", i)
+			fast.Set(key, i)
+			slow.Set(key, i)
+		}
+		for i := 0; i < 40; i += 3 {
+			q := fmt.Sprintf("configure service %d how", i)
+			a, b := fast.Lookup(q), slow.Lookup(q)
+			if a.Hit != b.Hit || a.Similarity != b.Similarity || a.MatchedKey != b.MatchedKey {
+				t.Fatalf("dims %d, %q: inline %+v, hdc.Similarity %+v", dims, q, a, b)
+			}
+		}
+	}
+}
+
+func TestSmallDims_SnapshotAndLSH(t *testing.T) {
+	lsh := true
+	opts := cache.Options{Threshold: 0.8, Capacity: 32, LSHEnabled: &lsh, MissFilter: true}
+	c := cache.New(smallEncoder(256), opts)
+	c.Set("what is the capital of india", "Delhi")
+	c.Set("how to bake a chocolate cake", "Oven")
+	c.Set("what is the capital of india", "New Delhi") // replaces the vector in place
+	if v, ok, sim := c.Get("what is the capital of india"); !ok || v != "New Delhi" || sim != 1 {
+		t.Fatalf("got %v, %v, %v", v, ok, sim)
+	}
+
+	var buf bytes.Buffer
+	if err := cache.EncodeSnapshot(&buf, c.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := cache.DecodeSnapshot(&buf, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(snap.Entries[0].VecData); n != 4 {
+		t.Fatalf("snapshot stores %d words, want 4", n)
+	}
+	d := cache.New(smallEncoder(256), opts)
+	if err := d.LoadSnapshot(snap); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, sim := d.Get("how to bake a chocolate cake"); !ok || v != "Oven" || sim != 1 {
+		t.Fatalf("restored: got %v, %v, %v", v, ok, sim)
+	}
+	_, vecs := d.Vectors()
+	if len(vecs) != 2 || vecs[0].Dims() != 256 {
+		t.Fatalf("Vectors: %d of %d dims", len(vecs), vecs[0].Dims())
+	}
+}
+
+func BenchmarkCache_Get_SmallDims_1000(b *testing.B) {
+	for _, dims := range []int{512, 576} { // inline, and the smallest size that is not
+		b.Run(fmt.Sprint(dims), func(b *testing.B) {
+			lsh := false
+			c := cache.New(smallEncoder(dims), cache.Options{Threshold: 0.9, Capacity: 1000, LSHEnabled: &lsh})
+			for i := 0; i < 1000; i++ {
+				c.Set(fmt.Sprintf("cached question number %d about topic %d", i, i%37), i)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Get("a question that matches nothing in the cache")
+			}
+		})
+	}
+}
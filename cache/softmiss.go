@@ -0,0 +1,44 @@
+package cache
+
+import "time"
+
+// Soft misses (Options.SoftMiss) keep what a lookup learned when it found
+// nothing above the threshold: the best entry it scored, if that came
+// within SoftMiss, goes back to the caller marked as a miss. A caller can
+// re-rank it with a cross-encoder, ask a model whether its answer still
+// fits, or hand it to the model as a hint — without a second lookup. With
+// LSH, the best entry is the best candidate, or the best of the fallback
+// scan.
+
+// softMissLocked returns key's entry as a soft miss at sim, or the empty
+// Result when soft misses are off, sim is below Options.SoftMiss or the
+// entry's value cannot be read.
+func (c *Cache) softMissLocked(key string, sim float64) Result {
+	if c.softMiss == 0 || key == "" || sim < c.softMiss {
+		return Result{}
+	}
+	elem, ok := c.index.Get(key)
+	if !ok {
+		return Result{}
+	}
+	e := elem.Value.(*entry)
+	value, err := c.valueLocked(e)
+	if err != nil {
+		return Result{}
+	}
+	c.stats.softMisses.Add(1)
+	now := time.Now()
+	var expiresIn time.Duration
+	if !e.deadline.IsZero() {
+		expiresIn = max(e.deadline.Sub(now), 1)
+	}
+	return Result{
+		Value:       value,
+		Similarity:  sim,
+		MatchedKey:  e.key,
+		EntryAge:    now.Sub(e.ts),
+		ExpiresIn:   expiresIn,
+		EntrySource: e.source,
+		SoftMiss:    true,
+	}
+}
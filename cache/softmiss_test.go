@@ -0,0 +1,42 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestCache_SoftMiss(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	for _, lsh := range []bool{false, true} {
+		c := cache.New(enc, cache.Options{Threshold: 0.99, Capacity: 16, LSHEnabled: &lsh, SoftMiss: 0.6})
+		c.Set("what is the capital of india", "Delhi")
+		c.Set("how do I bake sourdough bread", "slowly")
+
+		sim, _ := c.Similarity("capital city of india", "what is the capital of india")
+		r := c.Lookup("capital city of india")
+		if r.Hit || !r.SoftMiss || r.Value != "Delhi" || r.MatchedKey != "what is the capital of india" || r.Similarity != sim {
+			t.Fatalf("lsh=%v: got %+v, want a soft miss on the india entry at %.3f", lsh, r, sim)
+		}
+		if st := c.Stats(); st.Misses != 1 || st.SoftMisses != 1 || st.Hits != 0 {
+			t.Errorf("lsh=%v: misses %d soft %d hits %d", lsh, st.Misses, st.SoftMisses, st.Hits)
+		}
+		if _, ok, _ := c.Get("capital city of india"); ok {
+			t.Errorf("lsh=%v: Get must still miss", lsh)
+		}
+
+		// Below the floor: a plain miss.
+		if r := c.Lookup("zebra migration patterns in kenya"); r.SoftMiss || r.Value != nil {
+			t.Errorf("lsh=%v: unrelated query soft-missed %+v", lsh, r)
+		}
+	}
+}
+
+func TestCache_SoftMissOff(t *testing.T) {
+	c := newCache(0.99, 16)
+	c.Set("what is the capital of india", "Delhi")
+	if r := c.Lookup("capital city of india"); r.SoftMiss || r.Value != nil || r.MatchedKey != "" {
+		t.Fatalf("soft misses are off, got %+v", r)
+	}
+}
@@ -0,0 +1,56 @@
+package cache
+
+import "time"
+
+// SetWithSource stores value with the default TTL, tagged with source
+// (a model name, pipeline version, ...). A later Set of the same key
+// replaces the tag along with the value. Tags are not kept in snapshots.
+func (c *Cache) SetWithSource(key string, value any, source string) {
+	c.setWithTTL(key, value, c.TTL(), source)
+}
+
+// SetAcceptedSources restricts lookups to entries tagged with one of
+// sources; untagged entries count as source "". Other entries stay cached
+// but are skipped by Get, Lookup and Contains. No sources lifts the
+// restriction.
+func (c *Cache) SetAcceptedSources(sources ...string) {
+	accepted := acceptSet(sources)
+	c.mu.Lock()
+	c.accepted = accepted
+	c.mu.Unlock()
+}
+
+func acceptSet(sources []string) map[string]bool {
+	if len(sources) == 0 {
+		return nil
+	}
+	m := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		m[s] = true
+	}
+	return m
+}
+
+// accepts reports whether lookups may return e.
+func (c *Cache) accepts(e *entry) bool {
+	return c.accepted == nil || c.accepted[e.source]
+}
+
+// DeleteSource removes every entry tagged with source and returns the count.
+func (c *Cache) DeleteSource(source string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	now := time.Now()
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if e := elem.Value.(*entry); e.source == source {
+			key := e.key
+			c.removeLocked(elem)
+			c.recordTombstoneLocked(key, now)
+			n++
+		}
+		elem = next
+	}
+	return n
+}
@@ -0,0 +1,82 @@
+package cache_test
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSource_AcceptedSources(t *testing.T) {
+	c := newCache(0.65, 16)
+	c.SetWithSource("what is the capital of india", "Delhi (v1)", "model-v1")
+	c.SetWithSource("capital of india please", "Delhi (v2)", "model-v2")
+
+	c.SetAcceptedSources("model-v2")
+	r := c.Lookup("what is the capital of india")
+	if !r.Hit || r.Value != "Delhi (v2)" || r.EntrySource != "model-v2" {
+		t.Fatalf("only model-v2 entries may answer, got %+v", r)
+	}
+	if ok, _ := c.Contains("what is the capital of india"); !ok {
+		t.Fatal("Contains must see the accepted entry")
+	}
+
+	c.SetAcceptedSources("model-v3")
+	if _, ok, _ := c.Get("what is the capital of india"); ok {
+		t.Fatal("no entry is from an accepted source")
+	}
+	if c.Len() != 2 {
+		t.Fatal("filtered entries must stay cached")
+	}
+
+	c.SetAcceptedSources()
+	if r := c.Lookup("what is the capital of india"); !r.Hit || r.EntrySource != "model-v1" {
+		t.Fatalf("lifting the filter must restore the exact match, got %+v", r)
+	}
+}
+
+func TestSource_UntaggedAndOverwrite(t *testing.T) {
+	c := newCache(0.99, 16)
+	c.Set("plain", 1)
+	c.SetWithSource("tagged", 2, "v1")
+	c.SetAcceptedSources("")
+	if _, ok, _ := c.Get("plain"); !ok {
+		t.Fatal(`untagged entries count as source ""`)
+	}
+	if _, ok, _ := c.Get("tagged"); ok {
+		t.Fatal("tagged entry must be filtered out")
+	}
+	c.Set("tagged", 3) // overwrite drops the tag
+	if v, ok, _ := c.Get("tagged"); !ok || v != 3 {
+		t.Fatalf("Set must replace the tag, got %v %v", v, ok)
+	}
+}
+
+func TestSource_DeleteSource(t *testing.T) {
+	c := newCache(0.99, 16)
+	c.SetWithSource("a", 1, "old")
+	c.SetWithSource("b", 2, "old")
+	c.SetWithSource("c", 3, "new")
+	if n := c.DeleteSource("old"); n != 2 {
+		t.Fatalf("want 2 purged, got %d", n)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("want 1 entry left, got %d", c.Len())
+	}
+}
+
+func TestLookupWhere(t *testing.T) {
+	c := newCache(0.65, 16)
+	c.Set("b:what is the capital of india", "Delhi (b)")
+	c.Set("a:capital of india please", "Delhi (a)")
+
+	inA := func(k string) bool { return strings.HasPrefix(k, "a:") }
+	r := c.LookupWhere("b:what is the capital of india", inA)
+	if !r.Hit || r.Value != "Delhi (a)" {
+		t.Fatalf("a closer rejected entry must not hide the accepted one, got %+v", r)
+	}
+	if r := c.LookupWhere("b:what is the capital of india", func(string) bool { return false }); r.Hit {
+		t.Fatalf("no entry is accepted, got %+v", r)
+	}
+	if r := c.Lookup("b:what is the capital of india"); r.Value != "Delhi (b)" {
+		t.Fatalf("Lookup must see every entry, got %+v", r)
+	}
+}
@@ -0,0 +1,395 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// NumSimBuckets is the number of buckets in Stats.HitSimilarity. Bucket i
+// counts hits with similarity in [i/NumSimBuckets, (i+1)/NumSimBuckets);
+// a similarity of exactly 1 falls in the last bucket.
+const NumSimBuckets = 20
+
+type Stats struct {
+	Entries        int
+	Hits           uint64
+	ExactHits      uint64 // hits on the query key's own entry; not saved in snapshots
+	Misses         uint64
+	Sets           uint64
+	Expired        uint64
+	Evictions      uint64 // entries evicted to make room
+	HitRate        float64
+	AvgSimOnHit    float64
+	LSHCandidates  uint64
+	LSHFallbacks   uint64
+	Pruned         uint64 // comparisons skipped by the popcount prefilter or CoarseBits
+	Busy           uint64 // lookups turned away by MaxConcurrentScans
+	Ambiguous      uint64 // misses whose best match lacked Options.Margin
+	DefiniteMisses uint64 // misses Options.MissFilter answered without a scan
+	SoftMisses     uint64 // misses that returned their best entry, see Options.SoftMiss
+	EarlyExits     uint64 // hits that ended their scan early, see Options.EarlyExit
+	HitSimilarity  [NumSimBuckets]uint64
+
+	// EntryAllocs counts entries allocated fresh, EntryReuses entries
+	// recycled from removed ones.
+	EntryAllocs uint64
+	EntryReuses uint64
+
+	// DedupShared is the number of entries currently sharing another
+	// entry's identical vector (Options.DedupVectors); DedupBytesSaved the
+	// vector memory that saves.
+	DedupShared     uint64
+	DedupBytesSaved uint64
+
+	// ValuesShared is the number of entries sharing another entry's equal
+	// value (Options.DedupValues) and ValueBytesSaved the memory that
+	// saves. ValueDedupRatio is the size of the values entries hold over
+	// the size actually stored, e.g. 3 when each answer is cached under
+	// three keys on average; 0 if nothing was measured.
+	ValuesShared    uint64
+	ValueBytesSaved uint64
+	ValueDedupRatio float64
+
+	// EncodeTimeouts counts encodes that overran Options.EncodeBudget.
+	EncodeTimeouts uint64
+
+	// EncodeCacheHits counts encodes answered by Options.EncodeCache.
+	EncodeCacheHits uint64
+
+	// EncodeErrors counts encoder panics and wrong-dims vectors recovered
+	// under Options.RecoverEncoderPanics.
+	EncodeErrors uint64
+
+	// Spooled counts values over Options.MaxValueBytes handed to
+	// Options.Spool; Oversize those not cached.
+	Spooled  uint64
+	Oversize uint64
+
+	// Latency holds percentiles of the phases timed under
+	// Options.LatencySampleRate, since the cache was created.
+	Latency struct{ Encode, LockWait, Scan Latency }
+
+	// Cold is the number of entries whose value is in Options.ColdStore;
+	// Faults counts hits that read a value back from it.
+	Cold   int
+	Faults uint64
+
+	// PerLookup describes the work done by each lookup: Candidates are
+	// the live entries the LSH index (or the scan) offered, Compared those
+	// left after the popcount prefilter and fully scored. Compared falling
+	// far below Candidates means the prefilter is earning its keep;
+	// Candidates near Entries means the index is not narrowing much.
+	PerLookup struct{ Candidates, Compared Distribution }
+}
+
+// Distribution summarizes a per-lookup count. P99 is exact below 8 and
+// otherwise the top of its bucket, within 12.5% of the true value.
+type Distribution struct {
+	Mean float64 `json:"mean"`
+	P99  uint64  `json:"p99"`
+}
+
+func distribution(h *hist) Distribution {
+	n, sum, at := h.quantiles(0.99)
+	if n == 0 {
+		return Distribution{}
+	}
+	return Distribution{Mean: float64(sum) / float64(n), P99: at[0] - 1} // bounds are exclusive
+}
+
+// counters holds everything Stats reports. Fields are updated with atomics,
+// mostly under c.mu for free, so Stats can be polled by metrics scrapers
+// without waiting on a scan.
+type counters struct {
+	entries          atomic.Int64 // mirrors lru.Len()
+	hits             atomic.Uint64
+	exactHits        atomic.Uint64
+	misses           atomic.Uint64
+	sets             atomic.Uint64
+	expired          atomic.Uint64
+	evictions        atomic.Uint64
+	lshCandidates    atomic.Uint64
+	lshFallbacks     atomic.Uint64
+	pruned           atomic.Uint64
+	busy             atomic.Uint64
+	ambiguous        atomic.Uint64
+	definiteMisses   atomic.Uint64
+	softMisses       atomic.Uint64
+	earlyExits       atomic.Uint64
+	entryAllocs      atomic.Uint64
+	entryReuses      atomic.Uint64
+	dedupShared      atomic.Int64
+	valuesShared     atomic.Int64
+	valueBytes       atomic.Int64  // of the values entries hold, with DedupValues
+	valueBytesStored atomic.Int64  // of those values once each
+	simSum           atomic.Uint64 // float64 bits
+	simHist          [NumSimBuckets]atomic.Uint64
+
+	encodeTimeouts  atomic.Uint64
+	encodeErrors    atomic.Uint64
+	encodeCacheHits atomic.Uint64
+	spooled         atomic.Uint64
+	oversize        atomic.Uint64
+	cold            atomic.Int64
+	faults          atomic.Uint64
+
+	candidates hist // per lookup: entries reaching the prefilter
+	compared   hist // per lookup: entries it passed to a full comparison
+}
+
+func (k *counters) hit(sim float64) {
+	k.hits.Add(1)
+	for {
+		old := k.simSum.Load()
+		if k.simSum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+sim)) {
+			break
+		}
+	}
+	b := int(sim * NumSimBuckets)
+	b = max(0, min(b, NumSimBuckets-1))
+	k.simHist[b].Add(1)
+}
+
+// Stats reads the counters without taking the cache lock. Each field is
+// current, but fields are not read at a single instant: under concurrent
+// traffic HitRate and AvgSimOnHit may lag Hits by an in-flight lookup.
+func (c *Cache) Stats() Stats {
+	k := &c.stats
+	s := Stats{
+		Entries:        int(k.entries.Load()),
+		Hits:           k.hits.Load(),
+		ExactHits:      k.exactHits.Load(),
+		Misses:         k.misses.Load(),
+		Sets:           k.sets.Load(),
+		Expired:        k.expired.Load(),
+		Evictions:      k.evictions.Load(),
+		LSHCandidates:  k.lshCandidates.Load(),
+		LSHFallbacks:   k.lshFallbacks.Load(),
+		Pruned:         k.pruned.Load(),
+		Busy:           k.busy.Load(),
+		Ambiguous:      k.ambiguous.Load(),
+		DefiniteMisses: k.definiteMisses.Load(),
+		SoftMisses:     k.softMisses.Load(),
+		EarlyExits:     k.earlyExits.Load(),
+		EntryAllocs:    k.entryAllocs.Load(),
+		EntryReuses:    k.entryReuses.Load(),
+		DedupShared:    uint64(k.dedupShared.Load()),
+	}
+	s.DedupBytesSaved = s.DedupShared * uint64(hdc.NumWords(c.dims)) * 8
+	s.ValuesShared = uint64(k.valuesShared.Load())
+	if held, stored := k.valueBytes.Load(), k.valueBytesStored.Load(); stored > 0 {
+		s.ValueBytesSaved = uint64(max(held-stored, 0))
+		s.ValueDedupRatio = float64(held) / float64(stored)
+	}
+	s.EncodeTimeouts = k.encodeTimeouts.Load()
+	s.EncodeErrors = k.encodeErrors.Load()
+	s.EncodeCacheHits = k.encodeCacheHits.Load()
+	s.Spooled = k.spooled.Load()
+	s.Oversize = k.oversize.Load()
+	if c.lat != nil {
+		s.Latency.Encode = latency(&c.lat.h[phaseEncode])
+		s.Latency.LockWait = latency(&c.lat.h[phaseLockWait])
+		s.Latency.Scan = latency(&c.lat.h[phaseScan])
+	}
+	s.PerLookup.Candidates = distribution(&k.candidates)
+	s.PerLookup.Compared = distribution(&k.compared)
+	s.Cold = int(k.cold.Load())
+	s.Faults = k.faults.Load()
+	for i := range k.simHist {
+		s.HitSimilarity[i] = k.simHist[i].Load()
+	}
+	if total := s.Hits + s.Misses; total > 0 {
+		s.HitRate = float64(s.Hits) / float64(total)
+	}
+	if s.Hits > 0 {
+		s.AvgSimOnHit = math.Float64frombits(k.simSum.Load()) / float64(s.Hits)
+	}
+	return s
+}
+
+// Counters are the lifetime totals a Snapshot carries across restarts, so
+// long-horizon hit rates survive deploys. See Options.IgnoreSavedCounters.
+type Counters struct {
+	Hits, Misses, Sets, Expired, Evictions uint64
+
+	SimSum        float64 // sum of hit similarities, for AvgSimOnHit
+	HitSimilarity [NumSimBuckets]uint64
+}
+
+// lifetime returns the current lifetime totals.
+func (k *counters) lifetime() *Counters {
+	n := &Counters{
+		Hits:      k.hits.Load(),
+		Misses:    k.misses.Load(),
+		Sets:      k.sets.Load(),
+		Expired:   k.expired.Load(),
+		Evictions: k.evictions.Load(),
+		SimSum:    math.Float64frombits(k.simSum.Load()),
+	}
+	for i := range k.simHist {
+		n.HitSimilarity[i] = k.simHist[i].Load()
+	}
+	return n
+}
+
+// restore raises each counter to its saved total. Taking the larger keeps
+// loading the same totals twice, e.g. a base snapshot and then its deltas,
+// from counting anything twice.
+func (k *counters) restore(n *Counters) {
+	raise := func(c *atomic.Uint64, v uint64) {
+		for {
+			old := c.Load()
+			if old >= v || c.CompareAndSwap(old, v) {
+				return
+			}
+		}
+	}
+	if k.hits.Load() < n.Hits {
+		k.simSum.Store(math.Float64bits(n.SimSum))
+		for i := range k.simHist {
+			k.simHist[i].Store(n.HitSimilarity[i])
+		}
+	}
+	raise(&k.hits, n.Hits)
+	raise(&k.misses, n.Misses)
+	raise(&k.sets, n.Sets)
+	raise(&k.expired, n.Expired)
+	raise(&k.evictions, n.Evictions)
+}
+
+// statsJSON is Stats under the JSON names MarshalJSON writes. The fields
+// must stay in step with Stats's, which the conversions check at compile
+// time; the names stay put when Go fields are renamed.
+type statsJSON struct {
+	Entries        int                   `json:"entries"`
+	Hits           uint64                `json:"hits"`
+	ExactHits      uint64                `json:"exact_hits"`
+	Misses         uint64                `json:"misses"`
+	Sets           uint64                `json:"sets"`
+	Expired        uint64                `json:"expired"`
+	Evictions      uint64                `json:"evictions"`
+	HitRate        float64               `json:"hit_rate"`
+	AvgSimOnHit    float64               `json:"avg_sim_on_hit"`
+	LSHCandidates  uint64                `json:"lsh_candidates"`
+	LSHFallbacks   uint64                `json:"lsh_fallbacks"`
+	Pruned         uint64                `json:"pruned"`
+	Busy           uint64                `json:"busy"`
+	Ambiguous      uint64                `json:"ambiguous"`
+	DefiniteMisses uint64                `json:"definite_misses"`
+	SoftMisses     uint64                `json:"soft_misses"`
+	EarlyExits     uint64                `json:"early_exits"`
+	HitSimilarity  [NumSimBuckets]uint64 `json:"hit_similarity"`
+
+	EntryAllocs     uint64  `json:"entry_allocs"`
+	EntryReuses     uint64  `json:"entry_reuses"`
+	DedupShared     uint64  `json:"dedup_shared"`
+	DedupBytesSaved uint64  `json:"dedup_bytes_saved"`
+	ValuesShared    uint64  `json:"values_shared"`
+	ValueBytesSaved uint64  `json:"value_bytes_saved"`
+	ValueDedupRatio float64 `json:"value_dedup_ratio"`
+	EncodeTimeouts  uint64  `json:"encode_timeouts"`
+	EncodeCacheHits uint64  `json:"encode_cache_hits"`
+	EncodeErrors    uint64  `json:"encode_errors"`
+	Spooled         uint64  `json:"spooled"`
+	Oversize        uint64  `json:"oversize"`
+
+	Latency struct {
+		Encode   Latency `json:"encode"`
+		LockWait Latency `json:"lock_wait"`
+		Scan     Latency `json:"scan"`
+	} `json:"latency"`
+
+	Cold   int    `json:"cold"`
+	Faults uint64 `json:"faults"`
+
+	PerLookup struct {
+		Candidates Distribution `json:"candidates"`
+		Compared   Distribution `json:"compared"`
+	} `json:"per_lookup"`
+}
+
+// MarshalJSON writes s with snake_case names ("hit_rate",
+// "per_lookup.candidates.p99") that do not change with the Go fields;
+// durations are in nanoseconds, under names ending in _ns.
+func (s Stats) MarshalJSON() ([]byte, error) { return json.Marshal(statsJSON(s)) }
+
+// UnmarshalJSON reads what MarshalJSON writes.
+func (s *Stats) UnmarshalJSON(data []byte) error { return json.Unmarshal(data, (*statsJSON)(s)) }
+
+// String formats s for logs and terminals: one topic per line, counts with
+// the rates derived from them, and lines for features that saw no use
+// left out.
+func (s Stats) String() string {
+	var b strings.Builder
+	lookups := s.Hits + s.Misses
+	fmt.Fprintf(&b, "entries %d, lookups %d: hits %d (%s), misses %d", s.Entries, lookups, s.Hits, pct(s.Hits, lookups), s.Misses)
+	if s.Hits > 0 {
+		fmt.Fprintf(&b, "\nhits: %s exact, avg similarity %.3f", pct(s.ExactHits, s.Hits), s.AvgSimOnHit)
+		if s.EarlyExits > 0 {
+			fmt.Fprintf(&b, ", %s ended the scan early", pct(s.EarlyExits, s.Hits))
+		}
+	}
+	fmt.Fprintf(&b, "\nsets %d, expired %d, evictions %d (%s of sets)", s.Sets, s.Expired, s.Evictions, pct(s.Evictions, s.Sets))
+	if s.LSHCandidates > 0 || s.LSHFallbacks > 0 {
+		fmt.Fprintf(&b, "\nlsh: %d candidates, %d fallback scans (%s of lookups)", s.LSHCandidates, s.LSHFallbacks, pct(s.LSHFallbacks, lookups))
+	}
+	writeWork(&b, s.PerLookup.Candidates, s.PerLookup.Compared, s.Pruned)
+	writeCounts(&b, "misses", count{"ambiguous", s.Ambiguous}, count{"definite", s.DefiniteMisses}, count{"soft", s.SoftMisses}, count{"busy", s.Busy})
+	writeCounts(&b, "entries", count{"allocated", s.EntryAllocs}, count{"reused", s.EntryReuses}, count{"sharing a vector", s.DedupShared}, count{"sharing a value", s.ValuesShared})
+	if s.ValueDedupRatio > 0 {
+		fmt.Fprintf(&b, "\nvalue dedup: %.2fx, %d bytes saved", s.ValueDedupRatio, s.ValueBytesSaved)
+	}
+	writeCounts(&b, "encodes", count{"timed out", s.EncodeTimeouts}, count{"failed", s.EncodeErrors}, count{"from cache", s.EncodeCacheHits})
+	writeCounts(&b, "values", count{"spooled", s.Spooled}, count{"too large", s.Oversize}, count{"cold", uint64(s.Cold)}, count{"faulted in", s.Faults})
+	writeLatency(&b, "encode", s.Latency.Encode)
+	writeLatency(&b, "lock wait", s.Latency.LockWait)
+	writeLatency(&b, "scan", s.Latency.Scan)
+	return b.String()
+}
+
+// pct formats n as a percentage of total, "-" if total is 0.
+func pct(n, total uint64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(n)/float64(total))
+}
+
+// count is a labelled number for writeCounts.
+type count struct {
+	label string
+	n     uint64
+}
+
+// writeCounts writes a line of counts, leaving out zeros, or nothing if
+// all are zero.
+func writeCounts(b *strings.Builder, topic string, counts ...count) {
+	var parts []string
+	for _, c := range counts {
+		if c.n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", c.label, c.n))
+		}
+	}
+	if len(parts) > 0 {
+		fmt.Fprintf(b, "\n%s: %s", topic, strings.Join(parts, ", "))
+	}
+}
+
+func writeWork(b *strings.Builder, candidates, compared Distribution, pruned uint64) {
+	if candidates.Mean == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\nper lookup: %.1f candidates (p99 %d), %.1f compared (p99 %d), %d pruned in all",
+		candidates.Mean, candidates.P99, compared.Mean, compared.P99, pruned)
+}
+
+func writeLatency(b *strings.Builder, phase string, l Latency) {
+	if l.Samples > 0 {
+		fmt.Fprintf(b, "\n%s latency: p50 %v, p95 %v, p99 %v (%d samples)", phase, l.P50, l.P95, l.P99, l.Samples)
+	}
+}
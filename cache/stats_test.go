@@ -0,0 +1,136 @@
+package cache_test
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestStats_HitSimilarityHistogram(t *testing.T) {
+	c := newCache(0.70, 16)
+	c.Set("what is the capital of india", "Delhi")
+	c.Get("what is the capital of india") // sim 1 → last bucket
+	_, _, sim := c.Get("capital city of india")
+
+	s := c.Stats()
+	if s.HitSimilarity[len(s.HitSimilarity)-1] != 1 {
+		t.Fatalf("exact hit must land in the last bucket, got %v", s.HitSimilarity)
+	}
+	if b := int(sim * 20); s.HitSimilarity[b] != 1 {
+		t.Fatalf("paraphrase (sim %.3f) must land in bucket %d, got %v", sim, b, s.HitSimilarity)
+	}
+	var total uint64
+	for _, n := range s.HitSimilarity {
+		total += n
+	}
+	if total != s.Hits {
+		t.Fatalf("histogram total %d != hits %d", total, s.Hits)
+	}
+}
+
+func TestStats_ConcurrentWithTraffic(t *testing.T) {
+	c := newCache(0.70, 8)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				c.Set(string(rune('a'+g))+string(rune('a'+i%26)), i)
+				c.Get(string(rune('a' + g)))
+				c.Stats()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	s := c.Stats()
+	if s.Sets != 800 || s.Hits+s.Misses != 800 {
+		t.Fatalf("lost updates: %+v", s)
+	}
+	if s.Entries != c.Len() || s.Entries > 8 {
+		t.Fatalf("entry count drifted: stats=%d len=%d", s.Entries, c.Len())
+	}
+}
+
+func TestStats_SavedCounters(t *testing.T) {
+	c := newCache(0.70, 2)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3) // evicts a
+	c.Get("b")
+	c.Get("zzzz")
+	if s := c.Stats(); s.Evictions != 1 {
+		t.Fatalf("Evictions: got %d", s.Evictions)
+	}
+	snap := c.Snapshot()
+
+	restored := newCache(0.70, 2)
+	restored.Get("b") // a miss before the load
+	if err := restored.LoadSnapshot(snap); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.LoadSnapshot(snap); err != nil { // loading twice counts nothing twice
+		t.Fatal(err)
+	}
+	want, got := c.Stats(), restored.Stats()
+	if got.Hits != want.Hits || got.Misses != want.Misses || got.Sets != want.Sets ||
+		got.Evictions != want.Evictions || got.AvgSimOnHit != want.AvgSimOnHit || got.HitSimilarity != want.HitSimilarity {
+		t.Fatalf("counters not restored:\n got %+v\nwant %+v", got, want)
+	}
+
+	fresh := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.7, Capacity: 2, IgnoreSavedCounters: true})
+	if err := fresh.LoadSnapshot(snap); err != nil {
+		t.Fatal(err)
+	}
+	if s := fresh.Stats(); s.Hits != 0 || s.Evictions != 0 {
+		t.Fatalf("IgnoreSavedCounters must skip them: %+v", s)
+	}
+}
+
+func TestStats_JSON(t *testing.T) {
+	c := newCache(0.70, 16)
+	c.Set("what is the capital of india", "Delhi")
+	c.Get("what is the capital of india")
+	c.Get("unrelated question about rust lifetimes")
+
+	data, err := json.Marshal(c.Stats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names map[string]any
+	json.Unmarshal(data, &names)
+	for _, k := range []string{"entries", "hits", "exact_hits", "hit_rate", "per_lookup", "latency"} {
+		if _, ok := names[k]; !ok {
+			t.Errorf("JSON lacks %q: %s", k, data)
+		}
+	}
+	var back cache.Stats
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back != c.Stats() {
+		t.Errorf("round trip: got %+v, want %+v", back, c.Stats())
+	}
+}
+
+func TestStats_String(t *testing.T) {
+	c := newCache(0.70, 16)
+	c.Set("what is the capital of india", "Delhi")
+	c.Get("what is the capital of india")
+	c.Get("unrelated question about rust lifetimes")
+
+	s := c.Stats().String()
+	for _, want := range []string{"entries 1, lookups 2: hits 1 (50.0%), misses 1", "100.0% exact"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("String() lacks %q:\n%s", want, s)
+		}
+	}
+	if strings.Contains(s, "encodes:") {
+		t.Errorf("String() shows unused encode counters:\n%s", s)
+	}
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"sort"
+	"time"
+)
+
+// Tombstone records that Key was explicitly deleted at DeletedAt. Loading a
+// snapshot applies its tombstones, so an older copy of a deleted entry,
+// whether already cached or in the snapshot itself, is not brought back.
+type Tombstone struct {
+	Key       string
+	DeletedAt time.Time
+}
+
+// tomb is a recorded delete: its time, and its write stamp for
+// DeltaSnapshot.
+type tomb struct {
+	at    time.Time
+	wrote uint64
+}
+
+// recordTombstoneLocked remembers an explicit delete of key when
+// Options.TombstoneTTL is set. Tombstones older than the window are dropped
+// lazily, whenever the map has doubled since the last sweep.
+func (c *Cache) recordTombstoneLocked(key string, now time.Time) {
+	if c.tombTTL <= 0 {
+		return
+	}
+	c.tombs[key] = tomb{at: now, wrote: c.tickLocked(now)}
+	if len(c.tombs) < c.tombSweepAt {
+		return
+	}
+	for k, t := range c.tombs {
+		if now.Sub(t.at) > c.tombTTL {
+			delete(c.tombs, k)
+		}
+	}
+	c.tombSweepAt = 2*len(c.tombs) + 64
+}
+
+// Tombstones returns the deletes recorded within Options.TombstoneTTL,
+// oldest first.
+func (c *Cache) Tombstones() []Tombstone {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tombstonesLocked(time.Now(), 0)
+}
+
+// tombstonesLocked returns the live tombstones recorded after stamp since.
+func (c *Cache) tombstonesLocked(now time.Time, since uint64) []Tombstone {
+	var out []Tombstone
+	for k, t := range c.tombs {
+		if now.Sub(t.at) <= c.tombTTL && t.wrote > since {
+			out = append(out, Tombstone{Key: k, DeletedAt: t.at})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].DeletedAt.Equal(out[j].DeletedAt) {
+			return out[i].DeletedAt.Before(out[j].DeletedAt)
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// applyTombstoneLocked removes key if it was written before t was, and
+// keeps t if it is newer than what is recorded.
+func (c *Cache) applyTombstoneLocked(t Tombstone, now time.Time) {
+	if elem, ok := c.index.Get(t.Key); ok && elem.Value.(*entry).ts.Before(t.DeletedAt) {
+		c.removeLocked(elem)
+	}
+	if c.tombTTL > 0 && now.Sub(t.DeletedAt) <= c.tombTTL && t.DeletedAt.After(c.tombs[t.Key].at) {
+		c.tombs[t.Key] = tomb{at: t.DeletedAt, wrote: c.tickLocked(now)}
+	}
+}
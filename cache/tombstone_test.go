@@ -0,0 +1,101 @@
+package cache_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func newTombCache(ttl time.Duration) *cache.Cache {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	return cache.New(enc, cache.Options{Threshold: 0.99, Capacity: 16, TombstoneTTL: ttl})
+}
+
+func TestTombstone_RecordedAndCleared(t *testing.T) {
+	c := newTombCache(time.Hour)
+	c.Set("alpha", 1)
+	c.Set("bravo", 2)
+	c.Delete("alpha")
+	c.DeleteFunc(func(k string) bool { return k == "bravo" })
+
+	ts := c.Tombstones()
+	if len(ts) != 2 || ts[0].Key != "alpha" || ts[1].Key != "bravo" {
+		t.Fatalf("want tombstones for alpha and bravo, got %+v", ts)
+	}
+	c.Set("alpha", 3)
+	if ts := c.Tombstones(); len(ts) != 1 || ts[0].Key != "bravo" {
+		t.Fatalf("a new Set must clear the tombstone, got %+v", ts)
+	}
+}
+
+func TestTombstone_Off(t *testing.T) {
+	c := newCache(0.99, 16)
+	c.Set("alpha", 1)
+	c.Delete("alpha")
+	if ts := c.Tombstones(); len(ts) != 0 {
+		t.Fatalf("tombstones must be off by default, got %+v", ts)
+	}
+}
+
+func TestTombstone_OldSnapshotDoesNotResurrect(t *testing.T) {
+	c := newTombCache(time.Hour)
+	c.Set("alpha", 1)
+	c.Set("bravo", 2)
+	old := c.Snapshot()
+
+	c.Delete("alpha")
+	if err := c.LoadSnapshot(old); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get("alpha"); ok {
+		t.Fatal("restoring an older snapshot must not bring back a deleted entry")
+	}
+	if _, ok, _ := c.Get("bravo"); !ok {
+		t.Fatal("other entries must load")
+	}
+}
+
+func TestTombstone_PropagatesThroughSnapshot(t *testing.T) {
+	primary := newTombCache(time.Hour)
+	replica := newTombCache(time.Hour)
+	replica.Set("alpha", "stale copy")
+
+	time.Sleep(time.Millisecond)
+	primary.Set("alpha", 1)
+	primary.Delete("alpha")
+	primary.Set("bravo", 2)
+
+	var buf bytes.Buffer
+	if err := cache.EncodeSnapshot(&buf, primary.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := cache.DecodeSnapshot(&buf, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Tombstones) != 1 || snap.Tombstones[0].Key != "alpha" {
+		t.Fatalf("tombstones must survive encoding, got %+v", snap.Tombstones)
+	}
+	if err := replica.LoadSnapshot(snap); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := replica.Get("alpha"); ok {
+		t.Fatal("the delete must reach the replica's older copy")
+	}
+	if ts := replica.Tombstones(); len(ts) != 1 {
+		t.Fatalf("replica must keep the tombstone, got %+v", ts)
+	}
+}
+
+func TestTombstone_Window(t *testing.T) {
+	c := newTombCache(20 * time.Millisecond)
+	c.Set("alpha", 1)
+	c.Delete("alpha")
+	time.Sleep(30 * time.Millisecond)
+	if ts := c.Tombstones(); len(ts) != 0 {
+		t.Fatalf("tombstones past the window must be dropped, got %+v", ts)
+	}
+}
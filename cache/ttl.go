@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// jitterTTL spreads ttl uniformly over [ttl·(1-j), ttl·(1+j)] so entries
+// written together do not all expire, and get refreshed, together.
+func (c *Cache) jitterTTL(ttl time.Duration) time.Duration {
+	if c.ttlJitter == 0 || ttl <= 0 {
+		return ttl
+	}
+	f := 1 + c.ttlJitter*(2*rand.Float64()-1)
+	if d := time.Duration(float64(ttl) * f); d > 0 {
+		return d
+	}
+	return 1
+}
+
+// slideLocked pushes a hit entry's deadline a full TTL past now when
+// sliding expiration is on. Entries without a TTL are left alone.
+func (c *Cache) slideLocked(e *entry, now time.Time) {
+	if c.slidingTTL && e.ttl > 0 {
+		e.deadline = now.Add(e.ttl)
+	}
+}
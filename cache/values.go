@@ -0,0 +1,94 @@
+package cache
+
+// sharedValue is one stored string or []byte value and the number of
+// entries holding it.
+type sharedValue struct {
+	value any
+	size  int64
+	refs  int
+}
+
+// setValueLocked assigns v to e, whose cold reference, if any, the caller
+// has released. With Options.DedupValues, a string or []byte equal to one
+// already stored for another key is shared instead, so an answer cached
+// under many paraphrases of its question is held once. Hash collisions
+// between different values are left unshared, as are values of other
+// types.
+func (c *Cache) setValueLocked(e *entry, v any) {
+	c.releaseValueLocked(e)
+	e.value = v
+	if c.values == nil {
+		return
+	}
+	n, data, ok := valueSize(v)
+	if !ok {
+		return
+	}
+	s, isString := v.(string)
+	h := hashBytes(data, s, isString)
+	sv, found := c.values[h]
+	switch {
+	case !found:
+		c.values[h] = &sharedValue{value: v, size: n, refs: 1}
+		c.stats.valueBytesStored.Add(n)
+	case sameValue(sv.value, v):
+		sv.refs++
+		e.value = sv.value
+		c.stats.valuesShared.Add(1)
+	default:
+		return
+	}
+	c.stats.valueBytes.Add(n)
+	e.valHash, e.valInterned = h, true
+}
+
+// releaseValueLocked drops e's reference to a stored value, if it holds
+// one, before the value is replaced, goes cold or the entry is removed.
+func (c *Cache) releaseValueLocked(e *entry) {
+	if !e.valInterned {
+		return
+	}
+	e.valInterned = false
+	sv := c.values[e.valHash]
+	c.stats.valueBytes.Add(-sv.size)
+	if sv.refs--; sv.refs == 0 {
+		delete(c.values, e.valHash)
+		c.stats.valueBytesStored.Add(-sv.size)
+	} else {
+		c.stats.valuesShared.Add(-1)
+	}
+}
+
+// sameValue reports whether a and b are strings or byte slices with the
+// same type and content.
+func sameValue(a, b any) bool {
+	switch a := a.(type) {
+	case string:
+		b, ok := b.(string)
+		return ok && a == b
+	case []byte:
+		b, ok := b.([]byte)
+		return ok && string(a) == string(b)
+	}
+	return false
+}
+
+// hashBytes is FNV-1a over a []byte or, if isString, a string, seeded
+// differently for the two so equal contents of different types do not
+// collide.
+func hashBytes(data []byte, s string, isString bool) uint64 {
+	h := uint64(14695981039346656037)
+	if isString {
+		h ^= 1
+		for i := 0; i < len(s); i++ {
+			h ^= uint64(s[i])
+			h *= 1099511628211
+		}
+		return h
+	}
+	for _, b := range data {
+		h ^= uint64(b)
+		h *= 1099511628211
+	}
+	return h
+}
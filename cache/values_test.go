@@ -0,0 +1,59 @@
+package cache_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestDedupValues(t *testing.T) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()),
+		cache.Options{Threshold: 0.80, Capacity: 3, DedupValues: true})
+	answer := strings.Repeat("New Delhi. ", 10) // 110 bytes
+	c.Set("what is the capital of india", answer)
+	c.Set("capital city of india", strings.Clone(answer))
+	c.Set("india's capital", []byte(answer)) // another type: not shared
+
+	s := c.Stats()
+	if s.ValuesShared != 1 || s.ValueBytesSaved != 110 || s.ValueDedupRatio != 1.5 {
+		t.Fatalf("shared %d, saved %d, ratio %v", s.ValuesShared, s.ValueBytesSaved, s.ValueDedupRatio)
+	}
+	if v, ok, _ := c.Get("capital city of india"); !ok || v != answer {
+		t.Fatalf("got %v, %v", v, ok)
+	}
+
+	c.Set("capital city of india", "Delhi") // update releases the shared copy
+	if s := c.Stats(); s.ValuesShared != 0 || s.ValueDedupRatio != 1 {
+		t.Fatalf("after update: shared %d, ratio %v", s.ValuesShared, s.ValueDedupRatio)
+	}
+	c.Set("delhi is the capital", answer) // evicts "what is the capital of india"
+	if s := c.Stats(); s.ValuesShared != 0 || s.ValueBytesSaved != 0 {
+		t.Fatalf("after eviction: shared %d, saved %d", s.ValuesShared, s.ValueBytesSaved)
+	}
+	c.Delete("india's capital")
+	c.Delete("capital city of india")
+	c.Delete("delhi is the capital")
+	if s := c.Stats(); s.ValueDedupRatio != 0 {
+		t.Fatalf("empty cache: ratio %v", s.ValueDedupRatio)
+	}
+}
+
+func TestDedupValues_Cold(t *testing.T) {
+	store := newMemStore()
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+		Threshold: 0.75, Capacity: 10, HotEntries: 1, ColdStore: store, DedupValues: true,
+	})
+	c.Set("alpha beta gamma", "same answer")
+	c.Set("delta epsilon zeta", "same answer") // pushes the first cold
+	if s := c.Stats(); s.ValuesShared != 0 || s.Cold != 1 {
+		t.Fatalf("cold value still shared: %+v", s)
+	}
+	if v, ok, _ := c.Get("alpha beta gamma"); !ok || v != "same answer" {
+		t.Fatalf("got %v, %v", v, ok)
+	}
+	if s := c.Stats(); s.ValuesShared != 0 || s.Cold != 1 {
+		t.Fatalf("after fault: shared %d, cold %d", s.ValuesShared, s.Cold)
+	}
+}
@@ -0,0 +1,41 @@
+package cache
+
+// valueSize returns the size of a string or []byte value; other values
+// are not measured.
+func valueSize(v any) (int64, []byte, bool) {
+	switch v := v.(type) {
+	case string:
+		return int64(len(v)), nil, true
+	case []byte:
+		return int64(len(v)), v, true
+	}
+	return 0, nil, false
+}
+
+// admit applies Options.ValueScrubber and then Options.MaxValueBytes to a
+// value about to be stored: a string or []byte over the limit is handed to
+// Options.Spool and replaced by what it returns, or, without Spool or if
+// Spool fails, rejected.
+func (c *Cache) admit(v any) (any, bool) {
+	if c.scrub != nil {
+		v = c.scrub(v)
+	}
+	if c.maxValue <= 0 {
+		return v, true
+	}
+	n, data, ok := valueSize(v)
+	if !ok || n <= c.maxValue {
+		return v, true
+	}
+	if c.spool != nil {
+		if data == nil {
+			data = []byte(v.(string))
+		}
+		if sv, err := c.spool(data); err == nil {
+			c.stats.spooled.Add(1)
+			return sv, true
+		}
+	}
+	c.stats.oversize.Add(1)
+	return nil, false
+}
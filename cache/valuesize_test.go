@@ -0,0 +1,74 @@
+package cache_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+type spooled struct{ n int }
+
+func TestCache_MaxValueBytes(t *testing.T) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{Threshold: 0.75, Capacity: 16, MaxValueBytes: 8})
+	c.Set("small", "12345678")
+	c.Set("large", "123456789")
+	c.SetMany([]cache.Item{{Key: "large bytes", Value: []byte("123456789")}, {Key: "struct", Value: struct{ s string }{strings.Repeat("x", 64)}}})
+	if _, ok, _ := c.Get("small"); !ok {
+		t.Error("value at the limit not cached")
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want the two values not over the limit", c.Len())
+	}
+	if st := c.Stats(); st.Oversize != 2 || st.Spooled != 0 {
+		t.Errorf("oversize %d spooled %d, want 2 and 0", st.Oversize, st.Spooled)
+	}
+}
+
+func TestCache_Spool(t *testing.T) {
+	fail := false
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+		Threshold: 0.75, Capacity: 16, MaxValueBytes: 4,
+		Spool: func(data []byte) (any, error) {
+			if fail {
+				return nil, errors.New("disk full")
+			}
+			return spooled{len(data)}, nil
+		},
+	})
+	c.Set("large", "123456789")
+	if v, ok, _ := c.Get("large"); !ok || v != (spooled{9}) {
+		t.Errorf("got %v, %v, want the spooled handle", v, ok)
+	}
+	fail = true
+	c.SetVec("failed", hdc.NewNGramEncoder(hdc.DefaultConfig()).Encode("failed"), "123456789", 0)
+	if st := c.Stats(); st.Spooled != 1 || st.Oversize != 1 || c.Len() != 1 {
+		t.Errorf("spooled %d oversize %d len %d, want 1, 1, 1", st.Spooled, st.Oversize, c.Len())
+	}
+}
+
+// The scrubbed value is what MaxValueBytes measures.
+func TestCache_ValueScrubber(t *testing.T) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+		Threshold: 0.75, Capacity: 16, MaxValueBytes: 10,
+		ValueScrubber: func(v any) any {
+			if s, ok := v.(string); ok {
+				return strings.ReplaceAll(s, "secret", "*")
+			}
+			return v
+		},
+	})
+	c.Set("token", "a secret value")
+	if v, ok, _ := c.Get("token"); !ok || v != "a * value" {
+		t.Errorf("got %v, %v, want the scrubbed value", v, ok)
+	}
+	c.SetMany([]cache.Item{{Key: "many", Value: "secret"}, {Key: "kept", Value: 42}})
+	if v, _, _ := c.Get("many"); v != "*" {
+		t.Errorf("SetMany stored %v", v)
+	}
+	if v, _, _ := c.Get("kept"); v != 42 {
+		t.Errorf("non-string value stored as %v", v)
+	}
+}
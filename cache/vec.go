@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// Encode returns the cache's vector for key, under Options.EncodeBudget;
+// ok is false if the budget ran out with no fallback. For callers that
+// build vectors out of encoded parts, e.g. multi-field keys.
+func (c *Cache) Encode(key string) (hdc.Vector, bool) { return c.encode(key) }
+
+// SetVec stores value under key with vec as its vector instead of the
+// encoder's, e.g. one built by the caller or precomputed elsewhere. vec must
+// come from the same vector space as the encoder's for lookups to mean
+// anything. Zero ttl = never expires. Panics if vec has the wrong dims.
+func (c *Cache) SetVec(key string, vec hdc.Vector, value any, ttl time.Duration) {
+	if ttl < 0 {
+		panic("cache: TTL must not be negative")
+	}
+	c.checkDims(vec)
+	if c.closed.Load() {
+		return
+	}
+	value, ok := c.admit(value)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.unlock()
+	c.setLocked(key, vec, value, ttl, "", time.Now(), true)
+}
+
+// LookupVec is Lookup for a caller-built query vector; key only names the
+// query in events and may be empty. Like Lookup, it reports a miss when
+// Options.MaxConcurrentScans turns it away. Panics if vec has the wrong dims.
+func (c *Cache) LookupVec(key string, vec hdc.Vector) Result {
+	c.checkDims(vec)
+	if c.closed.Load() {
+		return Result{}
+	}
+	if c.limit != nil {
+		if !c.limit.acquire() {
+			c.stats.busy.Add(1)
+			return Result{}
+		}
+		defer c.limit.release()
+	}
+	return c.lookupVec(key, nil, nil, nil, vec)
+}
+
+func (c *Cache) checkDims(vec hdc.Vector) {
+	if vec.Dims() != c.dims {
+		panic(fmt.Sprintf("cache: vector has %d dims, cache has %d", vec.Dims(), c.dims))
+	}
+}
@@ -0,0 +1,31 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+func TestSetVec_LookupVec(t *testing.T) {
+	c := newTestCache(10, 0.9)
+	v := hdc.Random(c.Dims(), 42)
+	c.SetVec("doc:42", v, "answer", 0)
+
+	r := c.LookupVec("", v)
+	if !r.Hit || r.Value != "answer" || r.MatchedKey != "doc:42" || r.Similarity != 1 {
+		t.Fatalf("got %+v", r)
+	}
+	if r := c.LookupVec("", hdc.Random(c.Dims(), 43)); r.Hit {
+		t.Fatalf("an unrelated vector must miss, got %+v", r)
+	}
+	if !c.Delete("doc:42") {
+		t.Fatal("SetVec entries must be addressable by key")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("wrong dims must panic")
+		}
+	}()
+	c.SetVec("bad", hdc.Random(64, 1), nil, 0)
+}
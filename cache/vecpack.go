@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// Compressed snapshots (EncodeSnapshotCompressed) store, once, the bitwise
+// majority of all vectors — the centroid, which is the vector closest to
+// all of them — and then each vector as the positions of the bits where
+// it differs from it, gap-encoded as uvarints. A vector that differs in
+// too many bits for that to be smaller is stored raw behind a mode byte.
+// Vectors of keys that encode alike share most bits with the centroid,
+// so their residuals are sparse; random-looking ones are not. The cold
+// tier (Options.ColdStore) moves only values, so it has no vectors to pack.
+
+const (
+	packRaw    = 0 // numWords little-endian words follow
+	packSparse = 1 // a count and that many bit-position gaps follow
+)
+
+// vecPacker writes vectors against a centroid.
+type vecPacker struct {
+	centroid []uint64
+	dims     int
+	buf      []byte
+}
+
+func newVecPacker(s Snapshot) *vecPacker {
+	nw := hdc.NumWords(s.Dims)
+	counts := make([]int, s.Dims)
+	for _, e := range s.Entries {
+		for w, word := range e.VecData {
+			for ; word != 0; word &= word - 1 {
+				if b := w*64 + bits.TrailingZeros64(word); b < s.Dims {
+					counts[b]++
+				}
+			}
+		}
+	}
+	centroid := make([]uint64, nw)
+	for b, n := range counts {
+		if 2*n > len(s.Entries) {
+			centroid[b/64] |= 1 << uint(b%64)
+		}
+	}
+	return &vecPacker{centroid: centroid, dims: s.Dims}
+}
+
+func (p *vecPacker) writeCentroid(w *bytes.Buffer) {
+	for _, word := range p.centroid {
+		w.Write(binary.LittleEndian.AppendUint64(nil, word))
+	}
+}
+
+// write packs vec, whose length encodeEntry has checked.
+func (p *vecPacker) write(w *bytes.Buffer, vec []uint64) {
+	n := 0
+	for i, word := range vec {
+		n += bits.OnesCount64(word ^ p.centroid[i])
+	}
+	p.buf = binary.AppendUvarint(p.buf[:0], uint64(n))
+	next := 0 // first position the next gap counts from
+	for i, word := range vec {
+		for d := word ^ p.centroid[i]; d != 0; d &= d - 1 {
+			pos := i*64 + bits.TrailingZeros64(d)
+			p.buf = binary.AppendUvarint(p.buf, uint64(pos-next))
+			next = pos + 1
+		}
+		if len(p.buf) >= len(vec)*8 {
+			break // raw is no bigger
+		}
+	}
+	if len(p.buf) < len(vec)*8 {
+		w.WriteByte(packSparse)
+		w.Write(p.buf)
+		return
+	}
+	w.WriteByte(packRaw)
+	for _, word := range vec {
+		w.Write(binary.LittleEndian.AppendUint64(nil, word))
+	}
+}
+
+// vecUnpacker reads vectors written by vecPacker.
+type vecUnpacker struct {
+	centroid []uint64
+	dims     int
+}
+
+func readCentroid(r *bytes.Reader, dims int) (*vecUnpacker, error) {
+	centroid := make([]uint64, hdc.NumWords(dims))
+	if err := binary.Read(r, binary.LittleEndian, centroid); err != nil {
+		return nil, err
+	}
+	return &vecUnpacker{centroid: centroid, dims: dims}, nil
+}
+
+func (u *vecUnpacker) read(r *bytes.Reader) ([]uint64, error) {
+	mode, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	vec := make([]uint64, len(u.centroid))
+	switch mode {
+	case packRaw:
+		if err := binary.Read(r, binary.LittleEndian, vec); err != nil {
+			return nil, err
+		}
+		return vec, nil
+	case packSparse:
+	default:
+		return nil, fmt.Errorf("unknown vector encoding %d", mode)
+	}
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(u.dims) {
+		return nil, fmt.Errorf("%d differing bits exceed dims %d", n, u.dims)
+	}
+	next := uint64(0)
+	for i := uint64(0); i < n; i++ {
+		gap, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		pos := next + gap
+		if gap >= uint64(u.dims) || pos >= uint64(u.dims) {
+			return nil, fmt.Errorf("bit position %d out of range", pos)
+		}
+		vec[pos/64] |= 1 << (pos % 64)
+		next = pos + 1
+	}
+	for i := range vec {
+		vec[i] ^= u.centroid[i]
+	}
+	return vec, nil
+}
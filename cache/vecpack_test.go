@@ -0,0 +1,68 @@
+package cache_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestEncodeSnapshotCompressed(t *testing.T) {
+	const dims = 10000
+	base := hdc.Random(dims, 1).RawData()
+	snap := cache.Snapshot{Version: 2, Dims: dims, Capacity: 100}
+	for i := 0; i < 50; i++ { // near-duplicates of base: a few bits flipped
+		vec := slices.Clone(base)
+		for j := 0; j < 20; j++ {
+			b := (i*7919 + j*104729) % dims
+			vec[b/64] ^= 1 << (b % 64)
+		}
+		snap.Entries = append(snap.Entries, cache.EntrySnapshot{Key: fmt.Sprint("key ", i), VecData: vec, Value: float64(i)})
+	}
+	snap.Entries = append(snap.Entries, cache.EntrySnapshot{Key: "unrelated", VecData: hdc.Random(dims, 2).RawData(), Value: "x"})
+
+	var raw, packed bytes.Buffer
+	if err := cache.EncodeSnapshot(&raw, snap); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.EncodeSnapshotCompressed(&packed, snap); err != nil {
+		t.Fatal(err)
+	}
+	if packed.Len()*5 > raw.Len() {
+		t.Errorf("compressed %d bytes, raw %d: want at least 5x smaller", packed.Len(), raw.Len())
+	}
+
+	got, err := cache.DecodeSnapshot(&packed, dims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Entries) != len(snap.Entries) {
+		t.Fatalf("%d entries, want %d", len(got.Entries), len(snap.Entries))
+	}
+	for i, e := range got.Entries {
+		if e.Key != snap.Entries[i].Key || !slices.Equal(e.VecData, snap.Entries[i].VecData) {
+			t.Errorf("entry %d (%q) did not round-trip", i, e.Key)
+		}
+	}
+}
+
+func TestEncodeSnapshotCompressed_NotMappable(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	c := cache.New(enc, cache.Options{Threshold: 0.8, Capacity: 8})
+	c.Set("hello", "world")
+	var buf bytes.Buffer
+	if err := cache.EncodeSnapshotCompressed(&buf, c.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	path := t.TempDir() + "/snap.xrdb"
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.OpenMapped(path, enc, 0.8); err == nil {
+		t.Fatal("mapped a compressed snapshot")
+	}
+}
@@ -0,0 +1,66 @@
+package cache
+
+// Watermarks (Options.LowWatermark): a full cache evicts down to the low
+// watermark in one batch instead of one entry per Set, so most Sets on a
+// full cache evict nothing. With Options.BackgroundEviction the batch runs
+// on its own goroutine and the cache overshoots Capacity meanwhile, by up
+// to Capacity−LowWatermark entries; at that hard limit Sets evict inline
+// again.
+
+// lowLocked returns the low watermark, kept below a capacity lowered by
+// SetCapacity.
+func (c *Cache) lowLocked() int { return min(c.low, c.capacity-1) }
+
+// roomLocked makes room for one new entry, key.
+func (c *Cache) roomLocked(key string) {
+	if c.pool != nil {
+		c.groupRoomLocked(key)
+		return
+	}
+	n := c.lru.Len()
+	switch {
+	case n < c.capacity:
+	case c.low <= 0:
+		c.evictLocked(1)
+	case c.background && n < 2*c.capacity-c.lowLocked():
+		c.kickEvictorLocked()
+	default:
+		c.evictLocked(n - c.lowLocked() + 1)
+	}
+}
+
+// trimLocked brings the cache back within capacity after a batch of
+// inserts.
+func (c *Cache) trimLocked() {
+	if c.pool != nil {
+		c.trimGroupsLocked()
+		return
+	}
+	n := c.lru.Len()
+	switch {
+	case n <= c.capacity:
+	case c.low <= 0:
+		c.evictLocked(n - c.capacity)
+	case c.background && n <= 2*c.capacity-c.lowLocked():
+		c.kickEvictorLocked()
+	default:
+		c.evictLocked(n - c.lowLocked())
+	}
+}
+
+// kickEvictorLocked starts a background eviction down to the low
+// watermark unless one is running. Its evict events are delivered on that
+// goroutine.
+func (c *Cache) kickEvictorLocked() {
+	if !c.evicting.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		c.mu.Lock()
+		defer c.unlock()
+		defer c.evicting.Store(false)
+		if over := c.lru.Len() - c.lowLocked(); over > 0 && c.lru.Len() > c.capacity {
+			c.evictLocked(over)
+		}
+	}()
+}
@@ -0,0 +1,84 @@
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+func TestCache_LowWatermark(t *testing.T) {
+	var evicts int
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+		Threshold: 0.75, Capacity: 10, LowWatermark: 6,
+		OnEvent: func(ev cache.Event) {
+			if ev.Kind == cache.EventEvict {
+				evicts++
+			}
+		},
+	})
+	for i := 0; i < 10; i++ {
+		c.Set(fmt.Sprintf("key number %d", i), i)
+	}
+	c.Set("one more key", 10)
+	if c.Len() != 6 || evicts != 5 {
+		t.Fatalf("len %d after %d evictions, want 6 after 5", c.Len(), evicts)
+	}
+	if _, ok, sim := c.Get("key number 9"); !ok || sim != 1 {
+		t.Error("most recent entries must survive")
+	}
+	// The next four Sets fit without evicting.
+	for i := 0; i < 4; i++ {
+		c.Set(fmt.Sprintf("later key %d", i), i)
+	}
+	if c.Len() != 10 || evicts != 5 {
+		t.Errorf("len %d after %d evictions, want 10 after 5", c.Len(), evicts)
+	}
+
+	c.SetMany([]cache.Item{{Key: "batch a", Value: 1}, {Key: "batch b", Value: 2}})
+	if c.Len() != 6 {
+		t.Errorf("SetMany over capacity: len %d, want 6", c.Len())
+	}
+}
+
+func TestCache_BackgroundEviction(t *testing.T) {
+	c := cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), cache.Options{
+		Threshold: 0.75, Capacity: 10, LowWatermark: 6, BackgroundEviction: true,
+	})
+	for i := 0; i < 11; i++ {
+		c.Set(fmt.Sprintf("key number %d", i), i)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Len() != 6 {
+		if time.Now().After(deadline) {
+			t.Fatalf("background eviction left %d entries, want 6", c.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A cache that outruns the evictor is held at the hard limit.
+	for i := 0; i < 1000; i++ {
+		c.Set(fmt.Sprintf("burst key %d", i), i)
+		if n := c.Len(); n > 14 {
+			t.Fatalf("len %d over the hard limit 14", n)
+		}
+	}
+}
+
+func TestCache_LowWatermark_Invalid(t *testing.T) {
+	for _, opts := range []cache.Options{
+		{Threshold: 0.75, Capacity: 10, LowWatermark: 10},
+		{Threshold: 0.75, Capacity: 10, BackgroundEviction: true},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic for %+v", opts)
+				}
+			}()
+			cache.New(hdc.NewNGramEncoder(hdc.DefaultConfig()), opts)
+		}()
+	}
+}
@@ -0,0 +1,102 @@
+package xordb
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CanonicalizeJSON returns key as canonical JSON, for using request structs
+// and maps as cache keys: object fields are sorted, whitespace is dropped
+// and numbers are written one way (1.0, 1e0 and 1 are all "1"), so equal
+// requests give equal keys whatever their field order or formatting.
+// Strings are kept as they are, HTML characters unescaped. key may be
+// anything encoding/json marshals, including a json.RawMessage of existing
+// JSON. Numbers beyond float64 precision are rounded unless they are
+// integers that fit in an int64. Panics if key cannot be marshaled, e.g. a
+// channel or a cyclic value.
+//
+//	db.Set(xordb.CanonicalizeJSON(req), resp)
+func CanonicalizeJSON(key any) string {
+	data, err := json.Marshal(key)
+	if err != nil {
+		panic("xordb: canonicalize JSON: " + err.Error())
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		panic("xordb: canonicalize JSON: " + err.Error())
+	}
+	var b strings.Builder
+	writeCanonical(&b, v)
+	return b.String()
+}
+
+func writeCanonical(b *strings.Builder, v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeString(b, k)
+			b.WriteByte(':')
+			writeCanonical(b, v[k])
+		}
+		b.WriteByte('}')
+	case []any:
+		b.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeCanonical(b, e)
+		}
+		b.WriteByte(']')
+	case string:
+		writeString(b, v)
+	case json.Number:
+		b.WriteString(canonicalNumber(v))
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case nil:
+		b.WriteString("null")
+	}
+}
+
+// writeString writes s as a JSON string without HTML escaping.
+func writeString(b *strings.Builder, s string) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s) // strings always encode
+	b.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+// canonicalNumber writes integers in decimal and everything else in the
+// shortest form that round-trips through float64.
+func canonicalNumber(n json.Number) string {
+	if i, err := n.Int64(); err == nil {
+		return strconv.FormatInt(i, 10)
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return n.String() // out of float64 range; keep as written
+	}
+	if f == 0 {
+		return "0" // also -0
+	}
+	if f >= -1<<63 && f < 1<<63 && f == float64(int64(f)) {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
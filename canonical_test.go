@@ -0,0 +1,62 @@
+package xordb_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestCanonicalizeJSON(t *testing.T) {
+	type msg struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	type request struct {
+		Model       string  `json:"model"`
+		Temperature float64 `json:"temperature"`
+		Messages    []msg   `json:"messages"`
+	}
+	req := request{Model: "gpt-4o", Temperature: 1, Messages: []msg{{"user", "a < b & c"}}}
+	want := `{"messages":[{"content":"a < b & c","role":"user"}],"model":"gpt-4o","temperature":1}`
+	if got := xordb.CanonicalizeJSON(req); got != want {
+		t.Errorf("struct:\n got %s\nwant %s", got, want)
+	}
+
+	raw := json.RawMessage(`{ "temperature": 1.0e0,
+		"model": "gpt-4o", "messages": [ {"role": "user", "content": "a < b & c"} ] }`)
+	if got := xordb.CanonicalizeJSON(raw); got != want {
+		t.Errorf("raw JSON:\n got %s\nwant %s", got, want)
+	}
+	m := map[string]any{"temperature": 1, "model": "gpt-4o", "messages": []map[string]string{{"role": "user", "content": "a < b & c"}}}
+	if got := xordb.CanonicalizeJSON(m); got != want {
+		t.Errorf("map:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestCanonicalizeJSON_Numbers(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{`1`, `1`},
+		{`1.0`, `1`},
+		{`100e-2`, `1`},
+		{`-0.0`, `0`},
+		{`0.1`, `0.1`},
+		{`1.5e300`, `1.5e+300`},
+		{`9007199254740993`, `9007199254740993`}, // int64, not rounded
+		{`[1,2.50,null,true]`, `[1,2.5,null,true]`},
+	}
+	for _, c := range cases {
+		if got := xordb.CanonicalizeJSON(json.RawMessage(c.in)); got != c.want {
+			t.Errorf("CanonicalizeJSON(%s) = %s, want %s", c.in, got, c.want)
+		}
+	}
+}
+
+func TestCanonicalizeJSON_Unmarshalable(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic for a channel")
+		}
+	}()
+	xordb.CanonicalizeJSON(make(chan int))
+}
@@ -0,0 +1,86 @@
+package xordb
+
+import "strings"
+
+// Message is one turn of a chat transcript, as sent to an LLM.
+type Message struct {
+	Role    string // "system", "user", "assistant", ...
+	Content string
+}
+
+// ChatKeyOptions tunes ChatKey. The zero value is a good start.
+type ChatKeyOptions struct {
+	// LatestWeight is the latest user turn's share of the similarity
+	// (default 0.7); the history and system prompt split the rest.
+	LatestWeight float64
+
+	// IgnoreSystem leaves system messages out of the key, for deployments
+	// whose system prompt varies in ways that do not change answers.
+	IgnoreSystem bool
+
+	// HistoryTokens caps the earlier turns kept in the key, most recent
+	// first (default 256; negative drops history). Tokens are counted as
+	// whitespace-separated words.
+	HistoryTokens int
+}
+
+// ChatKey builds a multi-field key (see KeyBuilder) from a chat transcript,
+// the usual key of an LLM cache: the latest user turn, which decides the
+// answer, weighted highest; the turns before it, truncated to
+// HistoryTokens; and the system prompt. Turns after the latest user turn
+// are left out. Every key has the same fields whatever the transcript, so
+// all chat keys built with the same options compare with each other.
+//
+//	db.SetKey(xordb.ChatKey(msgs, xordb.ChatKeyOptions{}), answer)
+func ChatKey(msgs []Message, opts ChatKeyOptions) *KeyBuilder {
+	if opts.LatestWeight == 0 {
+		opts.LatestWeight = 0.7
+	}
+	if opts.HistoryTokens == 0 {
+		opts.HistoryTokens = 256
+	}
+	last := -1
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == "user" {
+			last = i
+			break
+		}
+	}
+	var latest string
+	var system []string
+	var history []Message
+	for i, m := range msgs {
+		switch {
+		case i == last:
+			latest = m.Content
+		case m.Role == "system":
+			system = append(system, m.Content)
+		case i < last:
+			history = append(history, m)
+		}
+	}
+	k := Key().Field("latest", latest).Field("history", truncateHistory(history, opts.HistoryTokens))
+	if !opts.IgnoreSystem {
+		k.Field("system", strings.Join(system, "\n"))
+	}
+	return k.Weight("latest", opts.LatestWeight)
+}
+
+// truncateHistory formats turns as "role: content" lines, keeping the most
+// recent ones that fit in budget words; the oldest kept turn may be cut to
+// its last words.
+func truncateHistory(turns []Message, budget int) string {
+	var lines []string
+	for i := len(turns) - 1; i >= 0 && budget > 0; i-- {
+		words := strings.Fields(turns[i].Content)
+		if len(words) > budget {
+			words = words[len(words)-budget:]
+		}
+		budget -= len(words)
+		lines = append(lines, turns[i].Role+": "+strings.Join(words, " "))
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
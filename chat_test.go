@@ -0,0 +1,70 @@
+package xordb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestChatKey_Fields(t *testing.T) {
+	msgs := []xordb.Message{
+		{"system", "You are terse."},
+		{"user", "hi"},
+		{"assistant", "hello"},
+		{"user", "what is the capital of india"},
+		{"assistant", "Delhi"}, // after the latest user turn
+	}
+	want := `history="user: hi\nassistant: hello" latest="what is the capital of india" system="You are terse."`
+	if got := xordb.ChatKey(msgs, xordb.ChatKeyOptions{}).String(); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	want = `history="user: hi\nassistant: hello" latest="what is the capital of india"`
+	if got := xordb.ChatKey(msgs, xordb.ChatKeyOptions{IgnoreSystem: true}).String(); got != want {
+		t.Errorf("IgnoreSystem: got %s", got)
+	}
+}
+
+func TestChatKey_HistoryTokens(t *testing.T) {
+	msgs := []xordb.Message{
+		{"user", "one two three"},
+		{"assistant", "four five six"},
+		{"user", "latest"},
+	}
+	k := xordb.ChatKey(msgs, xordb.ChatKeyOptions{HistoryTokens: 4}).String()
+	if !strings.Contains(k, `history="user: three\nassistant: four five six"`) {
+		t.Errorf("want the most recent 4 words of history, got %s", k)
+	}
+	k = xordb.ChatKey(msgs, xordb.ChatKeyOptions{HistoryTokens: -1}).String()
+	if !strings.Contains(k, `history=""`) {
+		t.Errorf("want no history, got %s", k)
+	}
+}
+
+func TestChatKey_LatestTurnDominates(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.8))
+	db.SetKey(xordb.ChatKey([]xordb.Message{
+		{"system", "You are a helpful assistant."},
+		{"user", "I am planning a trip to Asia next month."},
+		{"assistant", "Sounds fun! How can I help?"},
+		{"user", "what is the capital of india"},
+	}, xordb.ChatKeyOptions{}), "Delhi")
+
+	// Different small talk, same question: hit.
+	if v, ok, sim := db.GetKey(xordb.ChatKey([]xordb.Message{
+		{"system", "You are a helpful assistant."},
+		{"user", "hello there"},
+		{"user", "what is the capital of india?"},
+	}, xordb.ChatKeyOptions{})); !ok || v != "Delhi" {
+		t.Errorf("same question after other history: hit %v, sim %.3f", ok, sim)
+	}
+	// Same history, different question: miss.
+	if _, ok, sim := db.GetKey(xordb.ChatKey([]xordb.Message{
+		{"system", "You are a helpful assistant."},
+		{"user", "I am planning a trip to Asia next month."},
+		{"assistant", "Sounds fun! How can I help?"},
+		{"user", "how do I apply for a visa"},
+	}, xordb.ChatKeyOptions{})); ok {
+		t.Errorf("different question hit at %.3f", sim)
+	}
+}
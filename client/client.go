@@ -0,0 +1,329 @@
+// Package client talks to an xordb-serve server, optionally through a
+// small local DB (the L1) in front of the shared remote cache (the L2).
+//
+// Lookups try the L1 first and go to the server only on an L1 miss; a
+// remote hit is copied into the L1. Writes go to the server first and then
+// to the L1 (write-through), so a client reads its own writes without a
+// round trip. Writes and deletes by other clients reach an L1 only when
+// its entries expire, so the L1 TTL bounds how stale a read can be.
+//
+// Every request states the protocol version the client speaks and, with an
+// L1, the L1's encoder fingerprint; a server that cannot honor them answers
+// with an error matching ErrIncompatible instead of serving lookups that
+// would score similarity differently from the L1.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// ProtocolVersion is the xordb-serve protocol version the client speaks.
+const ProtocolVersion = 1
+
+// Client is safe for concurrent use.
+type Client struct {
+	base  string
+	http  *http.Client
+	token string
+
+	l1     *xordb.DB // nil = no L1
+	l1TTL  time.Duration
+	l1Opts []xordb.Option
+	l1FP   string // L1 encoder fingerprint, as the server writes it
+}
+
+type Option func(*Client)
+
+// WithHTTPClient overrides the client used to reach the server. The
+// default has a 30s timeout.
+func WithHTTPClient(c *http.Client) Option { return func(cl *Client) { cl.http = c } }
+
+// WithToken sends "Authorization: Bearer <token>", for servers run with
+// -acl.
+func WithToken(token string) Option { return func(c *Client) { c.token = token } }
+
+// WithL1 puts a local DB with at most capacity entries in front of the
+// server, built with opts (e.g. the server's threshold and encoder
+// settings). Zero capacity, the default, disables the L1.
+func WithL1(capacity int, opts ...xordb.Option) Option {
+	return func(c *Client) {
+		c.l1Opts = append([]xordb.Option{xordb.WithCapacity(capacity)}, opts...)
+		if capacity <= 0 {
+			c.l1Opts = nil
+		}
+	}
+}
+
+// WithL1TTL sets how long an entry stays in the L1, and so how stale a
+// read can be after another client's write (default one minute). Entries
+// written with a shorter TTL keep theirs.
+func WithL1TTL(d time.Duration) Option {
+	if d <= 0 {
+		panic("client: L1 TTL must be positive")
+	}
+	return func(c *Client) { c.l1TTL = d }
+}
+
+// New returns a client of the server at baseURL (e.g.
+// "http://cache:7700").
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		base:  strings.TrimRight(baseURL, "/"),
+		http:  &http.Client{Timeout: 30 * time.Second},
+		l1TTL: time.Minute,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.l1Opts != nil {
+		c.l1 = xordb.New(append(c.l1Opts, xordb.WithTTL(c.l1TTL))...)
+		c.l1FP = fmt.Sprintf("%016x", c.l1.EncoderFingerprint())
+	}
+	return c
+}
+
+// L1 returns the local DB, or nil without WithL1, e.g. for its Stats.
+func (c *Client) L1() *xordb.DB { return c.l1 }
+
+// Result is the outcome of Get.
+type Result struct {
+	Value      any
+	Hit        bool
+	Similarity float64
+	Local      bool // served by the L1
+}
+
+// Error is a response from the server with a non-2xx status.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("client: server: HTTP %d", e.Status)
+	}
+	return "client: server: " + e.Message
+}
+
+// ErrIncompatible matches, with errors.Is, the *Error of a server that
+// does not speak the client's protocol version or whose encoder differs
+// from the L1's.
+var ErrIncompatible = errors.New("client: incompatible server")
+
+func (e *Error) Is(target error) bool {
+	return target == ErrIncompatible && e.Status == http.StatusPreconditionFailed
+}
+
+// Version is the server's protocol version range and encoder.
+type Version struct {
+	Protocol    int    `json:"protocol"`
+	MinProtocol int    `json:"min_protocol"`
+	Encoder     string `json:"encoder"` // fingerprint, hex
+	Dims        int    `json:"dims"`
+}
+
+// Version asks the server for its protocol version and encoder. Requests
+// check both anyway; Version is for checking up front, e.g. at startup.
+func (c *Client) Version(ctx context.Context) (Version, error) {
+	var v Version
+	if err := c.do(ctx, http.MethodGet, "/v1/version", nil, &v); err != nil {
+		return v, err
+	}
+	if v.Protocol != 0 && (ProtocolVersion < v.MinProtocol || ProtocolVersion > v.Protocol) {
+		return v, fmt.Errorf("%w: server speaks protocol %d to %d, client %d", ErrIncompatible, v.MinProtocol, v.Protocol, ProtocolVersion)
+	}
+	if c.l1 != nil && v.Encoder != c.l1FP {
+		return v, fmt.Errorf("%w: server encoder %s, L1 encoder %s", ErrIncompatible, v.Encoder, c.l1FP)
+	}
+	return v, nil
+}
+
+// Get looks key up in the L1, then on the server. Values from the server
+// are JSON-decoded, as from a snapshot: numbers are float64, objects
+// map[string]any.
+func (c *Client) Get(ctx context.Context, key string) (Result, error) {
+	if c.l1 != nil {
+		if v, ok, sim := c.l1.Get(key); ok {
+			return Result{Value: v, Hit: true, Similarity: sim, Local: true}, nil
+		}
+	}
+	var res struct {
+		Hit        bool    `json:"hit"`
+		Value      any     `json:"value"`
+		Similarity float64 `json:"similarity"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/get?key="+url.QueryEscape(key), nil, &res); err != nil {
+		return Result{}, err
+	}
+	if res.Hit && c.l1 != nil {
+		c.l1.Set(key, res.Value)
+	}
+	return Result{Value: res.Value, Hit: res.Hit, Similarity: res.Similarity}, nil
+}
+
+// GetMany looks up several keys, at most 1000, in one request: each in
+// the L1, and the misses on the server. Results are in the order of keys.
+func (c *Client) GetMany(ctx context.Context, keys []string) ([]Result, error) {
+	out := make([]Result, len(keys))
+	var remote []string
+	var at []int // index in keys of each remote key
+	for i, key := range keys {
+		if c.l1 != nil {
+			if v, ok, sim := c.l1.Get(key); ok {
+				out[i] = Result{Value: v, Hit: true, Similarity: sim, Local: true}
+				continue
+			}
+		}
+		remote = append(remote, key)
+		at = append(at, i)
+	}
+	if len(remote) == 0 {
+		return out, nil
+	}
+	var res struct {
+		Results []struct {
+			Hit        bool    `json:"hit"`
+			Value      any     `json:"value"`
+			Similarity float64 `json:"similarity"`
+		} `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/v1/mget", map[string][]string{"keys": remote}, &res); err != nil {
+		return nil, err
+	}
+	if len(res.Results) != len(remote) {
+		return nil, fmt.Errorf("client: mget: %d results for %d keys", len(res.Results), len(remote))
+	}
+	for j, r := range res.Results {
+		out[at[j]] = Result{Value: r.Value, Hit: r.Hit, Similarity: r.Similarity}
+		if r.Hit && c.l1 != nil {
+			c.l1.Set(remote[j], r.Value)
+		}
+	}
+	return out, nil
+}
+
+// Set stores value on the server with its default TTL, then in the L1.
+func (c *Client) Set(ctx context.Context, key string, value any) error {
+	return c.set(ctx, key, value, 0)
+}
+
+// SetWithTTL is Set with a TTL; zero means the entry never expires on the
+// server. The L1 keeps it for the shorter of ttl and the L1 TTL.
+func (c *Client) SetWithTTL(ctx context.Context, key string, value any, ttl time.Duration) error {
+	if ttl < 0 {
+		return errors.New("client: negative TTL")
+	}
+	return c.set(ctx, key, value, ttl)
+}
+
+func (c *Client) set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	req := map[string]any{"key": key, "value": value}
+	if ttl > 0 {
+		req["ttl"] = ttl.String()
+	}
+	if err := c.do(ctx, http.MethodPost, "/v1/set", req, nil); err != nil {
+		return err
+	}
+	if c.l1 != nil {
+		if ttl > 0 && ttl < c.l1TTL {
+			c.l1.SetWithTTL(key, value, ttl)
+		} else {
+			c.l1.Set(key, value)
+		}
+	}
+	return nil
+}
+
+// Delete removes key from the L1 and the server, reporting whether the
+// server had it.
+func (c *Client) Delete(ctx context.Context, key string) (bool, error) {
+	if c.l1 != nil {
+		c.l1.Delete(key)
+	}
+	var out struct {
+		Deleted bool `json:"deleted"`
+	}
+	err := c.do(ctx, http.MethodPost, "/v1/delete", map[string]string{"key": key}, &out)
+	return out.Deleted, err
+}
+
+// Feedback labels a hit, for the server's precision estimate and threshold
+// calibration: query was looked up, hitKey is the entry it matched ("" for
+// the entry it matches now), and correct says whether the answer was right.
+// It returns the hit key the server labeled.
+func (c *Client) Feedback(ctx context.Context, query, hitKey string, correct bool) (string, error) {
+	req := struct {
+		Query   string `json:"query"`
+		HitKey  string `json:"hit_key,omitempty"`
+		Correct bool   `json:"correct"`
+	}{query, hitKey, correct}
+	var out struct {
+		HitKey string `json:"hit_key"`
+	}
+	err := c.do(ctx, http.MethodPost, "/v1/feedback", req, &out)
+	return out.HitKey, err
+}
+
+// Stats returns the server's stats; L1().Stats() has the local ones.
+func (c *Client) Stats(ctx context.Context) (xordb.Stats, error) {
+	var st xordb.Stats
+	err := c.do(ctx, http.MethodGet, "/v1/stats", nil, &st)
+	return st, err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, dst any) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("client: %w", err)
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, r)
+	if err != nil {
+		return fmt.Errorf("client: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Set("Xordb-Protocol", strconv.Itoa(ProtocolVersion))
+	if c.l1FP != "" {
+		req.Header.Set("Xordb-Encoder", c.l1FP)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("client: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return &Error{Status: resp.StatusCode, Message: e.Error}
+	}
+	if dst == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("client: decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/client"
+)
+
+// fakeServer serves the parts of the xordb-serve API the client uses.
+func fakeServer(t *testing.T) (*httptest.Server, *xordb.DB, *atomic.Int64) {
+	t.Helper()
+	db := xordb.New(xordb.WithThreshold(0.75))
+	var gets atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/get", func(w http.ResponseWriter, r *http.Request) {
+		gets.Add(1)
+		v, ok, sim := db.Get(r.URL.Query().Get("key"))
+		json.NewEncoder(w).Encode(map[string]any{"hit": ok, "value": v, "similarity": sim})
+	})
+	mux.HandleFunc("POST /v1/set", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "token required"})
+			return
+		}
+		var req struct {
+			Key   string `json:"key"`
+			Value any    `json:"value"`
+			TTL   string `json:"ttl"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		ttl, _ := time.ParseDuration(req.TTL)
+		db.SetWithTTL(req.Key, req.Value, ttl)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /v1/delete", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Key string }
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(map[string]bool{"deleted": db.Delete(req.Key)})
+	})
+	mux.HandleFunc("POST /v1/mget", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Keys []string }
+		json.NewDecoder(r.Body).Decode(&req)
+		var out []map[string]any
+		for _, k := range req.Keys {
+			v, ok, sim := db.Get(k)
+			out = append(out, map[string]any{"hit": ok, "value": v, "similarity": sim})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"results": out})
+	})
+	mux.HandleFunc("GET /v1/stats", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(db.Stats())
+	})
+	mux.HandleFunc("POST /v1/feedback", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query   string
+			HitKey  string `json:"hit_key"`
+			Correct bool
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.HitKey == "" {
+			req.HitKey = db.Lookup(req.Query).MatchedKey
+		}
+		db.Feedback(req.Query, req.HitKey, req.Correct)
+		json.NewEncoder(w).Encode(map[string]string{"hit_key": req.HitKey})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, db, &gets
+}
+
+func TestClient_L1(t *testing.T) {
+	srv, remote, gets := fakeServer(t)
+	ctx := context.Background()
+	c := client.New(srv.URL, client.WithToken("t"), client.WithL1(64, xordb.WithThreshold(0.75)))
+
+	// Write-through: the writer's reads are local.
+	if err := c.Set(ctx, "what is the capital of india", "Delhi"); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := c.Get(ctx, "what is the capital of india"); err != nil || !res.Hit || !res.Local || res.Value != "Delhi" {
+		t.Errorf("Get = %+v, %v", res, err)
+	}
+	if gets.Load() != 0 {
+		t.Errorf("%d remote gets, want an L1 hit", gets.Load())
+	}
+
+	// Another client's write is fetched once, then served locally.
+	remote.Set("who wrote war and peace", "Tolstoy")
+	for i, local := range []bool{false, true} {
+		res, err := c.Get(ctx, "who wrote war and peace")
+		if err != nil || !res.Hit || res.Local != local || res.Value != "Tolstoy" {
+			t.Errorf("Get %d = %+v, %v", i, res, err)
+		}
+	}
+	if gets.Load() != 1 {
+		t.Errorf("%d remote gets, want 1", gets.Load())
+	}
+
+	remote.Set("how tall is mount everest", "8849 m")
+	rs, err := c.GetMany(ctx, []string{"who wrote war and peace", "how tall is mount everest", "when did the berlin wall fall"})
+	if err != nil || len(rs) != 3 || !rs[0].Local || rs[1].Local || rs[1].Value != "8849 m" || rs[2].Hit {
+		t.Errorf("GetMany = %+v, %v", rs, err)
+	}
+	if res, _ := c.Get(ctx, "how tall is mount everest"); !res.Local {
+		t.Error("GetMany did not fill the L1")
+	}
+	remote.Delete("how tall is mount everest")
+
+	if res, _ := c.Get(ctx, "when did the berlin wall fall"); res.Hit {
+		t.Errorf("unrelated key hit: %+v", res)
+	}
+	if ok, err := c.Delete(ctx, "what is the capital of india"); !ok || err != nil {
+		t.Errorf("Delete = %v, %v", ok, err)
+	}
+	if res, _ := c.Get(ctx, "what is the capital of india"); res.Hit {
+		t.Errorf("deleted key hit: %+v", res)
+	}
+	if st, err := c.Stats(ctx); err != nil || st.Entries != 1 {
+		t.Errorf("Stats = %d entries, %v", st.Entries, err)
+	}
+}
+
+func TestClient_NoL1(t *testing.T) {
+	srv, _, gets := fakeServer(t)
+	ctx := context.Background()
+	c := client.New(srv.URL, client.WithToken("t"))
+	if c.L1() != nil {
+		t.Fatal("L1 without WithL1")
+	}
+	c.SetWithTTL(ctx, "what is the capital of india", "Delhi", time.Hour)
+	res, err := c.Get(ctx, "what is the capital of india")
+	if err != nil || !res.Hit || res.Local || gets.Load() != 1 {
+		t.Errorf("Get = %+v, %v after %d remote gets", res, err, gets.Load())
+	}
+
+	err = client.New(srv.URL).Set(ctx, "k", 1)
+	var se *client.Error
+	if !errors.As(err, &se) || se.Status != http.StatusUnauthorized || se.Message != "token required" {
+		t.Errorf("Set without a token = %v", err)
+	}
+}
+
+func TestClient_Feedback(t *testing.T) {
+	srv, remote, _ := fakeServer(t)
+	ctx := context.Background()
+	c := client.New(srv.URL, client.WithToken("t"))
+	c.Set(ctx, "what is the capital of india", "Delhi")
+	hit, err := c.Feedback(ctx, "what is the capital of india?", "", false)
+	if err != nil || hit != "what is the capital of india" {
+		t.Fatalf("Feedback = %q, %v", hit, err)
+	}
+	if st := remote.Stats(); st.FeedbackWrong != 1 {
+		t.Errorf("server stats %+v", st)
+	}
+}
+
+func TestClient_Version(t *testing.T) {
+	server := xordb.New()
+	fp := fmt.Sprintf("%016x", server.EncoderFingerprint())
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/version", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(client.Version{Protocol: 1, MinProtocol: 1, Encoder: fp, Dims: server.Dims()})
+	})
+	mux.HandleFunc("GET /v1/get", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Xordb-Protocol") != "1" {
+			t.Errorf("protocol header %q", r.Header.Get("Xordb-Protocol"))
+		}
+		if got := r.Header.Get("Xordb-Encoder"); got != "" && got != fp {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			json.NewEncoder(w).Encode(map[string]string{"error": "encoder mismatch"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"hit": false})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	same := client.New(srv.URL, client.WithL1(8))
+	if v, err := same.Version(ctx); err != nil || v.Encoder != fp {
+		t.Errorf("Version = %+v, %v", v, err)
+	}
+	if _, err := same.Get(ctx, "q"); err != nil {
+		t.Errorf("Get with a matching L1: %v", err)
+	}
+
+	other := client.New(srv.URL, client.WithL1(8, xordb.WithDims(2048)))
+	if _, err := other.Version(ctx); !errors.Is(err, client.ErrIncompatible) {
+		t.Errorf("Version with another encoder: %v", err)
+	}
+	if _, err := other.Get(ctx, "q"); !errors.Is(err, client.ErrIncompatible) {
+		t.Errorf("Get with another encoder: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package xordb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// ErrClosed is returned by calls on a DB after Close.
+var ErrClosed = cache.ErrClosed
+
+// WithSaveOnClose makes Close save a snapshot to path (see Save) once
+// traffic has stopped.
+func WithSaveOnClose(path string) Option {
+	return func(o *dbOptions) { o.saveOnClose = path }
+}
+
+// Close shuts the DB down: it stops taking traffic, waits for a running
+// WithNearMissVerifier check (pending ones are dropped) until ctx is done,
+// saves to the WithSaveOnClose path, flushes the query log writer if it has
+// a Flush() error method, and closes the encoder and fallback encoder if
+// they are io.Closers — embed.MiniLMEncoder is, so an encoder shared with
+// another DB must not be passed to one that is closed.
+//
+// Afterwards sets are dropped, Get and Lookup miss, and TryLookup, Fetch,
+// SetVec, GetVec, SetEmbedding, GetByEmbedding, WarmFromJSONL and the load
+// methods return ErrClosed. Save, WriteSnapshot, ExportJSONL, Stats and the
+// other read-only maintenance calls keep working, so the contents can
+// still be persisted. A second Close returns ErrClosed.
+func (db *DB) Close(ctx context.Context) error {
+	if !db.closed.CompareAndSwap(false, true) {
+		return ErrClosed
+	}
+	db.c.Close()
+	var errs []error
+	if err := db.stopWarmup(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if db.vf != nil {
+		select {
+		case <-db.vf.stop():
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("xordb: close: near-miss verifier: %w", ctx.Err()))
+		}
+	}
+	if db.onClose != "" {
+		errs = append(errs, db.Save(db.onClose))
+	}
+	if db.ql != nil {
+		errs = append(errs, db.ql.flush())
+	}
+	for _, c := range db.closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("xordb: close: encoder: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkOpen returns ErrClosed after Close.
+func (db *DB) checkOpen() error {
+	if db.closed.Load() {
+		return ErrClosed
+	}
+	return nil
+}
+
+// flush flushes the log writer, if it buffers.
+func (l *queryLog) flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, ok := l.w.(interface{ Flush() error })
+	if !ok {
+		return nil
+	}
+	if err := f.Flush(); err != nil {
+		return fmt.Errorf("xordb: close: query log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package xordb_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+)
+
+type closingEncoder struct {
+	hdc.Encoder
+	closed int
+}
+
+func (e *closingEncoder) Close() error {
+	e.closed++
+	return nil
+}
+
+type flushBuffer struct {
+	strings.Builder
+	flushed bool
+}
+
+func (b *flushBuffer) Flush() error {
+	b.flushed = true
+	return nil
+}
+
+func TestDB_Close(t *testing.T) {
+	cfg := hdc.Config{Dims: 2048, NGramSize: 3, LongTextThresh: 200, ChunkSize: 128}
+	enc := &closingEncoder{Encoder: hdc.NewNGramEncoder(cfg)}
+	var log flushBuffer
+	path := filepath.Join(t.TempDir(), "cache.xrdb")
+	db := xordb.NewWithEncoder(enc, xordb.WithSeed(7), xordb.WithQueryLog(&log), xordb.WithSaveOnClose(path))
+	db.Set("what is the capital of india", "Delhi")
+
+	if err := db.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if enc.closed != 1 || !log.flushed {
+		t.Fatalf("encoder closed %d times, log flushed %v", enc.closed, log.flushed)
+	}
+	if err := db.Close(context.Background()); !errors.Is(err, xordb.ErrClosed) {
+		t.Fatalf("second Close: %v", err)
+	}
+	if enc.closed != 1 {
+		t.Fatal("second Close must not close the encoder again")
+	}
+
+	db.Set("capital of france", "Paris")
+	if _, ok, _ := db.Get("what is the capital of india"); ok {
+		t.Fatal("Get after Close must miss")
+	}
+	if _, err := db.TryLookup("what is the capital of india"); !errors.Is(err, xordb.ErrClosed) {
+		t.Fatalf("TryLookup: %v", err)
+	}
+	if _, err := db.Fetch("x", func(string) (any, error) { return 1, nil }); !errors.Is(err, xordb.ErrClosed) {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if err := db.Load(path); !errors.Is(err, xordb.ErrClosed) {
+		t.Fatalf("Load: %v", err)
+	}
+	if db.Len() != 1 {
+		t.Fatalf("sets after Close must be dropped, len %d", db.Len())
+	}
+
+	saved := xordb.NewWithEncoder(hdc.NewNGramEncoder(cfg), xordb.WithSeed(7))
+	if err := saved.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, _ := saved.Get("what is the capital of india"); !ok || v != "Delhi" {
+		t.Fatalf("WithSaveOnClose snapshot: %v %v", v, ok)
+	}
+}
+
+func TestDB_Close_WaitsForVerifier(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	db := xordb.New(xordb.WithThreshold(0.75), xordb.WithNearMissVerifier(0.1, func(q, cand string) bool {
+		started <- struct{}{}
+		<-release
+		return true
+	}))
+	db.Set("what is the capital of india", "Delhi")
+	db.Get("capital city of india")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := db.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close with a running verification: %v", err)
+	}
+	close(release)
+}
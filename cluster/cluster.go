@@ -0,0 +1,192 @@
+// Package cluster queries several xordb-serve nodes as one cache. Each
+// node holds its own entries — a shard of the corpus — and a lookup asks
+// every node, merging their hits into a global top-K above a threshold.
+//
+// A node that fails or exceeds its timeout does not fail the lookup: the
+// results come from the nodes that answered, and a *PartialError names the
+// ones that did not, so callers choose between using a partial answer and
+// retrying.
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Amansingh-afk/xordb/client"
+)
+
+// Node is one server of the cluster.
+type Node struct {
+	Name   string // in results and errors; unique within the cluster
+	Client *client.Client
+}
+
+// Cluster is safe for concurrent use.
+type Cluster struct {
+	nodes     []Node
+	timeout   time.Duration
+	threshold float64
+	topK      int
+}
+
+type Option func(*Cluster)
+
+// WithNodeTimeout bounds how long a lookup waits for each node (default
+// one second). The caller's context still bounds the whole lookup.
+func WithNodeTimeout(d time.Duration) Option {
+	if d <= 0 {
+		panic("cluster: node timeout must be positive")
+	}
+	return func(c *Cluster) { c.timeout = d }
+}
+
+// WithThreshold drops node hits below sim, on top of each node's own
+// threshold (default 0: keep every hit).
+func WithThreshold(sim float64) Option {
+	if sim < 0 || sim > 1 {
+		panic("cluster: threshold must be in [0, 1]")
+	}
+	return func(c *Cluster) { c.threshold = sim }
+}
+
+// WithTopK keeps the k most similar hits per key across nodes (default 1).
+func WithTopK(k int) Option {
+	if k <= 0 {
+		panic("cluster: top-K must be positive")
+	}
+	return func(c *Cluster) { c.topK = k }
+}
+
+// New returns a cluster of nodes.
+func New(nodes []Node, opts ...Option) *Cluster {
+	if len(nodes) == 0 {
+		panic("cluster: no nodes")
+	}
+	seen := make(map[string]bool)
+	for _, n := range nodes {
+		if n.Client == nil {
+			panic(fmt.Sprintf("cluster: node %q has no client", n.Name))
+		}
+		if seen[n.Name] {
+			panic(fmt.Sprintf("cluster: duplicate node %q", n.Name))
+		}
+		seen[n.Name] = true
+	}
+	c := &Cluster{nodes: nodes, timeout: time.Second, topK: 1}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Match is a hit on one node.
+type Match struct {
+	Node       string
+	Value      any
+	Similarity float64
+}
+
+// Result holds the hits for one key, most similar first.
+type Result struct {
+	Key     string
+	Matches []Match // empty = miss on every node that answered
+}
+
+// Hit reports whether any node had a match.
+func (r Result) Hit() bool { return len(r.Matches) > 0 }
+
+// Best returns the most similar match; ok is false on a miss.
+func (r Result) Best() (m Match, ok bool) {
+	if len(r.Matches) == 0 {
+		return Match{}, false
+	}
+	return r.Matches[0], true
+}
+
+// PartialError reports nodes that failed a lookup whose results come from
+// the others.
+type PartialError struct {
+	Failed map[string]error // by node name
+}
+
+func (e *PartialError) Error() string {
+	names := make([]string, 0, len(e.Failed))
+	for name := range e.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s: %v", name, e.Failed[name])
+	}
+	return fmt.Sprintf("cluster: %d node(s) failed: %s", len(names), strings.Join(parts, "; "))
+}
+
+// ErrAllFailed is wrapped by the error of a lookup no node answered.
+var ErrAllFailed = errors.New("cluster: every node failed")
+
+// GetMany looks keys up on every node in parallel and returns, per key in
+// order, the top-K hits at or above the threshold. If some nodes fail it
+// returns the others' results with a *PartialError; if all do, no results
+// and an error wrapping ErrAllFailed and the *PartialError.
+func (c *Cluster) GetMany(ctx context.Context, keys []string) ([]Result, error) {
+	perNode := make([][]client.Result, len(c.nodes))
+	errs := make([]error, len(c.nodes))
+	var wg sync.WaitGroup
+	for i, n := range c.nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			perNode[i], errs[i] = n.Client.GetMany(nctx, keys)
+		}()
+	}
+	wg.Wait()
+
+	out := make([]Result, len(keys))
+	for k, key := range keys {
+		out[k].Key = key
+	}
+	failed := make(map[string]error)
+	for i, n := range c.nodes {
+		if errs[i] != nil {
+			failed[n.Name] = errs[i]
+			continue
+		}
+		for k, r := range perNode[i] {
+			if r.Hit && r.Similarity >= c.threshold {
+				out[k].Matches = append(out[k].Matches, Match{Node: n.Name, Value: r.Value, Similarity: r.Similarity})
+			}
+		}
+	}
+	for k := range out {
+		m := out[k].Matches
+		sort.SliceStable(m, func(a, b int) bool { return m[a].Similarity > m[b].Similarity })
+		if len(m) > c.topK {
+			out[k].Matches = m[:c.topK]
+		}
+	}
+
+	switch {
+	case len(failed) == len(c.nodes):
+		return nil, fmt.Errorf("%w: %w", ErrAllFailed, &PartialError{Failed: failed})
+	case len(failed) > 0:
+		return out, &PartialError{Failed: failed}
+	}
+	return out, nil
+}
+
+// Get is GetMany for one key.
+func (c *Cluster) Get(ctx context.Context, key string) (Result, error) {
+	rs, err := c.GetMany(ctx, []string{key})
+	if len(rs) == 0 {
+		return Result{Key: key}, err
+	}
+	return rs[0], err
+}
@@ -0,0 +1,94 @@
+package cluster_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/client"
+	"github.com/Amansingh-afk/xordb/cluster"
+)
+
+// node serves POST /v1/mget from db after delay.
+func node(t *testing.T, db *xordb.DB, delay time.Duration) *client.Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/mget", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		var req struct{ Keys []string }
+		json.NewDecoder(r.Body).Decode(&req)
+		var out []map[string]any
+		for _, k := range req.Keys {
+			v, ok, sim := db.Get(k)
+			out = append(out, map[string]any{"hit": ok, "value": v, "similarity": sim})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"results": out})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return client.New(srv.URL)
+}
+
+func TestCluster_GetMany(t *testing.T) {
+	a := xordb.New(xordb.WithThreshold(0.6))
+	a.Set("what is the capital of india", "Delhi (a)")
+	b := xordb.New(xordb.WithThreshold(0.6))
+	b.Set("capital city of india", "Delhi (b)")
+	b.Set("who wrote war and peace", "Tolstoy")
+
+	c := cluster.New([]cluster.Node{{"a", node(t, a, 0)}, {"b", node(t, b, 0)}}, cluster.WithTopK(2))
+	rs, err := c.GetMany(context.Background(), []string{"what is the capital of india", "who wrote war and peace", "how tall is mount everest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := rs[0].Matches; len(m) != 2 || m[0].Node != "a" || m[0].Similarity != 1 || m[1].Node != "b" {
+		t.Errorf("capital: %+v", m)
+	}
+	if best, ok := rs[1].Best(); !ok || best.Node != "b" || best.Value != "Tolstoy" {
+		t.Errorf("war and peace: %+v", rs[1])
+	}
+	if rs[2].Hit() || rs[2].Key != "how tall is mount everest" {
+		t.Errorf("everest: %+v", rs[2])
+	}
+
+	// Top-1 and a global threshold above b's match.
+	c = cluster.New([]cluster.Node{{"a", node(t, a, 0)}, {"b", node(t, b, 0)}}, cluster.WithThreshold(0.99))
+	if r, _ := c.Get(context.Background(), "what is the capital of india"); len(r.Matches) != 1 || r.Matches[0].Node != "a" {
+		t.Errorf("top-1: %+v", r)
+	}
+}
+
+func TestCluster_Partial(t *testing.T) {
+	a := xordb.New()
+	a.Set("what is the capital of india", "Delhi")
+	slow := xordb.New()
+	c := cluster.New([]cluster.Node{{"a", node(t, a, 0)}, {"slow", node(t, slow, time.Second)}},
+		cluster.WithNodeTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	r, err := c.Get(context.Background(), "what is the capital of india")
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("waited %v for the slow node", time.Since(start))
+	}
+	var pe *cluster.PartialError
+	if !errors.As(err, &pe) || len(pe.Failed) != 1 || pe.Failed["slow"] == nil {
+		t.Fatalf("err = %v", err)
+	}
+	if !r.Hit() || r.Matches[0].Value != "Delhi" {
+		t.Errorf("partial result %+v", r)
+	}
+
+	c = cluster.New([]cluster.Node{{"slow", node(t, slow, time.Second)}}, cluster.WithNodeTimeout(50*time.Millisecond))
+	if _, err := c.Get(context.Background(), "q"); !errors.Is(err, cluster.ErrAllFailed) || !errors.As(err, &pe) {
+		t.Errorf("all failed: err = %v", err)
+	}
+}
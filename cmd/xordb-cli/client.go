@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// client — thin wrapper over the xordb-serve HTTP API.
+type client struct {
+	base string
+	http *http.Client
+}
+
+func newClient(addr string) *client {
+	return &client{
+		base: strings.TrimRight(addr, "/"),
+		http: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type getResult struct {
+	Hit        bool    `json:"hit"`
+	Value      any     `json:"value,omitempty"`
+	Similarity float64 `json:"similarity"`
+}
+
+type candidate struct {
+	Key        string
+	Similarity float64
+	Hit        bool
+}
+
+func (c *client) set(key string, value any, ttl string) error {
+	body := map[string]any{"key": key, "value": value}
+	if ttl != "" {
+		body["ttl"] = ttl
+	}
+	return c.postJSON("/v1/set", body, nil)
+}
+
+func (c *client) get(key string) (getResult, json.RawMessage, error) {
+	var res getResult
+	raw, err := c.getJSON("/v1/get?key="+url.QueryEscape(key), &res)
+	return res, raw, err
+}
+
+func (c *client) del(key string) (bool, error) {
+	var out struct {
+		Deleted bool `json:"deleted"`
+	}
+	err := c.postJSON("/v1/delete", map[string]string{"key": key}, &out)
+	return out.Deleted, err
+}
+
+func (c *client) stats() (map[string]any, json.RawMessage, error) {
+	var out map[string]any
+	raw, err := c.getJSON("/v1/stats", &out)
+	return out, raw, err
+}
+
+func (c *client) explain(key string, n int) ([]candidate, json.RawMessage, error) {
+	var out []candidate
+	raw, err := c.getJSON("/v1/explain?n="+strconv.Itoa(n)+"&key="+url.QueryEscape(key), &out)
+	return out, raw, err
+}
+
+// export streams the server's dump, JSONL or CSV, into w.
+func (c *client) export(w io.Writer, format string) error {
+	resp, err := c.http.Get(c.base + "/v1/export?format=" + url.QueryEscape(format))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// importJSONL uploads a JSONL dump and returns the number of entries loaded.
+func (c *client) importJSONL(r io.Reader) (int, error) {
+	resp, err := c.http.Post(c.base+"/v1/import", "application/x-ndjson", r)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Imported int    `json:"imported"`
+		Error    string `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&out)
+	if out.Error != "" {
+		return out.Imported, fmt.Errorf("server: %s", out.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return out.Imported, fmt.Errorf("server: HTTP %d", resp.StatusCode)
+	}
+	return out.Imported, nil
+}
+
+func (c *client) getJSON(path string, dst any) (json.RawMessage, error) {
+	resp, err := c.http.Get(c.base + path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return decodeResponse(resp, dst)
+}
+
+func (c *client) postJSON(path string, body, dst any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Post(c.base+path, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = decodeResponse(resp, dst)
+	return err
+}
+
+func decodeResponse(resp *http.Response, dst any) (json.RawMessage, error) {
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if dst != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, dst); err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+	}
+	return raw, nil
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	var e struct {
+		Error string `json:"error"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+		return fmt.Errorf("server: %s", e.Error)
+	}
+	return fmt.Errorf("server: HTTP %d", resp.StatusCode)
+}
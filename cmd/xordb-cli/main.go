@@ -0,0 +1,253 @@
+// xordb-cli — inspect and seed a running xordb-serve from the shell.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const usage = `xordb-cli — client for xordb-serve
+
+Usage:
+  xordb-cli [-addr URL] [-json] <command> [args]
+
+Commands:
+  set [-ttl 10m] <key> <value>   Store value (parsed as JSON if valid, else string)
+  get <key>                      Semantic lookup
+  del <key>                      Delete by exact key
+  stats                          Show cache stats
+  explain [-n 10] <key>          Show the closest keys and their similarity
+  export [-format csv] [file]    Dump entries as JSONL, or CSV rows for analysis (default: stdout)
+  import [file]                  Load JSONL entries (default: stdin)
+
+Environment:
+  XORDB_ADDR    Server address (default http://localhost:7700)`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("xordb-cli", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprintln(stderr, usage) }
+	addr := fs.String("addr", envOr("XORDB_ADDR", "http://localhost:7700"), "xordb-serve address")
+	asJSON := fs.Bool("json", false, "print raw JSON responses")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	c := newClient(*addr)
+	cmd, rest := fs.Arg(0), fs.Args()[1:]
+	var err error
+	switch cmd {
+	case "set":
+		err = cmdSet(c, rest, stdout, stderr, *asJSON)
+	case "get":
+		err = cmdGet(c, rest, stdout, *asJSON)
+	case "del", "delete":
+		err = cmdDel(c, rest, stdout, *asJSON)
+	case "stats":
+		err = cmdStats(c, stdout, *asJSON)
+	case "explain":
+		err = cmdExplain(c, rest, stdout, stderr, *asJSON)
+	case "export":
+		err = cmdExport(c, rest, stdout, stderr)
+	case "import":
+		err = cmdImport(c, rest, stdin, stdout, *asJSON)
+	case "help":
+		fmt.Fprintln(stdout, usage)
+	default:
+		fmt.Fprintf(stderr, "unknown command: %s\n", cmd)
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func cmdSet(c *client, args []string, stdout, stderr io.Writer, asJSON bool) error {
+	fs := flag.NewFlagSet("set", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	ttl := fs.String("ttl", "", "entry lifetime (Go duration)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: set [-ttl 10m] <key> <value>")
+	}
+	if err := c.set(fs.Arg(0), parseValue(fs.Arg(1)), *ttl); err != nil {
+		return err
+	}
+	if asJSON {
+		fmt.Fprintln(stdout, `{"ok":true}`)
+	} else {
+		fmt.Fprintln(stdout, "OK")
+	}
+	return nil
+}
+
+func cmdGet(c *client, args []string, stdout io.Writer, asJSON bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: get <key>")
+	}
+	res, raw, err := c.get(args[0])
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return writeRaw(stdout, raw)
+	}
+	if !res.Hit {
+		fmt.Fprintln(stdout, "MISS")
+		return nil
+	}
+	fmt.Fprintf(stdout, "HIT  sim=%.4f\n%s\n", res.Similarity, formatValue(res.Value))
+	return nil
+}
+
+func cmdDel(c *client, args []string, stdout io.Writer, asJSON bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: del <key>")
+	}
+	ok, err := c.del(args[0])
+	if err != nil {
+		return err
+	}
+	switch {
+	case asJSON:
+		fmt.Fprintf(stdout, "{\"deleted\":%t}\n", ok)
+	case ok:
+		fmt.Fprintln(stdout, "deleted")
+	default:
+		fmt.Fprintln(stdout, "not found")
+	}
+	return nil
+}
+
+func cmdStats(c *client, stdout io.Writer, asJSON bool) error {
+	stats, raw, err := c.stats()
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return writeRaw(stdout, raw)
+	}
+	for _, k := range []string{"entries", "hits", "misses", "sets", "expired", "hit_rate", "avg_sim_on_hit", "lsh_candidates", "lsh_fallbacks"} {
+		if v, ok := stats[k]; ok {
+			fmt.Fprintf(stdout, "%-14s %v\n", k, v)
+		}
+	}
+	return nil
+}
+
+func cmdExplain(c *client, args []string, stdout, stderr io.Writer, asJSON bool) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	n := fs.Int("n", 10, "number of candidates")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: explain [-n 10] <key>")
+	}
+	cands, raw, err := c.explain(fs.Arg(0), *n)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return writeRaw(stdout, raw)
+	}
+	for _, cd := range cands {
+		mark := " "
+		if cd.Hit {
+			mark = "✓"
+		}
+		fmt.Fprintf(stdout, "%s %.4f  %s\n", mark, cd.Similarity, cd.Key)
+	}
+	return nil
+}
+
+func cmdExport(c *client, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "jsonl", "jsonl (re-importable) or csv (key, value hash, hits, ...)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 || fs.Arg(0) == "-" {
+		return c.export(stdout, *format)
+	}
+	f, err := os.Create(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := c.export(f, *format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func cmdImport(c *client, args []string, stdin io.Reader, stdout io.Writer, asJSON bool) error {
+	r := stdin
+	if len(args) > 0 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	n, err := c.importJSONL(r)
+	if err != nil {
+		return fmt.Errorf("imported %d entries before failing: %w", n, err)
+	}
+	if asJSON {
+		fmt.Fprintf(stdout, "{\"imported\":%d}\n", n)
+	} else {
+		fmt.Fprintf(stdout, "imported %d entries\n", n)
+	}
+	return nil
+}
+
+// parseValue — valid JSON (numbers, objects, quoted strings) is sent as-is,
+// anything else as a plain string.
+func parseValue(s string) any {
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err == nil {
+		return v
+	}
+	return s
+}
+
+func formatValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func writeRaw(w io.Writer, raw []byte) error {
+	_, err := fmt.Fprintln(w, strings.TrimSpace(string(raw)))
+	return err
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// ── helpers ───────────────────────────────────────────────────────────────────
+
+// fakeServe mimics the xordb-serve API on top of a real DB.
+func fakeServe(t *testing.T) (string, *xordb.DB) {
+	t.Helper()
+	db := xordb.New(xordb.WithThreshold(0.70))
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/set", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Key   string `json:"key"`
+			Value any    `json:"value"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		db.Set(req.Key, req.Value)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /v1/get", func(w http.ResponseWriter, r *http.Request) {
+		v, ok, sim := db.Get(r.URL.Query().Get("key"))
+		writeJSON(w, map[string]any{"hit": ok, "value": v, "similarity": sim})
+	})
+	mux.HandleFunc("POST /v1/delete", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Key string }
+		json.NewDecoder(r.Body).Decode(&req)
+		writeJSON(w, map[string]bool{"deleted": db.Delete(req.Key)})
+	})
+	mux.HandleFunc("GET /v1/stats", func(w http.ResponseWriter, r *http.Request) { writeJSON(w, db.Stats()) })
+	mux.HandleFunc("GET /v1/explain", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, db.Explain(r.URL.Query().Get("key"), 10))
+	})
+	mux.HandleFunc("GET /v1/export", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") == "csv" {
+			db.ExportCSV(w)
+			return
+		}
+		db.ExportJSONL(w)
+	})
+	mux.HandleFunc("POST /v1/import", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := db.WarmFromJSONL(r.Body)
+		writeJSON(w, map[string]int{"imported": n})
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv.URL, db
+}
+
+func runCLI(t *testing.T, addr, stdin string, args ...string) (string, int) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	code := run(append([]string{"-addr", addr}, args...), strings.NewReader(stdin), &out, &errOut)
+	if code != 0 {
+		return errOut.String(), code
+	}
+	return out.String(), code
+}
+
+// ── commands ──────────────────────────────────────────────────────────────────
+
+func TestCLI_SetGet(t *testing.T) {
+	addr, db := fakeServe(t)
+
+	if out, code := runCLI(t, addr, "", "set", "what is the capital of india", "Delhi"); code != 0 || out != "OK\n" {
+		t.Fatalf("set: code=%d out=%q", code, out)
+	}
+	if db.Len() != 1 {
+		t.Fatalf("set must reach the server, len=%d", db.Len())
+	}
+
+	out, code := runCLI(t, addr, "", "get", "capital city of india")
+	if code != 0 || !strings.HasPrefix(out, "HIT") || !strings.Contains(out, "Delhi") {
+		t.Fatalf("get: code=%d out=%q", code, out)
+	}
+
+	out, _ = runCLI(t, addr, "", "-json", "get", "how do you bake a chocolate cake")
+	var res getResult
+	if err := json.Unmarshal([]byte(out), &res); err != nil || res.Hit {
+		t.Fatalf("json miss output: %q (err=%v)", out, err)
+	}
+}
+
+func TestCLI_SetParsesJSONValue(t *testing.T) {
+	addr, db := fakeServe(t)
+	runCLI(t, addr, "", "set", "n", "42")
+	if v, _, _ := db.Get("n"); v != float64(42) {
+		t.Fatalf("numeric value must be sent as JSON number, got %T %v", v, v)
+	}
+}
+
+func TestCLI_DelStatsExplain(t *testing.T) {
+	addr, db := fakeServe(t)
+	db.Set("alpha", 1)
+	db.Set("beta", 2)
+
+	if out, _ := runCLI(t, addr, "", "del", "alpha"); out != "deleted\n" {
+		t.Fatalf("del: %q", out)
+	}
+	if out, _ := runCLI(t, addr, "", "stats"); !strings.Contains(out, "entries") {
+		t.Fatalf("stats: %q", out)
+	}
+	if out, _ := runCLI(t, addr, "", "explain", "beta"); !strings.Contains(out, "1.0000  beta") {
+		t.Fatalf("explain: %q", out)
+	}
+}
+
+func TestCLI_ExportImport(t *testing.T) {
+	addr, db := fakeServe(t)
+	db.Set("alpha", "A")
+
+	dump, code := runCLI(t, addr, "", "export")
+	if code != 0 || !strings.Contains(dump, `"key":"alpha"`) {
+		t.Fatalf("export: code=%d out=%q", code, dump)
+	}
+
+	if out, code := runCLI(t, addr, "", "export", "-format", "csv"); code != 0 || !strings.HasPrefix(out, "key,value_sha256,") || !strings.Contains(out, "\nalpha,") {
+		t.Fatalf("export csv: code=%d out=%q", code, out)
+	}
+
+	addr2, db2 := fakeServe(t)
+	if out, code := runCLI(t, addr2, dump, "import"); code != 0 || out != "imported 1 entries\n" {
+		t.Fatalf("import: code=%d out=%q", code, out)
+	}
+	if db2.Len() != 1 {
+		t.Fatalf("import must load entries, len=%d", db2.Len())
+	}
+}
+
+func TestCLI_Usage(t *testing.T) {
+	if _, code := runCLI(t, "http://unused", ""); code != 2 {
+		t.Fatalf("no command must exit 2, got %d", code)
+	}
+	if _, code := runCLI(t, "http://unused", "", "bogus"); code != 2 {
+		t.Fatalf("unknown command must exit 2, got %d", code)
+	}
+	if _, code := runCLI(t, "http://unused", "", "get"); code != 1 {
+		t.Fatalf("missing args must exit 1, got %d", code)
+	}
+}
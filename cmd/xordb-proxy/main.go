@@ -0,0 +1,48 @@
+// xordb-proxy — semantic caching proxy for OpenAI-compatible chat APIs.
+//
+// Point your client's base URL at the proxy; POST /v1/chat/completions
+// requests are answered from cache when a semantically similar conversation
+// was seen before under the same model and temperature. Everything else
+// (streaming, other endpoints) is passed through untouched.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func main() {
+	listen := flag.String("listen", ":8080", "address to listen on")
+	upstream := flag.String("upstream", "https://api.openai.com", "OpenAI-compatible API base URL")
+	threshold := flag.Float64("threshold", 0.90, "minimum similarity for a cache hit")
+	capacity := flag.Int("capacity", 4096, "max cached completions per namespace")
+	ttl := flag.Duration("ttl", 0, "cached completion lifetime (0 = never expires)")
+	namespaces := flag.Int("namespaces", defaultMaxNamespaces, "max (model, temperature) namespaces kept; the least recently used is dropped")
+	flag.Parse()
+
+	u, err := url.Parse(*upstream)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		fmt.Fprintf(os.Stderr, "error: invalid -upstream %q\n", *upstream)
+		os.Exit(1)
+	}
+
+	p := newProxy(u,
+		xordb.WithThreshold(*threshold),
+		xordb.WithCapacity(*capacity),
+		xordb.WithTTL(*ttl),
+	)
+	p.maxNamespaces = max(*namespaces, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xordb/stats", p.statsHandler)
+	mux.Handle("/", p)
+
+	log.Printf("xordb-proxy listening on %s → %s", *listen, u)
+	log.Fatal(http.ListenAndServe(*listen, mux))
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+const (
+	chatPath       = "/v1/chat/completions"
+	maxRequestBody = 4 << 20  // 4 MB
+	maxCachedBody  = 16 << 20 // 16 MB, matches the snapshot value limit
+
+	defaultMaxNamespaces = 64
+)
+
+// proxy — semantic cache in front of an OpenAI-compatible chat endpoint.
+// Every (model, temperature) pair gets its own DB so completions produced
+// under different sampling settings never answer each other. Clients pick
+// both, so at most maxNamespaces DBs are kept and the least recently used
+// one is dropped to make room.
+type proxy struct {
+	upstream      *url.URL
+	client        *http.Client
+	passthru      *httputil.ReverseProxy
+	dbOpts        []xordb.Option
+	maxBody       int // larger responses are streamed through uncached
+	maxNamespaces int
+
+	mu   sync.Mutex
+	dbs  map[string]*namespaceDB
+	tick uint64
+}
+
+type namespaceDB struct {
+	db   *xordb.DB
+	used uint64 // proxy.tick of the last request
+}
+
+func newProxy(upstream *url.URL, opts ...xordb.Option) *proxy {
+	return &proxy{
+		upstream: upstream,
+		client:   &http.Client{},
+		passthru: httputil.NewSingleHostReverseProxy(upstream),
+		dbOpts:   opts,
+		maxBody:  maxCachedBody,
+
+		maxNamespaces: defaultMaxNamespaces,
+		dbs:           make(map[string]*namespaceDB),
+	}
+}
+
+type chatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature *float64      `json:"temperature"`
+	Stream      bool          `json:"stream"`
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != chatPath {
+		p.passthru.ServeHTTP(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody+1))
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, "xordb-proxy: read body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxRequestBody {
+		http.Error(w, "xordb-proxy: request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var req chatRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.Stream || len(req.Messages) == 0 {
+		// streaming ya unparseable — cache nahi karte, seedha upstream
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		p.passthru.ServeHTTP(w, r)
+		return
+	}
+
+	db := p.db(namespace(req))
+	key := messageKey(req.Messages)
+
+	if v, ok, sim := db.Get(key); ok {
+		if cached, isStr := v.(string); isStr {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Xordb-Cache", "HIT")
+			w.Header().Set("X-Xordb-Similarity", strconv.FormatFloat(sim, 'f', 4, 64))
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, cached)
+			return
+		}
+	}
+
+	resp, err := p.forward(r, body)
+	if err != nil {
+		http.Error(w, "xordb-proxy: upstream: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, int64(p.maxBody)+1))
+	if err != nil {
+		http.Error(w, "xordb-proxy: upstream: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	overflow := len(respBody) > p.maxBody
+
+	if resp.StatusCode == http.StatusOK && !overflow && json.Valid(respBody) {
+		db.Set(key, string(respBody))
+	}
+
+	copyHeader(w.Header(), resp.Header)
+	w.Header().Set("X-Xordb-Cache", "MISS")
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+	if overflow {
+		// too large to cache — pass the rest through as it arrives
+		io.Copy(w, resp.Body)
+	}
+}
+
+// forward replays the chat request against the upstream and returns the
+// decoded (uncompressed) response.
+func (p *proxy) forward(r *http.Request, body []byte) (*http.Response, error) {
+	target := *p.upstream
+	target.Path = strings.TrimRight(target.Path, "/") + r.URL.Path
+	target.RawQuery = r.URL.RawQuery
+
+	out, err := http.NewRequestWithContext(r.Context(), http.MethodPost, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	copyHeader(out.Header, r.Header)
+	// Transport handles gzip itself only when we don't ask for it explicitly,
+	// and we need the plain body to cache it.
+	out.Header.Del("Accept-Encoding")
+	return p.client.Do(out)
+}
+
+// db returns the cache for a namespace, creating it on first use. A new
+// namespace past maxNamespaces drops the least recently used one; requests
+// still holding it finish normally.
+func (p *proxy) db(ns string) *xordb.DB {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tick++
+	if n, ok := p.dbs[ns]; ok {
+		n.used = p.tick
+		return n.db
+	}
+	if len(p.dbs) >= p.maxNamespaces {
+		var idle string
+		for name, n := range p.dbs {
+			if idle == "" || n.used < p.dbs[idle].used {
+				idle = name
+			}
+		}
+		delete(p.dbs, idle)
+	}
+	n := &namespaceDB{db: xordb.New(p.dbOpts...), used: p.tick}
+	p.dbs[ns] = n
+	return n.db
+}
+
+// namespace — model + temperature. Missing temperature is kept distinct from
+// an explicit value since the upstream default may change.
+func namespace(req chatRequest) string {
+	temp := "default"
+	if req.Temperature != nil {
+		temp = strconv.FormatFloat(*req.Temperature, 'f', -1, 64)
+	}
+	return req.Model + "|t=" + temp
+}
+
+// messageKey renders messages as "role: content" lines with whitespace
+// collapsed, so formatting differences in the payload don't affect the key.
+func messageKey(msgs []chatMessage) string {
+	var b strings.Builder
+	for i, m := range msgs {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(m.Role)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(strings.Fields(messageText(m.Content)), " "))
+	}
+	return b.String()
+}
+
+// messageText extracts text from a message content field, which is either a
+// plain string or an array of typed parts (only "text" parts are used).
+func messageText(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err == nil {
+		texts := make([]string, 0, len(parts))
+		for _, part := range parts {
+			if part.Type == "text" {
+				texts = append(texts, part.Text)
+			}
+		}
+		return strings.Join(texts, " ")
+	}
+	return string(raw)
+}
+
+// stats returns per-namespace cache stats.
+func (p *proxy) stats() map[string]xordb.Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make(map[string]xordb.Stats, len(p.dbs))
+	for ns, n := range p.dbs {
+		out[ns] = n.db.Stats()
+	}
+	return out
+}
+
+// statsHandler serves per-namespace stats as JSON.
+func (p *proxy) statsHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(p.stats()); err != nil {
+		http.Error(w, fmt.Sprintf("xordb-proxy: stats: %v", err), http.StatusInternalServerError)
+	}
+}
+
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+	"Content-Length", "Content-Encoding",
+}
+
+func copyHeader(dst, src http.Header) {
+	for k, vs := range src {
+		for _, v := range vs {
+			dst.Add(k, v)
+		}
+	}
+	for _, h := range hopHeaders {
+		dst.Del(h)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// ── helpers ───────────────────────────────────────────────────────────────────
+
+func newTestProxy(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, `{"id":"cmpl-1","choices":[{"message":{"role":"assistant","content":"Delhi"}}]}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	u, _ := url.Parse(upstream.URL)
+	srv := httptest.NewServer(newProxy(u, xordb.WithThreshold(0.80)))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func post(t *testing.T, srv *httptest.Server, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(srv.URL+chatPath, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+	return resp
+}
+
+const (
+	chatIndia   = `{"model":"gpt-4o","temperature":0,"messages":[{"role":"user","content":"what is the capital of india"}]}`
+	chatIndiaWS = `{"model":"gpt-4o","temperature":0,"messages":[{"role":"user","content":"what is  the capital of   india?"}]}`
+)
+
+// ── proxy ─────────────────────────────────────────────────────────────────────
+
+func TestProxy_MissThenHit(t *testing.T) {
+	srv, calls := newTestProxy(t, http.StatusOK)
+
+	if resp := post(t, srv, chatIndia); resp.Header.Get("X-Xordb-Cache") != "MISS" {
+		t.Fatalf("first request must miss, got %q", resp.Header.Get("X-Xordb-Cache"))
+	}
+	resp := post(t, srv, chatIndiaWS)
+	if resp.Header.Get("X-Xordb-Cache") != "HIT" {
+		t.Fatalf("whitespace variant must hit, got %q", resp.Header.Get("X-Xordb-Cache"))
+	}
+	if resp.Header.Get("X-Xordb-Similarity") == "" {
+		t.Fatal("hit must report similarity")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("upstream must be called once, got %d", n)
+	}
+}
+
+func TestProxy_NamespacedByModelAndTemperature(t *testing.T) {
+	srv, calls := newTestProxy(t, http.StatusOK)
+
+	post(t, srv, chatIndia)
+	post(t, srv, strings.Replace(chatIndia, `"gpt-4o"`, `"gpt-4o-mini"`, 1))
+	post(t, srv, strings.Replace(chatIndia, `"temperature":0`, `"temperature":0.7`, 1))
+
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("different model/temperature must not share cache, upstream calls=%d", n)
+	}
+}
+
+func TestProxy_StreamingNotCached(t *testing.T) {
+	srv, calls := newTestProxy(t, http.StatusOK)
+
+	body := strings.Replace(chatIndia, `"model"`, `"stream":true,"model"`, 1)
+	post(t, srv, body)
+	post(t, srv, body)
+
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("streaming requests must bypass cache, upstream calls=%d", n)
+	}
+}
+
+func TestProxy_ErrorResponseNotCached(t *testing.T) {
+	srv, calls := newTestProxy(t, http.StatusTooManyRequests)
+
+	post(t, srv, chatIndia)
+	resp := post(t, srv, chatIndia)
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("upstream status must be relayed, got %d", resp.StatusCode)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("non-200 responses must not be cached, upstream calls=%d", n)
+	}
+}
+
+func TestProxy_OversizedResponseStreamedUncached(t *testing.T) {
+	big := `{"content":"` + strings.Repeat("x", 4096) + `"}`
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		io.WriteString(w, big)
+	}))
+	t.Cleanup(upstream.Close)
+
+	u, _ := url.Parse(upstream.URL)
+	p := newProxy(u, xordb.WithThreshold(0.80))
+	p.maxBody = 1024
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+
+	for range 2 {
+		resp, err := http.Post(srv.URL+chatPath, "application/json", strings.NewReader(chatIndia))
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(got) != big {
+			t.Fatalf("response truncated to %d of %d bytes", len(got), len(big))
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("oversized responses must not be cached, upstream calls=%d", n)
+	}
+}
+
+func TestProxy_NamespacesCapped(t *testing.T) {
+	u, _ := url.Parse("http://upstream.invalid")
+	p := newProxy(u)
+	p.maxNamespaces = 2
+
+	a := p.db("a")
+	p.db("b")
+	p.db("a") // b is now the least recently used
+	p.db("c")
+
+	if len(p.dbs) != 2 {
+		t.Fatalf("want 2 namespaces kept, got %d", len(p.dbs))
+	}
+	if _, ok := p.dbs["b"]; ok {
+		t.Fatal("least recently used namespace must be dropped")
+	}
+	if p.db("a") != a {
+		t.Fatal("recently used namespace must be kept")
+	}
+}
+
+func TestMessageKey_ContentParts(t *testing.T) {
+	msgs := []chatMessage{
+		{Role: "system", Content: []byte(`"be brief"`)},
+		{Role: "user", Content: []byte(`[{"type":"text","text":"hello"},{"type":"image_url","image_url":{}},{"type":"text","text":"world"}]`)},
+	}
+	want := "system: be brief\nuser: hello world"
+	if got := messageKey(msgs); got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,126 @@
+// xordb-replay — replay a recorded query log against a configured DB.
+//
+// Each query is looked up and, on a miss, stored (cache-aside), so the run
+// reports the hit rate, lookup latency and eviction churn a deployment with
+// the same settings would have seen. Use it for capacity and threshold
+// planning before rollout.
+//
+// The log is one query per line: plain text, or JSON with a key/query/prompt
+// field and an optional ts (see eval.ReadQueryLog). Logs from
+// xordb.WithQueryLog and saved /v1/events streams from xordb-serve work as-is.
+//
+// With -pricing, it also estimates the LLM spend the hits would have saved
+// (see eval.CostReport), from a JSON file of per-million-token prices:
+//
+//	{"gpt-4o": {"input_per_mtok": 2.5, "output_per_mtok": 10},
+//	 "": {"input_per_mtok": 0.15, "output_per_mtok": 0.6, "output_tokens": 300}}
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+)
+
+const usage = `xordb-replay — replay a query log against xordb
+
+Usage:
+  xordb-replay [flags] [log]     (log defaults to stdin)
+
+Flags:`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("xordb-replay", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, usage)
+		fs.PrintDefaults()
+	}
+	threshold := fs.Float64("threshold", 0.75, "minimum similarity for a cache hit")
+	capacity := fs.Int("capacity", 10000, "max cached entries (LRU)")
+	dims := fs.Int("dims", 10000, "hypervector dimensions")
+	ttl := fs.Duration("ttl", 0, "entry lifetime (0 = never expires)")
+	speed := fs.Float64("speed", 0, "replay at N× the recorded pace (1 = original timing, 0 = as fast as possible)")
+	snapshot := fs.String("snapshot", "", "warm the DB from this .xrdb snapshot before replaying")
+	pricingPath := fs.String("pricing", "", "JSON file of per-model prices; adds an estimate of the LLM cost saved")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *speed < 0 || fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	in := stdin
+	if fs.NArg() == 1 && fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintln(stderr, "error:", err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+	qs, err := eval.ReadQueryLog(in)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	var pricing eval.Pricing
+	if *pricingPath != "" {
+		if pricing, err = readPricing(*pricingPath); err != nil {
+			fmt.Fprintln(stderr, "error:", err)
+			return 1
+		}
+	}
+
+	ch := &churn{}
+	db := xordb.New(
+		xordb.WithThreshold(*threshold),
+		xordb.WithCapacity(*capacity),
+		xordb.WithDims(*dims),
+		xordb.WithTTL(*ttl),
+		xordb.WithEventHook(ch.record),
+	)
+	if *snapshot != "" {
+		if err := db.Load(*snapshot); err != nil {
+			fmt.Fprintln(stderr, "error:", err)
+			return 1
+		}
+	}
+
+	res := replay(db, qs, *speed, time.Sleep)
+	res.churn = ch
+	res.entries = db.Len()
+	res.writeText(stdout)
+	if pricing != nil {
+		fmt.Fprintln(stdout)
+		pricing.Savings(qs, res.hits).WriteText(stdout)
+	}
+	return 0
+}
+
+func readPricing(path string) (eval.Pricing, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p eval.Pricing
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("pricing %s: %w", path, err)
+	}
+	if len(p) == 0 {
+		return nil, fmt.Errorf("pricing %s: no models", path)
+	}
+	return p, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+)
+
+// churn counts entry turnover from the DB's event hook.
+type churn struct {
+	sets    atomic.Uint64
+	evicted atomic.Uint64
+	expired atomic.Uint64
+}
+
+func (c *churn) record(ev xordb.Event) {
+	switch ev.Kind {
+	case xordb.EventSet:
+		c.sets.Add(1)
+	case xordb.EventEvict:
+		c.evicted.Add(1)
+	case xordb.EventExpire:
+		c.expired.Add(1)
+	}
+}
+
+type result struct {
+	eval.LogReport
+	Span    time.Duration // first to last recorded timestamp
+	Elapsed time.Duration // wall time of the replay
+
+	churn   *churn
+	entries int
+	hits    []bool // by query, for eval.Pricing.Savings
+}
+
+// replay runs qs cache-aside. With speed > 0 and timestamps present, it
+// waits between queries so they arrive at speed× their recorded pace;
+// queries without a timestamp are not delayed.
+func replay(db *xordb.DB, qs []eval.Query, speed float64, sleep func(time.Duration)) *result {
+	res := &result{LogReport: eval.LogReport{Queries: len(qs)}, hits: make([]bool, len(qs))}
+	lat := make([]time.Duration, len(qs))
+	var simSum float64
+	var first time.Time
+	start := time.Now()
+	for i, q := range qs {
+		if !q.Time.IsZero() {
+			if first.IsZero() {
+				first = q.Time
+			}
+			if off := q.Time.Sub(first); off > res.Span {
+				res.Span = off
+			}
+			if speed > 0 {
+				due := time.Duration(float64(q.Time.Sub(first)) / speed)
+				if wait := due - time.Since(start); wait > 0 {
+					sleep(wait)
+				}
+			}
+		}
+
+		t := time.Now()
+		_, hit, sim := db.Get(q.Key)
+		lat[i] = time.Since(t)
+		res.hits[i] = hit
+		if hit {
+			res.Hits++
+			simSum += sim
+		} else {
+			db.Set(q.Key, q.Key)
+		}
+	}
+	res.Elapsed = time.Since(start)
+	if res.Hits > 0 {
+		res.AvgSimOnHit = simSum / float64(res.Hits)
+	}
+	res.Latency = eval.SummarizeLatency(lat)
+	return res
+}
+
+func (r *result) writeText(w io.Writer) {
+	fmt.Fprintf(w, "queries    %d (span %v, replayed in %v)\n", r.Queries, r.Span.Round(time.Millisecond), r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "hit rate   %.1f%% (%d hits, avg similarity %.3f)\n", 100*r.HitRate(), r.Hits, r.AvgSimOnHit)
+	fmt.Fprintf(w, "latency    mean %v  p50 %v  p90 %v  p99 %v  max %v\n",
+		r.Latency.Mean, r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max)
+	if r.churn == nil {
+		return
+	}
+	sets, evicted, expired := r.churn.sets.Load(), r.churn.evicted.Load(), r.churn.expired.Load()
+	var perK float64
+	if r.Queries > 0 {
+		perK = 1000 * float64(evicted) / float64(r.Queries)
+	}
+	fmt.Fprintf(w, "churn      %d sets, %d evicted (%.1f per 1k queries), %d expired\n", sets, evicted, perK, expired)
+	fmt.Fprintf(w, "entries    %d at end\n", r.entries)
+}
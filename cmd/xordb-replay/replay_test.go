@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+)
+
+// ── replay ────────────────────────────────────────────────────────────────────
+
+func TestReplay_Pacing(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	qs := []eval.Query{
+		{Time: t0, Key: "what is the capital of india"},
+		{Time: t0.Add(time.Minute), Key: "capital city of india"},
+		{Time: t0.Add(2 * time.Minute), Key: "how to bake a chocolate cake"},
+	}
+
+	res := replay(xordb.New(xordb.WithThreshold(0.70)), qs, 6000, time.Sleep) // 2m at 6000× = 20ms
+	if res.Hits != 1 || res.Span != 2*time.Minute {
+		t.Fatalf("got %+v", res.LogReport)
+	}
+	if res.Elapsed < 20*time.Millisecond || res.Elapsed > time.Second {
+		t.Fatalf("want ~20ms replay at 6000×, got %v", res.Elapsed)
+	}
+
+	var waited time.Duration
+	sleep := func(d time.Duration) { waited += d }
+	replay(xordb.New(), qs, 0, sleep)
+	if waited != 0 {
+		t.Fatalf("speed 0 must not wait, waited %v", waited)
+	}
+}
+
+// ── command ───────────────────────────────────────────────────────────────────
+
+func TestRun_ReportsChurn(t *testing.T) {
+	log := "alpha one\nbravo two\ncharlie three\ndelta four\nalpha one\n"
+	var out, errOut bytes.Buffer
+	if code := run([]string{"-capacity", "2", "-threshold", "0.95"}, strings.NewReader(log), &out, &errOut); code != 0 {
+		t.Fatalf("exit %d: %s", code, errOut.String())
+	}
+	for _, want := range []string{"queries    5", "hit rate   0.0%", "5 sets, 3 evicted (600.0 per 1k queries)", "entries    2"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("missing %q in:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRun_FileAndErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "q.jsonl")
+	os.WriteFile(path, []byte(`{"query":"what is the capital of india"}`+"\n"), 0o644)
+	var out, errOut bytes.Buffer
+	if code := run([]string{path}, nil, &out, &errOut); code != 0 || !strings.Contains(out.String(), "queries    1") {
+		t.Fatalf("exit %d: %s%s", code, out.String(), errOut.String())
+	}
+	if code := run([]string{"-speed", "-1"}, nil, &out, &errOut); code != 2 {
+		t.Fatalf("negative speed: want exit 2, got %d", code)
+	}
+	if code := run(nil, strings.NewReader(`{"ts":1}`), &out, &errOut); code != 1 {
+		t.Fatalf("bad log: want exit 1, got %d", code)
+	}
+}
+
+func TestRun_Pricing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.json")
+	os.WriteFile(path, []byte(`{"": {"input_per_mtok": 1000000, "output_per_mtok": 0}}`), 0o644)
+	log := `{"query":"alpha one","input_tokens":2}` + "\n" + `{"query":"alpha one","input_tokens":2}` + "\n"
+	var out, errOut bytes.Buffer
+	if code := run([]string{"-pricing", path}, strings.NewReader(log), &out, &errOut); code != 0 {
+		t.Fatalf("exit %d: %s", code, errOut.String())
+	}
+	if !strings.Contains(out.String(), "4.00 without cache  2.00 saved (50.0%)") {
+		t.Fatalf("no savings in:\n%s", out.String())
+	}
+
+	os.WriteFile(path, []byte(`{}`), 0o644)
+	if code := run([]string{"-pricing", path}, strings.NewReader(log), &out, &errOut); code != 1 {
+		t.Fatalf("empty pricing: want exit 1, got %d", code)
+	}
+}
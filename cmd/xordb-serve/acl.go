@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// An ACL file (-acl) lists the bearer tokens the /v1 API accepts and the
+// namespaces — key prefixes, as POST /admin/clear takes — each may read
+// and write:
+//
+//	{"tokens": [
+//	  {"name": "dashboards", "token": "…", "read": [""]},
+//	  {"name": "team-a", "token": "…", "read": ["shared:"], "write": ["team-a:"]}
+//	]}
+//
+// "" is every namespace. A token may read what it may write. Without -acl
+// the API is open.
+type acl struct {
+	mu     sync.RWMutex // guards Tokens across reloads
+	Tokens []*grant     `json:"tokens"`
+}
+
+type grant struct {
+	Name  string   `json:"name"` // for error messages, the audit log and quotas
+	Token string   `json:"token"`
+	Read  []string `json:"read"`
+	Write []string `json:"write"`
+
+	// Quotas, 0 = unlimited; see quota.go.
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"` // default: a second's worth
+	SetBytesPerMinute int64   `json:"set_bytes_per_minute"`
+}
+
+func loadACL(path string) (*acl, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var a acl
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("acl %s: %w", path, err)
+	}
+	seen := make(map[string]bool)
+	for i, g := range a.Tokens {
+		if g.Token == "" {
+			return nil, fmt.Errorf("acl %s: entry %d has no token", path, i)
+		}
+		if seen[g.Token] {
+			return nil, fmt.Errorf("acl %s: entry %d repeats a token", path, i)
+		}
+		seen[g.Token] = true
+		if g.RequestsPerSecond < 0 || g.Burst < 0 || g.SetBytesPerMinute < 0 {
+			return nil, fmt.Errorf("acl %s: entry %d has a negative quota", path, i)
+		}
+	}
+	return &a, nil
+}
+
+// replace swaps in the tokens of b, a reloaded ACL file. Requests already
+// authorized keep the grant they got.
+func (a *acl) replace(b *acl) {
+	a.mu.Lock()
+	a.Tokens = b.Tokens
+	a.mu.Unlock()
+}
+
+// grant returns the grant of the request's bearer token, or nil. Every
+// token is compared, in constant time, so timing does not reveal which
+// prefix of a token matched.
+func (a *acl) grant(r *http.Request) *grant {
+	got := []byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var found *grant
+	for _, g := range a.Tokens {
+		if subtle.ConstantTimeCompare(got, []byte(g.Token)) == 1 {
+			found = g
+		}
+	}
+	return found
+}
+
+// A nil *grant — no ACL — may do anything.
+
+func (g *grant) canRead(key string) bool {
+	return g == nil || hasPrefix(key, g.Read) || hasPrefix(key, g.Write)
+}
+
+func (g *grant) canWrite(key string) bool { return g == nil || hasPrefix(key, g.Write) }
+
+// readsAll reports whether g may read every namespace.
+func (g *grant) readsAll() bool { return g.canRead("") }
+
+func (g *grant) writesAll() bool { return g.canWrite("") }
+
+func hasPrefix(key string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+type grantKey struct{}
+
+// grantOf returns the grant authorize stored for r, nil without an ACL.
+func grantOf(r *http.Request) *grant {
+	g, _ := r.Context().Value(grantKey{}).(*grant)
+	return g
+}
+
+// authorize rejects requests without a token from the ACL, if there is
+// one, and passes the token's grant on to h. all additionally requires a
+// token that may read every namespace.
+func (s *server) authorize(h http.Handler, all bool) http.Handler {
+	if s.acl == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g := s.acl.grant(r)
+		if g == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="xordb"`)
+			writeError(w, http.StatusUnauthorized, errors.New("token required"))
+			return
+		}
+		if all && !g.readsAll() {
+			writeError(w, http.StatusForbidden, fmt.Errorf("token %q may not read every namespace", g.Name))
+			return
+		}
+		if rec := recordOf(r); rec != nil {
+			rec.who = g.Name
+		}
+		if ok, retry := s.quotas.allowRequest(g); !ok {
+			tooMany(w, retry, fmt.Errorf("token %q is over its request quota", g.Name))
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), grantKey{}, g)))
+	})
+}
+
+func errNoRead(g *grant, key string) error {
+	return fmt.Errorf("token %q may not read %q", g.Name, key)
+}
+
+func errNoWrite(g *grant, key string) error {
+	return fmt.Errorf("token %q may not write %q", g.Name, key)
+}
+
+// checkImport fails on the first record of a JSONL import g may not
+// write, so a rejected import writes nothing.
+func checkImport(g *grant, data []byte) error {
+	if g.writesAll() {
+		return nil
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, maxBodySize)
+	for sc.Scan() {
+		var rec struct {
+			Key string `json:"key"`
+		}
+		if json.Unmarshal(sc.Bytes(), &rec) == nil && !g.canWrite(rec.Key) {
+			return errNoWrite(g, rec.Key)
+		}
+	}
+	return sc.Err()
+}
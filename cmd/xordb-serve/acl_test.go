@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/replication"
+)
+
+func newACLServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	db := xordb.New(xordb.WithThreshold(0.70))
+	p := replication.NewPrimary(db, 100)
+	srv, _ := newTestServer(t, withDB(db), func(s *server) {
+		s.w, s.primary = p, p
+		s.acl = &acl{Tokens: []*grant{
+			{Name: "reader", Token: "r", Read: []string{""}},
+			{Name: "team-a", Token: "a", Read: []string{"shared:"}, Write: []string{"team-a:"}},
+			{Name: "team-b", Token: "b", Write: []string{"team-b:"}},
+			{Name: "loader", Token: "w", Write: []string{""}},
+		}}
+	})
+	return srv
+}
+
+func doAs(t *testing.T, srv *httptest.Server, token, method, path, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestACL_Writes(t *testing.T) {
+	srv := newACLServer(t)
+	for _, c := range []struct {
+		token, key string
+		want       int
+	}{
+		{"", "team-a:q", http.StatusUnauthorized},
+		{"wrong", "team-a:q", http.StatusUnauthorized},
+		{"r", "team-a:q", http.StatusForbidden},
+		{"a", "team-b:q", http.StatusForbidden},
+		{"a", "shared:q", http.StatusForbidden},
+		{"a", "team-a:q", http.StatusNoContent},
+	} {
+		resp := doAs(t, srv, c.token, "POST", "/v1/set", `{"key":"`+c.key+`","value":1}`)
+		if resp.StatusCode != c.want {
+			t.Errorf("token %q set %q: status %d, want %d", c.token, c.key, resp.StatusCode, c.want)
+		}
+	}
+	if resp := doAs(t, srv, "b", "POST", "/v1/delete", `{"key":"team-a:q"}`); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("cross-namespace delete: status %d", resp.StatusCode)
+	}
+	resp := doAs(t, srv, "a", "POST", "/v1/import", `{"key":"team-a:x","value":"imported"}`+"\n"+`{"key":"team-b:x","value":"imported"}`+"\n")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("import into another namespace: status %d", resp.StatusCode)
+	}
+	for _, token := range []string{"a", "w"} {
+		if resp := doAs(t, srv, token, "POST", "/v1/import", strings.Repeat("x", maxBodySize+1)); resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("token %q import over the size limit: status %d, want 413", token, resp.StatusCode)
+		}
+	}
+	var got getResponse
+	json.NewDecoder(doAs(t, srv, "r", "GET", "/v1/get?key=team-a:x", "").Body).Decode(&got)
+	if got.Value == "imported" {
+		t.Error("a rejected import wrote a record")
+	}
+}
+
+func TestACL_Reads(t *testing.T) {
+	srv := newACLServer(t)
+	doAs(t, srv, "b", "POST", "/v1/set", `{"key":"team-b:what is the capital of france","value":"Paris"}`)
+
+	get := func(token, key string) (int, getResponse) {
+		resp := doAs(t, srv, token, "GET", "/v1/get?key="+url.QueryEscape(key), "")
+		var out getResponse
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+	if status, _ := get("a", "team-b:what is the capital of france"); status != http.StatusForbidden {
+		t.Errorf("read of another namespace: status %d", status)
+	}
+	// Team A's own key is similar enough to hit team B's entry.
+	if _, out := get("a", "team-a:what is the capital of france"); out.Hit {
+		t.Errorf("team-a hit team-b's entry: %+v", out)
+	}
+	if _, out := get("r", "team-a:what is the capital of france"); !out.Hit {
+		t.Error("a token reading every namespace missed")
+	}
+
+	resp := doAs(t, srv, "a", "GET", "/v1/explain?key=team-a:what+is+the+capital+of+france", "")
+	var cands []xordb.Candidate
+	json.NewDecoder(resp.Body).Decode(&cands)
+	if len(cands) != 0 {
+		t.Errorf("explain showed %+v", cands)
+	}
+	body, _ := io.ReadAll(doAs(t, srv, "a", "GET", "/v1/export", "").Body)
+	if len(body) != 0 {
+		t.Errorf("export showed %s", body)
+	}
+	body, _ = io.ReadAll(doAs(t, srv, "b", "GET", "/v1/export", "").Body)
+	if !strings.Contains(string(body), "team-b:") {
+		t.Errorf("export of own namespace = %q", body)
+	}
+	body, _ = io.ReadAll(doAs(t, srv, "a", "GET", "/v1/export?format=csv", "").Body)
+	if lines := strings.Split(strings.TrimSpace(string(body)), "\n"); len(lines) != 1 || !strings.HasPrefix(lines[0], "key,") {
+		t.Errorf("csv export showed %s", body)
+	}
+
+	if resp := doAs(t, srv, "a", "GET", "/v1/replication/snapshot", ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("replication with a namespaced token: status %d", resp.StatusCode)
+	}
+	if resp := doAs(t, srv, "r", "GET", "/v1/replication/snapshot", ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("replication with a full reader: status %d", resp.StatusCode)
+	}
+}
+
+func TestACL_ReadsSkipOtherNamespaces(t *testing.T) {
+	srv := newACLServer(t)
+	doAs(t, srv, "b", "POST", "/v1/set", `{"key":"team-b:what is the capital of france","value":"Paris (b)"}`)
+	doAs(t, srv, "a", "POST", "/v1/set", `{"key":"team-a:capital of france please","value":"Paris (a)"}`)
+
+	// team B's entry is the closer one, but team A may not read it
+	resp := doAs(t, srv, "a", "GET", "/v1/get?key="+url.QueryEscape("team-a:what is the capital of france"), "")
+	var out getResponse
+	json.NewDecoder(resp.Body).Decode(&out)
+	if !out.Hit || out.Value != "Paris (a)" {
+		t.Errorf("team-b's entry hid team-a's own: %+v", out)
+	}
+}
+
+func TestACL_Pprof(t *testing.T) {
+	_, s := newTestServer(t, func(s *server) {
+		s.acl = &acl{Tokens: []*grant{
+			{Name: "reader", Token: "r", Read: []string{""}},
+			{Name: "team-a", Token: "a", Read: []string{"shared:"}},
+		}}
+	})
+	mux := http.NewServeMux()
+	s.mountPprof(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	for token, want := range map[string]int{"": http.StatusUnauthorized, "a": http.StatusForbidden, "r": http.StatusOK} {
+		if resp := doAs(t, srv, token, "GET", "/debug/pprof/cmdline", ""); resp.StatusCode != want {
+			t.Errorf("token %q: status %d, want %d", token, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestHub_FiltersByGrant(t *testing.T) {
+	h := newHub()
+	sub := h.subscribe(nil, &grant{Read: []string{"team-a:"}})
+	h.publish(xordb.Event{Kind: xordb.EventSet, Key: "team-b:q"})
+	h.publish(xordb.Event{Kind: xordb.EventMiss, Key: "team-a:q", Match: "team-b:q", Similarity: 0.6})
+	ev := <-sub.ch
+	if ev.Key != "team-a:q" || ev.Match != "" || ev.Similarity != 0 {
+		t.Errorf("got %+v, want team-a's miss without team-b's key", ev)
+	}
+	if len(sub.ch) != 0 {
+		t.Error("another namespace's event was delivered")
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// dbConfig holds the tunables the admin API can change. DBs built after a
+// change (replica bootstraps) start from the current values, not the flags.
+type dbConfig struct {
+	mu        sync.Mutex
+	threshold float64
+	capacity  int
+	ttl       time.Duration
+}
+
+func (c *dbConfig) options() []xordb.Option {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return []xordb.Option{
+		xordb.WithThreshold(c.threshold),
+		xordb.WithCapacity(c.capacity),
+		xordb.WithTTL(c.ttl),
+	}
+}
+
+type configResponse struct {
+	Threshold float64 `json:"threshold"`
+	Capacity  int     `json:"capacity"`
+	TTL       string  `json:"ttl"`
+}
+
+// configPatch — absent fields are left unchanged.
+type configPatch struct {
+	Threshold *float64 `json:"threshold"`
+	Capacity  *int     `json:"capacity"`
+	TTL       *string  `json:"ttl"`
+}
+
+type prefixRequest struct {
+	Prefix string `json:"prefix"`
+}
+
+// adminRoutes registers /admin/*, guarded by the bearer token. Admin
+// changes are local to this node: they are not replicated.
+func (s *server) adminRoutes(mux *http.ServeMux) {
+	mux.Handle("GET /admin/config", s.requireAdmin(s.handleGetConfig))
+	mux.Handle("PATCH /admin/config", s.requireAdmin(s.handlePatchConfig))
+	mux.Handle("POST /admin/snapshot", s.requireAdmin(s.handleSnapshot))
+	mux.Handle("POST /admin/clear", s.requireAdmin(s.handleClear))
+	mux.Handle("POST /admin/pin", s.requireAdmin(s.handlePin(true)))
+	mux.Handle("POST /admin/unpin", s.requireAdmin(s.handlePin(false)))
+	mux.Handle("GET /admin/keys", s.requireAdmin(s.handleKeys))
+	mux.Handle("POST /admin/reload", s.requireAdmin(s.handleReload))
+	mux.Handle("GET /admin/quotas", s.requireAdmin(s.handleQuotas))
+	if s.calib != nil {
+		mux.Handle("GET /admin/calibration", s.requireAdmin(s.handleGetCalibration))
+		mux.Handle("POST /admin/calibrate", s.requireAdmin(s.handleCalibrate))
+	}
+}
+
+func (s *server) requireAdmin(h http.HandlerFunc) http.Handler {
+	want := []byte("Bearer " + s.adminToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="xordb-admin"`)
+			writeError(w, http.StatusUnauthorized, errors.New("admin token required"))
+			return
+		}
+		h(w, r)
+	})
+}
+
+func (s *server) handleGetConfig(w http.ResponseWriter, _ *http.Request) {
+	db := s.db()
+	writeJSON(w, http.StatusOK, configResponse{
+		Threshold: db.Threshold(),
+		Capacity:  db.Capacity(),
+		TTL:       db.TTL().String(),
+	})
+}
+
+func (s *server) handlePatchConfig(w http.ResponseWriter, r *http.Request) {
+	var req configPatch
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.applyConfig(req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.handleGetConfig(w, r)
+}
+
+// applyConfig validates every field of req, then applies them to the live
+// DB and to the options of DBs built later.
+func (s *server) applyConfig(req configPatch) error {
+	if req.Threshold != nil && (*req.Threshold <= 0 || *req.Threshold > 1) {
+		return errors.New("threshold must be in (0, 1]")
+	}
+	if req.Capacity != nil && *req.Capacity <= 0 {
+		return errors.New("capacity must be positive")
+	}
+	var ttl time.Duration
+	if req.TTL != nil {
+		var err error
+		if ttl, err = time.ParseDuration(*req.TTL); err != nil || ttl < 0 {
+			return errors.New("ttl must be a non-negative duration")
+		}
+	}
+
+	db := s.db()
+	if s.cfg != nil {
+		s.cfg.mu.Lock()
+		defer s.cfg.mu.Unlock()
+	}
+	if req.Threshold != nil {
+		db.SetThreshold(*req.Threshold)
+		if s.cfg != nil {
+			s.cfg.threshold = *req.Threshold
+		}
+	}
+	if req.Capacity != nil {
+		db.SetCapacity(*req.Capacity)
+		if s.cfg != nil {
+			s.cfg.capacity = *req.Capacity
+		}
+	}
+	if req.TTL != nil {
+		db.SetTTL(ttl)
+		if s.cfg != nil {
+			s.cfg.ttl = ttl
+		}
+	}
+	return nil
+}
+
+func (s *server) handleSnapshot(w http.ResponseWriter, _ *http.Request) {
+	if s.snapshotPath == "" {
+		writeError(w, http.StatusConflict, errors.New("no snapshot path configured (-snapshot)"))
+		return
+	}
+	db := s.db()
+	start := time.Now()
+	if err := db.Save(s.snapshotPath); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"path":     s.snapshotPath,
+		"entries":  db.Len(),
+		"duration": time.Since(start).String(),
+	})
+}
+
+// handleClear removes a namespace, i.e. every key starting with prefix.
+// An empty prefix clears the whole cache.
+func (s *server) handleClear(w http.ResponseWriter, r *http.Request) {
+	if !s.writable(w) {
+		return
+	}
+	var req prefixRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	n := s.db().DeletePrefix(req.Prefix)
+	if s.primary != nil && n > 0 {
+		s.primary.Resync() // bulk delete bypassed the log
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"cleared": n})
+}
+
+func (s *server) handlePin(pin bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req keyRequest
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		var found bool
+		if pin {
+			found = s.db().Pin(req.Key)
+		} else {
+			found = s.db().Unpin(req.Key)
+		}
+		if !found {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no entry with key %q", req.Key))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]bool{"pinned": pin})
+	}
+}
+
+type keysResponse struct {
+	Records []xordb.Record `json:"records"`
+	Cursor  string         `json:"cursor"` // "0" when the scan is complete
+}
+
+// handleKeys pages through entries with DB.Scan: GET /admin/keys?cursor=&count=.
+func (s *server) handleKeys(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var cursor uint64
+	if v := q.Get("cursor"); v != "" {
+		var err error
+		if cursor, err = strconv.ParseUint(v, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("cursor must be a non-negative integer"))
+			return
+		}
+	}
+	count := 100
+	if v := q.Get("count"); v != "" {
+		var err error
+		if count, err = strconv.Atoi(v); err != nil || count <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("count must be a positive integer"))
+			return
+		}
+	}
+	recs, next := s.db().Scan(cursor, count)
+	writeJSON(w, http.StatusOK, keysResponse{Records: recs, Cursor: strconv.FormatUint(next, 10)})
+}
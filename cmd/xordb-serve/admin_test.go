@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+const testToken = "s3cret"
+
+func newAdminServer(t *testing.T) (*httptest.Server, *server) {
+	t.Helper()
+	cfg := &dbConfig{threshold: 0.99, capacity: 16}
+	return newTestServer(t, withDB(xordb.New(cfg.options()...)), func(s *server) {
+		s.cfg = cfg
+		s.adminToken = testToken
+		s.snapshotPath = filepath.Join(t.TempDir(), "cache.xrdb")
+	})
+}
+
+func adminDo(t *testing.T, srv *httptest.Server, method, path, body string) (*http.Response, map[string]any) {
+	t.Helper()
+	req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out map[string]any
+	json.NewDecoder(resp.Body).Decode(&out)
+	return resp, out
+}
+
+func TestAdmin_RequiresToken(t *testing.T) {
+	srv, _ := newAdminServer(t)
+	for _, auth := range []string{"", "Bearer wrong"} {
+		req, _ := http.NewRequest("GET", srv.URL+"/admin/config", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("auth %q: want 401, got %d", auth, resp.StatusCode)
+		}
+	}
+}
+
+func TestAdmin_DisabledWithoutToken(t *testing.T) {
+	srv, _ := newTestServer(t)
+	resp, err := http.Get(srv.URL + "/admin/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("admin routes must not exist without a token, got %d", resp.StatusCode)
+	}
+}
+
+func TestAdmin_PatchConfig(t *testing.T) {
+	srv, s := newAdminServer(t)
+	s.db().Set("what is the capital of india", "Delhi")
+	if getKey(t, srv, "capital city of india").Hit {
+		t.Fatal("must miss at 0.99")
+	}
+
+	resp, out := adminDo(t, srv, "PATCH", "/admin/config", `{"threshold":0.7,"ttl":"1h"}`)
+	if resp.StatusCode != http.StatusOK || out["threshold"] != 0.7 || out["ttl"] != "1h0m0s" || out["capacity"] != 16.0 {
+		t.Fatalf("patch: %d %v", resp.StatusCode, out)
+	}
+	if !getKey(t, srv, "capital city of india").Hit {
+		t.Fatal("must hit after lowering threshold")
+	}
+	if s.cfg.threshold != 0.7 {
+		t.Fatal("patch must update the config used for new DBs")
+	}
+
+	resp, _ = adminDo(t, srv, "PATCH", "/admin/config", `{"threshold":0.5,"capacity":0}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid capacity: want 400, got %d", resp.StatusCode)
+	}
+	if s.db().Threshold() != 0.7 {
+		t.Fatal("rejected patch must not apply any field")
+	}
+}
+
+func TestAdmin_ClearPrefix(t *testing.T) {
+	srv, s := newAdminServer(t)
+	s.db().Set("tenant-a:one", 1)
+	s.db().Set("tenant-a:two", 2)
+	s.db().Set("tenant-b:one", 3)
+
+	resp, out := adminDo(t, srv, "POST", "/admin/clear", `{"prefix":"tenant-a:"}`)
+	if resp.StatusCode != http.StatusOK || out["cleared"] != 2.0 || s.db().Len() != 1 {
+		t.Fatalf("clear: %d %v len=%d", resp.StatusCode, out, s.db().Len())
+	}
+}
+
+func TestAdmin_Pin(t *testing.T) {
+	srv, s := newAdminServer(t)
+	s.db().Set("keep me", 1)
+
+	if resp, _ := adminDo(t, srv, "POST", "/admin/pin", `{"key":"keep me"}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("pin: want 200, got %d", resp.StatusCode)
+	}
+	adminDo(t, srv, "PATCH", "/admin/config", `{"capacity":1}`)
+	s.db().Set("other", 2)
+	if _, ok, _ := s.db().Get("keep me"); !ok {
+		t.Fatal("pinned entry must survive capacity shrink")
+	}
+	if resp, _ := adminDo(t, srv, "POST", "/admin/pin", `{"key":"missing"}`); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("pin missing: want 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestAdmin_Keys(t *testing.T) {
+	srv, s := newAdminServer(t)
+	for _, k := range []string{"one", "two", "three"} {
+		s.db().Set(k, k)
+	}
+
+	var keys []any
+	cursor := "0"
+	for pages := 0; ; pages++ {
+		resp, out := adminDo(t, srv, "GET", "/admin/keys?count=2&cursor="+cursor, "")
+		if resp.StatusCode != http.StatusOK || pages > 2 {
+			t.Fatalf("keys: %d %v", resp.StatusCode, out)
+		}
+		for _, rec := range out["records"].([]any) {
+			keys = append(keys, rec.(map[string]any)["key"])
+		}
+		if cursor = out["cursor"].(string); cursor == "0" {
+			break
+		}
+	}
+	if len(keys) != 3 || keys[0] != "one" || keys[2] != "three" {
+		t.Fatalf("want all keys in insertion order, got %v", keys)
+	}
+	if resp, _ := adminDo(t, srv, "GET", "/admin/keys?cursor=-1", ""); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("bad cursor: want 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestAdmin_Snapshot(t *testing.T) {
+	srv, s := newAdminServer(t)
+	s.db().Set("hello", "world")
+
+	resp, out := adminDo(t, srv, "POST", "/admin/snapshot", "")
+	if resp.StatusCode != http.StatusOK || out["entries"] != 1.0 {
+		t.Fatalf("snapshot: %d %v", resp.StatusCode, out)
+	}
+	db := xordb.New()
+	if err := db.Load(s.snapshotPath); err != nil || db.Len() != 1 {
+		t.Fatalf("snapshot file must load: len=%d err=%v", db.Len(), err)
+	}
+}
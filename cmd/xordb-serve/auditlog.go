@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// auditLog writes one JSON line per /v1 request (-audit-log):
+//
+//	{"time":"…","level":"INFO","msg":"request","op":"get","who":"team-a",
+//	 "namespace":"team-a:","key":"…","status":200,"hit":true,
+//	 "similarity":0.91,"latency_ms":0.42,"remote":"10.0.0.7:51234"}
+//
+// who is the ACL token's name ("" without -acl), namespace the key up to
+// and including its first ':'. Requests that fail (status >= 400) are
+// always logged, the rest with probability sample. keys controls how
+// keys appear: "plain", "hash" (the first 16 hex digits of their SHA-256,
+// enough to correlate lines) or "omit".
+type auditLog struct {
+	log    *slog.Logger
+	sample float64
+	keys   string
+}
+
+func newAuditLog(w io.Writer, sample float64, keys string) (*auditLog, error) {
+	if sample < 0 || sample > 1 {
+		return nil, fmt.Errorf("audit sample rate must be in [0, 1], got %v", sample)
+	}
+	switch keys {
+	case "plain", "hash", "omit":
+	default:
+		return nil, fmt.Errorf("audit keys must be plain, hash or omit, got %q", keys)
+	}
+	return &auditLog{log: slog.New(slog.NewJSONHandler(w, nil)), sample: sample, keys: keys}, nil
+}
+
+// auditRecord collects what the handlers learn about a request.
+type auditRecord struct {
+	who    string
+	key    string
+	lookup bool // a get: hit and similarity are meaningful
+	hit    bool
+	sim    float64
+}
+
+type auditKey struct{}
+
+func recordOf(r *http.Request) *auditRecord {
+	rec, _ := r.Context().Value(auditKey{}).(*auditRecord)
+	return rec
+}
+
+// noteKey records the key a request is about.
+func noteKey(r *http.Request, key string) {
+	if rec := recordOf(r); rec != nil {
+		rec.key = key
+	}
+}
+
+// noteLookup records a lookup's outcome.
+func noteLookup(r *http.Request, hit bool, sim float64) {
+	if rec := recordOf(r); rec != nil {
+		rec.lookup, rec.hit, rec.sim = true, hit, sim
+	}
+}
+
+// statusWriter remembers the status code written.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush and Hijack pass through for /v1/events.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking unsupported")
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return hj.Hijack()
+}
+
+// audited logs requests to h as op, if the audit log is on.
+func (s *server) audited(op string, h http.Handler) http.Handler {
+	a := s.audit
+	if a == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &auditRecord{}
+		sw := &statusWriter{ResponseWriter: w}
+		h.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), auditKey{}, rec)))
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		if sw.status < 400 && (a.sample == 0 || rand.Float64() >= a.sample) {
+			return
+		}
+		attrs := []slog.Attr{
+			slog.String("op", op),
+			slog.String("who", rec.who),
+			slog.String("namespace", namespace(rec.key)),
+		}
+		if k := a.redact(rec.key); k != "" {
+			attrs = append(attrs, slog.String("key", k))
+		}
+		attrs = append(attrs, slog.Int("status", sw.status))
+		if rec.lookup {
+			attrs = append(attrs, slog.Bool("hit", rec.hit), slog.Float64("similarity", rec.sim))
+		}
+		attrs = append(attrs,
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote", r.RemoteAddr),
+		)
+		a.log.LogAttrs(r.Context(), slog.LevelInfo, "request", attrs...)
+	})
+}
+
+func (a *auditLog) redact(key string) string {
+	switch {
+	case key == "" || a.keys == "omit":
+		return ""
+	case a.keys == "hash":
+		sum := sha256.Sum256([]byte(key))
+		return hex.EncodeToString(sum[:8])
+	}
+	return key
+}
+
+// namespace returns key up to and including its first ':', or "".
+func namespace(key string) string {
+	if i := strings.IndexByte(key, ':'); i >= 0 {
+		return key[:i+1]
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newAuditServer(t *testing.T, sample float64, keys string) (*httptest.Server, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	a, err := newAuditLog(&buf, sample, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, _ := newTestServer(t, func(s *server) {
+		s.audit = a
+		s.acl = &acl{Tokens: []*grant{{Name: "team-a", Token: "a", Write: []string{"team-a:"}}}}
+	})
+	return srv, &buf
+}
+
+func auditLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("bad line %q: %v", line, err)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestAuditLog(t *testing.T) {
+	srv, buf := newAuditServer(t, 1, "plain")
+	doAs(t, srv, "a", "POST", "/v1/set", `{"key":"team-a:capital of india","value":"Delhi"}`)
+	doAs(t, srv, "a", "GET", "/v1/get?key=team-a:capital+of+india", "")
+	doAs(t, srv, "", "GET", "/v1/get?key=team-a:x", "")
+
+	lines := auditLines(t, buf)
+	if len(lines) != 3 {
+		t.Fatalf("%d lines, want 3:\n%s", len(lines), buf)
+	}
+	set, get, denied := lines[0], lines[1], lines[2]
+	if set["op"] != "set" || set["who"] != "team-a" || set["namespace"] != "team-a:" || set["status"] != 204.0 {
+		t.Errorf("set line %v", set)
+	}
+	if get["op"] != "get" || get["key"] != "team-a:capital of india" || get["hit"] != true || get["similarity"] != 1.0 {
+		t.Errorf("get line %v", get)
+	}
+	if _, ok := get["latency_ms"]; !ok {
+		t.Errorf("get line has no latency: %v", get)
+	}
+	if denied["status"] != 401.0 || denied["who"] != "" {
+		t.Errorf("unauthorized line %v", denied)
+	}
+	if _, ok := set["hit"]; ok {
+		t.Errorf("set line has a hit field: %v", set)
+	}
+}
+
+func TestAuditLog_SampleAndRedact(t *testing.T) {
+	srv, buf := newAuditServer(t, 0, "hash")
+	doAs(t, srv, "a", "POST", "/v1/set", `{"key":"team-a:q","value":1}`)
+	doAs(t, srv, "a", "POST", "/v1/set", `{"key":"team-b:secret question","value":1}`)
+
+	lines := auditLines(t, buf)
+	if len(lines) != 1 {
+		t.Fatalf("%d lines, want only the failed request:\n%s", len(lines), buf)
+	}
+	if k := lines[0]["key"].(string); len(k) != 16 || strings.Contains(buf.String(), "secret") {
+		t.Errorf("key logged as %q", k)
+	}
+	if lines[0]["namespace"] != "team-b:" {
+		t.Errorf("namespace %v", lines[0]["namespace"])
+	}
+
+	if _, err := newAuditLog(buf, 2, "plain"); err == nil {
+		t.Error("sample rate 2 accepted")
+	}
+	if _, err := newAuditLog(buf, 1, "mask"); err == nil {
+		t.Error(`keys "mask" accepted`)
+	}
+}
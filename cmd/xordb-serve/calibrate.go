@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+)
+
+// POST /v1/feedback labels a hit: {"query": "...", "hit_key": "...",
+// "correct": false}. hit_key is the entry the query matched; if omitted it
+// is the entry the query matches now. Labels count in /v1/stats
+// (FeedbackCorrect, FeedbackWrong, EstPrecision).
+//
+// With -calibrate-every, a background job keeps the most recent labels and
+// on every tick replays them through the threshold sweep of xordb/eval,
+// looking for the lowest threshold whose labeled hits reach
+// -calibrate-precision. The result is a recommendation, shown by
+// GET /admin/calibration; with -calibrate-apply it is also applied, as
+// PATCH /admin/config would. Guardrails bound what the job may do:
+//
+//   - nothing happens before calibrateMinLabels labels are in
+//   - the threshold stays within [-calibrate-min, -calibrate-max]; the
+//     floor defaults to -threshold
+//   - one run moves it by at most -calibrate-max-step
+//
+// Labels only ever cover hits, so they show a threshold is too loose, not
+// too strict. When every label is right, the job recommends the floor: it
+// relaxes a threshold it tightened earlier once wrong hits stop coming.
+
+const (
+	calibrateWindow    = 1000 // labels kept
+	calibrateMinLabels = 20
+)
+
+type feedbackRequest struct {
+	Query   string `json:"query"`
+	HitKey  string `json:"hit_key,omitempty"`
+	Correct *bool  `json:"correct"`
+}
+
+func (s *server) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	var req feedbackRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	noteKey(r, req.Query)
+	if req.Query == "" || req.Correct == nil {
+		writeError(w, http.StatusBadRequest, errors.New("query and correct are required"))
+		return
+	}
+	g := grantOf(r)
+	if !g.canRead(req.Query) {
+		writeError(w, http.StatusForbidden, errNoRead(g, req.Query))
+		return
+	}
+	db := s.db()
+	if req.HitKey == "" {
+		cs := db.Explain(req.Query, 1)
+		if len(cs) == 0 || !cs[0].Hit || !g.canRead(cs[0].Key) {
+			writeError(w, http.StatusBadRequest, errors.New("query does not hit: give hit_key"))
+			return
+		}
+		req.HitKey = cs[0].Key
+	} else if !g.canRead(req.HitKey) {
+		writeError(w, http.StatusForbidden, errNoRead(g, req.HitKey))
+		return
+	}
+	db.Feedback(req.Query, req.HitKey, *req.Correct)
+	if s.calib != nil {
+		s.calib.label(eval.Pair{Cached: req.HitKey, Lookup: req.Query, ExpectHit: *req.Correct})
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"hit_key": req.HitKey})
+}
+
+// calibrator is the -calibrate-every job.
+type calibrator struct {
+	every     time.Duration
+	apply     bool
+	precision float64 // target for labeled hits
+	floor     float64
+	ceil      float64
+	maxStep   float64
+	dims      int // of the scratch DB the labels are replayed through
+
+	mu     sync.Mutex
+	labels []eval.Pair // ring of the most recent labels
+	next   int
+	last   *calibration // nil before the first run
+}
+
+// calibration is one run of the job, as GET /admin/calibration shows it.
+type calibration struct {
+	At          time.Time `json:"at"`
+	Labels      int       `json:"labels"`
+	Current     float64   `json:"current"`
+	Recommended float64   `json:"recommended,omitempty"` // 0 = none, see Reason
+	Precision   float64   `json:"precision"`             // of the labels at Recommended
+	Recall      float64   `json:"recall"`
+	Applied     bool      `json:"applied"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+func (c *calibrator) label(p eval.Pair) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.labels) < calibrateWindow {
+		c.labels = append(c.labels, p)
+		return
+	}
+	c.labels[c.next] = p
+	c.next = (c.next + 1) % calibrateWindow
+}
+
+// runCalibration calibrates every -calibrate-every until ctx is done.
+func (s *server) runCalibration(ctx context.Context) {
+	t := time.NewTicker(s.calib.every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.calibrate()
+		}
+	}
+}
+
+// calibrate runs the job once and records the outcome.
+func (s *server) calibrate() calibration {
+	c := s.calib
+	c.mu.Lock()
+	labels := append([]eval.Pair(nil), c.labels...)
+	c.mu.Unlock()
+
+	db := s.db()
+	out := calibration{At: time.Now(), Labels: len(labels), Current: db.Threshold()}
+	defer func() {
+		c.mu.Lock()
+		c.last = &out
+		c.mu.Unlock()
+	}()
+	if len(labels) < calibrateMinLabels {
+		out.Reason = fmt.Sprintf("%d labels, need %d", len(labels), calibrateMinLabels)
+		return out
+	}
+
+	correct := 0
+	for _, p := range labels {
+		if p.ExpectHit {
+			correct++
+		}
+	}
+	target := c.floor
+	if float64(correct)/float64(len(labels)) < c.precision {
+		scratch := xordb.New(xordb.WithDims(c.dims), xordb.WithCapacity(len(labels)))
+		pt := eval.Calibrate(scratch, labels, eval.PrecisionAtLeast(c.precision))
+		if pt.TP+pt.FP == 0 || pt.Precision() < c.precision {
+			out.Reason = fmt.Sprintf("no threshold reaches precision %.2f on the labels", c.precision)
+			return out
+		}
+		target = pt.Threshold
+	}
+	target = min(max(target, c.floor), c.ceil)
+	target = min(max(target, out.Current-c.maxStep), out.Current+c.maxStep)
+	target = math.Round(target*1e4) / 1e4
+	out.Recommended = target
+
+	scratch := xordb.New(xordb.WithDims(c.dims), xordb.WithCapacity(len(labels)))
+	for _, pt := range eval.SweepThresholds(scratch, labels, []float64{target}).Points {
+		out.Precision, out.Recall = pt.Precision(), pt.Recall()
+	}
+
+	if c.apply && target != out.Current {
+		if err := s.applyConfig(configPatch{Threshold: &target}); err != nil {
+			out.Reason = err.Error()
+			return out
+		}
+		out.Applied = true
+		log.Printf("xordb-serve: calibration: threshold %.4f -> %.4f (%d labels, precision %.3f)",
+			out.Current, target, len(labels), out.Precision)
+	}
+	return out
+}
+
+// GET /admin/calibration: the last run, 404 before the first.
+func (s *server) handleGetCalibration(w http.ResponseWriter, _ *http.Request) {
+	s.calib.mu.Lock()
+	last := s.calib.last
+	s.calib.mu.Unlock()
+	if last == nil {
+		writeError(w, http.StatusNotFound, errors.New("calibration has not run yet"))
+		return
+	}
+	writeJSON(w, http.StatusOK, last)
+}
+
+// POST /admin/calibrate: run now, without waiting for the next tick.
+func (s *server) handleCalibrate(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.calibrate())
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func newCalibrationServer(t *testing.T, apply bool) (*httptest.Server, *server) {
+	t.Helper()
+	cfg := &dbConfig{threshold: 0.7, capacity: 100}
+	return newTestServer(t, withDB(xordb.New(cfg.options()...)), func(s *server) {
+		s.cfg = cfg
+		s.adminToken = testToken
+		s.calib = &calibrator{apply: apply, precision: 0.95, floor: 0.7, ceil: 0.9, maxStep: 0.05, dims: s.db().Dims()}
+	})
+}
+
+// label sends 15 right and, unless allRight, 15 wrong labels at around 0.77.
+func label(t *testing.T, srv *httptest.Server, allRight bool) {
+	t.Helper()
+	for i := 0; i < 15; i++ {
+		hit := fmt.Sprintf("what is the capital of country number %d", i)
+		postJSON(t, srv, "/v1/set", fmt.Sprintf(`{"key": %q, "value": 1}`, hit)).Body.Close()
+		resp := postJSON(t, srv, "/v1/feedback", fmt.Sprintf(`{"query": %q, "correct": true}`, hit+"?"))
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("feedback without hit_key: %d", resp.StatusCode)
+		}
+		if !allRight {
+			postJSON(t, srv, "/v1/feedback", fmt.Sprintf(
+				`{"query": "how do I reset my username for account %d", "hit_key": "how do I reset my password for account %d", "correct": false}`, i, i)).Body.Close()
+		}
+	}
+}
+
+func TestCalibration_Applies(t *testing.T) {
+	srv, s := newCalibrationServer(t, true)
+	if resp, _ := adminDo(t, srv, "GET", "/admin/calibration", ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("before the first run: %d", resp.StatusCode)
+	}
+	if c := s.calibrate(); c.Recommended != 0 || c.Reason == "" {
+		t.Fatalf("no labels: %+v", c)
+	}
+
+	label(t, srv, false)
+	_, out := adminDo(t, srv, "POST", "/admin/calibrate", "")
+	if out["labels"] != 30.0 || out["recommended"] != 0.75 || out["applied"] != true {
+		t.Fatalf("first run moves one step: %v", out)
+	}
+	if got := s.db().Threshold(); got != 0.75 {
+		t.Fatalf("threshold %v, want 0.75", got)
+	}
+	if c := s.calibrate(); c.Recommended != 0.8 || c.Precision != 1 {
+		t.Fatalf("second run: %+v", c)
+	}
+	if st := s.db().Stats(); st.FeedbackCorrect != 15 || st.FeedbackWrong != 15 {
+		t.Fatalf("feedback not counted: %d right, %d wrong", st.FeedbackCorrect, st.FeedbackWrong)
+	}
+}
+
+func TestCalibration_RecommendsOnly(t *testing.T) {
+	srv, s := newCalibrationServer(t, false)
+	s.db().SetThreshold(0.8)
+	label(t, srv, true)
+	label(t, srv, true)
+	c := s.calibrate()
+	if c.Recommended != 0.75 || c.Applied || s.db().Threshold() != 0.8 {
+		t.Fatalf("all labels right: want a step toward the floor, not applied: %+v", c)
+	}
+	_, out := adminDo(t, srv, "GET", "/admin/calibration", "")
+	if out["recommended"] != 0.75 {
+		t.Fatalf("last run: %v", out)
+	}
+}
+
+func TestFeedback_Validation(t *testing.T) {
+	srv, _ := newCalibrationServer(t, false)
+	for _, body := range []string{
+		`{"query": "x"}`,
+		`{"correct": true}`,
+		`{"query": "nothing cached is like this", "correct": false}`,
+	} {
+		resp := postJSON(t, srv, "/v1/feedback", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: want 400, got %d", body, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+const (
+	subscriberBuffer = 1024
+	eventsKeepalive  = 15 * time.Second
+)
+
+// hub fans cache events out to /v1/events subscribers. Publishing never
+// blocks the cache: a subscriber whose buffer is full loses events, and is
+// told how many in a "dropped" message once it catches up.
+type hub struct {
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	ch      chan xordb.Event
+	kinds   map[xordb.EventKind]bool // nil = all kinds
+	g       *grant                   // namespaces the subscriber may see; nil = all
+	dropped atomic.Uint64
+}
+
+func newHub() *hub { return &hub{subs: make(map[*subscriber]struct{})} }
+
+// publish is passed to xordb.WithEventHook.
+func (h *hub) publish(ev xordb.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if sub.kinds != nil && !sub.kinds[ev.Kind] || !sub.g.canRead(ev.Key) {
+			continue
+		}
+		ev := ev
+		if !sub.g.canRead(ev.Match) { // another namespace's closest key
+			ev.Match, ev.Similarity = "", 0
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+func (h *hub) subscribe(kinds map[xordb.EventKind]bool, g *grant) *subscriber {
+	sub := &subscriber{ch: make(chan xordb.Event, subscriberBuffer), kinds: kinds, g: g}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+func (h *hub) unsubscribe(sub *subscriber) {
+	h.mu.Lock()
+	delete(h.subs, sub)
+	h.mu.Unlock()
+}
+
+// parseKinds reads ?kinds=hit,miss; empty means every kind.
+func parseKinds(s string) (map[xordb.EventKind]bool, error) {
+	if s == "" {
+		return nil, nil
+	}
+	kinds := make(map[xordb.EventKind]bool)
+	for _, k := range strings.Split(s, ",") {
+		switch kind := xordb.EventKind(strings.TrimSpace(k)); kind {
+		case xordb.EventHit, xordb.EventMiss, xordb.EventSet, xordb.EventEvict, xordb.EventExpire:
+			kinds[kind] = true
+		default:
+			return nil, fmt.Errorf("unknown event kind %q", k)
+		}
+	}
+	return kinds, nil
+}
+
+// handleEvents streams cache events as Server-Sent Events, or over a
+// WebSocket when the request asks for an upgrade. Each message is one
+// xordb.Event as JSON.
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	kinds, err := parseKinds(r.URL.Query().Get("kinds"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if isWebSocketUpgrade(r) {
+		s.serveEventsWS(w, r, kinds)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming unsupported"))
+		return
+	}
+	sub := s.events.subscribe(kinds, grantOf(r))
+	defer s.events.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(eventsKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case ev := <-sub.ch:
+			if n := sub.dropped.Swap(0); n > 0 {
+				fmt.Fprintf(w, "event: dropped\ndata: {\"dropped\":%d}\n\n", n)
+			}
+			data, _ := json.Marshal(ev)
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Kind, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func (s *server) serveEventsWS(w http.ResponseWriter, r *http.Request, kinds map[xordb.EventKind]bool) {
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer conn.Close()
+
+	sub := s.events.subscribe(kinds, grantOf(r))
+	defer s.events.unsubscribe(sub)
+
+	keepalive := time.NewTicker(eventsKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-conn.closed:
+			return
+		case <-keepalive.C:
+			if err := conn.writeFrame(wsPing, nil); err != nil {
+				return
+			}
+		case ev := <-sub.ch:
+			if n := sub.dropped.Swap(0); n > 0 {
+				conn.writeFrame(wsText, []byte(fmt.Sprintf(`{"kind":"dropped","dropped":%d}`, n)))
+			}
+			data, _ := json.Marshal(ev)
+			if err := conn.writeFrame(wsText, data); err != nil {
+				return
+			}
+		}
+	}
+}
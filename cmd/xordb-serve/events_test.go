@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func newEventsServer(t *testing.T) (*httptest.Server, *xordb.DB) {
+	t.Helper()
+	events := newHub()
+	db := xordb.New(xordb.WithThreshold(0.70), xordb.WithEventHook(events.publish))
+	srv, _ := newTestServer(t, withDB(db), func(s *server) { s.events = events })
+	return srv, db
+}
+
+func TestEvents_SSE(t *testing.T) {
+	srv, db := newEventsServer(t)
+
+	resp, err := http.Get(srv.URL + "/v1/events?kinds=hit,miss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("want text/event-stream, got %q", ct)
+	}
+
+	db.Set("what is the capital of india", "Delhi") // filtered out
+	db.Get("capital city of india")
+	db.Get("how to bake a cake")
+
+	sc := bufio.NewScanner(resp.Body)
+	var got []xordb.Event
+	for len(got) < 2 && sc.Scan() {
+		if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+			var ev xordb.Event
+			if err := json.Unmarshal([]byte(data), &ev); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, ev)
+		}
+	}
+	if len(got) != 2 || got[0].Kind != xordb.EventHit || got[1].Kind != xordb.EventMiss {
+		t.Fatalf("want hit then miss, got %+v", got)
+	}
+	if got[0].Match != "what is the capital of india" || got[0].Similarity < 0.70 {
+		t.Fatalf("hit event must carry match and similarity, got %+v", got[0])
+	}
+}
+
+func TestEvents_BadKind(t *testing.T) {
+	srv, _ := newEventsServer(t)
+	resp, err := http.Get(srv.URL + "/v1/events?kinds=hit,nope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("want 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestEvents_WebSocket(t *testing.T) {
+	srv, db := newEventsServer(t)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	io.WriteString(conn, "GET /v1/events?kinds=set HTTP/1.1\r\nHost: x\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("want 101, got %d", resp.StatusCode)
+	}
+	// RFC 6455 §1.3 example key/accept pair
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("bad accept %q", got)
+	}
+
+	db.Set("hello", 1)
+
+	op, payload := readServerFrame(t, br)
+	if op != wsText {
+		t.Fatalf("want text frame, got opcode %d", op)
+	}
+	var ev xordb.Event
+	if err := json.Unmarshal(payload, &ev); err != nil || ev.Kind != xordb.EventSet || ev.Key != "hello" {
+		t.Fatalf("want set event for hello, got %s (%v)", payload, err)
+	}
+
+	// masked client close; server echoes it
+	conn.Write([]byte{0x80 | wsClose, 0x80, 1, 2, 3, 4})
+	if op, _ := readServerFrame(t, br); op != wsClose {
+		t.Fatalf("want close echo, got opcode %d", op)
+	}
+}
+
+func readServerFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		t.Fatal(err)
+	}
+	n := int(hdr[1] & 0x7F)
+	if n == 126 {
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return hdr[0] & 0x0F, payload
+}
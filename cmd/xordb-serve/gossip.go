@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/gossip"
+)
+
+// Gossip: with -gossip-udp and -gossip-peers, or -gossip-redis, a primary
+// broadcasts the keys of /v1/delete to its peers, and drops the keys its
+// peers broadcast along with the entries matching them, at -gossip-min-sim
+// or its own threshold (see xordb/gossip). This keeps independent caches
+// roughly in step without replication; delivery is best effort, so keep a
+// -ttl as a backstop. Replicas follow their primary and take no part.
+
+// gossipCache is the gossip.Cache of a server: deletes go through the
+// write path, so replicas follow them too.
+type gossipCache struct{ s *server }
+
+func (c gossipCache) Delete(key string) bool { return c.s.w.Delete(key) }
+func (c gossipCache) Threshold() float64     { return c.s.db().Threshold() }
+
+func (c gossipCache) SimilarKeys(key string, n int, minSim float64) []xordb.KeySim {
+	return c.s.db().SimilarKeys(key, n, minSim)
+}
+
+// gossipTransport returns the transport the flags ask for, nil if none.
+func gossipTransport(udpAddr, peers, redisAddr, channel, password string) (gossip.Transport, error) {
+	switch {
+	case udpAddr != "" && redisAddr != "":
+		log.Fatal("xordb-serve: -gossip-udp and -gossip-redis are exclusive")
+	case udpAddr != "":
+		var list []string
+		for _, p := range strings.Split(peers, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				list = append(list, p)
+			}
+		}
+		return gossip.ListenUDP(udpAddr, list...)
+	case redisAddr != "":
+		return &gossip.Redis{Addr: redisAddr, Channel: channel, Password: password}, nil
+	}
+	return nil, nil
+}
+
+// broadcastDelete tells peers about a deleted key, logging failures: a
+// lost invalidation only leaves peers to expire the entry.
+func (s *server) broadcastDelete(ctx context.Context, key string) {
+	if s.gossip == nil {
+		return
+	}
+	if err := s.gossip.Broadcast(ctx, key); err != nil {
+		log.Printf("xordb-serve: %v", err)
+	}
+}
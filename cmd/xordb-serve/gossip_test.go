@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb/gossip"
+)
+
+func TestGossip_DeletePropagates(t *testing.T) {
+	here, err := gossip.ListenUDP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer here.Close()
+	there, err := gossip.ListenUDP("127.0.0.1:0", here.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer there.Close()
+	// here publishes to there; rebuild it with its peer now that the port
+	// is known.
+	addr := here.Addr().String()
+	here.Close()
+	if here, err = gossip.ListenUDP(addr, there.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	defer here.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	newNode := func(tr gossip.Transport) (*httptest.Server, *server) {
+		return newTestServer(t, func(s *server) {
+			s.gossip = gossip.New(gossipCache{s}, tr)
+			go s.gossip.Run(ctx)
+		})
+	}
+	a, _ := newNode(here)
+	b, sb := newNode(there)
+
+	for _, srv := range []*httptest.Server{a, b} {
+		postJSON(t, srv, "/v1/set", `{"key": "what is the refund policy", "value": "30 days"}`).Body.Close()
+		postJSON(t, srv, "/v1/set", `{"key": "what's the refund policy", "value": "30 days"}`).Body.Close()
+	}
+	postJSON(t, a, "/v1/delete", `{"key": "what is the refund policy"}`).Body.Close()
+
+	for deadline := time.Now().Add(2 * time.Second); sb.db().Len() != 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("peer kept %d entries", sb.db().Len())
+		}
+	}
+	resp, err := http.Get(b.URL + "/v1/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var st statsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Gossip == nil || st.Gossip.Received != 1 || st.Gossip.Invalidated != 2 {
+		t.Errorf("peer gossip stats %+v", st.Gossip)
+	}
+}
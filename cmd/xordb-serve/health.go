@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/selftest"
+)
+
+// GET /healthz answers 200 while the process serves HTTP at all, for
+// liveness probes. GET /readyz answers 200 only when the server can take
+// traffic, 503 otherwise, with the result of each check:
+//
+//	{"ready": false, "checks": {"encoder": "ok", "selftest": "ok",
+//	  "persistence": "open /data/.xordb-ready-1: read-only file system"}}
+//
+//   - encoder: a sample key encodes within -ready-encode-max
+//   - selftest: the encoder passes selftest (run once, on the first probe)
+//   - persistence: a primary can write to the -snapshot directory
+//   - replication: a replica has bootstrapped from its primary
+//   - shutdown: the server is not draining
+//
+// Neither endpoint needs a token or is audited: probes come every few
+// seconds from the orchestrator, not from tenants.
+
+const readyProbe = "what is the capital of india"
+
+type readiness struct {
+	encodeMax time.Duration // 0 = defaultEncodeMax
+
+	mu       sync.Mutex
+	ran      bool
+	selftest error
+}
+
+const defaultEncodeMax = time.Second
+
+func (s *server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *server) handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	checks := map[string]error{"encoder": s.checkEncoder()}
+	if checks["encoder"] == nil {
+		checks["selftest"] = s.checkSelftest()
+	} else {
+		checks["selftest"] = errors.New("not run: encoder check failed")
+	}
+	if s.draining.Load() {
+		checks["shutdown"] = errShuttingDown
+	}
+	if s.replica != nil {
+		if s.replica.Status().Resyncs == 0 {
+			checks["replication"] = errors.New("not bootstrapped from the primary yet")
+		} else {
+			checks["replication"] = nil
+		}
+	} else if s.snapshotPath != "" {
+		checks["persistence"] = checkWritable(filepath.Dir(s.snapshotPath))
+	}
+
+	ready := true
+	out := make(map[string]string, len(checks))
+	for name, err := range checks {
+		out[name] = "ok"
+		if err != nil {
+			out[name] = err.Error()
+			ready = false
+		}
+	}
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, map[string]any{"ready": ready, "checks": out})
+}
+
+// checkEncoder encodes a sample key, giving up after the latency bound so a
+// wedged encoder fails the probe rather than hanging it.
+func (s *server) checkEncoder() error {
+	max := s.ready.encodeMax
+	if max <= 0 {
+		max = defaultEncodeMax
+	}
+	db := s.db()
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		_, err := db.Encode(readyProbe)
+		done <- err
+	}()
+	t := time.NewTimer(max)
+	defer t.Stop()
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+		if d := time.Since(start); d > max {
+			return fmt.Errorf("sample encode took %s, over %s", d.Round(time.Millisecond), max)
+		}
+		return nil
+	case <-t.C:
+		return fmt.Errorf("sample encode still running after %s", max)
+	}
+}
+
+// checkSelftest runs selftest against the DB's encoder until it gets a
+// verdict, then keeps it: replicas rebuild their DB on every bootstrap, but
+// always with the same encoder settings, so one result holds for the life
+// of the process. A run cut short by a failed encode is not a verdict.
+func (s *server) checkSelftest() error {
+	r := &s.ready
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ran {
+		return r.selftest
+	}
+	db := s.db()
+	var failed error
+	rep := selftest.Run(encoderFunc(func(text string) hdc.Vector {
+		v, err := db.Encode(text)
+		if err != nil {
+			if failed == nil {
+				failed = err
+			}
+			return hdc.Random(db.Dims(), 0) // keep selftest going; the run is discarded
+		}
+		return v
+	}))
+	if failed != nil {
+		return failed
+	}
+	r.ran = true
+	if !rep.Passed() {
+		r.selftest = fmt.Errorf("encoder failed the %s check", rep.Failed()[0].Name)
+	}
+	return r.selftest
+}
+
+// encoderFunc adapts DB.Encode to hdc.Encoder for selftest.
+type encoderFunc func(string) hdc.Vector
+
+func (f encoderFunc) Encode(s string) hdc.Vector { return f(s) }
+
+// checkWritable creates and removes a file in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".xordb-ready-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func readyz(t *testing.T, s *server) (int, map[string]string) {
+	t.Helper()
+	mux := http.NewServeMux()
+	s.routes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+	var out struct {
+		Ready  bool
+		Checks map[string]string
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Ready != (rec.Code == http.StatusOK) {
+		t.Errorf("ready %v with status %d", out.Ready, rec.Code)
+	}
+	return rec.Code, out.Checks
+}
+
+func TestReadyz(t *testing.T) {
+	s := newServer(xordb.New())
+	s.acl = &acl{} // probes need no token
+	s.snapshotPath = filepath.Join(t.TempDir(), "cache.xrdb")
+	code, checks := readyz(t, s)
+	if code != http.StatusOK || checks["encoder"] != "ok" || checks["selftest"] != "ok" || checks["persistence"] != "ok" {
+		t.Fatalf("ready server: %d %v", code, checks)
+	}
+
+	s.snapshotPath = filepath.Join(t.TempDir(), "missing", "cache.xrdb")
+	if code, checks := readyz(t, s); code != http.StatusServiceUnavailable || checks["persistence"] == "ok" {
+		t.Errorf("unwritable snapshot dir: %d %v", code, checks)
+	}
+	s.snapshotPath = ""
+
+	s.draining.Store(true)
+	if code, checks := readyz(t, s); code != http.StatusServiceUnavailable || checks["shutdown"] == "" {
+		t.Errorf("draining: %d %v", code, checks)
+	}
+
+	mux := http.NewServeMux()
+	s.routes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("healthz while draining: %d", rec.Code)
+	}
+}
+
+func TestReadyz_SlowEncoder(t *testing.T) {
+	s := newServer(xordb.New())
+	s.ready.encodeMax = 1 // ns
+	code, checks := readyz(t, s)
+	if code != http.StatusServiceUnavailable || checks["encoder"] == "ok" || checks["selftest"] == "ok" {
+		t.Errorf("over the encode bound: %d %v", code, checks)
+	}
+}
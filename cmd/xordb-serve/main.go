@@ -0,0 +1,319 @@
+// xordb-serve — run an xordb cache as an HTTP service.
+//
+// Endpoints:
+//
+//	POST /v1/set      {"key": "...", "value": ..., "ttl": "10m"}
+//	GET  /v1/get?key=...
+//	POST /v1/mget     {"keys": ["...", ...]}
+//	POST /v1/delete   {"key": "..."}
+//	GET  /v1/stats
+//	GET  /v1/version  protocol version and encoder fingerprint
+//	GET  /v1/explain?key=...&n=10
+//	GET  /v1/export   (JSONL; ?format=csv for one analytics row per entry)
+//	POST /v1/import   (JSONL)
+//	POST /v1/feedback {"query": "...", "hit_key": "...", "correct": false}
+//	GET  /v1/events?kinds=hit,miss  (SSE, or WebSocket on upgrade)
+//	GET  /healthz     liveness
+//	GET  /readyz      readiness: encoder, persistence, replication (see health.go)
+//
+// Versioning: /v1 responses carry Xordb-Protocol and Xordb-Encoder (the
+// encoder fingerprint) headers; requests that send incompatible ones get
+// 412 (see version.go).
+//
+// Replication: every server is a primary unless -replica-of is given, and
+// serves GET /v1/replication/{snapshot,stream} to its replicas. A replica
+// bootstraps from the primary's snapshot, tails its write stream, serves
+// reads, and rejects writes with 403. Replica lag is reported under
+// "Replication" in /v1/stats. Replicas must use the same -dims.
+//
+// Access control: with -acl, every /v1 endpoint and /metrics require
+// "Authorization: Bearer <token>" with a token from the file, which grants
+// read and write access by key prefix (see acl.go); a token missing from
+// the file gets 401, one without access to a key 403. Lookups, explain,
+// export and events only show entries the token may read, so a similar key
+// in another namespace is a miss. Replication and /metrics need a token
+// that may read every namespace; replicas send -replica-token. Entries may
+// cap their token's request rate and set bytes; requests over quota get
+// 429 with Retry-After (see quota.go).
+//
+// Audit log: with -audit-log (a file, or "-" for stdout), every /v1 request
+// is logged as a JSON line: token name, namespace, operation, key, status,
+// hit and similarity for lookups, and latency (see auditlog.go). Failed
+// requests are always logged, the rest at the -audit-sample rate; keys are
+// written as -audit-keys says: plain, hash or omit.
+//
+// Reload: -config names a JSON file of {"threshold", "capacity", "ttl"},
+// applied over the flags at startup. SIGHUP or POST /admin/reload rereads
+// it and the -acl file and applies them to the live cache, which keeps its
+// entries.
+//
+// Admin: with -admin-token (or XORDB_ADMIN_TOKEN) set, /admin/* accepts
+// requests bearing "Authorization: Bearer <token>":
+//
+//	GET   /admin/config                 threshold, capacity, default ttl
+//	PATCH /admin/config   {"threshold": 0.8, "capacity": 5000, "ttl": "1h"}
+//	POST  /admin/snapshot               save to -snapshot path
+//	POST  /admin/clear    {"prefix": "tenant-a:"}  ("" clears everything)
+//	POST  /admin/pin      {"key": "..."}   exempt from eviction and expiry
+//	POST  /admin/unpin    {"key": "..."}
+//	POST  /admin/reload                 reread -config and -acl
+//	GET   /admin/quotas                 per-token quota usage
+//	GET   /admin/calibration            last calibration run
+//	POST  /admin/calibrate              run calibration now
+//
+// Calibration: with -calibrate-every, a background job periodically sweeps
+// the threshold over the labels sent to /v1/feedback and recommends the
+// lowest one reaching -calibrate-precision, or with -calibrate-apply
+// applies it, within -calibrate-min, -calibrate-max and -calibrate-max-step
+// (see calibrate.go).
+//
+// Gossip: with -gossip-udp and -gossip-peers, or -gossip-redis (password
+// in XORDB_GOSSIP_REDIS_PASSWORD), a primary broadcasts the keys of
+// /v1/delete and drops the keys its peers broadcast with the entries
+// matching them, best effort (see gossip.go). Counters appear under
+// "Gossip" in /v1/stats.
+//
+// With -snapshot, a primary loads the file at startup if it exists.
+//
+// On SIGTERM or SIGINT the server refuses new writes with 503, ends event
+// streams and replication, waits for requests in flight, and a primary
+// saves a final snapshot to -snapshot, all within -shutdown-timeout.
+// Snapshots are written to a temporary file and renamed, so running out of
+// time leaves the previous one intact. A second signal exits at once.
+//
+// With -metrics, Prometheus metrics are served at /metrics; with -status an
+// HTML page of stats, hit similarities, top keys and recent evictions at
+// /status; with -pprof the net/http/pprof handlers are mounted under
+// /debug/pprof/. Keep -pprof behind a private listener, it exposes heap and
+// goroutine dumps. Under -acl, /metrics and /status need a token reading
+// every namespace.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/gossip"
+	"github.com/Amansingh-afk/xordb/metrics"
+	"github.com/Amansingh-afk/xordb/replication"
+)
+
+func main() {
+	listen := flag.String("listen", ":7700", "address to listen on")
+	threshold := flag.Float64("threshold", 0.75, "minimum similarity for a cache hit")
+	capacity := flag.Int("capacity", 1024, "max cached entries")
+	dims := flag.Int("dims", 10000, "hypervector dimension")
+	ttl := flag.Duration("ttl", 0, "default entry lifetime (0 = never expires)")
+	withMetrics := flag.Bool("metrics", false, "serve Prometheus metrics at /metrics")
+	withStatus := flag.Bool("status", false, "serve an HTML status page at /status")
+	withPprof := flag.Bool("pprof", false, "mount net/http/pprof under /debug/pprof/")
+	replicaOf := flag.String("replica-of", "", "primary base URL (e.g. http://primary:7700); run as read-only replica")
+	replLog := flag.Int("repl-log", 100_000, "writes kept for replicas to catch up from (0 disables replication)")
+	adminToken := flag.String("admin-token", os.Getenv("XORDB_ADMIN_TOKEN"), "bearer token enabling /admin/* (empty = disabled)")
+	snapshot := flag.String("snapshot", "", "snapshot file for POST /admin/snapshot, loaded at startup")
+	configPath := flag.String("config", "", "JSON file of threshold, capacity and ttl, applied over the flags and on reload")
+	aclPath := flag.String("acl", "", "JSON file of bearer tokens and the key prefixes each may read and write (empty = open API)")
+	replicaToken := flag.String("replica-token", os.Getenv("XORDB_REPLICA_TOKEN"), "replica: bearer token sent to the primary")
+	auditPath := flag.String("audit-log", "", `file to append a JSON line per /v1 request to ("-" = stdout, empty = off)`)
+	auditSample := flag.Float64("audit-sample", 1, "fraction of successful requests the audit log records")
+	auditKeys := flag.String("audit-keys", "hash", "how the audit log writes keys: plain, hash or omit")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "on SIGTERM or SIGINT, how long to drain requests and save the final snapshot")
+	readyEncodeMax := flag.Duration("ready-encode-max", time.Second, "latency bound on the sample encode checked by /readyz")
+	resync := flag.Duration("resync", 10*time.Minute, "replica: full snapshot resync interval (0 = only when needed)")
+	calibrateEvery := flag.Duration("calibrate-every", 0, "run threshold calibration on /v1/feedback labels this often (0 = off)")
+	calibrateApply := flag.Bool("calibrate-apply", false, "apply calibrated thresholds instead of only recommending them")
+	calibratePrecision := flag.Float64("calibrate-precision", 0.95, "precision of labeled hits calibration aims for")
+	calibrateMin := flag.Float64("calibrate-min", 0, "lowest threshold calibration may set (0 = -threshold)")
+	calibrateMax := flag.Float64("calibrate-max", 0.99, "highest threshold calibration may set")
+	calibrateStep := flag.Float64("calibrate-max-step", 0.05, "largest threshold change one calibration run may make")
+	gossipUDP := flag.String("gossip-udp", "", "UDP address to exchange delete invalidations with -gossip-peers on (empty = off)")
+	gossipPeers := flag.String("gossip-peers", "", "comma-separated host:port of the -gossip-udp peers")
+	gossipRedis := flag.String("gossip-redis", "", "Redis host:port to exchange delete invalidations over pub/sub (empty = off)")
+	gossipChannel := flag.String("gossip-channel", "xordb:invalidate", "Redis channel for -gossip-redis")
+	gossipMinSim := flag.Float64("gossip-min-sim", 0, "similarity at which peers drop entries matching a deleted key (0 = each peer's threshold)")
+	flag.Parse()
+
+	events := newHub()
+	hook := events.publish
+	var status *metrics.StatusPage
+	if *withStatus {
+		status = metrics.NewStatusPage()
+		record := status.Events("default")
+		hook = func(ev xordb.Event) {
+			events.publish(ev)
+			record(ev)
+		}
+	}
+	cfg := &dbConfig{threshold: *threshold, capacity: *capacity, ttl: *ttl}
+	newDB := func() *xordb.DB {
+		return xordb.New(append(cfg.options(),
+			xordb.WithDims(*dims),
+			xordb.WithEventHook(hook),
+		)...)
+	}
+	loadDB := func() *xordb.DB {
+		db := newDB()
+		if *snapshot == "" {
+			return db
+		}
+		if err := db.Load(*snapshot); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Fatal(err)
+		}
+		log.Printf("xordb-serve: loaded %d entries from %s", db.Len(), *snapshot)
+		return db
+	}
+
+	// canceled at shutdown: ends replication and event streams
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var srv *server
+	switch {
+	case *replicaOf != "":
+		client := &http.Client{}
+		if *replicaToken != "" {
+			client.Transport = bearer{*replicaToken, http.DefaultTransport}
+		}
+		r := replication.NewReplica(strings.TrimRight(*replicaOf, "/")+"/v1/replication", newDB,
+			replication.WithResyncInterval(*resync), replication.WithHTTPClient(client))
+		go r.Run(ctx)
+		srv = newReplicaServer(r)
+		log.Printf("xordb-serve: replicating from %s", *replicaOf)
+	case *replLog > 0:
+		srv = newPrimaryServer(replication.NewPrimary(loadDB(), *replLog))
+	default:
+		srv = newServer(loadDB())
+	}
+
+	srv.events = events
+	srv.ready.encodeMax = *readyEncodeMax
+	srv.cfg = cfg
+	srv.adminToken = *adminToken
+	if *auditPath != "" {
+		out := os.Stdout
+		if *auditPath != "-" {
+			f, err := os.OpenFile(*auditPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+			if err != nil {
+				log.Fatal(err)
+			}
+			out = f
+		}
+		a, err := newAuditLog(out, *auditSample, *auditKeys)
+		if err != nil {
+			log.Fatal(err)
+		}
+		srv.audit = a
+	}
+	srv.configPath = *configPath
+	srv.aclPath = *aclPath
+	if *aclPath != "" {
+		srv.acl = &acl{}
+	}
+	if err := srv.reload(); err != nil {
+		log.Fatal(err)
+	}
+	go reloadOnSIGHUP(srv)
+	srv.snapshotPath = *snapshot
+	if *calibrateEvery > 0 {
+		floor := *calibrateMin
+		if floor == 0 {
+			floor = *threshold
+		}
+		if floor <= 0 || floor > *calibrateMax || *calibrateMax > 1 || *calibratePrecision <= 0 || *calibratePrecision > 1 || *calibrateStep <= 0 {
+			log.Fatal("xordb-serve: need 0 < -calibrate-min <= -calibrate-max <= 1, -calibrate-precision in (0, 1] and a positive -calibrate-max-step")
+		}
+		srv.calib = &calibrator{
+			every: *calibrateEvery, apply: *calibrateApply, precision: *calibratePrecision,
+			floor: floor, ceil: *calibrateMax, maxStep: *calibrateStep, dims: *dims,
+		}
+		go srv.runCalibration(ctx)
+	}
+	t, err := gossipTransport(*gossipUDP, *gossipPeers, *gossipRedis, *gossipChannel, os.Getenv("XORDB_GOSSIP_REDIS_PASSWORD"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if t != nil {
+		if srv.w == nil {
+			log.Fatal("xordb-serve: replicas follow their primary; gossip on the primary")
+		}
+		srv.gossip = gossip.New(gossipCache{srv}, t, gossip.WithMinSimilarity(*gossipMinSim))
+		go srv.gossip.Run(ctx)
+		log.Printf("xordb-serve: gossiping invalidations as %s", srv.gossip.Name())
+	}
+
+	mux := http.NewServeMux()
+	srv.routes(mux)
+	if *withMetrics {
+		col := metrics.NewCollector()
+		col.Register("default", srv)
+		mux.Handle("GET /metrics", srv.authorize(col, true))
+	}
+	if status != nil {
+		status.Register("default", srv)
+		mux.Handle("GET /status", srv.authorize(status, true))
+	}
+	if *withPprof {
+		srv.mountPprof(mux)
+	}
+
+	hs := &http.Server{Addr: *listen, Handler: mux, BaseContext: func(net.Listener) context.Context { return ctx }}
+	served := make(chan error, 1)
+	go func() { served <- hs.ListenAndServe() }()
+	log.Printf("xordb-serve listening on %s", *listen)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, shutdownSignals...)
+	select {
+	case err := <-served:
+		log.Fatal(err)
+	case sig := <-stop:
+		log.Printf("xordb-serve: %v: shutting down (deadline %s)", sig, *shutdownTimeout)
+	}
+	signal.Stop(stop) // a second signal kills the process
+	deadline, done := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer done()
+	srv.draining.Store(true)
+	cancel()
+	if err := hs.Shutdown(deadline); err != nil {
+		log.Printf("xordb-serve: draining requests: %v", err)
+	}
+	if err := srv.shutdown(deadline); err != nil {
+		log.Printf("xordb-serve: shutdown: %v", err)
+		os.Exit(1)
+	}
+}
+
+// bearer adds an Authorization header to every request.
+type bearer struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (b bearer) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+b.token)
+	return b.next.RoundTrip(r)
+}
+
+// mountPprof serves the profiles to tokens that may read everything, like
+// /metrics: the command line and heap can hold keys of any namespace.
+func (s *server) mountPprof(mux *http.ServeMux) {
+	for path, h := range map[string]http.HandlerFunc{
+		"/debug/pprof/":        pprof.Index,
+		"/debug/pprof/cmdline": pprof.Cmdline,
+		"/debug/pprof/profile": pprof.Profile,
+		"/debug/pprof/symbol":  pprof.Symbol,
+		"/debug/pprof/trace":   pprof.Trace,
+	} {
+		mux.Handle(path, s.authorize(h, true))
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ACL entries may cap their token's request rate and the bytes it writes,
+// so one misbehaving client cannot saturate the shared encoder:
+//
+//	{"name": "batch", "token": "…", "write": ["batch:"],
+//	 "requests_per_second": 50, "burst": 100, "set_bytes_per_minute": 10000000}
+//
+// Requests over either quota get 429 with Retry-After. Set bytes are the
+// key plus the JSON value of POST /v1/set, and the body of POST
+// /v1/import. Quotas are token buckets refilled continuously, kept by
+// token name across reloads, so entries sharing a name — an old and a new
+// token during rotation — share a quota (give them the same limits). GET
+// /admin/quotas reports usage.
+
+// bucket is a token bucket; a zero rate means unlimited.
+type bucket struct {
+	rate  float64 // refill per second
+	burst float64
+	level float64
+	last  time.Time
+}
+
+// limit sets the bucket's rate and capacity, keeping its level if the
+// limits are unchanged (e.g. across a reload) and filling it otherwise.
+func (b *bucket) limit(rate, burst float64, now time.Time) {
+	if b.rate == rate && b.burst == burst {
+		return
+	}
+	b.rate, b.burst, b.level, b.last = rate, burst, burst, now
+}
+
+// take removes n from the bucket if it holds that much. Otherwise it
+// reports how long until it will, or 0 if n exceeds the capacity.
+func (b *bucket) take(n float64, now time.Time) (ok bool, retry time.Duration) {
+	if b.rate <= 0 {
+		return true, 0
+	}
+	b.level = min(b.burst, b.level+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	switch {
+	case n > b.burst:
+		return false, 0
+	case n > b.level:
+		return false, time.Duration((n - b.level) / b.rate * float64(time.Second))
+	}
+	b.level -= n
+	return true, 0
+}
+
+func (b *bucket) available(now time.Time) float64 {
+	if b.rate <= 0 {
+		return math.Inf(1)
+	}
+	return min(b.burst, b.level+now.Sub(b.last).Seconds()*b.rate)
+}
+
+type quotaUsage struct {
+	requests, setBytes bucket
+	allowed, limited   uint64 // requests
+	bytes              uint64 // set bytes accepted
+}
+
+type quotas struct {
+	mu     sync.Mutex
+	byName map[string]*quotaUsage
+}
+
+// usage returns g's usage with its limits applied. q.mu must be held.
+func (q *quotas) usage(g *grant, now time.Time) *quotaUsage {
+	if q.byName == nil {
+		q.byName = make(map[string]*quotaUsage)
+	}
+	u := q.byName[g.Name]
+	if u == nil {
+		u = &quotaUsage{}
+		q.byName[g.Name] = u
+	}
+	burst := float64(g.Burst)
+	if burst <= 0 {
+		burst = max(1, math.Ceil(g.RequestsPerSecond))
+	}
+	u.requests.limit(g.RequestsPerSecond, burst, now)
+	perMin := float64(g.SetBytesPerMinute)
+	u.setBytes.limit(perMin/60, perMin, now)
+	return u
+}
+
+// allowRequest charges one request to g.
+func (q *quotas) allowRequest(g *grant) (bool, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	u := q.usage(g, now)
+	ok, retry := u.requests.take(1, now)
+	if ok {
+		u.allowed++
+	} else {
+		u.limited++
+	}
+	return ok, retry
+}
+
+// allowSetBytes charges n set bytes to g.
+func (q *quotas) allowSetBytes(g *grant, n int) (bool, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	u := q.usage(g, now)
+	ok, retry := u.setBytes.take(float64(n), now)
+	if ok {
+		u.bytes += uint64(n)
+	} else {
+		u.limited++
+	}
+	return ok, retry
+}
+
+// chargeSet charges a write of n bytes to the request's token, answering
+// the request with 429 if it is over quota. Without an ACL it always
+// passes.
+func (s *server) chargeSet(w http.ResponseWriter, r *http.Request, n int) bool {
+	g := grantOf(r)
+	if g == nil || g.SetBytesPerMinute <= 0 {
+		return true
+	}
+	ok, retry := s.quotas.allowSetBytes(g, n)
+	if ok {
+		return true
+	}
+	if retry == 0 {
+		writeError(w, http.StatusTooManyRequests, fmt.Errorf("%d bytes exceed token %q's set-byte quota of %d per minute", n, g.Name, g.SetBytesPerMinute))
+		return false
+	}
+	tooMany(w, retry, fmt.Errorf("token %q is over its set-byte quota", g.Name))
+	return false
+}
+
+func tooMany(w http.ResponseWriter, retry time.Duration, err error) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+	writeError(w, http.StatusTooManyRequests, err)
+}
+
+// setSize is what a set costs against the set-byte quota.
+func setSize(key string, value any) int {
+	b, _ := json.Marshal(value)
+	return len(key) + len(b)
+}
+
+type quotaReport struct {
+	Name              string  `json:"name"`
+	Requests          uint64  `json:"requests"`
+	Limited           uint64  `json:"limited"` // requests answered 429
+	SetBytes          uint64  `json:"set_bytes"`
+	RequestsPerSecond float64 `json:"requests_per_second,omitempty"`
+	Burst             int     `json:"burst,omitempty"`
+	SetBytesPerMinute int64   `json:"set_bytes_per_minute,omitempty"`
+	RequestsLeft      *int64  `json:"requests_left,omitempty"` // in the bucket now
+	SetBytesLeft      *int64  `json:"set_bytes_left,omitempty"`
+}
+
+func (s *server) handleQuotas(w http.ResponseWriter, _ *http.Request) {
+	var grants []*grant
+	if s.acl != nil {
+		s.acl.mu.RLock()
+		grants = s.acl.Tokens
+		s.acl.mu.RUnlock()
+	}
+	q := &s.quotas
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	seen := make(map[string]bool)
+	out := []quotaReport{}
+	for _, g := range grants {
+		if seen[g.Name] {
+			continue
+		}
+		seen[g.Name] = true
+		u := q.usage(g, now)
+		rep := quotaReport{
+			Name:              g.Name,
+			Requests:          u.allowed,
+			Limited:           u.limited,
+			SetBytes:          u.bytes,
+			RequestsPerSecond: g.RequestsPerSecond,
+			Burst:             int(u.requests.burst),
+			SetBytesPerMinute: g.SetBytesPerMinute,
+		}
+		if g.RequestsPerSecond <= 0 {
+			rep.Burst = 0
+		} else {
+			n := int64(u.requests.available(now))
+			rep.RequestsLeft = &n
+		}
+		if g.SetBytesPerMinute > 0 {
+			n := int64(u.setBytes.available(now))
+			rep.SetBytesLeft = &n
+		}
+		out = append(out, rep)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	writeJSON(w, http.StatusOK, map[string][]quotaReport{"tokens": out})
+}
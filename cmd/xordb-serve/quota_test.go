@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func newQuotaServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv, _ := newTestServer(t, withDB(xordb.New()), func(s *server) {
+		s.adminToken = "admin"
+		s.acl = &acl{Tokens: []*grant{
+			{Name: "slow", Token: "s", Write: []string{""}, RequestsPerSecond: 1, Burst: 2},
+			{Name: "small", Token: "b", Write: []string{""}, SetBytesPerMinute: 60},
+			{Name: "free", Token: "f", Read: []string{""}},
+		}}
+	})
+	return srv
+}
+
+func TestQuota_Requests(t *testing.T) {
+	srv := newQuotaServer(t)
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		resp := doAs(t, srv, "s", "GET", "/v1/get?key=q", "")
+		if resp.StatusCode != want {
+			t.Errorf("request %d: %d, want %d", i, resp.StatusCode, want)
+		}
+		if want == http.StatusTooManyRequests && resp.Header.Get("Retry-After") != "1" {
+			t.Errorf("Retry-After %q", resp.Header.Get("Retry-After"))
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if resp := doAs(t, srv, "f", "GET", "/v1/get?key=q", ""); resp.StatusCode != http.StatusOK {
+			t.Fatalf("unlimited token: %d", resp.StatusCode)
+		}
+	}
+
+	resp := doAs(t, srv, "admin", "GET", "/admin/quotas", "")
+	var out struct{ Tokens []quotaReport }
+	json.NewDecoder(resp.Body).Decode(&out)
+	byName := make(map[string]quotaReport)
+	for _, r := range out.Tokens {
+		byName[r.Name] = r
+	}
+	if r := byName["slow"]; r.Requests != 2 || r.Limited != 1 || r.Burst != 2 || r.RequestsLeft == nil {
+		t.Errorf("slow: %+v", r)
+	}
+	if r := byName["free"]; r.Requests != 10 || r.Limited != 0 || r.RequestsLeft != nil {
+		t.Errorf("free: %+v", r)
+	}
+}
+
+func TestQuota_SetBytes(t *testing.T) {
+	srv := newQuotaServer(t)
+	set := func(value string) *http.Response {
+		return doAs(t, srv, "b", "POST", "/v1/set", `{"key":"k","value":"`+value+`"}`)
+	}
+	// 1 + 32 bytes, then 1 + 32 more: the second is over 60 per minute.
+	v := strings.Repeat("x", 30)
+	if resp := set(v); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("first set: %d", resp.StatusCode)
+	}
+	resp := set(v)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("second set: %d", resp.StatusCode)
+	}
+	if d, _ := time.ParseDuration(resp.Header.Get("Retry-After") + "s"); d <= 0 || d > time.Minute {
+		t.Errorf("Retry-After %q", resp.Header.Get("Retry-After"))
+	}
+	if resp := set(strings.Repeat("x", 100)); resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "" {
+		t.Errorf("set larger than the quota: %d, Retry-After %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	if resp := doAs(t, srv, "b", "POST", "/v1/import", `{"key":"a","value":"`+v+`"}`+"\n"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("import over quota: %d", resp.StatusCode)
+	}
+}
+
+func TestLoadACL_NegativeQuota(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acl.json")
+	os.WriteFile(path, []byte(`{"tokens":[{"name":"x","token":"t","requests_per_second":-1}]}`), 0o600)
+	if _, err := loadACL(path); err == nil {
+		t.Error("negative quota accepted")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// A config file (-config) holds the tunables PATCH /admin/config takes, in
+// the same JSON, any subset:
+//
+//	{"threshold": 0.8, "capacity": 5000, "ttl": "1h"}
+//
+// It is applied at startup, over the flags, and again on every reload
+// (SIGHUP or POST /admin/reload), which also rereads the -acl file. A
+// reload changes the live DB in place, so the cache stays warm.
+
+// reload rereads the config and ACL files. Both are read and checked
+// before either is applied, so a bad file changes nothing.
+func (s *server) reload() error {
+	var cfg *configPatch
+	if s.configPath != "" {
+		data, err := os.ReadFile(s.configPath)
+		if err != nil {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		cfg = new(configPatch)
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("config %s: %w", s.configPath, err)
+		}
+	}
+	var a *acl
+	if s.aclPath != "" {
+		var err error
+		if a, err = loadACL(s.aclPath); err != nil {
+			return err
+		}
+	}
+	if cfg != nil {
+		if err := s.applyConfig(*cfg); err != nil {
+			return fmt.Errorf("config %s: %w", s.configPath, err)
+		}
+	}
+	if a != nil && s.acl != nil {
+		s.acl.replace(a)
+	}
+	return nil
+}
+
+func (s *server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if err := s.reload(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.handleGetConfig(w, r)
+}
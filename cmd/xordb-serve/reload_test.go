@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	srv, s := newAdminServer(t)
+	dir := t.TempDir()
+	s.configPath = filepath.Join(dir, "config.json")
+	s.aclPath = filepath.Join(dir, "acl.json")
+	s.acl = &acl{Tokens: []*grant{{Name: "old", Token: "old", Read: []string{""}}}}
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("config.json", `{"threshold": 0.8, "ttl": "1h"}`)
+	write("acl.json", `{"tokens": [{"name": "new", "token": "new", "read": [""]}]}`)
+
+	s.db().Set("kept", 1)
+	resp, out := adminDo(t, srv, "POST", "/admin/reload", "")
+	if resp.StatusCode != http.StatusOK || out["threshold"] != 0.8 || out["ttl"] != "1h0m0s" || out["capacity"] != 16.0 {
+		t.Fatalf("reload: %d %v", resp.StatusCode, out)
+	}
+	if s.db().Len() != 1 {
+		t.Error("reload dropped the cache")
+	}
+	if s.cfg.threshold != 0.8 {
+		t.Errorf("DBs built later get threshold %v", s.cfg.threshold)
+	}
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer new")
+	if g := s.acl.grant(req); g == nil || g.Name != "new" {
+		t.Errorf("reloaded ACL grants %+v", g)
+	}
+
+	// A bad file applies nothing.
+	write("config.json", `{"threshold": 2}`)
+	write("acl.json", `{"tokens": [{"name": "newer", "token": "newer"}]}`)
+	if resp, _ := adminDo(t, srv, "POST", "/admin/reload", ""); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad config: status %d", resp.StatusCode)
+	}
+	if s.db().Threshold() != 0.8 || s.acl.grant(req) == nil {
+		t.Error("a failed reload changed the configuration")
+	}
+	write("config.json", `{"treshold": 0.7}`)
+	if err := s.reload(); err == nil {
+		t.Error("a misspelled field was accepted")
+	}
+}
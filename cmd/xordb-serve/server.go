@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/gossip"
+	"github.com/Amansingh-afk/xordb/replication"
+)
+
+const maxBodySize = 16 << 20 // 16 MB, matches the snapshot value limit
+
+var (
+	errReadOnly     = errors.New("read-only replica: send writes to the primary")
+	errShuttingDown = errors.New("shutting down")
+)
+
+// writer is the write path: the DB itself, or a replication.Primary that
+// also records writes for replicas.
+type writer interface {
+	Set(key string, value any)
+	SetWithTTL(key string, value any, ttl time.Duration)
+	Delete(key string) bool
+}
+
+// server — JSON-over-HTTP front for a single DB.
+type server struct {
+	db      func() *xordb.DB // replicas swap their DB on every bootstrap
+	w       writer           // nil on replicas
+	primary *replication.Primary
+	replica *replication.Replica
+	events  *hub // nil = no /v1/events
+
+	acl          *acl      // nil = /v1 open to all
+	audit        *auditLog // nil = no request log
+	adminToken   string    // empty = no /admin/*
+	cfg          *dbConfig // runtime tunables; nil = apply to the live DB only
+	snapshotPath string    // target of POST /admin/snapshot
+	configPath   string    // -config, reread by reload
+	aclPath      string    // -acl, reread by reload
+
+	draining atomic.Bool  // set by shutdown: writes are refused
+	ready    readiness    // /readyz checks
+	quotas   quotas       // per-token usage, with -acl
+	calib    *calibrator  // nil = no -calibrate-every
+	gossip   *gossip.Node // nil = no invalidation broadcast
+}
+
+func newServer(db *xordb.DB) *server {
+	return &server{db: func() *xordb.DB { return db }, w: db}
+}
+
+func newPrimaryServer(p *replication.Primary) *server {
+	db := p.DB()
+	return &server{db: func() *xordb.DB { return db }, w: p, primary: p}
+}
+
+func newReplicaServer(r *replication.Replica) *server {
+	return &server{db: r.DB, replica: r}
+}
+
+// Stats lets the server act as a metrics.Source across replica DB swaps.
+func (s *server) Stats() xordb.Stats { return s.db().Stats() }
+
+// TopKeys completes metrics.StatusSource.
+func (s *server) TopKeys(n int) []xordb.KeyHits { return s.db().TopKeys(n) }
+
+// routes registers the cache API on mux.
+func (s *server) routes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	s.handle(mux, "POST /v1/set", "set", s.handleSet)
+	s.handle(mux, "GET /v1/get", "get", s.handleGet)
+	s.handle(mux, "POST /v1/mget", "mget", s.handleMGet)
+	s.handle(mux, "POST /v1/delete", "delete", s.handleDelete)
+	s.handle(mux, "GET /v1/stats", "stats", s.handleStats)
+	s.handle(mux, "GET /v1/version", "version", s.handleVersion)
+	s.handle(mux, "GET /v1/explain", "explain", s.handleExplain)
+	s.handle(mux, "GET /v1/export", "export", s.handleExport)
+	s.handle(mux, "POST /v1/import", "import", s.handleImport)
+	s.handle(mux, "POST /v1/feedback", "feedback", s.handleFeedback)
+	if s.primary != nil {
+		// replicas copy every namespace
+		repl := http.NewServeMux()
+		s.primary.Routes(repl, "/v1/replication")
+		mux.Handle("/v1/replication/", s.audited("replication", s.authorize(repl, true)))
+	}
+	if s.events != nil {
+		s.handle(mux, "GET /v1/events", "events", s.handleEvents)
+	}
+	if s.adminToken != "" {
+		s.adminRoutes(mux)
+	}
+}
+
+// handle registers h for pattern behind the ACL, version negotiation and
+// the audit log, which names the request op.
+func (s *server) handle(mux *http.ServeMux, pattern, op string, h http.HandlerFunc) {
+	mux.Handle(pattern, s.audited(op, s.negotiate(s.authorize(h, false))))
+}
+
+type setRequest struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+	TTL   string `json:"ttl,omitempty"` // Go duration; empty = server default
+}
+
+type getResponse struct {
+	Hit        bool    `json:"hit"`
+	Value      any     `json:"value,omitempty"`
+	Similarity float64 `json:"similarity"`
+}
+
+type keyRequest struct {
+	Key string `json:"key"`
+}
+
+type statsResponse struct {
+	xordb.Stats
+	Replication *replication.Status `json:",omitempty"`
+	Gossip      *gossip.Stats       `json:",omitempty"`
+}
+
+// MarshalJSON writes the stats' fields with Replication and Gossip among
+// them; left to the embedded Stats's MarshalJSON, they would be dropped.
+func (r statsResponse) MarshalJSON() ([]byte, error) {
+	out, err := json.Marshal(r.Stats)
+	if err != nil {
+		return nil, err
+	}
+	for _, extra := range []struct {
+		name string
+		v    any
+		set  bool
+	}{{"Replication", r.Replication, r.Replication != nil}, {"Gossip", r.Gossip, r.Gossip != nil}} {
+		if !extra.set {
+			continue
+		}
+		b, err := json.Marshal(extra.v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(append(append(out[:len(out)-1], `,"`+extra.name+`":`...), b...), '}')
+	}
+	return out, nil
+}
+
+func (r *statsResponse) UnmarshalJSON(data []byte) error {
+	var ext struct {
+		Replication *replication.Status
+		Gossip      *gossip.Stats
+	}
+	if err := json.Unmarshal(data, &ext); err != nil {
+		return err
+	}
+	r.Replication, r.Gossip = ext.Replication, ext.Gossip
+	return json.Unmarshal(data, &r.Stats)
+}
+
+func (s *server) handleSet(w http.ResponseWriter, r *http.Request) {
+	if !s.writable(w) {
+		return
+	}
+	var req setRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	noteKey(r, req.Key)
+	if req.Key == "" {
+		writeError(w, http.StatusBadRequest, errors.New("key is required"))
+		return
+	}
+	if g := grantOf(r); !g.canWrite(req.Key) {
+		writeError(w, http.StatusForbidden, errNoWrite(g, req.Key))
+		return
+	}
+	if g := grantOf(r); g != nil && g.SetBytesPerMinute > 0 && !s.chargeSet(w, r, setSize(req.Key, req.Value)) {
+		return
+	}
+	if req.TTL == "" {
+		s.w.Set(req.Key, req.Value)
+	} else {
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil || ttl < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("ttl must be a non-negative duration"))
+			return
+		}
+		s.w.SetWithTTL(req.Key, req.Value, ttl)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) handleGet(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	noteKey(r, key)
+	if key == "" {
+		writeError(w, http.StatusBadRequest, errors.New("key is required"))
+		return
+	}
+	g := grantOf(r)
+	if !g.canRead(key) {
+		writeError(w, http.StatusForbidden, errNoRead(g, key))
+		return
+	}
+	resp := s.lookup(g, key)
+	noteLookup(r, resp.Hit, resp.Similarity)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// lookup looks key up for a token with grant g.
+func (s *server) lookup(g *grant, key string) getResponse {
+	if g.readsAll() {
+		v, ok, sim := s.db().Get(key)
+		return getResponse{Hit: ok, Value: v, Similarity: sim}
+	}
+	// entries the token cannot read are not candidates, so they can
+	// neither answer nor hide the best entry it may read
+	res := s.db().LookupWhere(key, g.canRead)
+	if !res.Hit {
+		return getResponse{}
+	}
+	return getResponse{Hit: true, Value: res.Value, Similarity: res.Similarity}
+}
+
+type mgetRequest struct {
+	Keys []string `json:"keys"`
+}
+
+// maxMGetKeys bounds one POST /v1/mget.
+const maxMGetKeys = 1000
+
+// handleMGet looks up several keys at once: {"keys": [...]} →
+// {"results": [...]}, one /v1/get response per key, in order.
+func (s *server) handleMGet(w http.ResponseWriter, r *http.Request) {
+	var req mgetRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.Keys) == 0 || len(req.Keys) > maxMGetKeys {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("keys must hold 1 to %d keys", maxMGetKeys))
+		return
+	}
+	g := grantOf(r)
+	for _, key := range req.Keys {
+		if key == "" {
+			writeError(w, http.StatusBadRequest, errors.New("keys must not be empty"))
+			return
+		}
+		if !g.canRead(key) {
+			writeError(w, http.StatusForbidden, errNoRead(g, key))
+			return
+		}
+	}
+	out := make([]getResponse, len(req.Keys))
+	for i, key := range req.Keys {
+		out[i] = s.lookup(g, key)
+	}
+	writeJSON(w, http.StatusOK, map[string][]getResponse{"results": out})
+}
+
+func (s *server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if !s.writable(w) {
+		return
+	}
+	var req keyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	noteKey(r, req.Key)
+	if g := grantOf(r); !g.canWrite(req.Key) {
+		writeError(w, http.StatusForbidden, errNoWrite(g, req.Key))
+		return
+	}
+	deleted := s.w.Delete(req.Key)
+	s.broadcastDelete(r.Context(), req.Key)
+	writeJSON(w, http.StatusOK, map[string]bool{"deleted": deleted})
+}
+
+func (s *server) handleStats(w http.ResponseWriter, _ *http.Request) {
+	resp := statsResponse{Stats: s.db().Stats()}
+	if s.replica != nil {
+		st := s.replica.Status()
+		resp.Replication = &st
+	}
+	if s.gossip != nil {
+		st := s.gossip.Stats()
+		resp.Gossip = &st
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *server) handleExplain(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	key := q.Get("key")
+	noteKey(r, key)
+	if key == "" {
+		writeError(w, http.StatusBadRequest, errors.New("key is required"))
+		return
+	}
+	n := 10
+	if v := q.Get("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("n must be an integer"))
+			return
+		}
+	}
+	g := grantOf(r)
+	if !g.canRead(key) {
+		writeError(w, http.StatusForbidden, errNoRead(g, key))
+		return
+	}
+	if g.readsAll() {
+		writeJSON(w, http.StatusOK, s.db().Explain(key, n))
+		return
+	}
+	out := []xordb.Candidate{}
+	for _, c := range s.db().Explain(key, 0) {
+		if n > 0 && len(out) == n {
+			break
+		}
+		if g.canRead(c.Key) {
+			out = append(out, c)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *server) handleExport(w http.ResponseWriter, r *http.Request) {
+	g := grantOf(r)
+	switch r.URL.Query().Get("format") {
+	case "", "jsonl":
+	case "csv":
+		s.exportCSV(w, g)
+		return
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("format must be jsonl or csv"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	// headers are already sent by the time an encode error can happen, so the
+	// client sees a truncated stream instead of an error body
+	if g.readsAll() {
+		s.db().ExportJSONL(w)
+		return
+	}
+	enc := json.NewEncoder(w)
+	for _, rec := range s.db().Entries(xordb.OrderAccess) {
+		if g.canRead(rec.Key) {
+			if err := enc.Encode(rec); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// exportCSV writes the EntryInfos g may read as CSV (xordb.ExportCSV).
+func (s *server) exportCSV(w http.ResponseWriter, g *grant) {
+	infos := s.db().EntryInfos(xordb.OrderAccess)
+	if !g.readsAll() {
+		kept := infos[:0]
+		for _, in := range infos {
+			if g.canRead(in.Key) {
+				kept = append(kept, in)
+			}
+		}
+		infos = kept
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	xordb.WriteCSV(w, infos)
+}
+
+func (s *server) handleImport(w http.ResponseWriter, r *http.Request) {
+	if !s.writable(w) {
+		return
+	}
+	body := io.Reader(r.Body)
+	if g := grantOf(r); g != nil {
+		// read it all first: nothing is imported unless every record passes
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			writeError(w, bodyStatus(err, http.StatusBadRequest), err)
+			return
+		}
+		if err := checkImport(g, data); err != nil {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+		if g.SetBytesPerMinute > 0 && !s.chargeSet(w, r, len(data)) {
+			return
+		}
+		body = bytes.NewReader(data)
+	}
+	n, err := s.db().WarmFromJSONL(body)
+	if s.primary != nil && n > 0 {
+		s.primary.Resync() // bulk load bypassed the log
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"imported": n, "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"imported": n})
+}
+
+// writable reports whether the server takes writes, answering the request
+// if not.
+func (s *server) writable(w http.ResponseWriter) bool {
+	switch {
+	case s.w == nil:
+		writeError(w, http.StatusForbidden, errReadOnly)
+	case s.draining.Load():
+		writeError(w, http.StatusServiceUnavailable, errShuttingDown)
+	default:
+		return true
+	}
+	return false
+}
+
+func decodeJSON(r *http.Request, dst any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodySize))
+	if err := dec.Decode(dst); err != nil {
+		return errors.New("invalid JSON body: " + err.Error())
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// bodyStatus is the status for err from reading a request body: 413 for a
+// body over maxBodySize, otherwise status.
+func bodyStatus(err error, status int) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return status
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/replication"
+)
+
+// ── helpers ───────────────────────────────────────────────────────────────────
+
+// newTestServer serves a server configured by each of setup in turn, over
+// a DB with threshold 0.70 unless a setup supplied one (see withDB). That
+// default is only built after the setups, so they cannot use it.
+func newTestServer(t *testing.T, setup ...func(*server)) (*httptest.Server, *server) {
+	t.Helper()
+	s := &server{}
+	for _, fn := range setup {
+		fn(s)
+	}
+	if s.db == nil {
+		withDB(xordb.New(xordb.WithThreshold(0.70)))(s)
+	}
+	mux := http.NewServeMux()
+	s.routes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, s
+}
+
+// withDB is a newTestServer setup serving db instead.
+func withDB(db *xordb.DB) func(*server) {
+	return func(s *server) { s.db, s.w = func() *xordb.DB { return db }, db }
+}
+
+func postJSON(t *testing.T, srv *httptest.Server, path, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(srv.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("post %s: %v", path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func getKey(t *testing.T, srv *httptest.Server, key string) getResponse {
+	t.Helper()
+	resp, err := http.Get(srv.URL + "/v1/get?key=" + url.QueryEscape(key))
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	var out getResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return out
+}
+
+// ── API ───────────────────────────────────────────────────────────────────────
+
+func TestServer_SetGet(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	resp := postJSON(t, srv, "/v1/set", `{"key":"what is the capital of india","value":"Delhi"}`)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("set: want 204, got %d", resp.StatusCode)
+	}
+
+	got := getKey(t, srv, "capital city of india")
+	if !got.Hit || got.Value != "Delhi" {
+		t.Fatalf("want semantic hit Delhi, got %+v", got)
+	}
+	if got.Similarity < 0.70 {
+		t.Fatalf("hit similarity %.4f below threshold", got.Similarity)
+	}
+
+	if miss := getKey(t, srv, "how do you bake a chocolate cake"); miss.Hit {
+		t.Fatalf("unrelated query must miss, got %+v", miss)
+	}
+}
+
+func TestServer_SetValidation(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	for _, body := range []string{`{"value":1}`, `{"key":"k","ttl":"soon"}`, `{"key":"k","ttl":"-1s"}`, `not json`} {
+		if resp := postJSON(t, srv, "/v1/set", body); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("body %s: want 400, got %d", body, resp.StatusCode)
+		}
+	}
+}
+
+func TestServer_Delete(t *testing.T) {
+	srv, s := newTestServer(t)
+	db := s.db()
+	db.Set("k", "v")
+
+	resp := postJSON(t, srv, "/v1/delete", `{"key":"k"}`)
+	var out map[string]bool
+	json.NewDecoder(resp.Body).Decode(&out)
+	if !out["deleted"] {
+		t.Fatal("delete of existing key must report deleted=true")
+	}
+	if db.Len() != 0 {
+		t.Fatalf("len must be 0 after delete, got %d", db.Len())
+	}
+}
+
+func TestServer_MGet(t *testing.T) {
+	srv, s := newTestServer(t)
+	db := s.db()
+	db.Set("what is the capital of india", "Delhi")
+
+	resp := postJSON(t, srv, "/v1/mget", `{"keys":["capital city of india","how tall is mount everest"]}`)
+	var out struct{ Results []getResponse }
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Results) != 2 || !out.Results[0].Hit || out.Results[0].Value != "Delhi" || out.Results[1].Hit {
+		t.Errorf("mget = %+v", out.Results)
+	}
+	for _, body := range []string{`{"keys":[]}`, `{"keys":["ok",""]}`} {
+		if resp := postJSON(t, srv, "/v1/mget", body); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, resp.StatusCode)
+		}
+	}
+}
+
+func TestServer_Stats(t *testing.T) {
+	srv, ts := newTestServer(t)
+	db := ts.db()
+	db.Set("k", "v")
+	db.Get("k")
+
+	resp, err := http.Get(srv.URL + "/v1/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var s xordb.Stats
+	json.NewDecoder(resp.Body).Decode(&s)
+	if s.Entries != 1 || s.Hits != 1 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestServer_MethodNotAllowed(t *testing.T) {
+	srv, _ := newTestServer(t)
+	resp, err := http.Get(srv.URL + "/v1/set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("want 405, got %d", resp.StatusCode)
+	}
+}
+
+func TestServer_Explain(t *testing.T) {
+	srv, s := newTestServer(t)
+	db := s.db()
+	db.Set("what is the capital of india", "Delhi")
+	db.Set("how do you bake a chocolate cake", "oven")
+
+	resp, err := http.Get(srv.URL + "/v1/explain?n=1&key=" + url.QueryEscape("capital city of india"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var got []xordb.Candidate
+	json.NewDecoder(resp.Body).Decode(&got)
+	if len(got) != 1 || got[0].Key != "what is the capital of india" {
+		t.Fatalf("unexpected explain result %+v", got)
+	}
+}
+
+func TestServer_ExportImport(t *testing.T) {
+	srv, s := newTestServer(t)
+	db := s.db()
+	db.Set("alpha", "A")
+	db.Set("beta", "B")
+
+	resp, err := http.Get(srv.URL + "/v1/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	srv2, s2 := newTestServer(t)
+	db2 := s2.db()
+	imp := postJSON(t, srv2, "/v1/import", string(dump))
+	var out map[string]int
+	json.NewDecoder(imp.Body).Decode(&out)
+	if out["imported"] != 2 || db2.Len() != 2 {
+		t.Fatalf("want 2 imported, got %v len=%d", out, db2.Len())
+	}
+}
+
+func TestServer_Replica_ReadOnlyAndLag(t *testing.T) {
+	p := replication.NewPrimary(xordb.New(xordb.WithThreshold(0.70)), 100)
+	pmux := http.NewServeMux()
+	newPrimaryServer(p).routes(pmux)
+	psrv := httptest.NewServer(pmux)
+	t.Cleanup(psrv.Close)
+
+	r := replication.NewReplica(psrv.URL+"/v1/replication", func() *xordb.DB {
+		return xordb.New(xordb.WithThreshold(0.70))
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go r.Run(ctx)
+
+	rmux := http.NewServeMux()
+	newReplicaServer(r).routes(rmux)
+	rsrv := httptest.NewServer(rmux)
+	t.Cleanup(rsrv.Close)
+
+	postJSON(t, psrv, "/v1/set", `{"key":"what is the capital of india","value":"Delhi"}`)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !getKey(t, rsrv, "capital city of india").Hit {
+		if time.Now().After(deadline) {
+			t.Fatal("write on primary never reached replica")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if resp := postJSON(t, rsrv, "/v1/set", `{"key":"k","value":1}`); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("replica set: want 403, got %d", resp.StatusCode)
+	}
+
+	resp, err := http.Get(rsrv.URL + "/v1/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var st statsResponse
+	json.NewDecoder(resp.Body).Decode(&st)
+	if st.Replication == nil || st.Replication.AppliedSeq != 1 || st.Entries != 1 {
+		t.Fatalf("replica stats must include replication status, got %+v", st)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// shutdown prepares the server to exit: it refuses further writes, closes
+// the DB, which waits for its background work, and saves a final snapshot
+// to -snapshot on a primary or standalone server. Replicas save nothing;
+// they bootstrap from their primary. The snapshot is written to a
+// temporary file and renamed into place, so giving up at ctx's deadline
+// leaves the previous snapshot intact. There is no write-ahead log to
+// flush: the replication log is in memory and goes with the process.
+func (s *server) shutdown(ctx context.Context) error {
+	s.draining.Store(true)
+	db := s.db()
+	err := db.Close(ctx)
+	if s.snapshotPath == "" || s.replica != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() { done <- db.Save(s.snapshotPath) }()
+	select {
+	case saveErr := <-done:
+		if saveErr == nil {
+			log.Printf("xordb-serve: saved %d entries to %s in %s", db.Len(), s.snapshotPath, time.Since(start).Round(time.Millisecond))
+		}
+		return errors.Join(err, saveErr)
+	case <-ctx.Done():
+		return errors.Join(err, fmt.Errorf("final snapshot: %w", ctx.Err()))
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestServer_Shutdown(t *testing.T) {
+	srv, s := newAdminServer(t)
+	postJSON(t, srv, "/v1/set", `{"key":"what is the capital of india","value":"Delhi"}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if resp := postJSON(t, srv, "/v1/set", `{"key":"late","value":1}`); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("write after shutdown: status %d, want 503", resp.StatusCode)
+	}
+	if got := getKey(t, srv, "what is the capital of india"); got.Hit {
+		t.Error("closed DB still serving")
+	}
+
+	loaded := xordb.New()
+	if err := loaded.Load(s.snapshotPath); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, _ := loaded.Get("what is the capital of india"); !ok || v != "Delhi" {
+		t.Errorf("final snapshot holds %v, %v", v, ok)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+var shutdownSignals = []os.Signal{os.Interrupt}
+
+// reloadOnSIGHUP does nothing where there is no SIGHUP; use
+// POST /admin/reload.
+func reloadOnSIGHUP(*server) {}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// shutdownSignals start a graceful shutdown.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// reloadOnSIGHUP rereads the config and ACL files on every SIGHUP.
+func reloadOnSIGHUP(srv *server) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		if err := srv.reload(); err != nil {
+			log.Printf("xordb-serve: reload: %v", err)
+			continue
+		}
+		log.Printf("xordb-serve: reloaded configuration")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Every /v1 response carries the protocol version the server speaks and
+// its encoder's fingerprint (DB.EncoderFingerprint, hex):
+//
+//	Xordb-Protocol: 1
+//	Xordb-Encoder: 9f86d081884c7d65
+//
+// A client may send the same headers; the server answers 412 if it does
+// not speak the client's protocol version, or if the client's encoder —
+// that of a local L1, say — differs from its own, since the two would then
+// score similarity differently and a threshold tuned on one would be wrong
+// on the other. Clients that send neither are served as before. GET
+// /v1/version reports both, and the dims, for clients to check up front.
+
+const (
+	protocolVersion    = 1 // spoken by this server
+	minProtocolVersion = 1 // oldest still accepted
+
+	headerProtocol = "Xordb-Protocol"
+	headerEncoder  = "Xordb-Encoder"
+)
+
+type versionResponse struct {
+	Protocol    int    `json:"protocol"`
+	MinProtocol int    `json:"min_protocol"`
+	Encoder     string `json:"encoder"`
+	Dims        int    `json:"dims"`
+}
+
+func (s *server) encoderFingerprint() string {
+	return fmt.Sprintf("%016x", s.db().EncoderFingerprint())
+}
+
+// negotiate stamps responses with the server's version headers and
+// rejects requests whose own headers are incompatible.
+func (s *server) negotiate(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fp := s.encoderFingerprint()
+		w.Header().Set(headerProtocol, strconv.Itoa(protocolVersion))
+		w.Header().Set(headerEncoder, fp)
+		if v := r.Header.Get(headerProtocol); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < minProtocolVersion || n > protocolVersion {
+				writeError(w, http.StatusPreconditionFailed, fmt.Errorf("unsupported protocol version %q: server speaks %d to %d", v, minProtocolVersion, protocolVersion))
+				return
+			}
+		}
+		if v := r.Header.Get(headerEncoder); v != "" && v != fp {
+			writeError(w, http.StatusPreconditionFailed, fmt.Errorf("client encoder %s does not match server encoder %s: similarity would differ", v, fp))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) handleVersion(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, versionResponse{
+		Protocol:    protocolVersion,
+		MinProtocol: minProtocolVersion,
+		Encoder:     s.encoderFingerprint(),
+		Dims:        s.db().Dims(),
+	})
+}
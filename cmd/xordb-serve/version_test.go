@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestVersion(t *testing.T) {
+	srv, s := newTestServer(t)
+	db := s.db()
+	fp := fmt.Sprintf("%016x", db.EncoderFingerprint())
+
+	resp, err := http.Get(srv.URL + "/v1/version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var v versionResponse
+	json.NewDecoder(resp.Body).Decode(&v)
+	if v.Protocol != protocolVersion || v.Encoder != fp || v.Dims != db.Dims() {
+		t.Errorf("version %+v", v)
+	}
+	if resp.Header.Get(headerEncoder) != fp || resp.Header.Get(headerProtocol) != "1" {
+		t.Errorf("headers %v", resp.Header)
+	}
+
+	other := fmt.Sprintf("%016x", xordb.New(xordb.WithDims(2048)).EncoderFingerprint())
+	for _, c := range []struct {
+		protocol, encoder string
+		want              int
+	}{
+		{"", "", http.StatusOK},
+		{"1", fp, http.StatusOK},
+		{"2", "", http.StatusPreconditionFailed},
+		{"v1", "", http.StatusPreconditionFailed},
+		{"1", other, http.StatusPreconditionFailed},
+	} {
+		req, _ := http.NewRequest("GET", srv.URL+"/v1/get?key=q", nil)
+		if c.protocol != "" {
+			req.Header.Set(headerProtocol, c.protocol)
+		}
+		if c.encoder != "" {
+			req.Header.Set(headerEncoder, c.encoder)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != c.want {
+			t.Errorf("protocol %q encoder %q: %d, want %d", c.protocol, c.encoder, resp.StatusCode, c.want)
+		}
+	}
+}
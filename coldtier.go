@@ -0,0 +1,161 @@
+package xordb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// WithColdTier keeps only the hot most recently used entries' values in
+// memory. Older entries keep their vectors, so every entry is still
+// matched as before, but their values move to a file in dir ("" = the
+// system temp dir) and are read back when a lookup hits them, shrinking
+// the heap of a large cache to its vectors and a working set of values.
+// Values are stored as JSON, as in a snapshot, so one read back from disk
+// comes out as Load would return it: structs as map[string]any, numbers as
+// float64. Stats.Cold counts cold entries and Stats.Faults the hits that
+// read one back. Not compatible with WithSampledEviction.
+func WithColdTier(hot int, dir string) Option {
+	return func(o *dbOptions) { o.hotEntries, o.coldDir = hot, dir }
+}
+
+// coldFile is the WithColdTier cache.ColdStore: values appended to a file
+// that is unlinked as soon as it is created, like a Blob's. Space freed by
+// deletes is reclaimed by rewriting the live values to a new file once it
+// outweighs them.
+type coldFile struct {
+	dir string
+	c   *valueCipher // seals values if not nil
+
+	mu   sync.Mutex
+	f    *os.File // nil until the first Put
+	size int64    // bytes written to f
+	dead int64    // bytes of f no longer referenced
+	live map[*coldRef]struct{}
+}
+
+// coldRef locates a value in the file; compaction moves it.
+type coldRef struct{ off, n int64 }
+
+// minColdCompact is the least garbage worth compacting for.
+const minColdCompact = 1 << 20
+
+func newColdFile(dir string, c *valueCipher) *coldFile {
+	return &coldFile{dir: dir, c: c, live: make(map[*coldRef]struct{})}
+}
+
+func (s *coldFile) Put(v any) (any, error) {
+	if b, ok := v.(*Blob); ok {
+		return b, nil // already on disk
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("xordb: cold tier: %w", err)
+	}
+	if s.c != nil {
+		data = s.c.seal(data, nil)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		if s.f, err = createUnlinked(s.dir, "xordb-cold-*"); err != nil {
+			return nil, fmt.Errorf("xordb: cold tier: %w", err)
+		}
+	}
+	if s.dead >= minColdCompact && s.dead > s.size-s.dead {
+		if err := s.compactLocked(); err != nil {
+			return nil, fmt.Errorf("xordb: cold tier: %w", err)
+		}
+	}
+	if _, err := s.f.WriteAt(data, s.size); err != nil {
+		return nil, fmt.Errorf("xordb: cold tier: %w", err)
+	}
+	r := &coldRef{off: s.size, n: int64(len(data))}
+	s.size += r.n
+	s.live[r] = struct{}{}
+	return r, nil
+}
+
+func (s *coldFile) Get(ref any) (any, error) {
+	r, ok := ref.(*coldRef)
+	if !ok {
+		return ref, nil // a Blob
+	}
+	buf := make([]byte, r.n)
+	s.mu.Lock()
+	_, err := s.f.ReadAt(buf, r.off)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("xordb: cold tier: %w", err)
+	}
+	if s.c != nil {
+		if buf, err = s.c.open(buf, nil); err != nil {
+			return nil, fmt.Errorf("xordb: cold tier: %w", err)
+		}
+	}
+	var v any
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return nil, fmt.Errorf("xordb: cold tier: %w", err)
+	}
+	return v, nil
+}
+
+func (s *coldFile) Delete(ref any) {
+	r, ok := ref.(*coldRef)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	delete(s.live, r)
+	s.dead += r.n
+	s.mu.Unlock()
+}
+
+// compactLocked copies the live values to a new file and points their
+// references at it.
+func (s *coldFile) compactLocked() error {
+	f, err := createUnlinked(s.dir, "xordb-cold-*")
+	if err != nil {
+		return err
+	}
+	var size int64
+	for r := range s.live {
+		buf := make([]byte, r.n)
+		if _, err := s.f.ReadAt(buf, r.off); err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.WriteAt(buf, size); err != nil {
+			f.Close()
+			return err
+		}
+		r.off = size
+		size += r.n
+	}
+	s.f.Close()
+	s.f, s.size, s.dead = f, size, 0
+	return nil
+}
+
+// createUnlinked creates a temp file in dir and removes its name, so the
+// data lives only as long as the open file.
+func createUnlinked(dir, pattern string) (*os.File, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(f.Name())
+	return f, nil
+}
+
+// coldStore returns the cache's Options.ColdStore for WithColdTier, or nil.
+func (o *dbOptions) coldStore() cache.ColdStore {
+	if o.hotEntries <= 0 {
+		return nil
+	}
+	return newColdFile(o.coldDir, o.cipher)
+}
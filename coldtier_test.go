@@ -0,0 +1,82 @@
+package xordb_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+type answer struct {
+	Text   string
+	Tokens int
+}
+
+func TestDB_WithColdTier(t *testing.T) {
+	dir := t.TempDir()
+	db := xordb.New(xordb.WithColdTier(2, dir))
+	questions := []string{
+		"what is the capital of france",
+		"how tall is mount everest",
+		"who wrote war and peace",
+		"when did the berlin wall fall",
+	}
+	for i, q := range questions {
+		db.Set(q, answer{Text: fmt.Sprintf("answer %d", i), Tokens: i})
+	}
+	if st := db.Stats(); st.Cold != 2 {
+		t.Fatalf("%d cold entries, want 2", st.Cold)
+	}
+	if runtime.GOOS != "windows" {
+		if files, _ := os.ReadDir(dir); len(files) != 0 {
+			t.Errorf("%d files left in the cold dir, want them unlinked", len(files))
+		}
+	}
+
+	// Hot values come back as stored, cold ones as JSON decodes them.
+	if v, _, _ := db.Get("when did the berlin wall fall"); v != (answer{"answer 3", 3}) {
+		t.Errorf("hot value = %#v", v)
+	}
+	v, ok, _ := db.Get("what is the capital of france")
+	if m, isMap := v.(map[string]any); !ok || !isMap || m["Text"] != "answer 0" || m["Tokens"] != 0.0 {
+		t.Errorf("cold value = %#v, %v", v, ok)
+	}
+	if st := db.Stats(); st.Faults != 1 || st.Cold != 2 {
+		t.Errorf("%d faults, %d cold, want 1 and 2", st.Faults, st.Cold)
+	}
+
+	// Snapshots read cold values from disk.
+	path := filepath.Join(t.TempDir(), "cold.xrdb")
+	if err := db.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := xordb.New()
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, _ := loaded.Get("who wrote war and peace"); !ok || v.(map[string]any)["Text"] != "answer 2" {
+		t.Errorf("loaded cold value = %#v, %v", v, ok)
+	}
+}
+
+func TestDB_WithColdTier_Compaction(t *testing.T) {
+	db := xordb.New(xordb.WithColdTier(1, t.TempDir()))
+	big := strings.Repeat("x", 64<<10)
+	// Rewriting cold entries leaves their old values as garbage, which
+	// must be reclaimed without losing the live ones.
+	for round := 0; round < 40; round++ {
+		for i := 0; i < 3; i++ {
+			db.Set(fmt.Sprintf("document number %d", i), fmt.Sprintf("%d:%d:%s", round, i, big))
+		}
+	}
+	for i := 0; i < 3; i++ {
+		v, ok, _ := db.Get(fmt.Sprintf("document number %d", i))
+		if s, _ := v.(string); !ok || !strings.HasPrefix(s, fmt.Sprintf("39:%d:", i)) || len(s) != len(big)+5 {
+			t.Errorf("document %d: %.10q, %v", i, v, ok)
+		}
+	}
+}
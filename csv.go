@@ -0,0 +1,108 @@
+package xordb
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// EntryInfo describes one entry for analysis in standard data tooling:
+// what it holds, how it has been used and how typical its key is. Values
+// themselves are summarized by a hash, so an export can leave the building
+// without the cached content.
+type EntryInfo struct {
+	Key         string
+	ValueSHA256 string // of the value's JSON encoding, hex; "" if it has none
+	ValueBytes  int    // length of that encoding
+	SetAt       time.Time
+	ExpiresAt   *time.Time // nil = never
+	Source      string     // SetWithSource tag
+	Hits        uint64     // lookups answered since the last set
+
+	// CentroidSimilarity is the similarity of the entry's vector to the
+	// bitwise majority of every entry's vector: low for outliers, high
+	// for keys like most others.
+	CentroidSimilarity float64
+}
+
+// EntryInfos returns an EntryInfo for every live entry, in order. Like
+// Entries it copies the entries under one lock and does not affect LRU
+// order or stats. Hits and sources are not saved in snapshots, so they
+// restart from zero on Load.
+func (db *DB) EntryInfos(order Order) []EntryInfo {
+	snap := db.c.Snapshot()
+	var centroid hdc.Vector
+	if len(snap.Entries) > 0 {
+		vecs := make([]hdc.Vector, len(snap.Entries))
+		for i, e := range snap.Entries {
+			vecs[i] = hdc.FromWords(snap.Dims, e.VecData)
+		}
+		centroid = hdc.Bundle(vecs...)
+	}
+	sortEntries(snap.Entries, order)
+	out := make([]EntryInfo, len(snap.Entries))
+	for i, e := range snap.Entries {
+		rec := record(e)
+		info := EntryInfo{
+			Key:                e.Key,
+			SetAt:              e.Ts,
+			ExpiresAt:          rec.ExpiresAt,
+			Source:             e.Source,
+			Hits:               e.Hits,
+			CentroidSimilarity: hdc.Similarity(hdc.FromWords(snap.Dims, e.VecData), centroid),
+		}
+		if b, err := json.Marshal(e.Value); err == nil {
+			sum := sha256.Sum256(b)
+			info.ValueSHA256, info.ValueBytes = hex.EncodeToString(sum[:]), len(b)
+		}
+		out[i] = info
+	}
+	return out
+}
+
+// CSVHeader is the header row of ExportCSV.
+var CSVHeader = []string{"key", "value_sha256", "value_bytes", "set_at", "expires_at", "source", "hits", "centroid_similarity"}
+
+// ExportCSV writes EntryInfos(OrderAccess) as CSV with a CSVHeader row,
+// for loading into spreadsheets, DuckDB, pandas and the like; times are
+// RFC 3339, and expires_at is empty for entries that never expire. There
+// is no Parquet writer, to keep xordb free of dependencies: tools that read
+// CSV convert it in one step, e.g. DuckDB's
+// COPY (FROM 'cache.csv') TO 'cache.parquet'.
+func (db *DB) ExportCSV(w io.Writer) error {
+	return WriteCSV(w, db.EntryInfos(OrderAccess))
+}
+
+// WriteCSV writes infos as ExportCSV does, e.g. after filtering them.
+func WriteCSV(w io.Writer, infos []EntryInfo) error {
+	cw := csv.NewWriter(w)
+	cw.Write(CSVHeader)
+	for _, in := range infos {
+		expires := ""
+		if in.ExpiresAt != nil {
+			expires = in.ExpiresAt.UTC().Format(time.RFC3339Nano)
+		}
+		cw.Write([]string{
+			in.Key,
+			in.ValueSHA256,
+			strconv.Itoa(in.ValueBytes),
+			in.SetAt.UTC().Format(time.RFC3339Nano),
+			expires,
+			in.Source,
+			strconv.FormatUint(in.Hits, 10),
+			strconv.FormatFloat(in.CentroidSimilarity, 'f', 4, 64),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("xordb: export csv: %w", err)
+	}
+	return nil
+}
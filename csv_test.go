@@ -0,0 +1,73 @@
+package xordb_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestExportCSV(t *testing.T) {
+	db := xordb.New()
+	db.Set("what is the capital of india", "Delhi")
+	db.SetWithSource("what is the capital of france", "Paris", "gpt-x")
+	db.SetWithTTL("what is the capital of spain", "Madrid", time.Hour)
+	db.Set("zq9 vx7 kk2 ppp", 42)
+	db.Get("what is the capital of india")
+	db.Get("what's the capital of india")
+
+	var buf bytes.Buffer
+	if err := db.ExportCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(rows[0], xordb.CSVHeader) || len(rows) != 5 {
+		t.Fatalf("header %v, %d rows", rows[0], len(rows))
+	}
+	byKey := make(map[string][]string)
+	for _, r := range rows[1:] {
+		byKey[r[0]] = r
+	}
+
+	india := byKey["what is the capital of india"]
+	sum := sha256.Sum256([]byte(`"Delhi"`))
+	if india[1] != hex.EncodeToString(sum[:]) || india[2] != "7" || india[6] != "2" || india[4] != "" {
+		t.Errorf("india row %v", india)
+	}
+	if r := byKey["what is the capital of france"]; r[5] != "gpt-x" || r[6] != "0" {
+		t.Errorf("france row %v", r)
+	}
+	if r := byKey["what is the capital of spain"]; r[4] == "" {
+		t.Errorf("spain row has no expiry: %v", r)
+	}
+
+	infos := db.EntryInfos(xordb.OrderKey)
+	var outlier, typical float64
+	for _, in := range infos {
+		switch in.Key {
+		case "zq9 vx7 kk2 ppp":
+			outlier = in.CentroidSimilarity
+		case "what is the capital of spain":
+			typical = in.CentroidSimilarity
+		}
+	}
+	if outlier >= typical {
+		t.Errorf("outlier centroid similarity %.3f >= typical %.3f", outlier, typical)
+	}
+
+	// a set restarts the hit count
+	db.Set("what is the capital of india", "New Delhi")
+	for _, in := range db.EntryInfos(xordb.OrderKey) {
+		if in.Key == "what is the capital of india" && in.Hits != 0 {
+			t.Errorf("hits after set: %d", in.Hits)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package xordb
+
+import (
+	"fmt"
+	"io"
+)
+
+// LastSnapshotID returns the ID of the snapshot most recently written by
+// Save, SaveSharded, WriteSnapshot or SaveDelta, for use as a SaveDelta
+// base; 0 if none has been.
+func (db *DB) LastSnapshotID() uint64 { return db.snapID.Load() }
+
+// SaveDelta writes a snapshot holding only what changed since the snapshot
+// with ID since (see LastSnapshotID) and returns the delta's own ID, the
+// base for the next one. Added and updated entries are always included;
+// deletes only with WithTombstones, whose window must cover the interval.
+// The delta is an ordinary binary snapshot: restore with LoadChain, or Load
+// the base and ReadSnapshot each delta in order. Entries merged into the DB
+// by a load count as changes, so after a restore save a full snapshot as
+// the next base rather than a delta.
+func (db *DB) SaveDelta(w io.Writer, since uint64) (uint64, error) {
+	snap := db.c.DeltaSnapshot(since)
+	if err := db.encodeSnapshot(w, snap); err != nil {
+		return 0, fmt.Errorf("xordb: save delta: %w", err)
+	}
+	db.snapID.Store(snap.ID)
+	return snap.ID, nil
+}
+
+// LoadChain loads the snapshot at base and then each delta in order, as
+// written by Save and SaveDelta. It stops at the first error; earlier files
+// stay loaded.
+func (db *DB) LoadChain(base string, deltas ...string) error {
+	for _, path := range append([]string{base}, deltas...) {
+		if err := db.Load(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package xordb_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestDB_SaveDelta_LoadChain(t *testing.T) {
+	dir := t.TempDir()
+	base, d1, d2 := filepath.Join(dir, "base.xrdb"), filepath.Join(dir, "d1.xrdb"), filepath.Join(dir, "d2.xrdb")
+	saveDelta := func(db *xordb.DB, path string, since uint64) uint64 {
+		t.Helper()
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		id, err := db.SaveDelta(f, since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	src := xordb.New(xordb.WithThreshold(0.99), xordb.WithTombstones(time.Hour))
+	src.Set("what is the capital of india", "Delhi")
+	src.Set("how do I reset my password", "Settings")
+	if err := src.Save(base); err != nil {
+		t.Fatal(err)
+	}
+	id := src.LastSnapshotID()
+	if id == 0 {
+		t.Fatal("Save must record a snapshot ID")
+	}
+
+	src.Set("what is the capital of india", "New Delhi")
+	src.Delete("how do I reset my password")
+	id = saveDelta(src, d1, id)
+	src.Set("recipe for chocolate cake", "Flour")
+	if next := saveDelta(src, d2, id); next <= id || src.LastSnapshotID() != next {
+		t.Fatalf("delta IDs must advance: %d after %d", next, id)
+	}
+
+	dst := xordb.New(xordb.WithThreshold(0.99), xordb.WithTombstones(time.Hour))
+	if err := dst.LoadChain(base, d1, d2); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := exportString(t, dst), exportString(t, src); got != want {
+		t.Fatalf("chain restore differs:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestDB_LoadChain_MissingDelta(t *testing.T) {
+	dir := t.TempDir()
+	db := xordb.New()
+	db.Set("alpha", "A")
+	base := filepath.Join(dir, "base.xrdb")
+	if err := db.Save(base); err != nil {
+		t.Fatal(err)
+	}
+	dst := xordb.New()
+	if err := dst.LoadChain(base, filepath.Join(dir, "missing.xrdb")); err == nil {
+		t.Fatal("missing delta must fail")
+	}
+	if dst.Len() != 1 {
+		t.Fatalf("the base stays loaded, got %d entries", dst.Len())
+	}
+}
@@ -0,0 +1,78 @@
+package xordb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// Dimensioner is implemented by encoders that know the dims of their
+// vectors, e.g. embed.MiniLMEncoder. NewWithEncoder checks it against what
+// the encoder actually returns.
+type Dimensioner interface {
+	Dims() int
+}
+
+// dimsProbe is encoded alongside "" to catch encoders whose dims vary by
+// input.
+const dimsProbe = "xordb dims probe"
+
+// encoderDims finds enc's dims and checks them against the options: the
+// declared and actual dims must agree, vectors must not be empty, the
+// WithEncodeBudget fallback and WithLanguageRouting encoders must match,
+// and so must WithDims if it was given. Mismatches wrap ErrDimsMismatch.
+func (o *dbOptions) encoderDims(enc hdc.Encoder) (int, error) {
+	dims := enc.Encode("").Dims()
+	if d := enc.Encode(dimsProbe).Dims(); d != dims {
+		return 0, fmt.Errorf("xordb: %w: encoder returned %d dims for an empty key and %d for text", ErrDimsMismatch, dims, d)
+	}
+	if dims <= 0 {
+		return 0, fmt.Errorf("xordb: %w: encoder returned empty vectors", ErrDimsMismatch)
+	}
+	if d, ok := enc.(Dimensioner); ok && d.Dims() != dims {
+		return 0, fmt.Errorf("xordb: %w: encoder declares %d dims but returned %d", ErrDimsMismatch, d.Dims(), dims)
+	}
+	if o.encodeFallback != nil {
+		if d := o.encodeFallback.Encode(dimsProbe).Dims(); d != dims {
+			return 0, fmt.Errorf("xordb: %w: encode budget fallback returned %d dims, encoder %d", ErrDimsMismatch, d, dims)
+		}
+	}
+	for code, e := range o.langEncoders {
+		if d := e.Encode(dimsProbe).Dims(); d != dims {
+			return 0, fmt.Errorf("xordb: %w: WithLanguageRouting encoder %q returned %d dims, encoder %d", ErrDimsMismatch, code, d, dims)
+		}
+	}
+	if o.dimsSet && o.dims != dims {
+		return 0, fmt.Errorf("xordb: %w: WithDims(%d) but encoder returned %d dims", ErrDimsMismatch, o.dims, dims)
+	}
+	return dims, nil
+}
+
+// TryNewWithEncoder is NewWithEncoder that returns an error, rather than
+// panicking, when enc's dims do not fit the options (see NewWithEncoder),
+// wrapping ErrDimsMismatch, or an option is invalid, wrapping
+// ErrInvalidOption. A panic from enc itself is not caught.
+func TryNewWithEncoder(enc hdc.Encoder, opts ...Option) (*DB, error) {
+	if enc == nil {
+		return nil, errors.New("xordb: encoder must not be nil")
+	}
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.encoderLoader != nil {
+		return nil, invalidOption("WithEncoderLoader is for New and NewAsync, not NewWithEncoder")
+	}
+	if _, err := o.encoderDims(enc); err != nil {
+		return nil, err
+	}
+	db, err := tryNewDB(o.seededEncoders(enc), o)
+	if err != nil {
+		return nil, err
+	}
+	if o.warmup != nil {
+		db.startWarmup(o, nil, false)
+	}
+	return db, nil
+}
@@ -0,0 +1,91 @@
+package xordb_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+)
+
+// fixedEncoder returns vectors of dims, or of other for non-empty keys.
+type fixedEncoder struct{ dims, other, declared int }
+
+func (e fixedEncoder) Encode(key string) hdc.Vector {
+	d := e.dims
+	if key != "" && e.other != 0 {
+		d = e.other
+	}
+	if d == 0 {
+		return hdc.Vector{}
+	}
+	return hdc.New(d)
+}
+
+type declaredEncoder struct{ fixedEncoder }
+
+func (e declaredEncoder) Dims() int { return e.declared }
+
+func TestTryNewWithEncoder(t *testing.T) {
+	ngram := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	tests := []struct {
+		name string
+		enc  hdc.Encoder
+		opts []xordb.Option
+		want string // error substring, "" = ok
+		is   error
+	}{
+		{"ok", ngram, nil, "", nil},
+		{"matching WithDims", ngram, []xordb.Option{xordb.WithDims(10000)}, "", nil},
+		{"WithDims mismatch", ngram, []xordb.Option{xordb.WithDims(2048)}, "WithDims(2048) but encoder returned 10000 dims", xordb.ErrDimsMismatch},
+		{"empty vectors", fixedEncoder{}, nil, "empty vectors", xordb.ErrDimsMismatch},
+		{"varying dims", fixedEncoder{dims: 0, other: 512}, nil, "0 dims for an empty key and 512 for text", xordb.ErrDimsMismatch},
+		{"declared mismatch", declaredEncoder{fixedEncoder{dims: 512, declared: 1024}}, nil, "declares 1024 dims but returned 512", xordb.ErrDimsMismatch},
+		{"declared match", declaredEncoder{fixedEncoder{dims: 512, declared: 512}}, nil, "", nil},
+		{"fallback mismatch", ngram, []xordb.Option{xordb.WithEncodeBudget(1, fixedEncoder{dims: 512})}, "fallback returned 512 dims", xordb.ErrDimsMismatch},
+		{"invalid option", ngram, []xordb.Option{xordb.WithThreshold(2)}, "Threshold must be in (0, 1]", xordb.ErrInvalidOption},
+		{"invalid xordb option", ngram, []xordb.Option{xordb.WithAdaptiveThreshold(2)}, "adaptive threshold target", xordb.ErrInvalidOption},
+		{"query log sample", ngram, []xordb.Option{xordb.WithQueryLog(io.Discard), xordb.WithQueryLogSample(0)}, "query log sample rate must be in (0, 1]", xordb.ErrInvalidOption},
+		{"unknown index", ngram, []xordb.Option{xordb.WithIndex(xordb.Index(99))}, "unknown index", xordb.ErrInvalidOption},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := xordb.TryNewWithEncoder(tt.enc, tt.opts...)
+			if tt.want == "" {
+				if err != nil || db == nil {
+					t.Fatalf("got %v, %v", db, err)
+				}
+				return
+			}
+			if db != nil || !errors.Is(err, tt.is) || !strings.HasPrefix(err.Error(), "xordb: ") || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("want error containing %q, got %v, %v", tt.want, db, err)
+			}
+		})
+	}
+}
+
+// panicEncoder panics on every encode.
+type panicEncoder struct{}
+
+func (panicEncoder) Encode(string) hdc.Vector { panic("encoder bug") }
+
+func TestTryNewWithEncoder_EncoderPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "encoder bug" {
+			t.Fatalf("the encoder's panic must not become an error, got %v", r)
+		}
+	}()
+	xordb.TryNewWithEncoder(panicEncoder{})
+}
+
+func TestNewWithEncoder_DimsMismatchPanics(t *testing.T) {
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "WithDims(2048)") {
+			t.Fatalf("want a descriptive panic, got %q", msg)
+		}
+	}()
+	xordb.NewWithEncoder(hdc.NewNGramEncoder(hdc.DefaultConfig()), xordb.WithDims(2048))
+}
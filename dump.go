@@ -0,0 +1,112 @@
+package xordb
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/bits"
+	"strings"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+const dumpBits = 64 // leading bits shown in VectorDump.Bits
+
+// VectorDump is one line of DumpVectors output.
+type VectorDump struct {
+	Key     string  `json:"key"`
+	Dims    int     `json:"dims"`
+	Density float64 `json:"density"` // fraction of set bits; 0 or 1 means a broken vector
+	Bits    string  `json:"bits"`    // leading bits as 0/1, see DebugBits
+	Vector  string  `json:"vector"`  // base64 of the little-endian uint64 words
+}
+
+// DumpVectors writes every live entry's vector as one JSON VectorDump per
+// line, most recently used first. Intended for diagnosing encoder problems
+// from a file: an all-zero or saturated vector shows up as density 0 or 1,
+// and identical vectors for different keys as equal Vector strings. Does not
+// affect LRU order or stats.
+func (db *DB) DumpVectors(w io.Writer) error {
+	keys, vecs := db.c.Vectors()
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for i, v := range vecs {
+		d := VectorDump{
+			Key:     keys[i],
+			Dims:    v.Dims(),
+			Density: BitDensity(v),
+			Bits:    DebugBits(v, dumpBits),
+			Vector:  encodeWords(v.RawData()),
+		}
+		if err := enc.Encode(d); err != nil {
+			return fmt.Errorf("xordb: dump vectors: %q: %w", keys[i], err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("xordb: dump vectors: %w", err)
+	}
+	return nil
+}
+
+// PopCount returns the number of v's bits that are set. Index structures
+// can store it per vector: two vectors differ in at least the difference of
+// their popcounts, a cheap lower bound on Distance.
+func PopCount(v hdc.Vector) int {
+	var n int
+	for _, w := range v.RawData() {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// BitDensity returns the fraction of v's bits that are set.
+func BitDensity(v hdc.Vector) float64 {
+	if v.Dims() == 0 {
+		return 0
+	}
+	return float64(PopCount(v)) / float64(v.Dims())
+}
+
+// Distance returns the Hamming distance between a and b, the number of bits
+// in which they differ; hdc.Similarity is 1 - Distance/dims. Panics if the
+// dims differ.
+func Distance(a, b hdc.Vector) int {
+	if a.Dims() != b.Dims() {
+		panic("xordb: vectors must have the same dims")
+	}
+	bw := b.RawData()
+	var n int
+	for i, w := range a.RawData() {
+		n += bits.OnesCount64(w ^ bw[i])
+	}
+	return n
+}
+
+// DebugBits renders the first limit bits of v as '0'/'1' (all bits if limit
+// <= 0), with "…" appended when truncated.
+func DebugBits(v hdc.Vector, limit int) string {
+	n := v.Dims()
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	var sb strings.Builder
+	sb.Grow(n + len("…"))
+	for i := 0; i < n; i++ {
+		sb.WriteByte(byte('0' + v.Bit(i)))
+	}
+	if n < v.Dims() {
+		sb.WriteString("…")
+	}
+	return sb.String()
+}
+
+func encodeWords(words []uint64) string {
+	buf := make([]byte, 8*len(words))
+	for i, w := range words {
+		binary.LittleEndian.PutUint64(buf[8*i:], w)
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,86 @@
+package xordb_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+)
+
+// ── vector dump ───────────────────────────────────────────────────────────────
+
+func TestDB_DumpVectors(t *testing.T) {
+	db := xordb.New(xordb.WithDims(1024))
+	db.Set("alpha", 1)
+	db.Set("beta", 2)
+
+	var buf bytes.Buffer
+	if err := db.DumpVectors(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got %d", len(lines))
+	}
+	var d xordb.VectorDump
+	if err := json.Unmarshal([]byte(lines[0]), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Key != "beta" || d.Dims != 1024 {
+		t.Fatalf("want MRU entry first with dims, got %+v", d)
+	}
+	if d.Density <= 0 || d.Density >= 1 {
+		t.Fatalf("vector must be neither empty nor saturated, got density %f", d.Density)
+	}
+	if raw, err := base64.StdEncoding.DecodeString(d.Vector); err != nil || len(raw) != 1024/8 {
+		t.Fatalf("vector must be base64 of %d bytes, got %d (%v)", 1024/8, len(raw), err)
+	}
+	if len(d.Bits) != 64+len("…") {
+		t.Fatalf("want 64 leading bits, got %q", d.Bits)
+	}
+	if s := db.Stats(); s.Hits+s.Misses != 0 {
+		t.Fatal("DumpVectors must not touch stats")
+	}
+}
+
+func TestDebugBits_BitDensity(t *testing.T) {
+	v := hdc.FromWords(8, []uint64{0b1011})
+	if got := xordb.DebugBits(v, 0); got != "11010000" {
+		t.Fatalf("DebugBits: got %q", got)
+	}
+	if got := xordb.DebugBits(v, 3); got != "110…" {
+		t.Fatalf("truncated DebugBits: got %q", got)
+	}
+	if got := xordb.BitDensity(v); got != 3.0/8 {
+		t.Fatalf("BitDensity: got %f", got)
+	}
+	if xordb.BitDensity(hdc.New(64)) != 0 {
+		t.Fatal("zero vector must have density 0")
+	}
+}
+
+func TestPopCount_Distance(t *testing.T) {
+	a := hdc.FromWords(70, []uint64{0b1011, 1 << 5})
+	b := hdc.FromWords(70, []uint64{0b0110, 1 << 5})
+	if got := xordb.PopCount(a); got != 4 {
+		t.Fatalf("PopCount: got %d", got)
+	}
+	if got := xordb.Distance(a, b); got != 3 {
+		t.Fatalf("Distance: got %d", got)
+	}
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	x, y := enc.Encode("capital of india"), enc.Encode("capital of nepal")
+	if got, want := 1-float64(xordb.Distance(x, y))/float64(x.Dims()), hdc.Similarity(x, y); got != want {
+		t.Fatalf("Distance disagrees with hdc.Similarity: %f vs %f", got, want)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Distance of different dims must panic")
+		}
+	}()
+	xordb.Distance(a, hdc.New(64))
+}
@@ -3,6 +3,9 @@ package main
 
 import (
 	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,7 +13,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/Amansingh-afk/xordb"
 	"github.com/Amansingh-afk/xordb/embed"
+	"github.com/Amansingh-afk/xordb/eval"
 )
 
 const (
@@ -36,6 +41,16 @@ func main() {
 		printModelPath()
 	case "info":
 		printModelInfo()
+	case "bench":
+		if err := bench(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	case "distill":
+		if err := distill(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
 	case "help", "--help", "-h":
 		printUsage()
 	default:
@@ -52,6 +67,12 @@ Usage:
   xordb-model download [--force]   Download MiniLM-L6-v2 model
   xordb-model path                 Print model file path
   xordb-model info                 Print model info and status
+  xordb-model bench -data FILE     Evaluate on labeled pairs (.csv/.jsonl/.json)
+        [-encoder minilm|ngram] [-threshold 0.75] [-sweep] [-indexes]
+  xordb-model distill -corpus FILE Tune the n-gram encoder to imitate MiniLM
+        [-pairs 2000] [-teacher-threshold 0.75]
+                                   on a corpus, one text per line; prints
+                                   the config as JSON
   xordb-model help                 Show this help
 
 Environment:
@@ -206,3 +227,117 @@ func printModelInfo() {
 		fmt.Println("\nRun 'xordb-model download' to download the model.")
 	}
 }
+
+// bench evaluates an encoder on a labeled dataset, and with -sweep prints
+// the threshold curve and the threshold maximizing F1.
+func bench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	data := fs.String("data", "", "labeled pairs file (.csv, .jsonl or .json)")
+	encoder := fs.String("encoder", "minilm", "encoder: minilm or ngram")
+	threshold := fs.Float64("threshold", 0.75, "threshold for the report")
+	sweep := fs.Bool("sweep", false, "also sweep thresholds 0.50-0.99")
+	indexes := fs.Bool("indexes", false, "also compare recall and latency of the index backends")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *data == "" {
+		return errors.New("bench: -data is required")
+	}
+	pairs, err := eval.LoadFile(*data)
+	if err != nil {
+		return err
+	}
+
+	var newDB func(threshold float64, opts ...xordb.Option) *xordb.DB
+	switch *encoder {
+	case "minilm":
+		enc, err := embed.NewMiniLMEncoder()
+		if err != nil {
+			return fmt.Errorf("bench: %w (run 'xordb-model download')", err)
+		}
+		defer enc.Close()
+		newDB = func(t float64, opts ...xordb.Option) *xordb.DB {
+			return xordb.NewWithEncoder(enc, append([]xordb.Option{xordb.WithThreshold(t), xordb.WithCapacity(len(pairs))}, opts...)...)
+		}
+	case "ngram":
+		newDB = func(t float64, opts ...xordb.Option) *xordb.DB {
+			return xordb.New(append([]xordb.Option{xordb.WithThreshold(t), xordb.WithCapacity(len(pairs))}, opts...)...)
+		}
+	default:
+		return fmt.Errorf("bench: unknown encoder %q", *encoder)
+	}
+
+	fmt.Printf("%s encoder, threshold %.2f, %s\n\n", *encoder, *threshold, *data)
+	if err := eval.Run(newDB(*threshold), pairs).WriteText(os.Stdout); err != nil {
+		return err
+	}
+	if *sweep {
+		fmt.Println()
+		if err := eval.SweepThresholds(newDB(*threshold), pairs, nil).WriteText(os.Stdout); err != nil {
+			return err
+		}
+	}
+	if *indexes {
+		fmt.Printf("\nindex backends (%d entries, recall relative to linear)\n", len(pairs))
+		res := eval.CompareIndexes(func(opts ...xordb.Option) *xordb.DB { return newDB(*threshold, opts...) }, pairs, nil)
+		return eval.WriteIndexComparison(os.Stdout, res)
+	}
+	return nil
+}
+
+// distill prints the n-gram configuration whose similarities on the corpus
+// best follow MiniLM's cosine similarities.
+func distill(args []string) error {
+	fs := flag.NewFlagSet("distill", flag.ContinueOnError)
+	corpusPath := fs.String("corpus", "", "file with one text per line")
+	pairs := fs.Int("pairs", 2000, "text pairs to score")
+	teacherT := fs.Float64("teacher-threshold", 0.75, "MiniLM cosine similarity counted as a hit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *corpusPath == "" {
+		return errors.New("distill: -corpus is required")
+	}
+	data, err := os.ReadFile(*corpusPath)
+	if err != nil {
+		return err
+	}
+	var corpus []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			corpus = append(corpus, line)
+		}
+	}
+
+	enc, err := embed.NewMiniLMEncoder()
+	if err != nil {
+		return fmt.Errorf("distill: %w (run 'xordb-model download')", err)
+	}
+	defer enc.Close()
+	embs := make(map[string][]float32)
+	embedding := func(text string) []float32 {
+		e, ok := embs[text]
+		if !ok {
+			e, _ = enc.Embed(text) // nil on error: scores 0
+			embs[text] = e
+		}
+		return e
+	}
+	cosine := func(a, b string) float64 {
+		ea, eb := embedding(a), embedding(b)
+		if len(ea) != len(eb) {
+			return 0
+		}
+		var dot float64
+		for i := range ea {
+			dot += float64(ea[i] * eb[i])
+		}
+		return dot // embeddings are L2-normalized
+	}
+
+	fmt.Fprintf(os.Stderr, "distilling MiniLM on %d texts from %s\n", len(corpus), *corpusPath)
+	d := eval.Distill(corpus, cosine, eval.DistillConfig{Pairs: *pairs, TeacherThreshold: *teacherT})
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	return out.Encode(d)
+}
@@ -1,16 +1,24 @@
 package embed
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"math"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	ort "github.com/yalue/onnxruntime_go"
 
 	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+	"github.com/Amansingh-afk/xordb/hdcx"
 )
 
 const (
@@ -20,15 +28,65 @@ const (
 	defaultProjectionSeed = 0xDB_CAFE
 )
 
+var (
+	// ErrModelNotFound is wrapped by NewMiniLMEncoder, Reload and
+	// DefaultModelPath errors when there is no model file where they look;
+	// `xordb-model download` fetches one.
+	ErrModelNotFound = errors.New("embed: model not found")
+
+	// ErrClosed is wrapped by Embed and the other encoder methods that
+	// return errors, and by Reload, after Close. It is xordb.ErrClosed.
+	ErrClosed = cache.ErrClosed
+)
+
 // MiniLMEncoder — local MiniLM-L6-v2 via ONNX → 384-dim float → binary HDC vector.
 // Thread-safe after construction.
 type MiniLMEncoder struct {
-	mu         sync.Mutex
-	session    *ort.DynamicAdvancedSession
+	rt         *inference // shared with encoders from Reseed
 	tokenizer  *WordPieceTokenizer
-	projector  *hdc.Projector
+	projector  Projector
+	projSeed   uint64
+	streamed   bool // WithStreamedProjection
 	maxSeqLen  int
 	binaryDims int
+	timer      hdcx.StageTimer
+	stats      *encoderStats // shared with encoders from Reseed
+	onTrunc    func(text string, dropped int)
+	tuning     Tuning // WithTuning, for Reload
+}
+
+type encoderStats struct {
+	encodes, truncated, dropped atomic.Uint64
+	inferNanos                  atomic.Int64
+}
+
+// EncoderStats counts the encoder's work since construction, including
+// that of encoders derived from it by Reseed.
+type EncoderStats struct {
+	Encodes          uint64 // texts run through the model
+	TruncatedEncodes uint64 // of those, texts over the max sequence length
+	DroppedTokens    uint64 // tokens cut from them
+
+	// InferTime is the total time spent running the model, not counting
+	// waits for the session; over Encodes it is the mean SuggestTuning
+	// uses.
+	InferTime time.Duration
+}
+
+// Stats returns the encoder's counters.
+func (e *MiniLMEncoder) Stats() EncoderStats {
+	return EncoderStats{
+		Encodes:          e.stats.encodes.Load(),
+		TruncatedEncodes: e.stats.truncated.Load(),
+		DroppedTokens:    e.stats.dropped.Load(),
+		InferTime:        time.Duration(e.stats.inferNanos.Load()),
+	}
+}
+
+// inference serializes inference on one ONNX session.
+type inference struct {
+	mu      sync.Mutex
+	session *ort.DynamicAdvancedSession
 }
 
 type EncoderOption func(*encoderConfig)
@@ -38,6 +96,15 @@ type encoderConfig struct {
 	maxSeqLen      int
 	binaryDims     int
 	projectionSeed uint64
+	streamed       bool
+	projector      Projector
+	tokenizer      *WordPieceTokenizer
+	stageTimer     hdcx.StageTimer
+	vocabCorpus    []string
+	wordCache      int
+	keepWhole      []*regexp.Regexp
+	onTruncate     func(text string, dropped int)
+	tuning         Tuning
 }
 
 func defaultEncoderConfig() encoderConfig {
@@ -45,6 +112,7 @@ func defaultEncoderConfig() encoderConfig {
 		maxSeqLen:      defaultMaxSeqLen,
 		binaryDims:     defaultBinaryDims,
 		projectionSeed: defaultProjectionSeed,
+		wordCache:      defaultWordCache,
 	}
 }
 
@@ -64,6 +132,72 @@ func WithProjectionSeed(seed uint64) EncoderOption {
 	return func(c *encoderConfig) { c.projectionSeed = seed }
 }
 
+// WithStreamedProjection derives each hyperplane from the projection seed
+// and its index as it is used, instead of materializing all binaryDims ×
+// 384 float32 planes (15 MB at 10,000 dims) at construction: the encoder
+// and each Reseed start ~60 ms faster and hold no plane memory, while the
+// projection step of Encode takes about twice as long (a few ms at 10,000
+// dims, next to inference). Planes have ±1 entries rather than Gaussian
+// ones, so vectors differ from the default projection's for the same
+// seed; don't switch a cache with saved vectors.
+func WithStreamedProjection(on bool) EncoderOption {
+	return func(c *encoderConfig) { c.streamed = on }
+}
+
+// WithProjector makes the encoder use p, e.g. another encoder's
+// (MiniLMEncoder.Projector), instead of building its own planes, so a pool
+// of encoders holds one 15 MB plane matrix rather than one each. p must
+// produce vectors of the encoder's binary dims; WithProjectionSeed and
+// WithStreamedProjection then only affect Reseed.
+func WithProjector(p Projector) EncoderOption {
+	return func(c *encoderConfig) { c.projector = p }
+}
+
+// WithTokenizer makes the encoder use t, e.g. another encoder's
+// (MiniLMEncoder.Tokenizer) or one from NewTokenizer, instead of parsing
+// its own copy of the vocab. Tokenizers are safe for concurrent use, and a
+// shared one shares its word cache; WithPrunedVocab, WithWordCache and
+// WithKeepWhole are ignored, so configure t before sharing it.
+func WithTokenizer(t *WordPieceTokenizer) EncoderOption {
+	return func(c *encoderConfig) { c.tokenizer = t }
+}
+
+// WithPrunedVocab keeps only the vocab entries needed to tokenize corpus
+// (see WordPieceTokenizer.Prune), cutting the tokenizer's memory from the
+// full 30k entries to the domain's. Text outside the corpus still encodes,
+// but words the pruned vocab lacks become [UNK] or coarser pieces.
+func WithPrunedVocab(corpus []string) EncoderOption {
+	return func(c *encoderConfig) { c.vocabCorpus = corpus }
+}
+
+// WithWordCache sets how many split words the tokenizer caches (default
+// 4096; 0 disables), see WordPieceTokenizer.SetWordCache.
+func WithWordCache(n int) EncoderOption {
+	return func(c *encoderConfig) { c.wordCache = n }
+}
+
+// WithKeepWhole keeps text matching patterns, e.g. PatternURL or
+// PatternUUID, as one token rather than word pieces; see
+// WordPieceTokenizer.SetKeepWhole. Ignored with WithTokenizer.
+func WithKeepWhole(patterns ...*regexp.Regexp) EncoderOption {
+	return func(c *encoderConfig) { c.keepWhole = patterns }
+}
+
+// WithTruncationHook calls fn, on the encoding goroutine, for every text
+// whose tokens exceed the max sequence length, with the number of tokens
+// cut from its end. Such texts encode as their first maxSeqLen-2 tokens,
+// so two long prompts differing only past that point encode alike; the
+// count is also in Stats.TruncatedEncodes.
+func WithTruncationHook(fn func(text string, dropped int)) EncoderOption {
+	return func(c *encoderConfig) { c.onTruncate = fn }
+}
+
+// WithStageTimer times Encode's stages — hdcx.StageTokenize, StageInfer
+// and StageProject — on a sampled fraction of calls.
+func WithStageTimer(t hdcx.StageTimer) EncoderOption {
+	return func(c *encoderConfig) { c.stageTimer = t }
+}
+
 // NewMiniLMEncoder creates the encoder. ONNX runtime must be available.
 // Model path is auto-resolved if not set (see DefaultModelPath).
 func NewMiniLMEncoder(opts ...EncoderOption) (*MiniLMEncoder, error) {
@@ -75,16 +209,58 @@ func NewMiniLMEncoder(opts ...EncoderOption) (*MiniLMEncoder, error) {
 	if cfg.maxSeqLen < 3 {
 		return nil, fmt.Errorf("embed: maxSeqLen must be >= 3, got %d", cfg.maxSeqLen)
 	}
+	if cfg.projector != nil {
+		if d := cfg.projector.ProjectFloat(make([]float32, miniLMEmbDims)).Dims(); d != cfg.binaryDims {
+			return nil, fmt.Errorf("embed: %w", &cache.DimsError{What: "projector", Got: d, Want: cfg.binaryDims})
+		}
+	}
 
 	modelPath := cfg.modelPath
 	if modelPath == "" {
 		var err error
 		modelPath, err = DefaultModelPath()
 		if err != nil {
-			return nil, fmt.Errorf("embed: model not found: %w (run: xordb-model download)", err)
+			return nil, fmt.Errorf("%w (run: xordb-model download)", err)
 		}
 	}
-	if _, err := os.Stat(modelPath); err != nil {
+	session, err := newSession(modelPath, cfg.tuning)
+	if err != nil {
+		return nil, err
+	}
+
+	tok := cfg.tokenizer
+	if tok == nil {
+		tok = NewTokenizer()
+		tok.SetWordCache(cfg.wordCache)
+		tok.SetKeepWhole(cfg.keepWhole...)
+		if cfg.vocabCorpus != nil {
+			tok = tok.Prune(cfg.vocabCorpus)
+		}
+	}
+	proj := cfg.projector
+	if proj == nil {
+		proj = newProjector(cfg.binaryDims, cfg.projectionSeed, cfg.streamed)
+	}
+
+	return &MiniLMEncoder{
+		rt:         &inference{session: session},
+		tokenizer:  tok,
+		projector:  proj,
+		projSeed:   cfg.projectionSeed,
+		streamed:   cfg.streamed,
+		maxSeqLen:  cfg.maxSeqLen,
+		binaryDims: cfg.binaryDims,
+		timer:      cfg.stageTimer,
+		stats:      new(encoderStats),
+		onTrunc:    cfg.onTruncate,
+		tuning:     cfg.tuning,
+	}, nil
+}
+
+func newSession(modelPath string, t Tuning) (*ort.DynamicAdvancedSession, error) {
+	if _, err := os.Stat(modelPath); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %w", ErrModelNotFound, err)
+	} else if err != nil {
 		return nil, fmt.Errorf("embed: model file not accessible: %w", err)
 	}
 
@@ -92,94 +268,258 @@ func NewMiniLMEncoder(opts ...EncoderOption) (*MiniLMEncoder, error) {
 		return nil, fmt.Errorf("embed: ONNX runtime init failed: %w", err)
 	}
 
+	opts, err := t.sessionOptions()
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil {
+		defer opts.Destroy()
+	}
 	session, err := ort.NewDynamicAdvancedSession(
 		modelPath,
 		[]string{"input_ids", "attention_mask", "token_type_ids"},
 		[]string{"last_hidden_state"},
-		nil,
+		opts,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("embed: failed to create ONNX session: %w", err)
 	}
+	return session, nil
+}
 
-	return &MiniLMEncoder{
-		session:    session,
-		tokenizer:  NewWordPieceTokenizer(vocabData),
-		projector:  hdc.NewProjector(miniLMEmbDims, cfg.binaryDims, cfg.projectionSeed),
-		maxSeqLen:  cfg.maxSeqLen,
-		binaryDims: cfg.binaryDims,
-	}, nil
+// Reload swaps in the model at path for e and every encoder sharing its
+// session (see Reseed), without a restart. The new model is loaded and run
+// once on a probe text first; if either fails, e keeps the old model and
+// the error is returned. An encode in flight finishes on the old session,
+// which is then freed. A different model makes different vectors, so
+// entries cached under the old one stop matching: clear the cache, or
+// reload only a model with the same outputs, e.g. a re-quantized build
+// checked against saved vectors.
+func (e *MiniLMEncoder) Reload(modelPath string) error {
+	session, err := newSession(modelPath, e.tuning)
+	if err != nil {
+		return err
+	}
+	probe := *e
+	probe.rt = &inference{session: session}
+	if err := probe.infer(e.tokenizer.Tokenize("probe", e.maxSeqLen), func([]float32) {}); err != nil {
+		session.Destroy()
+		return fmt.Errorf("embed: new model failed a probe run: %w", err)
+	}
+
+	e.rt.mu.Lock()
+	defer e.rt.mu.Unlock()
+	if e.rt.session == nil {
+		session.Destroy()
+		return fmt.Errorf("embed: encoder: %w", ErrClosed)
+	}
+	old := e.rt.session
+	e.rt.session = session
+	return old.Destroy()
+}
+
+// Reseed implements xordb.Reseeder: it returns an encoder sharing e's ONNX
+// session whose projection is derived from seed, so its vectors are
+// unrelated to e's and to those of any other seed. Reseed(0) is e. Used by
+// xordb.WithSeed to namespace DBs.
+func (e *MiniLMEncoder) Reseed(seed uint64) hdc.Encoder {
+	if seed == 0 {
+		return e
+	}
+	ps := e.projSeed ^ splitmix(seed)
+	r := *e
+	r.projector = newProjector(e.binaryDims, ps, e.streamed)
+	r.projSeed = ps
+	return &r
+}
+
+// splitmix is the splitmix64 finalizer.
+func splitmix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
 }
 
 // Encode implements hdc.Encoder. Error → zero vector (interface mein error nahi hai).
 func (e *MiniLMEncoder) Encode(text string) hdc.Vector {
-	emb, err := e.Embed(text)
+	clock := e.timer.Start()
+	emb, err := e.embed(text, &clock)
 	if err != nil {
 		return hdc.New(e.binaryDims)
 	}
-	return e.projector.ProjectFloat(emb)
+	v := e.projector.ProjectFloat(emb)
+	clock.Lap(hdcx.StageProject)
+	return v
+}
+
+// Projector returns the encoder's projection, for WithProjector.
+func (e *MiniLMEncoder) Projector() Projector { return e.projector }
+
+// Tokenizer returns the encoder's tokenizer, for WithTokenizer.
+func (e *MiniLMEncoder) Tokenizer() *WordPieceTokenizer { return e.tokenizer }
+
+// Dims returns the binary vector size (see WithBinaryDims); it implements
+// xordb.Dimensioner.
+func (e *MiniLMEncoder) Dims() int { return e.binaryDims }
+
+// ProjectEmbedding implements xordb.EmbeddingProjector: it projects a
+// 384-dim MiniLM embedding, e.g. one computed by an existing pipeline, into
+// the same binary space as Encode.
+func (e *MiniLMEncoder) ProjectEmbedding(emb []float32) (hdc.Vector, error) {
+	if len(emb) != miniLMEmbDims {
+		return hdc.Vector{}, fmt.Errorf("embed: %w", &cache.DimsError{What: "embedding", Got: len(emb), Want: miniLMEmbDims})
+	}
+	return e.projector.ProjectFloat(emb), nil
 }
 
-// Embed returns the raw 384-dim float32 embedding (useful for debugging).
+// Embed returns the raw 384-dim float32 embedding. It implements
+// xordb.Embedder, for exporting a cache's keys to pgvector or FAISS.
 func (e *MiniLMEncoder) Embed(text string) ([]float32, error) {
+	return e.embed(text, &hdcx.StageClock{})
+}
+
+func (e *MiniLMEncoder) embed(text string, clock *hdcx.StageClock) ([]float32, error) {
 	tokens := e.tokenizer.Tokenize(text, e.maxSeqLen)
 	seqLen := len(tokens.InputIDs)
-	tokens.PadTo(e.maxSeqLen)
+	e.counted(text, tokens.Dropped)
+	clock.Lap(hdcx.StageTokenize)
 
+	var embedding []float32
+	err := e.infer(tokens, func(hidden []float32) {
+		embedding = meanPool(hidden, seqLen, e.maxSeqLen, miniLMEmbDims)
+	})
+	if err != nil {
+		return nil, err
+	}
+	l2Normalize(embedding)
+	clock.Lap(hdcx.StageInfer)
+
+	return embedding, nil
+}
+
+// TokenEmbedding is the model's output for one token, before pooling.
+type TokenEmbedding struct {
+	ID     int32     // vocab ID; [CLS] is 101, [SEP] 102
+	Span   Span      // where in the text it came from
+	Vector []float32 // 384 dims, L2-normalized
+}
+
+// EmbedTokens returns the per-token embeddings Embed averages, with the
+// span of text each token came from: for late-interaction (MaxSim)
+// scoring, or for highlighting which words of a text matched. [CLS] and
+// [SEP] are included, and text past the max sequence length is not.
+func (e *MiniLMEncoder) EmbedTokens(text string) ([]TokenEmbedding, error) {
+	tokens, spans := e.tokenizer.TokenizeSpans(text, e.maxSeqLen)
+	e.counted(text, tokens.Dropped)
+	ids := tokens.InputIDs
+	out := make([]TokenEmbedding, len(spans))
+	err := e.infer(tokens, func(hidden []float32) {
+		for t := range out {
+			vec := slices.Clone(hidden[t*miniLMEmbDims : (t+1)*miniLMEmbDims])
+			l2Normalize(vec)
+			out[t] = TokenEmbedding{ID: ids[t], Span: spans[t], Vector: vec}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EncodeTokens projects each word piece's embedding, as EmbedTokens
+// returns them without [CLS] and [SEP], into the binary space of Encode;
+// it implements cache.TokenEncoder for xordb.WithLateInteraction. Error →
+// nil, and the cache falls back to the pooled vector.
+func (e *MiniLMEncoder) EncodeTokens(text string) []hdc.Vector {
+	toks, err := e.EmbedTokens(text)
+	if err != nil {
+		return nil
+	}
+	var out []hdc.Vector
+	for _, t := range toks {
+		if t.ID != clsTokenID && t.ID != sepTokenID {
+			out = append(out, e.projector.ProjectFloat(t.Vector))
+		}
+	}
+	return out
+}
+
+// counted records an encode of text that lost dropped tokens.
+func (e *MiniLMEncoder) counted(text string, dropped int) {
+	e.stats.encodes.Add(1)
+	if dropped == 0 {
+		return
+	}
+	e.stats.truncated.Add(1)
+	e.stats.dropped.Add(uint64(dropped))
+	if e.onTrunc != nil {
+		e.onTrunc(text, dropped)
+	}
+}
+
+// infer runs the model over tokens, padded to the max sequence length,
+// and passes last_hidden_state, maxSeqLen rows of miniLMEmbDims, to use
+// before its tensor is freed.
+func (e *MiniLMEncoder) infer(tokens TokenizeResult, use func(hidden []float32)) error {
+	tokens.PadTo(e.maxSeqLen)
 	shape := ort.NewShape(1, int64(e.maxSeqLen))
 
 	inputIDs, err := ort.NewTensor(shape, castInt32ToInt64(tokens.InputIDs))
 	if err != nil {
-		return nil, fmt.Errorf("embed: creating input_ids tensor: %w", err)
+		return fmt.Errorf("embed: creating input_ids tensor: %w", err)
 	}
 	defer inputIDs.Destroy()
 
 	attentionMask, err := ort.NewTensor(shape, castInt32ToInt64(tokens.AttentionMask))
 	if err != nil {
-		return nil, fmt.Errorf("embed: creating attention_mask tensor: %w", err)
+		return fmt.Errorf("embed: creating attention_mask tensor: %w", err)
 	}
 	defer attentionMask.Destroy()
 
 	tokenTypeIDs, err := ort.NewTensor(shape, castInt32ToInt64(tokens.TokenTypeIDs))
 	if err != nil {
-		return nil, fmt.Errorf("embed: creating token_type_ids tensor: %w", err)
+		return fmt.Errorf("embed: creating token_type_ids tensor: %w", err)
 	}
 	defer tokenTypeIDs.Destroy()
 
 	outputShape := ort.NewShape(1, int64(e.maxSeqLen), miniLMEmbDims)
 	output, err := ort.NewEmptyTensor[float32](outputShape)
 	if err != nil {
-		return nil, fmt.Errorf("embed: creating output tensor: %w", err)
+		return fmt.Errorf("embed: creating output tensor: %w", err)
 	}
 	defer output.Destroy()
 
-	e.mu.Lock()
-	if e.session == nil {
-		e.mu.Unlock()
-		return nil, fmt.Errorf("embed: encoder is closed")
+	e.rt.mu.Lock()
+	if e.rt.session == nil {
+		e.rt.mu.Unlock()
+		return fmt.Errorf("embed: encoder: %w", ErrClosed)
 	}
-	err = e.session.Run(
+	start := time.Now()
+	err = e.rt.session.Run(
 		[]ort.ArbitraryTensor{inputIDs, attentionMask, tokenTypeIDs},
 		[]ort.ArbitraryTensor{output},
 	)
-	e.mu.Unlock()
+	e.stats.inferNanos.Add(int64(time.Since(start)))
+	e.rt.mu.Unlock()
 	if err != nil {
-		return nil, fmt.Errorf("embed: ONNX inference failed: %w", err)
+		return fmt.Errorf("embed: ONNX inference failed: %w", err)
 	}
 
-	outputData := output.GetData()
-	embedding := meanPool(outputData, seqLen, e.maxSeqLen, miniLMEmbDims)
-	l2Normalize(embedding)
-
-	return embedding, nil
+	use(output.GetData())
+	return nil
 }
 
+// Close releases the ONNX session, which also closes encoders sharing it
+// through Reseed.
 func (e *MiniLMEncoder) Close() error {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	if e.session != nil {
-		err := e.session.Destroy()
-		e.session = nil
+	e.rt.mu.Lock()
+	defer e.rt.mu.Unlock()
+	if e.rt.session != nil {
+		err := e.rt.session.Destroy()
+		e.rt.session = nil
 		return err
 	}
 	return nil
@@ -269,7 +609,7 @@ func DefaultModelPath() (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("model not found in any of: $XORDB_MODEL_PATH, %v", candidates)
+	return "", fmt.Errorf("%w in any of: $XORDB_MODEL_PATH, %v", ErrModelNotFound, candidates)
 }
 
 func ModelDir() string {
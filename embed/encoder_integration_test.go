@@ -98,6 +98,33 @@ func TestMiniLMEncoder_Embed_RawVector(t *testing.T) {
 	}
 }
 
+func TestMiniLMEncoder_EmbedTokens(t *testing.T) {
+	skipIfNoModel(t)
+
+	enc, err := NewMiniLMEncoder()
+	if err != nil {
+		t.Fatalf("NewMiniLMEncoder: %v", err)
+	}
+	defer enc.Close()
+
+	text := "hello world"
+	toks, err := enc.EmbedTokens(text)
+	if err != nil {
+		t.Fatalf("EmbedTokens: %v", err)
+	}
+	if len(toks) != 4 || toks[0].ID != clsTokenID || toks[3].ID != sepTokenID {
+		t.Fatalf("want [CLS] hello world [SEP], got %d tokens", len(toks))
+	}
+	if sp := toks[2].Span; text[sp.Start:sp.End] != "world" {
+		t.Fatalf("token 2 spans %q", text[sp.Start:sp.End])
+	}
+	for _, tk := range toks {
+		if len(tk.Vector) != miniLMEmbDims {
+			t.Fatalf("want dim=%d, got %d", miniLMEmbDims, len(tk.Vector))
+		}
+	}
+}
+
 func TestMiniLMEncoder_Deterministic(t *testing.T) {
 	skipIfNoModel(t)
 
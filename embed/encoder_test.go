@@ -1,7 +1,14 @@
 package embed
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
 )
 
 // ── unit tests (no ONNX model needed) ────────────────────────────────────────
@@ -87,6 +94,61 @@ func TestDefaultEncoderConfig(t *testing.T) {
 	}
 }
 
+func TestProjectEmbedding(t *testing.T) {
+	e := &MiniLMEncoder{projector: hdc.NewProjector(miniLMEmbDims, 1024, 1), binaryDims: 1024}
+	emb := make([]float32, miniLMEmbDims)
+	emb[0] = 1
+	v, err := e.ProjectEmbedding(emb)
+	if err != nil || v.Dims() != 1024 {
+		t.Fatalf("got %d dims, %v", v.Dims(), err)
+	}
+	if _, err := e.ProjectEmbedding(emb[:10]); err == nil {
+		t.Fatal("wrong embedding length must fail")
+	}
+}
+
+func TestReseed(t *testing.T) {
+	e := &MiniLMEncoder{projector: hdc.NewProjector(miniLMEmbDims, 1024, 1), projSeed: 1, binaryDims: 1024}
+	if e.Reseed(0) != e {
+		t.Fatal("Reseed(0) must return the encoder itself")
+	}
+	emb := make([]float32, miniLMEmbDims)
+	for i := range emb {
+		emb[i] = float32(i%7) - 3
+	}
+	v, _ := e.ProjectEmbedding(emb)
+	r1, _ := e.Reseed(5).(*MiniLMEncoder).ProjectEmbedding(emb)
+	r2, _ := e.Reseed(5).(*MiniLMEncoder).ProjectEmbedding(emb)
+	if s := hdc.Similarity(v, r1); s > 0.6 {
+		t.Fatalf("a reseeded projection must be unrelated: %.3f", s)
+	}
+	if hdc.Similarity(r1, r2) != 1 {
+		t.Fatal("Reseed must be deterministic")
+	}
+}
+
+func TestWithProjector_DimsMismatch(t *testing.T) {
+	_, err := NewMiniLMEncoder(WithProjector(hdc.NewProjector(miniLMEmbDims, 512, 1)))
+	if !errors.Is(err, cache.ErrDimsMismatch) || !strings.Contains(err.Error(), "projector has 512 dims") {
+		t.Fatalf("got %v, want a projector dims error", err)
+	}
+}
+
+func TestEncoderStats_Truncation(t *testing.T) {
+	var hooked []int
+	e := &MiniLMEncoder{stats: new(encoderStats), onTrunc: func(_ string, n int) { hooked = append(hooked, n) }}
+	e.counted("short", 0)
+	e.counted("long", 5)
+	e.counted("longer", 7)
+	want := EncoderStats{Encodes: 3, TruncatedEncodes: 2, DroppedTokens: 12}
+	if got := e.Stats(); got != want {
+		t.Fatalf("Stats = %+v, want %+v", got, want)
+	}
+	if len(hooked) != 2 || hooked[0] != 5 || hooked[1] != 7 {
+		t.Fatalf("hook saw %v", hooked)
+	}
+}
+
 func TestModelDir_ReturnsNonEmpty(t *testing.T) {
 	dir := ModelDir()
 	if dir == "" {
@@ -100,3 +162,20 @@ func abs32(x float32) float32 {
 	}
 	return x
 }
+
+func TestModelNotFound(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XORDB_MODEL_PATH", filepath.Join(dir, "missing.onnx"))
+	t.Setenv("XDG_DATA_HOME", dir)
+	t.Setenv("HOME", dir)
+	if _, err := DefaultModelPath(); !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("DefaultModelPath: %v", err)
+	}
+	if _, err := NewMiniLMEncoder(); !errors.Is(err, ErrModelNotFound) {
+		t.Fatalf("NewMiniLMEncoder: %v", err)
+	}
+	_, err := NewMiniLMEncoder(WithModelPath(filepath.Join(dir, "missing.onnx")))
+	if !errors.Is(err, ErrModelNotFound) || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("WithModelPath: %v", err)
+	}
+}
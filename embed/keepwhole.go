@@ -0,0 +1,64 @@
+package embed
+
+import (
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+// Patterns for SetKeepWhole and WithKeepWhole: technical tokens that
+// WordPiece would shatter into a dozen pieces or [UNK]. PatternIdentifier
+// matches snake_case, camelCase and dotted names like os.path.join; pass
+// it after PatternEmail, which it would otherwise cut short.
+var (
+	PatternURL        = regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^\s<>"']+`)
+	PatternEmail      = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`)
+	PatternUUID       = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	PatternIdentifier = regexp.MustCompile(`\b(?:[A-Za-z]+_\w+|[a-z]+[A-Z]\w*|[A-Za-z_]\w+(?:\.[A-Za-z_]\w+)+)\b`)
+)
+
+// SetKeepWhole makes text matching any of patterns a single token instead
+// of word pieces: the match, lowercased, is hashed to one of the vocab's
+// 994 [unusedN] IDs, so equal matches get equal IDs and different ones
+// collide 1 time in 994. The model never trained on those IDs, so a kept token
+// carries identity rather than meaning: two queries naming the same UUID
+// agree on it, where its pieces would have made both look like noise.
+// Patterns are tried leftmost-first, earlier ones winning at the same
+// position. Call it before use; no patterns turns it off.
+func (t *WordPieceTokenizer) SetKeepWhole(patterns ...*regexp.Regexp) {
+	if len(patterns) == 0 {
+		t.keep = nil
+		return
+	}
+	alts := make([]string, len(patterns))
+	for i, p := range patterns {
+		alts[i] = "(?:" + p.String() + ")"
+	}
+	t.keep = regexp.MustCompile(strings.Join(alts, "|"))
+}
+
+// keptID returns the [unusedN] ID for a kept match.
+func (t *WordPieceTokenizer) keptID(match string) int32 {
+	if len(t.unused) == 0 {
+		return unkTokenID
+	}
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(match)))
+	return t.unused[h.Sum64()%uint64(len(t.unused))]
+}
+
+// segments calls fn for each run of text between kept matches, with its
+// byte offset, and for each match with kept set.
+func (t *WordPieceTokenizer) segments(text string, fn func(seg string, off int, kept bool)) {
+	at := 0
+	for _, m := range t.keep.FindAllStringIndex(text, -1) {
+		if m[0] > at {
+			fn(text[at:m[0]], at, false)
+		}
+		fn(text[m[0]:m[1]], m[0], true)
+		at = m[1]
+	}
+	if at < len(text) {
+		fn(text[at:], at, false)
+	}
+}
@@ -0,0 +1,59 @@
+package embed
+
+import (
+	"math"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+// Projector turns a pooled 384-dim embedding into a binary vector:
+// *hdc.Projector, or the one WithStreamedProjection builds. It must be
+// safe for concurrent use; both are.
+type Projector interface {
+	ProjectFloat(embedding []float32) hdc.Vector
+}
+
+func newProjector(binaryDims int, seed uint64, streamed bool) Projector {
+	if streamed {
+		return streamedProjector{embDims: miniLMEmbDims, binaryDims: binaryDims, seed: seed}
+	}
+	return hdc.NewProjector(miniLMEmbDims, binaryDims, seed)
+}
+
+// streamedProjector is random-hyperplane projection with planes derived
+// on the fly from (seed, plane index) instead of materialized. Plane i has
+// ±1 entries: the bits of hdcx.NewRandSource(seed + i·γ), so nothing is
+// allocated at construction (hdc.NewProjector spends ~60 ms and 15 MB at
+// 10,000 dims), while each call regenerates the planes and takes about
+// twice as long as a materialized projection (BenchmarkProjection). Sign
+// planes preserve angles like Gaussian ones for dense embeddings, but the
+// vectors differ from hdc.Projector's for the same seed.
+type streamedProjector struct {
+	embDims, binaryDims int
+	seed                uint64
+}
+
+func (p streamedProjector) ProjectFloat(embedding []float32) hdc.Vector {
+	if len(embedding) != p.embDims {
+		panic("embed: embedding length does not match projector embDims")
+	}
+	words := make([]uint64, hdc.NumWords(p.binaryDims))
+	for i := 0; i < p.binaryDims; i++ {
+		src := hdcx.NewRandSource(p.seed + uint64(i)*0x9e3779b97f4a7c15)
+		var dot float32
+		var signs uint64
+		for j, x := range embedding {
+			if j%64 == 0 {
+				signs = src.Uint64()
+			}
+			// x or -x, by flipping its sign bit: no branch to mispredict
+			dot += math.Float32frombits(math.Float32bits(x) ^ uint32(signs&1)<<31)
+			signs >>= 1
+		}
+		if dot >= 0 {
+			words[i/64] |= 1 << uint(i%64)
+		}
+	}
+	return hdc.FromWords(p.binaryDims, words)
+}
@@ -0,0 +1,61 @@
+package embed
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+func TestStreamedProjector(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	a, noise := make([]float32, miniLMEmbDims), make([]float32, miniLMEmbDims)
+	for i := range a {
+		a[i], noise[i] = float32(rng.NormFloat64()), float32(rng.NormFloat64())
+	}
+	b := make([]float32, miniLMEmbDims) // cos(a, b) ≈ 0.9
+	for i := range b {
+		b[i] = 0.9*a[i] + float32(math.Sqrt(1-0.81))*noise[i]
+	}
+
+	p := newProjector(4096, 7, true)
+	va, vb := p.ProjectFloat(a), p.ProjectFloat(b)
+	if hdc.Similarity(va, p.ProjectFloat(a)) != 1 {
+		t.Fatal("projection must be deterministic")
+	}
+	// Sign projections agree on a fraction 1 - θ/π of bits: 0.856 here.
+	if s := hdc.Similarity(va, vb); s < 0.82 || s > 0.89 {
+		t.Errorf("similarity %.3f for cosine 0.9, want ~0.856", s)
+	}
+	if s := hdc.Similarity(va, p.ProjectFloat(noise)); s < 0.45 || s > 0.55 {
+		t.Errorf("similarity %.3f for unrelated embeddings, want ~0.5", s)
+	}
+	if s := hdc.Similarity(va, newProjector(4096, 8, true).ProjectFloat(a)); s > 0.55 {
+		t.Errorf("another seed must give unrelated planes: %.3f", s)
+	}
+}
+
+func BenchmarkProjection(b *testing.B) {
+	emb := make([]float32, miniLMEmbDims)
+	for i := range emb {
+		emb[i] = float32(i%7) - 3
+	}
+	for _, streamed := range []bool{false, true} {
+		name := "materialized"
+		if streamed {
+			name = "streamed"
+		}
+		b.Run(name+"/construct", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				newProjector(defaultBinaryDims, 1, streamed)
+			}
+		})
+		p := newProjector(defaultBinaryDims, 1, streamed)
+		b.Run(name+"/project", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				p.ProjectFloat(emb)
+			}
+		})
+	}
+}
@@ -1,8 +1,13 @@
 package embed
 
 import (
+	"regexp"
 	"strings"
+	"sync"
 	"unicode"
+	"unicode/utf8"
+
+	"github.com/Amansingh-afk/xordb/hdcx"
 )
 
 // BERT uncased special tokens
@@ -13,48 +18,117 @@ const (
 	padTokenID = 0
 )
 
-// WordPieceTokenizer — BERT-style subword tokenization. Read-only after init.
+// WordPieceTokenizer — BERT-style subword tokenization. Safe for concurrent
+// use. The vocab is parsed on first use, so an encoder that is built but
+// never used does not pay for it, and the pieces of recently split words are
+// cached (see SetWordCache).
 type WordPieceTokenizer struct {
+	text     string // vocab source until parsed
+	once     sync.Once
 	vocab    map[string]int32
 	maxToken int
+	cache    *wordCache     // nil = off
+	keep     *regexp.Regexp // see SetKeepWhole; nil = off
+	unused   []int32        // [unusedN] IDs, for kept matches
 }
 
 func NewWordPieceTokenizer(vocabText string) *WordPieceTokenizer {
-	lines := strings.Split(vocabText, "\n")
-	vocab := make(map[string]int32, len(lines))
-	maxToken := 0
-	for i, line := range lines {
-		line = strings.TrimRight(line, "\r")
-		if line == "" {
-			continue
+	return &WordPieceTokenizer{text: vocabText, cache: newWordCache(defaultWordCache)}
+}
+
+// NewTokenizer returns a tokenizer for the bundled MiniLM vocab, the one
+// NewMiniLMEncoder builds by default.
+func NewTokenizer() *WordPieceTokenizer { return NewWordPieceTokenizer(vocabData) }
+
+// SetWordCache sets how many split words keep their piece IDs cached
+// (default 4096), so repeated words skip the greedy longest-match search;
+// 0 turns the cache off. Call it before use.
+func (t *WordPieceTokenizer) SetWordCache(n int) { t.cache = newWordCache(n) }
+
+// load parses the vocab once.
+func (t *WordPieceTokenizer) load() {
+	t.once.Do(func() {
+		if t.vocab != nil {
+			return // built by Prune
 		}
-		vocab[line] = int32(i)
-		if len(line) > maxToken {
-			maxToken = len(line)
+		lines := strings.Split(t.text, "\n")
+		t.vocab = make(map[string]int32, len(lines))
+		for i, line := range lines {
+			line = strings.TrimRight(line, "\r")
+			if line == "" {
+				continue
+			}
+			t.vocab[line] = int32(i)
+			t.maxToken = max(t.maxToken, len(line))
+			if strings.HasPrefix(line, "[unused") {
+				t.unused = append(t.unused, int32(i))
+			}
 		}
+		t.text = ""
+	})
+}
+
+// VocabSize returns the number of vocab entries.
+func (t *WordPieceTokenizer) VocabSize() int {
+	t.load()
+	return len(t.vocab)
+}
+
+// Prune returns a tokenizer keeping only the vocab entries used to tokenize
+// corpus. Token IDs are unchanged, so it feeds the same model, and corpus
+// text tokenizes exactly as before; other words may fall back to shorter
+// pieces or [UNK] and embed less accurately. For constrained deployments
+// with a known domain, e.g. an FAQ.
+func (t *WordPieceTokenizer) Prune(corpus []string) *WordPieceTokenizer {
+	t.load()
+	keep := make(map[int32]bool)
+	for _, text := range corpus {
+		for _, id := range t.Tokenize(text, 0).InputIDs {
+			keep[id] = true
+		}
+	}
+	p := &WordPieceTokenizer{
+		vocab:  make(map[string]int32, len(keep)),
+		cache:  newWordCache(t.cache.size()),
+		keep:   t.keep,
+		unused: t.unused,
 	}
-	return &WordPieceTokenizer{vocab: vocab, maxToken: maxToken}
+	for tok, id := range t.vocab {
+		if keep[id] {
+			p.vocab[tok] = id
+			p.maxToken = max(p.maxToken, len(tok))
+		}
+	}
+	return p
 }
 
 type TokenizeResult struct {
 	InputIDs      []int32
 	AttentionMask []int32
 	TokenTypeIDs  []int32
+	Dropped       int // tokens cut to fit maxLen
 }
 
 // Tokenize converts text into BERT token IDs with [CLS] and [SEP].
 func (t *WordPieceTokenizer) Tokenize(text string, maxLen int) TokenizeResult {
-	cleaned := t.preprocess(text)
-	words := strings.Fields(cleaned)
-
-	ids := make([]int32, 0, len(words)*2+2)
-	ids = append(ids, clsTokenID)
-
-	for _, word := range words {
-		ids = append(ids, t.wordPiece(word)...)
+	t.load()
+	var ids []int32
+	if t.keep == nil {
+		ids = t.appendWords(append(make([]int32, 0, len(text)/2+2), clsTokenID), text)
+	} else {
+		ids = []int32{clsTokenID}
+		t.segments(text, func(seg string, _ int, kept bool) {
+			if kept {
+				ids = append(ids, t.keptID(seg))
+			} else {
+				ids = t.appendWords(ids, seg)
+			}
+		})
 	}
 
+	dropped := 0
 	if maxLen > 0 && len(ids) >= maxLen {
+		dropped = len(ids) - (maxLen - 1)
 		ids = ids[:maxLen-1]
 	}
 	ids = append(ids, sepTokenID)
@@ -70,7 +144,16 @@ func (t *WordPieceTokenizer) Tokenize(text string, maxLen int) TokenizeResult {
 		InputIDs:      ids,
 		AttentionMask: mask,
 		TokenTypeIDs:  typeIDs,
+		Dropped:       dropped,
+	}
+}
+
+// appendWords appends the word pieces of text to ids.
+func (t *WordPieceTokenizer) appendWords(ids []int32, text string) []int32 {
+	for _, word := range strings.Fields(t.preprocess(text)) {
+		ids = append(ids, t.wordPiece(word)...)
 	}
+	return ids
 }
 
 // PadTo pads to exactly n tokens.
@@ -82,16 +165,106 @@ func (r *TokenizeResult) PadTo(n int) {
 	}
 }
 
+// Span is the byte range text[Start:End] of the original text a token
+// came from. Every piece of a split word gets the whole word's span, and
+// [CLS] and [SEP] get an empty one.
+type Span struct{ Start, End int }
+
+// TokenizeSpans is Tokenize that also returns the span of each token, by
+// position, for mapping token-level output back onto the text.
+func (t *WordPieceTokenizer) TokenizeSpans(text string, maxLen int) (TokenizeResult, []Span) {
+	t.load()
+	ids := []int32{clsTokenID}
+	spans := []Span{{}}
+	words := func(seg string, off int) {
+		for _, w := range wordSpans(seg) {
+			for _, id := range t.wordPiece(w.word) {
+				ids = append(ids, id)
+				spans = append(spans, Span{off + w.span.Start, off + w.span.End})
+			}
+		}
+	}
+	if t.keep == nil {
+		words(text, 0)
+	} else {
+		t.segments(text, func(seg string, off int, kept bool) {
+			if kept {
+				ids = append(ids, t.keptID(seg))
+				spans = append(spans, Span{off, off + len(seg)})
+			} else {
+				words(seg, off)
+			}
+		})
+	}
+	dropped := 0
+	if maxLen > 0 && len(ids) >= maxLen {
+		dropped = len(ids) - (maxLen - 1)
+		ids, spans = ids[:maxLen-1], spans[:maxLen-1]
+	}
+	ids = append(ids, sepTokenID)
+	spans = append(spans, Span{})
+
+	n := len(ids)
+	mask := make([]int32, n)
+	for i := range mask {
+		mask[i] = 1
+	}
+	return TokenizeResult{InputIDs: ids, AttentionMask: mask, TokenTypeIDs: make([]int32, n), Dropped: dropped}, spans
+}
+
+type spannedWord struct {
+	word string
+	span Span
+}
+
+// wordSpans splits text into the words preprocess and strings.Fields
+// would, keeping where each came from. Runes are folded one at a time,
+// which is what FoldAccents does; a combining mark folds to nothing and
+// stays part of its word.
+func wordSpans(text string) []spannedWord {
+	var words []spannedWord
+	var cur strings.Builder
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			words = append(words, spannedWord{cur.String(), Span{start, end}})
+			cur.Reset()
+			start = -1
+		}
+	}
+	for i, r := range text {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		end := i + size
+		folded := hdcx.FoldAccents(string(r))
+		if folded == "" && start >= 0 {
+			continue
+		}
+		for _, f := range folded {
+			switch {
+			case isPunctuation(f):
+				flush(i)
+				words = append(words, spannedWord{string(f), Span{i, end}})
+			case unicode.IsSpace(f) || isControl(f):
+				flush(i)
+			default:
+				if start < 0 {
+					start = i
+				}
+				cur.WriteRune(f)
+			}
+		}
+	}
+	flush(len(text))
+	return words
+}
+
 func (t *WordPieceTokenizer) preprocess(text string) string {
-	text = strings.ToLower(text)
+	text = hdcx.FoldAccents(text)
 
 	var b strings.Builder
 	b.Grow(len(text) + 32)
 
 	for _, r := range text {
-		if unicode.In(r, unicode.Mn) {
-			continue // strip accents
-		}
 		if isPunctuation(r) {
 			b.WriteByte(' ')
 			b.WriteRune(r)
@@ -107,10 +280,19 @@ func (t *WordPieceTokenizer) preprocess(text string) string {
 }
 
 func (t *WordPieceTokenizer) wordPiece(word string) []int32 {
-	if _, ok := t.vocab[word]; ok {
-		return []int32{t.vocab[word]}
+	if id, ok := t.vocab[word]; ok {
+		return []int32{id}
 	}
+	if ids, ok := t.cache.get(word); ok {
+		return ids
+	}
+	ids := t.split(word)
+	t.cache.put(word, ids)
+	return ids
+}
 
+// split runs the greedy longest-match search for a word not in the vocab.
+func (t *WordPieceTokenizer) split(word string) []int32 {
 	runes := []rune(word)
 	ids := make([]int32, 0, 4)
 	start := 0
@@ -1,6 +1,8 @@
 package embed
 
 import (
+	"slices"
+	"sync"
 	"testing"
 )
 
@@ -12,13 +14,17 @@ func newTestTokenizer() *WordPieceTokenizer {
 
 func TestNewWordPieceTokenizer_LoadsVocab(t *testing.T) {
 	tok := newTestTokenizer()
-	if len(tok.vocab) < 30000 {
-		t.Fatalf("expected ~30522 vocab entries, got %d", len(tok.vocab))
+	if tok.vocab != nil {
+		t.Fatal("vocab must be parsed lazily")
+	}
+	if n := tok.VocabSize(); n < 30000 {
+		t.Fatalf("expected ~30522 vocab entries, got %d", n)
 	}
 }
 
 func TestNewWordPieceTokenizer_SpecialTokens(t *testing.T) {
 	tok := newTestTokenizer()
+	tok.load()
 	checks := map[string]int32{
 		"[PAD]": 0,
 		"[UNK]": 100,
@@ -95,6 +101,16 @@ func TestTokenize_CaseInsensitive(t *testing.T) {
 	}
 }
 
+func TestTokenize_AccentInsensitive(t *testing.T) {
+	tok := newTestTokenizer()
+	want := tok.Tokenize("cafe creme", 0).InputIDs
+	for _, text := range []string{"Café Crème", "cafe\u0301 cre\u0300me"} {
+		if got := tok.Tokenize(text, 0).InputIDs; !slices.Equal(got, want) {
+			t.Fatalf("%q: got %v, want %v", text, got, want)
+		}
+	}
+}
+
 // ── punctuation handling ──────────────────────────────────────────────────────
 
 func TestTokenize_PunctuationSeparated(t *testing.T) {
@@ -138,6 +154,13 @@ func TestTokenize_Truncation(t *testing.T) {
 		t.Fatalf("truncation to maxLen=8 failed, got %d tokens", len(res.InputIDs))
 	}
 
+	if res.Dropped != 3 {
+		t.Fatalf("10 tokens cut to 7 must drop 3, got %d", res.Dropped)
+	}
+	if full := tok.Tokenize("the quick brown fox", 8); full.Dropped != 0 {
+		t.Fatalf("short text dropped %d tokens", full.Dropped)
+	}
+
 	// Must still end with [SEP]
 	last := res.InputIDs[len(res.InputIDs)-1]
 	if last != sepTokenID {
@@ -204,10 +227,142 @@ func TestTokenize_EmptyString(t *testing.T) {
 	}
 }
 
+// ── spans ─────────────────────────────────────────────────────────────────────
+
+func TestTokenizeSpans(t *testing.T) {
+	tok := newTestTokenizer()
+	for _, text := range []string{
+		"What is the capital of India?",
+		"  Café  de\tl'Éte\u0301 — unaffordable!! ",
+		"naïve tokenization\x00here",
+		"",
+	} {
+		want := tok.Tokenize(text, 0)
+		got, spans := tok.TokenizeSpans(text, 0)
+		if !slices.Equal(want.InputIDs, got.InputIDs) {
+			t.Fatalf("%q: ids %v, Tokenize gives %v", text, got.InputIDs, want.InputIDs)
+		}
+		if len(spans) != len(got.InputIDs) {
+			t.Fatalf("%q: %d spans for %d tokens", text, len(spans), len(got.InputIDs))
+		}
+		for i, sp := range spans {
+			if sp.Start < 0 || sp.End < sp.Start || sp.End > len(text) {
+				t.Fatalf("%q: token %d span %v out of range", text, i, sp)
+			}
+		}
+	}
+
+	text := "Hello, unaffordable world"
+	_, spans := tok.TokenizeSpans(text, 0)
+	var words []string
+	for _, sp := range spans[1 : len(spans)-1] {
+		words = append(words, text[sp.Start:sp.End])
+	}
+	if len(words) < 5 {
+		t.Fatalf("unaffordable should split into pieces, got %q", words)
+	}
+	words = slices.Compact(words) // the pieces share their word's span
+	if want := []string{"Hello", ",", "unaffordable", "world"}; !slices.Equal(words, want) {
+		t.Fatalf("spans cover %q, want %q", words, want)
+	}
+
+	res, spans := tok.TokenizeSpans(text, 4)
+	if len(res.InputIDs) != 4 || len(spans) != 4 || spans[3] != (Span{}) || res.Dropped == 0 {
+		t.Fatalf("truncated to %d ids, %d spans (last %v)", len(res.InputIDs), len(spans), spans[len(spans)-1])
+	}
+}
+
+// ── pruning ───────────────────────────────────────────────────────────────────
+
+func TestPrune(t *testing.T) {
+	full := newTestTokenizer()
+	corpus := []string{"What is the capital of India?", "unaffordable tokenization"}
+	p := full.Prune(corpus)
+
+	if n := p.VocabSize(); n == 0 || n > 20 {
+		t.Fatalf("pruned vocab has %d entries", n)
+	}
+	for _, text := range corpus {
+		want, got := full.Tokenize(text, 0).InputIDs, p.Tokenize(text, 0).InputIDs
+		if !slices.Equal(want, got) {
+			t.Fatalf("%q: pruned tokenizer gives %v, full %v", text, got, want)
+		}
+	}
+	res := p.Tokenize("zebra", 0)
+	if res.InputIDs[1] != unkTokenID {
+		t.Fatalf("word outside the corpus must be [UNK], got %v", res.InputIDs)
+	}
+}
+
+// ── word cache ────────────────────────────────────────────────────────────────
+
+func TestWordCache(t *testing.T) {
+	tok := newTestTokenizer()
+	uncached := newTestTokenizer()
+	uncached.SetWordCache(0)
+
+	text := "unaffordable tokenization of unaffordable words"
+	want := uncached.Tokenize(text, 0).InputIDs
+	for i := 0; i < 3; i++ {
+		if got := tok.Tokenize(text, 0).InputIDs; !slices.Equal(got, want) {
+			t.Fatalf("round %d: cached %v, uncached %v", i, got, want)
+		}
+	}
+	if _, ok := tok.cache.get("unaffordable"); !ok {
+		t.Fatal("split word must be cached")
+	}
+	if _, ok := tok.cache.get("of"); ok {
+		t.Fatal("whole-vocab words need no cache entry")
+	}
+}
+
+func TestWordCache_Evicts(t *testing.T) {
+	c := newWordCache(2)
+	c.put("a", []int32{1})
+	c.put("b", []int32{2})
+	c.get("a")
+	c.put("c", []int32{3})
+	if _, ok := c.get("b"); ok {
+		t.Fatal("least recently used word must be evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("recently used word must stay")
+	}
+	if c.lru.Len() != 2 || len(c.index) != 2 {
+		t.Fatalf("cache holds %d/%d entries", c.lru.Len(), len(c.index))
+	}
+}
+
+func TestWordCache_Concurrent(t *testing.T) {
+	tok := newTestTokenizer()
+	tok.SetWordCache(8)
+	texts := []string{"unaffordable tokenization", "hyperdimensional computing", "xordb caches embeddings", "antidisestablishmentarianism"}
+	want := make([][]int32, len(texts))
+	for i, text := range texts {
+		want[i] = newTestTokenizer().Tokenize(text, 0).InputIDs
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				k := (g + i) % len(texts)
+				if got := tok.Tokenize(texts[k], 0).InputIDs; !slices.Equal(got, want[k]) {
+					t.Errorf("%q: got %v, want %v", texts[k], got, want[k])
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
 // ── benchmarks ────────────────────────────────────────────────────────────────
 
 func BenchmarkTokenize_Short(b *testing.B) {
 	tok := newTestTokenizer()
+	tok.load()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		tok.Tokenize("what is the capital of india", 128)
@@ -216,9 +371,67 @@ func BenchmarkTokenize_Short(b *testing.B) {
 
 func BenchmarkTokenize_Medium(b *testing.B) {
 	tok := newTestTokenizer()
+	tok.load()
 	text := "the quick brown fox jumps over the lazy dog and the cow jumped over the moon"
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		tok.Tokenize(text, 128)
 	}
 }
+
+func benchmarkSubwords(b *testing.B, cache int) {
+	tok := newTestTokenizer()
+	tok.SetWordCache(cache)
+	tok.load()
+	text := "unaffordable hyperparameters for tokenization of multilingual embeddings"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tok.Tokenize(text, 128)
+	}
+}
+
+func BenchmarkTokenize_Subwords(b *testing.B)             { benchmarkSubwords(b, defaultWordCache) }
+func BenchmarkTokenize_Subwords_NoWordCache(b *testing.B) { benchmarkSubwords(b, 0) }
+
+// ── keep whole ────────────────────────────────────────────────────────────────
+
+func TestSetKeepWhole(t *testing.T) {
+	tok := newTestTokenizer()
+	tok.SetKeepWhole(PatternURL, PatternEmail, PatternUUID, PatternIdentifier)
+
+	text := "mail ops@example.com about 123e4567-e89b-12d3-a456-426614174000 at https://x.io/a?b=1 in get_user_by_id"
+	res, spans := tok.TokenizeSpans(text, 0)
+	if want := tok.Tokenize(text, 0).InputIDs; !slices.Equal(res.InputIDs, want) {
+		t.Fatalf("TokenizeSpans ids %v, Tokenize %v", res.InputIDs, want)
+	}
+	var kept []string
+	for i, id := range res.InputIDs {
+		if id > 0 && id < 999 && id != unkTokenID && id != clsTokenID && id != sepTokenID {
+			kept = append(kept, text[spans[i].Start:spans[i].End])
+		}
+	}
+	want := []string{"ops@example.com", "123e4567-e89b-12d3-a456-426614174000", "https://x.io/a?b=1", "get_user_by_id"}
+	if !slices.Equal(kept, want) {
+		t.Fatalf("kept %q, want %q", kept, want)
+	}
+	// words between matches tokenize as before
+	if n := len(res.InputIDs); n != 2+len(want)+4 { // mail, about, at, in
+		t.Fatalf("got %d tokens: %v", n, res.InputIDs)
+	}
+
+	a := tok.Tokenize("see 123E4567-E89B-12D3-A456-426614174000", 0).InputIDs
+	b := tok.Tokenize("see 123e4567-e89b-12d3-a456-426614174000", 0).InputIDs
+	c := tok.Tokenize("see 00000000-e89b-12d3-a456-426614174000", 0).InputIDs
+	if !slices.Equal(a, b) || slices.Equal(b, c) {
+		t.Fatalf("kept IDs: %v %v %v", a, b, c)
+	}
+
+	p := tok.Prune([]string{"hello"})
+	if got := p.Tokenize("hello https://x.io", 0).InputIDs; len(got) != 4 {
+		t.Fatalf("pruned tokenizer must keep the patterns, got %v", got)
+	}
+	tok.SetKeepWhole()
+	if got := tok.Tokenize("https://x.io", 0).InputIDs; len(got) < 6 {
+		t.Fatalf("no patterns must split again, got %v", got)
+	}
+}
@@ -0,0 +1,87 @@
+package embed
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// Tuning is how an encoder's inference uses the CPU. ONNX Runtime runs
+// its own thread pools next to the Go scheduler, and by default each
+// session's intra-op pool takes every core: several encoders serving
+// concurrent requests then run cores × encoders threads and slow each
+// other down. An encoder runs one text at a time, so throughput comes from
+// running Encoders of them (sharing WithProjector and WithTokenizer) with
+// few threads each, and single-request latency from one with many.
+type Tuning struct {
+	IntraOpThreads int // threads per inference; 0 = ONNX Runtime's default
+	InterOpThreads int // threads across independent graph nodes; 0 = default
+	Encoders       int // encoders worth running in parallel, advisory
+}
+
+// Short texts finish in a few milliseconds, where a thread pool's handoff
+// costs more than splitting the work saves.
+const shortInfer = 5 * time.Millisecond
+
+// SuggestTuning splits cpus between the Go scheduler and inference for a
+// server encoding concurrent requests whose inference takes meanInfer
+// (0 if unknown; see EncoderStats.InferTime). A quarter of the cores, at
+// least one, is left for Go; the rest go to encoders of 2 threads each
+// for short inferences and 4 for longer ones, where a thread pool pays
+// off. One core gets one single-threaded encoder.
+func SuggestTuning(cpus int, meanInfer time.Duration) Tuning {
+	if cpus <= 1 {
+		return Tuning{IntraOpThreads: 1, InterOpThreads: 1, Encoders: 1}
+	}
+	free := cpus - max(1, cpus/4)
+	per := 4
+	if meanInfer > 0 && meanInfer < shortInfer {
+		per = 2
+	}
+	per = min(per, free)
+	return Tuning{IntraOpThreads: per, InterOpThreads: 1, Encoders: max(1, free/per)}
+}
+
+// SuggestTuning is SuggestTuning for this machine's cores and e's mean
+// inference time so far.
+func (e *MiniLMEncoder) SuggestTuning() Tuning {
+	var mean time.Duration
+	if s := e.Stats(); s.Encodes > 0 {
+		mean = s.InferTime / time.Duration(s.Encodes)
+	}
+	return SuggestTuning(runtime.NumCPU(), mean)
+}
+
+// WithTuning sizes the ONNX Runtime thread pools of the encoder's session,
+// and of sessions Reload creates, e.g. to SuggestTuning's numbers. Encoders
+// is up to the caller.
+func WithTuning(t Tuning) EncoderOption {
+	return func(c *encoderConfig) { c.tuning = t }
+}
+
+// sessionOptions returns ONNX Runtime options for t, or nil for defaults.
+func (t Tuning) sessionOptions() (*ort.SessionOptions, error) {
+	if t.IntraOpThreads < 0 || t.InterOpThreads < 0 {
+		return nil, fmt.Errorf("embed: thread counts must not be negative")
+	}
+	if t.IntraOpThreads == 0 && t.InterOpThreads == 0 {
+		return nil, nil
+	}
+	o, err := ort.NewSessionOptions()
+	if err != nil {
+		return nil, fmt.Errorf("embed: creating session options: %w", err)
+	}
+	if t.IntraOpThreads > 0 {
+		err = o.SetIntraOpNumThreads(t.IntraOpThreads)
+	}
+	if err == nil && t.InterOpThreads > 0 {
+		err = o.SetInterOpNumThreads(t.InterOpThreads)
+	}
+	if err != nil {
+		o.Destroy()
+		return nil, fmt.Errorf("embed: setting thread counts: %w", err)
+	}
+	return o, nil
+}
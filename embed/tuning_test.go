@@ -0,0 +1,36 @@
+package embed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuggestTuning(t *testing.T) {
+	cases := []struct {
+		cpus int
+		mean time.Duration
+		want Tuning
+	}{
+		{1, 0, Tuning{1, 1, 1}},
+		{2, 0, Tuning{1, 1, 1}},
+		{4, 0, Tuning{3, 1, 1}},
+		{8, 0, Tuning{4, 1, 1}},
+		{8, 2 * time.Millisecond, Tuning{2, 1, 3}},
+		{16, 20 * time.Millisecond, Tuning{4, 1, 3}},
+		{64, time.Millisecond, Tuning{2, 1, 24}},
+	}
+	for _, c := range cases {
+		if got := SuggestTuning(c.cpus, c.mean); got != c.want {
+			t.Errorf("SuggestTuning(%d, %v) = %+v, want %+v", c.cpus, c.mean, got, c.want)
+		}
+	}
+}
+
+func TestTuning_NegativeThreads(t *testing.T) {
+	if _, err := (Tuning{IntraOpThreads: -1}).sessionOptions(); err == nil {
+		t.Fatal("negative thread count must fail")
+	}
+	if o, err := (Tuning{}).sessionOptions(); o != nil || err != nil {
+		t.Fatalf("zero Tuning must mean defaults, got %v %v", o, err)
+	}
+}
@@ -0,0 +1,62 @@
+package embed
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Reloader is what WatchModel reloads; *MiniLMEncoder implements it.
+type Reloader interface {
+	Reload(modelPath string) error
+}
+
+// WatchModel polls modelPath every interval and calls enc.Reload when the
+// file's size or modification time changes, until ctx is done. Replace the
+// file by renaming a finished copy over it: a half-written file fails the
+// reload and is retried once it changes again. Reload errors go to onErr
+// if it is not nil; a missing file is skipped until it reappears. Run it
+// on its own goroutine:
+//
+//	go embed.WatchModel(ctx, enc, path, 10*time.Second, func(err error) { log.Print(err) })
+func WatchModel(ctx context.Context, enc Reloader, modelPath string, interval time.Duration, onErr func(error)) {
+	if interval <= 0 {
+		panic("embed: WatchModel interval must be positive")
+	}
+	report := func(err error) {
+		if onErr != nil {
+			onErr(err)
+		}
+	}
+	seen := stamp(modelPath) // the model the encoder was built from
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		now := stamp(modelPath)
+		if now == seen || now == (fileStamp{}) {
+			continue // unchanged, or missing mid-replace
+		}
+		seen = now
+		if err := enc.Reload(modelPath); err != nil {
+			report(err)
+		}
+	}
+}
+
+type fileStamp struct {
+	size int64
+	mod  time.Time
+}
+
+func stamp(path string) fileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{fi.Size(), fi.ModTime()}
+}
@@ -0,0 +1,81 @@
+package embed
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeReloader struct {
+	mu    sync.Mutex
+	paths []string
+	err   error
+}
+
+func (f *fakeReloader) Reload(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paths = append(f.paths, path)
+	return f.err
+}
+
+func (f *fakeReloader) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.paths)
+}
+
+func TestWatchModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.onnx")
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	enc := &fakeReloader{err: errors.New("bad model")}
+	var errs []error
+	var mu sync.Mutex
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		WatchModel(ctx, enc, path, 5*time.Millisecond, func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		})
+		close(done)
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	if n := enc.count(); n != 0 {
+		t.Fatalf("unchanged file reloaded %d times", n)
+	}
+	if err := os.WriteFile(path, []byte("version 2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for enc.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	<-done
+
+	if n := enc.count(); n != 1 {
+		t.Fatalf("one change must reload once, got %d", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 1 {
+		t.Fatalf("reload errors reported: %v", errs)
+	}
+}
+
+func TestReload_MissingFile(t *testing.T) {
+	e := &MiniLMEncoder{rt: &inference{}}
+	if err := e.Reload(filepath.Join(t.TempDir(), "none.onnx")); err == nil {
+		t.Fatal("reloading a missing model must fail")
+	}
+}
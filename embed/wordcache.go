@@ -0,0 +1,73 @@
+package embed
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultWordCache = 4096 // words; see SetWordCache
+	maxCachedWord    = 64   // longer words (hashes, blobs) are not cached
+)
+
+// wordCache is an LRU of word → WordPiece IDs, shared by concurrent
+// Tokenize calls. Cached slices are never modified.
+type wordCache struct {
+	mu    sync.Mutex
+	max   int
+	lru   *list.List // of *wordIDs, most recent first
+	index map[string]*list.Element
+}
+
+type wordIDs struct {
+	word string
+	ids  []int32
+}
+
+// newWordCache returns a cache of n words, or nil (no caching) if n <= 0.
+func newWordCache(n int) *wordCache {
+	if n <= 0 {
+		return nil
+	}
+	return &wordCache{max: n, lru: list.New(), index: make(map[string]*list.Element, n)}
+}
+
+func (c *wordCache) get(word string) ([]int32, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.index[word]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*wordIDs).ids, true
+}
+
+func (c *wordCache) put(word string, ids []int32) {
+	if c == nil || len(word) > maxCachedWord {
+		return
+	}
+	word = strings.Clone(word) // don't pin the query text
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.index[word]; ok {
+		return // a concurrent call got there first
+	}
+	if c.lru.Len() >= c.max {
+		old := c.lru.Back()
+		delete(c.index, old.Value.(*wordIDs).word)
+		c.lru.Remove(old)
+	}
+	c.index[word] = c.lru.PushFront(&wordIDs{word, ids})
+}
+
+func (c *wordCache) size() int {
+	if c == nil {
+		return 0
+	}
+	return c.max
+}
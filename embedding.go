@@ -0,0 +1,51 @@
+package xordb
+
+import (
+	"errors"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// EmbeddingProjector is implemented by encoders that produce vectors from
+// float embeddings, e.g. embed.MiniLMEncoder, which projects its model's
+// output by random hyperplanes. A DB built with such an encoder accepts raw
+// embeddings through SetEmbedding and GetByEmbedding.
+type EmbeddingProjector interface {
+	// ProjectEmbedding maps emb into the encoder's vector space, as Encode
+	// would a text with that embedding.
+	ProjectEmbedding(emb []float32) (hdc.Vector, error)
+}
+
+var errNoProjector = errors.New("xordb: encoder does not implement EmbeddingProjector")
+
+// SetEmbedding stores value under key using an embedding the caller has
+// already computed, e.g. in an existing vector pipeline, instead of running
+// the encoder's model again. The DB's encoder must be an
+// EmbeddingProjector, and emb must come from the same model it runs.
+func (db *DB) SetEmbedding(key string, value any, emb []float32) error {
+	vec, err := db.project(emb)
+	if err != nil {
+		return err
+	}
+	return db.SetVec(vec, key, value)
+}
+
+// GetByEmbedding is Lookup for a precomputed query embedding; see
+// SetEmbedding.
+func (db *DB) GetByEmbedding(emb []float32) (Result, error) {
+	vec, err := db.project(emb)
+	if err != nil {
+		return Result{}, err
+	}
+	return db.GetVec(vec)
+}
+
+func (db *DB) project(emb []float32) (hdc.Vector, error) {
+	if err := db.checkOpen(); err != nil {
+		return hdc.Vector{}, err
+	}
+	if db.proj == nil {
+		return hdc.Vector{}, errNoProjector
+	}
+	return db.proj.ProjectEmbedding(emb)
+}
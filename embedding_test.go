@@ -0,0 +1,50 @@
+package xordb_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+)
+
+// projEncoder projects embeddings like embed.MiniLMEncoder; text encodes to
+// the zero vector.
+type projEncoder struct{ p *hdc.Projector }
+
+func (e projEncoder) Encode(string) hdc.Vector { return hdc.New(1024) }
+
+func (e projEncoder) ProjectEmbedding(emb []float32) (hdc.Vector, error) {
+	return e.p.ProjectFloat(emb), nil
+}
+
+func TestDB_SetEmbedding(t *testing.T) {
+	db := xordb.NewWithEncoder(projEncoder{hdc.NewProjector(8, 1024, 1)}, xordb.WithThreshold(0.9))
+	emb := []float32{0.3, -0.1, 0.8, 0.2, -0.5, 0.1, 0.4, -0.2}
+	if err := db.SetEmbedding("doc", "answer", emb); err != nil {
+		t.Fatal(err)
+	}
+
+	near := append([]float32(nil), emb...)
+	near[0] += 0.05
+	r, err := db.GetByEmbedding(near)
+	if err != nil || !r.Hit || r.Value != "answer" {
+		t.Fatalf("a nearby embedding must hit: %+v, %v", r, err)
+	}
+	opposite := make([]float32, len(emb))
+	for i, x := range emb {
+		opposite[i] = -x
+	}
+	if r, _ := db.GetByEmbedding(opposite); r.Hit {
+		t.Fatalf("an opposite embedding must miss: %+v", r)
+	}
+}
+
+func TestDB_SetEmbedding_NoProjector(t *testing.T) {
+	db := xordb.New()
+	if err := db.SetEmbedding("k", 1, []float32{1}); err == nil {
+		t.Fatal("the n-gram encoder cannot project embeddings")
+	}
+	if _, err := db.GetByEmbedding([]float32{1}); err == nil {
+		t.Fatal("the n-gram encoder cannot project embeddings")
+	}
+}
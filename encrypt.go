@@ -0,0 +1,166 @@
+package xordb
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// WithValueEncryption encrypts values with AES-GCM wherever they are
+// written to disk — snapshots (Save, SaveDelta, SaveSharded,
+// WriteSnapshot), the WithColdTier file and WithBlobDir spool files —
+// while keys and vectors stay in the clear, so similarity search and
+// OpenMapped work unchanged. key must be 16, 24 or 32 bytes (AES-128, -192
+// or -256); the same key is needed to load the snapshots, and loading an
+// encrypted snapshot without it, or with another, fails. Snapshots
+// written before encryption was turned on still load. Values in memory,
+// exports, audits and the replication stream are not encrypted.
+func WithValueEncryption(key []byte) Option {
+	vc, err := newValueCipher(key)
+	if err != nil {
+		panic(err.Error())
+	}
+	return func(o *dbOptions) { o.cipher = vc }
+}
+
+// valueCipher seals values with AES-GCM under a random nonce, which
+// prefixes the ciphertext.
+type valueCipher struct{ aead cipher.AEAD }
+
+func newValueCipher(key []byte) (*valueCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("xordb: value encryption key must be 16, 24 or 32 bytes, got %d", len(key))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("xordb: value encryption: %w", err)
+	}
+	return &valueCipher{aead: aead}, nil
+}
+
+// overhead is how many bytes sealing adds.
+func (c *valueCipher) overhead() int { return c.aead.NonceSize() + c.aead.Overhead() }
+
+// seal encrypts plain, authenticating ad with it.
+func (c *valueCipher) seal(plain, ad []byte) []byte {
+	out := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plain)+c.aead.Overhead())
+	if _, err := rand.Read(out); err != nil {
+		panic("xordb: reading random nonce: " + err.Error())
+	}
+	return c.aead.Seal(out, out, plain, ad)
+}
+
+// errSealed is returned when a value does not decrypt.
+var errSealed = errors.New("value does not decrypt: wrong key or corrupt data")
+
+func (c *valueCipher) open(sealed, ad []byte) ([]byte, error) {
+	n := c.aead.NonceSize()
+	if len(sealed) < n {
+		return nil, errSealed
+	}
+	plain, err := c.aead.Open(nil, sealed[:n], sealed[n:], ad)
+	if err != nil {
+		return nil, errSealed
+	}
+	return plain, nil
+}
+
+// sealedField is the one field of the JSON object an encrypted snapshot
+// value is stored as, the base64 of its sealed JSON encoding.
+const sealedField = "$xordb_sealed"
+
+// sealedValue is a snapshot value encrypted by sealSnapshot.
+type sealedValue []byte
+
+func (s sealedValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string][]byte{sealedField: s})
+}
+
+// sealSnapshot returns snap with every value encrypted, authenticated
+// with its key so values cannot be swapped between entries.
+func (c *valueCipher) sealSnapshot(snap cache.Snapshot) (cache.Snapshot, error) {
+	es := make([]cache.EntrySnapshot, len(snap.Entries))
+	for i, e := range snap.Entries {
+		data, err := json.Marshal(e.Value)
+		if err != nil {
+			return snap, fmt.Errorf("value of %q: %w", e.Key, err)
+		}
+		e.Value = sealedValue(c.seal(data, []byte(e.Key)))
+		es[i] = e
+	}
+	snap.Entries = es
+	return snap, nil
+}
+
+// openSnapshot decrypts the values of a decoded snapshot in place. c may
+// be nil, which fails on the first encrypted value.
+func (c *valueCipher) openSnapshot(snap *cache.Snapshot) error {
+	for i := range snap.Entries {
+		e := &snap.Entries[i]
+		v, ok, err := c.openValue(e.Key, e.Value)
+		if err != nil {
+			return fmt.Errorf("value of %q: %w", e.Key, err)
+		}
+		if ok {
+			e.Value = v
+		}
+	}
+	return nil
+}
+
+// openValue decrypts v, a value decoded from a snapshot, if it is sealed,
+// reporting whether it was.
+func (c *valueCipher) openValue(key string, v any) (any, bool, error) {
+	m, ok := v.(map[string]any)
+	if !ok || len(m) != 1 {
+		return v, false, nil
+	}
+	s, ok := m[sealedField].(string)
+	if !ok {
+		return v, false, nil
+	}
+	if c == nil {
+		return nil, true, errors.New("value is encrypted; see WithValueEncryption")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, true, errSealed
+	}
+	data, err := c.open(sealed, []byte(key))
+	if err != nil {
+		return nil, true, err
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, true, err
+	}
+	return out, true, nil
+}
+
+// blobChunk is the plaintext size of each separately sealed piece of an
+// encrypted Blob, so ReadAt decrypts only the pieces it reads.
+const blobChunk = 64 << 10
+
+// chunkAD authenticates a Blob chunk's position, so chunks cannot be
+// reordered.
+func chunkAD(i int64) []byte { return binary.LittleEndian.AppendUint64(nil, uint64(i)) }
+
+// sealChunks encrypts data as a sequence of blobChunk pieces.
+func (c *valueCipher) sealChunks(data []byte) []byte {
+	var out bytes.Buffer
+	for i := int64(0); len(data) > 0 || i == 0; i++ {
+		n := min(len(data), blobChunk)
+		out.Write(c.seal(data[:n], chunkAD(i)))
+		data = data[n:]
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,119 @@
+package xordb_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestDB_WithValueEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	db := xordb.New(xordb.WithValueEncryption(key))
+	db.Set("what is the capital of france", "the answer is paris")
+	db.Set("who wrote war and peace", answer{Text: "tolstoy", Tokens: 3})
+
+	path := filepath.Join(t.TempDir(), "sealed.xrdb")
+	if err := db.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("paris")) || bytes.Contains(raw, []byte("tolstoy")) {
+		t.Error("snapshot contains a plaintext value")
+	}
+	if !bytes.Contains(raw, []byte("what is the capital of france")) {
+		t.Error("snapshot lost the plaintext key")
+	}
+
+	loaded := xordb.New(xordb.WithValueEncryption(key))
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, _ := loaded.Get("what is the capital of france"); !ok || v != "the answer is paris" {
+		t.Errorf("loaded value = %#v, %v", v, ok)
+	}
+	if v, _, _ := loaded.Get("who wrote war and peace"); v.(map[string]any)["Text"] != "tolstoy" {
+		t.Errorf("loaded struct value = %#v", v)
+	}
+
+	if err := xordb.New().Load(path); err == nil {
+		t.Error("loading without the key succeeded")
+	}
+	wrong := xordb.New(xordb.WithValueEncryption(bytes.Repeat([]byte{8}, 32)))
+	if err := wrong.Load(path); err == nil {
+		t.Error("loading with the wrong key succeeded")
+	}
+
+	m, err := xordb.OpenMapped(path, xordb.WithValueEncryption(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if v, ok, _ := m.Get("what is the capital of france"); !ok || v != "the answer is paris" {
+		t.Errorf("mapped value = %#v, %v", v, ok)
+	}
+
+	// Snapshots written in the clear still load.
+	plain := filepath.Join(t.TempDir(), "plain.xrdb")
+	old := xordb.New()
+	old.Set("how tall is mount everest", "8849 metres")
+	if err := old.Save(plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Load(plain); err != nil {
+		t.Fatal(err)
+	}
+	if v, _, _ := loaded.Get("how tall is mount everest"); v != "8849 metres" {
+		t.Errorf("plaintext snapshot value = %#v", v)
+	}
+}
+
+func TestDB_WithValueEncryption_DiskStores(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 16)
+	db := xordb.New(
+		xordb.WithValueEncryption(key),
+		xordb.WithColdTier(1, t.TempDir()),
+		xordb.WithMaxValueBytes(1<<10), xordb.WithBlobDir(t.TempDir()),
+	)
+	db.Set("what is the capital of france", "paris")
+	db.Set("how tall is mount everest", "8849 metres")
+	if v, ok, _ := db.Get("what is the capital of france"); !ok || v != "paris" {
+		t.Errorf("cold value = %#v, %v", v, ok)
+	}
+
+	// Spans several sealed chunks.
+	long := strings.Repeat("a long completion ", 10000)
+	db.Set("summarize the report", long)
+	v, _, _ := db.Get("summarize the report")
+	b, ok := v.(*xordb.Blob)
+	if !ok {
+		t.Fatalf("got %T, want a *Blob", v)
+	}
+	if b.Size() != int64(len(long)) {
+		t.Errorf("Size() = %d, want %d", b.Size(), len(long))
+	}
+	if got, err := io.ReadAll(b.Reader()); err != nil || string(got) != long {
+		t.Errorf("Reader: %d bytes, %v", len(got), err)
+	}
+	p := make([]byte, 100)
+	off := int64(64<<10 - 50)
+	if n, err := b.ReadAt(p, off); n != len(p) || err != nil || string(p) != long[off:off+100] {
+		t.Errorf("ReadAt across chunks = %d, %v", n, err)
+	}
+}
+
+func TestWithValueEncryption_BadKey(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("a 10-byte key must panic")
+		}
+	}()
+	xordb.WithValueEncryption(make([]byte, 10))
+}
@@ -0,0 +1,47 @@
+package xordb
+
+import (
+	"fmt"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// Errors callers can branch on with errors.Is and errors.As rather than by
+// matching messages. Their messages have no package prefix; the errors
+// wrapping them start with that of the package returning them, "xordb:"
+// here. Besides these: ErrClosed after Close, ErrBusy from TryLookup,
+// ErrEncoder for contained encoder failures, ErrWarmingUp while a
+// WithEncoderLoader encoder loads, and, from other packages,
+// hdcx.ErrIncompatible and embed.ErrModelNotFound. Constructors that panic
+// on bad options have error-returning variants, such as TryNewWithEncoder,
+// whose errors use the same causes.
+var (
+	// ErrDimsMismatch is wrapped by errors about vectors of the wrong
+	// size: an encoder disagreeing with WithDims or its fallback, a
+	// SetVec vector, a snapshot of other dims. Most carry a *DimsError.
+	ErrDimsMismatch = cache.ErrDimsMismatch
+
+	// ErrIncompatibleSnapshot is wrapped by Load and the other load
+	// methods for snapshots of another format version, dims or encoder;
+	// loading them would only produce wrong hits.
+	ErrIncompatibleSnapshot = cache.ErrIncompatibleSnapshot
+
+	// ErrEncoderUnavailable is wrapped when the encoder cannot be used:
+	// still loading (ErrWarmingUp), failed to load (WaitForWarmup), or
+	// over WithEncodeBudget with no fallback (Encode).
+	ErrEncoderUnavailable = cache.ErrEncoderUnavailable
+
+	// ErrInvalidOption is wrapped by TryNewWithEncoder errors for options
+	// out of range or that do not work together; New and NewWithEncoder
+	// panic with them.
+	ErrInvalidOption = cache.ErrInvalidOption
+)
+
+// DimsError details a dims mismatch: What had Got dims where Want were
+// expected. It matches ErrDimsMismatch.
+type DimsError = cache.DimsError
+
+// invalidOption returns an error wrapping ErrInvalidOption.
+func invalidOption(msg string) error {
+	return fmt.Errorf("xordb: %w: %s", ErrInvalidOption, msg)
+}
@@ -0,0 +1,48 @@
+package xordb_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestErrors_Taxonomy(t *testing.T) {
+	ngram := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	_, err := xordb.TryNewWithEncoder(ngram, xordb.WithDims(2048))
+	if !errors.Is(err, xordb.ErrDimsMismatch) || !strings.HasPrefix(err.Error(), "xordb: dims mismatch: ") {
+		t.Fatalf("TryNewWithEncoder: %v", err)
+	}
+
+	db := xordb.New()
+	var de *xordb.DimsError
+	if err := db.SetVec(hdc.Random(64, 1), "k", 1); !errors.As(err, &de) || de.Got != 64 || de.Want != 10000 ||
+		!errors.Is(err, xordb.ErrDimsMismatch) {
+		t.Fatalf("SetVec: %v", err)
+	}
+
+	small := xordb.New(xordb.WithDims(512))
+	small.Set("what is the capital of india", "Delhi")
+	path := filepath.Join(t.TempDir(), "small.xdb")
+	if err := small.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	err = db.Load(path)
+	if !errors.Is(err, xordb.ErrIncompatibleSnapshot) || !errors.Is(err, xordb.ErrDimsMismatch) {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if !errors.Is(xordb.ErrWarmingUp, xordb.ErrEncoderUnavailable) {
+		t.Fatal("ErrWarmingUp must be an ErrEncoderUnavailable")
+	}
+	failing := xordb.NewAsync(xordb.WithEncoderLoader(func(context.Context) (hdc.Encoder, error) {
+		return nil, errors.New("no model")
+	}))
+	if err := failing.WaitForWarmup(context.Background()); !errors.Is(err, xordb.ErrEncoderUnavailable) {
+		t.Fatalf("WaitForWarmup: %v", err)
+	}
+}
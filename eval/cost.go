@@ -0,0 +1,160 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// ModelPrice is what one model charges per million tokens, in any currency
+// as long as every price in a Pricing uses the same one.
+type ModelPrice struct {
+	InputPerMTok  float64 `json:"input_per_mtok"`
+	OutputPerMTok float64 `json:"output_per_mtok"`
+
+	// OutputTokens is the completion length assumed for queries whose log
+	// line does not record one; 0 leaves their output unpriced.
+	OutputTokens int `json:"output_tokens,omitempty"`
+}
+
+// Pricing maps model names to prices. The "" entry prices queries whose
+// model is not in the map, including logs that record no model at all.
+type Pricing map[string]ModelPrice
+
+func (p Pricing) price(model string) (ModelPrice, bool) {
+	if mp, ok := p[model]; ok {
+		return mp, true
+	}
+	mp, ok := p[""]
+	return mp, ok
+}
+
+// EstimateTokens guesses the token count of text by the usual rule of thumb
+// of four characters per token for English; CostReport uses it for queries
+// whose log line records no input tokens.
+func EstimateTokens(text string) int {
+	return max(1, (utf8.RuneCountInString(text)+3)/4)
+}
+
+// Savings is the cost of a query log's LLM calls with and without the
+// cache. Every hit is a call saved; a miss costs the same as without the
+// cache, since the cache's own lookups cost no tokens.
+type Savings struct {
+	Queries int
+	Hits    int
+
+	InputTokens, OutputTokens           int64 // of every priced query
+	SavedInputTokens, SavedOutputTokens int64 // of the hits among them
+
+	Cost  float64 // of every priced query: the bill without a cache
+	Saved float64 // of the hits: the bill the cache avoids
+
+	// Unpriced counts queries whose model has no price; they are left out
+	// of the token counts and costs.
+	Unpriced int
+
+	ByModel map[string]ModelSavings // by Query.Model, "" if not recorded
+}
+
+// ModelSavings is one model's share of a Savings.
+type ModelSavings struct {
+	Queries, Hits int
+	Cost, Saved   float64
+}
+
+func (s *Savings) HitRate() float64 { return ratio(s.Hits, s.Queries) }
+
+// SavedFraction is the share of the bill the cache avoids.
+func (s *Savings) SavedFraction() float64 {
+	if s.Cost == 0 {
+		return 0
+	}
+	return s.Saved / s.Cost
+}
+
+// CostReport replays queries against db as RunLog does and prices the
+// calls its hits would have saved. Run it with the capacity, TTL and
+// threshold of the planned deployment, on a log long enough for the cache
+// to warm up, or the estimate will be low.
+func CostReport(db *xordb.DB, queries []Query, pricing Pricing) *Savings {
+	hits := make([]bool, len(queries))
+	runLog(db, queries, func(i int, hit bool) { hits[i] = hit })
+	return pricing.Savings(queries, hits)
+}
+
+// Savings prices queries whose hits, by index, come from a replay done
+// elsewhere, e.g. a paced one or a remote cache. Tokens a query does not
+// record are estimated: input with EstimateTokens, output as its model's
+// ModelPrice.OutputTokens.
+func (p Pricing) Savings(queries []Query, hits []bool) *Savings {
+	if len(hits) != len(queries) {
+		panic("eval: Savings needs one hit flag per query")
+	}
+	s := &Savings{Queries: len(queries), ByModel: make(map[string]ModelSavings)}
+	for i, q := range queries {
+		m := s.ByModel[q.Model]
+		m.Queries++
+		if hits[i] {
+			s.Hits++
+			m.Hits++
+		}
+		mp, ok := p.price(q.Model)
+		if !ok {
+			s.Unpriced++
+			s.ByModel[q.Model] = m
+			continue
+		}
+		in, out := q.InputTokens, q.OutputTokens
+		if in == 0 {
+			in = EstimateTokens(q.Key)
+		}
+		if out == 0 {
+			out = mp.OutputTokens
+		}
+		cost := (float64(in)*mp.InputPerMTok + float64(out)*mp.OutputPerMTok) / 1e6
+		s.InputTokens += int64(in)
+		s.OutputTokens += int64(out)
+		s.Cost += cost
+		m.Cost += cost
+		if hits[i] {
+			s.SavedInputTokens += int64(in)
+			s.SavedOutputTokens += int64(out)
+			s.Saved += cost
+			m.Saved += cost
+		}
+		s.ByModel[q.Model] = m
+	}
+	return s
+}
+
+// WriteText prints the savings with a per-model breakdown.
+func (s *Savings) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "queries\t%d\t(%d hits, %.1f%%)\n", s.Queries, s.Hits, 100*s.HitRate())
+	fmt.Fprintf(tw, "tokens\t%d in, %d out\t(%d in, %d out saved)\n",
+		s.InputTokens, s.OutputTokens, s.SavedInputTokens, s.SavedOutputTokens)
+	fmt.Fprintf(tw, "cost\t%.2f without cache\t%.2f saved (%.1f%%)\n", s.Cost, s.Saved, 100*s.SavedFraction())
+	if s.Unpriced > 0 {
+		fmt.Fprintf(tw, "unpriced\t%d\t(no price for their model)\n", s.Unpriced)
+	}
+	models := make([]string, 0, len(s.ByModel))
+	for m := range s.ByModel {
+		models = append(models, m)
+	}
+	sort.Strings(models)
+	if len(models) > 1 || (len(models) == 1 && models[0] != "") {
+		fmt.Fprintln(tw, "\nmodel\tqueries\thits\tcost\tsaved")
+		for _, name := range models {
+			m := s.ByModel[name]
+			if name == "" {
+				name = "(none)"
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f\t%.2f\n", name, m.Queries, m.Hits, m.Cost, m.Saved)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,81 @@
+package eval_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+)
+
+func TestReadQueryLog_Usage(t *testing.T) {
+	in := `{"query":"a","model":"gpt-4o","input_tokens":120,"output_tokens":300}
+{"query":"b","model":"gpt-4o-mini","usage":{"prompt_tokens":80,"completion_tokens":40}}
+{"query":"c"}
+`
+	qs, err := eval.ReadQueryLog(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []eval.Query{
+		{Key: "a", Model: "gpt-4o", InputTokens: 120, OutputTokens: 300},
+		{Key: "b", Model: "gpt-4o-mini", InputTokens: 80, OutputTokens: 40},
+		{Key: "c"},
+	}
+	for i := range want {
+		if qs[i] != want[i] {
+			t.Errorf("line %d: %+v, want %+v", i+1, qs[i], want[i])
+		}
+	}
+}
+
+func TestCostReport(t *testing.T) {
+	qs := []eval.Query{
+		{Key: "what is the capital of india", Model: "big", InputTokens: 1000, OutputTokens: 500},
+		{Key: "capital city of india", Model: "big", InputTokens: 1000, OutputTokens: 500},
+		{Key: "how to bake a chocolate cake"}, // estimated 7 tokens in, 100 out
+		{Key: "what is the capital of india"},
+		{Key: "unknown", Model: "other"},
+	}
+	pricing := eval.Pricing{
+		"big": {InputPerMTok: 10, OutputPerMTok: 30},
+		"":    {InputPerMTok: 1, OutputPerMTok: 2, OutputTokens: 100},
+	}
+	s := eval.CostReport(xordb.New(xordb.WithThreshold(0.70)), qs, pricing)
+
+	if s.Queries != 5 || s.Hits != 2 || s.Unpriced != 0 {
+		t.Fatalf("got %+v", s)
+	}
+	bigCall := (1000*10 + 500*30) / 1e6
+	smallCall := (7*1 + 100*2) / 1e6
+	unknown := (2*1 + 100*2) / 1e6
+	if !near(s.Cost, 2*bigCall+2*smallCall+unknown) || !near(s.Saved, bigCall+smallCall) {
+		t.Errorf("cost %v, saved %v", s.Cost, s.Saved)
+	}
+	if s.SavedInputTokens != 1007 || s.SavedOutputTokens != 600 {
+		t.Errorf("saved tokens %d in, %d out", s.SavedInputTokens, s.SavedOutputTokens)
+	}
+	if m := s.ByModel["big"]; m.Queries != 2 || m.Hits != 1 || !near(m.Saved, bigCall) {
+		t.Errorf("big: %+v", m)
+	}
+
+	var buf bytes.Buffer
+	s.WriteText(&buf)
+	for _, want := range []string{"40.0%", "(none)", "big", "other"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestPricing_Unpriced(t *testing.T) {
+	qs := []eval.Query{{Key: "a", Model: "x"}, {Key: "b"}}
+	s := eval.Pricing{"x": {InputPerMTok: 1e6}}.Savings(qs, []bool{true, true})
+	if s.Unpriced != 1 || s.Cost != 1 || s.Saved != 1 || s.SavedFraction() != 1 {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-12 }
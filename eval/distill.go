@@ -0,0 +1,298 @@
+package eval
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+// Teacher scores two texts with the expensive model being imitated, e.g.
+// the cosine of their MiniLM embeddings. Only the order of its scores
+// matters, and where they cross DistillConfig.TeacherThreshold.
+type Teacher func(a, b string) float64
+
+// DistillConfig is the search Distill runs. Zero fields take defaults.
+type DistillConfig struct {
+	NGramSizes []int          // default 2, 3, 4, 5
+	Profiles   []hdcx.Profile // default none plus every preset in hdcx.Profiles
+	Stopwords  []int          // corpus-frequent words to drop; default 0, 10, 25, 50
+
+	// Pairs caps the text pairs scored (default 2000); a corpus with more
+	// pairs than that is sampled.
+	Pairs int
+	Seed  int64
+
+	// TeacherThreshold is the teacher score that counts as a hit (default
+	// 0.75); the student's threshold is chosen to agree with it.
+	TeacherThreshold float64
+}
+
+// Distilled is the n-gram configuration that best imitates a teacher.
+type Distilled struct {
+	NGramSize int      `json:"ngram_size"`
+	Profile   string   `json:"profile,omitempty"` // an hdcx.Profiles name, "" for none
+	Stopwords []string `json:"stopwords,omitempty"`
+	Threshold float64  `json:"threshold"`
+
+	// Correlation is the Spearman rank correlation of student and teacher
+	// scores over the sampled pairs; Agreement the fraction of pairs on
+	// which both are on the same side of their thresholds.
+	Correlation float64 `json:"correlation"`
+	Agreement   float64 `json:"agreement"`
+	Pairs       int     `json:"pairs"`
+}
+
+// Options returns the xordb options that build the distilled encoder.
+// Stopwords are removed by a key normalizer, so they also drop out of
+// exact keys.
+func (d Distilled) Options() []xordb.Option {
+	opts := []xordb.Option{xordb.WithThreshold(d.Threshold)}
+	if p, ok := hdcx.Profiles[d.Profile]; ok {
+		opts = append(opts, xordb.WithNormalizationProfile(p))
+	}
+	opts = append(opts, xordb.WithNGramSize(d.NGramSize))
+	if len(d.Stopwords) > 0 {
+		opts = append(opts, xordb.WithKeyNormalizer(stopwordRemover(d.Stopwords)))
+	}
+	return opts
+}
+
+// Distill tunes the built-in n-gram encoder to a corpus, using teacher as
+// ground truth: every combination of n-gram size, normalization profile
+// and stopword count in cfg is scored by how well its similarities rank
+// the sampled pairs of corpus texts the way teacher does, and the best one
+// is returned with the threshold at which it agrees most with teacher's
+// hits. hdc-go's encoder has no per-n-gram weights, so dropping frequent,
+// uninformative words is the weighting it can learn. Each combination
+// encodes the sampled texts once; teacher is called once per pair.
+func Distill(corpus []string, teacher Teacher, cfg DistillConfig) Distilled {
+	if len(cfg.NGramSizes) == 0 {
+		cfg.NGramSizes = []int{2, 3, 4, 5}
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = []hdcx.Profile{{}}
+		for _, name := range sortedProfileNames() {
+			cfg.Profiles = append(cfg.Profiles, hdcx.Profiles[name])
+		}
+	}
+	if len(cfg.Stopwords) == 0 {
+		cfg.Stopwords = []int{0, 10, 25, 50}
+	}
+	if cfg.Pairs <= 0 {
+		cfg.Pairs = 2000
+	}
+	if cfg.TeacherThreshold == 0 {
+		cfg.TeacherThreshold = 0.75
+	}
+
+	idx := samplePairs(len(corpus), cfg.Pairs, cfg.Seed)
+	want := make([]float64, len(idx))
+	for i, p := range idx {
+		want[i] = teacher(corpus[p[0]], corpus[p[1]])
+	}
+	wantRanks := ranks(want)
+	frequent := frequentWords(corpus)
+
+	best := Distilled{Correlation: -2}
+	for _, n := range cfg.NGramSizes {
+		for _, prof := range cfg.Profiles {
+			for _, k := range cfg.Stopwords {
+				stop := frequent[:min(k, len(frequent))]
+				got := studentScores(corpus, idx, n, prof, stop)
+				if r := pearson(ranks(got), wantRanks); r > best.Correlation {
+					best = Distilled{NGramSize: n, Profile: prof.Name, Stopwords: stop, Correlation: r, Pairs: len(idx)}
+					best.Threshold, best.Agreement = agreeingThreshold(got, want, cfg.TeacherThreshold)
+				}
+			}
+		}
+	}
+	return best
+}
+
+func sortedProfileNames() []string {
+	names := make([]string, 0, len(hdcx.Profiles))
+	for name := range hdcx.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// samplePairs returns every pair of n texts, or limit of them at random.
+func samplePairs(n, limit int, seed int64) [][2]int {
+	var out [][2]int
+	if n*(n-1)/2 <= limit {
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				out = append(out, [2]int{i, j})
+			}
+		}
+		return out
+	}
+	rng := rand.New(rand.NewSource(seed))
+	seen := make(map[[2]int]bool, limit)
+	for len(out) < limit {
+		i, j := rng.Intn(n), rng.Intn(n)
+		if i == j {
+			continue
+		}
+		p := [2]int{min(i, j), max(i, j)}
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func studentScores(corpus []string, idx [][2]int, n int, prof hdcx.Profile, stop []string) []float64 {
+	prof.NGramSize = n
+	cfg := hdc.DefaultConfig()
+	cfg.NGramSize = n
+	enc := hdcx.NewProfileEncoder(prof, cfg)
+	remove := stopwordRemover(stop)
+	vecs := make(map[int]hdc.Vector)
+	vec := func(i int) hdc.Vector {
+		v, ok := vecs[i]
+		if !ok {
+			v = enc.Encode(remove(corpus[i]))
+			vecs[i] = v
+		}
+		return v
+	}
+	out := make([]float64, len(idx))
+	for k, p := range idx {
+		out[k] = hdc.Similarity(vec(p[0]), vec(p[1]))
+	}
+	return out
+}
+
+// frequentWords returns the corpus's words by how many texts contain them,
+// most first, ties alphabetically.
+func frequentWords(corpus []string) []string {
+	df := make(map[string]int)
+	for _, text := range corpus {
+		seen := make(map[string]bool)
+		for _, w := range words(text) {
+			if !seen[w] {
+				seen[w] = true
+				df[w]++
+			}
+		}
+	}
+	out := make([]string, 0, len(df))
+	for w := range df {
+		out = append(out, w)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if df[out[i]] != df[out[j]] {
+			return df[out[i]] > df[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// stopwordRemover returns a normalizer dropping stop words, compared
+// case-insensitively; with none it returns text unchanged.
+func stopwordRemover(stop []string) func(string) string {
+	if len(stop) == 0 {
+		return func(s string) string { return s }
+	}
+	set := make(map[string]bool, len(stop))
+	for _, w := range stop {
+		set[w] = true
+	}
+	return func(text string) string {
+		fields := strings.Fields(text)
+		kept := fields[:0:0]
+		for _, f := range fields {
+			if w := words(f); len(w) == 1 && set[w[0]] {
+				continue
+			}
+			kept = append(kept, f)
+		}
+		if len(kept) == 0 {
+			return text // nothing but stop words: keep them
+		}
+		return strings.Join(kept, " ")
+	}
+}
+
+// ranks returns the rank of each value, ties sharing their mean rank.
+func ranks(xs []float64) []float64 {
+	order := make([]int, len(xs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return xs[order[a]] < xs[order[b]] })
+	out := make([]float64, len(xs))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && xs[order[j+1]] == xs[order[i]] {
+			j++
+		}
+		r := float64(i+j) / 2
+		for k := i; k <= j; k++ {
+			out[order[k]] = r
+		}
+		i = j + 1
+	}
+	return out
+}
+
+// pearson is the correlation of xs and ys, 0 if either is constant.
+func pearson(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if n == 0 {
+		return 0
+	}
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx, my = mx/n, my/n
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+// agreeingThreshold returns the DefaultThresholds value at which got ≥ t
+// agrees most often with want ≥ teacherT, and that fraction; the lowest
+// such threshold on ties.
+func agreeingThreshold(got, want []float64, teacherT float64) (float64, float64) {
+	bestT, bestN := 0.0, -1
+	for _, t := range DefaultThresholds() {
+		n := 0
+		for i := range got {
+			if (got[i] >= t) == (want[i] >= teacherT) {
+				n++
+			}
+		}
+		if n > bestN {
+			bestT, bestN = t, n
+		}
+	}
+	return bestT, ratio(bestN, len(got))
+}
@@ -0,0 +1,76 @@
+package eval_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+// jaccard is a teacher that ignores filler words, as a semantic model
+// mostly does.
+func jaccard(a, b string) float64 {
+	filler := map[string]bool{"what": true, "is": true, "the": true, "of": true, "how": true, "do": true, "i": true}
+	set := func(s string) map[string]bool {
+		m := map[string]bool{}
+		for _, w := range strings.Fields(s) {
+			if !filler[w] {
+				m[w] = true
+			}
+		}
+		return m
+	}
+	x, y := set(a), set(b)
+	n := 0
+	for w := range x {
+		if y[w] {
+			n++
+		}
+	}
+	if u := len(x) + len(y) - n; u > 0 {
+		return float64(n) / float64(u)
+	}
+	return 1
+}
+
+func TestDistill(t *testing.T) {
+	corpus := []string{
+		"what is the capital of india", "capital of india",
+		"what is the capital of france", "capital france",
+		"how do i bake a chocolate cake", "bake chocolate cake",
+		"how do i reset my password", "reset password",
+		"what is the price of bitcoin", "bitcoin price",
+	}
+	cfg := eval.DistillConfig{
+		NGramSizes: []int{3, 4},
+		Profiles:   []hdcx.Profile{{}, hdcx.NaturalLanguage},
+		Stopwords:  []int{0, 5},
+	}
+	d := eval.Distill(corpus, jaccard, cfg)
+	if d.Pairs != len(corpus)*(len(corpus)-1)/2 {
+		t.Fatalf("a small corpus must use every pair, got %d", d.Pairs)
+	}
+	if d.Correlation < 0.3 || d.Agreement < 0.5 {
+		t.Fatalf("poor imitation: %+v", d)
+	}
+	if d.NGramSize != 3 && d.NGramSize != 4 {
+		t.Fatalf("n-gram size %d outside the grid", d.NGramSize)
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil || !strings.Contains(string(b), `"ngram_size"`) {
+		t.Fatalf("json: %s %v", b, err)
+	}
+	db := xordb.New(d.Options()...)
+	db.Set("what is the capital of india", 1)
+	if _, ok, _ := db.Get("what is the capital of india"); !ok {
+		t.Fatal("distilled DB must hit an exact key")
+	}
+
+	if again := eval.Distill(corpus, jaccard, cfg); again.NGramSize != d.NGramSize || again.Correlation != d.Correlation {
+		t.Fatal("Distill must be deterministic")
+	}
+}
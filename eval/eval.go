@@ -0,0 +1,209 @@
+// Package eval measures how well a configured DB separates paraphrases from
+// non-matches on a labeled dataset.
+//
+//	pairs, err := eval.LoadFile("pairs.jsonl")
+//	rep := eval.Run(xordb.New(xordb.WithThreshold(0.8), xordb.WithCapacity(len(pairs))), pairs)
+//	fmt.Printf("precision=%.3f recall=%.3f f1=%.3f p99=%v\n",
+//		rep.Precision(), rep.Recall(), rep.F1(), rep.Latency.P99)
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// Pair is one labeled example: Cached is stored, Lookup is queried, and
+// ExpectHit says whether Lookup should be served Cached's answer.
+type Pair struct {
+	Cached    string `json:"cached"`
+	Lookup    string `json:"lookup"`
+	Answer    string `json:"answer,omitempty"`
+	ExpectHit bool   `json:"expect_hit"`
+	Category  string `json:"category,omitempty"`
+}
+
+// UnmarshalJSON also accepts "expect" for "expect_hit".
+func (p *Pair) UnmarshalJSON(b []byte) error {
+	type plain Pair
+	var aux struct {
+		plain
+		Expect *bool `json:"expect"`
+	}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	*p = Pair(aux.plain)
+	if aux.Expect != nil {
+		p.ExpectHit = *aux.Expect
+	}
+	return nil
+}
+
+// Result is the outcome of one lookup.
+type Result struct {
+	Pair
+	Hit        bool
+	Similarity float64 // 0 on a miss, as returned by Get
+	Latency    time.Duration
+}
+
+func (r Result) Correct() bool { return r.Hit == r.ExpectHit }
+
+// Confusion counts classification outcomes, a hit being a positive.
+type Confusion struct {
+	TP, FP, FN, TN int
+}
+
+func (c *Confusion) add(expectHit, hit bool) {
+	switch {
+	case expectHit && hit:
+		c.TP++
+	case !expectHit && hit:
+		c.FP++
+	case expectHit && !hit:
+		c.FN++
+	default:
+		c.TN++
+	}
+}
+
+func (c Confusion) Total() int { return c.TP + c.FP + c.FN + c.TN }
+
+// Precision is the fraction of hits that were correct (0 with no hits).
+func (c Confusion) Precision() float64 { return ratio(c.TP, c.TP+c.FP) }
+
+// Recall is the fraction of expected hits that were found.
+func (c Confusion) Recall() float64 { return ratio(c.TP, c.TP+c.FN) }
+
+func (c Confusion) F1() float64 {
+	p, r := c.Precision(), c.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+// FPR is the fraction of non-matches that were wrongly served a hit.
+func (c Confusion) FPR() float64      { return ratio(c.FP, c.FP+c.TN) }
+func (c Confusion) Accuracy() float64 { return ratio(c.TP+c.TN, c.Total()) }
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// Latency summarizes per-lookup Get latency.
+type Latency struct {
+	Mean, P50, P90, P99, Max time.Duration
+}
+
+// Report aggregates a run.
+type Report struct {
+	Confusion                       // overall
+	Categories map[string]Confusion // by Pair.Category ("" for unlabeled)
+	Latency    Latency
+	Results    []Result // in input order
+}
+
+// Run stores every Cached key in db, then looks up every Lookup key and
+// summarizes the outcomes. db should be empty and hold at least len(pairs)
+// entries, or evictions will show up as false negatives. Stored values are
+// Answer when set, otherwise the Cached key.
+func Run(db *xordb.DB, pairs []Pair) *Report {
+	for _, p := range pairs {
+		v := p.Answer
+		if v == "" {
+			v = p.Cached
+		}
+		db.Set(p.Cached, v)
+	}
+
+	results := make([]Result, len(pairs))
+	for i, p := range pairs {
+		start := time.Now()
+		_, hit, sim := db.Get(p.Lookup)
+		results[i] = Result{Pair: p, Hit: hit, Similarity: sim, Latency: time.Since(start)}
+	}
+	return Summarize(results)
+}
+
+// Summarize builds a Report from results produced elsewhere (a remote
+// cache, a replayed log).
+func Summarize(results []Result) *Report {
+	rep := &Report{Categories: make(map[string]Confusion), Results: results}
+	lat := make([]time.Duration, len(results))
+	for i, r := range results {
+		rep.Confusion.add(r.ExpectHit, r.Hit)
+		c := rep.Categories[r.Category]
+		c.add(r.ExpectHit, r.Hit)
+		rep.Categories[r.Category] = c
+		lat[i] = r.Latency
+	}
+	rep.Latency = SummarizeLatency(lat)
+	return rep
+}
+
+// SummarizeLatency computes mean, percentiles and max. It sorts lat in place.
+func SummarizeLatency(lat []time.Duration) Latency {
+	if len(lat) == 0 {
+		return Latency{}
+	}
+	var sum time.Duration
+	for _, d := range lat {
+		sum += d
+	}
+	sort.Slice(lat, func(i, j int) bool { return lat[i] < lat[j] })
+	return Latency{
+		Mean: sum / time.Duration(len(lat)),
+		P50:  percentile(lat, 50),
+		P90:  percentile(lat, 90),
+		P99:  percentile(lat, 99),
+		Max:  lat[len(lat)-1],
+	}
+}
+
+// percentile — nearest-rank on sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// WriteText prints a human-readable summary with a per-category breakdown.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "queries\t%d\n", r.Total())
+	fmt.Fprintf(tw, "precision\t%.1f%%\t(%d/%d hits correct)\n", 100*r.Precision(), r.TP, r.TP+r.FP)
+	fmt.Fprintf(tw, "recall\t%.1f%%\t(%d/%d matches found)\n", 100*r.Recall(), r.TP, r.TP+r.FN)
+	fmt.Fprintf(tw, "f1\t%.1f%%\n", 100*r.F1())
+	fmt.Fprintf(tw, "fp rate\t%.1f%%\t(%d/%d wrong hits)\n", 100*r.FPR(), r.FP, r.FP+r.TN)
+	fmt.Fprintf(tw, "latency\tmean %v\tp50 %v  p90 %v  p99 %v  max %v\n",
+		r.Latency.Mean, r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max)
+
+	cats := make([]string, 0, len(r.Categories))
+	for c := range r.Categories {
+		cats = append(cats, c)
+	}
+	sort.Strings(cats)
+	if len(cats) > 1 || (len(cats) == 1 && cats[0] != "") {
+		fmt.Fprintln(tw, "\ncategory\tcorrect\tTP\tFP\tFN\tTN")
+		for _, name := range cats {
+			c := r.Categories[name]
+			if name == "" {
+				name = "(none)"
+			}
+			fmt.Fprintf(tw, "%s\t%d/%d\t%d\t%d\t%d\t%d\n", name, c.TP+c.TN, c.Total(), c.TP, c.FP, c.FN, c.TN)
+		}
+	}
+	return tw.Flush()
+}
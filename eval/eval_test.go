@@ -0,0 +1,116 @@
+package eval_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+)
+
+var pairs = []eval.Pair{
+	{Cached: "what is the capital of india", Lookup: "capital city of india", ExpectHit: true, Category: "match"},
+	{Cached: "who wrote ramayana", Lookup: "who wrote the ramayana", ExpectHit: true, Category: "match"},
+	{Cached: "how to bake a chocolate cake", Lookup: "price of bitcoin today", ExpectHit: false, Category: "neg"},
+}
+
+func TestRun(t *testing.T) {
+	rep := eval.Run(xordb.New(xordb.WithThreshold(0.70)), pairs)
+
+	if rep.Total() != 3 || rep.TP != 2 || rep.TN != 1 {
+		t.Fatalf("want 2 TP and 1 TN, got %+v", rep.Confusion)
+	}
+	if rep.Precision() != 1 || rep.Recall() != 1 || rep.F1() != 1 || rep.FPR() != 0 {
+		t.Fatalf("perfect run must score 1/1/1/0, got p=%v r=%v f1=%v fpr=%v",
+			rep.Precision(), rep.Recall(), rep.F1(), rep.FPR())
+	}
+	if c := rep.Categories["match"]; c.TP != 2 || c.Total() != 2 {
+		t.Fatalf("match category: %+v", c)
+	}
+	if len(rep.Results) != 3 || !rep.Results[0].Hit || rep.Results[0].Similarity < 0.70 {
+		t.Fatalf("results must be in input order with similarities: %+v", rep.Results)
+	}
+	if rep.Latency.Max <= 0 || rep.Latency.P50 > rep.Latency.Max {
+		t.Fatalf("bad latency summary: %+v", rep.Latency)
+	}
+
+	var buf bytes.Buffer
+	rep.WriteText(&buf)
+	if !strings.Contains(buf.String(), "precision") || !strings.Contains(buf.String(), "match") {
+		t.Fatalf("text report missing sections:\n%s", buf.String())
+	}
+}
+
+func TestConfusion_Ratios(t *testing.T) {
+	c := eval.Confusion{TP: 6, FP: 2, FN: 4, TN: 8}
+	if c.Precision() != 0.75 || c.Recall() != 0.6 || c.FPR() != 0.2 || c.Accuracy() != 0.7 {
+		t.Fatalf("got p=%v r=%v fpr=%v acc=%v", c.Precision(), c.Recall(), c.FPR(), c.Accuracy())
+	}
+	if f1 := c.F1(); f1 < 0.6666 || f1 > 0.6667 {
+		t.Fatalf("f1: got %v", f1)
+	}
+	if (eval.Confusion{}).F1() != 0 {
+		t.Fatal("empty confusion must score 0, not NaN")
+	}
+}
+
+func TestSummarize_Percentiles(t *testing.T) {
+	results := make([]eval.Result, 100)
+	for i := range results {
+		results[i].Latency = time.Duration(i+1) * time.Millisecond
+	}
+	l := eval.Summarize(results).Latency
+	if l.P50 != 50*time.Millisecond || l.P90 != 90*time.Millisecond || l.P99 != 99*time.Millisecond || l.Max != 100*time.Millisecond {
+		t.Fatalf("nearest-rank percentiles wrong: %+v", l)
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	in := "lookup,cached,expect_hit,category,extra\n" +
+		"capital city of india,what is the capital of india,yes,match,x\n" +
+		"\"price of bitcoin, today\",how to bake a cake,0,neg,y\n"
+	got, err := eval.LoadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got[0].ExpectHit || got[1].ExpectHit || got[1].Lookup != "price of bitcoin, today" {
+		t.Fatalf("got %+v", got)
+	}
+
+	if _, err := eval.LoadCSV(strings.NewReader("cached,lookup\na,b\n")); err == nil {
+		t.Fatal("missing expect_hit column must error")
+	}
+	if _, err := eval.LoadCSV(strings.NewReader("cached,lookup,expect_hit\na,b,maybe\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("bad bool must report its line, got %v", err)
+	}
+}
+
+func TestLoadJSONL(t *testing.T) {
+	in := `{"cached":"a","lookup":"b","expect_hit":true}
+
+{"cached":"c","lookup":"d","expect_hit":false,"category":"neg"}
+`
+	got, err := eval.LoadJSONL(strings.NewReader(in))
+	if err != nil || len(got) != 2 || got[1].Category != "neg" {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+	if _, err := eval.LoadJSONL(strings.NewReader(`{"cached":"","lookup":"x"}`)); err == nil {
+		t.Fatal("empty cached key must error")
+	}
+}
+
+func TestLoadFile_JSONArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pairs.json")
+	os.WriteFile(path, []byte(`[{"cached":"a","lookup":"b","expect_hit":true}]`), 0o644)
+	got, err := eval.LoadFile(path)
+	if err != nil || len(got) != 1 || !got[0].ExpectHit {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+	if _, err := eval.LoadFile(filepath.Join(t.TempDir(), "pairs.txt")); err == nil {
+		t.Fatal("unknown extension must error")
+	}
+}
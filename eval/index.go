@@ -0,0 +1,86 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// IndexVariant is one index configuration to compare.
+type IndexVariant struct {
+	Name    string
+	Options []xordb.Option
+}
+
+// DefaultIndexVariants compares an exact scan with LSH alone, LSH with
+// multi-probe, and LSH with the linear fallback (the default).
+func DefaultIndexVariants() []IndexVariant {
+	return []IndexVariant{
+		{"linear", []xordb.Option{xordb.WithIndex(xordb.IndexLinear)}},
+		{"lsh", []xordb.Option{xordb.WithIndex(xordb.IndexLSH), xordb.WithLSHFallback(false)}},
+		{"lsh+probe4", []xordb.Option{xordb.WithIndex(xordb.IndexLSH), xordb.WithLSHFallback(false), xordb.WithLSHProbes(4)}},
+		{"lsh+fallback", []xordb.Option{xordb.WithIndex(xordb.IndexLSH), xordb.WithLSHFallback(true)}},
+	}
+}
+
+// IndexResult is one variant's outcome.
+type IndexResult struct {
+	Name   string
+	Report *Report
+	// Recall is the fraction of the first variant's hits this variant also
+	// found, i.e. recall relative to an exact scan when the first variant
+	// is linear.
+	Recall float64
+	// Candidates is the mean number of LSH candidates compared per lookup
+	// (0 for linear).
+	Candidates float64
+}
+
+// CompareIndexes runs pairs once per variant (nil means
+// DefaultIndexVariants) against a fresh DB from newDB, which receives the
+// variant's options and should set encoder, threshold and a capacity of at
+// least len(pairs). Put the exact variant first: Recall is measured against
+// it.
+func CompareIndexes(newDB func(...xordb.Option) *xordb.DB, pairs []Pair, variants []IndexVariant) []IndexResult {
+	if variants == nil {
+		variants = DefaultIndexVariants()
+	}
+	out := make([]IndexResult, len(variants))
+	for i, v := range variants {
+		db := newDB(v.Options...)
+		rep := Run(db, pairs)
+		out[i] = IndexResult{
+			Name:       v.Name,
+			Report:     rep,
+			Candidates: float64(db.Stats().LSHCandidates) / float64(max(len(pairs), 1)),
+		}
+	}
+
+	base := out[0].Report.Results
+	for i := range out {
+		var both, ref int
+		for j, r := range out[i].Report.Results {
+			if base[j].Hit {
+				ref++
+				if r.Hit {
+					both++
+				}
+			}
+		}
+		out[i].Recall = ratio(both, ref)
+	}
+	return out
+}
+
+// WriteIndexComparison prints one row per variant.
+func WriteIndexComparison(w io.Writer, results []IndexResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "index\trecall%\tf1%\tcand/q\tp50\tp99\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%.1f\t%.1f\t%.1f\t%v\t%v\t\n",
+			r.Name, 100*r.Recall, 100*r.Report.F1(), r.Candidates, r.Report.Latency.P50, r.Report.Latency.P99)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,37 @@
+package eval_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+)
+
+func TestCompareIndexes(t *testing.T) {
+	newDB := func(opts ...xordb.Option) *xordb.DB {
+		return xordb.New(append([]xordb.Option{xordb.WithThreshold(0.70)}, opts...)...)
+	}
+	res := eval.CompareIndexes(newDB, pairs, nil)
+	if len(res) != 4 || res[0].Name != "linear" {
+		t.Fatalf("want the four default variants, linear first: %+v", res)
+	}
+	if res[0].Recall != 1 || res[0].Candidates != 0 {
+		t.Fatalf("linear is the reference: %+v", res[0])
+	}
+	if last := res[3]; last.Name != "lsh+fallback" || last.Recall != 1 {
+		t.Fatalf("LSH with fallback must match linear exactly: %+v", last)
+	}
+	for _, r := range res[1:] {
+		if r.Candidates == 0 {
+			t.Fatalf("%s: LSH variants must report candidates", r.Name)
+		}
+	}
+
+	var buf bytes.Buffer
+	eval.WriteIndexComparison(&buf, res)
+	if !strings.Contains(buf.String(), "lsh+probe4") {
+		t.Fatalf("missing row:\n%s", buf.String())
+	}
+}
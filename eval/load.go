@@ -0,0 +1,131 @@
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadFile reads pairs from path, choosing the format by extension: .csv,
+// .jsonl/.ndjson, or .json (an array of pairs, like benchmarks/data.json).
+func LoadFile(path string) ([]Pair, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("eval: %w", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return LoadCSV(f)
+	case ".jsonl", ".ndjson":
+		return LoadJSONL(f)
+	case ".json":
+		var pairs []Pair
+		if err := json.NewDecoder(f).Decode(&pairs); err != nil {
+			return nil, fmt.Errorf("eval: %s: %w", path, err)
+		}
+		return checked(pairs)
+	}
+	return nil, fmt.Errorf("eval: %s: unknown format (want .csv, .jsonl or .json)", path)
+}
+
+// LoadJSONL reads one JSON Pair per line. Blank lines are skipped.
+func LoadJSONL(r io.Reader) ([]Pair, error) {
+	var pairs []Pair
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for line := 1; sc.Scan(); line++ {
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		var p Pair
+		if err := json.Unmarshal(b, &p); err != nil {
+			return nil, fmt.Errorf("eval: line %d: %w", line, err)
+		}
+		pairs = append(pairs, p)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("eval: %w", err)
+	}
+	return checked(pairs)
+}
+
+// LoadCSV reads a CSV with a header row naming its columns: cached, lookup
+// and expect_hit are required; answer and category are optional, and other
+// columns are ignored. expect_hit accepts anything strconv.ParseBool does,
+// plus yes/no.
+func LoadCSV(r io.Reader) ([]Pair, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("eval: csv header: %w", err)
+	}
+	col := make(map[string]int)
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, req := range []string{"cached", "lookup", "expect_hit"} {
+		if _, ok := col[req]; !ok {
+			return nil, fmt.Errorf("eval: csv header missing %q column", req)
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return rec[i]
+		}
+		return ""
+	}
+
+	var pairs []Pair
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("eval: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		hit, err := parseBool(field(rec, "expect_hit"))
+		if err != nil {
+			return nil, fmt.Errorf("eval: line %d: expect_hit: %w", line, err)
+		}
+		pairs = append(pairs, Pair{
+			Cached:    field(rec, "cached"),
+			Lookup:    field(rec, "lookup"),
+			Answer:    field(rec, "answer"),
+			ExpectHit: hit,
+			Category:  field(rec, "category"),
+		})
+	}
+	return checked(pairs)
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	return strconv.ParseBool(strings.TrimSpace(s))
+}
+
+func checked(pairs []Pair) ([]Pair, error) {
+	for i, p := range pairs {
+		if p.Cached == "" || p.Lookup == "" {
+			return nil, fmt.Errorf("eval: pair %d: cached and lookup must be non-empty", i)
+		}
+	}
+	return pairs, nil
+}
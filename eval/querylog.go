@@ -0,0 +1,156 @@
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// Query is one recorded lookup.
+type Query struct {
+	Time time.Time // zero if the log has no timestamps
+	Key  string
+
+	// The LLM call the lookup stood in front of, for CostReport; zero if
+	// the log does not record it.
+	Model        string
+	InputTokens  int
+	OutputTokens int
+}
+
+// ReadQueryLog reads recorded lookups, one per line, oldest first. A line is
+// either plain text (the whole line is the key) or a JSON object with the
+// key in "key", "query", "prompt" or "lookup" and an optional timestamp in
+// "ts", "time" or "timestamp" (RFC 3339 or Unix seconds). JSON lines may
+// also record the LLM call behind the lookup: "model", and "input_tokens"
+// or "prompt_tokens" and "output_tokens" or "completion_tokens", at the top
+// level or in an OpenAI-style "usage" object. JSON lines with a "kind"
+// other than hit or miss, such as set events saved from xordb-serve's
+// /v1/events, are skipped. Blank lines are ignored. Logs written with
+// xordb.WithQueryLog are read as-is.
+func ReadQueryLog(r io.Reader) ([]Query, error) {
+	var qs []Query
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for line := 1; sc.Scan(); line++ {
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		if b[0] != '{' {
+			qs = append(qs, Query{Key: string(b)})
+			continue
+		}
+		q, ok, err := parseLogRecord(b)
+		if err != nil {
+			return nil, fmt.Errorf("eval: query log line %d: %w", line, err)
+		}
+		if ok {
+			qs = append(qs, q)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("eval: query log: %w", err)
+	}
+	return qs, nil
+}
+
+func parseLogRecord(b []byte) (Query, bool, error) {
+	var rec map[string]any
+	if err := json.Unmarshal(b, &rec); err != nil {
+		return Query{}, false, err
+	}
+	if kind, ok := rec["kind"].(string); ok && kind != "hit" && kind != "miss" {
+		return Query{}, false, nil
+	}
+
+	var q Query
+	for _, f := range []string{"key", "query", "prompt", "lookup"} {
+		if s, ok := rec[f].(string); ok && s != "" {
+			q.Key = s
+			break
+		}
+	}
+	if q.Key == "" {
+		return Query{}, false, fmt.Errorf("no key, query, prompt or lookup field")
+	}
+	for _, f := range []string{"ts", "time", "timestamp"} {
+		switch v := rec[f].(type) {
+		case string:
+			t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(v))
+			if err != nil {
+				return Query{}, false, fmt.Errorf("%s: %w", f, err)
+			}
+			q.Time = t
+		case float64:
+			sec := int64(v)
+			q.Time = time.Unix(sec, int64((v-float64(sec))*1e9))
+		default:
+			continue
+		}
+		break
+	}
+	q.Model, _ = rec["model"].(string)
+	usage, _ := rec["usage"].(map[string]any)
+	for _, m := range []map[string]any{rec, usage} {
+		q.InputTokens = max(q.InputTokens, tokenField(m, "input_tokens", "prompt_tokens"))
+		q.OutputTokens = max(q.OutputTokens, tokenField(m, "output_tokens", "completion_tokens"))
+	}
+	return q, true, nil
+}
+
+func tokenField(rec map[string]any, names ...string) int {
+	for _, f := range names {
+		if v, ok := rec[f].(float64); ok && v > 0 {
+			return int(v)
+		}
+	}
+	return 0
+}
+
+// LogReport summarizes a query log run. Logs have no labels, so it reports
+// how much traffic the cache would have absorbed, not whether hits were right.
+type LogReport struct {
+	Queries     int
+	Hits        int
+	AvgSimOnHit float64
+	Latency     Latency // Get only
+}
+
+func (r *LogReport) HitRate() float64 { return ratio(r.Hits, r.Queries) }
+
+// RunLog replays queries in order, cache-aside: each query is looked up and,
+// on a miss, stored under its own key. Timestamps are ignored.
+func RunLog(db *xordb.DB, queries []Query) *LogReport {
+	return runLog(db, queries, func(int, bool) {})
+}
+
+// runLog is RunLog, calling each with every query's index and outcome.
+func runLog(db *xordb.DB, queries []Query, each func(i int, hit bool)) *LogReport {
+	rep := &LogReport{Queries: len(queries)}
+	lat := make([]time.Duration, len(queries))
+	var simSum float64
+	for i, q := range queries {
+		start := time.Now()
+		_, hit, sim := db.Get(q.Key)
+		lat[i] = time.Since(start)
+		each(i, hit)
+		if hit {
+			rep.Hits++
+			simSum += sim
+		} else {
+			db.Set(q.Key, q.Key)
+		}
+	}
+	if rep.Hits > 0 {
+		rep.AvgSimOnHit = simSum / float64(rep.Hits)
+	}
+	rep.Latency = SummarizeLatency(lat)
+	return rep
+}
@@ -0,0 +1,63 @@
+package eval_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+)
+
+func TestReadQueryLog(t *testing.T) {
+	in := `what is the capital of india
+{"ts":"2024-05-01T10:00:00Z","query":"capital city of india"}
+{"kind":"set","ts":"2024-05-01T10:00:01Z","key":"ignored"}
+{"kind":"miss","ts":1714557602.5,"key":"who wrote ramayana"}
+
+{"prompt":"no timestamp"}
+`
+	qs, err := eval.ReadQueryLog(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qs) != 4 {
+		t.Fatalf("want 4 queries (set event skipped), got %+v", qs)
+	}
+	if qs[0].Key != "what is the capital of india" || !qs[0].Time.IsZero() {
+		t.Fatalf("plain line: %+v", qs[0])
+	}
+	if !qs[1].Time.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("RFC 3339 ts: %+v", qs[1])
+	}
+	if want := time.Unix(1714557602, 5e8); !qs[2].Time.Equal(want) || qs[2].Key != "who wrote ramayana" {
+		t.Fatalf("unix ts: %+v", qs[2])
+	}
+
+	if _, err := eval.ReadQueryLog(strings.NewReader(`{"ts":"2024-05-01T10:00:00Z"}`)); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("record without a key must error with its line, got %v", err)
+	}
+}
+
+func TestRunLog(t *testing.T) {
+	qs := []eval.Query{
+		{Key: "what is the capital of india"},
+		{Key: "capital city of india"},
+		{Key: "how to bake a chocolate cake"},
+		{Key: "what is the capital of india"},
+	}
+	rep := eval.RunLog(xordb.New(xordb.WithThreshold(0.70)), qs)
+	if rep.Queries != 4 || rep.Hits != 2 || rep.HitRate() != 0.5 {
+		t.Fatalf("want 2/4 hits, got %+v", rep)
+	}
+	if rep.AvgSimOnHit < 0.70 || rep.Latency.Max <= 0 {
+		t.Fatalf("bad report %+v", rep)
+	}
+}
+
+func TestPair_ExpectAlias(t *testing.T) {
+	got, err := eval.LoadJSONL(strings.NewReader(`{"cached":"a","lookup":"b","expect":true,"category":"match"}`))
+	if err != nil || !got[0].ExpectHit || got[0].Category != "match" {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,167 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+// Point is the outcome of a dataset at one threshold.
+type Point struct {
+	Threshold float64
+	Confusion
+}
+
+// HitRate is the fraction of lookups served from cache.
+func (p Point) HitRate() float64 { return ratio(p.TP+p.FP, p.Total()) }
+
+// Objective scores a confusion matrix; higher is better.
+type Objective func(Confusion) float64
+
+// F1 is the default objective.
+func F1(c Confusion) float64 { return c.F1() }
+
+// PrecisionAtLeast maximizes recall among thresholds whose precision is at
+// least p, for when a wrong answer costs more than a miss.
+func PrecisionAtLeast(p float64) Objective {
+	return func(c Confusion) float64 {
+		if c.TP+c.FP == 0 || c.Precision() < p {
+			return -1
+		}
+		return c.Recall()
+	}
+}
+
+// Sweep is a threshold curve, ascending by threshold.
+type Sweep struct {
+	Points []Point
+}
+
+// DefaultThresholds returns 0.50, 0.51, ..., 0.99.
+func DefaultThresholds() []float64 {
+	ts := make([]float64, 0, 50)
+	for i := 50; i < 100; i++ {
+		ts = append(ts, float64(i)/100)
+	}
+	return ts
+}
+
+// SweepThresholds stores every Cached key in db, records each Lookup's best
+// similarity once, and classifies the dataset at each threshold (nil means
+// DefaultThresholds). db's own threshold does not matter; the same caveats
+// as Run apply to its contents and capacity.
+func SweepThresholds(db *xordb.DB, pairs []Pair, thresholds []float64) *Sweep {
+	if thresholds == nil {
+		thresholds = DefaultThresholds()
+	}
+	best := bestSimilarities(db, pairs)
+	ts := append([]float64(nil), thresholds...)
+	sort.Float64s(ts)
+	s := &Sweep{Points: make([]Point, len(ts))}
+	for i, t := range ts {
+		pt := Point{Threshold: t}
+		for j, p := range pairs {
+			pt.add(p.ExpectHit, best[j] >= t)
+		}
+		s.Points[i] = pt
+	}
+	return s
+}
+
+// bestSimilarities stores every Cached key in db and returns each Lookup's
+// best similarity (0 if db is empty).
+func bestSimilarities(db *xordb.DB, pairs []Pair) []float64 {
+	for _, p := range pairs {
+		db.Set(p.Cached, p.Cached)
+	}
+	best := make([]float64, len(pairs))
+	for i, p := range pairs {
+		if c := db.Explain(p.Lookup, 1); len(c) > 0 {
+			best[i] = c[0].Similarity
+		}
+	}
+	return best
+}
+
+// Calibrate is SweepThresholds without the grid: it returns the exact
+// threshold maximizing obj (F1 if nil), found among the observed
+// similarities by hdcx.CalibrateScores, the same search
+// xordb.WithAdaptiveThreshold uses. The point's FPR and FNR are the error
+// rates to expect at that threshold on data like pairs.
+func Calibrate(db *xordb.DB, pairs []Pair, obj Objective) Point {
+	if obj == nil {
+		obj = F1
+	}
+	best := bestSimilarities(db, pairs)
+	var pos, neg []float64
+	for i, p := range pairs {
+		if p.ExpectHit {
+			pos = append(pos, best[i])
+		} else {
+			neg = append(neg, best[i])
+		}
+	}
+	c := hdcx.CalibrateScores(pos, neg, func(c hdcx.Calibration) float64 {
+		return obj(Confusion{TP: c.TP, FP: c.FP, FN: c.FN, TN: c.TN})
+	})
+	return Point{Threshold: c.Threshold, Confusion: Confusion{TP: c.TP, FP: c.FP, FN: c.FN, TN: c.TN}}
+}
+
+// Best returns the point maximizing obj (F1 if nil). Ties go to the higher
+// threshold, which serves fewer wrong answers for the same score.
+func (s *Sweep) Best(obj Objective) Point {
+	if obj == nil {
+		obj = F1
+	}
+	var best Point
+	bestScore := -1.0
+	for _, p := range s.Points {
+		if sc := obj(p.Confusion); sc >= bestScore {
+			best, bestScore = p, sc
+		}
+	}
+	return best
+}
+
+// AUC is the area under the ROC curve (TPR against FPR) traced by the
+// sweep, closed at (0,0) and (1,1). It is only as fine as the thresholds.
+func (s *Sweep) AUC() float64 {
+	type xy struct{ x, y float64 }
+	pts := []xy{{0, 0}, {1, 1}}
+	for _, p := range s.Points {
+		pts = append(pts, xy{p.FPR(), p.Recall()})
+	}
+	sort.Slice(pts, func(i, j int) bool {
+		if pts[i].x != pts[j].x {
+			return pts[i].x < pts[j].x
+		}
+		return pts[i].y < pts[j].y
+	})
+	var area float64
+	for i := 1; i < len(pts); i++ {
+		area += (pts[i].x - pts[i-1].x) * (pts[i].y + pts[i-1].y) / 2
+	}
+	return area
+}
+
+// WriteText prints the curve as a table followed by the best-F1 threshold.
+func (s *Sweep) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "thresh\tTP\tFP\tFN\tTN\thit%\tprec%\trec%\tfpr%\tf1%\t")
+	for _, p := range s.Points {
+		fmt.Fprintf(tw, "%.2f\t%d\t%d\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t\n",
+			p.Threshold, p.TP, p.FP, p.FN, p.TN,
+			100*p.HitRate(), 100*p.Precision(), 100*p.Recall(), 100*p.FPR(), 100*p.F1())
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	b := s.Best(nil)
+	_, err := fmt.Fprintf(w, "\nbest F1 %.1f%% at threshold %.2f (precision %.1f%%, recall %.1f%%), ROC AUC %.3f\n",
+		100*b.F1(), b.Threshold, 100*b.Precision(), 100*b.Recall(), s.AUC())
+	return err
+}
@@ -0,0 +1,68 @@
+package eval_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+)
+
+func TestSweepThresholds(t *testing.T) {
+	s := eval.SweepThresholds(xordb.New(), pairs, []float64{0.99, 0.5, 0.7})
+
+	if len(s.Points) != 3 || s.Points[0].Threshold != 0.5 || s.Points[2].Threshold != 0.99 {
+		t.Fatalf("points must be sorted ascending: %+v", s.Points)
+	}
+	// recall can only fall as the threshold rises
+	for i := 1; i < len(s.Points); i++ {
+		if s.Points[i].TP > s.Points[i-1].TP {
+			t.Fatalf("TP increased with threshold: %+v", s.Points)
+		}
+	}
+	if b := s.Best(nil); b.Threshold != 0.7 || b.F1() != 1 {
+		t.Fatalf("want perfect F1 at 0.70, got %+v", b)
+	}
+	if auc := s.AUC(); auc != 1 {
+		t.Fatalf("perfectly separable data must have AUC 1, got %v", auc)
+	}
+
+	var buf bytes.Buffer
+	s.WriteText(&buf)
+	if !strings.Contains(buf.String(), "best F1 100.0% at threshold 0.70") {
+		t.Fatalf("missing summary:\n%s", buf.String())
+	}
+}
+
+func TestSweep_Best_Objective(t *testing.T) {
+	s := &eval.Sweep{Points: []eval.Point{
+		{Threshold: 0.6, Confusion: eval.Confusion{TP: 9, FP: 3, FN: 1, TN: 7}}, // p=.75 r=.9
+		{Threshold: 0.7, Confusion: eval.Confusion{TP: 7, FP: 1, FN: 3, TN: 9}}, // p=.875 r=.7
+		{Threshold: 0.8, Confusion: eval.Confusion{TP: 4, FP: 0, FN: 6, TN: 10}},
+	}}
+	if b := s.Best(eval.F1); b.Threshold != 0.6 {
+		t.Fatalf("best F1: want 0.6, got %v", b.Threshold)
+	}
+	if b := s.Best(eval.PrecisionAtLeast(0.85)); b.Threshold != 0.7 {
+		t.Fatalf("precision>=0.85: want 0.7, got %v", b.Threshold)
+	}
+}
+
+func TestDefaultThresholds(t *testing.T) {
+	ts := eval.DefaultThresholds()
+	if len(ts) != 50 || ts[0] != 0.5 || ts[49] != 0.99 || ts[25] != 0.75 {
+		t.Fatalf("got %v", ts)
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	p := eval.Calibrate(xordb.New(), pairs, nil)
+	if p.F1() != 1 {
+		t.Fatalf("perfectly separable data must calibrate to F1 1, got %+v", p)
+	}
+	s := eval.SweepThresholds(xordb.New(), pairs, []float64{p.Threshold})
+	if s.Points[0].Confusion != p.Confusion {
+		t.Fatalf("sweep at %v disagrees: %+v vs %+v", p.Threshold, s.Points[0].Confusion, p.Confusion)
+	}
+}
@@ -0,0 +1,333 @@
+package eval
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Transform rewrites a key into a paraphrase: the same question worded
+// differently. Apply reports false when the rule does not fit the key.
+type Transform struct {
+	Name  string
+	Apply func(rng *rand.Rand, key string) (string, bool)
+}
+
+// SynthConfig is what Synthesize generates. Zero fields take defaults.
+type SynthConfig struct {
+	Transforms []Transform // default DefaultTransforms(Synonyms)
+
+	// Synonyms adds domain word groups to the built-in ones used by the
+	// default synonym transform, e.g. {"k8s": {"kubernetes"}}. Lookups are
+	// by lower-case word; groups need not be symmetric.
+	Synonyms map[string][]string
+
+	Paraphrases   int // per key (default 2), category "match"
+	Negatives     int // per key (default 1): another key, category "neg"
+	HardNegatives int // per key (default 1): the key with a content word changed, category "hard-neg"
+	Seed          int64
+}
+
+// Synthesize generates labeled pairs from a corpus of keys, e.g. the keys
+// of a production cache (db.Entries, or a query log), so thresholds can be
+// tuned on the application's own phrasing rather than a small generic
+// dataset. Each key is the Cached side of its pairs:
+//
+//   - paraphrases (expect a hit): the key rewritten by a randomly chosen
+//     transform — synonym swap, clause reordering, a typo or a different
+//     question template — or two of them
+//   - negatives (expect a miss): another key of the corpus
+//   - hard negatives (expect a miss): the key with its last content word
+//     replaced by one from another key, e.g. "what is the capital of
+//     france" for "what is the capital of india"
+//
+// The rules know nothing of meaning, so a few pairs come out mislabeled:
+// a swapped word that was a synonym, another key that asks the same thing.
+// They make a threshold sweep representative, not a gold standard; review
+// a sample before trusting the exact numbers. Output is deterministic for
+// a seed.
+func Synthesize(keys []string, cfg SynthConfig) []Pair {
+	if cfg.Transforms == nil {
+		cfg.Transforms = DefaultTransforms(cfg.Synonyms)
+	}
+	if cfg.Paraphrases == 0 {
+		cfg.Paraphrases = 2
+	}
+	if cfg.Negatives == 0 {
+		cfg.Negatives = 1
+	}
+	if cfg.HardNegatives == 0 {
+		cfg.HardNegatives = 1
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	var out []Pair
+	for i, key := range keys {
+		seen := map[string]bool{strings.ToLower(key): true}
+		add := func(lookup string, hit bool, cat string) bool {
+			if lookup == "" || seen[strings.ToLower(lookup)] {
+				return false
+			}
+			seen[strings.ToLower(lookup)] = true
+			out = append(out, Pair{Cached: key, Lookup: lookup, ExpectHit: hit, Category: cat})
+			return true
+		}
+		for n, tries := 0, 0; n < cfg.Paraphrases && tries < 4*cfg.Paraphrases && len(cfg.Transforms) > 0; tries++ {
+			if add(paraphrase(rng, cfg.Transforms, key), true, "match") {
+				n++
+			}
+		}
+		if len(keys) < 2 {
+			continue
+		}
+		other := func() string {
+			j := rng.Intn(len(keys) - 1)
+			if j >= i {
+				j++
+			}
+			return keys[j]
+		}
+		for n, tries := 0, 0; n < cfg.Negatives && tries < 4*cfg.Negatives; tries++ {
+			if add(other(), false, "neg") {
+				n++
+			}
+		}
+		for n, tries := 0, 0; n < cfg.HardNegatives && tries < 4*cfg.HardNegatives; tries++ {
+			if add(hardNegative(key, other()), false, "hard-neg") {
+				n++
+			}
+		}
+	}
+	return out
+}
+
+// paraphrase applies one transform, or with probability 1/3 two different
+// ones, to key; "" if none applied.
+func paraphrase(rng *rand.Rand, ts []Transform, key string) string {
+	n := 1
+	if len(ts) > 1 && rng.Intn(3) == 0 {
+		n = 2
+	}
+	out, applied := key, 0
+	for _, i := range rng.Perm(len(ts)) {
+		if s, ok := ts[i].Apply(rng, out); ok {
+			out = s
+			if applied++; applied == n {
+				break
+			}
+		}
+	}
+	if applied == 0 {
+		return ""
+	}
+	return out
+}
+
+// hardNegative replaces the last content word of key with the last content
+// word of other that differs from it; "" if either has none.
+func hardNegative(key, other string) string {
+	words := strings.Fields(key)
+	i := lastContentWord(words)
+	ow := strings.Fields(other)
+	j := lastContentWord(ow)
+	if i < 0 || j < 0 {
+		return ""
+	}
+	a, b := trimWord(words[i]), trimWord(ow[j])
+	if strings.EqualFold(a, b) {
+		return ""
+	}
+	words[i] = strings.Replace(words[i], a, strings.ToLower(b), 1)
+	return strings.Join(words, " ")
+}
+
+func lastContentWord(words []string) int {
+	for i := len(words) - 1; i >= 0; i-- {
+		w := strings.ToLower(trimWord(words[i]))
+		if utf8.RuneCountInString(w) >= 3 && !synthStopwords[w] {
+			return i
+		}
+	}
+	return -1
+}
+
+// trimWord strips the punctuation around a word.
+func trimWord(w string) string {
+	return strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+}
+
+var synthStopwords = set("the", "and", "for", "are", "was", "were", "you", "your", "how", "what",
+	"who", "why", "when", "where", "which", "can", "could", "would", "should", "does", "did",
+	"this", "that", "these", "those", "with", "from", "into", "about", "there", "their", "have",
+	"has", "had", "not", "any", "all", "its", "our", "get", "way", "best", "tell", "please")
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// DefaultTransforms returns the built-in rules — synonym swap with the
+// built-in word groups plus extra, clause reordering, typo injection and
+// template change.
+func DefaultTransforms(extra map[string][]string) []Transform {
+	return []Transform{SynonymSwap(extra), Reorder, Typo, Template}
+}
+
+// synonyms are general-purpose word groups; SynonymSwap replaces a word
+// with another of its group.
+var synonyms = [][]string{
+	{"big", "large", "huge"}, {"small", "little", "tiny"}, {"buy", "purchase"},
+	{"fix", "repair", "resolve"}, {"make", "create", "build"}, {"start", "begin"},
+	{"help", "assist"}, {"show", "display"}, {"find", "locate"},
+	{"change", "modify", "update"}, {"delete", "remove"}, {"error", "problem", "issue"},
+	{"car", "automobile", "vehicle"}, {"movie", "film"}, {"quick", "fast"},
+	{"cheap", "inexpensive"}, {"explain", "describe"}, {"use", "utilize"},
+	{"need", "require"}, {"easy", "simple"}, {"cost", "price"}, {"city", "town"},
+	{"answer", "reply", "response"}, {"choose", "select", "pick"}, {"end", "finish"},
+	{"allow", "permit"}, {"stop", "halt"}, {"send", "transmit"}, {"receive", "get"},
+	{"show", "list"}, {"cancel", "revoke"}, {"account", "profile"}, {"doctor", "physician"},
+	{"child", "kid"}, {"happy", "glad"}, {"hard", "difficult"}, {"near", "close"},
+	{"reset", "restore"}, {"book", "reserve"}, {"check", "verify"}, {"install", "set up"},
+}
+
+// SynonymSwap replaces one word that has synonyms with one of them, from
+// the built-in groups and extra.
+func SynonymSwap(extra map[string][]string) Transform {
+	table := make(map[string][]string)
+	for _, group := range synonyms {
+		for _, w := range group {
+			for _, s := range group {
+				if s != w {
+					table[w] = append(table[w], s)
+				}
+			}
+		}
+	}
+	for w, ss := range extra {
+		w = strings.ToLower(w)
+		table[w] = append(table[w], ss...)
+	}
+	return Transform{Name: "synonym", Apply: func(rng *rand.Rand, key string) (string, bool) {
+		words := strings.Fields(key)
+		var cands []int
+		for i, w := range words {
+			if len(table[strings.ToLower(trimWord(w))]) > 0 {
+				cands = append(cands, i)
+			}
+		}
+		if len(cands) == 0 {
+			return "", false
+		}
+		i := cands[rng.Intn(len(cands))]
+		w := trimWord(words[i])
+		ss := table[strings.ToLower(w)]
+		words[i] = strings.Replace(words[i], w, ss[rng.Intn(len(ss))], 1)
+		return strings.Join(words, " "), true
+	}}
+}
+
+// clauseStarts are the words Reorder may move a trailing phrase from.
+var clauseStarts = set("in", "on", "for", "with", "at", "during", "after", "before", "without",
+	"using", "from", "when", "if", "while", "since", "because")
+
+// Reorder moves a trailing phrase that starts with a preposition or
+// conjunction to the front: "how do I reset my password on android"
+// becomes "on android, how do I reset my password".
+var Reorder = Transform{Name: "reorder", Apply: func(rng *rand.Rand, key string) (string, bool) {
+	words := strings.Fields(strings.TrimRight(key, "?.! "))
+	var cands []int
+	for i := 2; i < len(words)-1; i++ {
+		if clauseStarts[strings.ToLower(words[i])] {
+			cands = append(cands, i)
+		}
+	}
+	if len(cands) == 0 {
+		return "", false
+	}
+	i := cands[rng.Intn(len(cands))]
+	head := strings.Join(words[:i], " ")
+	if r, n := utf8.DecodeRuneInString(head); n > 0 && !isAcronym(words[0]) {
+		head = string(unicode.ToLower(r)) + head[n:]
+	}
+	return strings.Join(words[i:], " ") + ", " + head + suffixOf(key), true
+}}
+
+func isAcronym(w string) bool { return len(w) > 1 && strings.ToUpper(w) == w }
+
+// suffixOf returns the closing punctuation of key, e.g. "?".
+func suffixOf(key string) string {
+	trimmed := strings.TrimRight(key, "?.! ")
+	return strings.TrimSpace(key[len(trimmed):])
+}
+
+// Typo makes one typing mistake in a word of at least four letters:
+// swapping two adjacent letters, dropping one or doubling one.
+var Typo = Transform{Name: "typo", Apply: func(rng *rand.Rand, key string) (string, bool) {
+	words := strings.Fields(key)
+	var cands []int
+	for i, w := range words {
+		if len(w) >= 4 && isLetters(w) {
+			cands = append(cands, i)
+		}
+	}
+	if len(cands) == 0 {
+		return "", false
+	}
+	i := cands[rng.Intn(len(cands))]
+	w := []byte(words[i])
+	j := 1 + rng.Intn(len(w)-2) // keep the first and last letters
+	switch rng.Intn(3) {
+	case 0:
+		w[j], w[j+1] = w[j+1], w[j]
+	case 1:
+		w = slices.Delete(w, j, j+1)
+	default:
+		w = slices.Insert(w, j, w[j])
+	}
+	words[i] = string(w)
+	return strings.Join(words, " "), true
+}}
+
+func isLetters(w string) bool {
+	for i := 0; i < len(w); i++ {
+		if c := w[i] | 0x20; c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// templates are interchangeable openings of a question, as lower-case
+// words; Template swaps a key's opening for another of its group.
+var templates = [][]string{
+	{"what is", "what's", "tell me", "can you tell me", "i want to know"},
+	{"how do i", "how can i", "how to", "what is the way to", "how should i"},
+	{"where can i", "where do i", "where is the place to"},
+	{"why does", "what makes", "how come"},
+	{"can you", "could you", "please", "would you"},
+	{"who is", "who's", "who was"},
+}
+
+// Template replaces the opening of a question with an equivalent one:
+// "how do I reset my password" becomes "what is the way to reset my
+// password".
+var Template = Transform{Name: "template", Apply: func(rng *rand.Rand, key string) (string, bool) {
+	lower := strings.ToLower(key)
+	for _, group := range templates {
+		for _, t := range group {
+			if !strings.HasPrefix(lower, t+" ") {
+				continue
+			}
+			alt := group[rng.Intn(len(group)-1)]
+			if alt == t {
+				alt = group[len(group)-1]
+			}
+			return alt + key[len(t):], true
+		}
+	}
+	return "", false
+}}
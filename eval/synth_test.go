@@ -0,0 +1,78 @@
+package eval_test
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+)
+
+var synthKeys = []string{
+	"How do I reset my password on android?",
+	"what is the capital of india",
+	"how can I cancel my subscription",
+	"where can I buy a cheap car",
+	"explain the error in my build",
+}
+
+func TestSynthesize(t *testing.T) {
+	pairs := eval.Synthesize(synthKeys, eval.SynthConfig{Seed: 1})
+	cats := map[string]int{}
+	for _, p := range pairs {
+		cats[p.Category]++
+		if p.Lookup == p.Cached {
+			t.Errorf("lookup equals its key: %+v", p)
+		}
+		if p.ExpectHit != (p.Category == "match") {
+			t.Errorf("label does not match category: %+v", p)
+		}
+	}
+	if cats["match"] != 2*len(synthKeys) || cats["neg"] != len(synthKeys) || cats["hard-neg"] != len(synthKeys) {
+		t.Errorf("categories %v", cats)
+	}
+	if again := eval.Synthesize(synthKeys, eval.SynthConfig{Seed: 1}); !reflect.DeepEqual(again, pairs) {
+		t.Error("same seed, different pairs")
+	}
+
+	// the pairs are usable as they come: the sweep finds a useful threshold
+	db := xordb.New(xordb.WithCapacity(len(pairs)))
+	if best := eval.SweepThresholds(db, pairs, nil).Best(nil); best.F1() < 0.6 {
+		t.Errorf("best F1 %.2f at %.2f", best.F1(), best.Threshold)
+	}
+}
+
+func TestTransforms(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, tc := range []struct {
+		t         eval.Transform
+		key, want string
+	}{
+		{eval.Reorder, "How do I reset my password on android?", "on android, how do I reset my password?"},
+		{eval.SynonymSwap(map[string][]string{"k8s": {"kubernetes"}}), "restart k8s pods", "restart kubernetes pods"},
+		{eval.Template, "how do I reset my password", ""},
+		{eval.Typo, "reset password", ""},
+	} {
+		got, ok := tc.t.Apply(rng, tc.key)
+		if !ok || got == tc.key || (tc.want != "" && got != tc.want) {
+			t.Errorf("%s(%q) = %q, %v", tc.t.Name, tc.key, got, ok)
+		}
+	}
+	if got, _ := eval.Template.Apply(rng, "how do I reset my password"); !strings.HasSuffix(got, " reset my password") {
+		t.Errorf("template changed the subject: %q", got)
+	}
+	for _, tc := range []struct {
+		t   eval.Transform
+		key string
+	}{
+		{eval.Reorder, "capital of india"},
+		{eval.Template, "capital of india"},
+		{eval.Typo, "a b c"},
+	} {
+		if got, ok := tc.t.Apply(rng, tc.key); ok {
+			t.Errorf("%s(%q) applied: %q", tc.t.Name, tc.key, got)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package xordb
+
+import (
+	"time"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+type EventKind string
+
+const (
+	EventHit    EventKind = "hit"
+	EventMiss   EventKind = "miss"
+	EventSet    EventKind = "set"
+	EventEvict  EventKind = "evict"  // LRU eviction at capacity
+	EventExpire EventKind = "expire" // TTL reaped during a lookup
+)
+
+// Event describes one cache operation. See WithEventHook.
+type Event struct {
+	Kind EventKind `json:"kind"`
+	Time time.Time `json:"ts"`
+	Key  string    `json:"key"` // query key for hit/miss, entry key otherwise
+
+	// Hit: the matched key and its similarity. Miss: the closest key that
+	// was compared and its below-threshold similarity, useful for threshold
+	// calibration. Empty for other kinds.
+	Match      string  `json:"match,omitempty"`
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+// WithEventHook calls fn for every hit, miss, set, eviction and expiry.
+// fn runs synchronously on the calling goroutine after the cache lock is
+// released, so it may use the DB but should return quickly; hand events to a
+// channel for anything slow.
+func WithEventHook(fn func(Event)) Option {
+	return func(o *dbOptions) { o.onEvent = fn }
+}
+
+func (o *dbOptions) cacheOnEvent() func(cache.Event) {
+	fn := o.onEvent
+	if fn == nil {
+		return nil
+	}
+	return func(ev cache.Event) {
+		fn(Event{
+			Kind:       EventKind(ev.Kind),
+			Time:       ev.Time,
+			Key:        ev.Key,
+			Match:      ev.Match,
+			Similarity: ev.Similarity,
+		})
+	}
+}
+
+// chainEvent returns a hook calling hook (if any) and then fn.
+func chainEvent(hook, fn func(cache.Event)) func(cache.Event) {
+	if hook == nil {
+		return fn
+	}
+	return func(ev cache.Event) {
+		hook(ev)
+		fn(ev)
+	}
+}
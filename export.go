@@ -0,0 +1,280 @@
+package xordb
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// Record is one line of the JSONL export format. Vectors are not included:
+// keys are re-encoded on import, so exports move between encoders freely.
+type Record struct {
+	Key       string     `json:"key"`
+	Value     any        `json:"value"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Weight, if positive, ranks the record for WarmFromJSONL, e.g. its hit
+	// count or expected frequency. ExportJSONL leaves it unset.
+	Weight float64 `json:"weight,omitempty"`
+}
+
+const maxRecordLine = 17 << 20 // value limit (16 MB) + key and JSON overhead
+
+// Order selects the order of Entries and ExportJSONLOrdered.
+type Order int
+
+const (
+	// OrderAccess is least recently used first, so importing in order
+	// restores LRU order. It changes under read traffic.
+	OrderAccess Order = iota
+	// OrderInsertion is by the time each entry was last set, oldest first,
+	// ties by key: unaffected by reads.
+	OrderInsertion
+	// OrderKey is by key, bytewise: the same contents always give the same
+	// output, for diffable exports and reproducible replica bootstraps.
+	OrderKey
+)
+
+// ExportJSONL writes every live entry as one JSON Record per line, least
+// recently used first, so importing the file in order restores LRU order.
+func (db *DB) ExportJSONL(w io.Writer) error { return db.ExportJSONLOrdered(w, OrderAccess) }
+
+// ExportJSONLOrdered is ExportJSONL with the lines in order.
+func (db *DB) ExportJSONLOrdered(w io.Writer, order Order) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, rec := range db.Entries(order) {
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("xordb: export: %q: %w", rec.Key, err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("xordb: export: %w", err)
+	}
+	return nil
+}
+
+// Entries returns every live entry as a Record, in order. Like Snapshot it
+// copies the entries under one lock and does not affect LRU order or stats.
+func (db *DB) Entries(order Order) []Record {
+	es := db.c.Snapshot().Entries
+	sortEntries(es, order)
+	out := make([]Record, len(es))
+	for i, e := range es {
+		out[i] = record(e)
+	}
+	return out
+}
+
+// sortEntries puts snapshot entries, MRU first, in order.
+func sortEntries(es []cache.EntrySnapshot, order Order) {
+	switch order {
+	case OrderAccess:
+		slices.Reverse(es) // snapshots are MRU first
+	case OrderInsertion:
+		sort.SliceStable(es, func(i, j int) bool {
+			if !es[i].Ts.Equal(es[j].Ts) {
+				return es[i].Ts.Before(es[j].Ts)
+			}
+			return es[i].Key < es[j].Key
+		})
+	case OrderKey:
+		sort.Slice(es, func(i, j int) bool { return es[i].Key < es[j].Key })
+	default:
+		panic(fmt.Sprintf("xordb: unknown order %d", int(order)))
+	}
+}
+
+func record(es cache.EntrySnapshot) Record {
+	rec := Record{Key: es.Key, Value: es.Value}
+	if !es.Deadline.IsZero() {
+		dl := es.Deadline
+		rec.ExpiresAt = &dl
+	}
+	return rec
+}
+
+// Scan pages through live entries in insertion order, like Redis SCAN:
+// start with cursor 0 and pass each returned cursor back until it is 0.
+// Entries present for the whole scan are returned exactly once even while
+// the cache is being written, and each page holds the lock only briefly.
+// count <= 0 means 100. Does not affect LRU order or stats.
+func (db *DB) Scan(cursor uint64, count int) ([]Record, uint64) {
+	page, next := db.c.Scan(cursor, count)
+	out := make([]Record, len(page))
+	for i, es := range page {
+		out[i] = record(es)
+	}
+	return out, next
+}
+
+// WarmFromJSONL loads Records (as written by ExportJSONL) through Set, so
+// keys are encoded with this DB's encoder. Records already past their
+// expiry are skipped; others keep their remaining lifetime, and records
+// without expires_at get the default TTL. Blank lines are ignored.
+//
+// Records without a weight load in file order as they are read. Weighted
+// records are held until the end of the file, then only the Capacity
+// heaviest are loaded, lightest first so the heaviest end up most recently
+// used: a warm-up larger than the cache keeps the entries most likely to
+// hit. Weighted records are not loaded if the file has an error.
+//
+// Returns the number of entries loaded.
+func (db *DB) WarmFromJSONL(r io.Reader) (int, error) {
+	if err := db.checkOpen(); err != nil {
+		return 0, err
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxRecordLine)
+
+	now := time.Now()
+	n, line := 0, 0
+	var weighted []Record
+	for sc.Scan() {
+		line++
+		b := sc.Bytes()
+		if len(b) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return n, fmt.Errorf("xordb: warm: line %d: %w", line, err)
+		}
+		if rec.Key == "" {
+			return n, fmt.Errorf("xordb: warm: line %d: missing key", line)
+		}
+		if rec.Weight > 0 {
+			if rec.ExpiresAt == nil || rec.ExpiresAt.After(now) {
+				weighted = append(weighted, rec)
+			}
+			continue
+		}
+		if db.warm(rec, now) {
+			n++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return n, fmt.Errorf("xordb: warm: line %d: %w", line+1, err)
+	}
+
+	sort.SliceStable(weighted, func(i, j int) bool { return weighted[i].Weight > weighted[j].Weight })
+	if c := db.c.Capacity(); len(weighted) > c {
+		weighted = weighted[:c]
+	}
+	for i := len(weighted) - 1; i >= 0; i-- {
+		if db.warm(weighted[i], now) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// warm stores rec unless it expired before now.
+func (db *DB) warm(rec Record, now time.Time) bool {
+	if rec.ExpiresAt == nil {
+		db.c.Set(db.key(rec.Key), rec.Value)
+		return true
+	}
+	ttl := rec.ExpiresAt.Sub(now)
+	if ttl <= 0 {
+		return false
+	}
+	db.c.SetWithTTL(db.key(rec.Key), rec.Value, ttl)
+	return true
+}
+
+// Candidate is one scored entry returned by Explain.
+type Candidate struct {
+	Key        string
+	Similarity float64
+	Hit        bool // similarity >= threshold
+}
+
+// Explain returns the n entries most similar to key, best first (n <= 0
+// returns all). Useful for answering "why did this hit/miss?". Does not
+// affect LRU order or stats.
+func (db *DB) Explain(key string, n int) []Candidate {
+	cs := db.c.Explain(db.key(key), n)
+	out := make([]Candidate, len(cs))
+	for i, c := range cs {
+		out[i] = Candidate{Key: c.Key, Similarity: c.Similarity, Hit: c.Hit}
+	}
+	return out
+}
+
+// KeySim is a cached key and its similarity to a query.
+type KeySim struct {
+	Key        string
+	Similarity float64
+}
+
+// SimilarKeys returns up to n cached keys whose similarity to key is at
+// least minSim, best first (n <= 0 returns all). Values are not returned and
+// LRU order and stats are untouched, so it suits query suggestion and
+// dedup checks layered on a live cache.
+func (db *DB) SimilarKeys(key string, n int, minSim float64) []KeySim {
+	ks := db.c.SimilarKeys(db.key(key), n, minSim)
+	out := make([]KeySim, len(ks))
+	for i, k := range ks {
+		out[i] = KeySim{Key: k.Key, Similarity: k.Similarity}
+	}
+	return out
+}
+
+// KeyHits is a cached key and the lookups its entry answered.
+type KeyHits struct {
+	Key  string `json:"key"`
+	Hits uint64 `json:"hits"`
+}
+
+// TopKeys returns up to n cached keys whose entries answered the most
+// lookups, most first: the hot set worth pinning or warming elsewhere.
+// Counts restart when a key is set again and are not kept in snapshots.
+// Does not affect LRU order or stats.
+func (db *DB) TopKeys(n int) []KeyHits {
+	ks := db.c.TopKeys(n)
+	out := make([]KeyHits, len(ks))
+	for i, k := range ks {
+		out[i] = KeyHits{Key: k.Key, Hits: k.Hits}
+	}
+	return out
+}
+
+// Contains reports whether key would hit at the current threshold, and the
+// best similarity found, without touching LRU order, values or stats. Use it
+// to decide whether to consult the cache at all. It scans every entry, so
+// with LSH enabled a Get can still miss where Contains reports true.
+func (db *DB) Contains(key string) (bool, float64) { return db.c.Contains(db.key(key)) }
+
+// MaxSimilarity returns the best similarity between key and any cached
+// entry, 0 if the cache is empty. Like Contains, it has no side effects.
+func (db *DB) MaxSimilarity(key string) float64 { return db.c.MaxSimilarity(db.key(key)) }
+
+// DuplicateCluster is a group of entries that are paraphrases of each other.
+// Keeping only Canonical (or storing the others as aliases of it) reclaims
+// capacity without losing hits.
+type DuplicateCluster struct {
+	Canonical     string   // member most similar to the rest
+	Keys          []string // all members, Canonical included, most recent first
+	AvgSimilarity float64
+}
+
+// Duplicates returns clusters of entries whose pairwise similarity is at
+// least minSim (linked transitively), largest first. minSim is usually at
+// or above the hit threshold. Compares every pair of entries, so it is meant
+// for offline or occasional use. Does not affect LRU order or stats. Panics
+// if minSim is outside (0, 1].
+func (db *DB) Duplicates(minSim float64) []DuplicateCluster {
+	cs := db.c.Duplicates(minSim)
+	out := make([]DuplicateCluster, len(cs))
+	for i, c := range cs {
+		out[i] = DuplicateCluster{Canonical: c.Canonical, Keys: c.Keys, AvgSimilarity: c.AvgSimilarity}
+	}
+	return out
+}
@@ -0,0 +1,202 @@
+package xordb_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestDB_ExportJSONL_WarmFromJSONL_RoundTrip(t *testing.T) {
+	db := xordb.New()
+	db.Set("alpha", "A")
+	db.Set("beta", 2)
+	db.SetWithTTL("gamma", "C", time.Hour)
+
+	var buf bytes.Buffer
+	if err := db.ExportJSONL(&buf); err != nil {
+		t.Fatalf("ExportJSONL: %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Fatalf("want 3 lines, got %d:\n%s", lines, buf.String())
+	}
+	if !strings.HasPrefix(buf.String(), `{"key":"alpha"`) {
+		t.Fatalf("export must be LRU-first, got:\n%s", buf.String())
+	}
+
+	db2 := xordb.New()
+	n, err := db2.WarmFromJSONL(&buf)
+	if err != nil {
+		t.Fatalf("WarmFromJSONL: %v", err)
+	}
+	if n != 3 || db2.Len() != 3 {
+		t.Fatalf("want 3 loaded, got n=%d len=%d", n, db2.Len())
+	}
+	if v, ok, _ := db2.Get("beta"); !ok || v != float64(2) {
+		t.Fatalf("want beta=2 (JSON number), got %v ok=%v", v, ok)
+	}
+}
+
+func TestDB_Entries_Order(t *testing.T) {
+	db := xordb.New()
+	for _, k := range []string{"charlie", "alpha", "bravo"} {
+		db.Set(k, k)
+		time.Sleep(time.Millisecond) // distinct set times
+	}
+	db.Get("charlie") // reads move charlie to the recent end
+
+	keys := func(order xordb.Order) string {
+		var ks []string
+		for _, r := range db.Entries(order) {
+			ks = append(ks, r.Key)
+		}
+		return strings.Join(ks, " ")
+	}
+	for _, tc := range []struct {
+		order xordb.Order
+		want  string
+	}{
+		{xordb.OrderAccess, "alpha bravo charlie"},
+		{xordb.OrderInsertion, "charlie alpha bravo"},
+		{xordb.OrderKey, "alpha bravo charlie"},
+	} {
+		if got := keys(tc.order); got != tc.want {
+			t.Errorf("order %d: %s, want %s", tc.order, got, tc.want)
+		}
+	}
+
+	db.Get("alpha")
+	if got := keys(xordb.OrderInsertion); got != "charlie alpha bravo" {
+		t.Errorf("insertion order changed under reads: %s", got)
+	}
+
+	var a, b bytes.Buffer
+	db.ExportJSONLOrdered(&a, xordb.OrderKey)
+	db.Get("bravo")
+	db.ExportJSONLOrdered(&b, xordb.OrderKey)
+	if a.String() != b.String() || !strings.HasPrefix(a.String(), `{"key":"alpha"`) {
+		t.Errorf("key-ordered exports differ under reads:\n%s\n%s", a.String(), b.String())
+	}
+}
+
+func TestDB_Scan(t *testing.T) {
+	db := xordb.New()
+	db.Set("alpha", 1)
+	db.SetWithTTL("bravo", 2, time.Hour)
+	db.Set("charlie", 3)
+
+	page, cursor := db.Scan(0, 2)
+	if len(page) != 2 || page[0].Key != "alpha" || page[1].ExpiresAt == nil || cursor == 0 {
+		t.Fatalf("first page: %+v cursor=%d", page, cursor)
+	}
+	page, cursor = db.Scan(cursor, 2)
+	if len(page) != 1 || page[0].Key != "charlie" || cursor != 0 {
+		t.Fatalf("last page: %+v cursor=%d", page, cursor)
+	}
+}
+
+func TestDB_WarmFromJSONL_SkipsExpiredAndBlank(t *testing.T) {
+	in := `{"key":"old","value":1,"expires_at":"2000-01-01T00:00:00Z"}
+
+{"key":"fresh","value":2}
+`
+	db := xordb.New()
+	n, err := db.WarmFromJSONL(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || db.Len() != 1 {
+		t.Fatalf("want only fresh loaded, got n=%d len=%d", n, db.Len())
+	}
+}
+
+func TestDB_WarmFromJSONL_Weighted(t *testing.T) {
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	in := `{"key":"rarely asked","value":1,"weight":1}
+{"key":"asked all the time","value":2,"weight":50}
+{"key":"expired favourite","value":3,"weight":99,"expires_at":"` + past + `"}
+{"key":"asked sometimes","value":4,"weight":10}
+`
+	db := xordb.New(xordb.WithCapacity(2), xordb.WithThreshold(0.99))
+	n, err := db.WarmFromJSONL(strings.NewReader(in))
+	if err != nil || n != 2 {
+		t.Fatalf("want the 2 heaviest live records, got n=%d err=%v", n, err)
+	}
+	for _, k := range []string{"asked all the time", "asked sometimes"} {
+		if _, ok, _ := db.Get(k); !ok {
+			t.Fatalf("%q must be loaded", k)
+		}
+	}
+	if _, ok, _ := db.Get("rarely asked"); ok {
+		t.Fatal("lightest record must be left out")
+	}
+}
+
+func TestDB_WarmFromJSONL_BadLine(t *testing.T) {
+	in := "{\"key\":\"a\",\"value\":1}\nnot json\n"
+	db := xordb.New()
+	n, err := db.WarmFromJSONL(strings.NewReader(in))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("want error mentioning line 2, got %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("lines before the error must load, got n=%d", n)
+	}
+}
+
+func TestDB_Explain(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.70))
+	db.Set("what is the capital of india", "Delhi")
+	db.Set("how do you bake a chocolate cake", "oven")
+
+	got := db.Explain("capital city of india", 1)
+	if len(got) != 1 || got[0].Key != "what is the capital of india" || !got[0].Hit {
+		t.Fatalf("unexpected explain result %+v", got)
+	}
+	if s := db.Stats(); s.Hits+s.Misses != 0 {
+		t.Fatalf("Explain must not count as a lookup, got %+v", s)
+	}
+}
+
+func TestDB_Contains(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.65), xordb.WithKeyNormalizer(strings.ToLower))
+	db.Set("what is the capital of india", "Delhi")
+
+	if ok, sim := db.Contains("Capital City of India"); !ok || sim < 0.65 {
+		t.Fatalf("want contained, got ok=%v sim=%f", ok, sim)
+	}
+	if got := db.MaxSimilarity("WHAT IS THE CAPITAL OF INDIA"); got != 1 {
+		t.Fatalf("MaxSimilarity must normalize the key, got %f", got)
+	}
+	if s := db.Stats(); s.Hits+s.Misses != 0 {
+		t.Fatalf("Contains must not count as a lookup, got %+v", s)
+	}
+}
+
+func TestDB_SimilarKeys(t *testing.T) {
+	db := xordb.New()
+	db.Set("what is the capital of india", "Delhi")
+	db.Set("how do you bake a chocolate cake", "oven")
+
+	got := db.SimilarKeys("capital city of india", 5, 0.6)
+	if len(got) != 1 || got[0].Key != "what is the capital of india" {
+		t.Fatalf("unexpected similar keys %+v", got)
+	}
+	if s := db.Stats(); s.Hits+s.Misses != 0 {
+		t.Fatalf("SimilarKeys must not count as a lookup, got %+v", s)
+	}
+}
+
+func TestDB_Duplicates(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.70))
+	db.Set("what is the capital of india", "Delhi")
+	db.Set("capital city of india", "Delhi")
+	db.Set("how do you bake a chocolate cake", "oven")
+
+	got := db.Duplicates(0.70)
+	if len(got) != 1 || len(got[0].Keys) != 2 || got[0].Canonical == "" {
+		t.Fatalf("want one two-key cluster, got %+v", got)
+	}
+}
@@ -0,0 +1,88 @@
+package xordb
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Faults injects failures at the DB's internal failure points, for testing
+// how an application copes when the cache misbehaves: an encoder that fails
+// (ONNX runtime errors, bad input), a match search that stalls (a huge or
+// contended cache) and an eviction storm (memory pressure, a capacity cut).
+// RandomFaults injects each at a configurable rate; implement Faults for
+// anything scripted. Methods run on the caller's goroutine, the scan delay
+// with the cache lock held, and must be safe for concurrent use.
+type Faults interface {
+	// EncodeFault, if it returns an error, fails the encode of key as an
+	// encoder panic would: with WithEncoderPanicRecovery the failure is
+	// counted in Stats.EncodeErrors and kept for LastEncodeError, and Set
+	// stores nothing and Get misses; without, the error is panicked with.
+	EncodeFault(key string) error
+
+	// ScanDelay stalls the match search of a lookup for key.
+	ScanDelay(key string) time.Duration
+
+	// EvictionStorm is how many entries a Set of a new key evicts beyond
+	// those it needs room for; pinned entries are spared.
+	EvictionStorm() int
+}
+
+// WithFaults injects the failures of f, for tests and staging. Leave it
+// out in production: a nil Faults costs nothing.
+func WithFaults(f Faults) Option { return func(o *dbOptions) { o.faults = f } }
+
+// ErrInjected is the encode failure RandomFaults injects.
+var ErrInjected = errors.New("xordb: injected fault")
+
+// RandomFaults is a Faults that injects each kind of failure independently
+// at random, at the given rates in [0, 1]. The zero value injects nothing.
+type RandomFaults struct {
+	EncodeErrorRate float64 // fraction of encodes that fail with ErrInjected
+
+	SlowScanRate float64 // fraction of lookups whose search stalls
+	SlowScan     time.Duration
+
+	EvictionStormRate float64 // fraction of new keys that set off a storm
+	EvictionStormSize int     // entries a storm evicts
+
+	// Seed makes the injected sequence repeatable for a single goroutine;
+	// with concurrent callers only the rates are.
+	Seed int64
+
+	once sync.Once
+	mu   sync.Mutex
+	rng  *rand.Rand
+}
+
+func (f *RandomFaults) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	f.once.Do(func() { f.rng = rand.New(rand.NewSource(f.Seed)) })
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rng.Float64() < rate
+}
+
+func (f *RandomFaults) EncodeFault(string) error {
+	if f.roll(f.EncodeErrorRate) {
+		return ErrInjected
+	}
+	return nil
+}
+
+func (f *RandomFaults) ScanDelay(string) time.Duration {
+	if f.roll(f.SlowScanRate) {
+		return f.SlowScan
+	}
+	return 0
+}
+
+func (f *RandomFaults) EvictionStorm() int {
+	if f.roll(f.EvictionStormRate) {
+		return f.EvictionStormSize
+	}
+	return 0
+}
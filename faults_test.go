@@ -0,0 +1,34 @@
+package xordb_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestRandomFaults(t *testing.T) {
+	f := &xordb.RandomFaults{EncodeErrorRate: 0.5, EvictionStormRate: 1, EvictionStormSize: 2, Seed: 1}
+	db := xordb.New(xordb.WithFaults(f), xordb.WithEncoderPanicRecovery(true))
+	for i := 0; i < 200; i++ {
+		db.Set(fmt.Sprintf("question number %d", i), i)
+	}
+	s := db.Stats()
+	if s.EncodeErrors < 60 || s.EncodeErrors > 140 {
+		t.Errorf("EncodeErrors = %d of 200 at rate 0.5", s.EncodeErrors)
+	}
+	if db.Len() != 1 {
+		t.Errorf("Len() = %d, want 1 after storms", db.Len())
+	}
+	if err := db.LastEncodeError(); !errors.Is(err, xordb.ErrInjected) {
+		t.Errorf("LastEncodeError() = %v", err)
+	}
+
+	var zero xordb.RandomFaults
+	db = xordb.New(xordb.WithFaults(&zero))
+	db.Set("capital of india", "Delhi")
+	if _, ok, _ := db.Get("capital of india"); !ok {
+		t.Error("the zero RandomFaults must inject nothing")
+	}
+}
@@ -0,0 +1,113 @@
+package xordb
+
+import (
+	"sync"
+
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+const (
+	feedbackWindow     = 1000 // labeled hits kept for threshold adaptation
+	feedbackMinSamples = 20   // labels needed before the threshold moves
+)
+
+// WithAdaptiveThreshold lets Feedback raise the hit threshold until the
+// precision of recent labeled hits reaches target (e.g. 0.95). The threshold
+// never drops below the one configured with WithThreshold: feedback only
+// ever covers hits, so it can show a threshold is too loose but not that it
+// is too strict. Zero disables adaptation; panics at construction if target
+// is outside [0, 1].
+func WithAdaptiveThreshold(target float64) Option {
+	return func(o *dbOptions) { o.adaptiveTarget = target }
+}
+
+type labeled struct {
+	sim     float64
+	correct bool
+}
+
+// feedback holds Feedback counters and the window used for adaptation.
+type feedback struct {
+	mu      sync.Mutex
+	correct uint64
+	wrong   uint64
+
+	target float64 // 0 = adaptation disabled
+	base   float64 // configured threshold, the adaptation floor
+	recent []labeled
+	next   int // ring position in recent once full
+}
+
+// Feedback records whether a hit was right: query is the lookup key and
+// hitKey the entry it matched (Event.Match, or Explain). Applications often
+// learn this downstream, e.g. a user flags a cached answer as wrong. Labels
+// are counted in Stats; with WithAdaptiveThreshold they also tune the
+// threshold. Feedback about an entry that is no longer cached still counts.
+func (db *DB) Feedback(query, hitKey string, correct bool) {
+	sim, ok := db.c.Similarity(db.key(query), db.key(hitKey))
+
+	f := db.fb
+	f.mu.Lock()
+	if correct {
+		f.correct++
+	} else {
+		f.wrong++
+	}
+	if !ok || f.target == 0 {
+		f.mu.Unlock()
+		return
+	}
+	l := labeled{sim: sim, correct: correct}
+	if len(f.recent) < feedbackWindow {
+		f.recent = append(f.recent, l)
+	} else {
+		f.recent[f.next] = l
+		f.next = (f.next + 1) % feedbackWindow
+	}
+	t, adjust := f.thresholdLocked()
+	f.mu.Unlock()
+
+	if adjust {
+		db.c.SetThreshold(t)
+	}
+}
+
+// thresholdLocked returns the lowest threshold at or above base at which the
+// labeled hits it would admit meet the precision target.
+func (f *feedback) thresholdLocked() (float64, bool) {
+	if len(f.recent) < feedbackMinSamples {
+		return 0, false
+	}
+	var right, wrong []float64
+	for _, l := range f.recent {
+		if l.correct {
+			right = append(right, l.sim)
+		} else {
+			wrong = append(wrong, l.sim)
+		}
+	}
+	best := 1.0
+	if c := hdcx.CalibrateScores(right, wrong, hdcx.MinPrecision(f.target)); c.TP+c.FP > 0 && c.Precision() >= f.target {
+		best = c.Threshold
+	}
+	if float64(len(right))/float64(len(f.recent)) >= f.target {
+		best = f.base // every labeled hit qualifies: no reason to tighten
+	}
+	return max(best, f.base), true
+}
+
+func (f *feedback) setBase(t float64) {
+	f.mu.Lock()
+	f.base = t
+	f.mu.Unlock()
+}
+
+func (f *feedback) stats(s *Stats) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s.FeedbackCorrect = f.correct
+	s.FeedbackWrong = f.wrong
+	if n := f.correct + f.wrong; n > 0 {
+		s.EstPrecision = float64(f.correct) / float64(n)
+	}
+}
@@ -0,0 +1,79 @@
+package xordb_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// ── Feedback ──────────────────────────────────────────────────────────────────
+
+const (
+	fbKey        = "what is the capital of india"
+	fbParaphrase = "capital city of india"
+)
+
+func TestFeedback_Stats(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.70))
+	db.Set(fbKey, "Delhi")
+
+	db.Feedback(fbKey, fbKey, true)
+	db.Feedback(fbKey, fbKey, true)
+	db.Feedback(fbParaphrase, fbKey, false)
+	db.Feedback("q", "evicted long ago", true)
+
+	s := db.Stats()
+	if s.FeedbackCorrect != 3 || s.FeedbackWrong != 1 || s.EstPrecision != 0.75 {
+		t.Fatalf("got %+v", s)
+	}
+	if db.Threshold() != 0.70 {
+		t.Fatal("threshold must not move without WithAdaptiveThreshold")
+	}
+}
+
+func TestFeedback_AdaptiveThreshold_Raises(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.60), xordb.WithAdaptiveThreshold(0.90))
+	db.Set(fbKey, "Delhi")
+	if _, ok, _ := db.Get(fbParaphrase); !ok {
+		t.Fatal("paraphrase must hit before feedback")
+	}
+
+	for i := 0; i < 20; i++ {
+		db.Feedback(fbKey, fbKey, true)
+	}
+	if db.Threshold() != 0.60 {
+		t.Fatalf("all-correct feedback must keep the threshold, got %f", db.Threshold())
+	}
+	for i := 0; i < 5; i++ {
+		db.Feedback(fbParaphrase, fbKey, false) // 20/25 = 0.8 precision at its similarity
+	}
+	if db.Threshold() <= 0.60 {
+		t.Fatal("wrong hits below the target must raise the threshold")
+	}
+	if _, ok, _ := db.Get(fbParaphrase); ok {
+		t.Fatal("paraphrase must miss once the threshold is raised past it")
+	}
+	if _, ok, _ := db.Get(fbKey); !ok {
+		t.Fatal("exact key must still hit")
+	}
+}
+
+func TestFeedback_AdaptiveThreshold_TooFewSamples(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.60), xordb.WithAdaptiveThreshold(0.90))
+	db.Set(fbKey, "Delhi")
+	for i := 0; i < 5; i++ {
+		db.Feedback(fbParaphrase, fbKey, false)
+	}
+	if db.Threshold() != 0.60 {
+		t.Fatal("threshold must not move before enough labels")
+	}
+}
+
+func TestWithAdaptiveThreshold_Invalid_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	xordb.New(xordb.WithAdaptiveThreshold(1.5))
+}
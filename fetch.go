@@ -0,0 +1,161 @@
+package xordb
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// WithEarlyRefresh makes Fetch refresh entries that have a TTL before they
+// expire, XFetch-style: a hit reloads the entry with probability growing as
+// its expiry approaches, scaled by how long its last load took and by beta
+// (1 is the usual choice; higher refreshes earlier). Hot keys are then
+// reloaded by one caller a little ahead of time rather than by every caller
+// the moment they expire. 0, the default, disables it.
+func WithEarlyRefresh(beta float64) Option {
+	return func(o *dbOptions) { o.earlyRefresh = beta }
+}
+
+// fetcher runs Fetch loads: one at a time per key, remembering how long
+// each key's last load took for early refresh.
+type fetcher struct {
+	beta float64
+	max  int // deltas kept before they are forgotten
+
+	mu     sync.Mutex
+	flight map[string]*load
+	delta  map[string]time.Duration
+}
+
+// errLoadPanicked is the error of a load whose function panicked, for the
+// callers that waited on it; the panic goes on in the caller that ran it.
+var errLoadPanicked = errors.New("xordb: fetch: load panicked")
+
+type load struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+func newFetcher(beta float64, capacity int) *fetcher {
+	return &fetcher{
+		beta:   beta,
+		max:    2 * capacity,
+		flight: make(map[string]*load),
+		delta:  make(map[string]time.Duration),
+	}
+}
+
+// observe forgets the load time of entries that leave the cache.
+func (f *fetcher) observe(ev cache.Event) {
+	if ev.Kind != cache.EventEvict && ev.Kind != cache.EventExpire {
+		return
+	}
+	f.mu.Lock()
+	delete(f.delta, ev.Key)
+	f.mu.Unlock()
+}
+
+// early reports whether a hit should be refreshed now: XFetch's
+// now − δ·β·ln(rand) ≥ expiry.
+func (f *fetcher) early(r Result) bool {
+	if f.beta == 0 || r.ExpiresIn <= 0 {
+		return false
+	}
+	f.mu.Lock()
+	d := f.delta[r.MatchedKey]
+	f.mu.Unlock()
+	if d <= 0 {
+		return false // not loaded by Fetch, or instantly
+	}
+	gap := -float64(d) * f.beta * math.Log(1-rand.Float64())
+	return gap >= float64(r.ExpiresIn)
+}
+
+// do runs fn for key unless a load of key is already running, in which case
+// it waits for that load and returns it — or, without wait, returns nil at
+// once.
+func (f *fetcher) do(key string, wait bool, fn func() (any, error)) *load {
+	f.mu.Lock()
+	if l, busy := f.flight[key]; busy {
+		f.mu.Unlock()
+		if !wait {
+			return nil
+		}
+		<-l.done
+		return l
+	}
+	l := &load{done: make(chan struct{})}
+	f.flight[key] = l
+	f.mu.Unlock()
+
+	start := time.Now()
+	finished := false
+	defer func() {
+		if !finished {
+			l.value, l.err = nil, errLoadPanicked
+		}
+		f.mu.Lock()
+		delete(f.flight, key)
+		if l.err == nil && f.beta > 0 {
+			if len(f.delta) >= f.max {
+				clear(f.delta) // re-learned on the next loads
+			}
+			f.delta[key] = time.Since(start)
+		}
+		f.mu.Unlock()
+		close(l.done)
+	}()
+	l.value, l.err = fn()
+	finished = true
+	return l
+}
+
+// Fetch is a read-through Get: on a hit it returns the cached value; on a
+// miss it calls load, caches the result under key and returns it. load is
+// passed key as the DB stores it, after WithKeyScrubber, WithKeyNormalizer
+// and WithQuestionNormalization (see NormalizeKey), not as the caller
+// wrote it, so keys that name one entry share one load. Concurrent misses
+// on one key share a single load. Errors from load are
+// returned and not cached. If load panics, the panic goes on in the caller
+// that ran it, and those waiting on it get an error.
+//
+// With WithEarlyRefresh, a hit may instead reload the matched entry —
+// calling load with its stored key — before it expires; concurrent callers
+// keep getting the cached value meanwhile, and if the reload fails the
+// cached value is returned.
+func (db *DB) Fetch(key string, load func(key string) (any, error)) (any, error) {
+	if err := db.checkOpen(); err != nil {
+		return nil, err
+	}
+	r := db.Lookup(key)
+	if r.Hit {
+		if !db.fe.early(r) {
+			return r.Value, nil
+		}
+		l := db.fe.do(r.MatchedKey, false, db.loadInto(r.MatchedKey, load))
+		if l == nil || l.err != nil {
+			return r.Value, nil
+		}
+		return l.value, nil
+	}
+	k := db.key(key)
+	l := db.fe.do(k, true, db.loadInto(k, load))
+	return l.value, l.err
+}
+
+// loadInto returns a function loading k and caching the result.
+func (db *DB) loadInto(k string, load func(string) (any, error)) func() (any, error) {
+	return func() (any, error) {
+		v, err := load(k)
+		if err != nil {
+			return nil, err
+		}
+		db.c.Set(k, v)
+		return v, nil
+	}
+}
@@ -0,0 +1,131 @@
+package xordb_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestDB_Fetch(t *testing.T) {
+	db := xordb.New()
+	var calls atomic.Int32
+	load := func(key string) (any, error) {
+		calls.Add(1)
+		if key == "bad" {
+			return nil, errors.New("backend down")
+		}
+		return "answer to " + key, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		v, err := db.Fetch("what is the capital of france", load)
+		if err != nil || v != "answer to what is the capital of france" {
+			t.Fatalf("got %v, %v", v, err)
+		}
+	}
+	if v, _ := db.Fetch("what's the capital of france", load); v != "answer to what is the capital of france" {
+		t.Fatalf("a similar key must hit: %v", v)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("load must run once, ran %d times", calls.Load())
+	}
+
+	if _, err := db.Fetch("bad", load); err == nil {
+		t.Fatal("load errors must be returned")
+	}
+	if db.Len() != 1 {
+		t.Fatal("load errors must not be cached")
+	}
+}
+
+func TestDB_Fetch_SharedLoad(t *testing.T) {
+	db := xordb.New()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(string) (any, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := db.Fetch("hot key", load); v != 42 || err != nil {
+				t.Errorf("got %v, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Fatalf("concurrent misses must share one load, got %d", calls.Load())
+	}
+}
+
+func TestDB_Fetch_LoadPanics(t *testing.T) {
+	db := xordb.New()
+	started, release := make(chan struct{}), make(chan struct{})
+	leader := make(chan any)
+	go func() {
+		defer func() { leader <- recover() }()
+		db.Fetch("hot key", func(string) (any, error) {
+			close(started)
+			<-release
+			panic("backend bug")
+		})
+	}()
+	<-started
+	waiter := make(chan error)
+	go func() {
+		v, err := db.Fetch("hot key", func(string) (any, error) { return 42, nil })
+		if v != nil {
+			t.Errorf("waiter got %v", v)
+		}
+		waiter <- err
+	}()
+	time.Sleep(20 * time.Millisecond) // let the waiter join the load
+	close(release)
+	if r := <-leader; r != "backend bug" {
+		t.Fatalf("the panic must reach the caller that ran the load, got %v", r)
+	}
+	if err := <-waiter; err == nil {
+		t.Fatal("a waiter on a panicked load must get an error")
+	}
+	if v, err := db.Fetch("hot key", func(string) (any, error) { return 42, nil }); v != 42 || err != nil {
+		t.Fatalf("a later Fetch must load again, got %v, %v", v, err)
+	}
+}
+
+func TestDB_Fetch_EarlyRefresh(t *testing.T) {
+	var calls atomic.Int32
+	load := func(string) (any, error) {
+		time.Sleep(5 * time.Millisecond)
+		return calls.Add(1), nil
+	}
+
+	// β this large makes a refresh all but certain on every hit
+	db := xordb.New(xordb.WithTTL(time.Hour), xordb.WithEarlyRefresh(1e9))
+	db.Fetch("k", load)
+	if v, _ := db.Fetch("k", load); v != int32(2) {
+		t.Fatalf("the hit must have been refreshed, got %v", v)
+	}
+	if r := db.Lookup("k"); r.ExpiresIn <= 0 || r.ExpiresIn > time.Hour {
+		t.Fatalf("ExpiresIn: %v", r.ExpiresIn)
+	}
+
+	calls.Store(0)
+	db = xordb.New(xordb.WithTTL(time.Hour))
+	db.Fetch("k", load)
+	db.Fetch("k", load)
+	if calls.Load() != 1 {
+		t.Fatal("without WithEarlyRefresh, hits must not reload")
+	}
+}
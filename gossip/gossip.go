@@ -0,0 +1,289 @@
+// Package gossip spreads best-effort cache invalidations between xordb
+// nodes that neither replicate nor cluster, e.g. one cache per application
+// instance. When a node deletes a key, its peers drop the key and every
+// entry semantically matching it, since a paraphrase of a stale answer is
+// just as stale.
+//
+// Delivery is soft state: a message may be lost, duplicated or delayed, and
+// nothing retries it. Pair gossip with a TTL so an invalidation that never
+// arrives only keeps an entry until it expires.
+//
+//	node := gossip.New(db, &gossip.Redis{Addr: "redis:6379", Channel: "xordb"})
+//	go node.Run(ctx)
+//	node.Invalidate(ctx, "what is our refund policy") // here and on peers
+package gossip
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// Cache is what a Node invalidates; *xordb.DB satisfies it.
+type Cache interface {
+	Delete(key string) bool
+	SimilarKeys(key string, n int, minSim float64) []xordb.KeySim
+	Threshold() float64
+}
+
+// Transport carries messages between nodes. Publish sends msg to every
+// peer, the sender itself optionally included; Subscribe delivers the
+// messages of peers until ctx is done or the transport fails, and returns
+// why. Both may be called concurrently.
+type Transport interface {
+	Publish(ctx context.Context, msg []byte) error
+	Subscribe(ctx context.Context, deliver func(msg []byte)) error
+}
+
+// Message is one invalidation as sent on the wire, in JSON.
+type Message struct {
+	Origin string `json:"origin"` // sending node
+	ID     uint64 `json:"id"`     // per origin, for dropping duplicates
+	Key    string `json:"key"`
+
+	// MinSim is the similarity at or above which an entry counts as a
+	// match of Key; 0 leaves it to the receiving node's threshold.
+	MinSim float64 `json:"min_sim,omitempty"`
+}
+
+// Stats counts a node's traffic.
+type Stats struct {
+	Sent        uint64 // messages published
+	SendErrors  uint64
+	Received    uint64 // messages from peers, duplicates excluded
+	Duplicates  uint64
+	Malformed   uint64
+	Invalidated uint64 // entries deleted on peers' behalf
+	LastError   string `json:",omitempty"` // of the transport
+}
+
+// Node publishes this node's invalidations and applies its peers'.
+type Node struct {
+	cache      Cache
+	t          Transport
+	name       string
+	minSim     float64
+	maxMatches int
+	backoff    time.Duration
+
+	nextID atomic.Uint64
+
+	mu      sync.Mutex
+	ids     []seenID // ring of recently applied messages, for dropping duplicates
+	next    int
+	lastErr error // of the transport
+
+	sent, sendErrors, received, duplicates, malformed, invalidated atomic.Uint64
+}
+
+type seenID struct {
+	origin string
+	id     uint64
+}
+
+// recentIDs is how many applied messages a node remembers for dropping
+// duplicates that arrive out of order.
+const recentIDs = 1024
+
+type Option func(*Node)
+
+// WithName names the node in its messages (default random). Names must be
+// unique among peers: a node ignores messages bearing its own.
+func WithName(name string) Option {
+	if name == "" {
+		panic("gossip: empty node name")
+	}
+	return func(n *Node) { n.name = name }
+}
+
+// WithMinSimilarity sets the similarity at or above which peers treat an
+// entry as a match of an invalidated key (default 0: each peer's own
+// threshold, i.e. the entries a lookup of the key would hit).
+func WithMinSimilarity(sim float64) Option {
+	if sim < 0 || sim > 1 {
+		panic("gossip: minimum similarity must be in [0, 1]")
+	}
+	return func(n *Node) { n.minSim = sim }
+}
+
+// WithMaxMatches caps the entries one received invalidation deletes besides
+// the key itself (default 16), bounding the damage of an overly general
+// key.
+func WithMaxMatches(k int) Option {
+	if k < 0 {
+		panic("gossip: max matches must not be negative")
+	}
+	return func(n *Node) { n.maxMatches = k }
+}
+
+// WithRetryBackoff sets how long Run waits before resubscribing after the
+// transport fails (default one second, doubling up to 30s).
+func WithRetryBackoff(d time.Duration) Option {
+	if d <= 0 {
+		panic("gossip: retry backoff must be positive")
+	}
+	return func(n *Node) { n.backoff = d }
+}
+
+// New returns a node invalidating c over t. Call Run to receive.
+func New(c Cache, t Transport, opts ...Option) *Node {
+	if c == nil || t == nil {
+		panic("gossip: New needs a cache and a transport")
+	}
+	n := &Node{cache: c, t: t, maxMatches: 16, backoff: time.Second}
+	for _, opt := range opts {
+		opt(n)
+	}
+	if n.name == "" {
+		var b [8]byte
+		rand.Read(b[:])
+		n.name = hex.EncodeToString(b[:])
+	}
+	return n
+}
+
+// Name returns the node's name.
+func (n *Node) Name() string { return n.name }
+
+// Invalidate drops key and the entries matching it here, as a peer would,
+// and broadcasts the invalidation. It returns how many entries were
+// deleted here; the error is the transport's, after the local drop.
+func (n *Node) Invalidate(ctx context.Context, key string) (int, error) {
+	deleted := n.drop(key, n.minSim)
+	return deleted, n.Broadcast(ctx, key)
+}
+
+// Broadcast asks peers to invalidate key without touching the local cache,
+// e.g. after a Delete that should stay exact here.
+func (n *Node) Broadcast(ctx context.Context, key string) error {
+	msg, err := json.Marshal(Message{Origin: n.name, ID: n.nextID.Add(1), Key: key, MinSim: n.minSim})
+	if err != nil {
+		return fmt.Errorf("gossip: %w", err)
+	}
+	if err := n.t.Publish(ctx, msg); err != nil {
+		n.sendErrors.Add(1)
+		n.fail(err)
+		return fmt.Errorf("gossip: publish: %w", err)
+	}
+	n.sent.Add(1)
+	return nil
+}
+
+// Run receives peers' invalidations until ctx is done, resubscribing with
+// backoff when the transport fails. It returns ctx's error.
+func (n *Node) Run(ctx context.Context) error {
+	wait := n.backoff
+	for {
+		start := time.Now()
+		err := n.t.Subscribe(ctx, n.receive)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if time.Since(start) > 4*wait {
+			wait = n.backoff // it had recovered; start over
+		}
+		n.fail(err) // soft state: a lost subscription only delays invalidations
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, 30*time.Second)
+	}
+}
+
+// receive applies one message from the transport.
+func (n *Node) receive(raw []byte) {
+	var m Message
+	if err := json.Unmarshal(raw, &m); err != nil || m.Origin == "" || m.Key == "" {
+		n.malformed.Add(1)
+		return
+	}
+	if m.Origin == n.name {
+		return // our own, echoed by the transport
+	}
+	if !n.firstSight(m.Origin, m.ID) {
+		n.duplicates.Add(1)
+		return
+	}
+	n.received.Add(1)
+	n.invalidated.Add(uint64(n.drop(m.Key, m.MinSim)))
+}
+
+// firstSight records origin's message id and reports whether it is new.
+func (n *Node) firstSight(origin string, id uint64) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, s := range n.ids {
+		if s.origin == origin && s.id == id {
+			return false
+		}
+	}
+	if len(n.ids) < recentIDs {
+		n.ids = append(n.ids, seenID{origin, id})
+	} else {
+		n.ids[n.next] = seenID{origin, id}
+		n.next = (n.next + 1) % recentIDs
+	}
+	return true
+}
+
+// drop deletes key and up to maxMatches entries scoring at least minSim
+// against it, or the cache's threshold if minSim is 0.
+func (n *Node) drop(key string, minSim float64) int {
+	deleted := 0
+	if n.cache.Delete(key) {
+		deleted++
+	}
+	if n.maxMatches == 0 {
+		return deleted
+	}
+	if minSim <= 0 {
+		minSim = n.cache.Threshold()
+	}
+	for _, ks := range n.cache.SimilarKeys(key, n.maxMatches, minSim) {
+		if n.cache.Delete(ks.Key) {
+			deleted++
+		}
+	}
+	return deleted
+}
+
+func (n *Node) fail(err error) {
+	if err == nil {
+		return
+	}
+	n.mu.Lock()
+	n.lastErr = err
+	n.mu.Unlock()
+}
+
+// Stats returns the node's counters.
+func (n *Node) Stats() Stats {
+	n.mu.Lock()
+	var last string
+	if n.lastErr != nil {
+		last = n.lastErr.Error()
+	}
+	n.mu.Unlock()
+	return Stats{
+		LastError:   last,
+		Sent:        n.sent.Load(),
+		SendErrors:  n.sendErrors.Load(),
+		Received:    n.received.Load(),
+		Duplicates:  n.duplicates.Load(),
+		Malformed:   n.malformed.Load(),
+		Invalidated: n.invalidated.Load(),
+	}
+}
+
+// ErrClosed is returned by a closed transport.
+var ErrClosed = errors.New("gossip: transport closed")
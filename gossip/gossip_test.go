@@ -0,0 +1,164 @@
+package gossip_test
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/gossip"
+)
+
+// bus is an in-memory Transport shared by every node in a test, echoing
+// messages to their sender as Redis does.
+type bus struct {
+	mu   sync.Mutex
+	subs []func([]byte)
+}
+
+func (b *bus) Publish(_ context.Context, msg []byte) error {
+	b.mu.Lock()
+	subs := slices.Clone(b.subs)
+	b.mu.Unlock()
+	for _, deliver := range subs {
+		deliver(msg)
+	}
+	return nil
+}
+
+func (b *bus) Subscribe(ctx context.Context, deliver func([]byte)) error {
+	b.mu.Lock()
+	b.subs = append(b.subs, deliver)
+	b.mu.Unlock()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (b *bus) waitSubs(t *testing.T, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		b.mu.Lock()
+		got := len(b.subs)
+		b.mu.Unlock()
+		if got >= n {
+			return
+		}
+	}
+	t.Fatalf("fewer than %d subscribers", n)
+}
+
+func seeded() *xordb.DB {
+	db := xordb.New(xordb.WithThreshold(0.7))
+	db.Set("what is the refund policy", "30 days")
+	db.Set("what's the refund policy", "30 days")
+	db.Set("how do I reset my password", "Settings")
+	return db
+}
+
+func TestNode_Invalidate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var b bus
+	here, there := seeded(), seeded()
+	a := gossip.New(here, &b, gossip.WithName("a"))
+	peer := gossip.New(there, &b, gossip.WithName("b"))
+	go a.Run(ctx)
+	go peer.Run(ctx)
+	b.waitSubs(t, 2)
+
+	n, err := a.Invalidate(ctx, "what is the refund policy")
+	if err != nil || n != 2 {
+		t.Fatalf("Invalidate = %d, %v; want both phrasings dropped here", n, err)
+	}
+	for _, db := range []*xordb.DB{here, there} {
+		if db.Len() != 1 {
+			t.Errorf("Len() = %d, want only the password entry left", db.Len())
+		}
+	}
+	if s := peer.Stats(); s.Received != 1 || s.Invalidated != 2 {
+		t.Errorf("peer stats %+v", s)
+	}
+	if s := a.Stats(); s.Sent != 1 || s.Received != 0 {
+		t.Errorf("own echo must be ignored: %+v", s)
+	}
+
+	// Broadcast leaves the sender alone.
+	here.Set("how do I reset my password", "Settings")
+	there.Set("how do I reset my password", "Settings")
+	if err := a.Broadcast(ctx, "how do I reset my password"); err != nil {
+		t.Fatal(err)
+	}
+	if here.Len() != 1 || there.Len() != 0 {
+		t.Errorf("after Broadcast: here %d, there %d", here.Len(), there.Len())
+	}
+}
+
+func TestNode_DuplicatesAndMalformed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var b bus
+	db := seeded()
+	n := gossip.New(db, &b, gossip.WithName("b"), gossip.WithMaxMatches(0))
+	go n.Run(ctx)
+	b.waitSubs(t, 1)
+
+	msg, _ := json.Marshal(gossip.Message{Origin: "a", ID: 7, Key: "what is the refund policy"})
+	b.Publish(ctx, msg)
+	b.Publish(ctx, msg)
+	b.Publish(ctx, []byte("not json"))
+	s := n.Stats()
+	if s.Received != 1 || s.Duplicates != 1 || s.Malformed != 1 || s.Invalidated != 1 {
+		t.Errorf("stats %+v", s)
+	}
+	if db.Len() != 2 {
+		t.Errorf("WithMaxMatches(0) must delete the exact key only: %d left", db.Len())
+	}
+}
+
+func TestUDP(t *testing.T) {
+	recv, err := gossip.ListenUDP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recv.Close()
+	send, err := gossip.ListenUDP("127.0.0.1:0", recv.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer send.Close()
+
+	// recv only accepts its peers' addresses, and it has none yet: rebuild
+	// it knowing the sender.
+	addr := recv.Addr().String()
+	recv.Close()
+	if recv, err = gossip.ListenUDP(addr, send.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	defer recv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() { done <- recv.Subscribe(ctx, func(m []byte) { got <- string(m) }) }()
+	deadline := time.After(2 * time.Second)
+	for sent := false; !sent; {
+		send.Publish(ctx, []byte("hello"))
+		select {
+		case m := <-got:
+			if m != "hello" {
+				t.Errorf("got %q", m)
+			}
+			sent = true
+		case <-time.After(20 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("no datagram received")
+		}
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Subscribe returned %v after cancel", err)
+	}
+}
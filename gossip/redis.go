@@ -0,0 +1,211 @@
+package gossip
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Redis is a Transport over Redis pub/sub: messages are published to
+// Channel and every node subscribed to it receives them, the sender
+// included. It speaks just enough of the Redis protocol for PUBLISH,
+// SUBSCRIBE and AUTH, so it needs no client library. The zero
+// DialTimeout means five seconds.
+type Redis struct {
+	Addr     string // host:port
+	Channel  string
+	Username string // for AUTH, with Password; empty for a password only
+	Password string // AUTH is sent if set
+
+	DialTimeout time.Duration
+
+	mu  sync.Mutex
+	pub *redisConn // for Publish, dialed on first use
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *Redis) dial(ctx context.Context) (*redisConn, error) {
+	timeout := c.DialTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return nil, err
+	}
+	rc := &redisConn{conn, bufio.NewReader(conn)}
+	if c.Password != "" {
+		args := []string{"AUTH", c.Password}
+		if c.Username != "" {
+			args = []string{"AUTH", c.Username, c.Password}
+		}
+		if _, err := rc.do(ctx, args...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+// Publish sends msg to Channel, dialing or redialing as needed.
+func (c *Redis) Publish(ctx context.Context, msg []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pub == nil {
+		rc, err := c.dial(ctx)
+		if err != nil {
+			return fmt.Errorf("gossip: redis: %w", err)
+		}
+		c.pub = rc
+	}
+	if _, err := c.pub.do(ctx, "PUBLISH", c.Channel, string(msg)); err != nil {
+		var re redisError
+		if !errors.As(err, &re) { // the connection is broken, not the command
+			c.pub.Close()
+			c.pub = nil
+		}
+		return fmt.Errorf("gossip: redis: %w", err)
+	}
+	return nil
+}
+
+// Subscribe delivers the messages published to Channel over a connection
+// of its own until ctx is done or the connection fails.
+func (c *Redis) Subscribe(ctx context.Context, deliver func(msg []byte)) error {
+	rc, err := c.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("gossip: redis: %w", err)
+	}
+	defer rc.Close()
+	stop := context.AfterFunc(ctx, func() { rc.Close() })
+	defer stop()
+	if _, err := rc.do(ctx, "SUBSCRIBE", c.Channel); err != nil {
+		return fmt.Errorf("gossip: redis: %w", err)
+	}
+	rc.SetDeadline(time.Time{})
+	for {
+		v, err := readReply(rc.r)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("gossip: redis: %w", err)
+		}
+		// Pushed messages are ["message", channel, payload].
+		if a, ok := v.([]any); ok && len(a) == 3 && a[0] == "message" {
+			if payload, ok := a[2].(string); ok {
+				deliver([]byte(payload))
+			}
+		}
+	}
+}
+
+// Close closes the publishing connection; running Subscribes end with
+// their context.
+func (c *Redis) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pub == nil {
+		return nil
+	}
+	err := c.pub.Close()
+	c.pub = nil
+	return err
+}
+
+// do sends a command and reads its reply, within ctx's deadline or a
+// minute.
+func (rc *redisConn) do(ctx context.Context, args ...string) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	rc.SetDeadline(deadline)
+	if err := writeCommand(rc, args...); err != nil {
+		return nil, err
+	}
+	return readReply(rc.r)
+}
+
+// writeCommand writes args as a RESP array of bulk strings.
+func writeCommand(w io.Writer, args ...string) error {
+	b := make([]byte, 0, 64)
+	b = append(b, '*')
+	b = strconv.AppendInt(b, int64(len(args)), 10)
+	b = append(b, "\r\n"...)
+	for _, a := range args {
+		b = append(b, '$')
+		b = strconv.AppendInt(b, int64(len(a)), 10)
+		b = append(b, "\r\n"...)
+		b = append(b, a...)
+		b = append(b, "\r\n"...)
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// redisError is an error reply: the server refused a command.
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// readReply reads one RESP2 reply: a string, an int64, nil, a []any, or a
+// redisError.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("malformed bulk length %q", body)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("malformed array length %q", body)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		a := make([]any, n)
+		for i := range a {
+			if a[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	}
+	return nil, fmt.Errorf("unknown reply type %q", kind)
+}
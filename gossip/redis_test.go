@@ -0,0 +1,154 @@
+package gossip_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb/gossip"
+)
+
+// fakeRedis serves AUTH, PUBLISH and SUBSCRIBE for one channel.
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+
+	mu   sync.Mutex
+	subs map[net.Conn]bool
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{ln: ln, password: password, subs: make(map[net.Conn]bool)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			f.mu.Lock()
+			delete(f.subs, conn)
+			f.mu.Unlock()
+			return
+		}
+		switch strings.ToUpper(args[0]) {
+		case "AUTH":
+			if args[len(args)-1] != f.password {
+				io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authed = true
+			io.WriteString(conn, "+OK\r\n")
+		case "SUBSCRIBE":
+			if !authed {
+				io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+				continue
+			}
+			f.mu.Lock()
+			f.subs[conn] = true
+			f.mu.Unlock()
+			fmt.Fprintf(conn, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(args[1]), args[1])
+		case "PUBLISH":
+			if !authed {
+				io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+				continue
+			}
+			f.mu.Lock()
+			for s := range f.subs {
+				fmt.Fprintf(s, "*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(args[1]), args[1], len(args[2]), args[2])
+			}
+			n := len(f.subs)
+			f.mu.Unlock()
+			fmt.Fprintf(conn, ":%d\r\n", n)
+		}
+	}
+}
+
+func (f *fakeRedis) subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs)
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedis(t *testing.T) {
+	f := newFakeRedis(t, "s3cret")
+	tr := &gossip.Redis{Addr: f.ln.Addr().String(), Channel: "xordb", Password: "s3cret"}
+	defer tr.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() { done <- tr.Subscribe(ctx, func(m []byte) { got <- string(m) }) }()
+	for deadline := time.Now().Add(time.Second); f.subscribers() == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no subscription")
+		}
+	}
+
+	if err := tr.Publish(ctx, []byte(`{"key":"a b\r\nc"}`)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case m := <-got:
+		if m != `{"key":"a b\r\nc"}` {
+			t.Errorf("got %q", m)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not delivered")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Subscribe returned %v after cancel", err)
+	}
+
+	bad := &gossip.Redis{Addr: f.ln.Addr().String(), Channel: "xordb", Password: "wrong"}
+	if err := bad.Publish(context.Background(), []byte("x")); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("wrong password: %v", err)
+	}
+}
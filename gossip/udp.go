@@ -0,0 +1,103 @@
+package gossip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// maxDatagram is the largest UDP payload over IPv4.
+const maxDatagram = 65507
+
+// UDP is a Transport that sends every message as one datagram to each of a
+// fixed list of peers, with no broker to run. Messages over 64 KiB cannot
+// be sent. Datagrams from addresses outside the peer list are ignored, but
+// the traffic is neither authenticated nor encrypted: keep it on a private
+// network.
+type UDP struct {
+	conn    *net.UDPConn
+	peers   []*net.UDPAddr
+	allowed map[string]bool // peer IPs
+	closed  atomic.Bool
+}
+
+// ListenUDP listens on addr, e.g. ":7946", and publishes to peers, given
+// as host:port. The list may include the node's own address, so every node
+// can be given the same one.
+func ListenUDP(addr string, peers ...string) (*UDP, error) {
+	la, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("gossip: listen %s: %w", addr, err)
+	}
+	u := &UDP{allowed: make(map[string]bool)}
+	for _, p := range peers {
+		pa, err := net.ResolveUDPAddr("udp", p)
+		if err != nil {
+			return nil, fmt.Errorf("gossip: peer %s: %w", p, err)
+		}
+		u.peers = append(u.peers, pa)
+		u.allowed[pa.IP.String()] = true
+	}
+	if u.conn, err = net.ListenUDP("udp", la); err != nil {
+		return nil, fmt.Errorf("gossip: listen %s: %w", addr, err)
+	}
+	return u, nil
+}
+
+// Addr returns the address the transport listens on.
+func (u *UDP) Addr() net.Addr { return u.conn.LocalAddr() }
+
+// Publish sends msg to every peer. A peer that cannot be reached does not
+// stop the others; the error joins every failure.
+func (u *UDP) Publish(_ context.Context, msg []byte) error {
+	if u.closed.Load() {
+		return ErrClosed
+	}
+	if len(msg) > maxDatagram {
+		return fmt.Errorf("gossip: message of %d bytes exceeds a datagram", len(msg))
+	}
+	var errs []error
+	for _, p := range u.peers {
+		if _, err := u.conn.WriteToUDP(msg, p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Subscribe delivers datagrams from peers until ctx is done or the
+// transport is closed. Only one Subscribe may run at a time.
+func (u *UDP) Subscribe(ctx context.Context, deliver func(msg []byte)) error {
+	if u.closed.Load() {
+		return ErrClosed
+	}
+	u.conn.SetReadDeadline(time.Time{})
+	stop := context.AfterFunc(ctx, func() { u.conn.SetReadDeadline(time.Unix(1, 0)) })
+	defer stop()
+	buf := make([]byte, maxDatagram)
+	for {
+		n, from, err := u.conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if u.closed.Load() {
+				return ErrClosed
+			}
+			return fmt.Errorf("gossip: receive: %w", err)
+		}
+		if !u.allowed[from.IP.String()] {
+			continue
+		}
+		deliver(append([]byte(nil), buf[:n]...))
+	}
+}
+
+// Close stops the transport.
+func (u *UDP) Close() error {
+	u.closed.Store(true)
+	return u.conn.Close()
+}
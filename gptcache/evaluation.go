@@ -0,0 +1,29 @@
+package gptcache
+
+// SimilarityEvaluator scores a candidate against the query, like GPTCache's
+// similarity_evaluation. Scores are normalized over Range before being
+// compared with Config.SimilarityThreshold.
+type SimilarityEvaluator interface {
+	Evaluate(query string, candidate Match) float64
+	Range() (min, max float64)
+}
+
+// SearchDistance scores by the vector similarity found during search
+// (SearchDistanceEvaluation).
+type SearchDistance struct{}
+
+func (SearchDistance) Evaluate(_ string, m Match) float64 { return m.Similarity }
+func (SearchDistance) Range() (float64, float64)          { return 0, 1 }
+
+// ExactMatch accepts only candidates whose question equals the query
+// (ExactMatchEvaluation).
+type ExactMatch struct{}
+
+func (ExactMatch) Evaluate(query string, m Match) float64 {
+	if query == m.Question {
+		return 1
+	}
+	return 0
+}
+
+func (ExactMatch) Range() (float64, float64) { return 0, 1 }
@@ -0,0 +1,220 @@
+// Package gptcache — a GPTCache-shaped front for xordb, for teams moving
+// from the Python GPTCache library.
+//
+//	c := gptcache.New(
+//		gptcache.WithPreProcessor(gptcache.LastContent),
+//		gptcache.WithDataManager(gptcache.DataManager{MaxSize: 5000, Eviction: gptcache.LRU}),
+//		gptcache.WithConfig(gptcache.Config{SimilarityThreshold: 0.8}),
+//	)
+//	if answer, ok, _ := c.Get(req); ok { ... }
+//	c.Put(req, answer)
+//
+// GPTCache concepts map as follows:
+//
+//	pre_embedding_func     PreProcessor (LastContent, AllContent, Prompt)
+//	embedding_func         an hdc.Encoder (WithEncoder); n-gram by default
+//	data_manager           DataManager: size, eviction policy, TTL
+//	similarity_evaluation  SimilarityEvaluator (SearchDistance, ExactMatch)
+//	post_process_func      PostProcessor (First)
+//	Config                 Config.SimilarityThreshold
+//
+// Differences: storage and vector search are xordb's in-memory cache, so
+// there is no separate cache/vector store to configure; eviction is LRU only;
+// and the search returns the single best match, so post-processors always
+// see at most one candidate.
+package gptcache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+)
+
+// Config mirrors gptcache.Config.
+type Config struct {
+	// SimilarityThreshold is compared against the evaluator's score,
+	// normalized to [0, 1] over its Range. Default 0.8, as in GPTCache.
+	SimilarityThreshold float64
+}
+
+// EvictionPolicy names a data-manager eviction strategy.
+type EvictionPolicy string
+
+// LRU is the only policy xordb implements; GPTCache's FIFO, LFU and RR are
+// rejected rather than silently substituted.
+const LRU EvictionPolicy = "LRU"
+
+// DataManager mirrors get_data_manager(..., max_size, eviction).
+type DataManager struct {
+	MaxSize  int            // default 1000, as in GPTCache
+	Eviction EvictionPolicy // default LRU
+	TTL      time.Duration  // zero = never expires
+}
+
+// Cache is a GPTCache-style cache. Safe for concurrent use.
+type Cache struct {
+	db   *xordb.DB
+	pre  PreProcessor
+	eval SimilarityEvaluator
+	post PostProcessor
+	cfg  Config
+}
+
+type Option func(*options)
+
+type options struct {
+	enc  hdc.Encoder
+	dm   DataManager
+	pre  PreProcessor
+	eval SimilarityEvaluator
+	post PostProcessor
+	cfg  Config
+}
+
+// WithEncoder sets the embedding function (e.g. an xordb/embed MiniLM encoder).
+func WithEncoder(enc hdc.Encoder) Option { return func(o *options) { o.enc = enc } }
+
+func WithDataManager(dm DataManager) Option { return func(o *options) { o.dm = dm } }
+
+// WithPreProcessor sets how a request is turned into the text that is
+// embedded. Default LastContent.
+func WithPreProcessor(p PreProcessor) Option { return func(o *options) { o.pre = p } }
+
+// WithSimilarityEvaluator sets how a candidate is scored. Default
+// SearchDistance.
+func WithSimilarityEvaluator(e SimilarityEvaluator) Option {
+	return func(o *options) { o.eval = e }
+}
+
+// WithPostProcessor sets how the answer is chosen from the candidates.
+// Default First.
+func WithPostProcessor(p PostProcessor) Option { return func(o *options) { o.post = p } }
+
+func WithConfig(cfg Config) Option { return func(o *options) { o.cfg = cfg } }
+
+// New is the counterpart of cache.init(...). Panics on invalid
+// configuration, like xordb.New.
+func New(opts ...Option) *Cache {
+	o := options{
+		dm:   DataManager{MaxSize: 1000, Eviction: LRU},
+		pre:  LastContent,
+		eval: SearchDistance{},
+		post: First,
+		cfg:  Config{SimilarityThreshold: 0.8},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.dm.Eviction == "" {
+		o.dm.Eviction = LRU
+	}
+	if o.dm.Eviction != LRU {
+		panic(fmt.Sprintf("gptcache: eviction policy %q not supported, xordb evicts LRU", o.dm.Eviction))
+	}
+	if o.cfg.SimilarityThreshold <= 0 || o.cfg.SimilarityThreshold > 1 {
+		panic("gptcache: Config.SimilarityThreshold must be in (0, 1]")
+	}
+	if o.pre == nil || o.eval == nil || o.post == nil {
+		panic("gptcache: processors and evaluator must not be nil")
+	}
+
+	// The vector search uses the same threshold, so with SearchDistance the
+	// evaluation is a re-check; stricter evaluators further filter its hits.
+	dbOpts := []xordb.Option{
+		xordb.WithThreshold(o.cfg.SimilarityThreshold),
+		xordb.WithCapacity(o.dm.MaxSize),
+		xordb.WithTTL(o.dm.TTL),
+	}
+	var db *xordb.DB
+	if o.enc != nil {
+		db = xordb.NewWithEncoder(o.enc, dbOpts...)
+	} else {
+		db = xordb.New(dbOpts...)
+	}
+	return &Cache{db: db, pre: o.pre, eval: o.eval, post: o.post, cfg: o.cfg}
+}
+
+// DB exposes the underlying xordb cache (stats, persistence).
+func (c *Cache) DB() *xordb.DB { return c.db }
+
+// stored is what goes into the DB: evaluators need the original question.
+type stored struct {
+	Question string `json:"question"`
+	Answer   any    `json:"answer"`
+}
+
+// unwrap accepts a stored value, or its JSON form after a Save/Load round
+// trip.
+func unwrap(v any) (stored, bool) {
+	switch v := v.(type) {
+	case stored:
+		return v, true
+	case map[string]any:
+		q, ok := v["question"].(string)
+		return stored{Question: q, Answer: v["answer"]}, ok
+	}
+	return stored{}, false
+}
+
+// Get pre-processes req and returns the cached answer, if any. The error is
+// from the pre-processor.
+func (c *Cache) Get(req map[string]any) (any, bool, error) {
+	q, err := c.pre(req)
+	if err != nil {
+		return nil, false, err
+	}
+	v, ok := c.GetPrompt(q)
+	return v, ok, nil
+}
+
+// Put pre-processes req and caches answer under it.
+func (c *Cache) Put(req map[string]any, answer any) error {
+	q, err := c.pre(req)
+	if err != nil {
+		return err
+	}
+	c.PutPrompt(q, answer)
+	return nil
+}
+
+// GetPrompt is gptcache.adapter.api.get: look up a plain prompt, skipping
+// the pre-processor.
+func (c *Cache) GetPrompt(prompt string) (any, bool) {
+	v, ok, sim := c.db.Get(prompt)
+	if !ok {
+		return nil, false
+	}
+	s, ok := unwrap(v)
+	if !ok {
+		return nil, false // written to the DB directly, not through this adapter
+	}
+	m := Match{Question: s.Question, Answer: s.Answer, Similarity: sim}
+
+	lo, hi := c.eval.Range()
+	score := c.eval.Evaluate(prompt, m)
+	if hi > lo {
+		score = (score - lo) / (hi - lo)
+	}
+	if score < c.cfg.SimilarityThreshold {
+		return nil, false
+	}
+	return c.post([]Match{m}).Answer, true
+}
+
+// PutPrompt is gptcache.adapter.api.put.
+func (c *Cache) PutPrompt(prompt string, answer any) {
+	c.db.Set(prompt, stored{Question: prompt, Answer: answer})
+}
+
+// ImportData mirrors cache.import_data: bulk-load question/answer pairs.
+func (c *Cache) ImportData(questions []string, answers []any) error {
+	if len(questions) != len(answers) {
+		return fmt.Errorf("gptcache: import: %d questions but %d answers", len(questions), len(answers))
+	}
+	for i, q := range questions {
+		c.PutPrompt(q, answers[i])
+	}
+	return nil
+}
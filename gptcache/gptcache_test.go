@@ -0,0 +1,92 @@
+package gptcache_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb/gptcache"
+)
+
+func chat(msgs ...string) map[string]any {
+	var out []any
+	for _, m := range msgs {
+		out = append(out, map[string]any{"role": "user", "content": m})
+	}
+	return map[string]any{"model": "gpt-4o", "messages": out}
+}
+
+func TestCache_GetPut_LastContent(t *testing.T) {
+	c := gptcache.New(gptcache.WithConfig(gptcache.Config{SimilarityThreshold: 0.7}))
+
+	if err := c.Put(chat("hi", "what is the capital of india"), "Delhi"); err != nil {
+		t.Fatal(err)
+	}
+	v, ok, err := c.Get(chat("capital city of india"))
+	if err != nil || !ok || v != "Delhi" {
+		t.Fatalf("want semantic hit Delhi, got %v ok=%v err=%v", v, ok, err)
+	}
+	if _, ok, _ := c.Get(chat("how do I bake bread")); ok {
+		t.Fatal("unrelated query must miss")
+	}
+}
+
+func TestCache_PreProcessorErrors(t *testing.T) {
+	c := gptcache.New()
+	if _, _, err := c.Get(map[string]any{"messages": []any{}}); err == nil {
+		t.Fatal("empty messages must error")
+	}
+	c = gptcache.New(gptcache.WithPreProcessor(gptcache.Prompt))
+	if err := c.Put(map[string]any{"prompt": 3}, "x"); err == nil {
+		t.Fatal("non-string prompt must error")
+	}
+}
+
+func TestAllContent(t *testing.T) {
+	got, err := gptcache.AllContent(chat("a", "b"))
+	if err != nil || got != "a\nb" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+}
+
+func TestCache_ExactMatchEvaluator(t *testing.T) {
+	c := gptcache.New(
+		gptcache.WithSimilarityEvaluator(gptcache.ExactMatch{}),
+		gptcache.WithConfig(gptcache.Config{SimilarityThreshold: 0.7}),
+	)
+	c.PutPrompt("what is the capital of india", "Delhi")
+	if _, ok := c.GetPrompt("capital city of india"); ok {
+		t.Fatal("exact-match evaluator must reject paraphrases")
+	}
+	if v, ok := c.GetPrompt("what is the capital of india"); !ok || v != "Delhi" {
+		t.Fatalf("exact query must hit, got %v ok=%v", v, ok)
+	}
+}
+
+func TestCache_UnsupportedEviction_Panics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic for FIFO eviction")
+		}
+	}()
+	gptcache.New(gptcache.WithDataManager(gptcache.DataManager{MaxSize: 10, Eviction: "FIFO"}))
+}
+
+func TestCache_ImportData_SurvivesSaveLoad(t *testing.T) {
+	c := gptcache.New()
+	if err := c.ImportData([]string{"q1", "q2"}, []any{"a1"}); err == nil {
+		t.Fatal("mismatched lengths must error")
+	}
+	c.ImportData([]string{"who wrote ramayana"}, []any{"Valmiki"})
+
+	path := filepath.Join(t.TempDir(), "c.xrdb")
+	if err := c.DB().Save(path); err != nil {
+		t.Fatal(err)
+	}
+	c2 := gptcache.New()
+	if err := c2.DB().Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := c2.GetPrompt("who wrote ramayana"); !ok || v != "Valmiki" {
+		t.Fatalf("want Valmiki after reload, got %v ok=%v", v, ok)
+	}
+}
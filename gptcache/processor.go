@@ -0,0 +1,81 @@
+package gptcache
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PreProcessor extracts the text to embed from a request, like GPTCache's
+// pre_embedding_func. Requests are decoded JSON, e.g. an OpenAI chat body.
+type PreProcessor func(req map[string]any) (string, error)
+
+// PostProcessor picks the answer from the evaluated candidates, best first,
+// like GPTCache's post_process_messages_func. candidates is never empty.
+type PostProcessor func(candidates []Match) Match
+
+// Match is a cached candidate for a query.
+type Match struct {
+	Question   string // the cached question the answer was stored under
+	Answer     any
+	Similarity float64 // vector similarity to the query
+}
+
+var errNoMessages = errors.New("gptcache: request has no messages")
+
+// LastContent uses the content of the last chat message (last_content).
+func LastContent(req map[string]any) (string, error) {
+	msgs, err := messages(req)
+	if err != nil {
+		return "", err
+	}
+	return content(msgs[len(msgs)-1])
+}
+
+// AllContent joins the content of every chat message with newlines
+// (all_content), so the whole conversation must be similar to hit.
+func AllContent(req map[string]any) (string, error) {
+	msgs, err := messages(req)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, len(msgs))
+	for i, m := range msgs {
+		if parts[i], err = content(m); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// Prompt uses the "prompt" field of a completion request (get_prompt).
+func Prompt(req map[string]any) (string, error) {
+	p, ok := req["prompt"].(string)
+	if !ok {
+		return "", errors.New(`gptcache: request has no string "prompt"`)
+	}
+	return p, nil
+}
+
+// First returns the best candidate.
+func First(candidates []Match) Match { return candidates[0] }
+
+func messages(req map[string]any) ([]any, error) {
+	msgs, ok := req["messages"].([]any)
+	if !ok || len(msgs) == 0 {
+		return nil, errNoMessages
+	}
+	return msgs, nil
+}
+
+func content(msg any) (string, error) {
+	m, ok := msg.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("gptcache: message is %T, not an object", msg)
+	}
+	s, ok := m["content"].(string)
+	if !ok {
+		return "", errors.New(`gptcache: message has no string "content"`)
+	}
+	return s, nil
+}
@@ -0,0 +1,74 @@
+// Package hdcx provides hypervector operations that hdc-go v0.1.0 lacks,
+// built only on its public API (hdc.FromWords and Vector.RawData), so they
+// work with any hdc.Vector. Results are ordinary hdc.Vectors.
+package hdcx
+
+import (
+	"math/bits"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// counts returns, per bit, how many of vecs have it set. Panics on an empty
+// or mixed-dims input.
+func counts(vecs []hdc.Vector) []int32 {
+	if len(vecs) == 0 {
+		panic("hdcx: need at least one vector")
+	}
+	dims := vecs[0].Dims()
+	c := make([]int32, dims)
+	for _, v := range vecs {
+		if v.Dims() != dims {
+			panic("hdcx: vectors must have the same dims")
+		}
+		for w, word := range v.RawData() {
+			for word != 0 {
+				b := bits.TrailingZeros64(word)
+				c[w*64+b]++
+				word &= word - 1
+			}
+		}
+	}
+	return c
+}
+
+// fromBits builds a vector whose bit i is set when set(i) is true.
+func fromBits(dims int, set func(i int) bool) hdc.Vector {
+	words := make([]uint64, hdc.NumWords(dims))
+	for i := 0; i < dims; i++ {
+		if set(i) {
+			words[i/64] |= 1 << uint(i%64)
+		}
+	}
+	return hdc.FromWords(dims, words)
+}
+
+// BundleThreshold sets each bit that at least threshold of vecs have set.
+// threshold len(vecs)/2+1 is hdc.Bundle's strict majority; lower values
+// keep more of each input (an OR at 1), higher ones less (an AND at
+// len(vecs)).
+func BundleThreshold(vecs []hdc.Vector, threshold int) hdc.Vector {
+	c := counts(vecs)
+	return fromBits(len(c), func(i int) bool { return int(c[i]) >= threshold })
+}
+
+// Bundle is the majority vote of vecs, like hdc.Bundle, except that a bit
+// set in exactly half of an even number of inputs is taken from a random
+// vector seeded by tieSeed instead of always being 0. hdc.Bundle's 0-ties
+// make bundles of an even count sparser than half density, which biases
+// their similarities slightly; seeded ties keep the density at one half and
+// the result deterministic.
+func Bundle(tieSeed uint64, vecs ...hdc.Vector) hdc.Vector {
+	c := counts(vecs)
+	n := int32(len(vecs))
+	if n%2 == 1 {
+		return fromBits(len(c), func(i int) bool { return 2*c[i] > n })
+	}
+	tie := hdc.Random(len(c), tieSeed).RawData()
+	return fromBits(len(c), func(i int) bool {
+		if 2*c[i] == n {
+			return tie[i/64]>>uint(i%64)&1 == 1
+		}
+		return 2*c[i] > n
+	})
+}
@@ -0,0 +1,69 @@
+package hdcx_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+func TestBundleThreshold(t *testing.T) {
+	a := hdc.FromWords(8, []uint64{0b0111})
+	b := hdc.FromWords(8, []uint64{0b0011})
+	c := hdc.FromWords(8, []uint64{0b0001})
+	vecs := []hdc.Vector{a, b, c}
+	for th, want := range map[int]uint64{1: 0b0111, 2: 0b0011, 3: 0b0001, 4: 0} {
+		if got := hdcx.BundleThreshold(vecs, th).RawData()[0]; got != want {
+			t.Errorf("threshold %d: got %04b, want %04b", th, got, want)
+		}
+	}
+}
+
+func TestBundleThreshold_MatchesMajority(t *testing.T) {
+	var vecs []hdc.Vector
+	for i := uint64(0); i < 5; i++ {
+		vecs = append(vecs, hdc.Random(1000, i))
+	}
+	if got, want := hdcx.BundleThreshold(vecs, 3), hdc.Bundle(vecs...); hdc.Similarity(got, want) != 1 {
+		t.Fatal("threshold len/2+1 must equal hdc.Bundle")
+	}
+	if got, want := hdcx.Bundle(42, vecs...), hdc.Bundle(vecs...); hdc.Similarity(got, want) != 1 {
+		t.Fatal("odd counts have no ties: Bundle must equal hdc.Bundle")
+	}
+}
+
+func TestBundle_SeededTies(t *testing.T) {
+	const dims = 10000
+	a, b := hdc.Random(dims, 1), hdc.Random(dims, 2)
+	x := hdcx.Bundle(7, a, b)
+	if y := hdcx.Bundle(7, a, b); hdc.Similarity(x, y) != 1 {
+		t.Fatal("Bundle must be deterministic for a tie seed")
+	}
+	if d := density(x); d < 0.48 || d > 0.52 {
+		t.Fatalf("seeded ties must keep density near 0.5, got %.3f", d)
+	}
+	if d := density(hdc.Bundle(a, b)); d > 0.3 {
+		t.Fatalf("hdc.Bundle of two resolves ties to 0 (density ~0.25), got %.3f", d)
+	}
+	// Each input stays about equally similar to the bundle.
+	if sa, sb := hdc.Similarity(x, a), hdc.Similarity(x, b); sa < 0.7 || sb < 0.7 {
+		t.Fatalf("bundle must resemble its inputs: %.3f %.3f", sa, sb)
+	}
+}
+
+func TestBundle_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("mixed dims must panic")
+		}
+	}()
+	hdcx.Bundle(0, hdc.New(64), hdc.New(128))
+}
+
+func density(v hdc.Vector) float64 {
+	n := 0
+	for i := 0; i < v.Dims(); i++ {
+		n += int(v.Bit(i))
+	}
+	return float64(n) / float64(v.Dims())
+}
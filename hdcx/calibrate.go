@@ -0,0 +1,122 @@
+package hdcx
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// Calibration is the outcome of a labeled set of similarities at one
+// threshold, counting a score at or above Threshold as a hit: TP are
+// positives hit, FP negatives hit, FN positives missed, TN negatives missed.
+type Calibration struct {
+	Threshold      float64
+	TP, FP, FN, TN int
+}
+
+// Precision is the fraction of hits that are positives (0 without hits).
+func (c Calibration) Precision() float64 { return ratio(c.TP, c.TP+c.FP) }
+
+// Recall is the fraction of positives hit.
+func (c Calibration) Recall() float64 { return ratio(c.TP, c.TP+c.FN) }
+
+// FPR is the expected false-positive rate: the fraction of negatives hit.
+func (c Calibration) FPR() float64 { return ratio(c.FP, c.FP+c.TN) }
+
+// FNR is the expected false-negative rate: the fraction of positives missed.
+func (c Calibration) FNR() float64 { return ratio(c.FN, c.TP+c.FN) }
+
+// F1 is the harmonic mean of precision and recall.
+func (c Calibration) F1() float64 {
+	p, r := c.Precision(), c.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// Objective scores a Calibration; higher is better.
+type Objective func(Calibration) float64
+
+// MaxF1 is the default objective.
+func MaxF1(c Calibration) float64 { return c.F1() }
+
+// MinPrecision maximizes recall among thresholds whose precision is at
+// least p, i.e. picks the lowest threshold that keeps precision at p.
+func MinPrecision(p float64) Objective {
+	return func(c Calibration) float64 {
+		if c.TP+c.FP == 0 || c.Precision() < p {
+			return -1
+		}
+		return c.Recall()
+	}
+}
+
+// CalibrateScores returns the threshold maximizing obj (MaxF1 if nil) over
+// positive and negative similarity scores, with the counts there. Every
+// distinct score is a candidate, so the cut is exact rather than on a grid;
+// ties go to the higher threshold, which serves fewer wrong hits for the
+// same score. With no scores it returns threshold 1.
+func CalibrateScores(positives, negatives []float64, obj Objective) Calibration {
+	if obj == nil {
+		obj = MaxF1
+	}
+	type scored struct {
+		sim float64
+		pos bool
+	}
+	all := make([]scored, 0, len(positives)+len(negatives))
+	for _, s := range positives {
+		all = append(all, scored{s, true})
+	}
+	for _, s := range negatives {
+		all = append(all, scored{s, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].sim > all[j].sim })
+
+	best := Calibration{Threshold: 1, FN: len(positives), TN: len(negatives)}
+	if len(all) == 0 {
+		return best
+	}
+	// Lower the threshold one distinct score at a time, admitting ties
+	// together.
+	cur, bestScore := best, math.Inf(-1)
+	for i, s := range all {
+		if s.pos {
+			cur.TP++
+			cur.FN--
+		} else {
+			cur.FP++
+			cur.TN--
+		}
+		if i+1 < len(all) && all[i+1].sim == s.sim {
+			continue
+		}
+		cur.Threshold = s.sim
+		if sc := obj(cur); sc > bestScore {
+			best, bestScore = cur, sc
+		}
+	}
+	return best
+}
+
+// CalibrateThreshold is CalibrateScores over the hdc.Similarity of each
+// pair: positives should hit each other, negatives should not.
+func CalibrateThreshold(positives, negatives [][2]hdc.Vector, obj Objective) Calibration {
+	sims := func(pairs [][2]hdc.Vector) []float64 {
+		out := make([]float64, len(pairs))
+		for i, p := range pairs {
+			out[i] = hdc.Similarity(p[0], p[1])
+		}
+		return out
+	}
+	return CalibrateScores(sims(positives), sims(negatives), obj)
+}
@@ -0,0 +1,61 @@
+package hdcx_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+// ── CalibrateScores ─────────────────────────────────────────────────────────
+
+func TestCalibrateScores_ExactCut(t *testing.T) {
+	c := hdcx.CalibrateScores([]float64{0.91, 0.83, 0.77}, []float64{0.74, 0.62}, nil)
+	if c.Threshold != 0.77 || c.TP != 3 || c.FP != 0 || c.F1() != 1 {
+		t.Fatalf("want a perfect cut at 0.77, got %+v", c)
+	}
+	if c.FPR() != 0 || c.FNR() != 0 {
+		t.Fatalf("error rates: FPR %v FNR %v", c.FPR(), c.FNR())
+	}
+}
+
+func TestCalibrateScores_Ties(t *testing.T) {
+	// A positive and a negative share 0.8: both are admitted together.
+	c := hdcx.CalibrateScores([]float64{0.9, 0.8}, []float64{0.8, 0.5}, hdcx.MinPrecision(1))
+	if c.Threshold != 0.9 || c.TP != 1 || c.FP != 0 {
+		t.Fatalf("a tied negative must keep the cut above it, got %+v", c)
+	}
+}
+
+func TestCalibrateScores_MinPrecision(t *testing.T) {
+	pos := []float64{0.95, 0.9, 0.85, 0.7}
+	neg := []float64{0.8, 0.6}
+	c := hdcx.CalibrateScores(pos, neg, hdcx.MinPrecision(0.8))
+	if c.Threshold != 0.7 || c.TP != 4 || c.FP != 1 {
+		t.Fatalf("want the lowest cut keeping precision ≥0.8, got %+v", c)
+	}
+	if c.FPR() != 0.5 || c.FNR() != 0 {
+		t.Fatalf("error rates: FPR %v FNR %v", c.FPR(), c.FNR())
+	}
+}
+
+func TestCalibrateScores_Empty(t *testing.T) {
+	c := hdcx.CalibrateScores(nil, nil, nil)
+	if c.Threshold != 1 || c.TP+c.FP+c.FN+c.TN != 0 {
+		t.Fatalf("got %+v", c)
+	}
+}
+
+// ── CalibrateThreshold ──────────────────────────────────────────────────────
+
+func TestCalibrateThreshold(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	pair := func(a, b string) [2]hdc.Vector { return [2]hdc.Vector{enc.Encode(a), enc.Encode(b)} }
+	c := hdcx.CalibrateThreshold(
+		[][2]hdc.Vector{pair("what is the capital of france", "what's the capital of france")},
+		[][2]hdc.Vector{pair("what is the capital of france", "how do i bake bread")},
+		nil)
+	if c.TP != 1 || c.FP != 0 || c.Threshold <= 0.5 {
+		t.Fatalf("got %+v", c)
+	}
+}
@@ -0,0 +1,37 @@
+package hdcx
+
+import (
+	"strings"
+	"unicode"
+)
+
+// FoldAccents lowercases text and removes diacritics: precomposed letters
+// become their base letter and combining marks are dropped — the NFD,
+// strip-Mn, lowercase sequence of BERT's uncased tokenizer, for Latin, Greek
+// and Cyrillic. "Café" and "café" both become "cafe". The WordPiece
+// tokenizer in xordb/embed and Profile.FoldAccents both use it, so the
+// n-gram and MiniLM encoders see the same text.
+func FoldAccents(text string) string {
+	ascii := true
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return strings.ToLower(text)
+	}
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range text {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if base, ok := unaccent[r]; ok {
+			r = base
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
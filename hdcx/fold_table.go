@@ -0,0 +1,125 @@
+package hdcx
+
+// accented lists, for each base letter, the precomposed letters whose
+// canonical decomposition (NFD) is that letter plus combining marks only,
+// for the Latin, Greek and Cyrillic blocks (Unicode 14). FoldAccents maps
+// them back to the base letter without pulling in a normalization library.
+var accented = map[rune]string{
+	'A': "ÀÁÂÃÄÅĀĂĄǍǞǠǺȀȂȦḀẠẢẤẦẨẪẬẮẰẲẴẶ",
+	'B': "ḂḄḆ",
+	'C': "ÇĆĈĊČḈ",
+	'D': "ĎḊḌḎḐḒ",
+	'E': "ÈÉÊËĒĔĖĘĚȄȆȨḔḖḘḚḜẸẺẼẾỀỂỄỆ",
+	'F': "Ḟ",
+	'G': "ĜĞĠĢǦǴḠ",
+	'H': "ĤȞḢḤḦḨḪ",
+	'I': "ÌÍÎÏĨĪĬĮİǏȈȊḬḮỈỊ",
+	'J': "Ĵ",
+	'K': "ĶǨḰḲḴ",
+	'L': "ĹĻĽḶḸḺḼ",
+	'M': "ḾṀṂ",
+	'N': "ÑŃŅŇǸṄṆṈṊ",
+	'O': "ÒÓÔÕÖŌŎŐƠǑǪǬȌȎȪȬȮȰṌṎṐṒỌỎỐỒỔỖỘỚỜỞỠỢ",
+	'P': "ṔṖ",
+	'R': "ŔŖŘȐȒṘṚṜṞ",
+	'S': "ŚŜŞŠȘṠṢṤṦṨ",
+	'T': "ŢŤȚṪṬṮṰ",
+	'U': "ÙÚÛÜŨŪŬŮŰŲƯǓǕǗǙǛȔȖṲṴṶṸṺỤỦỨỪỬỮỰ",
+	'V': "ṼṾ",
+	'W': "ŴẀẂẄẆẈ",
+	'X': "ẊẌ",
+	'Y': "ÝŶŸȲẎỲỴỶỸ",
+	'Z': "ŹŻŽẐẒẔ",
+	'a': "àáâãäåāăąǎǟǡǻȁȃȧḁạảấầẩẫậắằẳẵặ",
+	'b': "ḃḅḇ",
+	'c': "çćĉċčḉ",
+	'd': "ďḋḍḏḑḓ",
+	'e': "èéêëēĕėęěȅȇȩḕḗḙḛḝẹẻẽếềểễệ",
+	'f': "ḟ",
+	'g': "ĝğġģǧǵḡ",
+	'h': "ĥȟḣḥḧḩḫẖ",
+	'i': "ìíîïĩīĭįǐȉȋḭḯỉị",
+	'j': "ĵǰ",
+	'k': "ķǩḱḳḵ",
+	'l': "ĺļľḷḹḻḽ",
+	'm': "ḿṁṃ",
+	'n': "ñńņňǹṅṇṉṋ",
+	'o': "òóôõöōŏőơǒǫǭȍȏȫȭȯȱṍṏṑṓọỏốồổỗộớờởỡợ",
+	'p': "ṕṗ",
+	'r': "ŕŗřȑȓṙṛṝṟ",
+	's': "śŝşšșṡṣṥṧṩ",
+	't': "ţťțṫṭṯṱẗ",
+	'u': "ùúûüũūŭůűųưǔǖǘǚǜȕȗṳṵṷṹṻụủứừửữự",
+	'v': "ṽṿ",
+	'w': "ŵẁẃẅẇẉẘ",
+	'x': "ẋẍ",
+	'y': "ýÿŷȳẏẙỳỵỷỹ",
+	'z': "źżžẑẓẕ",
+	'Æ': "ǢǼ",
+	'Ø': "Ǿ",
+	'æ': "ǣǽ",
+	'ø': "ǿ",
+	'ſ': "ẛ",
+	'Ʒ': "Ǯ",
+	'ʒ': "ǯ",
+	'Α': "ΆἈἉἊἋἌἍἎἏᾈᾉᾊᾋᾌᾍᾎᾏᾸᾹᾺΆᾼ",
+	'Ε': "ΈἘἙἚἛἜἝῈΈ",
+	'Η': "ΉἨἩἪἫἬἭἮἯᾘᾙᾚᾛᾜᾝᾞᾟῊΉῌ",
+	'Ι': "ΊΪἸἹἺἻἼἽἾἿῘῙῚΊ",
+	'Ο': "ΌὈὉὊὋὌὍῸΌ",
+	'Ρ': "Ῥ",
+	'Υ': "ΎΫὙὛὝὟῨῩῪΎ",
+	'Ω': "ΏὨὩὪὫὬὭὮὯᾨᾩᾪᾫᾬᾭᾮᾯῺΏῼ",
+	'α': "άἀἁἂἃἄἅἆἇὰάᾀᾁᾂᾃᾄᾅᾆᾇᾰᾱᾲᾳᾴᾶᾷ",
+	'ε': "έἐἑἒἓἔἕὲέ",
+	'η': "ήἠἡἢἣἤἥἦἧὴήᾐᾑᾒᾓᾔᾕᾖᾗῂῃῄῆῇ",
+	'ι': "ΐίϊἰἱἲἳἴἵἶἷὶίῐῑῒΐῖῗ",
+	'ο': "όὀὁὂὃὄὅὸό",
+	'ρ': "ῤῥ",
+	'υ': "ΰϋύὐὑὒὓὔὕὖὗὺύῠῡῢΰῦῧ",
+	'ω': "ώὠὡὢὣὤὥὦὧὼώᾠᾡᾢᾣᾤᾥᾦᾧῲῳῴῶῷ",
+	'ϒ': "ϓϔ",
+	'І': "Ї",
+	'А': "ӐӒ",
+	'Г': "Ѓ",
+	'Е': "ЀЁӖ",
+	'Ж': "ӁӜ",
+	'З': "Ӟ",
+	'И': "ЍЙӢӤ",
+	'К': "Ќ",
+	'О': "Ӧ",
+	'У': "ЎӮӰӲ",
+	'Ч': "Ӵ",
+	'Ы': "Ӹ",
+	'Э': "Ӭ",
+	'а': "ӑӓ",
+	'г': "ѓ",
+	'е': "ѐёӗ",
+	'ж': "ӂӝ",
+	'з': "ӟ",
+	'и': "йѝӣӥ",
+	'к': "ќ",
+	'о': "ӧ",
+	'у': "ўӯӱӳ",
+	'ч': "ӵ",
+	'ы': "ӹ",
+	'э': "ӭ",
+	'і': "ї",
+	'Ѵ': "Ѷ",
+	'ѵ': "ѷ",
+	'Ә': "Ӛ",
+	'ә': "ӛ",
+	'Ө': "Ӫ",
+	'ө': "ӫ",
+}
+
+// unaccent inverts accented.
+var unaccent = func() map[rune]rune {
+	m := make(map[rune]rune, 800)
+	for base, variants := range accented {
+		for _, r := range variants {
+			m[r] = base
+		}
+	}
+	return m
+}()
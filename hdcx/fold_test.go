@@ -0,0 +1,26 @@
+package hdcx_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+func TestFoldAccents(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"Hello World", "hello world"},
+		{"Café", "cafe"},
+		{"café", "cafe"}, // already decomposed
+		{"São Paulo, Zürich, Kraków", "sao paulo, zurich, krakow"},
+		{"Tiếng Việt", "tieng viet"},
+		{"Ελληνικά άέή", "ελληνικα αεη"},
+		{"Ёлка й", "елка и"},
+		{"Straße Øre Łódź", "straße øre łodz"}, // no canonical decomposition
+		{"東京", "東京"},
+	}
+	for _, c := range cases {
+		if got := hdcx.FoldAccents(c.in); got != c.want {
+			t.Errorf("FoldAccents(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package hdcx
+
+import "github.com/Amansingh-afk/hdc-go"
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// ByteHasher folds a stream of bytes into a hypervector: bytes are hashed
+// with 64-bit FNV-1a (seeded), and Vector expands the hash into dims
+// pseudorandom bits. Equal input gives an equal vector; any difference gives
+// a quasi-orthogonal one (similarity ~0.5), so hashed keys match exactly or
+// not at all. It implements io.Writer.
+type ByteHasher struct {
+	dims int
+	seed uint64
+	h    uint64
+}
+
+// NewByteHasher returns a hasher producing vectors of dims bits; different
+// seeds give unrelated vectors for the same bytes.
+func NewByteHasher(dims int, seed uint64) *ByteHasher {
+	if dims <= 0 {
+		panic("hdcx: dims must be positive")
+	}
+	b := &ByteHasher{dims: dims, seed: seed}
+	b.Reset()
+	return b
+}
+
+// Write adds p to the hashed stream. It never fails.
+func (b *ByteHasher) Write(p []byte) (int, error) {
+	h := b.h
+	for _, c := range p {
+		h ^= uint64(c)
+		h *= fnvPrime
+	}
+	b.h = h
+	return len(p), nil
+}
+
+// Reset discards the bytes written so far.
+func (b *ByteHasher) Reset() { b.h = fnvOffset ^ mix(b.seed) }
+
+// Vector returns the hypervector for the bytes written so far.
+func (b *ByteHasher) Vector() hdc.Vector {
+	return Random(b.dims, b.h)
+}
+
+// HashBytes returns the ByteHasher vector of data.
+func HashBytes(data []byte, dims int, seed uint64) hdc.Vector {
+	b := NewByteHasher(dims, seed)
+	b.Write(data)
+	return b.Vector()
+}
+
+// ByteEncoder is an hdc.Encoder for binary keys — image hashes, serialized
+// protos — that should not go through text normalization: it hashes the
+// key's raw bytes with HashBytes. Use it with xordb.NewWithEncoder and pass
+// keys as string(data). Lookups hit only on exact matches.
+type ByteEncoder struct {
+	Dims int
+	Seed uint64
+}
+
+func (e ByteEncoder) Encode(key string) hdc.Vector {
+	b := NewByteHasher(e.Dims, e.Seed)
+	b.Write([]byte(key))
+	return b.Vector()
+}
+
+// mix is the splitmix64 finalizer.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
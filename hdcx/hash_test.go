@@ -0,0 +1,61 @@
+package hdcx_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+func TestHashBytes(t *testing.T) {
+	data := []byte{0x00, 0xff, 0x10, 'a', 0x00}
+	a := hdcx.HashBytes(data, 1000, 1)
+	if a.Dims() != 1000 {
+		t.Fatalf("dims: got %d", a.Dims())
+	}
+	if hdc.Similarity(a, hdcx.HashBytes(data, 1000, 1)) != 1 {
+		t.Fatal("HashBytes must be deterministic")
+	}
+	for name, v := range map[string]hdc.Vector{
+		"one byte changed": hdcx.HashBytes([]byte{0x00, 0xff, 0x11, 'a', 0x00}, 1000, 1),
+		"other seed":       hdcx.HashBytes(data, 1000, 2),
+		"prefix":           hdcx.HashBytes(data[:4], 1000, 1),
+	} {
+		if s := hdc.Similarity(a, v); s > 0.6 || s < 0.4 {
+			t.Errorf("%s: want a quasi-orthogonal vector, similarity %.3f", name, s)
+		}
+	}
+	if d := density(a); d < 0.45 || d > 0.55 {
+		t.Fatalf("density %.3f, want ~0.5", d)
+	}
+}
+
+func TestByteHasher_Streaming(t *testing.T) {
+	data := bytes.Repeat([]byte("chunked binary payload "), 100)
+	h := hdcx.NewByteHasher(512, 9)
+	if _, err := io.Copy(h, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if hdc.Similarity(h.Vector(), hdcx.HashBytes(data, 512, 9)) != 1 {
+		t.Fatal("streamed and one-shot hashes must agree")
+	}
+	h.Reset()
+	if hdc.Similarity(h.Vector(), hdcx.HashBytes(nil, 512, 9)) != 1 {
+		t.Fatal("Reset must start a new stream")
+	}
+}
+
+func TestByteEncoder_WithDB(t *testing.T) {
+	db := xordb.NewWithEncoder(hdcx.ByteEncoder{Dims: 10000, Seed: 1})
+	key := string([]byte{0xde, 0xad, 0xbe, 0xef})
+	db.Set(key, "image-42")
+	if v, ok, _ := db.Get(key); !ok || v != "image-42" {
+		t.Fatalf("exact binary key must hit, got %v %v", v, ok)
+	}
+	if _, ok, _ := db.Get(string([]byte{0xde, 0xad, 0xbe, 0xee})); ok {
+		t.Fatal("a different binary key must miss")
+	}
+}
@@ -0,0 +1,59 @@
+package hdcx
+
+import (
+	"math"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// Interleave builds a vector whose every bit is copied from one of vecs,
+// chosen pseudorandomly (by seed and bit position) with probability
+// proportional to its weight. Two interleavings with the same seed and
+// weights then compare as the weighted mean of their parts:
+//
+//	Similarity(Interleave(s, a, w), Interleave(s, b, w)) ≈ Σ wᵢ·Similarity(aᵢ, bᵢ) / Σ wᵢ
+//
+// unlike a bundle, where a heavy enough input outvotes the rest entirely.
+// Weights must be non-negative with a positive sum.
+func Interleave(seed uint64, vecs []hdc.Vector, weights []float64) hdc.Vector {
+	if len(vecs) == 0 || len(vecs) != len(weights) {
+		panic("hdcx: need one weight per vector, and at least one vector")
+	}
+	dims := vecs[0].Dims()
+	cum := make([]uint64, len(weights)) // cumulative weights scaled to 2⁶⁴
+	var total float64
+	for i, w := range weights {
+		if w < 0 || math.IsNaN(w) {
+			panic("hdcx: weights must not be negative")
+		}
+		if vecs[i].Dims() != dims {
+			panic("hdcx: vectors must have equal dims")
+		}
+		total += w
+	}
+	if total <= 0 || math.IsInf(total, 0) {
+		panic("hdcx: weights must have a positive, finite sum")
+	}
+	var acc float64
+	for i, w := range weights {
+		acc += w
+		if f := acc / total * 0x1p64; f >= 0x1p64 {
+			cum[i] = math.MaxUint64
+		} else {
+			cum[i] = uint64(f)
+		}
+	}
+	raw := make([][]uint64, len(vecs))
+	for i, v := range vecs {
+		raw[i] = v.RawData()
+	}
+	base := mix(seed ^ 0x696e746c76) // "intlv"
+	return fromBits(dims, func(i int) bool {
+		u := mix(base + uint64(i))
+		k := 0
+		for u > cum[k] {
+			k++
+		}
+		return raw[k][i/64]>>uint(i%64)&1 == 1
+	})
+}
@@ -0,0 +1,40 @@
+package hdcx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+func TestInterleave_WeightedMean(t *testing.T) {
+	a := hdcx.RandomBatch(10000, []uint64{1, 2, 3})
+	b := []hdc.Vector{a[0], hdcx.Random(10000, 9), a[2]} // second part differs: sim ~0.5
+	for _, ws := range [][]float64{{1, 1, 1}, {0.1, 0.8, 0.1}, {0.45, 0.1, 0.45}, {0, 1, 0}} {
+		want := (ws[0] + 0.5*ws[1] + ws[2]) / (ws[0] + ws[1] + ws[2])
+		got := hdc.Similarity(hdcx.Interleave(7, a, ws), hdcx.Interleave(7, b, ws))
+		if math.Abs(got-want) > 0.02 {
+			t.Fatalf("weights %v: similarity %.3f, want ~%.3f", ws, got, want)
+		}
+	}
+}
+
+func TestInterleave_Panics(t *testing.T) {
+	v := hdcx.RandomBatch(64, []uint64{1, 2})
+	for name, fn := range map[string]func(){
+		"no vectors":   func() { hdcx.Interleave(1, nil, nil) },
+		"weight count": func() { hdcx.Interleave(1, v, []float64{1}) },
+		"negative":     func() { hdcx.Interleave(1, v, []float64{1, -1}) },
+		"zero sum":     func() { hdcx.Interleave(1, v, []float64{0, 0}) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
@@ -0,0 +1,170 @@
+package hdcx
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// Digits selects how a Profile treats runs of digits.
+type Digits int
+
+const (
+	DigitsKeep     Digits = iota // encode digits as written
+	DigitsCollapse               // every run of digits becomes a single '0'
+	DigitsDrop                   // remove digits
+)
+
+// Profile is a named bundle of normalization settings for the n-gram
+// encoder, picked for a kind of key rather than tuned knob by knob. hdc-go
+// always folds case and splits text into sentences on . ? ! and newlines;
+// a Profile rewrites keys before they reach it to get the other behaviours.
+// The zero Profile leaves keys as they are.
+type Profile struct {
+	Name string
+
+	NGramSize        int  // 0 = the encoder's default (3)
+	StripPunctuation bool // drop punctuation before encoding
+
+	// KeepSentences stops hdc-go splitting keys into separately encoded
+	// sentences, which loses order across . ? ! — right for "a.b.c" paths
+	// and log lines, wrong for prose.
+	KeepSentences bool
+
+	// SplitWords breaks camelCase, snake_case and kebab-case into
+	// space-separated words, so "getUserID" matches "get_user_id".
+	SplitWords bool
+
+	Digits Digits
+
+	// FoldAccents removes diacritics (see FoldAccents), so "café" matches
+	// "cafe" as it does under MiniLM.
+	FoldAccents bool
+}
+
+var (
+	// NaturalLanguage suits questions and prompts: punctuation and accents
+	// are noise, sentences are encoded separately and bundled, digits are
+	// kept.
+	NaturalLanguage = Profile{Name: "natural-language", StripPunctuation: true, FoldAccents: true}
+
+	// Code keeps punctuation, which carries meaning in source, and does not
+	// split on the dots of member access; longer n-grams follow tokens.
+	Code = Profile{Name: "code", NGramSize: 4, KeepSentences: true}
+
+	// LogLine collapses digit runs, so timestamps, PIDs and counters do not
+	// tell otherwise identical lines apart, and drops punctuation.
+	LogLine = Profile{Name: "log-line", StripPunctuation: true, KeepSentences: true, Digits: DigitsCollapse}
+
+	// Identifier splits names into words, so naming conventions do not
+	// matter.
+	Identifier = Profile{Name: "identifier", StripPunctuation: true, KeepSentences: true, SplitWords: true}
+)
+
+// Profiles lists the presets, by Name.
+var Profiles = map[string]Profile{
+	NaturalLanguage.Name: NaturalLanguage,
+	Code.Name:            Code,
+	LogLine.Name:         LogLine,
+	Identifier.Name:      Identifier,
+}
+
+// Config returns cfg with p's encoder settings applied.
+func (p Profile) Config(cfg hdc.Config) hdc.Config {
+	if p.NGramSize > 0 {
+		cfg.NGramSize = p.NGramSize
+	}
+	cfg.StripPunctuation = p.StripPunctuation
+	return cfg
+}
+
+// sentenceStand maps hdc-go's sentence separators to look-alikes it does
+// not split on.
+var sentenceStand = map[rune]rune{'.': '․', '?': '？', '!': '！', '\n': ' '}
+
+// Prepare rewrites text as p requires before it is encoded. It does not
+// fold case, except with FoldAccents; the encoder does that.
+func (p Profile) Prepare(text string) string {
+	if p.FoldAccents {
+		if p.SplitWords {
+			// Word breaks need the case; fold after splitting.
+			p.FoldAccents = false
+			return FoldAccents(p.Prepare(text))
+		}
+		text = FoldAccents(text)
+	}
+	if !p.KeepSentences && !p.SplitWords && p.Digits == DigitsKeep {
+		return text
+	}
+	var b strings.Builder
+	b.Grow(len(text))
+	var prev rune
+	for i, r := range text {
+		switch {
+		case unicode.IsDigit(r) && p.Digits == DigitsDrop:
+			continue
+		case unicode.IsDigit(r) && p.Digits == DigitsCollapse:
+			if unicode.IsDigit(prev) {
+				prev = r
+				continue
+			}
+			r = '0'
+			prev = r
+		case p.SplitWords && (r == '_' || r == '-'):
+			r = ' '
+		case p.SplitWords && unicode.IsUpper(r) && i > 0 && wordBreak(prev, text[i+len(string(r)):]):
+			b.WriteByte(' ')
+		}
+		if s, ok := sentenceStand[r]; ok && p.KeepSentences {
+			if p.StripPunctuation {
+				s = ' '
+			}
+			r = s
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+// wordBreak reports whether an upper-case rune preceded by prev and followed
+// by rest starts a new word: after a lower-case letter or digit ("userID"),
+// or as the last capital of an acronym ("HTTPServer").
+func wordBreak(prev rune, rest string) bool {
+	if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+		return true
+	}
+	if !unicode.IsUpper(prev) {
+		return false
+	}
+	for _, next := range rest {
+		return unicode.IsLower(next)
+	}
+	return false
+}
+
+// ProfileEncoder is an hdc.NGramEncoder that runs keys through a Profile
+// first.
+type ProfileEncoder struct {
+	p   Profile
+	enc *hdc.NGramEncoder
+
+	// Timer, if set before use, times StageNormalize and StageBundle.
+	Timer StageTimer
+}
+
+// NewProfileEncoder returns an encoder for p built from cfg (see
+// Profile.Config).
+func NewProfileEncoder(p Profile, cfg hdc.Config) *ProfileEncoder {
+	return &ProfileEncoder{p: p, enc: hdc.NewNGramEncoder(p.Config(cfg))}
+}
+
+func (e *ProfileEncoder) Encode(text string) hdc.Vector {
+	clock := e.Timer.Start()
+	text = e.p.Prepare(text)
+	clock.Lap(StageNormalize)
+	v := e.enc.Encode(text)
+	clock.Lap(StageBundle)
+	return v
+}
@@ -0,0 +1,57 @@
+package hdcx_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+func TestProfile_Prepare(t *testing.T) {
+	cases := []struct {
+		p        hdcx.Profile
+		in, want string
+	}{
+		{hdcx.NaturalLanguage, "What is Go? A language.", "what is go? a language."},
+		{hdcx.NaturalLanguage, "Crème brûlée à Paris", "creme brulee a paris"},
+		{hdcx.Profile{SplitWords: true, FoldAccents: true}, "crèmeBrûlée", "creme brulee"},
+		{hdcx.Code, "os.Getenv(\"HOME\")", "os․Getenv(\"HOME\")"},
+		{hdcx.LogLine, "2024-05-01 pid=4312 done.", "0-0-0 pid=0 done "},
+		{hdcx.Identifier, "getUserID", "get User ID"},
+		{hdcx.Identifier, "HTTPServer_v2", "HTTP Server v2"},
+		{hdcx.Profile{Digits: hdcx.DigitsDrop}, "v2.10", "v."},
+	}
+	for _, c := range cases {
+		if got := c.p.Prepare(c.in); got != c.want {
+			t.Errorf("%s.Prepare(%q) = %q, want %q", c.p.Name, c.in, got, c.want)
+		}
+	}
+}
+
+func TestProfileEncoder(t *testing.T) {
+	cfg := hdc.DefaultConfig()
+	sim := func(p hdcx.Profile, a, b string) float64 {
+		e := hdcx.NewProfileEncoder(p, cfg)
+		return hdc.Similarity(e.Encode(a), e.Encode(b))
+	}
+	if s := sim(hdcx.Identifier, "getUserID", "get_user_id"); s != 1 {
+		t.Fatalf("Identifier must ignore naming conventions: %.3f", s)
+	}
+	a, b := "request 8812 failed at 10:42:07", "request 17 failed at 23:01:55"
+	if s, base := sim(hdcx.LogLine, a, b), sim(hdcx.Profile{}, a, b); s != 1 || base >= 1 {
+		t.Fatalf("LogLine must ignore numbers: %.3f (plain %.3f)", s, base)
+	}
+	// Without KeepSentences the dots split "a.b" into bundled pieces, which
+	// loses their order.
+	if s := sim(hdcx.Code, "user.name.first", "first.name.user"); s > 0.9 {
+		t.Fatalf("Code must keep order across dots: %.3f", s)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	for name, p := range hdcx.Profiles {
+		if p.Name != name {
+			t.Fatalf("%q is listed as %q", p.Name, name)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package hdcx
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// ErrIncompatible is returned by CheckCompatible for vectors that cannot be
+// compared meaningfully.
+var ErrIncompatible = errors.New("hdcx: incompatible vectors")
+
+// Provenance records where a vector came from. Two vectors of equal dims
+// from different encoders or seeds compare as near-orthogonal noise, which
+// nothing in hdc.Vector itself reveals; a pipeline mixing vectors from
+// several sources can carry a Provenance along to check that instead.
+// Zero fields are unknown and match anything.
+type Provenance struct {
+	Encoder uint64 // the encoder's fingerprint, e.g. cache.Fingerprint or DB.EncoderFingerprint
+	Seed    uint64 // the seed the encoder's vectors were derived from
+}
+
+// Compatible reports whether vectors of p and q come from the same encoder
+// and seed, as far as both know.
+func (p Provenance) Compatible(q Provenance) bool {
+	return known(p.Encoder, q.Encoder) && known(p.Seed, q.Seed)
+}
+
+func known(a, b uint64) bool { return a == 0 || b == 0 || a == b }
+
+func (p Provenance) String() string {
+	return fmt.Sprintf("encoder %016x, seed %d", p.Encoder, p.Seed)
+}
+
+// TaggedVector is a vector with its Provenance. hdc.Vector, from hdc-go,
+// has no room for a label, so the label travels beside it; the embedded
+// Vector is used as is. The provenance cannot be changed once attached.
+type TaggedVector struct {
+	hdc.Vector
+	prov Provenance
+}
+
+// WithProvenance attaches p to v.
+func WithProvenance(v hdc.Vector, p Provenance) TaggedVector {
+	return TaggedVector{Vector: v, prov: p}
+}
+
+// Provenance returns the label attached by WithProvenance.
+func (v TaggedVector) Provenance() Provenance { return v.prov }
+
+// CheckCompatible returns an error wrapping ErrIncompatible unless a and b
+// have the same dims and compatible provenance.
+func CheckCompatible(a, b TaggedVector) error {
+	if a.Dims() != b.Dims() {
+		return fmt.Errorf("%w: %d dims and %d dims", ErrIncompatible, a.Dims(), b.Dims())
+	}
+	if !a.prov.Compatible(b.prov) {
+		return fmt.Errorf("%w: %v and %v", ErrIncompatible, a.prov, b.prov)
+	}
+	return nil
+}
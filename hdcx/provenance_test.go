@@ -0,0 +1,34 @@
+package hdcx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+func TestProvenance(t *testing.T) {
+	v := hdcx.Random(1024, 1)
+	a := hdcx.WithProvenance(v, hdcx.Provenance{Encoder: 0xabc, Seed: 7})
+	if a.Provenance() != (hdcx.Provenance{Encoder: 0xabc, Seed: 7}) || hdc.Similarity(a.Vector, v) != 1 {
+		t.Fatalf("tag must carry its label and leave the vector alone: %v", a.Provenance())
+	}
+
+	for _, tt := range []struct {
+		b  hdcx.TaggedVector
+		ok bool
+	}{
+		{hdcx.WithProvenance(v, hdcx.Provenance{Encoder: 0xabc, Seed: 7}), true},
+		{hdcx.WithProvenance(v, hdcx.Provenance{}), true}, // unknown
+		{hdcx.WithProvenance(v, hdcx.Provenance{Encoder: 0xabc}), true},
+		{hdcx.WithProvenance(v, hdcx.Provenance{Encoder: 0xdef, Seed: 7}), false},
+		{hdcx.WithProvenance(v, hdcx.Provenance{Encoder: 0xabc, Seed: 8}), false},
+		{hdcx.WithProvenance(hdcx.Random(2048, 1), hdcx.Provenance{Encoder: 0xabc, Seed: 7}), false},
+	} {
+		err := hdcx.CheckCompatible(a, tt.b)
+		if (err == nil) != tt.ok || (err != nil && !errors.Is(err, hdcx.ErrIncompatible)) {
+			t.Errorf("%v, %d dims: got %v", tt.b.Provenance(), tt.b.Dims(), err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package hdcx
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// contractions expands the contractions questions are written with; keys
+// are lower case with a straight apostrophe. Suffixes not listed here
+// ("n't", "'re", ...) are expanded by contractionSuffixes.
+var contractions = map[string]string{
+	"can't": "cannot", "won't": "will not", "shan't": "shall not",
+	"let's": "let us", "what's": "what is", "who's": "who is",
+	"where's": "where is", "when's": "when is", "why's": "why is",
+	"how's": "how is", "it's": "it is", "that's": "that is",
+	"there's": "there is", "here's": "here is", "he's": "he is",
+	"she's": "she is", "i'm": "i am",
+}
+
+var contractionSuffixes = []struct{ suffix, full string }{
+	{"n't", " not"}, {"'re", " are"}, {"'ve", " have"}, {"'ll", " will"}, {"'d", " would"},
+}
+
+// questionOpeners are the phrases NormalizeQuestion strips from the start
+// of a question, as lower-case words after contraction expansion. They
+// carry the form of the question, not its subject; "why", "when" and
+// "where" change what is asked and are kept.
+var questionOpeners = [][]string{
+	{"can", "you", "please"}, {"could", "you", "please"}, {"would", "you", "please"},
+	{"can", "you"}, {"could", "you"}, {"would", "you"}, {"please"},
+	{"tell", "me"}, {"do", "you", "know"}, {"i", "want", "to", "know"},
+	{"i", "would", "like", "to", "know"}, {"is", "it", "possible", "to"},
+	{"is", "there", "a", "way", "to"},
+	{"how", "do", "i"}, {"how", "can", "i"}, {"how", "should", "i"}, {"how", "would", "i"},
+	{"how", "do", "you"}, {"how", "does", "one"}, {"how", "do", "we"}, {"how", "to"},
+	{"what", "is"}, {"what", "are"}, {"what", "was"}, {"what", "were"},
+	{"who", "is"}, {"who", "are"}, {"who", "was"}, {"who", "were"},
+}
+
+// NormalizeQuestion rewrites a question-style key so that its subject,
+// not its template, dominates the encoding: contractions are expanded
+// ("what's" → "what is", "don't" → "do not"), leading question phrases
+// such as "what is", "how do I" and "can you tell me" are dropped,
+// named entities — capitalized words other than the first, and all-caps
+// acronyms — are moved to the front in their order, and trailing
+// question marks and full stops are removed. "What's the capital of
+// France?" and "Can you tell me the capital of Spain?" become "France the
+// capital of" and "Spain the capital of", which share far less than the
+// questions did. Text that is all opener is returned with only its
+// contractions expanded.
+func NormalizeQuestion(text string) string {
+	fields := strings.Fields(text)
+	var words []string
+	var starts []bool // word was the first of the text
+	for i, f := range fields {
+		for j, w := range strings.Fields(expandContraction(f)) {
+			words = append(words, w)
+			starts = append(starts, i == 0 && j == 0)
+		}
+	}
+	if len(words) == 0 {
+		return ""
+	}
+
+	rest, restStarts := words, starts
+	for {
+		n := openerLen(rest)
+		if n == 0 || n == len(rest) {
+			break
+		}
+		rest, restStarts = rest[n:], restStarts[n:]
+	}
+	rest[len(rest)-1] = strings.TrimRight(rest[len(rest)-1], "?.!")
+	if rest[len(rest)-1] == "" {
+		rest, restStarts = rest[:len(rest)-1], restStarts[:len(restStarts)-1]
+	}
+
+	var entities, others []string
+	for i, w := range rest {
+		if isEntity(w, restStarts[i]) {
+			entities = append(entities, strings.TrimRightFunc(w, unicode.IsPunct))
+		} else {
+			others = append(others, w)
+		}
+	}
+	return strings.Join(append(entities, others...), " ")
+}
+
+// expandContraction returns word with a contraction spelled out, or word
+// unchanged; curly apostrophes count as straight ones.
+func expandContraction(word string) string {
+	w := strings.ReplaceAll(word, "’", "'")
+	lower := strings.ToLower(w)
+	core := strings.TrimRightFunc(lower, func(r rune) bool { return r != '\'' && unicode.IsPunct(r) })
+	tail := lower[len(core):]
+	if full, ok := contractions[core]; ok {
+		return full + tail
+	}
+	for _, s := range contractionSuffixes {
+		if strings.HasSuffix(core, s.suffix) && len(core) > len(s.suffix) {
+			return w[:len(core)-len(s.suffix)] + s.full + tail
+		}
+	}
+	return word
+}
+
+// openerLen returns how many of words' leading words are a question
+// opener, the longest match, or 0.
+func openerLen(words []string) int {
+	best := 0
+	for _, o := range questionOpeners {
+		if len(o) <= best || len(o) > len(words) {
+			continue
+		}
+		match := true
+		for i, w := range o {
+			if strings.ToLower(strings.TrimRightFunc(words[i], unicode.IsPunct)) != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			best = len(o)
+		}
+	}
+	return best
+}
+
+// isEntity reports whether word looks like a name: capitalized and not
+// capitalized only for starting the text, or an acronym of two or more
+// capitals. The pronoun "I" is not a name.
+func isEntity(word string, first bool) bool {
+	word = strings.TrimFunc(word, unicode.IsPunct)
+	r, _ := utf8.DecodeRuneInString(word)
+	if !unicode.IsUpper(r) || word == "I" {
+		return false
+	}
+	if !first {
+		return true
+	}
+	upper := 0
+	for _, r := range word {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	return upper >= 2
+}
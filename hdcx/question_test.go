@@ -0,0 +1,38 @@
+package hdcx_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+func TestNormalizeQuestion(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"What's the capital of France?", "France the capital of"},
+		{"Can you tell me the capital of New Zealand?", "New Zealand the capital of"},
+		{"how do I reset my AWS password", "AWS reset my password"},
+		{"Why doesn't Docker start?", "Docker Why does not start"},
+		{"why can’t I log in", "why cannot I log in"},
+		{"AWS outage today?", "AWS outage today"},
+		{"what is", "what is"},
+		{"   ", ""},
+	}
+	for _, c := range cases {
+		if got := hdcx.NormalizeQuestion(c.in); got != c.want {
+			t.Errorf("NormalizeQuestion(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+// The template two questions share weighs less against the entities that
+// tell them apart once normalized.
+func TestNormalizeQuestion_TemplateConfusion(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	a, b := "What is the population of Norway?", "What is the population of Sweden?"
+	before := hdc.Similarity(enc.Encode(a), enc.Encode(b))
+	after := hdc.Similarity(enc.Encode(hdcx.NormalizeQuestion(a)), enc.Encode(hdcx.NormalizeQuestion(b)))
+	if after >= before {
+		t.Errorf("normalized similarity %.3f, raw %.3f", after, before)
+	}
+}
@@ -0,0 +1,86 @@
+package hdcx
+
+import "github.com/Amansingh-afk/hdc-go"
+
+// RandSource is a stream of uniformly distributed 64-bit values. The
+// generators in this package take one, so a caller that persists vectors
+// can pin the exact algorithm behind them.
+type RandSource interface {
+	Uint64() uint64
+}
+
+// RandVersion identifies the algorithm of NewRandSource, and with it every
+// vector Random, RandomBatch, ByteHasher and Interleave derive from a seed.
+// It changes only if that algorithm does, which would make vectors saved
+// under the old version unreproducible; none of them depend on math/rand,
+// so Go releases do not change them.
+const RandVersion = 1
+
+// SplitMix64 is the RandVersion 1 generator: Steele, Lea and Flood's
+// splitmix64, a 64-bit state advanced by the golden-ratio increment
+// 0x9e3779b97f4a7c15 and finalized with Stafford's Mix13 (see mix). It
+// needs no setup, and is not safe for concurrent use.
+type SplitMix64 struct{ state uint64 }
+
+// NewRandSource returns the RandVersion generator for seed. The seed is
+// mixed first, so nearby seeds start unrelated streams.
+func NewRandSource(seed uint64) *SplitMix64 { return &SplitMix64{state: mix(seed)} }
+
+// Uint64 returns the next value.
+func (s *SplitMix64) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	return mix(s.state)
+}
+
+// Float64 returns a value in [0, 1) from the top 53 bits of src's next
+// value, the same on every platform.
+func Float64(src RandSource) float64 {
+	return float64(src.Uint64()>>11) * 0x1p-53
+}
+
+// Random returns a deterministic pseudorandom vector for seed. Like
+// hdc.Random, the same (dims, seed) always gives the same vector and
+// different seeds give quasi-orthogonal ones, but it is generated with
+// splitmix64 (NewRandSource), which needs no setup and is frozen at
+// RandVersion, instead of a math/rand source, which allocates and seeds
+// ~5 KB of state per call. The two produce different vectors for the same
+// seed; don't mix them in one symbol space.
+func Random(dims int, seed uint64) hdc.Vector {
+	return RandomFrom(NewRandSource(seed), dims)
+}
+
+// RandomFrom returns a vector of dims bits drawn from src, one word per
+// value, with the bits past dims cleared.
+func RandomFrom(src RandSource, dims int) hdc.Vector {
+	if dims <= 0 {
+		panic("hdcx: dims must be positive")
+	}
+	words := make([]uint64, hdc.NumWords(dims))
+	fillRandom(words, dims, src)
+	return hdc.FromWords(dims, words)
+}
+
+// RandomBatch returns Random(dims, seed) for each of seeds, reusing one
+// scratch buffer — for pre-seeding symbol tables or building projections,
+// where thousands of vectors are needed at once.
+func RandomBatch(dims int, seeds []uint64) []hdc.Vector {
+	if dims <= 0 {
+		panic("hdcx: dims must be positive")
+	}
+	out := make([]hdc.Vector, len(seeds))
+	words := make([]uint64, hdc.NumWords(dims))
+	for i, seed := range seeds {
+		fillRandom(words, dims, NewRandSource(seed))
+		out[i] = hdc.FromWords(dims, words)
+	}
+	return out
+}
+
+func fillRandom(words []uint64, dims int, src RandSource) {
+	for i := range words {
+		words[i] = src.Uint64()
+	}
+	if r := dims % 64; r != 0 {
+		words[len(words)-1] &= 1<<uint(r) - 1
+	}
+}
@@ -0,0 +1,74 @@
+package hdcx_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+func TestRandom(t *testing.T) {
+	a := hdcx.Random(10000, 1)
+	if hdc.Similarity(a, hdcx.Random(10000, 1)) != 1 {
+		t.Fatal("Random must be deterministic")
+	}
+	if s := hdc.Similarity(a, hdcx.Random(10000, 2)); s < 0.47 || s > 0.53 {
+		t.Fatalf("different seeds must be quasi-orthogonal, similarity %.3f", s)
+	}
+	if d := density(a); d < 0.48 || d > 0.52 {
+		t.Fatalf("density %.3f, want ~0.5", d)
+	}
+	if v := hdcx.Random(70, 3); v.RawData()[1]>>6 != 0 {
+		t.Fatal("padding bits past dims must be zero")
+	}
+}
+
+// TestRandom_Frozen pins RandVersion 1: if it fails, vectors saved by
+// earlier releases can no longer be reproduced.
+func TestRandom_Frozen(t *testing.T) {
+	want := []uint64{0x989b3f130a063869, 0x290db4bf2570ded7, 0x1}
+	for i, w := range hdcx.Random(130, 42).RawData() {
+		if w != want[i] {
+			t.Fatalf("word %d = %#x, want %#x", i, w, want[i])
+		}
+	}
+	src := hdcx.NewRandSource(42)
+	if hdc.Similarity(hdcx.RandomFrom(src, 130), hdcx.Random(130, 42)) != 1 {
+		t.Fatal("RandomFrom(NewRandSource(seed)) must equal Random(seed)")
+	}
+	if f := hdcx.Float64(src); f < 0 || f >= 1 {
+		t.Fatalf("Float64 = %v, want [0, 1)", f)
+	}
+}
+
+func TestRandomBatch(t *testing.T) {
+	seeds := []uint64{5, 6, 7}
+	vs := hdcx.RandomBatch(1000, seeds)
+	if len(vs) != len(seeds) {
+		t.Fatalf("want %d vectors, got %d", len(seeds), len(vs))
+	}
+	for i, seed := range seeds {
+		if hdc.Similarity(vs[i], hdcx.Random(1000, seed)) != 1 {
+			t.Fatalf("vector %d differs from Random", i)
+		}
+	}
+}
+
+func BenchmarkRandomBatch(b *testing.B) {
+	seeds := make([]uint64, 1000)
+	for i := range seeds {
+		seeds[i] = uint64(i)
+	}
+	b.Run("hdc.Random", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, s := range seeds {
+				hdc.Random(10000, s)
+			}
+		}
+	})
+	b.Run("hdcx.RandomBatch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			hdcx.RandomBatch(10000, seeds)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package hdcx
+
+import "github.com/Amansingh-afk/hdc-go"
+
+// RoleSet is a family of quasi-orthogonal role vectors with stable indices,
+// for record encoding and namespacing: binding a value to role i (XOR)
+// makes it dissimilar to the same value under any other role, and binding
+// again recovers it. Role i depends only on (dims, seed, i), so growing a
+// set keeps existing roles. Safe for concurrent use.
+type RoleSet struct {
+	roles []hdc.Vector
+}
+
+// NewRoleSet returns n roles of dims bits derived from seed.
+func NewRoleSet(dims int, seed uint64, n int) *RoleSet {
+	if n < 0 {
+		panic("hdcx: role count must not be negative")
+	}
+	seeds := make([]uint64, n)
+	base := mix(seed ^ 0x726f6c6573) // "roles": keeps role seeds apart from plain Random seeds
+	for i := range seeds {
+		seeds[i] = base + uint64(i)
+	}
+	return &RoleSet{roles: RandomBatch(dims, seeds)}
+}
+
+// Len returns the number of roles.
+func (r *RoleSet) Len() int { return len(r.roles) }
+
+// Role returns role i. Panics if i is out of range.
+func (r *RoleSet) Role(i int) hdc.Vector { return r.roles[i] }
+
+// Bind binds v to role i. Bind is its own inverse: Bind(i, Bind(i, v)) == v.
+func (r *RoleSet) Bind(i int, v hdc.Vector) hdc.Vector { return hdc.Bind(r.roles[i], v) }
@@ -0,0 +1,42 @@
+package hdcx_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+func TestRoleSet(t *testing.T) {
+	rs := hdcx.NewRoleSet(10000, 1, 8)
+	if rs.Len() != 8 {
+		t.Fatalf("Len: got %d", rs.Len())
+	}
+	for i := 0; i < rs.Len(); i++ {
+		for j := i + 1; j < rs.Len(); j++ {
+			if s := hdc.Similarity(rs.Role(i), rs.Role(j)); s < 0.47 || s > 0.53 {
+				t.Fatalf("roles %d and %d not quasi-orthogonal: %.3f", i, j, s)
+			}
+		}
+	}
+
+	bigger := hdcx.NewRoleSet(10000, 1, 16)
+	if hdc.Similarity(bigger.Role(7), rs.Role(7)) != 1 {
+		t.Fatal("growing the set must keep existing roles")
+	}
+	if s := hdc.Similarity(hdcx.NewRoleSet(10000, 2, 8).Role(0), rs.Role(0)); s > 0.53 {
+		t.Fatalf("other seeds must give other roles: %.3f", s)
+	}
+}
+
+func TestRoleSet_Bind(t *testing.T) {
+	rs := hdcx.NewRoleSet(10000, 1, 2)
+	v := hdc.NewNGramEncoder(hdc.DefaultConfig()).Encode("alice")
+	a, b := rs.Bind(0, v), rs.Bind(1, v)
+	if s := hdc.Similarity(a, b); s > 0.53 {
+		t.Fatalf("the same value under two roles must differ: %.3f", s)
+	}
+	if hdc.Similarity(rs.Bind(0, a), v) != 1 {
+		t.Fatal("Bind must be its own inverse")
+	}
+}
@@ -0,0 +1,52 @@
+package hdcx
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Stage names a step of an encoder's pipeline.
+type Stage string
+
+const (
+	StageNormalize Stage = "normalize" // text rewriting before encoding, e.g. Profile.Prepare
+	StageTokenize  Stage = "tokenize"  // WordPiece tokenization
+	StageInfer     Stage = "infer"     // model inference, pooling included
+	StageProject   Stage = "project"   // float embedding to binary vector
+	StageBundle    Stage = "bundle"    // n-gram encoding and bundling in hdc-go
+)
+
+// StageTimer reports how long each stage of an Encode call took, for a
+// random fraction Rate of calls (0 or 1 = every call), so a slowdown can be
+// pinned to a stage in production. Func is called on the encoding
+// goroutine and must be cheap and safe for concurrent use. The zero
+// StageTimer times nothing.
+type StageTimer struct {
+	Rate float64
+	Func func(stage Stage, d time.Duration)
+}
+
+// Start begins timing one Encode call. The returned clock does nothing if
+// the call is not sampled.
+func (t StageTimer) Start() StageClock {
+	if t.Func == nil || (t.Rate > 0 && t.Rate < 1 && rand.Float64() >= t.Rate) {
+		return StageClock{}
+	}
+	return StageClock{f: t.Func, last: time.Now()}
+}
+
+// StageClock times the stages of one Encode call; see StageTimer.Start.
+type StageClock struct {
+	f    func(Stage, time.Duration)
+	last time.Time
+}
+
+// Lap reports the time since Start or the previous Lap as stage.
+func (c *StageClock) Lap(stage Stage) {
+	if c.f == nil {
+		return
+	}
+	now := time.Now()
+	c.f(stage, now.Sub(c.last))
+	c.last = now
+}
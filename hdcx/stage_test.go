@@ -0,0 +1,52 @@
+package hdcx
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+func TestStageTimer(t *testing.T) {
+	var got []Stage
+	tm := StageTimer{Func: func(s Stage, d time.Duration) {
+		if d < 0 {
+			t.Errorf("%s: negative duration %v", s, d)
+		}
+		got = append(got, s)
+	}}
+	c := tm.Start()
+	c.Lap(StageTokenize)
+	c.Lap(StageInfer)
+	if len(got) != 2 || got[0] != StageTokenize || got[1] != StageInfer {
+		t.Fatalf("laps: %v", got)
+	}
+
+	var zero StageClock
+	zero.Lap(StageBundle) // must not panic
+	c = StageTimer{}.Start()
+	c.Lap(StageBundle)
+}
+
+func TestStageTimer_Rate(t *testing.T) {
+	var n atomic.Int64
+	tm := StageTimer{Rate: 0.1, Func: func(Stage, time.Duration) { n.Add(1) }}
+	for i := 0; i < 10000; i++ {
+		c := tm.Start()
+		c.Lap(StageBundle)
+	}
+	if got := n.Load(); got < 700 || got > 1300 {
+		t.Fatalf("rate 0.1 timed %d of 10000 calls", got)
+	}
+}
+
+func TestProfileEncoder_Timer(t *testing.T) {
+	var stages []Stage
+	enc := NewProfileEncoder(LogLine, hdc.DefaultConfig())
+	enc.Timer = StageTimer{Func: func(s Stage, _ time.Duration) { stages = append(stages, s) }}
+	enc.Encode("GET /api/v1 200 12ms")
+	if len(stages) != 2 || stages[0] != StageNormalize || stages[1] != StageBundle {
+		t.Fatalf("stages: %v", stages)
+	}
+}
@@ -0,0 +1,99 @@
+// Package echocache adapts xordb/httpcache to Echo. It is a separate module
+// so the core stays dependency-free.
+//
+//	db := xordb.New(xordb.WithThreshold(0.8))
+//	e.GET("/search", searchHandler, echocache.New(db))
+//	e.POST("/ask", askHandler, echocache.New(db,
+//		echocache.WithMinSimilarity(0.92),
+//		echocache.WithMethods("POST"),
+//		echocache.WithKeyFunc(func(c echo.Context, body []byte) (string, bool) {
+//			return c.Param("tenant") + ":" + string(body), true
+//		}),
+//	))
+//
+// Each call to New is configured independently, so routes sharing one DB
+// can use different thresholds and keys.
+package echocache
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/httpcache"
+	"github.com/labstack/echo/v4"
+)
+
+// KeyFunc is httpcache.KeyFunc with access to the Echo context (path
+// params, values set by earlier middleware).
+type KeyFunc func(c echo.Context, body []byte) (key string, ok bool)
+
+type Option func(*config)
+
+type config struct {
+	keyFn KeyFunc
+	opts  []httpcache.Option
+}
+
+// WithKeyFunc replaces the default fingerprint (httpcache.DefaultKey).
+func WithKeyFunc(fn KeyFunc) Option { return func(c *config) { c.keyFn = fn } }
+
+// WithMinSimilarity sets this route's hit threshold (see
+// httpcache.WithMinSimilarity).
+func WithMinSimilarity(t float64) Option {
+	return func(c *config) { c.opts = append(c.opts, httpcache.WithMinSimilarity(t)) }
+}
+
+func WithMethods(methods ...string) Option {
+	return func(c *config) { c.opts = append(c.opts, httpcache.WithMethods(methods...)) }
+}
+
+func WithMaxBodySize(n int) Option {
+	return func(c *config) { c.opts = append(c.opts, httpcache.WithMaxBodySize(n)) }
+}
+
+type ctxKey struct{}
+
+// call carries the Echo context into the wrapped handler.
+type call struct {
+	c    echo.Context
+	next echo.HandlerFunc
+}
+
+// New returns Echo middleware caching the responses of the wrapped handler.
+// Hits are written directly and the handler is not called.
+func New(db *xordb.DB, opts ...Option) echo.MiddlewareFunc {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	hopts := cfg.opts
+	if cfg.keyFn != nil {
+		keyFn := cfg.keyFn
+		hopts = append(hopts, httpcache.WithKeyFunc(func(r *http.Request, body []byte) (string, bool) {
+			return keyFn(r.Context().Value(ctxKey{}).(*call).c, body)
+		}))
+	}
+
+	h := httpcache.New(db, hopts...).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cl := r.Context().Value(ctxKey{}).(*call)
+		orig := cl.c.Response()
+		cl.c.SetRequest(r)
+		// w writes through to orig, so orig's status and size stay accurate
+		cl.c.SetResponse(echo.NewResponse(w, cl.c.Echo()))
+		defer cl.c.SetResponse(orig)
+		// Render errors here, through the recorder, so an error response is
+		// seen (and not cached) instead of an empty 200.
+		if err := cl.next(cl.c); err != nil {
+			cl.c.Error(err)
+		}
+	}))
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			h.ServeHTTP(c.Response(), r.WithContext(context.WithValue(r.Context(), ctxKey{}, &call{c: c, next: next})))
+			return nil
+		}
+	}
+}
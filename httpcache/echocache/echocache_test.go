@@ -0,0 +1,89 @@
+package echocache_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/httpcache/echocache"
+	"github.com/labstack/echo/v4"
+)
+
+func do(e *echo.Echo, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestEcho_MissThenHit(t *testing.T) {
+	calls := 0
+	e := echo.New()
+	e.GET("/search", func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusOK, map[string]string{"q": c.QueryParam("q")})
+	}, echocache.New(xordb.New(xordb.WithThreshold(0.8))))
+
+	first := do(e, "GET", "/search?q=capital+of+india", "")
+	second := do(e, "GET", "/search?q=capital++of+india", "")
+	if first.Header().Get("X-Xordb-Cache") != "MISS" || second.Header().Get("X-Xordb-Cache") != "HIT" {
+		t.Fatalf("want MISS then HIT, got %q, %q", first.Header().Get("X-Xordb-Cache"), second.Header().Get("X-Xordb-Cache"))
+	}
+	if calls != 1 {
+		t.Fatalf("handler must run once, ran %d times", calls)
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Fatalf("cached body differs: %q vs %q", second.Body, first.Body)
+	}
+}
+
+func TestEcho_PerRouteKeyAndThreshold(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.8))
+	calls := 0
+	handler := func(c echo.Context) error {
+		calls++
+		return c.String(http.StatusOK, "tenant "+c.Param("tenant"))
+	}
+	byTenant := echocache.WithKeyFunc(func(c echo.Context, body []byte) (string, bool) {
+		return c.Param("tenant") + " " + string(body), true
+	})
+
+	e := echo.New()
+	e.POST("/:tenant/ask", handler, echocache.New(db, echocache.WithMethods("POST"), byTenant))
+	e.POST("/:tenant/strict", handler, echocache.New(db, echocache.WithMethods("POST"), byTenant,
+		echocache.WithMinSimilarity(0.999)))
+
+	do(e, "POST", "/acme/ask", "what is the capital of india")
+	if rec := do(e, "POST", "/acme/ask", "what is the capital of india now"); rec.Header().Get("X-Xordb-Cache") != "HIT" {
+		t.Fatal("paraphrase must hit on the default route")
+	}
+	do(e, "POST", "/acme/strict", "what is the capital of india")
+	if rec := do(e, "POST", "/acme/strict", "what is the capital of india now"); rec.Header().Get("X-Xordb-Cache") == "HIT" {
+		t.Fatal("paraphrase must miss on the strict route")
+	}
+	if calls != 3 {
+		t.Fatalf("want 3 handler runs, got %d", calls)
+	}
+}
+
+func TestEcho_HandlerErrorPropagates(t *testing.T) {
+	e := echo.New()
+	boom := errors.New("boom")
+	var seen error
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		seen = err
+		c.NoContent(http.StatusBadGateway)
+	}
+	calls := 0
+	e.GET("/x", func(c echo.Context) error { calls++; return boom }, echocache.New(xordb.New()))
+
+	if rec := do(e, "GET", "/x", ""); rec.Code != http.StatusBadGateway || !errors.Is(seen, boom) {
+		t.Fatalf("handler error must reach Echo: code=%d err=%v", rec.Code, seen)
+	}
+	if rec := do(e, "GET", "/x", ""); rec.Code != http.StatusBadGateway || calls != 2 {
+		t.Fatalf("error responses must not be cached: code=%d calls=%d", rec.Code, calls)
+	}
+}
@@ -0,0 +1,145 @@
+// Package gincache adapts xordb/httpcache to Gin. It is a separate module so
+// the core stays dependency-free.
+//
+//	db := xordb.New(xordb.WithThreshold(0.8))
+//	r.GET("/search", gincache.New(db), searchHandler)
+//	r.POST("/ask", gincache.New(db,
+//		gincache.WithMinSimilarity(0.92),
+//		gincache.WithMethods("POST"),
+//		gincache.WithKeyFunc(func(c *gin.Context, body []byte) (string, bool) {
+//			return c.Param("tenant") + ":" + string(body), true
+//		}),
+//	), askHandler)
+//
+// Each call to New is configured independently, so routes sharing one DB
+// can use different thresholds and keys.
+package gincache
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/httpcache"
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc is httpcache.KeyFunc with access to the Gin context (path
+// params, values set by earlier middleware).
+type KeyFunc func(c *gin.Context, body []byte) (key string, ok bool)
+
+type Option func(*config)
+
+type config struct {
+	keyFn KeyFunc
+	opts  []httpcache.Option
+}
+
+// WithKeyFunc replaces the default fingerprint (httpcache.DefaultKey).
+func WithKeyFunc(fn KeyFunc) Option { return func(c *config) { c.keyFn = fn } }
+
+// WithMinSimilarity sets this route's hit threshold (see
+// httpcache.WithMinSimilarity).
+func WithMinSimilarity(t float64) Option {
+	return func(c *config) { c.opts = append(c.opts, httpcache.WithMinSimilarity(t)) }
+}
+
+func WithMethods(methods ...string) Option {
+	return func(c *config) { c.opts = append(c.opts, httpcache.WithMethods(methods...)) }
+}
+
+func WithMaxBodySize(n int) Option {
+	return func(c *config) { c.opts = append(c.opts, httpcache.WithMaxBodySize(n)) }
+}
+
+type ctxKey struct{}
+
+// New returns Gin middleware caching the responses of the handlers after it.
+// Hits are written directly and abort the chain.
+func New(db *xordb.DB, opts ...Option) gin.HandlerFunc {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	hopts := cfg.opts
+	if cfg.keyFn != nil {
+		keyFn := cfg.keyFn
+		hopts = append(hopts, httpcache.WithKeyFunc(func(r *http.Request, body []byte) (string, bool) {
+			return keyFn(r.Context().Value(ctxKey{}).(*gin.Context), body)
+		}))
+	}
+
+	h := httpcache.New(db, hopts...).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := r.Context().Value(ctxKey{}).(*gin.Context)
+		c.Set(ranKey, true)
+		orig := c.Writer
+		c.Request = r
+		c.Writer = &responseWriter{ResponseWriter: orig, w: w, status: http.StatusOK, size: noWritten}
+		defer func() { c.Writer = orig }()
+		c.Next()
+	}))
+
+	return func(c *gin.Context) {
+		r := c.Request.WithContext(context.WithValue(c.Request.Context(), ctxKey{}, c))
+		h.ServeHTTP(c.Writer, r)
+		if _, ran := c.Get(ranKey); !ran {
+			c.Abort() // served from cache
+		}
+	}
+}
+
+const (
+	ranKey    = "xordb.gincache.ran" // set in the Gin context when the chain ran
+	noWritten = -1
+)
+
+// responseWriter routes downstream writes through httpcache's recorder
+// while keeping Gin's lazy WriteHeader: Gin sets the status before render
+// helpers add Content-Type, so the header must not go out until the first
+// write.
+type responseWriter struct {
+	gin.ResponseWriter // original, for Hijack, CloseNotify, Pusher
+	w                  http.ResponseWriter
+	status             int
+	size               int
+}
+
+func (rw *responseWriter) Header() http.Header { return rw.w.Header() }
+func (rw *responseWriter) Status() int         { return rw.status }
+func (rw *responseWriter) Size() int           { return rw.size }
+func (rw *responseWriter) Written() bool       { return rw.size != noWritten }
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if code > 0 && !rw.Written() {
+		rw.status = code
+	}
+}
+
+func (rw *responseWriter) WriteHeaderNow() {
+	if !rw.Written() {
+		rw.size = 0
+		rw.w.WriteHeader(rw.status)
+	}
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.WriteHeaderNow()
+	n, err := rw.w.Write(b)
+	rw.size += n
+	return n, err
+}
+
+func (rw *responseWriter) WriteString(s string) (int, error) {
+	rw.WriteHeaderNow()
+	n, err := io.WriteString(rw.w, s)
+	rw.size += n
+	return n, err
+}
+
+func (rw *responseWriter) Flush() {
+	rw.WriteHeaderNow()
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
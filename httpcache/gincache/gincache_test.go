@@ -0,0 +1,87 @@
+package gincache_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/httpcache/gincache"
+	"github.com/gin-gonic/gin"
+)
+
+func init() { gin.SetMode(gin.TestMode) }
+
+func do(r http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGin_MissThenHit(t *testing.T) {
+	calls := 0
+	r := gin.New()
+	r.GET("/search", gincache.New(xordb.New(xordb.WithThreshold(0.8))), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"q": c.Query("q")})
+	})
+
+	first := do(r, "GET", "/search?q=capital+of+india", "")
+	second := do(r, "GET", "/search?q=capital++of+india", "")
+	if first.Header().Get("X-Xordb-Cache") != "MISS" || second.Header().Get("X-Xordb-Cache") != "HIT" {
+		t.Fatalf("want MISS then HIT, got %q, %q", first.Header().Get("X-Xordb-Cache"), second.Header().Get("X-Xordb-Cache"))
+	}
+	if calls != 1 {
+		t.Fatalf("handler must run once, ran %d times", calls)
+	}
+	if second.Body.String() != first.Body.String() || second.Header().Get("Content-Type") != first.Header().Get("Content-Type") {
+		t.Fatalf("cached response differs: %q %q vs %q %q", second.Header().Get("Content-Type"), second.Body, first.Header().Get("Content-Type"), first.Body)
+	}
+	if !strings.HasPrefix(first.Header().Get("Content-Type"), "application/json") {
+		t.Fatalf("Content-Type set after c.Status must survive, got %q", first.Header().Get("Content-Type"))
+	}
+}
+
+func TestGin_PerRouteKeyAndThreshold(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.8))
+	calls := 0
+	handler := func(c *gin.Context) {
+		calls++
+		c.String(http.StatusOK, "tenant "+c.Param("tenant"))
+	}
+	byTenant := gincache.WithKeyFunc(func(c *gin.Context, body []byte) (string, bool) {
+		return c.Param("tenant") + " " + string(body), true
+	})
+
+	r := gin.New()
+	r.POST("/:tenant/ask", gincache.New(db, gincache.WithMethods("POST"), byTenant), handler)
+	r.POST("/:tenant/strict", gincache.New(db, gincache.WithMethods("POST"), byTenant,
+		gincache.WithMinSimilarity(0.999)), handler)
+
+	do(r, "POST", "/acme/ask", "what is the capital of india")
+	if rec := do(r, "POST", "/acme/ask", "what is the capital of india now"); rec.Header().Get("X-Xordb-Cache") != "HIT" {
+		t.Fatal("paraphrase must hit on the default route")
+	}
+	do(r, "POST", "/acme/strict", "what is the capital of india")
+	if rec := do(r, "POST", "/acme/strict", "what is the capital of india now"); rec.Header().Get("X-Xordb-Cache") == "HIT" {
+		t.Fatal("paraphrase must miss on the strict route")
+	}
+	if calls != 3 {
+		t.Fatalf("want 3 handler runs, got %d", calls)
+	}
+}
+
+func TestGin_ErrorNotCached(t *testing.T) {
+	calls := 0
+	r := gin.New()
+	r.GET("/x", gincache.New(xordb.New()), func(c *gin.Context) {
+		calls++
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+	do(r, "GET", "/x", "")
+	if rec := do(r, "GET", "/x", ""); rec.Code != http.StatusInternalServerError || calls != 2 {
+		t.Fatalf("errors must not be cached: code=%d calls=%d", rec.Code, calls)
+	}
+}
@@ -0,0 +1,362 @@
+// Package httpcache — net/http middleware that serves responses from an
+// xordb semantic cache.
+//
+//	db := xordb.New(xordb.WithThreshold(0.9))
+//	mux.Handle("/search", httpcache.New(db).Handler(searchHandler))
+//
+// Requests are fingerprinted (method, path, normalized query and body) and
+// looked up semantically; a hit is only served when it was recorded for the
+// same method and path and its Vary'd request headers match.
+package httpcache
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+const (
+	defaultMaxBody = 1 << 20 // 1 MB, request and response
+)
+
+// KeyFunc builds the fingerprint for a request. body is the buffered request
+// body (nil for bodiless methods). Returning ok=false bypasses the cache.
+type KeyFunc func(r *http.Request, body []byte) (key string, ok bool)
+
+// Middleware caches responses of the wrapped handler. Safe for concurrent use.
+type Middleware struct {
+	db      *xordb.DB
+	keyFn   KeyFunc
+	methods map[string]bool
+	maxBody int
+	minSim  float64
+}
+
+type Option func(*Middleware)
+
+// WithKeyFunc replaces the default fingerprint (see DefaultKey).
+func WithKeyFunc(fn KeyFunc) Option { return func(m *Middleware) { m.keyFn = fn } }
+
+// WithMethods sets which request methods are cacheable (default GET, HEAD).
+// Add POST for JSON query APIs where the body is the question.
+func WithMethods(methods ...string) Option {
+	return func(m *Middleware) {
+		m.methods = make(map[string]bool, len(methods))
+		for _, meth := range methods {
+			m.methods[strings.ToUpper(meth)] = true
+		}
+	}
+}
+
+// WithMaxBodySize caps both the buffered request body and the captured
+// response body. Larger exchanges are passed through uncached.
+func WithMaxBodySize(n int) Option { return func(m *Middleware) { m.maxBody = n } }
+
+// WithMinSimilarity serves hits only at or above t. Use it to give one route
+// a stricter threshold than the DB's, so several routes can share one DB;
+// it cannot loosen the DB threshold.
+func WithMinSimilarity(t float64) Option { return func(m *Middleware) { m.minSim = t } }
+
+// New creates a Middleware backed by db.
+func New(db *xordb.DB, opts ...Option) *Middleware {
+	if db == nil {
+		panic("httpcache: db must not be nil")
+	}
+	m := &Middleware{
+		db:      db,
+		keyFn:   DefaultKey,
+		methods: map[string]bool{http.MethodGet: true, http.MethodHead: true},
+		maxBody: defaultMaxBody,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.maxBody <= 0 {
+		panic("httpcache: max body size must be positive")
+	}
+	if m.minSim < 0 || m.minSim > 1 {
+		panic("httpcache: min similarity must be in [0, 1]")
+	}
+	return m
+}
+
+// cachedResponse is the value stored in the DB.
+type cachedResponse struct {
+	Route  string // method + path the response was recorded for
+	Status int
+	Header http.Header
+	Body   []byte
+	Vary   map[string]string // canonical header name → request value
+}
+
+// Handler wraps next with the cache.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.methods[r.Method] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		reqCC := parseCacheControl(r.Header.Get("Cache-Control"))
+		if reqCC.has("no-store") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, ok := m.bufferBody(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key, ok := m.keyFn(r, body)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		route := r.Method + " " + r.URL.Path
+
+		if !reqCC.has("no-cache") {
+			if v, hit, sim := m.db.Get(key); hit && sim >= m.minSim {
+				if cr, isResp := v.(*cachedResponse); isResp && cr.Route == route && cr.varyMatches(r) {
+					serveCached(w, r, cr, sim)
+					return
+				}
+			}
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK, limit: m.maxBody}
+		w.Header().Set("X-Xordb-Cache", "MISS")
+		next.ServeHTTP(rec, r)
+		m.store(key, route, r, rec)
+	})
+}
+
+// bufferBody reads the request body (up to maxBody) and restores it for the
+// next handler. ok=false when the body is too large to fingerprint.
+func (m *Middleware) bufferBody(r *http.Request) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+	buf, err := io.ReadAll(io.LimitReader(r.Body, int64(m.maxBody)+1))
+	rest := r.Body
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), rest), rest}
+	if err != nil || len(buf) > m.maxBody {
+		return nil, false
+	}
+	return buf, true
+}
+
+func (m *Middleware) store(key, route string, r *http.Request, rec *recorder) {
+	if rec.overflow || !cacheableStatus(rec.status) {
+		return
+	}
+	h := rec.Header()
+	respCC := parseCacheControl(h.Get("Cache-Control"))
+	if respCC.has("no-store") || respCC.has("no-cache") || respCC.has("private") {
+		return
+	}
+
+	vary := make(map[string]string)
+	for _, line := range h.Values("Vary") {
+		for _, name := range strings.Split(line, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return // uncacheable by definition
+			}
+			if name != "" {
+				vary[name] = r.Header.Get(name)
+			}
+		}
+	}
+
+	header := h.Clone()
+	header.Del("X-Xordb-Cache")
+	cr := &cachedResponse{
+		Route:  route,
+		Status: rec.status,
+		Header: header,
+		Body:   rec.buf.Bytes(),
+		Vary:   vary,
+	}
+
+	if ttl, ok := respCC.maxAge(); ok {
+		if ttl <= 0 {
+			return
+		}
+		m.db.SetWithTTL(key, cr, ttl)
+		return
+	}
+	m.db.Set(key, cr)
+}
+
+func (cr *cachedResponse) varyMatches(r *http.Request) bool {
+	for name, want := range cr.Vary {
+		if r.Header.Get(name) != want {
+			return false
+		}
+	}
+	return true
+}
+
+func serveCached(w http.ResponseWriter, r *http.Request, cr *cachedResponse, sim float64) {
+	h := w.Header()
+	for k, vs := range cr.Header {
+		h[k] = append([]string(nil), vs...)
+	}
+	h.Set("X-Xordb-Cache", "HIT")
+	h.Set("X-Xordb-Similarity", strconv.FormatFloat(sim, 'f', 4, 64))
+	h.Set("Content-Length", strconv.Itoa(len(cr.Body)))
+	w.WriteHeader(cr.Status)
+	if r.Method != http.MethodHead {
+		w.Write(cr.Body)
+	}
+}
+
+func cacheableStatus(code int) bool {
+	switch code {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+		return true
+	}
+	return false
+}
+
+// DefaultKey fingerprints method, path, sorted query parameters and the
+// request body. JSON bodies are re-serialized with sorted keys so field order
+// and formatting don't matter; other bodies have whitespace collapsed.
+func DefaultKey(r *http.Request, body []byte) (string, bool) {
+	var b strings.Builder
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+	b.WriteString(r.URL.Path)
+	if q := normalizeQuery(r.URL.Query()); q != "" {
+		b.WriteByte('\n')
+		b.WriteString(q)
+	}
+	if len(body) > 0 {
+		b.WriteByte('\n')
+		b.WriteString(normalizeBody(body))
+	}
+	return b.String(), true
+}
+
+func normalizeQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+strings.Join(strings.Fields(strings.Join(q[k], " ")), " "))
+	}
+	return strings.Join(parts, " ")
+}
+
+func normalizeBody(body []byte) string {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err == nil && !dec.More() {
+		// encoding/json sorts map keys, so this is canonical enough
+		if out, err := json.Marshal(v); err == nil {
+			return string(out)
+		}
+	}
+	return strings.Join(strings.Fields(string(body)), " ")
+}
+
+// ── response capture ──────────────────────────────────────────────────────────
+
+// recorder writes through to the client while capturing up to limit bytes.
+// Past the limit it keeps streaming but marks the response uncacheable.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buf         bytes.Buffer
+	limit       int
+	overflow    bool
+}
+
+func (rec *recorder) WriteHeader(code int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.wroteHeader = true
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *recorder) Write(p []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if !rec.overflow {
+		if rec.buf.Len()+len(p) > rec.limit {
+			rec.overflow = true
+			rec.buf = bytes.Buffer{}
+		} else {
+			rec.buf.Write(p)
+		}
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+// Flush keeps streaming handlers (SSE, chunked JSON) working through the cache.
+func (rec *recorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		if !rec.wroteHeader {
+			rec.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+func (rec *recorder) Unwrap() http.ResponseWriter { return rec.ResponseWriter }
+
+// ── Cache-Control ─────────────────────────────────────────────────────────────
+
+type cacheControl map[string]string
+
+func parseCacheControl(v string) cacheControl {
+	cc := cacheControl{}
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, val, _ := strings.Cut(part, "=")
+		cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(val), `"`)
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+// maxAge returns s-maxage, else max-age. ok=false when neither is present.
+func (cc cacheControl) maxAge() (time.Duration, bool) {
+	for _, d := range []string{"s-maxage", "max-age"} {
+		if v, ok := cc[d]; ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return 0, true // malformed: treat as stale
+			}
+			return time.Duration(n) * time.Second, true
+		}
+	}
+	return 0, false
+}
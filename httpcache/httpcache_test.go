@@ -0,0 +1,234 @@
+package httpcache_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/httpcache"
+)
+
+// ── helpers ───────────────────────────────────────────────────────────────────
+
+type countingHandler struct {
+	calls  int
+	header http.Header
+	status int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	for k, vs := range h.header {
+		w.Header()[k] = vs
+	}
+	if h.status != 0 {
+		w.WriteHeader(h.status)
+	}
+	body, _ := io.ReadAll(r.Body)
+	io.WriteString(w, "answer:"+r.URL.Query().Get("q")+string(body))
+}
+
+func do(t *testing.T, h http.Handler, method, target, body string, hdr http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	var rd io.Reader
+	if body != "" {
+		rd = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, rd)
+	for k, vs := range hdr {
+		req.Header[k] = vs
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func newDB() *xordb.DB { return xordb.New(xordb.WithThreshold(0.80)) }
+
+// ── basic hit / miss ──────────────────────────────────────────────────────────
+
+func TestMiddleware_MissThenHit(t *testing.T) {
+	next := &countingHandler{}
+	h := httpcache.New(newDB()).Handler(next)
+
+	first := do(t, h, "GET", "/search?q=capital+of+india", "", nil)
+	if first.Header().Get("X-Xordb-Cache") != "MISS" {
+		t.Fatalf("first request must miss, got %q", first.Header().Get("X-Xordb-Cache"))
+	}
+	second := do(t, h, "GET", "/search?q=capital++of+india", "", nil)
+	if second.Header().Get("X-Xordb-Cache") != "HIT" {
+		t.Fatalf("normalized query must hit, got %q", second.Header().Get("X-Xordb-Cache"))
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Fatalf("cached body mismatch: %q vs %q", second.Body.String(), first.Body.String())
+	}
+	if next.calls != 1 {
+		t.Fatalf("handler must run once, ran %d times", next.calls)
+	}
+}
+
+func TestMiddleware_MinSimilarity(t *testing.T) {
+	db := newDB()
+	next := &countingHandler{}
+	loose := httpcache.New(db).Handler(next)
+	strict := httpcache.New(db, httpcache.WithMinSimilarity(0.999)).Handler(next)
+
+	do(t, loose, "GET", "/search?q=what+is+the+capital+of+india", "", nil)
+	if rec := do(t, strict, "GET", "/search?q=what+is+the+capital+of+india+today", "", nil); rec.Header().Get("X-Xordb-Cache") == "HIT" {
+		t.Fatal("paraphrase below the route's min similarity must miss")
+	}
+	if rec := do(t, loose, "GET", "/search?q=what+is+the+capital+of+india+now", "", nil); rec.Header().Get("X-Xordb-Cache") != "HIT" {
+		t.Fatal("same paraphrase must hit at the DB threshold")
+	}
+}
+
+func TestMiddleware_DifferentPath_NoHit(t *testing.T) {
+	next := &countingHandler{}
+	h := httpcache.New(newDB()).Handler(next)
+
+	do(t, h, "GET", "/users/1", "", nil)
+	rec := do(t, h, "GET", "/users/2", "", nil)
+	if rec.Header().Get("X-Xordb-Cache") == "HIT" {
+		t.Fatal("similar path must not be served from another route")
+	}
+}
+
+func TestMiddleware_PostJSON_FieldOrderIgnored(t *testing.T) {
+	next := &countingHandler{}
+	h := httpcache.New(newDB(), httpcache.WithMethods("POST")).Handler(next)
+
+	do(t, h, "POST", "/ask", `{"question":"what is the capital of india","lang":"en"}`, nil)
+	rec := do(t, h, "POST", "/ask", `{ "lang": "en", "question": "what is the capital of india" }`, nil)
+	if rec.Header().Get("X-Xordb-Cache") != "HIT" {
+		t.Fatal("reordered JSON body must hit")
+	}
+	if next.calls != 1 {
+		t.Fatalf("handler must run once, ran %d times", next.calls)
+	}
+}
+
+func TestMiddleware_HandlerSeesBody(t *testing.T) {
+	next := &countingHandler{}
+	h := httpcache.New(newDB(), httpcache.WithMethods("POST")).Handler(next)
+
+	rec := do(t, h, "POST", "/ask", `hello`, nil)
+	if rec.Body.String() != "answer:hello" {
+		t.Fatalf("handler must receive original body, got %q", rec.Body.String())
+	}
+}
+
+func TestMiddleware_UncachedMethod(t *testing.T) {
+	next := &countingHandler{}
+	h := httpcache.New(newDB()).Handler(next)
+
+	do(t, h, "POST", "/ask", `x`, nil)
+	do(t, h, "POST", "/ask", `x`, nil)
+	if next.calls != 2 {
+		t.Fatalf("POST must bypass cache by default, calls=%d", next.calls)
+	}
+}
+
+// ── Cache-Control ─────────────────────────────────────────────────────────────
+
+func TestMiddleware_ResponseNoStore(t *testing.T) {
+	next := &countingHandler{header: http.Header{"Cache-Control": {"no-store"}}}
+	h := httpcache.New(newDB()).Handler(next)
+
+	do(t, h, "GET", "/a?q=x", "", nil)
+	do(t, h, "GET", "/a?q=x", "", nil)
+	if next.calls != 2 {
+		t.Fatalf("no-store responses must not be cached, calls=%d", next.calls)
+	}
+}
+
+func TestMiddleware_RequestNoCache_Refreshes(t *testing.T) {
+	next := &countingHandler{}
+	h := httpcache.New(newDB()).Handler(next)
+
+	do(t, h, "GET", "/a?q=x", "", nil)
+	do(t, h, "GET", "/a?q=x", "", http.Header{"Cache-Control": {"no-cache"}})
+	if next.calls != 2 {
+		t.Fatalf("request no-cache must skip lookup, calls=%d", next.calls)
+	}
+	do(t, h, "GET", "/a?q=x", "", nil)
+	if next.calls != 2 {
+		t.Fatalf("refreshed entry must serve later requests, calls=%d", next.calls)
+	}
+}
+
+func TestMiddleware_MaxAge(t *testing.T) {
+	next := &countingHandler{header: http.Header{"Cache-Control": {"max-age=1"}}}
+	h := httpcache.New(newDB()).Handler(next)
+
+	do(t, h, "GET", "/a?q=x", "", nil)
+	if rec := do(t, h, "GET", "/a?q=x", "", nil); rec.Header().Get("X-Xordb-Cache") != "HIT" {
+		t.Fatal("fresh entry must hit")
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if rec := do(t, h, "GET", "/a?q=x", "", nil); rec.Header().Get("X-Xordb-Cache") == "HIT" {
+		t.Fatal("entry past max-age must not hit")
+	}
+}
+
+func TestMiddleware_Vary(t *testing.T) {
+	next := &countingHandler{header: http.Header{"Vary": {"Accept-Language"}}}
+	h := httpcache.New(newDB()).Handler(next)
+
+	do(t, h, "GET", "/a?q=x", "", http.Header{"Accept-Language": {"en"}})
+	if rec := do(t, h, "GET", "/a?q=x", "", http.Header{"Accept-Language": {"de"}}); rec.Header().Get("X-Xordb-Cache") == "HIT" {
+		t.Fatal("different Vary'd header must not hit")
+	}
+	if rec := do(t, h, "GET", "/a?q=x", "", http.Header{"Accept-Language": {"de"}}); rec.Header().Get("X-Xordb-Cache") != "HIT" {
+		t.Fatal("same Vary'd header must hit")
+	}
+}
+
+func TestMiddleware_ErrorStatusNotCached(t *testing.T) {
+	next := &countingHandler{status: http.StatusInternalServerError}
+	h := httpcache.New(newDB()).Handler(next)
+
+	do(t, h, "GET", "/a?q=x", "", nil)
+	do(t, h, "GET", "/a?q=x", "", nil)
+	if next.calls != 2 {
+		t.Fatalf("5xx must not be cached, calls=%d", next.calls)
+	}
+}
+
+// ── body capture ──────────────────────────────────────────────────────────────
+
+func TestMiddleware_OversizedResponse_Streamed(t *testing.T) {
+	big := strings.Repeat("x", 64)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, big)
+		w.(http.Flusher).Flush()
+		io.WriteString(w, big)
+	})
+	h := httpcache.New(newDB(), httpcache.WithMaxBodySize(100)).Handler(next)
+
+	rec := do(t, h, "GET", "/a", "", nil)
+	if rec.Body.Len() != 128 {
+		t.Fatalf("client must receive full body, got %d bytes", rec.Body.Len())
+	}
+	if rec := do(t, h, "GET", "/a", "", nil); rec.Header().Get("X-Xordb-Cache") == "HIT" {
+		t.Fatal("oversized response must not be cached")
+	}
+}
+
+func TestMiddleware_CustomKeyFunc_Bypass(t *testing.T) {
+	next := &countingHandler{}
+	key := func(r *http.Request, _ []byte) (string, bool) {
+		q := r.URL.Query().Get("q")
+		return q, q != ""
+	}
+	h := httpcache.New(newDB(), httpcache.WithKeyFunc(key)).Handler(next)
+
+	do(t, h, "GET", "/a", "", nil)
+	do(t, h, "GET", "/a", "", nil)
+	if next.calls != 2 {
+		t.Fatalf("ok=false must bypass cache, calls=%d", next.calls)
+	}
+}
@@ -0,0 +1,190 @@
+package xordb
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+// KeyBuilder describes a key made of named fields, e.g. (model, system
+// prompt, user prompt), for SetKey and GetKey. Each field is encoded on its
+// own and bound to a role derived from its name, so equal text in two
+// fields does not match; the key's vector then takes each field's share of
+// bits from its weight, making a key's similarity the weighted mean of its
+// fields' similarities. Keys compare meaningfully only with keys of the
+// same field names and weights. Snapshots keep the vectors, but
+// WarmFromJSONL re-encodes String() as plain text.
+type KeyBuilder struct {
+	fields []keyField
+	ns     string // mixed into the field roles, see Template
+}
+
+type keyField struct {
+	name, value string
+	weight      float64 // < 0 = not set
+}
+
+// Key starts a multi-field key:
+//
+//	k := xordb.Key().Field("model", m).Field("system", sys).Field("prompt", p).Weight("prompt", 0.8)
+func Key() *KeyBuilder { return &KeyBuilder{} }
+
+// Field sets field name to value, replacing any earlier value.
+func (k *KeyBuilder) Field(name, value string) *KeyBuilder {
+	if f := k.field(name); f != nil {
+		f.value = value
+		return k
+	}
+	k.fields = append(k.fields, keyField{name: name, value: value, weight: -1})
+	return k
+}
+
+// Weight sets field name's share of the similarity, in [0, 1]. Fields
+// without a weight split what the weighted ones leave of 1 equally, so
+// Weight("prompt", 0.8) on a three-field key leaves 0.1 to each other
+// field; if the weights set add up to more than 1 they are scaled down. A
+// zero weight keeps a field in the exact key but out of the similarity.
+// Panics if name is not a field or w is out of range.
+func (k *KeyBuilder) Weight(name string, w float64) *KeyBuilder {
+	if !(w >= 0 && w <= 1) {
+		panic("xordb: key field weight must be in [0, 1]")
+	}
+	f := k.field(name)
+	if f == nil {
+		panic("xordb: weight for unknown key field " + strconv.Quote(name))
+	}
+	f.weight = w
+	return k
+}
+
+func (k *KeyBuilder) field(name string) *keyField {
+	for i := range k.fields {
+		if k.fields[i].name == name {
+			return &k.fields[i]
+		}
+	}
+	return nil
+}
+
+// String is the exact key the entry is stored under: the fields sorted by
+// name, as name="value" pairs, after the quoted template for Template keys.
+// Weights are not part of it.
+func (k *KeyBuilder) String() string { return k.normalized(nil).exact() }
+
+// normalized returns the fields sorted by name with norm applied to the
+// values, as a new builder.
+func (k *KeyBuilder) normalized(norm func(string) string) *KeyBuilder {
+	fs := append([]keyField(nil), k.fields...)
+	sort.Slice(fs, func(i, j int) bool { return fs[i].name < fs[j].name })
+	if norm != nil {
+		for i := range fs {
+			fs[i].value = norm(fs[i].value)
+		}
+	}
+	return &KeyBuilder{fields: fs, ns: k.ns}
+}
+
+// exact formats sorted fields; see String.
+func (k *KeyBuilder) exact() string {
+	var b strings.Builder
+	if k.ns != "" {
+		b.WriteString(strconv.Quote(k.ns))
+	}
+	for i, f := range k.fields {
+		if i > 0 || k.ns != "" {
+			b.WriteByte(' ')
+		}
+		b.WriteString(f.name)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(f.value))
+	}
+	return b.String()
+}
+
+// weights resolves the field weights as described at Weight.
+func (k *KeyBuilder) weights() []float64 {
+	ws := make([]float64, len(k.fields))
+	var set float64
+	unset := 0
+	for _, f := range k.fields {
+		if f.weight < 0 {
+			unset++
+		} else {
+			set += f.weight
+		}
+	}
+	for i, f := range k.fields {
+		switch {
+		case f.weight >= 0:
+			ws[i] = f.weight
+		case set < 1:
+			ws[i] = (1 - set) / float64(unset)
+		}
+	}
+	return ws
+}
+
+// keySeed fixes the field roles and the bit interleaving, so multi-field
+// vectors stay comparable across processes and snapshots.
+const keySeed = 0x786b6579 // "xkey"
+
+// vec encodes sorted fields; ok is false if a field overran the encode
+// budget.
+func (k *KeyBuilder) vec(c *cache.Cache) (hdc.Vector, bool) {
+	if len(k.fields) == 0 {
+		panic("xordb: key has no fields")
+	}
+	ws := k.weights()
+	var total float64
+	for _, w := range ws {
+		total += w
+	}
+	if total == 0 {
+		panic("xordb: key fields all have zero weight")
+	}
+	parts := make([]hdc.Vector, len(k.fields))
+	for i, f := range k.fields {
+		v, ok := c.Encode(f.value)
+		if !ok {
+			return hdc.Vector{}, false
+		}
+		name := f.name
+		if k.ns != "" {
+			name = k.ns + "\x00" + name
+		}
+		role := hdcx.HashBytes([]byte(name), c.Dims(), keySeed)
+		parts[i] = hdc.Bind(role, v)
+	}
+	return hdcx.Interleave(keySeed, parts, ws), true
+}
+
+// SetKey is Set for a multi-field key, stored under k.String(). The key
+// normalizer applies to each field's value.
+func (db *DB) SetKey(k *KeyBuilder, value any) {
+	k = k.normalized(db.norm)
+	vec, ok := k.vec(db.c)
+	if !ok {
+		return // over the encode budget; not cached
+	}
+	db.c.SetVec(k.exact(), vec, value, db.c.TTL())
+}
+
+// GetKey is Get for a multi-field key; see SetKey.
+func (db *DB) GetKey(k *KeyBuilder) (any, bool, float64) {
+	r := db.LookupKey(k)
+	return r.Value, r.Hit, r.Similarity
+}
+
+// LookupKey is Lookup for a multi-field key; see SetKey.
+func (db *DB) LookupKey(k *KeyBuilder) Result {
+	k = k.normalized(db.norm)
+	vec, ok := k.vec(db.c)
+	if !ok {
+		return Result{}
+	}
+	return result(db.c.LookupVec(k.exact(), vec))
+}
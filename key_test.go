@@ -0,0 +1,48 @@
+package xordb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestKey_String(t *testing.T) {
+	a := xordb.Key().Field("user", "u1").Field("model", "gpt").Field("user", "u2")
+	b := xordb.Key().Field("model", "gpt").Field("user", "u2").Weight("user", 0.5)
+	if a.String() != `model="gpt" user="u2"` || a.String() != b.String() {
+		t.Fatalf("exact keys must be sorted and ignore weights: %s / %s", a, b)
+	}
+}
+
+func TestDB_SetKey(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.8), xordb.WithKeyNormalizer(strings.TrimSpace))
+	key := func(model, prompt string) *xordb.KeyBuilder {
+		return xordb.Key().Field("model", model).Field("prompt", prompt).Weight("prompt", 0.8)
+	}
+	db.SetKey(key("gpt-4", "what is the capital of france"), "Paris")
+
+	if v, ok, _ := db.GetKey(key("gpt-4", " what's the capital of france ")); !ok || v != "Paris" {
+		t.Fatalf("a paraphrased prompt must hit, got %v %v", v, ok)
+	}
+	r := db.LookupKey(key("gpt-4", "how do i bake bread"))
+	if r.Hit {
+		t.Fatalf("a different prompt must miss: %+v", r)
+	}
+	// The prompt carries 0.8 of the similarity, so a different model costs
+	// at most ~0.1 (half of its 0.2 share).
+	if _, _, sim := db.GetKey(key("claude", "what is the capital of france")); sim < 0.85 {
+		t.Fatalf("the model field must matter less than the prompt: %.3f", sim)
+	}
+	if !db.Delete(key("gpt-4", "what is the capital of france").String()) {
+		t.Fatal("SetKey must store under Key.String()")
+	}
+}
+
+func TestDB_SetKey_FieldsAreRoleBound(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.8))
+	db.SetKey(xordb.Key().Field("a", "hello world").Field("b", "goodbye"), 1)
+	if _, ok, sim := db.GetKey(xordb.Key().Field("a", "goodbye").Field("b", "hello world")); ok {
+		t.Fatalf("swapping values between fields must miss: %.3f", sim)
+	}
+}
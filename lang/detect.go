@@ -0,0 +1,123 @@
+// Package lang guesses the language of short texts such as cache keys and
+// routes them to per-language encoders or vector namespaces, so that
+// mixed-language traffic does not cross-match on shared n-grams.
+//
+//	lang.Detect("¿Cuál es la capital de Francia?") // "es"
+package lang
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Codes are ISO 639-1. Non-Latin scripts map to the script's most common
+// language: Cyrillic is "ru", Arabic "ar", Devanagari "hi", Han without kana
+// "zh".
+const Unknown = ""
+
+// profiles holds the most frequent trigrams of each Latin-script language,
+// words padded with '_'. Detection counts how many of a text's trigrams each
+// profile contains.
+var profiles = map[string]string{
+	"en": "_th the he_ _an and nd_ ing ng_ _to ion _of of_ to_ ed_ er_ is_ _in in_ ent tio _is at_ _wh wha hat re_ for _fo or_ _yo you ou_ _ho how _do _ca _be _it it_",
+	"es": "_de de_ os_ _la la_ el_ _el que _qu ue_ en_ as_ ión ón_ con _pa par _lo los _se _un una _po por ara _es qué ué_ _có cóm ómo _cu cuá uál dón _me _y_",
+	"fr": "_de es_ de_ le_ _le ent _la la_ nt_ ion on_ que les _pa ue_ _et et_ des _qu eur _un une _es est st_ _po pou our com omm _je _vo vou ous _ce _du _où",
+	"de": "en_ er_ _de der ie_ ich ein sch die _di und _un nd_ ch_ den cht ten _ei gen ung es_ ine _da das _be _ge ist _is _wi wie _ic _wa was ße_ _ü _ist _zu",
+	"it": "_di di_ to_ _la re_ che _ch he_ one ell del lla le_ ne_ no_ _co zio _il il_ per _pe con ato are _è_ _qu qua com ome _de _un gli _gl _no",
+	"pt": "_de de_ os_ ão_ do_ _do _qu que da_ _da _co _se com as_ ção nte ra_ _pa par men _um um_ em_ _é_ ado _nã não omo _on ões _qu _ma",
+	"nl": "en_ de_ _de een van _va an_ et_ het _he _ee ij_ ver aar oor ing _ge nde _en den cht _zi ijn _ve _wa wat _ho hoe oe_ _ik ik_ _ni",
+}
+
+var trigramSets = func() map[string]map[string]bool {
+	m := make(map[string]map[string]bool, len(profiles))
+	for l, p := range profiles {
+		set := make(map[string]bool)
+		for _, t := range strings.Fields(p) {
+			set[t] = true
+		}
+		m[l] = set
+	}
+	return m
+}()
+
+// Detect returns text's language code, or Unknown if text has too few
+// letters or no language clearly wins. Short keys are often Unknown.
+func Detect(text string) string {
+	var latin, cyrillic, greek, arabic, hebrew, devanagari, thai, han, kana, hangul int
+	for _, r := range text {
+		switch {
+		case !unicode.IsLetter(r):
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Hebrew, r):
+			hebrew++
+		case unicode.Is(unicode.Devanagari, r):
+			devanagari++
+		case unicode.Is(unicode.Thai, r):
+			thai++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		}
+	}
+	best, code := latin, "latin"
+	for _, s := range []struct {
+		n    int
+		code string
+	}{
+		{cyrillic, "ru"}, {greek, "el"}, {arabic, "ar"}, {hebrew, "he"},
+		{devanagari, "hi"}, {thai, "th"}, {han + kana, "zh"}, {hangul, "ko"},
+	} {
+		if s.n > best {
+			best, code = s.n, s.code
+		}
+	}
+	switch {
+	case best == 0:
+		return Unknown
+	case code == "zh" && kana > 0:
+		return "ja"
+	case code != "latin":
+		return code
+	}
+	return detectLatin(text)
+}
+
+// detectLatin picks the profile matching most of text's trigrams.
+func detectLatin(text string) string {
+	var tris []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		rs := []rune("_" + w + "_")
+		for i := 0; i+3 <= len(rs); i++ {
+			tris = append(tris, string(rs[i:i+3]))
+		}
+	}
+	best, second, code := 0, 0, Unknown
+	for l, set := range trigramSets {
+		n := 0
+		for _, t := range tris {
+			if set[t] {
+				n++
+			}
+		}
+		switch {
+		case n > best:
+			best, second, code = n, best, l
+		case n > second:
+			second = n
+		}
+	}
+	if best < 2 || best == second {
+		return Unknown
+	}
+	return code
+}
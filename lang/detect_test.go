@@ -0,0 +1,44 @@
+package lang_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/xordb/lang"
+)
+
+func TestDetect(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"what is the capital of india", "en"},
+		{"¿Cuál es la capital de Francia?", "es"},
+		{"quelle est la capitale de la France", "fr"},
+		{"was ist die Hauptstadt von Deutschland", "de"},
+		{"qual è la capitale d'Italia", "it"},
+		{"como fazer pão de queijo", "pt"},
+		{"wat is de hoofdstad van Nederland", "nl"},
+		{"привет как дела", "ru"},
+		{"東京の天気", "ja"},
+		{"北京天气", "zh"},
+		{"안녕하세요", "ko"},
+		{"Καλημέρα", "el"},
+		{"hello", lang.Unknown}, // too short to tell
+		{"12345 !!", lang.Unknown},
+		{"", lang.Unknown},
+	}
+	for _, c := range cases {
+		if got := lang.Detect(c.in); got != c.want {
+			t.Errorf("Detect(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestDetect_CodesListed(t *testing.T) {
+	known := make(map[string]bool)
+	for _, code := range lang.Languages {
+		known[code] = true
+	}
+	for _, s := range []string{"the way to the station", "東京の天気", "שלום עולם", "مرحبا بالعالم", "नमस्ते दुनिया", "สวัสดีครับ"} {
+		if code := lang.Detect(s); !known[code] {
+			t.Errorf("Detect(%q) = %q, not in Languages", s, code)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package lang
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+// Languages lists the codes Detect can return.
+var Languages = []string{
+	"en", "es", "fr", "de", "it", "pt", "nl",
+	"ru", "el", "ar", "he", "hi", "th", "zh", "ja", "ko",
+}
+
+// namespaceSeed derives the per-language binding keys.
+const namespaceSeed = 0x6c616e67 // "lang"
+
+// Router is an hdc.Encoder that detects each key's language, encodes it
+// with that language's encoder, or the default one, and binds the vector
+// to a key derived from the language code. Keys in different languages
+// then land in unrelated parts of the space and cannot match one another,
+// however many n-grams they share; keys Detect cannot place (Unknown, e.g.
+// very short ones) stay unbound and match only each other.
+type Router struct {
+	def  hdc.Encoder
+	encs map[string]hdc.Encoder
+	keys map[string]hdc.Vector
+	dims int
+}
+
+// NewRouter returns a Router encoding with encs[language], falling back to
+// def; encs is keyed by the codes in Languages and may be nil to use def
+// for everything. Every encoder must return vectors of def's dims. Panics
+// if def is nil or the dims differ.
+func NewRouter(def hdc.Encoder, encs map[string]hdc.Encoder) *Router {
+	if def == nil {
+		panic("lang: default encoder must not be nil")
+	}
+	dims := def.Encode("").Dims()
+	if dims <= 0 {
+		panic("lang: default encoder returned empty vectors")
+	}
+	r := &Router{def: def, encs: make(map[string]hdc.Encoder, len(encs)), keys: make(map[string]hdc.Vector), dims: dims}
+	for code, enc := range encs {
+		if enc == nil {
+			continue
+		}
+		if d := enc.Encode("").Dims(); d != dims {
+			panic(fmt.Sprintf("lang: %q encoder returned %d dims, default %d", code, d, dims))
+		}
+		r.encs[code] = enc
+	}
+	for _, code := range Languages {
+		r.keys[code] = hdcx.HashBytes([]byte(code), dims, namespaceSeed)
+	}
+	return r
+}
+
+// Encode encodes text in its language's namespace.
+func (r *Router) Encode(text string) hdc.Vector {
+	code := Detect(text)
+	enc, ok := r.encs[code]
+	if !ok {
+		enc = r.def
+	}
+	v := enc.Encode(text)
+	if code == Unknown {
+		return v
+	}
+	return hdc.Bind(r.keys[code], v)
+}
+
+// Dims returns the dims of the router's vectors.
+func (r *Router) Dims() int { return r.dims }
+
+// Close closes the encoders that are io.Closers.
+func (r *Router) Close() error {
+	var errs []error
+	for _, enc := range r.encs {
+		if c, ok := enc.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	if c, ok := r.def.(io.Closer); ok {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
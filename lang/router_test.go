@@ -0,0 +1,80 @@
+package lang_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/lang"
+)
+
+func ngram() hdc.Encoder { return hdc.NewNGramEncoder(hdc.DefaultConfig()) }
+
+// ── namespaces ──
+
+func TestRouter_SeparatesLanguages(t *testing.T) {
+	r := lang.NewRouter(ngram(), nil)
+	en := "the capital of spain is madrid"
+	es := "la capital de españa es madrid"
+	if s := hdc.Similarity(ngram().Encode(en), ngram().Encode(es)); s < 0.6 {
+		t.Fatalf("plain similarity %.3f, want the sentences to overlap", s)
+	}
+	if s := hdc.Similarity(r.Encode(en), r.Encode(es)); s > 0.55 {
+		t.Errorf("routed similarity %.3f, want unrelated", s)
+	}
+	a, b := "what is the capital of spain", "what is the capital of spain?"
+	if s := hdc.Similarity(r.Encode(a), r.Encode(b)); s < 0.9 {
+		t.Errorf("same-language similarity %.3f, want kept", s)
+	}
+}
+
+func TestRouter_UnknownUnbound(t *testing.T) {
+	def := ngram()
+	r := lang.NewRouter(def, nil)
+	if hdc.Similarity(r.Encode("ok"), def.Encode("ok")) != 1 {
+		t.Error("undetected key was bound")
+	}
+	if r.Dims() != 10000 {
+		t.Errorf("Dims() = %d", r.Dims())
+	}
+}
+
+// ── per-language encoders ──
+
+type countingEncoder struct {
+	hdc.Encoder
+	n      *int
+	closed *bool
+}
+
+func (e countingEncoder) Encode(s string) hdc.Vector { *e.n++; return e.Encoder.Encode(s) }
+func (e countingEncoder) Close() error               { *e.closed = true; return errors.New("closed") }
+
+func TestRouter_PerLanguageEncoder(t *testing.T) {
+	var n int
+	var closed bool
+	r := lang.NewRouter(ngram(), map[string]hdc.Encoder{"fr": countingEncoder{ngram(), &n, &closed}})
+	n = 0
+	r.Encode("what is the capital of france")
+	if n != 0 {
+		t.Error("English key used the French encoder")
+	}
+	r.Encode("quelle est la capitale de la France")
+	if n != 1 {
+		t.Errorf("French encoder called %d times, want 1", n)
+	}
+	if err := r.Close(); err == nil || !closed {
+		t.Errorf("Close() = %v, closed %v", err, closed)
+	}
+}
+
+func TestRouter_DimsMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic")
+		}
+	}()
+	cfg := hdc.DefaultConfig()
+	cfg.Dims = 2048
+	lang.NewRouter(ngram(), map[string]hdc.Encoder{"de": hdc.NewNGramEncoder(cfg)})
+}
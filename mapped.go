@@ -0,0 +1,87 @@
+package xordb
+
+import (
+	"fmt"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// Mapped is a read-only cache served straight from a snapshot file mapped
+// into memory, for large read-mostly corpora built offline: vectors stay in
+// the page cache instead of the Go heap, and opening one only indexes keys,
+// so restarts are near-instant. The CRC is left to Verify. Lookups are exact linear scans with Hamming similarity. Safe for
+// concurrent use; call Close when done.
+type Mapped struct {
+	m      *cache.Mapped
+	norm   func(string) string
+	cipher *valueCipher
+}
+
+// OpenMapped maps a snapshot written by Save (or one segment of
+// SaveSharded) with the built-in n-gram encoder. Only the encoding options,
+// WithThreshold, WithKeyNormalizer and WithValueEncryption apply; they
+// must match the DB that wrote the file.
+func OpenMapped(path string, opts ...Option) (*Mapped, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return openMapped(path, o.ngramEncoder(), o)
+}
+
+// OpenMappedWithEncoder is OpenMapped with a custom encoder.
+func OpenMappedWithEncoder(path string, enc hdc.Encoder, opts ...Option) (*Mapped, error) {
+	if enc == nil {
+		panic("xordb: encoder must not be nil")
+	}
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if _, err := o.encoderDims(enc); err != nil {
+		return nil, err
+	}
+	return openMapped(path, o.seededEncoders(enc), o)
+}
+
+func openMapped(path string, enc hdc.Encoder, o dbOptions) (*Mapped, error) {
+	m, err := cache.OpenMapped(path, enc, o.threshold)
+	if err != nil {
+		return nil, fmt.Errorf("xordb: open mapped: %w", err)
+	}
+	return &Mapped{m: m, norm: o.normalizer(), cipher: o.cipher}, nil
+}
+
+// Get returns (value, true, similarity) on a hit, (nil, false, 0) on a miss.
+// An encrypted value that does not decrypt is a miss.
+func (m *Mapped) Get(key string) (any, bool, float64) {
+	if m.norm != nil {
+		key = m.norm(key)
+	}
+	matched, v, ok, sim := m.m.GetMatch(key)
+	if !ok {
+		return nil, false, 0
+	}
+	v, _, err := m.cipher.openValue(matched, v)
+	if err != nil {
+		return nil, false, 0
+	}
+	return v, true, sim
+}
+
+// Verify checks the file's CRC. It reads the whole file, which OpenMapped
+// avoids to keep opening fast; call it right after opening when a corrupt
+// file must never serve, or in the background to detect one.
+func (m *Mapped) Verify() error {
+	if err := m.m.Verify(); err != nil {
+		return fmt.Errorf("xordb: verify mapped: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of entries in the file, including expired ones.
+func (m *Mapped) Len() int { return m.m.Len() }
+
+// Close unmaps the file; later lookups miss.
+func (m *Mapped) Close() error { return m.m.Close() }
@@ -0,0 +1,45 @@
+package xordb_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestOpenMapped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.xrdb")
+	db := xordb.New(xordb.WithThreshold(0.65))
+	db.Set("what is the capital of india", "Delhi")
+	if err := db.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := xordb.OpenMapped(path, xordb.WithThreshold(0.65), xordb.WithKeyNormalizer(strings.TrimSpace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.Verify(); err != nil {
+		t.Fatalf("intact file must verify: %v", err)
+	}
+	if v, ok, sim := m.Get("  capital city of india "); !ok || v != "Delhi" || sim < 0.65 {
+		t.Fatalf("want a semantic hit, got %v %v %v", v, ok, sim)
+	}
+	if _, ok, _ := m.Get("recipe for chocolate cake"); ok {
+		t.Fatal("unrelated key must miss")
+	}
+}
+
+func TestOpenMapped_DimsMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.xrdb")
+	db := xordb.New()
+	db.Set("alpha", "A")
+	if err := db.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := xordb.OpenMapped(path, xordb.WithDims(4096)); err == nil {
+		t.Fatal("dims mismatch must fail")
+	}
+}
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Dashboard returns a Grafana dashboard, as importable JSON, charting cache
+// effectiveness from the Collector's series: hit rate, the similarity of
+// hits, eviction rate, encode latency and size. It asks for a Prometheus
+// data source on import and has a "cache" variable over the cache label.
+//
+// The queries are built from Metrics, so the dashboard cannot name a series
+// the Collector does not emit; xordb-dashboard.json in this directory is its
+// output, kept in sync by the package tests.
+func Dashboard() []byte {
+	ts := func(title, unit string, x, y int, exprs ...string) panel {
+		p := panel{
+			Type: "timeseries", Title: title, Datasource: datasource,
+			GridPos:     gridPos{H: 8, W: 12, X: x, Y: y},
+			FieldConfig: fieldConfig{Defaults: fieldDefaults{Unit: unit}},
+		}
+		for i, e := range exprs {
+			p.Targets = append(p.Targets, target{Expr: e, LegendFormat: "{{cache}}", RefID: string(rune('A' + i))})
+		}
+		return p
+	}
+	hits, misses := series("xordb_hits_total"), series("xordb_misses_total")
+	sim := known("xordb_hit_similarity")
+
+	hitRate := ts("Hit rate", "percentunit", 0, 0,
+		fmt.Sprintf("sum by (cache) (rate(%s[$__rate_interval])) / (sum by (cache) (rate(%s[$__rate_interval])) + sum by (cache) (rate(%s[$__rate_interval])))",
+			hits, hits, misses))
+	hitRate.FieldConfig.Defaults.Min, hitRate.FieldConfig.Defaults.Max = ptr(0.0), ptr(1.0)
+
+	heatmap := panel{
+		Type: "heatmap", Title: "Similarity of hits", Datasource: datasource,
+		GridPos: gridPos{H: 8, W: 12, X: 12, Y: 0},
+		Targets: []target{{
+			Expr:         fmt.Sprintf("sum by (le) (increase(%s_bucket{cache=~\"$cache\"}[$__rate_interval]))", sim),
+			Format:       "heatmap",
+			LegendFormat: "{{le}}",
+			RefID:        "A",
+		}},
+		Options: map[string]any{"calculate": false, "yAxis": map[string]any{"unit": "percentunit"}},
+	}
+
+	d := dashboard{
+		Title:         "xordb cache effectiveness",
+		UID:           "xordb-effectiveness",
+		SchemaVersion: 39,
+		Time:          timeRange{From: "now-6h", To: "now"},
+		Refresh:       "30s",
+		Tags:          []string{"xordb"},
+		Inputs: []input{{
+			Name: "DS_PROMETHEUS", Label: "Prometheus", Type: "datasource",
+			PluginID: "prometheus", PluginName: "Prometheus",
+		}},
+		Templating: templating{List: []variable{{
+			Name: "cache", Label: "cache", Type: "query", Datasource: datasource,
+			Query:      fmt.Sprintf("label_values(%s, cache)", known("xordb_entries")),
+			Refresh:    2,
+			IncludeAll: true, Multi: true,
+			Current: map[string]any{"text": "All", "value": "$__all"},
+		}}},
+		Panels: []panel{
+			hitRate,
+			heatmap,
+			ts("Evictions and expirations", "ops", 0, 8,
+				fmt.Sprintf("sum by (cache) (rate(%s[$__rate_interval]))", series("xordb_evictions_total")),
+				fmt.Sprintf("sum by (cache) (rate(%s[$__rate_interval]))", series("xordb_expired_total"))),
+			ts("Encode latency p99", "s", 12, 8, series("xordb_encode_latency_p99_seconds")),
+			ts("Entries", "short", 0, 16, series("xordb_entries")),
+			ts("Mean similarity of hits", "percentunit", 12, 16, series("xordb_avg_similarity_on_hit")),
+		},
+	}
+	d.Panels[2].Targets[1].LegendFormat = "{{cache}} expired"
+	for i := range d.Panels {
+		d.Panels[i].ID = i + 1
+	}
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		panic("metrics: " + err.Error())
+	}
+	return append(b, '\n')
+}
+
+// series returns the selector of the family name filtered by the dashboard's
+// cache variable.
+func series(name string) string { return known(name) + `{cache=~"$cache"}` }
+
+// known returns name, or panics if Metrics has no such family.
+func known(name string) string {
+	for _, m := range Metrics {
+		if m.Name == name {
+			return name
+		}
+	}
+	panic("metrics: dashboard references unknown metric " + name)
+}
+
+func ptr[T any](v T) *T { return &v }
+
+var datasource = map[string]string{"type": "prometheus", "uid": "${DS_PROMETHEUS}"}
+
+type dashboard struct {
+	Inputs        []input    `json:"__inputs"`
+	Title         string     `json:"title"`
+	UID           string     `json:"uid"`
+	Tags          []string   `json:"tags"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Time          timeRange  `json:"time"`
+	Refresh       string     `json:"refresh"`
+	Templating    templating `json:"templating"`
+	Panels        []panel    `json:"panels"`
+}
+
+type input struct {
+	Name       string `json:"name"`
+	Label      string `json:"label"`
+	Type       string `json:"type"`
+	PluginID   string `json:"pluginId"`
+	PluginName string `json:"pluginName"`
+}
+
+type timeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type templating struct {
+	List []variable `json:"list"`
+}
+
+type variable struct {
+	Name       string            `json:"name"`
+	Label      string            `json:"label"`
+	Type       string            `json:"type"`
+	Datasource map[string]string `json:"datasource"`
+	Query      string            `json:"query"`
+	Refresh    int               `json:"refresh"`
+	IncludeAll bool              `json:"includeAll"`
+	Multi      bool              `json:"multi"`
+	Current    map[string]any    `json:"current"`
+}
+
+type panel struct {
+	ID          int               `json:"id"`
+	Type        string            `json:"type"`
+	Title       string            `json:"title"`
+	Datasource  map[string]string `json:"datasource"`
+	GridPos     gridPos           `json:"gridPos"`
+	FieldConfig fieldConfig       `json:"fieldConfig"`
+	Options     map[string]any    `json:"options,omitempty"`
+	Targets     []target          `json:"targets"`
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type fieldConfig struct {
+	Defaults fieldDefaults `json:"defaults"`
+}
+
+type fieldDefaults struct {
+	Unit string   `json:"unit,omitempty"`
+	Min  *float64 `json:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty"`
+}
+
+type target struct {
+	Expr         string `json:"expr"`
+	Format       string `json:"format,omitempty"`
+	LegendFormat string `json:"legendFormat"`
+	RefID        string `json:"refId"`
+}
@@ -0,0 +1,67 @@
+package metrics_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/metrics"
+)
+
+var update = flag.Bool("update", false, "rewrite xordb-dashboard.json")
+
+func TestDashboard_File(t *testing.T) {
+	got := metrics.Dashboard()
+	if *update {
+		if err := os.WriteFile("xordb-dashboard.json", got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile("xordb-dashboard.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("xordb-dashboard.json is stale: go test ./metrics -run Dashboard -update")
+	}
+}
+
+func TestDashboard_SeriesExist(t *testing.T) {
+	var d struct {
+		Panels []struct {
+			Targets []struct{ Expr string }
+		}
+	}
+	if err := json.Unmarshal(metrics.Dashboard(), &d); err != nil {
+		t.Fatal(err)
+	}
+
+	db := xordb.New()
+	db.Set("what is the capital of india", "Delhi")
+	db.Get("what is the capital of india")
+	col := metrics.NewCollector()
+	col.Register("faq", db)
+	var b strings.Builder
+	col.WriteTo(&b)
+
+	names := regexp.MustCompile(`xordb_[a-z0-9_]+`)
+	n := 0
+	for _, p := range d.Panels {
+		for _, tg := range p.Targets {
+			for _, name := range names.FindAllString(tg.Expr, -1) {
+				n++
+				if !strings.Contains(b.String(), "\n"+name+"{") {
+					t.Errorf("%s is not exposed", name)
+				}
+			}
+		}
+	}
+	if n < 6 {
+		t.Fatalf("only %d series referenced", n)
+	}
+}
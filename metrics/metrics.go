@@ -0,0 +1,184 @@
+// Package metrics exposes xordb Stats in the Prometheus text format without
+// pulling in the Prometheus client library.
+//
+//	col := metrics.NewCollector()
+//	col.Register("default", db)
+//	mux.Handle("/metrics", col)
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// Source is anything that reports xordb stats; *xordb.DB satisfies it.
+type Source interface {
+	Stats() xordb.Stats
+}
+
+// Metric describes one exported series family.
+type Metric struct {
+	Name  string
+	Type  string // "counter", "gauge" or "histogram"
+	Help  string
+	value func(xordb.Stats) float64
+
+	// buckets, for histograms, returns the count of each bucket, not
+	// cumulative, with upper bounds SimilarityBuckets; value is the sum.
+	buckets func(xordb.Stats) []uint64
+}
+
+// SimilarityBuckets are the upper bounds of xordb_hit_similarity's buckets.
+var SimilarityBuckets = func() []float64 {
+	b := make([]float64, cache.NumSimBuckets)
+	for i := range b {
+		b[i] = float64(i+1) / cache.NumSimBuckets
+	}
+	return b
+}()
+
+// Metrics lists every family the Collector emits, in output order.
+var Metrics = []Metric{
+	{"xordb_entries", "gauge", "Current number of cached entries.",
+		func(s xordb.Stats) float64 { return float64(s.Entries) }, nil},
+	{"xordb_hits_total", "counter", "Lookups answered from cache.",
+		func(s xordb.Stats) float64 { return float64(s.Hits) }, nil},
+	{"xordb_misses_total", "counter", "Lookups with no entry above threshold.",
+		func(s xordb.Stats) float64 { return float64(s.Misses) }, nil},
+	{"xordb_exact_hits_total", "counter", "Hits on the entry stored under the key looked up.",
+		func(s xordb.Stats) float64 { return float64(s.ExactHits) }, nil},
+	{"xordb_sets_total", "counter", "Set calls, including updates of existing keys.",
+		func(s xordb.Stats) float64 { return float64(s.Sets) }, nil},
+	{"xordb_expired_total", "counter", "Entries removed after their TTL elapsed.",
+		func(s xordb.Stats) float64 { return float64(s.Expired) }, nil},
+	{"xordb_evictions_total", "counter", "Entries evicted to make room.",
+		func(s xordb.Stats) float64 { return float64(s.Evictions) }, nil},
+	{"xordb_hit_rate", "gauge", "Hits / (hits + misses) over the cache's lifetime.",
+		func(s xordb.Stats) float64 { return s.HitRate }, nil},
+	{"xordb_avg_similarity_on_hit", "gauge", "Mean similarity of cache hits.",
+		func(s xordb.Stats) float64 { return s.AvgSimOnHit }, nil},
+	{"xordb_lsh_candidates_total", "counter", "Candidates evaluated via the LSH index.",
+		func(s xordb.Stats) float64 { return float64(s.LSHCandidates) }, nil},
+	{"xordb_lsh_fallbacks_total", "counter", "LSH misses that fell back to a linear scan.",
+		func(s xordb.Stats) float64 { return float64(s.LSHFallbacks) }, nil},
+	{"xordb_pruned_total", "counter", "Comparisons skipped by the popcount prefilter.",
+		func(s xordb.Stats) float64 { return float64(s.Pruned) }, nil},
+	{"xordb_definite_misses_total", "counter", "Misses answered by the popcount miss filter without a scan.",
+		func(s xordb.Stats) float64 { return float64(s.DefiniteMisses) }, nil},
+	{"xordb_soft_misses_total", "counter", "Misses that returned their best entry below the threshold.",
+		func(s xordb.Stats) float64 { return float64(s.SoftMisses) }, nil},
+	{"xordb_busy_total", "counter", "Lookups turned away by the concurrent scan limit.",
+		func(s xordb.Stats) float64 { return float64(s.Busy) }, nil},
+	{"xordb_ambiguous_total", "counter", "Misses whose best match did not lead the runner-up by the margin.",
+		func(s xordb.Stats) float64 { return float64(s.Ambiguous) }, nil},
+	{"xordb_spooled_total", "counter", "Values over the size limit spooled to disk.",
+		func(s xordb.Stats) float64 { return float64(s.Spooled) }, nil},
+	{"xordb_oversize_total", "counter", "Values over the size limit not cached.",
+		func(s xordb.Stats) float64 { return float64(s.Oversize) }, nil},
+	{"xordb_cold_entries", "gauge", "Entries whose value is on disk in the cold tier.",
+		func(s xordb.Stats) float64 { return float64(s.Cold) }, nil},
+	{"xordb_cold_faults_total", "counter", "Hits that read a value back from the cold tier.",
+		func(s xordb.Stats) float64 { return float64(s.Faults) }, nil},
+	{"xordb_encode_cache_hits_total", "counter", "Keys whose vector came from the encode cache.",
+		func(s xordb.Stats) float64 { return float64(s.EncodeCacheHits) }, nil},
+	{"xordb_encode_latency_p99_seconds", "gauge", "99th percentile of sampled encode times.",
+		func(s xordb.Stats) float64 { return s.Latency.Encode.P99.Seconds() }, nil},
+	{"xordb_lock_wait_p99_seconds", "gauge", "99th percentile of sampled lookup lock waits.",
+		func(s xordb.Stats) float64 { return s.Latency.LockWait.P99.Seconds() }, nil},
+	{"xordb_scan_latency_p99_seconds", "gauge", "99th percentile of sampled match searches.",
+		func(s xordb.Stats) float64 { return s.Latency.Scan.P99.Seconds() }, nil},
+	{"xordb_lookup_candidates_mean", "gauge", "Mean entries offered by LSH or the scan per lookup.",
+		func(s xordb.Stats) float64 { return s.PerLookup.Candidates.Mean }, nil},
+	{"xordb_lookup_compared_mean", "gauge", "Mean entries fully compared per lookup, after the prefilter.",
+		func(s xordb.Stats) float64 { return s.PerLookup.Compared.Mean }, nil},
+	{"xordb_lookup_compared_p99", "gauge", "99th percentile of entries fully compared per lookup.",
+		func(s xordb.Stats) float64 { return float64(s.PerLookup.Compared.P99) }, nil},
+	{"xordb_encode_errors_total", "counter", "Encoder panics and wrong-dims vectors recovered.",
+		func(s xordb.Stats) float64 { return float64(s.EncodeErrors) }, nil},
+	{Name: "xordb_hit_similarity", Type: "histogram",
+		Help:    "Similarity of cache hits; a bucket's upper bound is exclusive, except 1.",
+		value:   func(s xordb.Stats) float64 { return s.AvgSimOnHit * float64(s.Hits) },
+		buckets: func(s xordb.Stats) []uint64 { return s.HitSimilarity[:] }},
+}
+
+// Collector gathers stats from registered sources on every scrape.
+// Safe for concurrent use.
+type Collector struct {
+	mu      sync.RWMutex
+	sources map[string]Source // cache label → source
+}
+
+func NewCollector() *Collector {
+	return &Collector{sources: make(map[string]Source)}
+}
+
+// Register adds (or replaces) a source under the given cache label.
+func (c *Collector) Register(name string, src Source) {
+	c.mu.Lock()
+	c.sources[name] = src
+	c.mu.Unlock()
+}
+
+// Unregister removes a source. No-op if absent.
+func (c *Collector) Unregister(name string) {
+	c.mu.Lock()
+	delete(c.sources, name)
+	c.mu.Unlock()
+}
+
+// WriteTo writes all metrics in Prometheus text exposition format (0.0.4).
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.sources))
+	for name := range c.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	stats := make([]xordb.Stats, len(names))
+	for i, name := range names {
+		stats[i] = c.sources[name].Stats()
+	}
+	c.mu.RUnlock()
+
+	var b strings.Builder
+	for _, m := range Metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", m.Name, m.Help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", m.Name, m.Type)
+		for i, name := range names {
+			if m.buckets != nil {
+				writeHistogram(&b, m, strconv.Quote(name), stats[i])
+				continue
+			}
+			fmt.Fprintf(&b, "%s{cache=%s} %s\n", m.Name, strconv.Quote(name),
+				strconv.FormatFloat(m.value(stats[i]), 'g', -1, 64))
+		}
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func writeHistogram(b *strings.Builder, m Metric, cache string, s xordb.Stats) {
+	var total uint64
+	for i, n := range m.buckets(s) {
+		total += n
+		fmt.Fprintf(b, "%s_bucket{cache=%s,le=\"%s\"} %d\n", m.Name, cache,
+			strconv.FormatFloat(SimilarityBuckets[i], 'g', -1, 64), total)
+	}
+	fmt.Fprintf(b, "%s_bucket{cache=%s,le=\"+Inf\"} %d\n", m.Name, cache, total)
+	fmt.Fprintf(b, "%s_sum{cache=%s} %s\n", m.Name, cache, strconv.FormatFloat(m.value(s), 'g', -1, 64))
+	fmt.Fprintf(b, "%s_count{cache=%s} %d\n", m.Name, cache, total)
+}
+
+// ServeHTTP makes the Collector usable as a /metrics handler.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
@@ -0,0 +1,95 @@
+package metrics_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/metrics"
+)
+
+func TestCollector_Exposition(t *testing.T) {
+	db := xordb.New()
+	db.Set("what is the capital of india", "Delhi")
+	db.Get("what is the capital of india")
+	db.Get("how do you bake a chocolate cake")
+
+	col := metrics.NewCollector()
+	col.Register("faq", db)
+
+	rec := httptest.NewRecorder()
+	col.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE xordb_hits_total counter",
+		`xordb_entries{cache="faq"} 1`,
+		`xordb_hits_total{cache="faq"} 1`,
+		`xordb_misses_total{cache="faq"} 1`,
+		`xordb_hit_rate{cache="faq"} 0.5`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in output:\n%s", want, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+}
+
+func TestCollector_MultipleSources_Sorted(t *testing.T) {
+	col := metrics.NewCollector()
+	col.Register("b", xordb.New())
+	col.Register("a", xordb.New())
+
+	var b strings.Builder
+	col.WriteTo(&b)
+	out := b.String()
+	ia := strings.Index(out, `xordb_entries{cache="a"}`)
+	ib := strings.Index(out, `xordb_entries{cache="b"}`)
+	if ia < 0 || ib < 0 || ia > ib {
+		t.Fatalf("sources must be emitted in label order:\n%s", out)
+	}
+
+	col.Unregister("a")
+	b.Reset()
+	col.WriteTo(&b)
+	if strings.Contains(b.String(), `cache="a"`) {
+		t.Fatal("unregistered source must not be emitted")
+	}
+}
+
+func TestCollector_Empty(t *testing.T) {
+	var b strings.Builder
+	if _, err := metrics.NewCollector().WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "# HELP xordb_entries") {
+		t.Fatal("families must be described even with no sources")
+	}
+}
+
+func TestCollector_SimilarityHistogram(t *testing.T) {
+	db := xordb.New()
+	db.Set("what is the capital of india", "Delhi")
+	db.Get("what is the capital of india")
+	db.Get("what is the capital of india")
+
+	col := metrics.NewCollector()
+	col.Register("faq", db)
+	var b strings.Builder
+	col.WriteTo(&b)
+	for _, want := range []string{
+		"# TYPE xordb_hit_similarity histogram",
+		`xordb_hit_similarity_bucket{cache="faq",le="0.05"} 0`,
+		`xordb_hit_similarity_bucket{cache="faq",le="1"} 2`,
+		`xordb_hit_similarity_bucket{cache="faq",le="+Inf"} 2`,
+		`xordb_hit_similarity_sum{cache="faq"} 2`,
+		`xordb_hit_similarity_count{cache="faq"} 2`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("missing %q in output:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package metrics
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// StatusSource is what a StatusPage shows; *xordb.DB satisfies it.
+type StatusSource interface {
+	Source
+	TopKeys(n int) []xordb.KeyHits
+}
+
+const (
+	statusTopKeys  = 10 // hottest keys listed per cache
+	statusRecent   = 20 // evictions and expiries kept per cache
+	statusRefresh  = 10 // seconds between page reloads
+	statusBarWidth = 100
+)
+
+// StatusPage is a self-contained HTML page of each registered cache's
+// live stats, the similarity of its hits, its hottest keys and its recent
+// evictions and expiries: observability for a small deployment with no
+// Prometheus to scrape the Collector. The page reloads itself every ten
+// seconds and needs no JavaScript or assets.
+//
+//	page := metrics.NewStatusPage()
+//	db := xordb.New(xordb.WithEventHook(page.Events("default")))
+//	page.Register("default", db)
+//	mux.Handle("/status", page)
+//
+// Evictions are only seen through the Events hook; without it that table
+// stays empty. The page lists stored keys, so serve it only to those who
+// may read them. Safe for concurrent use.
+type StatusPage struct {
+	mu      sync.Mutex
+	sources map[string]StatusSource
+	recent  map[string]*evictionLog
+}
+
+// evictionLog keeps a cache's latest evictions and expiries, oldest
+// overwritten first.
+type evictionLog struct {
+	events []xordb.Event
+	next   int
+}
+
+func NewStatusPage() *StatusPage {
+	return &StatusPage{sources: make(map[string]StatusSource), recent: make(map[string]*evictionLog)}
+}
+
+// Register adds (or replaces) a source under the given cache name.
+func (p *StatusPage) Register(name string, src StatusSource) {
+	p.mu.Lock()
+	p.sources[name] = src
+	p.mu.Unlock()
+}
+
+// Unregister removes a source and its recorded evictions. No-op if absent.
+func (p *StatusPage) Unregister(name string) {
+	p.mu.Lock()
+	delete(p.sources, name)
+	delete(p.recent, name)
+	p.mu.Unlock()
+}
+
+// Events returns a hook for xordb.WithEventHook that records the evictions
+// and expiries of the cache registered as name; other events are ignored.
+// Chain it with any other hook the DB needs.
+func (p *StatusPage) Events(name string) func(xordb.Event) {
+	return func(ev xordb.Event) {
+		if ev.Kind != xordb.EventEvict && ev.Kind != xordb.EventExpire {
+			return
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		l := p.recent[name]
+		if l == nil {
+			l = &evictionLog{}
+			p.recent[name] = l
+		}
+		if len(l.events) < statusRecent {
+			l.events = append(l.events, ev)
+			return
+		}
+		l.events[l.next] = ev
+		l.next = (l.next + 1) % statusRecent
+	}
+}
+
+// latest returns the log's events, newest first.
+func (l *evictionLog) latest() []xordb.Event {
+	if l == nil {
+		return nil
+	}
+	out := make([]xordb.Event, 0, len(l.events))
+	for i := range l.events {
+		out = append(out, l.events[(l.next-1-i+2*len(l.events))%len(l.events)])
+	}
+	return out
+}
+
+type statusCache struct {
+	Name    string
+	Stats   xordb.Stats
+	Buckets []statusBucket
+	TopKeys []xordb.KeyHits
+	Recent  []xordb.Event
+}
+
+type statusBucket struct {
+	Upper float64
+	Count uint64
+	Width int // of the bar, out of statusBarWidth
+}
+
+// ServeHTTP renders the page.
+func (p *StatusPage) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	p.mu.Lock()
+	names := make([]string, 0, len(p.sources))
+	for name := range p.sources {
+		names = append(names, name)
+	}
+	srcs := make([]StatusSource, len(names))
+	recent := make([][]xordb.Event, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		srcs[i] = p.sources[name]
+		recent[i] = p.recent[name].latest()
+	}
+	p.mu.Unlock()
+
+	data := struct {
+		Refresh int
+		Now     time.Time
+		Caches  []statusCache
+	}{Refresh: statusRefresh, Now: time.Now()}
+	for i, name := range names {
+		s := srcs[i].Stats()
+		data.Caches = append(data.Caches, statusCache{
+			Name:    name,
+			Stats:   s,
+			Buckets: similarityBars(s),
+			TopKeys: srcs[i].TopKeys(statusTopKeys),
+			Recent:  recent[i],
+		})
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	statusTemplate.Execute(w, data)
+}
+
+// similarityBars returns the non-empty tail of the hit similarity
+// histogram, from its first non-empty bucket, scaled to the largest.
+func similarityBars(s xordb.Stats) []statusBucket {
+	first, most := -1, uint64(0)
+	for i, n := range s.HitSimilarity {
+		if n > 0 && first < 0 {
+			first = i
+		}
+		most = max(most, n)
+	}
+	if first < 0 {
+		return nil
+	}
+	out := make([]statusBucket, 0, len(s.HitSimilarity)-first)
+	for i := first; i < len(s.HitSimilarity); i++ {
+		n := s.HitSimilarity[i]
+		out = append(out, statusBucket{Upper: SimilarityBuckets[i], Count: n, Width: int(n * statusBarWidth / most)})
+	}
+	return out
+}
+
+var statusTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"pct": func(f float64) float64 { return 100 * f },
+	"ago": func(now, t time.Time) string { return now.Sub(t).Round(time.Second).String() },
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<title>xordb status</title>
+<style>
+body{font:14px system-ui,sans-serif;margin:2em;color:#222}
+h2{border-bottom:1px solid #ccc;padding-bottom:.2em}
+.tiles{display:flex;flex-wrap:wrap;gap:1em}
+.tile{background:#f4f4f4;padding:.6em 1em;min-width:7em}
+.tile b{display:block;font-size:1.5em}
+table{border-collapse:collapse;margin:.5em 0}
+td,th{padding:.15em .6em;text-align:left;vertical-align:top}
+td.n{text-align:right;font-variant-numeric:tabular-nums}
+.bar{background:#4a7;height:.9em}
+code{word-break:break-all}
+</style></head><body>
+<h1>xordb status</h1>
+<p>{{.Now.Format "2006-01-02 15:04:05 MST"}}, reloading every {{.Refresh}}s</p>
+{{range .Caches}}{{$now := $.Now}}
+<h2>{{.Name}}</h2>
+<div class="tiles">
+<div class="tile"><b>{{.Stats.Entries}}</b>entries</div>
+<div class="tile"><b>{{printf "%.1f%%" (pct .Stats.HitRate)}}</b>hit rate</div>
+<div class="tile"><b>{{.Stats.Hits}}</b>hits</div>
+<div class="tile"><b>{{.Stats.Misses}}</b>misses</div>
+<div class="tile"><b>{{.Stats.Sets}}</b>sets</div>
+<div class="tile"><b>{{.Stats.Evictions}}</b>evictions</div>
+<div class="tile"><b>{{.Stats.Expired}}</b>expired</div>
+<div class="tile"><b>{{printf "%.3f" .Stats.AvgSimOnHit}}</b>mean hit similarity</div>
+</div>
+<h3>Similarity of hits</h3>
+{{if .Buckets}}<table>{{range .Buckets}}
+<tr><td class="n">&lt; {{printf "%.2f" .Upper}}</td><td class="n">{{.Count}}</td><td><div class="bar" style="width:{{.Width}}px"></div></td></tr>{{end}}
+</table>{{else}}<p>No hits yet.</p>{{end}}
+<h3>Top keys</h3>
+{{if .TopKeys}}<table><tr><th>hits</th><th>key</th></tr>{{range .TopKeys}}
+<tr><td class="n">{{.Hits}}</td><td><code>{{.Key}}</code></td></tr>{{end}}
+</table>{{else}}<p>No entry has answered a lookup yet.</p>{{end}}
+<h3>Recent evictions</h3>
+{{if .Recent}}<table><tr><th>ago</th><th></th><th>key</th></tr>{{range .Recent}}
+<tr><td class="n">{{ago $now .Time}}</td><td>{{.Kind}}</td><td><code>{{.Key}}</code></td></tr>{{end}}
+</table>{{else}}<p>None recorded.</p>{{end}}
+<details><summary>All stats</summary><pre>{{.Stats}}</pre></details>
+{{else}}<p>No caches registered.</p>{{end}}
+</body></html>
+`))
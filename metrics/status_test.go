@@ -0,0 +1,55 @@
+package metrics_test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/metrics"
+)
+
+func TestStatusPage(t *testing.T) {
+	page := metrics.NewStatusPage()
+	db := xordb.New(xordb.WithCapacity(25), xordb.WithEventHook(page.Events("faq")))
+	page.Register("faq", db)
+	db.Set("<script>alert(1)</script>", "x")
+	for i := 0; i < 50; i++ {
+		db.Set(fmt.Sprintf("filler entry number %d", i), i)
+		db.Get("<script>alert(1)</script>") // kept in the cache
+	}
+
+	rec := httptest.NewRecorder()
+	page.ServeHTTP(rec, httptest.NewRequest("GET", "/status", nil))
+	body := rec.Body.String()
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("Content-Type %q", ct)
+	}
+	for _, want := range []string{
+		"<h2>faq</h2>",
+		"<td class=\"n\">50</td><td><code>&lt;script&gt;alert(1)&lt;/script&gt;</code>", // top key, escaped
+		"<td class=\"n\">&lt; 1.00</td><td class=\"n\">50</td>",                         // similarity bucket
+		"<td>evict</td><td><code>filler entry number 24</code>",                         // the latest eviction
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+	if strings.Contains(body, "<script>") {
+		t.Error("keys must be escaped")
+	}
+	if n := strings.Count(body, "<td>evict</td>"); n != 20 {
+		t.Errorf("%d evictions listed, want the last 20", n)
+	}
+	if strings.Contains(body, "filler entry number 4<") {
+		t.Error("evictions past the last 20 must be dropped")
+	}
+
+	page.Unregister("faq")
+	rec = httptest.NewRecorder()
+	page.ServeHTTP(rec, httptest.NewRequest("GET", "/status", nil))
+	if !strings.Contains(rec.Body.String(), "No caches registered.") {
+		t.Error("an unregistered cache must disappear")
+	}
+}
@@ -0,0 +1,81 @@
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// memcachedRelative is the largest exptime memcached reads as seconds from
+// now; larger values are Unix times.
+const memcachedRelative = 30 * 24 * 60 * 60
+
+// ImportMemcached loads a memcached dump into db: the text-protocol storage
+// commands memcached-tool's dump writes, one per entry,
+//
+//	add <key> <flags> <exptime> <bytes>\r\n<data>\r\n
+//
+// ("set" works too). Flags are ignored. An exptime of 0 means no expiry,
+// up to 30 days is relative to now, and anything larger a Unix time, as in
+// memcached itself.
+func ImportMemcached(ctx context.Context, db *xordb.DB, r io.Reader, opts ...Option) (Progress, error) {
+	l := newLoader(db, newConfig(opts))
+	err := readMemcached(ctx, bufio.NewReaderSize(r, 64<<10), l)
+	p := l.finish()
+	if err != nil {
+		return p, fmt.Errorf("migrate: memcached: %w", err)
+	}
+	return p, nil
+}
+
+func readMemcached(ctx context.Context, r *bufio.Reader, l *loader) error {
+	for line := 1; ; line++ {
+		head, err := r.ReadBytes('\n')
+		if err == io.EOF && len(bytes.TrimSpace(head)) == 0 {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		head = bytes.TrimRight(head, "\r\n")
+		if len(head) == 0 {
+			continue
+		}
+		f := bytes.Fields(head)
+		if len(f) != 5 || (string(f[0]) != "add" && string(f[0]) != "set") {
+			return fmt.Errorf("line %d: want \"add <key> <flags> <exptime> <bytes>\", got %q", line, head)
+		}
+		exptime, err1 := strconv.ParseInt(string(f[3]), 10, 64)
+		n, err2 := strconv.Atoi(string(f[4]))
+		if err1 != nil || err2 != nil || n < 0 || n > maxString {
+			return fmt.Errorf("line %d: bad exptime or length in %q", line, head)
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("line %d: value of %q: %w", line, f[1], err)
+		}
+		if !bytes.HasSuffix(data, []byte("\r\n")) {
+			return fmt.Errorf("line %d: value of %q is not %d bytes", line, f[1], n)
+		}
+		line++
+
+		var expires time.Time
+		switch {
+		case exptime < 0:
+			expires = time.Unix(1, 0) // already expired, as in memcached
+		case exptime > memcachedRelative:
+			expires = time.Unix(exptime, 0)
+		case exptime > 0:
+			expires = time.Now().Add(time.Duration(exptime) * time.Second)
+		}
+		if err := l.add(ctx, record{key: string(f[1]), raw: data[:n], expires: expires}); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package migrate_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/migrate"
+)
+
+func TestImportMemcached(t *testing.T) {
+	future := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	dump := "add what_is_the_capital_of_india 0 0 5\r\nDelhi\r\n" +
+		"set who_wrote_war_and_peace 0 600 7\r\nTolstoy\r\n" +
+		"add what_is_the_capital_of_france 0 " + future + " 5\r\nParis\r\n" +
+		"add stale 0 1000000000 1\r\nx\r\n" +
+		"add json 3 0 8\r\n{\"n\": 1}\r\n"
+	db := xordb.New()
+	p, err := migrate.ImportMemcached(context.Background(), db, strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != (migrate.Progress{Read: 5, Loaded: 4, Expired: 1}) {
+		t.Errorf("progress %+v", p)
+	}
+	if v, ok, _ := db.Get("what_is_the_capital_of_india"); !ok || v != "Delhi" {
+		t.Errorf("india = %v, %v", v, ok)
+	}
+	if r := db.Lookup("who_wrote_war_and_peace"); r.ExpiresIn <= 0 || r.ExpiresIn > 10*time.Minute {
+		t.Errorf("relative exptime: %+v", r)
+	}
+	if r := db.Lookup("what_is_the_capital_of_france"); r.ExpiresIn <= 50*time.Minute || r.ExpiresIn > time.Hour {
+		t.Errorf("absolute exptime: %+v", r)
+	}
+	if v, _, _ := db.Get("json"); v.(map[string]any)["n"] != 1.0 {
+		t.Errorf("json = %v", v)
+	}
+}
+
+func TestImportMemcached_Errors(t *testing.T) {
+	for _, dump := range []string{
+		"get k\r\n",
+		"add k 0 0 x\r\nv\r\n",
+		"add k 0 0 5\r\nab\r\n",
+	} {
+		if _, err := migrate.ImportMemcached(context.Background(), xordb.New(), strings.NewReader(dump)); err == nil {
+			t.Errorf("%q: no error", dump)
+		}
+	}
+}
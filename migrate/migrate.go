@@ -0,0 +1,196 @@
+// Package migrate bulk-loads the contents of exact-match caches into an
+// xordb DB: Redis RDB snapshots (ImportRDB) and memcached dumps
+// (ImportMemcached), for moving a cache's existing entries over instead of
+// starting cold.
+//
+//	f, _ := os.Open("dump.rdb")
+//	p, err := migrate.ImportRDB(ctx, db, f,
+//		migrate.WithWorkers(8),
+//		migrate.WithProgress(10000, func(p migrate.Progress) { log.Print(p) }))
+//
+// Keys are encoded by several workers at once, since encoding dominates the
+// cost of a bulk load, so entries land in no particular LRU order. Entries
+// keep their remaining lifetime; those already expired are skipped, and
+// entries without one get the DB's default TTL. Values are decoded as JSON
+// if they are valid JSON and kept as strings otherwise (WithValueDecoder
+// overrides this).
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// Progress counts the records of a dump as they are processed.
+type Progress struct {
+	Read        int // records read from the dump
+	Loaded      int // stored in the DB
+	Expired     int // skipped: already past their expiry
+	Unsupported int // skipped: not a string (Redis lists, sets, hashes, ...)
+	Failed      int // skipped: the value decoder returned an error
+}
+
+func (p Progress) String() string {
+	return fmt.Sprintf("read %d, loaded %d, expired %d, unsupported %d, failed %d",
+		p.Read, p.Loaded, p.Expired, p.Unsupported, p.Failed)
+}
+
+type config struct {
+	workers  int
+	every    int
+	progress func(Progress)
+	decode   func(key string, raw []byte) (any, error)
+	redisDB  int
+}
+
+type Option func(*config)
+
+// WithWorkers sets how many keys are encoded at once (default GOMAXPROCS).
+func WithWorkers(n int) Option {
+	if n <= 0 {
+		panic("migrate: workers must be positive")
+	}
+	return func(c *config) { c.workers = n }
+}
+
+// WithProgress calls fn after every n records read and once at the end.
+// fn runs on the reading goroutine, so a slow fn slows the import.
+func WithProgress(n int, fn func(Progress)) Option {
+	if n <= 0 {
+		panic("migrate: progress interval must be positive")
+	}
+	return func(c *config) { c.every, c.progress = n, fn }
+}
+
+// WithValueDecoder replaces the default value decoding (JSON if valid, else
+// the string). A record whose decoder returns an error is skipped and
+// counted in Progress.Failed.
+func WithValueDecoder(fn func(key string, raw []byte) (any, error)) Option {
+	return func(c *config) { c.decode = fn }
+}
+
+// WithRedisDB imports only Redis database n (SELECT n); by default keys
+// from every database are imported together.
+func WithRedisDB(n int) Option {
+	if n < 0 {
+		panic("migrate: Redis database must not be negative")
+	}
+	return func(c *config) { c.redisDB = n }
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{workers: runtime.GOMAXPROCS(0), redisDB: -1, decode: decodeValue}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func decodeValue(_ string, raw []byte) (any, error) {
+	var v any
+	if json.Valid(raw) && json.Unmarshal(raw, &v) == nil {
+		return v, nil
+	}
+	return string(raw), nil
+}
+
+// record is one key of a dump. A zero expiry means none.
+type record struct {
+	key     string
+	raw     []byte
+	expires time.Time
+}
+
+// loader stores records through a pool of workers and keeps the counts.
+type loader struct {
+	db  *xordb.DB
+	cfg *config
+	in  chan record
+	wg  sync.WaitGroup
+
+	mu sync.Mutex
+	p  Progress
+}
+
+func newLoader(db *xordb.DB, cfg *config) *loader {
+	l := &loader{db: db, cfg: cfg, in: make(chan record, 4*cfg.workers)}
+	for i := 0; i < cfg.workers; i++ {
+		l.wg.Add(1)
+		go l.work()
+	}
+	return l
+}
+
+func (l *loader) work() {
+	defer l.wg.Done()
+	for rec := range l.in {
+		v, err := l.cfg.decode(rec.key, rec.raw)
+		if err != nil {
+			l.count(func(p *Progress) { p.Failed++ })
+			continue
+		}
+		if rec.expires.IsZero() {
+			l.db.Set(rec.key, v)
+		} else if ttl := time.Until(rec.expires); ttl > 0 {
+			l.db.SetWithTTL(rec.key, v, ttl)
+		} else {
+			l.count(func(p *Progress) { p.Expired++ })
+			continue
+		}
+		l.count(func(p *Progress) { p.Loaded++ })
+	}
+}
+
+func (l *loader) count(f func(*Progress)) {
+	l.mu.Lock()
+	f(&l.p)
+	l.mu.Unlock()
+}
+
+// add queues rec, or counts it if it is skipped unread.
+func (l *loader) add(ctx context.Context, rec record) error {
+	l.read()
+	if !rec.expires.IsZero() && !rec.expires.After(time.Now()) {
+		l.count(func(p *Progress) { p.Expired++ })
+		return nil
+	}
+	select {
+	case l.in <- rec:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// skip counts a record of a type that cannot be imported.
+func (l *loader) skip() {
+	l.read()
+	l.count(func(p *Progress) { p.Unsupported++ })
+}
+
+func (l *loader) read() {
+	l.mu.Lock()
+	l.p.Read++
+	report := l.cfg.progress != nil && l.p.Read%l.cfg.every == 0
+	p := l.p
+	l.mu.Unlock()
+	if report {
+		l.cfg.progress(p)
+	}
+}
+
+// finish waits for the workers and reports the final counts.
+func (l *loader) finish() Progress {
+	close(l.in)
+	l.wg.Wait()
+	if l.cfg.progress != nil {
+		l.cfg.progress(l.p)
+	}
+	return l.p
+}
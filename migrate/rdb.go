@@ -0,0 +1,414 @@
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// RDB opcodes and value types, from Redis's rdb.h.
+const (
+	rdbSlotInfo     = 0xF4
+	rdbFunction2    = 0xF5
+	rdbFunctionPre  = 0xF6
+	rdbModuleAux    = 0xF7
+	rdbIdle         = 0xF8
+	rdbFreq         = 0xF9
+	rdbAux          = 0xFA
+	rdbResizeDB     = 0xFB
+	rdbExpireTimeMS = 0xFC
+	rdbExpireTime   = 0xFD
+	rdbSelectDB     = 0xFE
+	rdbEOF          = 0xFF
+
+	rdbString        = 0
+	rdbList          = 1
+	rdbSet           = 2
+	rdbZSet          = 3
+	rdbHash          = 4
+	rdbZSet2         = 5
+	rdbHashZipmap    = 9
+	rdbListZiplist   = 10
+	rdbSetIntset     = 11
+	rdbZSetZiplist   = 12
+	rdbHashZiplist   = 13
+	rdbListQuicklist = 14
+	rdbHashListpack  = 16
+	rdbZSetListpack  = 17
+	rdbListQuicklst2 = 18
+	rdbSetListpack   = 20
+
+	rdbMaxVersion = 12
+)
+
+// ImportRDB loads the string keys of a Redis RDB file (as written by SAVE,
+// BGSAVE or redis-cli --rdb) into db. Lists, sets, sorted sets and hashes
+// are skipped and counted as Unsupported; a stream or module type ends the
+// import with an error, since its encoding cannot be skipped safely. The
+// trailing checksum is not verified.
+func ImportRDB(ctx context.Context, db *xordb.DB, r io.Reader, opts ...Option) (Progress, error) {
+	cfg := newConfig(opts)
+	l := newLoader(db, cfg)
+	err := readRDB(ctx, &rdbReader{r: bufio.NewReaderSize(r, 64<<10)}, l, cfg.redisDB)
+	p := l.finish()
+	if err != nil {
+		return p, fmt.Errorf("migrate: rdb: %w", err)
+	}
+	return p, nil
+}
+
+func readRDB(ctx context.Context, r *rdbReader, l *loader, only int) error {
+	var magic [9]byte
+	if err := r.full(magic[:]); err != nil {
+		return err
+	}
+	if string(magic[:5]) != "REDIS" {
+		return errors.New("not an RDB file")
+	}
+	version, err := strconv.Atoi(string(magic[5:]))
+	if err != nil || version < 1 || version > rdbMaxVersion {
+		return fmt.Errorf("unsupported RDB version %q", magic[5:])
+	}
+
+	db := 0
+	var expires time.Time
+	for {
+		op, err := r.byte()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case rdbEOF:
+			return nil
+		case rdbSelectDB:
+			n, err := r.length()
+			if err != nil {
+				return err
+			}
+			db = int(n)
+			continue
+		case rdbResizeDB:
+			if _, err := r.length(); err != nil {
+				return err
+			}
+			if _, err := r.length(); err != nil {
+				return err
+			}
+			continue
+		case rdbSlotInfo:
+			for i := 0; i < 3; i++ {
+				if _, err := r.length(); err != nil {
+					return err
+				}
+			}
+			continue
+		case rdbAux:
+			if _, err := r.string(); err != nil {
+				return err
+			}
+			if _, err := r.string(); err != nil {
+				return err
+			}
+			continue
+		case rdbFunction2:
+			if _, err := r.string(); err != nil {
+				return err
+			}
+			continue
+		case rdbModuleAux, rdbFunctionPre:
+			return fmt.Errorf("opcode %#x (module data or pre-7.0 functions) is not supported", op)
+		case rdbExpireTime:
+			var b [4]byte
+			if err := r.full(b[:]); err != nil {
+				return err
+			}
+			expires = time.Unix(int64(binary.LittleEndian.Uint32(b[:])), 0)
+			continue
+		case rdbExpireTimeMS:
+			var b [8]byte
+			if err := r.full(b[:]); err != nil {
+				return err
+			}
+			expires = time.UnixMilli(int64(binary.LittleEndian.Uint64(b[:])))
+			continue
+		case rdbIdle:
+			if _, err := r.length(); err != nil {
+				return err
+			}
+			continue
+		case rdbFreq:
+			if _, err := r.byte(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// a key of type op, with the expiry read before it if any
+		key, err := r.string()
+		if err != nil {
+			return err
+		}
+		exp := expires
+		expires = time.Time{}
+		if op != rdbString {
+			if err := r.skipValue(op); err != nil {
+				return fmt.Errorf("key %q: %w", key, err)
+			}
+			if only < 0 || db == only {
+				l.skip()
+			}
+			continue
+		}
+		val, err := r.string()
+		if err != nil {
+			return fmt.Errorf("key %q: %w", key, err)
+		}
+		if only >= 0 && db != only {
+			continue
+		}
+		if err := l.add(ctx, record{key: string(key), raw: val, expires: exp}); err != nil {
+			return err
+		}
+	}
+}
+
+type rdbReader struct {
+	r *bufio.Reader
+}
+
+func (r *rdbReader) byte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return b, err
+}
+
+func (r *rdbReader) full(b []byte) error {
+	_, err := io.ReadFull(r.r, b)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// lengthOrEncoding reads a length, or the format of a specially encoded
+// string (enc true).
+func (r *rdbReader) lengthOrEncoding() (n uint64, enc bool, err error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, false, err
+	}
+	switch b >> 6 {
+	case 0:
+		return uint64(b & 0x3f), false, nil
+	case 1:
+		next, err := r.byte()
+		return uint64(b&0x3f)<<8 | uint64(next), false, err
+	case 3:
+		return uint64(b & 0x3f), true, nil
+	}
+	switch b {
+	case 0x80:
+		var buf [4]byte
+		err := r.full(buf[:])
+		return uint64(binary.BigEndian.Uint32(buf[:])), false, err
+	case 0x81:
+		var buf [8]byte
+		err := r.full(buf[:])
+		return binary.BigEndian.Uint64(buf[:]), false, err
+	}
+	return 0, false, fmt.Errorf("bad length byte %#x", b)
+}
+
+func (r *rdbReader) length() (uint64, error) {
+	n, enc, err := r.lengthOrEncoding()
+	if err == nil && enc {
+		err = errors.New("encoded string where a length was expected")
+	}
+	return n, err
+}
+
+// maxString bounds the strings read, so a corrupt length fails instead of
+// allocating without limit.
+const maxString = 512 << 20 // Redis's proto-max-bulk-len default
+
+func (r *rdbReader) string() ([]byte, error) {
+	n, enc, err := r.lengthOrEncoding()
+	if err != nil {
+		return nil, err
+	}
+	if !enc {
+		if n > maxString {
+			return nil, fmt.Errorf("string of %d bytes", n)
+		}
+		b := make([]byte, n)
+		return b, r.full(b)
+	}
+	switch n {
+	case 0, 1, 2: // int8, int16, int32
+		buf := make([]byte, 1<<n)
+		if err := r.full(buf); err != nil {
+			return nil, err
+		}
+		var v int64
+		switch n {
+		case 0:
+			v = int64(int8(buf[0]))
+		case 1:
+			v = int64(int16(binary.LittleEndian.Uint16(buf)))
+		case 2:
+			v = int64(int32(binary.LittleEndian.Uint32(buf)))
+		}
+		return strconv.AppendInt(nil, v, 10), nil
+	case 3: // LZF
+		clen, err := r.length()
+		if err != nil {
+			return nil, err
+		}
+		ulen, err := r.length()
+		if err != nil {
+			return nil, err
+		}
+		if clen > maxString || ulen > maxString {
+			return nil, fmt.Errorf("compressed string of %d bytes", ulen)
+		}
+		in := make([]byte, clen)
+		if err := r.full(in); err != nil {
+			return nil, err
+		}
+		return lzfDecompress(in, int(ulen))
+	}
+	return nil, fmt.Errorf("unknown string encoding %d", n)
+}
+
+func (r *rdbReader) skipStrings(n uint64) error {
+	for i := uint64(0); i < n; i++ {
+		if _, err := r.string(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipValue reads past a value of type typ.
+func (r *rdbReader) skipValue(typ byte) error {
+	switch typ {
+	case rdbList, rdbSet, rdbListQuicklist:
+		n, err := r.length()
+		if err != nil {
+			return err
+		}
+		return r.skipStrings(n)
+	case rdbHash:
+		n, err := r.length()
+		if err != nil {
+			return err
+		}
+		return r.skipStrings(2 * n)
+	case rdbZSet:
+		n, err := r.length()
+		if err != nil {
+			return err
+		}
+		for i := uint64(0); i < n; i++ {
+			if _, err := r.string(); err != nil {
+				return err
+			}
+			l, err := r.byte() // score as text; 253-255 are NaN and ±Inf
+			if err != nil {
+				return err
+			}
+			if l < 253 {
+				if err := r.full(make([]byte, l)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case rdbZSet2:
+		n, err := r.length()
+		if err != nil {
+			return err
+		}
+		for i := uint64(0); i < n; i++ {
+			if _, err := r.string(); err != nil {
+				return err
+			}
+			var score [8]byte
+			if err := r.full(score[:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case rdbHashZipmap, rdbListZiplist, rdbSetIntset, rdbZSetZiplist,
+		rdbHashZiplist, rdbHashListpack, rdbZSetListpack, rdbSetListpack:
+		_, err := r.string() // one serialized blob
+		return err
+	case rdbListQuicklst2:
+		n, err := r.length()
+		if err != nil {
+			return err
+		}
+		for i := uint64(0); i < n; i++ {
+			if _, err := r.length(); err != nil { // container format
+				return err
+			}
+			if _, err := r.string(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("value type %d (stream, module or newer) is not supported", typ)
+}
+
+// lzfDecompress expands LZF data, as Redis compresses long strings, to
+// exactly n bytes.
+func lzfDecompress(in []byte, n int) ([]byte, error) {
+	out := make([]byte, 0, n)
+	for i := 0; i < len(in); {
+		ctrl := int(in[i])
+		i++
+		if ctrl < 32 { // literal run of ctrl+1 bytes
+			end := i + ctrl + 1
+			if end > len(in) || len(out)+ctrl+1 > n {
+				return nil, errors.New("corrupt LZF data")
+			}
+			out = append(out, in[i:end]...)
+			i = end
+			continue
+		}
+		length := ctrl >> 5 // back reference
+		if length == 7 {
+			if i >= len(in) {
+				return nil, errors.New("corrupt LZF data")
+			}
+			length += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, errors.New("corrupt LZF data")
+		}
+		ref := len(out) - (ctrl&0x1f)<<8 - int(in[i]) - 1
+		i++
+		length += 2
+		if ref < 0 || len(out)+length > n {
+			return nil, errors.New("corrupt LZF data")
+		}
+		for j := 0; j < length; j++ { // may overlap its own output
+			out = append(out, out[ref+j])
+		}
+	}
+	if len(out) != n {
+		return nil, fmt.Errorf("LZF data expands to %d bytes, want %d", len(out), n)
+	}
+	return out, nil
+}
@@ -0,0 +1,170 @@
+package migrate_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/migrate"
+)
+
+// rdb builds an RDB file.
+type rdb struct{ bytes.Buffer }
+
+func (b *rdb) length(n int) {
+	switch {
+	case n < 64:
+		b.WriteByte(byte(n))
+	case n < 1<<14:
+		b.WriteByte(0x40 | byte(n>>8))
+		b.WriteByte(byte(n))
+	default:
+		b.WriteByte(0x80)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+func (b *rdb) str(s string) {
+	b.length(len(s))
+	b.WriteString(s)
+}
+
+func (b *rdb) set(key, value string) {
+	b.WriteByte(0) // string
+	b.str(key)
+	b.str(value)
+}
+
+func (b *rdb) expireMS(t time.Time) {
+	b.WriteByte(0xFC)
+	b.Write(binary.LittleEndian.AppendUint64(nil, uint64(t.UnixMilli())))
+}
+
+func testRDB() []byte {
+	var b rdb
+	b.WriteString("REDIS0011")
+	b.WriteByte(0xFA)
+	b.str("redis-ver")
+	b.str("7.2.4")
+	b.WriteByte(0xFE)
+	b.length(0)
+	b.WriteByte(0xFB)
+	b.length(8)
+	b.length(2)
+
+	b.set("what is the capital of india", `{"answer":"Delhi"}`)
+	b.set("who wrote war and peace", "Tolstoy")
+
+	b.WriteByte(0) // int-encoded value
+	b.str("how many legs does a spider have")
+	b.WriteByte(0xC0)
+	b.WriteByte(8)
+
+	b.WriteByte(0) // LZF-compressed value: "abc" then a 6-byte back reference
+	b.str("lzf")
+	b.WriteByte(0xC3)
+	b.length(6)
+	b.length(9)
+	b.Write([]byte{2, 'a', 'b', 'c', 4 << 5, 2})
+
+	b.expireMS(time.Now().Add(-time.Hour))
+	b.set("expired question", "gone")
+	b.expireMS(time.Now().Add(time.Hour))
+	b.WriteByte(0xF9) // LFU frequency
+	b.WriteByte(3)
+	b.set("what is the capital of france", "Paris")
+
+	b.WriteByte(1) // list
+	b.str("queue")
+	b.length(2)
+	b.str("a")
+	b.str("b")
+	b.WriteByte(5) // sorted set, binary scores
+	b.str("board")
+	b.length(1)
+	b.str("alice")
+	b.Write(make([]byte, 8))
+	b.WriteByte(16) // hash as one listpack blob
+	b.str("profile")
+	b.str("\x00\x01opaque")
+
+	b.WriteByte(0xFE)
+	b.length(1)
+	b.set("db one question", "elsewhere")
+
+	b.WriteByte(0xFF)
+	b.Write(make([]byte, 8)) // checksum, not verified
+	return b.Bytes()
+}
+
+func TestImportRDB(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.8))
+	var reports []migrate.Progress
+	p, err := migrate.ImportRDB(context.Background(), db, bytes.NewReader(testRDB()),
+		migrate.WithWorkers(3),
+		migrate.WithProgress(4, func(p migrate.Progress) { reports = append(reports, p) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := migrate.Progress{Read: 10, Loaded: 6, Expired: 1, Unsupported: 3}
+	if p != want {
+		t.Errorf("progress %+v, want %+v", p, want)
+	}
+	if len(reports) != 3 || reports[2] != p || reports[0].Read != 4 {
+		t.Errorf("reports %+v", reports)
+	}
+
+	for key, want := range map[string]any{
+		"what is the capital of india":     map[string]any{"answer": "Delhi"},
+		"who wrote war and peace":          "Tolstoy",
+		"how many legs does a spider have": 8.0,
+		"lzf":                              "abcabcabc",
+		"db one question":                  "elsewhere",
+	} {
+		v, ok, _ := db.Get(key)
+		if !ok || !equalJSON(v, want) {
+			t.Errorf("%q = %v, %v; want %v", key, v, ok, want)
+		}
+	}
+	if r := db.Lookup("what is the capital of france"); !r.Hit || r.ExpiresIn <= 0 || r.ExpiresIn > time.Hour {
+		t.Errorf("france: %+v", r)
+	}
+	if _, ok, _ := db.Get("expired question"); ok {
+		t.Error("expired key imported")
+	}
+}
+
+func equalJSON(a, b any) bool {
+	if m, ok := b.(map[string]any); ok {
+		am, ok := a.(map[string]any)
+		return ok && len(am) == len(m) && am["answer"] == m["answer"]
+	}
+	return a == b
+}
+
+func TestImportRDB_OneDB(t *testing.T) {
+	db := xordb.New()
+	p, err := migrate.ImportRDB(context.Background(), db, bytes.NewReader(testRDB()), migrate.WithRedisDB(1))
+	if err != nil || p.Loaded != 1 || p.Unsupported != 0 || db.Len() != 1 {
+		t.Errorf("progress %+v, %d entries, %v", p, db.Len(), err)
+	}
+}
+
+func TestImportRDB_Errors(t *testing.T) {
+	stream := append([]byte("REDIS0011"), 15, 1, 's')
+	for name, data := range map[string][]byte{
+		"not rdb":   []byte("hello world"),
+		"version":   []byte("REDIS0099"),
+		"truncated": testRDB()[:40],
+		"stream":    stream,
+	} {
+		_, err := migrate.ImportRDB(context.Background(), xordb.New(), bytes.NewReader(data))
+		if err == nil || !strings.HasPrefix(err.Error(), "migrate: rdb: ") {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
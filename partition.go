@@ -0,0 +1,184 @@
+package xordb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+type partitionKey struct{}
+
+// ContextWithPartition returns ctx carrying partition, the convention the
+// *Context methods read unless WithPartitionBy says otherwise.
+func ContextWithPartition(ctx context.Context, partition string) context.Context {
+	return context.WithValue(ctx, partitionKey{}, partition)
+}
+
+// PartitionFromContext returns the partition set by ContextWithPartition,
+// or "".
+func PartitionFromContext(ctx context.Context) string {
+	p, _ := ctx.Value(partitionKey{}).(string)
+	return p
+}
+
+// WithPartitionBy sets how SetContext, GetContext and LookupContext pick
+// a request's partition, e.g. from the model name a middleware stored in
+// ctx. The default is PartitionFromContext. fn must be safe for concurrent
+// use.
+//
+// Partitions keep answers apart that must never be served for each other
+// even when their keys match, e.g. completions from different models: a
+// gpt-4o answer for a llama-3 request is a wrong answer, not a near miss.
+// All partitions share the DB's encoder, capacity and snapshots; a
+// partitioned entry's vector is bound to a key derived from the partition
+// name, so it only matches lookups in the same partition, and its exact
+// key is the quoted partition name, a space and the key, e.g.
+// `"gpt-4o" what is the capital of india`. The empty partition is the
+// plain Set and Get namespace. Snapshots keep the bound vectors, but
+// WarmFromJSONL re-encodes exact keys as plain text, outside any partition.
+func WithPartitionBy(fn func(ctx context.Context) string) Option {
+	return func(o *dbOptions) { o.partitionBy = fn }
+}
+
+// partitionSeed derives the partition binding keys.
+const partitionSeed = 0x70617274 // "part"
+
+// partitions caches the binding key of each partition seen.
+type partitions struct {
+	by     func(context.Context) string
+	quotas map[string]PartitionQuota
+	keys   sync.Map // name → hdc.Vector
+}
+
+func (p *partitions) key(name string, dims int) hdc.Vector {
+	if v, ok := p.keys.Load(name); ok {
+		return v.(hdc.Vector)
+	}
+	v, _ := p.keys.LoadOrStore(name, hdcx.HashBytes([]byte(name), dims, partitionSeed))
+	return v.(hdc.Vector)
+}
+
+// partitioned returns the partition of ctx and the exact key and vector of
+// key in it; ok is false if the encode budget ran out.
+func (db *DB) partitioned(ctx context.Context, key string) (name, exact string, vec hdc.Vector, ok bool) {
+	key = db.key(key)
+	name = db.parts.by(ctx)
+	vec, ok = db.c.Encode(key)
+	if !ok || name == "" {
+		return name, key, vec, ok
+	}
+	return name, partitionPrefix(name) + key, hdc.Bind(db.parts.key(name, db.c.Dims()), vec), true
+}
+
+func partitionPrefix(name string) string { return strconv.Quote(name) + " " }
+
+// SetContext is Set in ctx's partition.
+func (db *DB) SetContext(ctx context.Context, key string, value any) {
+	name, exact, vec, ok := db.partitioned(ctx, key)
+	if !ok || db.closed.Load() {
+		return
+	}
+	ttl := db.c.TTL()
+	if q := db.parts.quotas[name]; q.TTL > 0 {
+		ttl = q.TTL
+	}
+	db.c.SetVec(exact, vec, value, ttl)
+}
+
+// GetContext is Get in ctx's partition.
+func (db *DB) GetContext(ctx context.Context, key string) (any, bool, float64) {
+	r := db.LookupContext(ctx, key)
+	return r.Value, r.Hit, r.Similarity
+}
+
+// LookupContext is Lookup in ctx's partition. MatchedKey carries the
+// partition prefix.
+func (db *DB) LookupContext(ctx context.Context, key string) Result {
+	_, exact, vec, ok := db.partitioned(ctx, key)
+	if !ok {
+		return Result{}
+	}
+	return result(db.c.LookupVec(exact, vec))
+}
+
+// DeletePartition removes every entry of partition, e.g. the answers of a
+// retired model, and returns the count. The empty partition cannot be
+// deleted this way; use DeletePrefix.
+func (db *DB) DeletePartition(partition string) int {
+	if partition == "" {
+		return 0
+	}
+	prefix := partitionPrefix(partition)
+	return db.c.DeleteFunc(func(k string) bool { return strings.HasPrefix(k, prefix) })
+}
+
+// PartitionQuota overrides the DB's settings for one partition, so tenants
+// sharing a DB do not share its limits. Zero fields keep the DB's.
+type PartitionQuota struct {
+	// Capacity reserves that many entries for the partition, with an LRU
+	// list of its own: its entries are evicted only to make room for its
+	// own, and its traffic evicts only its own. Partitions without a
+	// Capacity share the rest of WithCapacity, evicting among themselves.
+	Capacity int
+
+	TTL       time.Duration // default TTL of SetContext in the partition
+	Threshold float64       // for lookups in the partition
+}
+
+// WithPartitionQuota sets the quota of partition; call it once per
+// partition. The empty partition, plain Set and Get, may have one too. The
+// reserved capacities must leave part of WithCapacity to the partitions
+// without one, and SetCapacity resizes only that shared part.
+//
+// Quotas need exact LRU, so they cannot be combined with
+// WithSampledEviction or WithEvictionWatermark. A partition's threshold is
+// not moved by SetThreshold or WithAdaptiveThreshold, and with LSH the
+// index is tuned for WithThreshold, so a much lower partition threshold
+// finds fewer candidates than a scan would.
+func WithPartitionQuota(partition string, q PartitionQuota) Option {
+	return func(o *dbOptions) {
+		if o.quotas == nil {
+			o.quotas = make(map[string]PartitionQuota)
+		}
+		o.quotas[partition] = q
+	}
+}
+
+// groupOf returns the cache's GroupOf for WithPartitionQuota: the partition
+// of an exact key, read back from its quoted prefix.
+func (o *dbOptions) groupOf() func(string) string {
+	if len(o.quotas) == 0 {
+		return nil
+	}
+	return partitionOf
+}
+
+func (o *dbOptions) groups() map[string]cache.Group {
+	if len(o.quotas) == 0 {
+		return nil
+	}
+	gs := make(map[string]cache.Group, len(o.quotas))
+	for name, q := range o.quotas {
+		gs[name] = cache.Group{Capacity: q.Capacity, Threshold: q.Threshold}
+	}
+	return gs
+}
+
+// partitionOf returns the partition of an exact key, "" for a plain key.
+func partitionOf(key string) string {
+	if !strings.HasPrefix(key, `"`) {
+		return ""
+	}
+	quoted, err := strconv.QuotedPrefix(key)
+	if err != nil || !strings.HasPrefix(key[len(quoted):], " ") {
+		return ""
+	}
+	name, _ := strconv.Unquote(quoted)
+	return name
+}
@@ -0,0 +1,96 @@
+package xordb_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestDB_Partitions(t *testing.T) {
+	db := xordb.New()
+	gpt := xordb.ContextWithPartition(context.Background(), "gpt-4o")
+	llama := xordb.ContextWithPartition(context.Background(), "llama-3")
+
+	db.SetContext(gpt, "what is the capital of india", "Delhi (gpt)")
+	db.SetContext(llama, "what is the capital of india", "Delhi (llama)")
+	if db.Len() != 2 {
+		t.Fatalf("Len() = %d, want one entry per partition", db.Len())
+	}
+
+	r := db.LookupContext(llama, "what is the capital of india?")
+	if !r.Hit || r.Value != "Delhi (llama)" || r.MatchedKey != `"llama-3" what is the capital of india` {
+		t.Errorf("llama lookup = %+v", r)
+	}
+	if v, hit, _ := db.GetContext(gpt, "What is the capital of India"); !hit || v != "Delhi (gpt)" {
+		t.Errorf("gpt lookup = %v, %v", v, hit)
+	}
+	mistral := xordb.ContextWithPartition(context.Background(), "mistral")
+	if _, hit, sim := db.GetContext(mistral, "what is the capital of india"); hit {
+		t.Errorf("other partition hit at %.3f", sim)
+	}
+	if _, hit, _ := db.Get("what is the capital of india"); hit {
+		t.Error("plain Get hit a partitioned entry")
+	}
+
+	if n := db.DeletePartition("gpt-4o"); n != 1 {
+		t.Errorf("DeletePartition removed %d, want 1", n)
+	}
+	if _, hit, _ := db.GetContext(gpt, "what is the capital of india"); hit {
+		t.Error("deleted partition still hits")
+	}
+}
+
+func TestDB_WithPartitionBy(t *testing.T) {
+	type modelKey struct{}
+	db := xordb.New(xordb.WithPartitionBy(func(ctx context.Context) string {
+		m, _ := ctx.Value(modelKey{}).(string)
+		return m
+	}))
+	ctx := context.WithValue(context.Background(), modelKey{}, "gpt-4o")
+	db.SetContext(ctx, "hello world", 1)
+	if _, hit, _ := db.GetContext(ctx, "hello world"); !hit {
+		t.Error("same partition missed")
+	}
+	if _, hit, _ := db.GetContext(context.Background(), "hello world"); hit {
+		t.Error("unpartitioned lookup hit")
+	}
+	// The empty partition is the plain namespace.
+	db.SetContext(context.Background(), "plain key", 2)
+	if v, hit, _ := db.Get("plain key"); !hit || v != 2 {
+		t.Errorf("plain Get = %v, %v", v, hit)
+	}
+}
+
+func TestDB_PartitionQuota(t *testing.T) {
+	db := xordb.New(xordb.WithCapacity(20),
+		xordb.WithPartitionQuota("paid", xordb.PartitionQuota{Capacity: 5, TTL: time.Hour, Threshold: 0.95}))
+	paid := xordb.ContextWithPartition(context.Background(), "paid")
+	free := xordb.ContextWithPartition(context.Background(), "free")
+
+	for i := 0; i < 5; i++ {
+		db.SetContext(paid, fmt.Sprintf("paid question number %d", i), i)
+	}
+	for i := 0; i < 100; i++ {
+		db.SetContext(free, fmt.Sprintf("free question number %d", i), i)
+		db.Set(fmt.Sprintf("plain question number %d", i), i)
+	}
+	for i := 0; i < 5; i++ {
+		if _, ok, _ := db.GetContext(paid, fmt.Sprintf("paid question number %d", i)); !ok {
+			t.Errorf("paid entry %d was evicted by other partitions", i)
+		}
+	}
+	if db.Len() != 20 {
+		t.Errorf("Len() = %d, want the capacity", db.Len())
+	}
+
+	r := db.LookupContext(paid, "paid question number 0")
+	if r.ExpiresIn <= 50*time.Minute {
+		t.Errorf("paid entry expires in %v, want the partition TTL", r.ExpiresIn)
+	}
+	if _, ok, _ := db.GetContext(paid, "a paid question, number 0"); ok {
+		t.Error("paid lookups must use the partition threshold")
+	}
+}
@@ -0,0 +1,225 @@
+// Package perf is the measurement core of xordb's benchmarks as a library:
+// it measures a DB's lookup latency and memory on a dataset and checks them
+// against limits, so a test suite can fail when a change to the
+// configuration, the encoder or xordb itself makes the cache slower or
+// larger on its own data.
+//
+//	func TestCachePerf(t *testing.T) {
+//		pairs, _ := eval.LoadFile("testdata/pairs.jsonl")
+//		db := xordb.New(xordb.WithCapacity(len(pairs)))
+//		perf.Gate(t, db, pairs, perf.Limits{
+//			P99Latency:      200 * time.Microsecond,
+//			HeapGrowthBytes: 64 << 20,
+//		}, perf.WithRounds(5))
+//	}
+//
+// Latencies vary with the machine and its load; limits should leave room
+// for the slowest machine the suite runs on, or come from a baseline
+// measured there (Measurement.Limits).
+package perf
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+)
+
+// Measurement is the outcome of Measure. Memory figures are taken after a
+// garbage collection, so they count what the DB retains, not garbage.
+type Measurement struct {
+	Lookups     int           `json:"lookups"`
+	MeanLatency time.Duration `json:"mean_latency_ns"`
+	P50Latency  time.Duration `json:"p50_latency_ns"`
+	P90Latency  time.Duration `json:"p90_latency_ns"`
+	P99Latency  time.Duration `json:"p99_latency_ns"`
+	MaxLatency  time.Duration `json:"max_latency_ns"`
+
+	HeapBytes       uint64 `json:"heap_bytes"`        // live Go heap at the end
+	HeapGrowthBytes uint64 `json:"heap_growth_bytes"` // HeapBytes less the heap before the run
+	RSSBytes        uint64 `json:"rss_bytes"`         // resident set of the process; 0 where unknown
+
+	// Report is the first round's accuracy report, for gating hit quality
+	// alongside speed. Not serialized.
+	Report *eval.Report `json:"-"`
+}
+
+type config struct {
+	rounds int
+}
+
+type Option func(*config)
+
+// WithRounds looks every pair up n times (default 1); more rounds steady
+// the percentiles of a small dataset. Accuracy comes from the first round.
+func WithRounds(n int) Option {
+	if n <= 0 {
+		panic("perf: rounds must be positive")
+	}
+	return func(c *config) { c.rounds = n }
+}
+
+// Measure stores every pair's Cached key in db and looks up every Lookup
+// key, as eval.Run does, timing each Get. db should be empty and hold at
+// least len(pairs) entries.
+func Measure(db *xordb.DB, pairs []eval.Pair, opts ...Option) Measurement {
+	cfg := &config{rounds: 1}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	before := liveHeap()
+
+	rep := eval.Run(db, pairs)
+	lat := make([]time.Duration, 0, cfg.rounds*len(pairs))
+	for _, r := range rep.Results {
+		lat = append(lat, r.Latency)
+	}
+	for i := 1; i < cfg.rounds; i++ {
+		for _, p := range pairs {
+			start := time.Now()
+			db.Get(p.Lookup)
+			lat = append(lat, time.Since(start))
+		}
+	}
+	l := eval.SummarizeLatency(lat)
+
+	m := Measurement{
+		Lookups:     len(lat),
+		MeanLatency: l.Mean,
+		P50Latency:  l.P50,
+		P90Latency:  l.P90,
+		P99Latency:  l.P99,
+		MaxLatency:  l.Max,
+		HeapBytes:   liveHeap(),
+		RSSBytes:    RSS(),
+		Report:      rep,
+	}
+	if m.HeapBytes > before {
+		m.HeapGrowthBytes = m.HeapBytes - before
+	}
+	runtime.KeepAlive(db)
+	return m
+}
+
+func liveHeap() uint64 {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
+
+// RSS returns the resident set size of the process in bytes, read from
+// /proc/self/status; 0 where that is unavailable (outside Linux).
+func RSS() uint64 {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if rest, ok := strings.CutPrefix(sc.Text(), "VmRSS:"); ok {
+			if fields := strings.Fields(rest); len(fields) == 2 && fields[1] == "kB" {
+				kb, err := strconv.ParseUint(fields[0], 10, 64)
+				if err == nil {
+					return kb << 10
+				}
+			}
+		}
+	}
+	return 0
+}
+
+// Limits bounds a Measurement. Zero fields are not checked; RSSBytes is
+// also not checked where RSS is unknown.
+type Limits struct {
+	MeanLatency time.Duration `json:"mean_latency_ns,omitempty"`
+	P50Latency  time.Duration `json:"p50_latency_ns,omitempty"`
+	P90Latency  time.Duration `json:"p90_latency_ns,omitempty"`
+	P99Latency  time.Duration `json:"p99_latency_ns,omitempty"`
+	MaxLatency  time.Duration `json:"max_latency_ns,omitempty"`
+
+	HeapBytes       uint64 `json:"heap_bytes,omitempty"`
+	HeapGrowthBytes uint64 `json:"heap_growth_bytes,omitempty"`
+	RSSBytes        uint64 `json:"rss_bytes,omitempty"`
+}
+
+// Limits turns a baseline into limits that allow it to grow by the factor
+// slack, e.g. 1.5 for 50%, on every figure but MaxLatency, which is left
+// unchecked as too noisy. A baseline saved as JSON on the machine that runs
+// the gate makes a tighter gate than hand-picked numbers.
+func (m Measurement) Limits(slack float64) Limits {
+	if slack < 1 {
+		panic("perf: slack must be at least 1")
+	}
+	d := func(v time.Duration) time.Duration { return time.Duration(float64(v) * slack) }
+	b := func(v uint64) uint64 { return uint64(float64(v) * slack) }
+	return Limits{
+		MeanLatency:     d(m.MeanLatency),
+		P50Latency:      d(m.P50Latency),
+		P90Latency:      d(m.P90Latency),
+		P99Latency:      d(m.P99Latency),
+		HeapBytes:       b(m.HeapBytes),
+		HeapGrowthBytes: b(m.HeapGrowthBytes),
+		RSSBytes:        b(m.RSSBytes),
+	}
+}
+
+// Violation is one limit a Measurement exceeds. Got and Limit are in
+// nanoseconds for latencies and bytes for memory.
+type Violation struct {
+	Metric     string // the JSON name of the field, e.g. "p99_latency_ns"
+	Got, Limit uint64
+}
+
+func (v Violation) String() string {
+	if strings.HasSuffix(v.Metric, "_ns") {
+		return fmt.Sprintf("%s: %v exceeds %v", strings.TrimSuffix(v.Metric, "_ns"),
+			time.Duration(v.Got), time.Duration(v.Limit))
+	}
+	return fmt.Sprintf("%s: %d exceeds %d", v.Metric, v.Got, v.Limit)
+}
+
+// Check returns every limit m exceeds, nil if it passes.
+func (m Measurement) Check(l Limits) []Violation {
+	var out []Violation
+	check := func(metric string, got, limit uint64) {
+		if limit > 0 && got > limit {
+			out = append(out, Violation{metric, got, limit})
+		}
+	}
+	ns := func(d time.Duration) uint64 { return uint64(max(d, 0)) }
+	check("mean_latency_ns", ns(m.MeanLatency), ns(l.MeanLatency))
+	check("p50_latency_ns", ns(m.P50Latency), ns(l.P50Latency))
+	check("p90_latency_ns", ns(m.P90Latency), ns(l.P90Latency))
+	check("p99_latency_ns", ns(m.P99Latency), ns(l.P99Latency))
+	check("max_latency_ns", ns(m.MaxLatency), ns(l.MaxLatency))
+	check("heap_bytes", m.HeapBytes, l.HeapBytes)
+	check("heap_growth_bytes", m.HeapGrowthBytes, l.HeapGrowthBytes)
+	check("rss_bytes", m.RSSBytes, l.RSSBytes)
+	return out
+}
+
+// TB is the part of testing.TB that Gate uses.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Gate measures db on pairs and fails tb once for every limit exceeded. It
+// returns the measurement, e.g. to log it or to save it as the next
+// baseline.
+func Gate(tb TB, db *xordb.DB, pairs []eval.Pair, l Limits, opts ...Option) Measurement {
+	tb.Helper()
+	m := Measure(db, pairs, opts...)
+	for _, v := range m.Check(l) {
+		tb.Errorf("perf: %v", v)
+	}
+	return m
+}
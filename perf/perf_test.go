@@ -0,0 +1,87 @@
+package perf_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+	"github.com/Amansingh-afk/xordb/perf"
+)
+
+func pairs() []eval.Pair {
+	var ps []eval.Pair
+	for i := 0; i < 50; i++ {
+		ps = append(ps, eval.Pair{
+			Cached:    fmt.Sprintf("what is the capital of country number %d", i),
+			Lookup:    fmt.Sprintf("what's the capital of country number %d", i),
+			ExpectHit: true,
+		})
+	}
+	return ps
+}
+
+func TestMeasure(t *testing.T) {
+	m := perf.Measure(xordb.New(), pairs(), perf.WithRounds(3))
+	if m.Lookups != 150 || m.Report.Total() != 50 {
+		t.Errorf("lookups %d, report %d", m.Lookups, m.Report.Total())
+	}
+	if m.P50Latency <= 0 || m.P50Latency > m.P99Latency || m.P99Latency > m.MaxLatency {
+		t.Errorf("latencies %+v", m)
+	}
+	if m.HeapBytes == 0 || m.HeapGrowthBytes > m.HeapBytes {
+		t.Errorf("heap %d, growth %d", m.HeapBytes, m.HeapGrowthBytes)
+	}
+	if runtime.GOOS == "linux" && m.RSSBytes == 0 {
+		t.Error("no RSS on Linux")
+	}
+}
+
+// recorder is a perf.TB.
+type recorder struct{ errs []string }
+
+func (r *recorder) Helper() {}
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func TestGate(t *testing.T) {
+	var r recorder
+	perf.Gate(&r, xordb.New(), pairs(), perf.Limits{P99Latency: time.Hour, HeapBytes: 1 << 40})
+	if len(r.errs) != 0 {
+		t.Errorf("generous limits failed: %v", r.errs)
+	}
+
+	perf.Gate(&r, xordb.New(), pairs(), perf.Limits{P99Latency: 1, HeapGrowthBytes: 1})
+	if len(r.errs) != 2 || !strings.HasPrefix(r.errs[0], "perf: p99_latency: ") ||
+		!strings.HasPrefix(r.errs[1], "perf: heap_growth_bytes: ") {
+		t.Errorf("tight limits: %q", r.errs)
+	}
+}
+
+func TestMeasurement_Limits(t *testing.T) {
+	base := perf.Measurement{P99Latency: 100 * time.Microsecond, MaxLatency: time.Second, HeapBytes: 1000}
+	b, _ := json.Marshal(base)
+	var saved perf.Measurement
+	if err := json.Unmarshal(b, &saved); err != nil || saved.P99Latency != base.P99Latency {
+		t.Fatalf("round trip: %+v, %v", saved, err)
+	}
+	l := saved.Limits(1.5)
+	if l.P99Latency != 150*time.Microsecond || l.HeapBytes != 1500 || l.MaxLatency != 0 {
+		t.Errorf("limits %+v", l)
+	}
+
+	worse := base
+	worse.P99Latency, worse.MaxLatency, worse.HeapBytes = 200*time.Microsecond, 10*time.Second, 1400
+	v := worse.Check(l)
+	if len(v) != 1 || v[0] != (perf.Violation{"p99_latency_ns", 200000, 150000}) {
+		t.Errorf("violations %v", v)
+	}
+	if s := v[0].String(); s != "p99_latency: 200µs exceeds 150µs" {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -0,0 +1,102 @@
+package xordb
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// WithQueryLog writes one JSON line per lookup to w:
+//
+//	{"ts":"2024-05-01T12:00:00Z","key":"capital city of india","hit":true,"similarity":0.91,"match":"what is the capital of india"}
+//
+// key is the normalized key; on a miss, match and similarity describe the
+// closest entry compared, if any. eval.ReadQueryLog and xordb-replay read
+// the log as-is, so production traffic can be replayed against other
+// settings offline. Lines are written synchronously after the cache lock is
+// released; write errors are counted in Stats.QueryLogErrors and the record
+// is dropped. See WithQueryLogSample and WithQueryLogRotate.
+func WithQueryLog(w io.Writer) Option { return func(o *dbOptions) { o.queryLog = w } }
+
+// WithQueryLogSample logs only a random fraction rate (in (0, 1]) of
+// lookups. The default, 1, logs every lookup.
+func WithQueryLogSample(rate float64) Option {
+	return func(o *dbOptions) { o.queryLogSample = rate }
+}
+
+// WithQueryLogRotate calls rotate once the current log writer has received
+// maxBytes, and logs to the writer it returns from then on — typically after
+// closing old and opening a new file. If rotate fails, logging continues to
+// old and rotation is retried after the next maxBytes.
+func WithQueryLogRotate(maxBytes int64, rotate func(old io.Writer) (io.Writer, error)) Option {
+	return func(o *dbOptions) { o.queryLogMax, o.queryLogRotate = maxBytes, rotate }
+}
+
+type queryRecord struct {
+	Time       time.Time `json:"ts"`
+	Key        string    `json:"key"`
+	Hit        bool      `json:"hit"`
+	Similarity float64   `json:"similarity,omitempty"`
+	Match      string    `json:"match,omitempty"`
+}
+
+type queryLog struct {
+	sample float64
+	max    int64
+	rotate func(old io.Writer) (io.Writer, error)
+
+	mu      sync.Mutex
+	w       io.Writer
+	written int64 // bytes written to w since it was opened
+
+	errors atomic.Uint64
+}
+
+func (o *dbOptions) newQueryLog() *queryLog {
+	if o.queryLog == nil {
+		return nil
+	}
+	return &queryLog{sample: o.queryLogSample, max: o.queryLogMax, rotate: o.queryLogRotate, w: o.queryLog}
+}
+
+func (l *queryLog) observe(ev cache.Event) {
+	if ev.Kind != cache.EventHit && ev.Kind != cache.EventMiss {
+		return
+	}
+	if l.sample < 1 && rand.Float64() >= l.sample {
+		return
+	}
+	b, err := json.Marshal(queryRecord{
+		Time:       ev.Time,
+		Key:        ev.Key,
+		Hit:        ev.Kind == cache.EventHit,
+		Similarity: ev.Similarity,
+		Match:      ev.Match,
+	})
+	if err != nil {
+		l.errors.Add(1)
+		return
+	}
+	b = append(b, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n, err := l.w.Write(b)
+	l.written += int64(n)
+	if err != nil {
+		l.errors.Add(1)
+	}
+	if l.rotate != nil && l.written >= l.max {
+		l.written = 0
+		if w, err := l.rotate(l.w); err == nil {
+			l.w = w
+		} else {
+			l.errors.Add(1)
+		}
+	}
+}
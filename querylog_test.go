@@ -0,0 +1,108 @@
+package xordb_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/eval"
+)
+
+type logLine struct {
+	Key        string  `json:"key"`
+	Hit        bool    `json:"hit"`
+	Similarity float64 `json:"similarity"`
+	Match      string  `json:"match"`
+}
+
+func readLog(t *testing.T, b []byte) []logLine {
+	t.Helper()
+	var lines []logLine
+	for _, s := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		if s == "" {
+			continue
+		}
+		var l logLine
+		if err := json.Unmarshal([]byte(s), &l); err != nil {
+			t.Fatalf("bad log line %q: %v", s, err)
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+func TestDB_WithQueryLog(t *testing.T) {
+	var buf bytes.Buffer
+	db := xordb.New(xordb.WithQueryLog(&buf), xordb.WithKeyNormalizer(strings.TrimSpace))
+	db.Set("what is the capital of india", "Delhi")
+	db.Get("  what is the capital of india ")
+	db.Get("recipe for chocolate cake")
+
+	lines := readLog(t, buf.Bytes())
+	if len(lines) != 2 {
+		t.Fatalf("want one line per lookup, got %d: %s", len(lines), buf.String())
+	}
+	if l := lines[0]; !l.Hit || l.Key != "what is the capital of india" || l.Match != l.Key || l.Similarity != 1 {
+		t.Fatalf("hit line: %+v", l)
+	}
+	if l := lines[1]; l.Hit || l.Key != "recipe for chocolate cake" {
+		t.Fatalf("miss line: %+v", l)
+	}
+
+	qs, err := eval.ReadQueryLog(&buf)
+	if err != nil || len(qs) != 2 || qs[1].Key != "recipe for chocolate cake" || qs[0].Time.IsZero() {
+		t.Fatalf("eval.ReadQueryLog must read the log: %+v %v", qs, err)
+	}
+}
+
+func TestDB_WithQueryLogSample(t *testing.T) {
+	var buf bytes.Buffer
+	db := xordb.New(xordb.WithQueryLog(&buf), xordb.WithQueryLogSample(0.5))
+	const n = 1000
+	for i := 0; i < n; i++ {
+		db.Get("what is the capital of india")
+	}
+	if got := len(readLog(t, buf.Bytes())); got < n/4 || got > 3*n/4 {
+		t.Fatalf("sampling 0.5 of %d lookups logged %d", n, got)
+	}
+}
+
+func TestDB_WithQueryLogRotate(t *testing.T) {
+	files := []*bytes.Buffer{{}}
+	db := xordb.New(
+		xordb.WithQueryLog(files[0]),
+		xordb.WithQueryLogRotate(1, func(old io.Writer) (io.Writer, error) {
+			if old != files[len(files)-1] {
+				t.Error("rotate must be passed the current writer")
+			}
+			files = append(files, &bytes.Buffer{})
+			return files[len(files)-1], nil
+		}),
+	)
+	db.Get("first query")
+	db.Get("second query")
+	if len(files) != 3 {
+		t.Fatalf("want a rotation per record, got %d files", len(files))
+	}
+	for i, want := range []string{"first query", "second query"} {
+		if l := readLog(t, files[i].Bytes()); len(l) != 1 || l[0].Key != want {
+			t.Fatalf("file %d: %+v", i, l)
+		}
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestDB_WithQueryLog_Errors(t *testing.T) {
+	db := xordb.New(xordb.WithQueryLog(failWriter{}))
+	db.Get("what is the capital of india")
+	if s := db.Stats(); s.QueryLogErrors != 1 {
+		t.Fatalf("want 1 query log error, got %d", s.QueryLogErrors)
+	}
+}
@@ -0,0 +1,110 @@
+// Package replication — asynchronous primary/replica replication for xordb.
+//
+// The primary records every write in a bounded in-memory Log and serves it
+// as an NDJSON stream; replicas bootstrap from a binary snapshot (vectors
+// included, so nothing is re-encoded), then tail the stream. A replica that
+// falls off the end of the log, or is told to by the primary, re-bootstraps
+// from a fresh snapshot.
+package replication
+
+import (
+	"sync"
+	"time"
+)
+
+type Op string
+
+const (
+	OpSet    Op = "set"
+	OpDelete Op = "delete"
+	OpResync Op = "resync" // bulk change (e.g. import): replicas re-bootstrap
+	OpPing   Op = "ping"   // heartbeat, never stored in the log
+)
+
+// Event is one replicated write.
+type Event struct {
+	Seq  uint64    `json:"seq"`
+	Op   Op        `json:"op"`
+	Time time.Time `json:"ts"` // primary clock when recorded
+	Key  string    `json:"key,omitempty"`
+
+	Value     any        `json:"value,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // explicit TTL
+	Persist   bool       `json:"persist,omitempty"`    // explicit "never expires"
+	// neither ExpiresAt nor Persist: replica applies its default TTL
+}
+
+// Log is a bounded ring of recent events. Safe for concurrent use.
+type Log struct {
+	mu     sync.Mutex
+	ring   []Event
+	head   int // index of oldest event
+	n      int
+	seq    uint64
+	notify chan struct{} // closed on every append
+}
+
+// NewLog keeps the most recent size events.
+func NewLog(size int) *Log {
+	if size <= 0 {
+		panic("replication: log size must be positive")
+	}
+	return &Log{ring: make([]Event, size), notify: make(chan struct{})}
+}
+
+// Append assigns the next sequence number to ev and stores it.
+func (l *Log) Append(ev Event) uint64 {
+	l.mu.Lock()
+	l.seq++
+	ev.Seq = l.seq
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	if l.n < len(l.ring) {
+		l.ring[(l.head+l.n)%len(l.ring)] = ev
+		l.n++
+	} else {
+		l.ring[l.head] = ev
+		l.head = (l.head + 1) % len(l.ring)
+	}
+	close(l.notify)
+	l.notify = make(chan struct{})
+	l.mu.Unlock()
+	return ev.Seq
+}
+
+// Seq returns the last assigned sequence number (0 if empty).
+func (l *Log) Seq() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.seq
+}
+
+// Since returns events with Seq > seq, oldest first, and a channel closed on
+// the next append. ok=false when events after seq were already trimmed and
+// the caller must re-bootstrap.
+// covers reports whether Since(seq) would succeed.
+func (l *Log) covers(seq uint64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return seq <= l.seq && seq >= l.seq-uint64(l.n)
+}
+
+func (l *Log) Since(seq uint64) (events []Event, next <-chan struct{}, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if seq > l.seq {
+		return nil, l.notify, false // caller is ahead: log was reset
+	}
+	oldest := l.seq - uint64(l.n) // seq of the event just before the ring
+	if seq < oldest {
+		return nil, l.notify, false
+	}
+	count := int(l.seq - seq)
+	events = make([]Event, count)
+	for i := 0; i < count; i++ {
+		events[i] = l.ring[(l.head+l.n-count+i)%len(l.ring)]
+	}
+	return events, l.notify, true
+}
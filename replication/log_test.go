@@ -0,0 +1,54 @@
+package replication
+
+import "testing"
+
+func TestLog_Since(t *testing.T) {
+	l := NewLog(4)
+	for i := 0; i < 3; i++ {
+		l.Append(Event{Op: OpSet, Key: string(rune('a' + i))})
+	}
+	if l.Seq() != 3 {
+		t.Fatalf("want seq 3, got %d", l.Seq())
+	}
+
+	evs, _, ok := l.Since(1)
+	if !ok || len(evs) != 2 || evs[0].Seq != 2 || evs[1].Key != "c" {
+		t.Fatalf("Since(1): ok=%v events=%+v", ok, evs)
+	}
+	if evs, _, ok := l.Since(3); !ok || len(evs) != 0 {
+		t.Fatalf("Since(head) must be empty and ok, got ok=%v n=%d", ok, len(evs))
+	}
+}
+
+func TestLog_Trimmed(t *testing.T) {
+	l := NewLog(2)
+	for i := 0; i < 5; i++ {
+		l.Append(Event{Op: OpSet})
+	}
+	if _, _, ok := l.Since(2); ok {
+		t.Fatal("seq older than the ring must report !ok")
+	}
+	evs, _, ok := l.Since(3)
+	if !ok || len(evs) != 2 || evs[0].Seq != 4 || evs[1].Seq != 5 {
+		t.Fatalf("Since(3): ok=%v events=%+v", ok, evs)
+	}
+	if _, _, ok := l.Since(9); ok {
+		t.Fatal("seq ahead of the log must report !ok")
+	}
+}
+
+func TestLog_NotifyOnAppend(t *testing.T) {
+	l := NewLog(2)
+	_, next, _ := l.Since(0)
+	select {
+	case <-next:
+		t.Fatal("notify must not fire before append")
+	default:
+	}
+	l.Append(Event{Op: OpDelete})
+	select {
+	case <-next:
+	default:
+		t.Fatal("notify must fire after append")
+	}
+}
@@ -0,0 +1,159 @@
+package replication
+
+import (
+	"bufio"
+	"encoding/json"
+	"hash/maphash"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+const (
+	seqHeader        = "X-Xordb-Seq"
+	defaultHeartbeat = time.Second
+)
+
+// Primary applies writes to a DB and records them for replicas.
+// Route all writes through it; writes made directly on the DB are invisible
+// to replicas until their next snapshot.
+type Primary struct {
+	db        *xordb.DB
+	log       *Log
+	heartbeat time.Duration
+	chunkSize int
+	staged    stager
+
+	// A write and its log entry happen under its key's stripe lock, so
+	// writes to one key reach replicas in the order the primary applied
+	// them while writes to other keys encode in parallel.
+	seed    maphash.Seed
+	stripes [writeStripes]sync.Mutex
+}
+
+const writeStripes = 64
+
+// NewPrimary keeps the last logSize writes for replicas to catch up from.
+func NewPrimary(db *xordb.DB, logSize int, opts ...PrimaryOption) *Primary {
+	p := &Primary{db: db, log: NewLog(logSize), heartbeat: defaultHeartbeat, chunkSize: defaultChunkSize, seed: maphash.MakeSeed()}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *Primary) DB() *xordb.DB { return p.db }
+
+// Seq returns the sequence number of the last recorded write.
+func (p *Primary) Seq() uint64 { return p.log.Seq() }
+
+func (p *Primary) Set(key string, value any) {
+	defer p.lock(key)()
+	p.db.Set(key, value)
+	p.log.Append(Event{Op: OpSet, Key: key, Value: value})
+}
+
+func (p *Primary) SetWithTTL(key string, value any, ttl time.Duration) {
+	defer p.lock(key)()
+	p.db.SetWithTTL(key, value, ttl)
+	ev := Event{Op: OpSet, Key: key, Value: value, Persist: ttl == 0}
+	if ttl > 0 {
+		dl := time.Now().Add(ttl)
+		ev.ExpiresAt = &dl
+	}
+	p.log.Append(ev)
+}
+
+func (p *Primary) Delete(key string) bool {
+	defer p.lock(key)()
+	ok := p.db.Delete(key)
+	if ok {
+		p.log.Append(Event{Op: OpDelete, Key: key})
+	}
+	return ok
+}
+
+// lock takes key's stripe lock and returns its unlock. Keys are locked as
+// the DB stores them, after its key normalizer.
+func (p *Primary) lock(key string) func() {
+	m := &p.stripes[maphash.String(p.seed, p.db.NormalizeKey(key))%writeStripes]
+	m.Lock()
+	return m.Unlock
+}
+
+// Resync tells replicas to re-bootstrap. Call after bulk changes made
+// directly on the DB (imports, snapshot loads).
+func (p *Primary) Resync() {
+	p.staged.unstage() // it predates the bulk change
+	p.log.Append(Event{Op: OpResync})
+}
+
+// Routes registers the replication endpoints under prefix (e.g.
+// "/v1/replication"): GET prefix/snapshot, the chunked prefix/snapshot/manifest
+// and prefix/snapshot/chunk (see transfer.go), and GET prefix/stream?from=SEQ.
+func (p *Primary) Routes(mux *http.ServeMux, prefix string) {
+	mux.HandleFunc("GET "+prefix+"/snapshot", p.handleSnapshot)
+	mux.HandleFunc("GET "+prefix+"/snapshot/manifest", p.handleManifest)
+	mux.HandleFunc("GET "+prefix+"/snapshot/chunk", p.handleChunk)
+	mux.HandleFunc("GET "+prefix+"/stream", p.handleStream)
+}
+
+// handleSnapshot — binary snapshot plus the log position it covers. The seq
+// is read before the snapshot, so the replica may replay a few writes the
+// snapshot already contains; sets and deletes are idempotent.
+func (p *Primary) handleSnapshot(w http.ResponseWriter, _ *http.Request) {
+	seq := p.log.Seq()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set(seqHeader, strconv.FormatUint(seq, 10))
+	p.db.WriteSnapshot(w)
+}
+
+func (p *Primary) handleStream(w http.ResponseWriter, r *http.Request) {
+	from, err := strconv.ParseUint(r.URL.Query().Get("from"), 10, 64)
+	if err != nil {
+		http.Error(w, "from must be a sequence number", http.StatusBadRequest)
+		return
+	}
+	events, next, ok := p.log.Since(from)
+	if !ok {
+		http.Error(w, "sequence no longer in log, re-bootstrap", http.StatusGone)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	ticker := time.NewTicker(p.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		for _, ev := range events {
+			if err := enc.Encode(ev); err != nil {
+				return
+			}
+			from = ev.Seq
+		}
+		if err := bw.Flush(); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-next:
+		case <-ticker.C:
+			enc.Encode(Event{Op: OpPing, Seq: p.log.Seq(), Time: time.Now()})
+		}
+		if events, next, ok = p.log.Since(from); !ok {
+			return // fell behind mid-stream; replica reconnects and gets 410
+		}
+	}
+}
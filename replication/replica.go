@@ -0,0 +1,317 @@
+package replication
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+var errResync = errors.New("replication: resync required")
+
+const defaultRetireGrace = 30 * time.Second
+
+// Status reports a replica's position relative to its primary.
+type Status struct {
+	Connected   bool
+	AppliedSeq  uint64        // last event applied locally
+	PrimarySeq  uint64        // last seq the primary reported
+	LagEvents   uint64        // PrimarySeq - AppliedSeq
+	Staleness   time.Duration // time since last message from the primary
+	LastContact time.Time
+	Resyncs     uint64 // snapshot bootstraps, including the first
+	LastError   string `json:",omitempty"`
+}
+
+// Replica follows a primary and serves reads from a local DB.
+type Replica struct {
+	base     string // primary replication prefix URL
+	newDB    func() *xordb.DB
+	client   *http.Client
+	resync   time.Duration
+	maxRetry time.Duration
+	grace    time.Duration
+
+	db atomic.Pointer[xordb.DB]
+
+	mu     sync.Mutex
+	status Status
+}
+
+type ReplicaOption func(*Replica)
+
+// WithResyncInterval re-bootstraps from a full snapshot periodically, which
+// corrects drift such as differing LRU eviction under replica read traffic.
+// Zero (default) disables periodic resyncs.
+func WithResyncInterval(d time.Duration) ReplicaOption {
+	return func(r *Replica) { r.resync = d }
+}
+
+// WithRetireGrace sets how long a DB replaced by a bootstrap stays open
+// for reads that fetched it from DB before the swap (default 30s); it is
+// closed afterwards.
+func WithRetireGrace(d time.Duration) ReplicaOption {
+	return func(r *Replica) { r.grace = d }
+}
+
+// WithHTTPClient overrides the client used to reach the primary.
+func WithHTTPClient(c *http.Client) ReplicaOption { return func(r *Replica) { r.client = c } }
+
+// NewReplica follows the primary whose replication endpoints live at
+// primaryURL (e.g. "http://primary:7700/v1/replication"). newDB must build a
+// DB configured like the primary's (same encoder and dims); it is called
+// for every snapshot bootstrap, and the DB it replaces is closed after the
+// WithRetireGrace period, so the DBs must not share an encoder that is an
+// io.Closer.
+func NewReplica(primaryURL string, newDB func() *xordb.DB, opts ...ReplicaOption) *Replica {
+	r := &Replica{
+		base:     strings.TrimRight(primaryURL, "/"),
+		newDB:    newDB,
+		client:   &http.Client{},
+		maxRetry: 30 * time.Second,
+		grace:    defaultRetireGrace,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.db.Store(newDB())
+	return r
+}
+
+// DB returns the current local copy. The pointer changes on every bootstrap,
+// and the old DB is closed once the WithRetireGrace period is over, so
+// fetch it per request rather than holding on to it.
+func (r *Replica) DB() *xordb.DB { return r.db.Load() }
+
+func (r *Replica) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.status
+	if s.PrimarySeq > s.AppliedSeq {
+		s.LagEvents = s.PrimarySeq - s.AppliedSeq
+	}
+	if !s.LastContact.IsZero() {
+		s.Staleness = time.Since(s.LastContact)
+	}
+	return s
+}
+
+// Run follows the primary until ctx is cancelled, reconnecting with backoff.
+func (r *Replica) Run(ctx context.Context) error {
+	needBootstrap := true
+	backoff := 100 * time.Millisecond
+	for ctx.Err() == nil {
+		if needBootstrap {
+			if err := r.bootstrap(ctx); err != nil {
+				r.fail(err)
+				if !sleepCtx(ctx, backoff) {
+					return ctx.Err()
+				}
+				backoff = min(backoff*2, r.maxRetry)
+				continue
+			}
+			needBootstrap = false
+		}
+
+		err := r.follow(ctx)
+		switch {
+		case errors.Is(err, errResync):
+			needBootstrap = true
+			backoff = 100 * time.Millisecond
+		case err != nil && ctx.Err() == nil:
+			r.fail(err)
+			if !sleepCtx(ctx, backoff) {
+				return ctx.Err()
+			}
+			backoff = min(backoff*2, r.maxRetry)
+		default:
+			backoff = 100 * time.Millisecond
+		}
+	}
+	return ctx.Err()
+}
+
+// bootstrap loads a full snapshot into a fresh DB and swaps it in,
+// transferred in checked chunks, or in one response from primaries that
+// predate chunked transfers.
+func (r *Replica) bootstrap(ctx context.Context) error {
+	data, seq, err := r.fetchSnapshot(ctx)
+	if errors.Is(err, errNoManifest) {
+		return r.bootstrapWhole(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	db := r.newDB()
+	if err := db.ReadSnapshot(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	r.install(db, seq)
+	return nil
+}
+
+// bootstrapWhole reads the snapshot from GET prefix/snapshot.
+func (r *Replica) bootstrapWhole(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.base+"/snapshot", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("replication: snapshot: HTTP %d", resp.StatusCode)
+	}
+	seq, err := strconv.ParseUint(resp.Header.Get(seqHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("replication: snapshot: bad %s header", seqHeader)
+	}
+	db := r.newDB()
+	if err := db.ReadSnapshot(resp.Body); err != nil {
+		return err
+	}
+	r.install(db, seq)
+	return nil
+}
+
+// install swaps in db, loaded from a snapshot covering the log up to seq,
+// and closes the DB it replaces once requests still using it are done.
+func (r *Replica) install(db *xordb.DB, seq uint64) {
+	if old := r.db.Swap(db); old != nil {
+		time.AfterFunc(r.grace, func() { old.Close(context.Background()) })
+	}
+
+	r.mu.Lock()
+	r.status.AppliedSeq = seq
+	if seq > r.status.PrimarySeq {
+		r.status.PrimarySeq = seq
+	}
+	r.status.LastContact = time.Now()
+	r.status.Resyncs++
+	r.mu.Unlock()
+}
+
+// follow tails the event stream. Returns errResync when the replica must
+// re-bootstrap, including when the periodic resync interval elapses.
+func (r *Replica) follow(ctx context.Context) error {
+	if r.resync > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.resync)
+		defer cancel()
+	}
+
+	r.mu.Lock()
+	from := r.status.AppliedSeq
+	r.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.base+"/stream?from="+strconv.FormatUint(from, 10), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusGone {
+		return errResync
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("replication: stream: HTTP %d", resp.StatusCode)
+	}
+
+	r.mu.Lock()
+	r.status.Connected = true
+	r.status.LastError = ""
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.status.Connected = false
+		r.mu.Unlock()
+	}()
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 0, 64*1024), 17<<20)
+	for sc.Scan() {
+		var ev Event
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			return fmt.Errorf("replication: stream: %w", err)
+		}
+		if err := r.apply(ev); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return errResync // periodic resync
+	}
+	if err := sc.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Replica) apply(ev Event) error {
+	db := r.DB()
+	switch ev.Op {
+	case OpPing:
+	case OpSet:
+		switch {
+		case ev.ExpiresAt != nil:
+			if ttl := time.Until(*ev.ExpiresAt); ttl > 0 {
+				db.SetWithTTL(ev.Key, ev.Value, ttl)
+			} else {
+				db.Delete(ev.Key) // already expired on arrival
+			}
+		case ev.Persist:
+			db.SetWithTTL(ev.Key, ev.Value, 0)
+		default:
+			db.Set(ev.Key, ev.Value)
+		}
+	case OpDelete:
+		db.Delete(ev.Key)
+	case OpResync:
+		return errResync
+	default:
+		return fmt.Errorf("replication: unknown op %q", ev.Op)
+	}
+
+	r.mu.Lock()
+	if ev.Op != OpPing {
+		r.status.AppliedSeq = ev.Seq
+	}
+	if ev.Seq > r.status.PrimarySeq {
+		r.status.PrimarySeq = ev.Seq
+	}
+	r.status.LastContact = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Replica) fail(err error) {
+	r.mu.Lock()
+	r.status.LastError = err.Error()
+	r.mu.Unlock()
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
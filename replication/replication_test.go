@@ -0,0 +1,149 @@
+package replication_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/replication"
+)
+
+// ── helpers ───────────────────────────────────────────────────────────────────
+
+func newDB() *xordb.DB { return xordb.New(xordb.WithThreshold(0.70)) }
+
+func startPrimary(t *testing.T, logSize int) (*replication.Primary, string) {
+	t.Helper()
+	p := replication.NewPrimary(newDB(), logSize)
+	mux := http.NewServeMux()
+	p.Routes(mux, "/v1/replication")
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return p, srv.URL + "/v1/replication"
+}
+
+func startReplica(t *testing.T, url string, opts ...replication.ReplicaOption) *replication.Replica {
+	t.Helper()
+	r := replication.NewReplica(url, newDB, opts...)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { r.Run(ctx); close(done) }()
+	t.Cleanup(func() { cancel(); <-done })
+	return r
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+// ── replication ───────────────────────────────────────────────────────────────
+
+func TestReplica_BootstrapAndStream(t *testing.T) {
+	p, url := startPrimary(t, 100)
+	p.Set("what is the capital of india", "Delhi") // before replica: via snapshot
+
+	r := startReplica(t, url)
+	waitFor(t, "bootstrap", func() bool { return r.DB().Len() == 1 })
+
+	p.Set("what is the capital of nepal", "Kathmandu") // via stream
+	p.SetWithTTL("short lived", "x", time.Hour)
+	waitFor(t, "stream", func() bool { return r.DB().Len() == 3 })
+
+	if v, ok, _ := r.DB().Get("capital city of nepal"); !ok || v != "Kathmandu" {
+		t.Fatalf("replica must serve replicated entry, got %v ok=%v", v, ok)
+	}
+
+	p.Delete("short lived")
+	waitFor(t, "delete", func() bool { return r.DB().Len() == 2 })
+
+	waitFor(t, "caught up", func() bool {
+		s := r.Status()
+		return s.Connected && s.AppliedSeq == p.Seq() && s.LagEvents == 0
+	})
+}
+
+// Concurrent writes to one key must reach the replica in the order the
+// primary applied them, leaving both with the same value.
+func TestReplica_ConcurrentWritesConverge(t *testing.T) {
+	p, url := startPrimary(t, 1000)
+	r := startReplica(t, url)
+	waitFor(t, "connect", func() bool { return r.Status().Connected })
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				p.Set("contended key", fmt.Sprint(w, i))
+			}
+		}()
+	}
+	wg.Wait()
+
+	waitFor(t, "caught up", func() bool { return r.Status().AppliedSeq == p.Seq() })
+	want, _, _ := p.DB().Get("contended key")
+	if got, ok, _ := r.DB().Get("contended key"); !ok || got != want {
+		t.Fatalf("replica has %v, primary %v", got, want)
+	}
+}
+
+func TestReplica_ResyncEvent(t *testing.T) {
+	p, url := startPrimary(t, 100)
+	r := startReplica(t, url, replication.WithRetireGrace(100*time.Millisecond))
+	waitFor(t, "connect", func() bool { return r.Status().Connected })
+
+	// bulk write behind the log's back, then tell replicas
+	old := r.DB()
+	p.DB().Set("imported", 1)
+	p.Resync()
+
+	waitFor(t, "resync", func() bool { return r.DB().Len() == 1 })
+	if s := r.Status(); s.Resyncs < 2 {
+		t.Fatalf("want a second bootstrap, got %d", s.Resyncs)
+	}
+	if _, err := old.TryLookup("imported"); err != nil {
+		t.Fatalf("the replaced DB must stay open for reads in flight: %v", err)
+	}
+	waitFor(t, "retire", func() bool {
+		_, err := old.TryLookup("imported")
+		return errors.Is(err, xordb.ErrClosed)
+	})
+}
+
+func TestReplica_FellOffLog(t *testing.T) {
+	p, url := startPrimary(t, 2)
+	r := replication.NewReplica(url, newDB)
+
+	// replica starts after the log already wrapped; stream from 0 must 410
+	for i := 0; i < 5; i++ {
+		p.Set(string(rune('a'+i)), i)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+
+	waitFor(t, "catch up", func() bool { return r.DB().Len() == 5 })
+}
+
+func TestReplica_PrimaryDown_ReportsError(t *testing.T) {
+	r := startReplica(t, "http://127.0.0.1:1/v1/replication")
+	waitFor(t, "error", func() bool { return r.Status().LastError != "" })
+	if r.Status().Connected {
+		t.Fatal("replica must not report connected")
+	}
+}
@@ -0,0 +1,232 @@
+package replication
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Bootstraps transfer a snapshot in chunks: GET prefix/snapshot/manifest
+// stages one — a binary snapshot held in the primary's memory, shared by
+// every replica that bootstraps while it is fresh — and describes it, and
+// GET prefix/snapshot/chunk?id=ID&i=N serves its chunks. Replicas check
+// each chunk and the whole against the manifest's SHA-256 sums and retry a
+// chunk that fails rather than starting over, so a flaky link or a large
+// corpus does not turn into repeated full transfers.
+
+const (
+	defaultChunkSize = 1 << 20
+	stagedTTL        = time.Minute // how long a staged snapshot is reused
+	stagedKeep       = 5 * time.Minute
+	chunkRetries     = 5
+)
+
+// Manifest describes a staged snapshot.
+type Manifest struct {
+	ID        string   `json:"id"`
+	Seq       uint64   `json:"seq"` // log position the snapshot covers
+	Size      int64    `json:"size"`
+	ChunkSize int      `json:"chunk_size"`
+	SHA256    string   `json:"sha256"` // of the whole snapshot, hex
+	Chunks    []string `json:"chunks"` // SHA-256 of each chunk, hex
+}
+
+type staged struct {
+	m    Manifest
+	data []byte
+	at   time.Time
+}
+
+// stager builds staged snapshots and keeps the recent ones, so replicas
+// bootstrapping together cost the primary one snapshot, and a transfer in
+// progress survives the next one being staged.
+type stager struct {
+	mu   sync.Mutex
+	byID map[string]*staged
+	last *staged
+}
+
+type PrimaryOption func(*Primary)
+
+// WithSnapshotChunkSize sets the size of bootstrap snapshot chunks
+// (default 1 MiB).
+func WithSnapshotChunkSize(n int) PrimaryOption {
+	if n <= 0 {
+		panic("replication: snapshot chunk size must be positive")
+	}
+	return func(p *Primary) { p.chunkSize = n }
+}
+
+// unstage stops the last staged snapshot from being handed out again.
+func (s *stager) unstage() {
+	s.mu.Lock()
+	s.last = nil
+	s.mu.Unlock()
+}
+
+// stage returns a fresh staged snapshot, building one if need be.
+func (p *Primary) stage() (*staged, error) {
+	s := &p.staged
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, st := range s.byID {
+		if now.Sub(st.at) > stagedKeep {
+			delete(s.byID, id)
+		}
+	}
+	if s.last != nil && now.Sub(s.last.at) < stagedTTL && p.log.covers(s.last.m.Seq) {
+		return s.last, nil
+	}
+
+	seq := p.log.Seq() // before the snapshot, as in handleSnapshot
+	var buf bytes.Buffer
+	if err := p.db.WriteSnapshot(&buf); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+	id := make([]byte, 8)
+	rand.Read(id)
+	sum := sha256.Sum256(data)
+	m := Manifest{
+		ID:        hex.EncodeToString(id),
+		Seq:       seq,
+		Size:      int64(len(data)),
+		ChunkSize: p.chunkSize,
+		SHA256:    hex.EncodeToString(sum[:]),
+	}
+	for off := 0; off < len(data); off += p.chunkSize {
+		sum := sha256.Sum256(data[off:min(off+p.chunkSize, len(data))])
+		m.Chunks = append(m.Chunks, hex.EncodeToString(sum[:]))
+	}
+	st := &staged{m: m, data: data, at: now}
+	if s.byID == nil {
+		s.byID = make(map[string]*staged)
+	}
+	s.byID[m.ID] = st
+	s.last = st
+	return st, nil
+}
+
+func (p *Primary) handleManifest(w http.ResponseWriter, _ *http.Request) {
+	st, err := p.stage()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(st.m)
+}
+
+func (p *Primary) handleChunk(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	p.staged.mu.Lock()
+	st := p.staged.byID[q.Get("id")]
+	p.staged.mu.Unlock()
+	if st == nil {
+		http.Error(w, "snapshot no longer staged, fetch a new manifest", http.StatusGone)
+		return
+	}
+	i, err := strconv.Atoi(q.Get("i"))
+	if err != nil || i < 0 || i >= len(st.m.Chunks) {
+		http.Error(w, "i must be a chunk index", http.StatusBadRequest)
+		return
+	}
+	off := i * st.m.ChunkSize
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(st.data[off:min(off+st.m.ChunkSize, len(st.data))])
+}
+
+// errNoManifest means the primary predates chunked transfers.
+var errNoManifest = errors.New("replication: primary has no snapshot manifest")
+
+// fetchSnapshot downloads a staged snapshot chunk by chunk, retrying
+// chunks that fail or do not match their sums, and returns it with the
+// sequence number it covers.
+func (r *Replica) fetchSnapshot(ctx context.Context) ([]byte, uint64, error) {
+	var m Manifest
+	if err := r.getJSON(ctx, r.base+"/snapshot/manifest", &m); err != nil {
+		return nil, 0, err
+	}
+	if m.ChunkSize <= 0 || m.Size < 0 || int64(len(m.Chunks)) != (m.Size+int64(m.ChunkSize)-1)/int64(m.ChunkSize) {
+		return nil, 0, errors.New("replication: snapshot: bad manifest")
+	}
+	data := make([]byte, 0, m.Size)
+	for i, want := range m.Chunks {
+		var err error
+		for try := 0; try < chunkRetries; try++ {
+			if try > 0 && !sleepCtx(ctx, time.Duration(try)*100*time.Millisecond) {
+				return nil, 0, ctx.Err()
+			}
+			var chunk []byte
+			if chunk, err = r.fetchChunk(ctx, m.ID, i); err != nil {
+				if errors.Is(err, errResync) {
+					return nil, 0, err // unstaged: start over
+				}
+				continue
+			}
+			if sum := sha256.Sum256(chunk); hex.EncodeToString(sum[:]) != want {
+				err = fmt.Errorf("replication: snapshot chunk %d: checksum mismatch", i)
+				continue
+			}
+			data = append(data, chunk...)
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != m.SHA256 {
+		return nil, 0, errors.New("replication: snapshot: checksum mismatch")
+	}
+	return data, m.Seq, nil
+}
+
+func (r *Replica) fetchChunk(ctx context.Context, id string, i int) ([]byte, error) {
+	url := r.base + "/snapshot/chunk?id=" + id + "&i=" + strconv.Itoa(i)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusGone:
+		return nil, errResync
+	}
+	return nil, fmt.Errorf("replication: snapshot chunk %d: HTTP %d", i, resp.StatusCode)
+}
+
+func (r *Replica) getJSON(ctx context.Context, url string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return errNoManifest
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("replication: snapshot manifest: HTTP %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
@@ -0,0 +1,80 @@
+package replication_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb/replication"
+)
+
+// flaky corrupts the first response for every snapshot chunk.
+type flaky struct {
+	mu     sync.Mutex
+	seen   map[string]bool
+	chunks int
+}
+
+func (f *flaky) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil || !strings.Contains(req.URL.Path, "/snapshot/chunk") {
+		return resp, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chunks++
+	if f.seen[req.URL.RawQuery] {
+		return resp, nil
+	}
+	f.seen[req.URL.RawQuery] = true
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	body[0] ^= 0xff
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func TestReplica_ChunkedBootstrap(t *testing.T) {
+	p := replication.NewPrimary(newDB(), 100, replication.WithSnapshotChunkSize(4096))
+	for i := 0; i < 20; i++ {
+		p.Set(fmt.Sprintf("question number %d about the weather", i), i)
+	}
+	mux := http.NewServeMux()
+	p.Routes(mux, "/v1/replication")
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	tr := &flaky{seen: make(map[string]bool)}
+	r := startReplica(t, srv.URL+"/v1/replication", replication.WithHTTPClient(&http.Client{Transport: tr}))
+	waitFor(t, "bootstrap", func() bool { return r.DB().Len() == 20 })
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if len(tr.seen) < 2 || tr.chunks != 2*len(tr.seen) {
+		t.Errorf("%d chunk requests for %d chunks, want each retried once", tr.chunks, len(tr.seen))
+	}
+	if s := r.Status(); s.Resyncs != 1 {
+		t.Errorf("%d bootstraps, want the corrupt chunks retried within one", s.Resyncs)
+	}
+}
+
+// Primaries without the manifest endpoint still bootstrap replicas.
+func TestReplica_WholeSnapshotFallback(t *testing.T) {
+	p := replication.NewPrimary(newDB(), 100)
+	p.Set("what is the capital of india", "Delhi")
+	full := http.NewServeMux()
+	p.Routes(full, "/v1/replication")
+	old := http.NewServeMux()
+	old.Handle("GET /v1/replication/snapshot", full)
+	old.Handle("GET /v1/replication/stream", full)
+	srv := httptest.NewServer(old)
+	t.Cleanup(srv.Close)
+
+	r := startReplica(t, srv.URL+"/v1/replication")
+	waitFor(t, "bootstrap", func() bool { return r.DB().Len() == 1 })
+}
@@ -0,0 +1,113 @@
+package xordb
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+const nearMissQueue = 256 // pending verifications; more are dropped
+
+// WithNearMissVerifier sends misses whose closest entry scored within
+// epsilon below the threshold to verify — a cross-encoder reranker, a
+// lexical check — on a background goroutine. If verify(query, candidate)
+// confirms the pair, the query is stored as an alias of the candidate
+// (same value, source and expiry), so the same paraphrase hits next time.
+// The lookup itself still misses. At most 256 verifications are pending;
+// misses beyond that are dropped. Counts are in Stats.NearMiss*. verify must
+// be safe for concurrent use with the DB; epsilon must be in (0, 1).
+func WithNearMissVerifier(epsilon float64, verify func(query, candidate string) bool) Option {
+	return func(o *dbOptions) { o.nearMissEps, o.nearMissVerify = epsilon, verify }
+}
+
+type nearMissPair struct{ query, candidate string }
+
+// verifier queues near misses and drains them on a goroutine started on
+// demand, so an idle DB holds no goroutine.
+type verifier struct {
+	c      *cache.Cache
+	eps    float64
+	verify func(query, candidate string) bool
+
+	mu      sync.Mutex
+	queue   []nearMissPair
+	running bool
+	stopped bool
+	idle    sync.WaitGroup // the run goroutine
+
+	queued    atomic.Uint64
+	dropped   atomic.Uint64
+	confirmed atomic.Uint64
+}
+
+// observe queues ev if it is a near miss.
+func (v *verifier) observe(ev cache.Event) {
+	if ev.Kind != cache.EventMiss || ev.Match == "" || ev.Match == ev.Key {
+		return
+	}
+	if t := v.c.Threshold(); ev.Similarity < t-v.eps || ev.Similarity >= t {
+		return
+	}
+	v.mu.Lock()
+	if v.stopped {
+		v.mu.Unlock()
+		return
+	}
+	if len(v.queue) >= nearMissQueue {
+		v.mu.Unlock()
+		v.dropped.Add(1)
+		return
+	}
+	v.queue = append(v.queue, nearMissPair{ev.Key, ev.Match})
+	start := !v.running
+	if start {
+		v.running = true
+		v.idle.Add(1)
+	}
+	v.mu.Unlock()
+	v.queued.Add(1)
+	if start {
+		go v.run()
+	}
+}
+
+func (v *verifier) run() {
+	defer v.idle.Done()
+	for {
+		v.mu.Lock()
+		if len(v.queue) == 0 {
+			v.running = false
+			v.mu.Unlock()
+			return
+		}
+		p := v.queue[0]
+		v.queue = v.queue[1:]
+		v.mu.Unlock()
+
+		if v.verify(p.query, p.candidate) && v.c.Alias(p.query, p.candidate) {
+			v.confirmed.Add(1)
+		}
+	}
+}
+
+// stop drops pending verifications, refuses new ones and returns a channel
+// closed once a verification already running has finished.
+func (v *verifier) stop() <-chan struct{} {
+	v.mu.Lock()
+	v.stopped = true
+	v.queue = nil
+	v.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		v.idle.Wait()
+		close(done)
+	}()
+	return done
+}
+
+func (v *verifier) stats(s *Stats) {
+	s.NearMissQueued = v.queued.Load()
+	s.NearMissDropped = v.dropped.Load()
+	s.NearMissConfirmed = v.confirmed.Load()
+}
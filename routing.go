@@ -0,0 +1,30 @@
+package xordb
+
+import (
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/lang"
+)
+
+// WithLanguageRouting detects each key's language (lang.Detect) and keeps
+// languages apart: keys are encoded with encoders[code] if given, the DB's
+// encoder otherwise, and each language's vectors are bound into a namespace
+// of their own, so "la casa" stops matching "the case" on shared n-grams.
+// Keys whose language cannot be told, typically a word or two, share one
+// namespace with each other. encoders may be nil; they are moved into the
+// WithSeed namespace like the DB's encoder and must return its dims.
+// Changes the vectors, so snapshots from DBs without it cannot be loaded.
+func WithLanguageRouting(encoders map[string]hdc.Encoder) Option {
+	return func(o *dbOptions) { o.langRouting, o.langEncoders = true, encoders }
+}
+
+// routed wraps enc in a language router if WithLanguageRouting was given.
+func (o *dbOptions) routed(enc hdc.Encoder) hdc.Encoder {
+	if !o.langRouting {
+		return enc
+	}
+	encs := make(map[string]hdc.Encoder, len(o.langEncoders))
+	for code, e := range o.langEncoders {
+		encs[code] = seeded(e, o.seed)
+	}
+	return lang.NewRouter(enc, encs)
+}
@@ -0,0 +1,39 @@
+package xordb_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestDB_WithLanguageRouting(t *testing.T) {
+	en := "the capital of spain is madrid"
+	es := "la capital de españa es madrid"
+
+	plain := xordb.New(xordb.WithThreshold(0.6))
+	plain.Set(en, "en")
+	if _, hit, _ := plain.Get(es); !hit {
+		t.Fatal("want the languages to cross-match without routing")
+	}
+
+	db := xordb.New(xordb.WithThreshold(0.6), xordb.WithLanguageRouting(nil))
+	db.Set(en, "en")
+	if v, hit, sim := db.Get(es); hit {
+		t.Errorf("Spanish key hit %v at %.3f", v, sim)
+	}
+	if v, hit, _ := db.Get("the capital of spain is madrid?"); !hit || v != "en" {
+		t.Errorf("same-language lookup = %v, %v", v, hit)
+	}
+}
+
+func TestDB_WithLanguageRouting_DimsMismatch(t *testing.T) {
+	cfg := hdc.DefaultConfig()
+	cfg.Dims = 2048
+	_, err := xordb.TryNewWithEncoder(hdc.NewNGramEncoder(hdc.DefaultConfig()),
+		xordb.WithLanguageRouting(map[string]hdc.Encoder{"de": hdc.NewNGramEncoder(cfg)}))
+	if !errors.Is(err, xordb.ErrDimsMismatch) {
+		t.Errorf("want ErrDimsMismatch for a per-language encoder of other dims, got %v", err)
+	}
+}
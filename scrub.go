@@ -0,0 +1,103 @@
+package xordb
+
+import (
+	"regexp"
+	"strings"
+)
+
+// WithKeyScrubber rewrites every key before WithKeyNormalizer and
+// encoding, e.g. with RedactPII, so personal data in prompts never reaches
+// vectors, snapshots or the replication stream. Lookups are scrubbed the
+// same way, so a question naming one customer's email matches the cached
+// answer for another's. fn must be deterministic and safe for concurrent
+// use.
+func WithKeyScrubber(fn func(string) string) Option {
+	return func(o *dbOptions) { o.keyScrubber = fn }
+}
+
+// WithValueScrubber rewrites every value before it is stored, e.g. with
+// ScrubPII; Set, Fetch, SetMany and the rest store what fn returns, while
+// Fetch still hands its caller the loader's value unchanged. Values already
+// in loaded snapshots are not rewritten. fn must be safe for concurrent
+// use.
+func WithValueScrubber(fn func(any) any) Option {
+	return func(o *dbOptions) { o.valueScrubber = fn }
+}
+
+// The placeholders RedactPII substitutes.
+const (
+	RedactedEmail = "[EMAIL]"
+	RedactedPhone = "[PHONE]"
+	RedactedCard  = "[CARD]"
+)
+
+var (
+	emailRE = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	cardRE  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	phoneRE = regexp.MustCompile(`\+\d{1,3}(?:[ .-]?\d{2,5}){2,4}\b|(?:\(\d{3}\) ?|\b\d{3}[ .-]?)\d{3}[ .-]?\d{4}\b`)
+)
+
+// RedactPII replaces email addresses, payment card numbers (13 to 19
+// digits, optionally grouped by spaces or dashes, that pass the Luhn check)
+// and phone numbers (international ones starting with +, and ten-digit
+// ones such as "(555) 123-4567") in text with RedactedEmail, RedactedCard
+// and RedactedPhone. It is a baseline, not a guarantee: names, addresses
+// and unusual formats pass through.
+func RedactPII(text string) string {
+	if !strings.ContainsAny(text, "@0123456789") {
+		return text
+	}
+	text = emailRE.ReplaceAllLiteralString(text, RedactedEmail)
+	text = cardRE.ReplaceAllStringFunc(text, func(m string) string {
+		if luhn(m) {
+			return RedactedCard
+		}
+		return m
+	})
+	return phoneRE.ReplaceAllLiteralString(text, RedactedPhone)
+}
+
+// ScrubPII applies RedactPII to a value: to strings and []byte, and to the
+// strings inside map[string]any and []any values, recursively, as decoded
+// JSON holds them. Other values, structs included, are returned as is. Maps
+// and slices are copied, not modified.
+func ScrubPII(v any) any {
+	switch v := v.(type) {
+	case string:
+		return RedactPII(v)
+	case []byte:
+		return []byte(RedactPII(string(v)))
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = ScrubPII(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = ScrubPII(e)
+		}
+		return out
+	}
+	return v
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		d := int(s[i] - '0')
+		if d < 0 || d > 9 {
+			continue
+		}
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
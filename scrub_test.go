@@ -0,0 +1,78 @@
+package xordb_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestRedactPII(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"mail jane.doe+work@example.co.uk today", "mail [EMAIL] today"},
+		{"card 4111 1111 1111 1111 expired", "card [CARD] expired"},
+		{"card 4111-1111-1111-1112 expired", "card 4111-1111-1111-1112 expired"}, // fails Luhn
+		{"call (555) 123-4567 or +44 20 7946 0958", "call [PHONE] or [PHONE]"},
+		{"call 555.123.4567", "call [PHONE]"},
+		{"released 2024-01-15, order 12345", "released 2024-01-15, order 12345"},
+		{"no personal data here", "no personal data here"},
+	}
+	for _, c := range cases {
+		if got := xordb.RedactPII(c.in); got != c.want {
+			t.Errorf("RedactPII(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestScrubPII(t *testing.T) {
+	in := map[string]any{
+		"to":    "bob@example.com",
+		"lines": []any{"call 555-123-4567", 3.0},
+		"raw":   []byte("bob@example.com"),
+	}
+	want := map[string]any{
+		"to":    "[EMAIL]",
+		"lines": []any{"call [PHONE]", 3.0},
+		"raw":   []byte("[EMAIL]"),
+	}
+	if got := xordb.ScrubPII(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("ScrubPII = %#v", got)
+	}
+	if in["to"] != "bob@example.com" {
+		t.Error("ScrubPII modified its argument")
+	}
+	if got := xordb.ScrubPII(answer{Text: "bob@example.com"}); got != (answer{Text: "bob@example.com"}) {
+		t.Errorf("struct scrubbed to %#v", got)
+	}
+}
+
+func TestDB_WithScrubbers(t *testing.T) {
+	db := xordb.New(
+		xordb.WithKeyScrubber(xordb.RedactPII),
+		xordb.WithValueScrubber(xordb.ScrubPII),
+		xordb.WithKeyNormalizer(strings.ToLower),
+	)
+	db.Set("Reset the password for alice@example.com", "sent a link to alice@example.com")
+
+	// Another address scrubs to the same key.
+	v, ok, sim := db.Get("reset the password for BOB@example.org")
+	if !ok || sim != 1 || v != "sent a link to [EMAIL]" {
+		t.Errorf("Get = %#v, %v, %.3f", v, ok, sim)
+	}
+	for _, r := range db.Entries(xordb.OrderAccess) {
+		if strings.Contains(r.Key, "@") {
+			t.Errorf("stored key %q", r.Key)
+		}
+	}
+
+	got, err := db.Fetch("call me at +1 415 555 0100", func(string) (any, error) {
+		return "noted +1 415 555 0100", nil
+	})
+	if err != nil || got != "noted +1 415 555 0100" {
+		t.Errorf("Fetch returned %#v, %v, want the loader's value", got, err)
+	}
+	if v, _, _ := db.Get("call me at +1 415 555 0100"); v != "noted [PHONE]" {
+		t.Errorf("fetched value stored as %#v", v)
+	}
+}
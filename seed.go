@@ -0,0 +1,70 @@
+package xordb
+
+import (
+	"io"
+	"sync"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+// Reseeder is implemented by encoders that can derive a copy of themselves
+// in another seed's vector space, e.g. embed.MiniLMEncoder, whose
+// projection then comes from the seed. Reseed(0) should return the encoder
+// unchanged.
+type Reseeder interface {
+	Reseed(seed uint64) hdc.Encoder
+}
+
+// seeded puts enc in the namespace of seed (see WithSeed): a Reseeder
+// reseeds itself; any other encoder has its vectors bound to a key derived
+// from seed, which keeps every similarity within the namespace and makes
+// vectors from different seeds unrelated. Seed 0 leaves enc as it is.
+func seeded(enc hdc.Encoder, seed uint64) hdc.Encoder {
+	if enc == nil || seed == 0 {
+		return enc
+	}
+	if r, ok := enc.(Reseeder); ok {
+		return r.Reseed(seed)
+	}
+	b := &boundEncoder{enc: enc, seed: seed}
+	if p, ok := enc.(EmbeddingProjector); ok {
+		return &boundProjector{b, p}
+	}
+	return b
+}
+
+type boundEncoder struct {
+	enc  hdc.Encoder
+	seed uint64
+	once sync.Once
+	key  hdc.Vector // from the first vector's dims
+}
+
+func (e *boundEncoder) Encode(text string) hdc.Vector { return e.bind(e.enc.Encode(text)) }
+
+func (e *boundEncoder) bind(v hdc.Vector) hdc.Vector {
+	e.once.Do(func() { e.key = hdcx.Random(v.Dims(), e.seed^0x6e616d657370) }) // "namesp"
+	return hdc.Bind(e.key, v)
+}
+
+// Close closes the wrapped encoder if it is an io.Closer; see DB.Close.
+func (e *boundEncoder) Close() error {
+	if c, ok := e.enc.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+type boundProjector struct {
+	*boundEncoder
+	p EmbeddingProjector
+}
+
+func (e *boundProjector) ProjectEmbedding(emb []float32) (hdc.Vector, error) {
+	v, err := e.p.ProjectEmbedding(emb)
+	if err != nil {
+		return hdc.Vector{}, err
+	}
+	return e.bind(v), nil
+}
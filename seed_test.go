@@ -0,0 +1,62 @@
+package xordb_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestNewWithEncoder_WithSeed(t *testing.T) {
+	enc := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	a := xordb.NewWithEncoder(enc, xordb.WithSeed(1))
+	b := xordb.NewWithEncoder(enc, xordb.WithSeed(2))
+
+	a.Set("what is the capital of france", "Paris")
+	if _, ok, _ := a.Get("what's the capital of france"); !ok {
+		t.Fatal("similarities within a namespace must be kept")
+	}
+
+	var snap bytes.Buffer
+	if err := a.WriteSnapshot(&snap); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ReadSnapshot(&snap); err == nil {
+		t.Fatal("a snapshot from another seed must be rejected")
+	}
+
+	// the plain encoder's vectors are outside both namespaces
+	r, err := a.GetVec(enc.Encode("what is the capital of france"))
+	if err != nil || r.Hit {
+		t.Fatalf("got %+v, %v", r, err)
+	}
+	c := xordb.NewWithEncoder(enc, xordb.WithSeed(2))
+	c.Set("what is the capital of france", "Paris")
+	if r := c.Explain("what is the capital of france", 1); len(r) != 1 || r[0].Similarity != 1 {
+		t.Fatalf("same seed must give the same vectors: %+v", r)
+	}
+}
+
+// reseeder records the seed it was asked for.
+type reseeder struct {
+	hdc.Encoder
+	seed uint64
+}
+
+func (r *reseeder) Reseed(seed uint64) hdc.Encoder { return &reseeder{r.Encoder, seed} }
+
+func TestNewWithEncoder_WithSeed_Reseeder(t *testing.T) {
+	enc := &reseeder{Encoder: hdc.NewNGramEncoder(hdc.DefaultConfig())}
+	db := xordb.NewWithEncoder(enc, xordb.WithSeed(7))
+	db.Set("k", 1)
+	if _, ok, sim := db.Get("k"); !ok || sim != 1 {
+		t.Fatalf("got %v %v", ok, sim)
+	}
+	// the reseeded copy encodes exactly like the original here, so plain
+	// n-gram vectors must match: no binding was added on top
+	r, err := db.GetVec(enc.Encode("k"))
+	if err != nil || !r.Hit {
+		t.Fatalf("a Reseeder must be reseeded, not bound: %+v, %v", r, err)
+	}
+}
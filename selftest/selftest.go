@@ -0,0 +1,253 @@
+// Package selftest checks that an hdc.Encoder has the properties xordb
+// relies on: deterministic output of a fixed size, invariance to case and
+// whitespace, near-orthogonal vectors for unrelated text, and tolerance of
+// small typos. Run it against a custom Encoder before plugging it into
+// xordb.NewWithEncoder.
+//
+//	rep := selftest.Run(myEncoder)
+//	if !rep.Passed() {
+//		rep.WriteText(os.Stderr)
+//	}
+package selftest
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"strings"
+
+	"github.com/Amansingh-afk/hdc-go"
+)
+
+// Limits are the pass thresholds, as hdc.Similarity values. A zero limit
+// disables its check.
+type Limits struct {
+	MinInvariance float64 // same text, different case or spacing
+	MinTypo       float64 // one transposed letter
+	MaxUnrelated  float64 // unrelated texts, upper bound
+	MinUnrelated  float64 // unrelated texts, lower bound (≈0.5 is orthogonal; far below is anti-correlated)
+}
+
+// DefaultLimits suit the built-in n-gram encoder and MiniLM at 10k dims.
+func DefaultLimits() Limits {
+	return Limits{MinInvariance: 0.99, MinTypo: 0.75, MaxUnrelated: 0.65, MinUnrelated: 0.35}
+}
+
+// DefaultTexts are mutually unrelated sentences used as probes.
+var DefaultTexts = []string{
+	"what is the capital of india",
+	"how do you bake a chocolate cake",
+	"reset my account password",
+	"weather forecast for tomorrow in paris",
+	"explain how photosynthesis works",
+	"best running shoes for flat feet",
+	"convert fifty dollars to euros",
+	"who wrote the novel war and peace",
+}
+
+type config struct {
+	texts  []string
+	limits Limits
+}
+
+type Option func(*config)
+
+// WithTexts replaces DefaultTexts. Texts must be unrelated to each other,
+// since every pair is used for the orthogonality check, and at least two.
+func WithTexts(texts ...string) Option { return func(c *config) { c.texts = texts } }
+
+func WithLimits(l Limits) Option { return func(c *config) { c.limits = l } }
+
+// Check is the outcome of one property check.
+type Check struct {
+	Name    string
+	Pass    bool
+	Worst   float64 // worst similarity observed (or 0/1 for yes/no checks)
+	Limit   float64
+	Example string // input that produced Worst
+}
+
+type Report struct {
+	Dims   int
+	Checks []Check
+}
+
+// Passed reports whether every check passed.
+func (r *Report) Passed() bool {
+	for _, c := range r.Checks {
+		if !c.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns the failing checks.
+func (r *Report) Failed() []Check {
+	var out []Check
+	for _, c := range r.Checks {
+		if !c.Pass {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Run encodes the probe texts and variants of them and reports each check.
+// Panics if fewer than two texts are configured.
+func Run(enc hdc.Encoder, opts ...Option) *Report {
+	cfg := config{texts: DefaultTexts, limits: DefaultLimits()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if len(cfg.texts) < 2 {
+		panic("selftest: need at least two texts")
+	}
+	lim := cfg.limits
+
+	vecs := make([]hdc.Vector, len(cfg.texts))
+	for i, t := range cfg.texts {
+		vecs[i] = enc.Encode(t)
+	}
+	rep := &Report{Dims: vecs[0].Dims()}
+
+	rep.Checks = append(rep.Checks, checkDims(enc, cfg.texts, vecs), checkDegenerate(cfg.texts, vecs))
+	if !rep.Checks[0].Pass {
+		return rep // similarity panics on mismatched dims; nothing else is meaningful
+	}
+
+	rep.Checks = append(rep.Checks,
+		minCheck("determinism", 1, cfg.texts, vecs, enc, func(s string) string { return s }),
+	)
+	if lim.MinInvariance > 0 {
+		rep.Checks = append(rep.Checks,
+			minCheck("case-invariance", lim.MinInvariance, cfg.texts, vecs, enc, strings.ToUpper),
+			minCheck("whitespace-invariance", lim.MinInvariance, cfg.texts, vecs, enc, spaced),
+		)
+	}
+	if lim.MinTypo > 0 {
+		rep.Checks = append(rep.Checks, minCheck("typo-tolerance", lim.MinTypo, cfg.texts, vecs, enc, typo))
+	}
+	if lim.MaxUnrelated > 0 || lim.MinUnrelated > 0 {
+		rep.Checks = append(rep.Checks, checkUnrelated(lim, cfg.texts, vecs)...)
+	}
+	return rep
+}
+
+func checkDims(enc hdc.Encoder, texts []string, vecs []hdc.Vector) Check {
+	c := Check{Name: "dims", Pass: true, Worst: 1, Limit: 1}
+	want := vecs[0].Dims()
+	probes := append([]string{"", "x", strings.Repeat(texts[0]+" ", 40)}, texts...)
+	for _, t := range probes {
+		if d := enc.Encode(t).Dims(); d != want || d == 0 {
+			return Check{Name: "dims", Worst: 0, Limit: 1, Example: fmt.Sprintf("%q: %d dims, want %d", truncate(t), d, want)}
+		}
+	}
+	return c
+}
+
+// checkDegenerate fails on all-zero or all-one vectors and on distinct texts
+// that encode identically.
+func checkDegenerate(texts []string, vecs []hdc.Vector) Check {
+	seen := make(map[string]string, len(vecs))
+	for i, v := range vecs {
+		var ones int
+		for _, w := range v.RawData() {
+			ones += bits.OnesCount64(w)
+		}
+		if ones == 0 || ones == v.Dims() {
+			return Check{Name: "non-degenerate", Limit: 1, Example: fmt.Sprintf("%q: %d of %d bits set", texts[i], ones, v.Dims())}
+		}
+		k := fmt.Sprint(v.RawData())
+		if prev, dup := seen[k]; dup && prev != texts[i] {
+			return Check{Name: "non-degenerate", Limit: 1, Example: fmt.Sprintf("%q and %q encode identically", prev, texts[i])}
+		}
+		seen[k] = texts[i]
+	}
+	return Check{Name: "non-degenerate", Pass: true, Worst: 1, Limit: 1}
+}
+
+// minCheck requires Similarity(text, variant(text)) >= limit for every text.
+func minCheck(name string, limit float64, texts []string, vecs []hdc.Vector, enc hdc.Encoder, variant func(string) string) Check {
+	c := Check{Name: name, Worst: 1, Limit: limit}
+	for i, t := range texts {
+		v := variant(t)
+		if s := hdc.Similarity(vecs[i], enc.Encode(v)); s < c.Worst {
+			c.Worst, c.Example = s, fmt.Sprintf("%q vs %q", t, v)
+		}
+	}
+	c.Pass = c.Worst >= limit
+	return c
+}
+
+func checkUnrelated(lim Limits, texts []string, vecs []hdc.Vector) []Check {
+	hi := Check{Name: "orthogonality-max", Worst: 0, Limit: lim.MaxUnrelated}
+	lo := Check{Name: "orthogonality-min", Worst: 1, Limit: lim.MinUnrelated}
+	for i := range vecs {
+		for j := i + 1; j < len(vecs); j++ {
+			s := hdc.Similarity(vecs[i], vecs[j])
+			ex := fmt.Sprintf("%q vs %q", texts[i], texts[j])
+			if s > hi.Worst {
+				hi.Worst, hi.Example = s, ex
+			}
+			if s < lo.Worst {
+				lo.Worst, lo.Example = s, ex
+			}
+		}
+	}
+	var out []Check
+	if lim.MaxUnrelated > 0 {
+		hi.Pass = hi.Worst <= lim.MaxUnrelated
+		out = append(out, hi)
+	}
+	if lim.MinUnrelated > 0 {
+		lo.Pass = lo.Worst >= lim.MinUnrelated
+		out = append(out, lo)
+	}
+	return out
+}
+
+// spaced pads text and doubles every space.
+func spaced(s string) string { return "  " + strings.ReplaceAll(s, " ", "  ") + " " }
+
+// typo swaps two adjacent letters in the middle of the longest word.
+func typo(s string) string {
+	words := strings.Fields(s)
+	best := 0
+	for i, w := range words {
+		if len(w) > len(words[best]) {
+			best = i
+		}
+	}
+	w := []byte(words[best])
+	if len(w) < 4 {
+		return s
+	}
+	m := len(w) / 2
+	w[m-1], w[m] = w[m], w[m-1]
+	words[best] = string(w)
+	return strings.Join(words, " ")
+}
+
+func truncate(s string) string {
+	if len(s) > 40 {
+		return s[:40] + "…"
+	}
+	return s
+}
+
+// WriteText prints one line per check.
+func (r *Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "encoder self-test (%d dims)\n", r.Dims)
+	for _, c := range r.Checks {
+		status := "ok  "
+		if !c.Pass {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "  %s %-22s worst %.4f  limit %.4f", status, c.Name, c.Worst, c.Limit)
+		if !c.Pass && c.Example != "" {
+			fmt.Fprintf(w, "  (%s)", c.Example)
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,100 @@
+package selftest_test
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/selftest"
+)
+
+func ngram() hdc.Encoder {
+	return hdc.NewNGramEncoder(hdc.Config{Dims: 10000, NGramSize: 3, LongTextThresh: 200, ChunkSize: 128})
+}
+
+// encoderFunc adapts a function to hdc.Encoder.
+type encoderFunc func(string) hdc.Vector
+
+func (f encoderFunc) Encode(s string) hdc.Vector { return f(s) }
+
+func failed(rep *selftest.Report) []string {
+	var names []string
+	for _, c := range rep.Failed() {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestRun_NGramPasses(t *testing.T) {
+	rep := selftest.Run(ngram())
+	if !rep.Passed() {
+		var buf bytes.Buffer
+		rep.WriteText(&buf)
+		t.Fatalf("built-in encoder must pass:\n%s", buf.String())
+	}
+	if rep.Dims != 10000 || len(rep.Checks) != 8 {
+		t.Fatalf("got dims=%d checks=%d", rep.Dims, len(rep.Checks))
+	}
+}
+
+func TestRun_NonDeterministic(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	enc := encoderFunc(func(string) hdc.Vector {
+		words := make([]uint64, hdc.NumWords(1024))
+		for i := range words {
+			words[i] = rng.Uint64()
+		}
+		return hdc.FromWords(1024, words)
+	})
+	got := failed(selftest.Run(enc))
+	if len(got) == 0 || got[0] != "determinism" {
+		t.Fatalf("random encoder must fail determinism first, failed %v", got)
+	}
+}
+
+func TestRun_CaseSensitive(t *testing.T) {
+	base := ngram()
+	enc := encoderFunc(func(s string) hdc.Vector {
+		if s != strings.ToLower(s) {
+			return base.Encode("upper " + strings.ToLower(s) + " upper upper")
+		}
+		return base.Encode(s)
+	})
+	if got := failed(selftest.Run(enc)); len(got) != 1 || got[0] != "case-invariance" {
+		t.Fatalf("want only case-invariance to fail, failed %v", got)
+	}
+
+	// zero limit disables the invariance checks
+	lim := selftest.DefaultLimits()
+	lim.MinInvariance = 0
+	if rep := selftest.Run(enc, selftest.WithLimits(lim)); !rep.Passed() {
+		t.Fatalf("disabled check must not fail, failed %v", failed(rep))
+	}
+}
+
+func TestRun_ConstantVector(t *testing.T) {
+	v := hdc.New(1024)
+	rep := selftest.Run(encoderFunc(func(string) hdc.Vector { return v }))
+	if got := failed(rep); len(got) == 0 || got[0] != "non-degenerate" {
+		t.Fatalf("zero vectors must fail non-degenerate, failed %v", got)
+	}
+}
+
+func TestRun_WrongDims(t *testing.T) {
+	base := ngram()
+	enc := encoderFunc(func(s string) hdc.Vector {
+		if s == "" {
+			return hdc.New(64)
+		}
+		return base.Encode(s)
+	})
+	rep := selftest.Run(enc, selftest.WithTexts("alpha beta", "gamma delta"))
+	if rep.Passed() || rep.Checks[0].Name != "dims" || rep.Checks[0].Pass {
+		t.Fatalf("want dims failure, got %+v", rep.Checks)
+	}
+	if len(rep.Checks) != 2 {
+		t.Fatal("similarity checks must be skipped after a dims failure")
+	}
+}
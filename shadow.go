@@ -0,0 +1,107 @@
+package xordb
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ShadowStats compares a Shadow's candidate against its primary over the
+// lookups mirrored so far. A lookup agrees when both miss, or both hit and
+// return equal values (compared with reflect.DeepEqual).
+type ShadowStats struct {
+	Lookups       uint64
+	BothHit       uint64 // both hit with equal values
+	BothMiss      uint64
+	PrimaryOnly   uint64 // primary hit, candidate missed
+	CandidateOnly uint64 // candidate hit, primary missed
+	ValueMismatch uint64 // both hit, different values
+}
+
+// Agreement is the fraction of lookups where the candidate matched the
+// primary. Zero when no lookups have been made.
+func (s ShadowStats) Agreement() float64 {
+	if s.Lookups == 0 {
+		return 0
+	}
+	return float64(s.BothHit+s.BothMiss) / float64(s.Lookups)
+}
+
+// Shadow serves reads and writes from a primary DB while mirroring every
+// Get, Set and Delete to a candidate — typically one with a different
+// encoder or threshold — and recording how often the two agree. Callers only
+// ever see the primary's results, so a candidate can be trialled against
+// live traffic without affecting it. Safe for concurrent use.
+type Shadow struct {
+	primary   *DB
+	candidate *DB
+
+	mu    sync.Mutex
+	stats ShadowStats
+}
+
+// NewShadow panics if either DB is nil or both are the same DB.
+func NewShadow(primary, candidate *DB) *Shadow {
+	if primary == nil || candidate == nil {
+		panic("xordb: NewShadow requires two DBs")
+	}
+	if primary == candidate {
+		panic("xordb: NewShadow primary and candidate must differ")
+	}
+	return &Shadow{primary: primary, candidate: candidate}
+}
+
+func (s *Shadow) Primary() *DB   { return s.primary }
+func (s *Shadow) Candidate() *DB { return s.candidate }
+
+func (s *Shadow) Set(key string, value any) {
+	s.primary.Set(key, value)
+	s.candidate.Set(key, value)
+}
+
+func (s *Shadow) SetWithTTL(key string, value any, ttl time.Duration) {
+	s.primary.SetWithTTL(key, value, ttl)
+	s.candidate.SetWithTTL(key, value, ttl)
+}
+
+// Get returns the primary's result after comparing it with the candidate's.
+func (s *Shadow) Get(key string) (any, bool, float64) {
+	v, ok, sim := s.primary.Get(key)
+	cv, cok, _ := s.candidate.Get(key)
+
+	s.mu.Lock()
+	s.stats.Lookups++
+	switch {
+	case ok && cok && reflect.DeepEqual(v, cv):
+		s.stats.BothHit++
+	case ok && cok:
+		s.stats.ValueMismatch++
+	case ok:
+		s.stats.PrimaryOnly++
+	case cok:
+		s.stats.CandidateOnly++
+	default:
+		s.stats.BothMiss++
+	}
+	s.mu.Unlock()
+	return v, ok, sim
+}
+
+// Delete reports whether the key existed in the primary.
+func (s *Shadow) Delete(key string) bool {
+	s.candidate.Delete(key)
+	return s.primary.Delete(key)
+}
+
+func (s *Shadow) Stats() ShadowStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// ResetStats zeroes the comparison counters, e.g. after warming both DBs.
+func (s *Shadow) ResetStats() {
+	s.mu.Lock()
+	s.stats = ShadowStats{}
+	s.mu.Unlock()
+}
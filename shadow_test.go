@@ -0,0 +1,66 @@
+package xordb_test
+
+import (
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+// ── Shadow ────────────────────────────────────────────────────────────────────
+
+func TestShadow_ServesPrimaryAndCounts(t *testing.T) {
+	primary := xordb.New(xordb.WithThreshold(0.70))
+	candidate := xordb.New(xordb.WithThreshold(0.99)) // stricter: misses paraphrases
+	s := xordb.NewShadow(primary, candidate)
+
+	s.Set("what is the capital of india", "Delhi")
+	if candidate.Len() != 1 {
+		t.Fatal("Set must be mirrored to the candidate")
+	}
+
+	if v, ok, _ := s.Get("what is the capital of india"); !ok || v != "Delhi" {
+		t.Fatalf("exact lookup: %v %v", v, ok)
+	}
+	if v, ok, _ := s.Get("capital city of india"); !ok || v != "Delhi" {
+		t.Fatalf("shadow must return the primary's hit, got %v %v", v, ok)
+	}
+	s.Get("how to bake a chocolate cake")
+
+	st := s.Stats()
+	want := xordb.ShadowStats{Lookups: 3, BothHit: 1, BothMiss: 1, PrimaryOnly: 1}
+	if st != want {
+		t.Fatalf("stats: want %+v, got %+v", want, st)
+	}
+	if a := st.Agreement(); a < 0.66 || a > 0.67 {
+		t.Fatalf("agreement: want 2/3, got %f", a)
+	}
+
+	if !s.Delete("what is the capital of india") || candidate.Len() != 0 {
+		t.Fatal("Delete must apply to both DBs")
+	}
+	s.ResetStats()
+	if s.Stats() != (xordb.ShadowStats{}) {
+		t.Fatal("ResetStats must zero counters")
+	}
+}
+
+func TestShadow_ValueMismatch(t *testing.T) {
+	primary, candidate := xordb.New(), xordb.New()
+	s := xordb.NewShadow(primary, candidate)
+	primary.Set("k", 1)
+	candidate.Set("k", 2)
+	s.Get("k")
+	if st := s.Stats(); st.ValueMismatch != 1 || st.Agreement() != 0 {
+		t.Fatalf("got %+v", st)
+	}
+}
+
+func TestNewShadow_SameDB_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	db := xordb.New()
+	xordb.NewShadow(db, db)
+}
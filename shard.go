@@ -0,0 +1,242 @@
+package xordb
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+const (
+	shardManifestName    = "manifest.json"
+	shardManifestFormat  = "xrdb-sharded"
+	shardManifestVersion = 1
+)
+
+// shardManifest lists the segment files of a sharded snapshot. Each segment
+// is a complete binary snapshot of consecutive entries; loading them in
+// order restores the LRU order. Encoder is the hex fingerprint of the
+// encoder that wrote it, omitted if unknown, and CRC the CRC-32C of the
+// manifest's compact JSON with CRC zero.
+type shardManifest struct {
+	Format  string      `json:"format"`
+	Version int         `json:"version"`
+	Dims    int         `json:"dims"`
+	Encoder string      `json:"encoder,omitempty"`
+	Shards  []shardFile `json:"shards"`
+	CRC     uint32      `json:"crc"`
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+func (m shardManifest) checksum() uint32 {
+	m.CRC = 0
+	b, _ := json.Marshal(m)
+	return crc32.Checksum(b, castagnoli)
+}
+
+type shardFile struct {
+	File    string `json:"file"`
+	Entries int    `json:"entries"`
+}
+
+// SaveSharded writes a snapshot to directory dir as shards segment files
+// (0 = GOMAXPROCS) plus a manifest, encoding and writing the segments in
+// parallel. It is the format to use for caches of hundreds of thousands of
+// entries, where a single-threaded Save and Load dominate restart time.
+// The manifest is replaced atomically after every segment is synced, so a
+// crash mid-save leaves the previous sharded snapshot loadable; segments it
+// no longer references are removed afterwards. dir is created if needed.
+func (db *DB) SaveSharded(dir string, shards int) error {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("xordb: save sharded: %w", err)
+	}
+	snap := db.c.Snapshot()
+	parts := snap.Split(shards)
+
+	gen := time.Now().UnixNano()
+	m := shardManifest{
+		Format:  shardManifestFormat,
+		Version: shardManifestVersion,
+		Dims:    snap.Dims,
+		Shards:  make([]shardFile, len(parts)),
+	}
+	if fp := db.c.EncoderFingerprint(); fp != 0 {
+		m.Encoder = fmt.Sprintf("%016x", fp)
+	}
+	errs := make([]error, len(parts))
+	var wg sync.WaitGroup
+	for i, p := range parts {
+		m.Shards[i] = shardFile{File: fmt.Sprintf("shard-%d-%03d.xrdb", gen, i), Entries: len(p.Entries)}
+		wg.Add(1)
+		go func(i int, p cache.Snapshot) {
+			defer wg.Done()
+			errs[i] = db.writeShard(filepath.Join(dir, m.Shards[i].File), p)
+		}(i, p)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			for _, s := range m.Shards {
+				os.Remove(filepath.Join(dir, s.File))
+			}
+			return fmt.Errorf("xordb: save sharded: shard %d: %w", i, err)
+		}
+	}
+
+	m.CRC = m.checksum()
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("xordb: save sharded: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, shardManifestName), b); err != nil {
+		return fmt.Errorf("xordb: save sharded: manifest: %w", err)
+	}
+	removeStaleShards(dir, m)
+	db.snapID.Store(snap.ID)
+	return nil
+}
+
+// writeShard writes one segment and syncs it.
+func (db *DB) writeShard(path string, s cache.Snapshot) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := db.encodeSnapshot(f, s); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeFileAtomic writes data to a temp file in path's directory, syncs it
+// and renames it over path.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".xrdb-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// removeStaleShards deletes segment files in dir that m does not reference.
+func removeStaleShards(dir string, m shardManifest) {
+	keep := make(map[string]bool, len(m.Shards))
+	for _, s := range m.Shards {
+		keep[s.File] = true
+	}
+	names, _ := filepath.Glob(filepath.Join(dir, "shard-*.xrdb"))
+	for _, name := range names {
+		if !keep[filepath.Base(name)] {
+			os.Remove(name)
+		}
+	}
+}
+
+// LoadSharded merges a snapshot written by SaveSharded into the cache,
+// decoding the segments in parallel. Like Load, expired entries are skipped
+// and a missing manifest is reported as os.ErrNotExist (wrapped). Nothing
+// is loaded unless every segment decodes.
+func (db *DB) LoadSharded(dir string) error {
+	b, err := os.ReadFile(filepath.Join(dir, shardManifestName))
+	if err != nil {
+		return fmt.Errorf("xordb: load sharded: %w", err)
+	}
+	var m shardManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return fmt.Errorf("xordb: load sharded: manifest: %w", err)
+	}
+	if m.Format != shardManifestFormat || m.Version != shardManifestVersion {
+		return fmt.Errorf("xordb: load sharded: unsupported manifest %q version %d", m.Format, m.Version)
+	}
+	if crc := m.checksum(); crc != m.CRC {
+		return fmt.Errorf("xordb: load sharded: manifest CRC mismatch (file=%08x computed=%08x)", m.CRC, crc)
+	}
+	if m.Encoder != "" {
+		enc, err := strconv.ParseUint(m.Encoder, 16, 64)
+		if err != nil {
+			return fmt.Errorf("xordb: load sharded: manifest: encoder %q: %w", m.Encoder, err)
+		}
+		if fp := db.c.EncoderFingerprint(); fp != 0 && fp != enc {
+			return fmt.Errorf("xordb: load sharded: %w: encoder fingerprint %016x does not match manifest's %s", ErrIncompatibleSnapshot, fp, m.Encoder)
+		}
+	}
+	if m.Dims != db.c.Dims() {
+		return fmt.Errorf("xordb: load sharded: %w: %w", ErrIncompatibleSnapshot, &DimsError{What: "snapshot", Got: m.Dims, Want: db.c.Dims()})
+	}
+
+	parts := make([]cache.Snapshot, len(m.Shards))
+	errs := make([]error, len(m.Shards))
+	var wg sync.WaitGroup
+	for i, s := range m.Shards {
+		if s.File != filepath.Base(s.File) || strings.HasPrefix(s.File, ".") {
+			return fmt.Errorf("xordb: load sharded: invalid shard file %q", s.File)
+		}
+		wg.Add(1)
+		go func(i int, s shardFile) {
+			defer wg.Done()
+			parts[i], errs[i] = readShard(filepath.Join(dir, s.File), db.c.Dims())
+			if errs[i] == nil && len(parts[i].Entries) != s.Entries {
+				errs[i] = fmt.Errorf("%d entries, manifest says %d", len(parts[i].Entries), s.Entries)
+			}
+		}(i, s)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("xordb: load sharded: shard %d: %w", i, err)
+		}
+	}
+	snap := cache.JoinSnapshots(parts)
+	if err := db.cipher.openSnapshot(&snap); err != nil {
+		return fmt.Errorf("xordb: load sharded: %w", err)
+	}
+	if err := db.c.LoadSnapshot(snap); err != nil {
+		return fmt.Errorf("xordb: load sharded: %w", err)
+	}
+	return nil
+}
+
+func readShard(path string, dims int) (cache.Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return cache.Snapshot{}, err
+	}
+	defer f.Close()
+	return cache.DecodeSnapshot(f, dims)
+}
@@ -0,0 +1,120 @@
+package xordb_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func exportString(t *testing.T, db *xordb.DB) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := db.ExportJSONL(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestDB_SaveSharded_LoadSharded(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "snap")
+	src := xordb.New(xordb.WithCapacity(100))
+	for i := 0; i < 50; i++ {
+		src.Set(fmt.Sprintf("question number %d about topic %d", i, i*7), i)
+	}
+	src.Get("question number 3 about topic 21") // move to the front
+	if err := src.SaveSharded(dir, 4); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := filepath.Glob(filepath.Join(dir, "shard-*.xrdb")); len(names) != 4 {
+		t.Fatalf("want 4 segments, got %v", names)
+	}
+
+	dst := xordb.New(xordb.WithCapacity(100))
+	if err := dst.LoadSharded(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := exportString(t, dst), exportString(t, src); got != want {
+		t.Fatalf("sharded round trip changed entries or order:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestDB_SaveSharded_ReplacesSegments(t *testing.T) {
+	dir := t.TempDir()
+	db := xordb.New()
+	db.Set("what is the capital of india", "Delhi")
+	if err := db.SaveSharded(dir, 4); err != nil {
+		t.Fatal(err)
+	}
+	db.Set("how do I reset my password", "Settings")
+	if err := db.SaveSharded(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := filepath.Glob(filepath.Join(dir, "shard-*.xrdb")); len(names) != 2 {
+		t.Fatalf("stale segments must be removed, got %v", names)
+	}
+	dst := xordb.New()
+	if err := dst.LoadSharded(dir); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Len() != 2 {
+		t.Fatalf("want 2 entries, got %d", dst.Len())
+	}
+}
+
+func TestDB_LoadSharded_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	src := xordb.New()
+	for i := 0; i < 10; i++ {
+		src.Set(fmt.Sprintf("question %d", i), i)
+	}
+	if err := src.SaveSharded(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	names, _ := filepath.Glob(filepath.Join(dir, "shard-*.xrdb"))
+	b, _ := os.ReadFile(names[1])
+	b[len(b)-1] ^= 0xff
+	os.WriteFile(names[1], b, 0o644)
+
+	dst := xordb.New()
+	if err := dst.LoadSharded(dir); err == nil {
+		t.Fatal("corrupt segment must fail the load")
+	}
+	if dst.Len() != 0 {
+		t.Fatalf("failed load must not merge any segment, got %d entries", dst.Len())
+	}
+}
+
+func TestDB_LoadSharded_ManifestChecked(t *testing.T) {
+	dir := t.TempDir()
+	src := xordb.New()
+	src.Set("what is the capital of india", "Delhi")
+	if err := src.SaveSharded(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "manifest.json")
+	orig, _ := os.ReadFile(path)
+
+	os.WriteFile(path, bytes.Replace(orig, []byte(`"entries": 1`), []byte(`"entries": 2`), 1), 0o644)
+	if err := xordb.New().LoadSharded(dir); err == nil || !strings.Contains(err.Error(), "CRC") {
+		t.Fatalf("edited manifest must fail its CRC, got %v", err)
+	}
+
+	os.WriteFile(path, orig, 0o644)
+	other := xordb.New(xordb.WithNGramSize(4))
+	if err := other.LoadSharded(dir); !errors.Is(err, xordb.ErrIncompatibleSnapshot) {
+		t.Fatalf("another encoder's manifest must be incompatible, got %v", err)
+	}
+}
+
+func TestDB_LoadSharded_Missing(t *testing.T) {
+	err := xordb.New().LoadSharded(t.TempDir())
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("want os.ErrNotExist, got %v", err)
+	}
+}
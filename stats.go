@@ -0,0 +1,135 @@
+package xordb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// statsJSON is Stats under the JSON names MarshalJSON writes, the same as
+// cache.Stats's for the fields they share. The fields must stay in step
+// with Stats's, which the conversions check at compile time.
+type statsJSON struct {
+	Entries        int                         `json:"entries"`
+	Hits           uint64                      `json:"hits"`
+	ExactHits      uint64                      `json:"exact_hits"`
+	Misses         uint64                      `json:"misses"`
+	Sets           uint64                      `json:"sets"`
+	Expired        uint64                      `json:"expired"`
+	Evictions      uint64                      `json:"evictions"`
+	HitRate        float64                     `json:"hit_rate"`
+	AvgSimOnHit    float64                     `json:"avg_sim_on_hit"`
+	LSHCandidates  uint64                      `json:"lsh_candidates"`
+	LSHFallbacks   uint64                      `json:"lsh_fallbacks"`
+	Pruned         uint64                      `json:"pruned"`
+	Busy           uint64                      `json:"busy"`
+	Ambiguous      uint64                      `json:"ambiguous"`
+	DefiniteMisses uint64                      `json:"definite_misses"`
+	SoftMisses     uint64                      `json:"soft_misses"`
+	EarlyExits     uint64                      `json:"early_exits"`
+	HitSimilarity  [cache.NumSimBuckets]uint64 `json:"hit_similarity"`
+
+	EntryAllocs     uint64  `json:"entry_allocs"`
+	EntryReuses     uint64  `json:"entry_reuses"`
+	DedupShared     uint64  `json:"dedup_shared"`
+	DedupBytesSaved uint64  `json:"dedup_bytes_saved"`
+	ValuesShared    uint64  `json:"values_shared"`
+	ValueBytesSaved uint64  `json:"value_bytes_saved"`
+	ValueDedupRatio float64 `json:"value_dedup_ratio"`
+	EncodeTimeouts  uint64  `json:"encode_timeouts"`
+	EncodeCacheHits uint64  `json:"encode_cache_hits"`
+	EncodeErrors    uint64  `json:"encode_errors"`
+	Spooled         uint64  `json:"spooled"`
+	Oversize        uint64  `json:"oversize"`
+	Cold            int     `json:"cold"`
+	Faults          uint64  `json:"faults"`
+
+	NearMissQueued    uint64  `json:"near_miss_queued"`
+	NearMissDropped   uint64  `json:"near_miss_dropped"`
+	NearMissConfirmed uint64  `json:"near_miss_confirmed"`
+	QueryLogErrors    uint64  `json:"query_log_errors"`
+	FeedbackCorrect   uint64  `json:"feedback_correct"`
+	FeedbackWrong     uint64  `json:"feedback_wrong"`
+	EstPrecision      float64 `json:"est_precision"`
+
+	Latency struct {
+		Encode   Latency `json:"encode"`
+		LockWait Latency `json:"lock_wait"`
+		Scan     Latency `json:"scan"`
+	} `json:"latency"`
+
+	PerLookup struct {
+		Candidates Distribution `json:"candidates"`
+		Compared   Distribution `json:"compared"`
+	} `json:"per_lookup"`
+}
+
+// MarshalJSON writes s with snake_case names ("hit_rate",
+// "near_miss_queued") that do not change with the Go fields; durations
+// are in nanoseconds, under names ending in _ns. Dashboards and log
+// pipelines can rely on them.
+func (s Stats) MarshalJSON() ([]byte, error) { return json.Marshal(statsJSON(s)) }
+
+// UnmarshalJSON reads what MarshalJSON writes, e.g. xordb-serve's
+// /v1/stats.
+func (s *Stats) UnmarshalJSON(data []byte) error { return json.Unmarshal(data, (*statsJSON)(s)) }
+
+// String formats s for logs and terminals, like cache.Stats.String, with
+// lines for near-miss verification, the query log and feedback when they
+// were used.
+func (s Stats) String() string {
+	c := cache.Stats{
+		Entries:         s.Entries,
+		Hits:            s.Hits,
+		ExactHits:       s.ExactHits,
+		Misses:          s.Misses,
+		Sets:            s.Sets,
+		Expired:         s.Expired,
+		Evictions:       s.Evictions,
+		HitRate:         s.HitRate,
+		AvgSimOnHit:     s.AvgSimOnHit,
+		LSHCandidates:   s.LSHCandidates,
+		LSHFallbacks:    s.LSHFallbacks,
+		Pruned:          s.Pruned,
+		Busy:            s.Busy,
+		Ambiguous:       s.Ambiguous,
+		DefiniteMisses:  s.DefiniteMisses,
+		SoftMisses:      s.SoftMisses,
+		EarlyExits:      s.EarlyExits,
+		HitSimilarity:   s.HitSimilarity,
+		EntryAllocs:     s.EntryAllocs,
+		EntryReuses:     s.EntryReuses,
+		DedupShared:     s.DedupShared,
+		DedupBytesSaved: s.DedupBytesSaved,
+		ValuesShared:    s.ValuesShared,
+		ValueBytesSaved: s.ValueBytesSaved,
+		ValueDedupRatio: s.ValueDedupRatio,
+		EncodeTimeouts:  s.EncodeTimeouts,
+		EncodeCacheHits: s.EncodeCacheHits,
+		EncodeErrors:    s.EncodeErrors,
+		Spooled:         s.Spooled,
+		Oversize:        s.Oversize,
+		Cold:            s.Cold,
+		Faults:          s.Faults,
+	}
+	c.Latency.Encode = cache.Latency(s.Latency.Encode)
+	c.Latency.LockWait = cache.Latency(s.Latency.LockWait)
+	c.Latency.Scan = cache.Latency(s.Latency.Scan)
+	c.PerLookup.Candidates = cache.Distribution(s.PerLookup.Candidates)
+	c.PerLookup.Compared = cache.Distribution(s.PerLookup.Compared)
+
+	var b strings.Builder
+	b.WriteString(c.String())
+	if s.NearMissQueued > 0 || s.NearMissDropped > 0 {
+		fmt.Fprintf(&b, "\nnear misses: %d queued for verification, %d confirmed, %d dropped", s.NearMissQueued, s.NearMissConfirmed, s.NearMissDropped)
+	}
+	if s.QueryLogErrors > 0 {
+		fmt.Fprintf(&b, "\nquery log: %d failed writes", s.QueryLogErrors)
+	}
+	if labeled := s.FeedbackCorrect + s.FeedbackWrong; labeled > 0 {
+		fmt.Fprintf(&b, "\nfeedback: %d correct, %d wrong, est. precision %.1f%%", s.FeedbackCorrect, s.FeedbackWrong, 100*s.EstPrecision)
+	}
+	return b.String()
+}
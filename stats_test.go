@@ -0,0 +1,40 @@
+package xordb_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestStats_JSON(t *testing.T) {
+	db := xordb.New()
+	db.Set("what is the capital of india", "Delhi")
+	db.Get("what is the capital of india")
+	db.Feedback("what is the capital of india", "what is the capital of india", true)
+
+	data, err := json.Marshal(db.Stats())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{`"hits":1`, `"feedback_correct":1`, `"near_miss_queued":0`, `"p99_ns":`} {
+		if !strings.Contains(string(data), k) {
+			t.Errorf("JSON lacks %s: %s", k, data)
+		}
+	}
+	var back xordb.Stats
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back != db.Stats() {
+		t.Errorf("round trip: got %+v, want %+v", back, db.Stats())
+	}
+
+	s := db.Stats().String()
+	for _, want := range []string{"hits 1 (100.0%)", "feedback: 1 correct, 0 wrong, est. precision 100.0%"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("String() lacks %q:\n%s", want, s)
+		}
+	}
+}
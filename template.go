@@ -0,0 +1,104 @@
+package xordb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Template is a prompt template with {name} placeholders, e.g.
+// "Summarize: {doc}", for keys whose boilerplate would otherwise dominate
+// n-gram similarity: two summaries of unrelated documents share every
+// n-gram of "Summarize: " and can score above the threshold. Template keys
+// are multi-field keys (see KeyBuilder) of the placeholder values alone, so
+// keys from one template compare by their values only, and keys from
+// different templates do not match even with equal values. Write a literal
+// brace as {{ or }}.
+type Template struct {
+	text  string
+	names []string
+	re    *regexp.Regexp
+}
+
+// ParseTemplate parses a template. Placeholder names must be non-empty and
+// distinct, and two placeholders must not be adjacent, or a filled-in
+// prompt could not be split back into values.
+func ParseTemplate(text string) (*Template, error) {
+	t := &Template{text: text}
+	var pattern strings.Builder
+	pattern.WriteString(`^(?s)`)
+	seen := make(map[string]bool)
+	adjacent := false
+	for rest := text; rest != ""; {
+		i := strings.IndexAny(rest, "{}")
+		if i < 0 {
+			pattern.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		lit := rest[:i]
+		rest = rest[i:]
+		if strings.HasPrefix(rest, "{{") || strings.HasPrefix(rest, "}}") {
+			pattern.WriteString(regexp.QuoteMeta(lit + rest[:1]))
+			rest = rest[2:]
+			adjacent = false
+			continue
+		}
+		if rest[0] == '}' {
+			return nil, fmt.Errorf("xordb: template %q: unmatched }", text)
+		}
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return nil, fmt.Errorf("xordb: template %q: unclosed {", text)
+		}
+		name := rest[1:end]
+		rest = rest[end+1:]
+		switch {
+		case name == "" || strings.ContainsAny(name, "{"):
+			return nil, fmt.Errorf("xordb: template %q: bad placeholder {%s}", text, name)
+		case seen[name]:
+			return nil, fmt.Errorf("xordb: template %q: placeholder {%s} used twice", text, name)
+		case adjacent && lit == "":
+			return nil, fmt.Errorf("xordb: template %q: placeholders {%s} and {%s} are adjacent", text, t.names[len(t.names)-1], name)
+		}
+		seen[name] = true
+		t.names = append(t.names, name)
+		pattern.WriteString(regexp.QuoteMeta(lit))
+		pattern.WriteString(`(.*?)`)
+		adjacent = true
+	}
+	if len(t.names) == 0 {
+		return nil, fmt.Errorf("xordb: template %q has no placeholders", text)
+	}
+	pattern.WriteString(`$`)
+	t.re = regexp.MustCompile(pattern.String())
+	return t, nil
+}
+
+// String returns the template as parsed.
+func (t *Template) String() string { return t.text }
+
+// Key splits a filled-in prompt back into its values and returns their key;
+// ok is false if prompt does not follow the template.
+func (t *Template) Key(prompt string) (k *KeyBuilder, ok bool) {
+	m := t.re.FindStringSubmatch(prompt)
+	if m == nil {
+		return nil, false
+	}
+	values := make(map[string]string, len(t.names))
+	for i, name := range t.names {
+		values[name] = m[i+1]
+	}
+	return t.Fill(values), true
+}
+
+// Fill returns the key of the prompt the template gives with values; a
+// missing placeholder is empty. The key is stored under the quoted
+// template followed by the values as name="value" pairs. Use Weight on the result to weigh one
+// placeholder above another.
+func (t *Template) Fill(values map[string]string) *KeyBuilder {
+	k := &KeyBuilder{ns: t.text}
+	for _, name := range t.names {
+		k.Field(name, values[name])
+	}
+	return k
+}
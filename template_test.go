@@ -0,0 +1,72 @@
+package xordb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestParseTemplate_Errors(t *testing.T) {
+	for _, tc := range []struct{ tmpl, want string }{
+		{"no placeholders", "no placeholders"},
+		{"Summarize: {doc", "unclosed {"},
+		{"Summarize: doc}", "unmatched }"},
+		{"Summarize: {}", "bad placeholder"},
+		{"{a} and {a}", "used twice"},
+		{"{a}{b}", "adjacent"},
+	} {
+		_, err := xordb.ParseTemplate(tc.tmpl)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("ParseTemplate(%q) = %v, want %q", tc.tmpl, err, tc.want)
+		}
+	}
+}
+
+func TestTemplate_Key(t *testing.T) {
+	tmpl, err := xordb.ParseTemplate("Translate {text} to {lang}. Use {{formal}} style.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, ok := tmpl.Key("Translate good morning to French. Use {formal} style.")
+	if !ok {
+		t.Fatal("prompt following the template not matched")
+	}
+	want := `"Translate {text} to {lang}. Use {{formal}} style." lang="French" text="good morning"`
+	if k.String() != want {
+		t.Errorf("got  %s\nwant %s", k.String(), want)
+	}
+	if _, ok := tmpl.Key("Summarize: good morning"); ok {
+		t.Error("unrelated prompt matched")
+	}
+}
+
+func TestTemplate_ValuesDominate(t *testing.T) {
+	summarize, _ := xordb.ParseTemplate("Please write a concise, accurate summary of the following document for an executive audience:\n\n{doc}")
+	translate, _ := xordb.ParseTemplate("Translate the following into German:\n{doc}")
+	render := func(doc string) string {
+		return "Please write a concise, accurate summary of the following document for an executive audience:\n\n" + doc
+	}
+
+	// As plain strings the boilerplate makes unrelated documents match.
+	plain := xordb.New()
+	plain.Set(render("quarterly revenue grew"), "summary A")
+	if _, hit, _ := plain.Get(render("new office in berlin")); !hit {
+		t.Fatal("want the shared boilerplate to cause a false hit without templates")
+	}
+
+	db := xordb.New()
+	k, _ := summarize.Key(render("quarterly revenue grew"))
+	db.SetKey(k, "summary A")
+	k, _ = summarize.Key(render("new office in berlin"))
+	if _, hit, sim := db.GetKey(k); hit {
+		t.Errorf("unrelated document hit at %.3f", sim)
+	}
+	k, _ = summarize.Key(render("quarterly revenue grew!"))
+	if v, hit, _ := db.GetKey(k); !hit || v != "summary A" {
+		t.Errorf("same document: hit %v, value %v", hit, v)
+	}
+	if _, hit, sim := db.GetKey(translate.Fill(map[string]string{"doc": "quarterly revenue grew"})); hit {
+		t.Errorf("other template with the same value hit at %.3f", sim)
+	}
+}
@@ -0,0 +1,114 @@
+package xordb
+
+import (
+	"fmt"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+// Dims returns the vector dimension of the DB's encoder.
+func (db *DB) Dims() int { return db.c.Dims() }
+
+// EncoderFingerprint identifies the DB's encoder — model, seed, n-gram
+// size, dims — by hashing its vector for a fixed probe text, as snapshots
+// do. Two DBs score similarity alike only if their fingerprints match.
+func (db *DB) EncoderFingerprint() uint64 { return db.c.EncoderFingerprint() }
+
+// Provenance labels the DB's vectors: its EncoderFingerprint, 0 while a
+// WithEncoderLoader encoder loads, and its WithSeed seed. Tag vectors
+// built for SetVec with it (hdcx.WithProvenance) for CheckVec to check on
+// the way in.
+func (db *DB) Provenance() hdcx.Provenance {
+	return hdcx.Provenance{Encoder: db.EncoderFingerprint(), Seed: db.seed}
+}
+
+// CheckVec reports whether vec can be passed to SetVec or GetVec: it must
+// have Dims() dims, and its provenance must be compatible with the DB's.
+// Errors wrap hdcx.ErrIncompatible.
+func (db *DB) CheckVec(vec hdcx.TaggedVector) error {
+	return hdcx.CheckCompatible(vec, hdcx.WithProvenance(hdc.New(db.c.Dims()), db.Provenance()))
+}
+
+// SetVec stores value under key with a precomputed vector, bypassing the
+// encoder — for vectors from an external embedding pipeline or a batch
+// job, or keys that are not text at all; key only names the entry for
+// Delete, Pin and the like. vec must be in the
+// encoder's vector space (same projection and seed) for lookups of encoded
+// keys to match it, and must have Dims() dims.
+func (db *DB) SetVec(vec hdc.Vector, key string, value any) error {
+	if err := db.checkOpen(); err != nil {
+		return err
+	}
+	if err := db.checkDims(vec); err != nil {
+		return err
+	}
+	db.c.SetVec(db.key(key), vec, value, db.c.TTL())
+	return nil
+}
+
+// GetVec is Lookup for a precomputed query vector; see SetVec. It fails
+// only if vec has the wrong dims.
+func (db *DB) GetVec(vec hdc.Vector) (Result, error) {
+	if err := db.checkOpen(); err != nil {
+		return Result{}, err
+	}
+	if err := db.checkDims(vec); err != nil {
+		return Result{}, err
+	}
+	return result(db.c.LookupVec("", vec)), nil
+}
+
+// GetUsing is Lookup with key encoded by enc instead of the DB's encoder,
+// to probe the stored entries with another model: during a migration, it
+// tells whether MiniLM queries would hit in a cache filled by the n-gram
+// encoder, without a second cache. key is normalized as for Lookup; enc is
+// used as given (WithSeed does not apply) and must produce Dims() dims.
+// WithEncoderPanicRecovery and WithEncodeBudget guard enc as they do the
+// DB's encoder, but the fallback is not used: like Encode, GetUsing then
+// fails. The lookup counts in Stats and promotes what it hits, as Lookup
+// does, and is scored semantically only, without WithScoreFusion's word
+// overlap.
+func (db *DB) GetUsing(enc hdc.Encoder, key string) (Result, error) {
+	if err := db.checkOpen(); err != nil {
+		return Result{}, err
+	}
+	key = db.key(key)
+	vec, ok := db.c.EncodeWith(enc, key)
+	if !ok {
+		if err := db.LastEncodeError(); err != nil {
+			return Result{}, fmt.Errorf("xordb: get using: %w", err)
+		}
+		return Result{}, fmt.Errorf("xordb: get using: %w on %q: encode budget exceeded", ErrEncoderUnavailable, key)
+	}
+	if vec.Dims() != db.c.Dims() {
+		return Result{}, fmt.Errorf("xordb: get using: %w", &DimsError{What: "encoder", Got: vec.Dims(), Want: db.c.Dims()})
+	}
+	return result(db.c.LookupVec(key, vec)), nil
+}
+
+// Encode returns the vector the DB stores and looks up for key, after key
+// normalization, e.g. to check an encoder in place or to build vectors for
+// SetVec. It fails if the DB is closed, or if the encoder failed under
+// WithEncoderPanicRecovery (ErrEncoder) or overran WithEncodeBudget with no
+// fallback (ErrEncoderUnavailable).
+func (db *DB) Encode(key string) (hdc.Vector, error) {
+	if err := db.checkOpen(); err != nil {
+		return hdc.Vector{}, err
+	}
+	vec, ok := db.c.Encode(db.key(key))
+	if !ok {
+		if err := db.LastEncodeError(); err != nil {
+			return hdc.Vector{}, err
+		}
+		return hdc.Vector{}, fmt.Errorf("xordb: %w on %q: encode budget exceeded", ErrEncoderUnavailable, key)
+	}
+	return vec, nil
+}
+
+func (db *DB) checkDims(vec hdc.Vector) error {
+	if vec.Dims() != db.c.Dims() {
+		return fmt.Errorf("xordb: %w", &DimsError{What: "vector", Got: vec.Dims(), Want: db.c.Dims()})
+	}
+	return nil
+}
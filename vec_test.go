@@ -0,0 +1,140 @@
+package xordb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/hdcx"
+)
+
+func TestDB_SetVec(t *testing.T) {
+	db := xordb.New(xordb.WithDims(2048))
+	if db.Dims() != 2048 {
+		t.Fatalf("Dims: got %d", db.Dims())
+	}
+	v := hdc.Random(2048, 7)
+	if err := db.SetVec(v, "\x00\xffimage-hash", "cat.jpg"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := db.GetVec(v)
+	if err != nil || !r.Hit || r.Value != "cat.jpg" || r.MatchedKey != "\x00\xffimage-hash" {
+		t.Fatalf("got %+v, %v", r, err)
+	}
+	if r, _ := db.GetVec(hdc.Random(2048, 8)); r.Hit {
+		t.Fatalf("an unrelated vector must miss: %+v", r)
+	}
+
+	// precomputed vectors share the encoder's space
+	enc := hdc.NewNGramEncoder(hdc.Config{Dims: 2048, NGramSize: 3, LongTextThresh: 200, ChunkSize: 128})
+	if err := db.SetVec(enc.Encode("what is the capital of france"), "q1", "Paris"); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, _ := db.Get("what's the capital of france"); !ok || v != "Paris" {
+		t.Fatalf("text lookups must find SetVec entries: %v %v", v, ok)
+	}
+}
+
+func TestDB_SetVec_Dims(t *testing.T) {
+	db := xordb.New()
+	if err := db.SetVec(hdc.Random(64, 1), "k", 1); err == nil {
+		t.Fatal("SetVec must reject the wrong dims")
+	}
+	if _, err := db.GetVec(hdc.Random(64, 1)); err == nil {
+		t.Fatal("GetVec must reject the wrong dims")
+	}
+	if db.Len() != 0 {
+		t.Fatal("nothing must be stored")
+	}
+}
+
+func TestDB_Encode(t *testing.T) {
+	db := xordb.New(xordb.WithDims(2048))
+	v, err := db.Encode("what is the capital of france")
+	if err != nil || v.Dims() != 2048 {
+		t.Fatalf("Encode: %v dims, %v", v.Dims(), err)
+	}
+	db.SetVec(v, "q", "Paris")
+	if r := db.Lookup("what is the capital of france"); !r.Hit || r.Similarity != 1 {
+		t.Errorf("Encode's vector is not the lookup's: %+v", r)
+	}
+	db.Close(context.Background())
+	if _, err := db.Encode("q"); !errors.Is(err, xordb.ErrClosed) {
+		t.Errorf("Encode after Close: %v", err)
+	}
+}
+
+func TestDB_EncoderFingerprint(t *testing.T) {
+	a, b := xordb.New(xordb.WithDims(2048)), xordb.New(xordb.WithDims(2048))
+	if a.EncoderFingerprint() == 0 || a.EncoderFingerprint() != b.EncoderFingerprint() {
+		t.Errorf("same encoder: %x, %x", a.EncoderFingerprint(), b.EncoderFingerprint())
+	}
+	if c := xordb.New(xordb.WithDims(4096)); c.EncoderFingerprint() == a.EncoderFingerprint() {
+		t.Error("different dims, same fingerprint")
+	}
+}
+
+func TestDB_CheckVec(t *testing.T) {
+	a := xordb.New(xordb.WithSeed(1))
+	b := xordb.New(xordb.WithSeed(2))
+	if p := a.Provenance(); p.Seed != 1 || p.Encoder != a.EncoderFingerprint() {
+		t.Fatalf("Provenance: %v", p)
+	}
+	vec, err := b.Encode("what is the capital of india")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.CheckVec(hdcx.WithProvenance(vec, b.Provenance())); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.CheckVec(hdcx.WithProvenance(vec, b.Provenance())); !errors.Is(err, hdcx.ErrIncompatible) {
+		t.Fatalf("a vector of another seed: %v", err)
+	}
+	if err := a.CheckVec(hdcx.WithProvenance(vec, hdcx.Provenance{})); err != nil {
+		t.Fatalf("an untagged vector of the right dims: %v", err)
+	}
+}
+
+func TestDB_GetUsing(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.65))
+	db.Set("what is the capital of india", "Delhi")
+
+	same := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	r, err := db.GetUsing(same, "capital city of india")
+	if err != nil || !r.Hit || r.Value != "Delhi" {
+		t.Fatalf("the DB's own encoder: %+v, %v", r, err)
+	}
+
+	cfg := hdc.DefaultConfig()
+	cfg.NGramSize = 5
+	r, err = db.GetUsing(hdc.NewNGramEncoder(cfg), "capital city of india")
+	if err != nil || r.Hit {
+		t.Fatalf("another vector space must miss: %+v, %v", r, err)
+	}
+
+	cfg = hdc.DefaultConfig()
+	cfg.Dims = 2048
+	if _, err := db.GetUsing(hdc.NewNGramEncoder(cfg), "capital city of india"); err == nil {
+		t.Fatal("a dims mismatch must fail")
+	}
+}
+
+func TestDB_GetUsing_Guarded(t *testing.T) {
+	ngram := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	db := xordb.New(xordb.WithEncoderPanicRecovery(true))
+	if _, err := db.GetUsing(panicEncoder{}, "capital city of india"); !errors.Is(err, xordb.ErrEncoder) {
+		t.Fatalf("a panicking encoder must fail the lookup: %v", err)
+	}
+
+	db = xordb.New(xordb.WithEncodeBudget(10*time.Millisecond, ngram))
+	if _, err := db.GetUsing(slowEncoder{ngram}, "capital city of india"); !errors.Is(err, xordb.ErrEncoderUnavailable) {
+		t.Fatalf("an encoder over budget must fail the lookup: %v", err)
+	}
+	if s := db.Stats(); s.EncodeTimeouts != 1 {
+		t.Fatalf("%d encode timeouts, want 1", s.EncodeTimeouts)
+	}
+}
@@ -0,0 +1,132 @@
+package xordb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Embedder is implemented by encoders that compute a float embedding before
+// reducing it to a vector, e.g. embed.MiniLMEncoder. A DB built with one
+// can export its keys' embeddings for an exhaustive vector store alongside
+// the cache; see ExportPgvector and ExportFAISS.
+type Embedder interface {
+	Embed(text string) ([]float32, error)
+}
+
+var errNoEmbedder = errors.New("xordb: encoder does not implement Embedder")
+
+// KeyEmbedding is a key and its float embedding.
+type KeyEmbedding struct {
+	Key       string
+	Embedding []float32
+}
+
+// Embeddings embeds the key of every live entry, in order, with the DB's
+// encoder. Only vectors are stored, so every key runs through the model
+// again: expect it to take as long as setting the keys did. The keys are
+// the stored ones, after any key normalizer.
+func (db *DB) Embeddings(order Order) ([]KeyEmbedding, error) {
+	if err := db.checkOpen(); err != nil {
+		return nil, err
+	}
+	if db.emb == nil {
+		return nil, errNoEmbedder
+	}
+	es := db.c.Snapshot().Entries
+	sortEntries(es, order)
+	out := make([]KeyEmbedding, len(es))
+	for i, e := range es {
+		emb, err := db.emb.Embed(e.Key)
+		if err != nil {
+			return nil, fmt.Errorf("xordb: embed %q: %w", e.Key, err)
+		}
+		out[i] = KeyEmbedding{e.Key, emb}
+	}
+	return out, nil
+}
+
+// ExportPgvector writes Embeddings(OrderKey) in PostgreSQL's COPY text
+// format, one "key<TAB>[x,y,...]" row per entry, for a pgvector table:
+//
+//	CREATE TABLE cache (key text PRIMARY KEY, embedding vector(384));
+//	\copy cache (key, embedding) FROM 'cache.tsv'
+func (db *DB) ExportPgvector(w io.Writer) error {
+	embs, err := db.Embeddings(OrderKey)
+	if err != nil {
+		return err
+	}
+	return WritePgvector(w, embs)
+}
+
+// WritePgvector writes embs as ExportPgvector does.
+func WritePgvector(w io.Writer, embs []KeyEmbedding) error {
+	bw := bufio.NewWriter(w)
+	var row []byte
+	for _, e := range embs {
+		row = append(row[:0], copyEscaper.Replace(e.Key)...)
+		row = append(row, '\t', '[')
+		for i, x := range e.Embedding {
+			if i > 0 {
+				row = append(row, ',')
+			}
+			row = strconv.AppendFloat(row, float64(x), 'g', -1, 32)
+		}
+		row = append(row, ']', '\n')
+		bw.Write(row)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("xordb: export pgvector: %w", err)
+	}
+	return nil
+}
+
+// copyEscaper escapes the characters COPY's text format gives meaning to.
+var copyEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// ExportFAISS writes Embeddings(OrderKey) for FAISS: the embeddings to vecs
+// in the .fvecs format (per row, the dimension as a little-endian int32,
+// then that many float32s), and to keys the matching keys, one JSON string
+// per line, so that row i of an index built from vecs is line i of keys.
+// In Python:
+//
+//	d = 384
+//	x = np.fromfile("cache.fvecs", dtype="float32").reshape(-1, d + 1)[:, 1:]
+//	index = faiss.IndexFlatIP(d); index.add(x)
+func (db *DB) ExportFAISS(vecs, keys io.Writer) error {
+	embs, err := db.Embeddings(OrderKey)
+	if err != nil {
+		return err
+	}
+	return WriteFAISS(vecs, keys, embs)
+}
+
+// WriteFAISS writes embs as ExportFAISS does.
+func WriteFAISS(vecs, keys io.Writer, embs []KeyEmbedding) error {
+	bv, bk := bufio.NewWriter(vecs), bufio.NewWriter(keys)
+	enc := json.NewEncoder(bk)
+	var row []byte
+	for _, e := range embs {
+		row = binary.LittleEndian.AppendUint32(row[:0], uint32(len(e.Embedding)))
+		for _, x := range e.Embedding {
+			row = binary.LittleEndian.AppendUint32(row, math.Float32bits(x))
+		}
+		bv.Write(row)
+		if err := enc.Encode(e.Key); err != nil {
+			return fmt.Errorf("xordb: export faiss: %q: %w", e.Key, err)
+		}
+	}
+	if err := bv.Flush(); err != nil {
+		return fmt.Errorf("xordb: export faiss: %w", err)
+	}
+	if err := bk.Flush(); err != nil {
+		return fmt.Errorf("xordb: export faiss: %w", err)
+	}
+	return nil
+}
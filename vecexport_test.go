@@ -0,0 +1,62 @@
+package xordb_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+)
+
+// embedEncoder embeds a text as {its length, half its first byte}.
+type embedEncoder struct{ hdc.Encoder }
+
+func (embedEncoder) Embed(text string) ([]float32, error) {
+	return []float32{float32(len(text)), float32(text[0]) / 2}, nil
+}
+
+func newEmbedDB() *xordb.DB {
+	db := xordb.NewWithEncoder(embedEncoder{hdc.NewNGramEncoder(hdc.DefaultConfig())})
+	db.Set("b\tkey", 1)
+	db.Set("a", 2)
+	return db
+}
+
+func TestDB_ExportPgvector(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newEmbedDB().ExportPgvector(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "a\t[1,48.5]\nb\\tkey\t[5,49]\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestDB_ExportFAISS(t *testing.T) {
+	var vecs, keys bytes.Buffer
+	if err := newEmbedDB().ExportFAISS(&vecs, &keys); err != nil {
+		t.Fatal(err)
+	}
+	if keys.String() != "\"a\"\n\"b\\tkey\"\n" {
+		t.Errorf("keys %q", keys.String())
+	}
+	var got []float32
+	for b := vecs.Bytes(); len(b) >= 4; b = b[4:] {
+		got = append(got, math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+	if len(got) != 6 || math.Float32bits(got[0]) != 2 || got[1] != 1 || got[2] != 48.5 ||
+		math.Float32bits(got[3]) != 2 || got[4] != 5 || got[5] != 49 {
+		t.Errorf("fvecs %v", got)
+	}
+}
+
+func TestDB_Embeddings_NoEmbedder(t *testing.T) {
+	_, err := xordb.New().Embeddings(xordb.OrderKey)
+	if err == nil || !strings.Contains(err.Error(), "Embedder") {
+		t.Errorf("err = %v", err)
+	}
+}
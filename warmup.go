@@ -0,0 +1,228 @@
+package xordb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb/cache"
+)
+
+// ErrWarmingUp is returned while a WithEncoderLoader encoder is still
+// loading by SetEmbedding, GetByEmbedding, Embeddings and the vector exports,
+// and by Load and the other load methods for snapshots that record their
+// encoder, which cannot be checked yet. It is an ErrEncoderUnavailable.
+var ErrWarmingUp = cache.ErrPending
+
+// WithEncoderLoader has New or NewAsync build the encoder by calling load,
+// e.g. to read a MiniLM model, instead of using the built-in n-gram one:
+//
+//	xordb.NewAsync(xordb.WithEncoderLoader(func(ctx context.Context) (hdc.Encoder, error) {
+//		return embed.NewMiniLMEncoder(embed.WithBinaryDims(10000))
+//	}))
+//
+// Its vectors must have WithDims dims (default 10000); WithSeed applies to
+// it as in NewWithEncoder. Until it returns, keys are encoded by the
+// WithEncodeBudget fallback if there is one, and otherwise Sets are dropped
+// and lookups miss. It cannot be combined with NewWithEncoder,
+// WithLanguageRouting or WithLateInteraction.
+func WithEncoderLoader(load func(ctx context.Context) (hdc.Encoder, error)) Option {
+	return func(o *dbOptions) { o.encoderLoader = load }
+}
+
+// WithWarmup runs fn once the encoder is ready, to fill the new DB — load a
+// snapshot, replay a seed file — before WaitForWarmup returns. New and
+// NewWithEncoder run it before returning; NewAsync in the background, while
+// the DB already serves what is loaded so far. ctx is cancelled by Close.
+func WithWarmup(fn func(ctx context.Context, db *DB) error) Option {
+	return func(o *dbOptions) { o.warmup = fn }
+}
+
+// NewAsync is New for services that cannot wait on their cache to start: it
+// returns at once, and the WithEncoderLoader encoder and the WithWarmup
+// work are done in the background. The DB is usable from the start —
+// without the encoder, lookups miss — and WaitForWarmup reports when it is
+// ready; a readiness probe can wait on it. Without either option NewAsync
+// is New.
+func NewAsync(opts ...Option) *DB {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o.build(true)
+}
+
+// warmup tracks the background part of construction.
+type warmup struct {
+	done   chan struct{} // closed when finished
+	err    error         // set before done is closed
+	cancel context.CancelFunc
+}
+
+// WaitForWarmup waits until the encoder is loaded and the WithWarmup work
+// is done, and returns their error, or ctx's if it is done first. It
+// returns nil at once for a DB without either. A DB whose warmup failed
+// keeps working: with the n-gram encoder, or without one, missing.
+func (db *DB) WaitForWarmup(ctx context.Context) error {
+	if db.warming == nil {
+		return nil
+	}
+	select {
+	case <-db.warming.done:
+		return db.warming.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// build creates the DB of New or NewAsync and runs its warmup, in the
+// background if async.
+func (o *dbOptions) build(async bool) *DB {
+	if o.encoderLoader == nil && o.warmup == nil {
+		return newDB(o.ngramEncoder(), *o)
+	}
+	var enc hdc.Encoder
+	var lazy *lazyEncoder
+	if o.encoderLoader != nil {
+		lazy = &lazyEncoder{dims: o.dims, done: make(chan struct{})}
+		enc = lazy
+		o.encodeFallback = seeded(o.encodeFallback, o.seed)
+	} else {
+		enc = o.ngramEncoder()
+	}
+	db := newDB(enc, *o)
+	db.startWarmup(*o, lazy, async)
+	return db
+}
+
+// startWarmup loads lazy, if any, and runs the WithWarmup function.
+func (db *DB) startWarmup(o dbOptions, lazy *lazyEncoder, async bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &warmup{done: make(chan struct{}), cancel: cancel}
+	db.warming = w
+	run := func() {
+		defer close(w.done)
+		if lazy != nil {
+			if w.err = lazy.load(ctx, o); w.err != nil {
+				return
+			}
+		}
+		if o.warmup != nil {
+			if err := o.warmup(ctx, db); err != nil {
+				w.err = fmt.Errorf("xordb: warmup: %w", err)
+			}
+		}
+	}
+	if async {
+		go run()
+	} else {
+		run()
+	}
+}
+
+// stopWarmup cancels a running warmup and waits for it until ctx is done.
+func (db *DB) stopWarmup(ctx context.Context) error {
+	if db.warming == nil {
+		return nil
+	}
+	db.warming.cancel()
+	select {
+	case <-db.warming.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("xordb: close: warmup: %w", ctx.Err())
+	}
+}
+
+// lazyEncoder stands in for a WithEncoderLoader encoder until it is loaded;
+// it is a cache.Pending, so the cache leaves it alone until Ready.
+type lazyEncoder struct {
+	dims  int
+	done  chan struct{} // closed when the load finished, well or not
+	ready atomic.Bool   // enc is set
+
+	mu     sync.Mutex
+	enc    hdc.Encoder
+	closed bool
+}
+
+func (l *lazyEncoder) Dims() int   { return l.dims }
+func (l *lazyEncoder) Ready() bool { return l.ready.Load() }
+
+// Encode waits for the load; the cache only calls it once Ready, but a
+// fingerprint or a wrapper may come earlier. After a failed load it returns
+// zero vectors.
+func (l *lazyEncoder) Encode(text string) hdc.Vector {
+	<-l.done
+	if !l.ready.Load() {
+		return hdc.New(l.dims)
+	}
+	return l.enc.Encode(text)
+}
+
+// load calls the loader, checks what it returns and installs it, unless
+// the DB was closed meanwhile.
+func (l *lazyEncoder) load(ctx context.Context, o dbOptions) error {
+	defer close(l.done)
+	enc, err := o.encoderLoader(ctx)
+	if err != nil {
+		return fmt.Errorf("xordb: %w: loader failed: %w", ErrEncoderUnavailable, err)
+	}
+	if enc == nil {
+		return fmt.Errorf("xordb: %w: loader returned a nil encoder", ErrEncoderUnavailable)
+	}
+	enc = seeded(enc, o.seed)
+	if d := enc.Encode(dimsProbe).Dims(); d != l.dims {
+		closeEncoder(enc)
+		return fmt.Errorf("xordb: %w: %w", ErrEncoderUnavailable, &DimsError{What: "loaded encoder", Got: d, Want: l.dims})
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		closeEncoder(enc)
+		return ErrClosed
+	}
+	l.enc = enc
+	l.ready.Store(true)
+	return nil
+}
+
+func (l *lazyEncoder) ProjectEmbedding(emb []float32) (hdc.Vector, error) {
+	if !l.ready.Load() {
+		return hdc.Vector{}, ErrWarmingUp
+	}
+	p, ok := l.enc.(EmbeddingProjector)
+	if !ok {
+		return hdc.Vector{}, errNoProjector
+	}
+	return p.ProjectEmbedding(emb)
+}
+
+func (l *lazyEncoder) Embed(text string) ([]float32, error) {
+	if !l.ready.Load() {
+		return nil, ErrWarmingUp
+	}
+	e, ok := l.enc.(Embedder)
+	if !ok {
+		return nil, errNoEmbedder
+	}
+	return e.Embed(text)
+}
+
+// Close closes the loaded encoder, or the one a running load returns.
+func (l *lazyEncoder) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	return closeEncoder(l.enc)
+}
+
+func closeEncoder(enc hdc.Encoder) error {
+	if c, ok := enc.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package xordb_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amansingh-afk/hdc-go"
+	"github.com/Amansingh-afk/xordb"
+)
+
+func TestNewAsync_EncoderLoader(t *testing.T) {
+	release := make(chan struct{})
+	enc := &closingEncoder{Encoder: hdc.NewNGramEncoder(hdc.DefaultConfig())}
+	db := xordb.NewAsync(xordb.WithThreshold(0.65), xordb.WithEncoderLoader(func(ctx context.Context) (hdc.Encoder, error) {
+		<-release
+		return enc, nil
+	}))
+
+	db.Set("what is the capital of india", "Delhi") // dropped: no encoder yet
+	if _, ok, _ := db.Get("what is the capital of india"); ok {
+		t.Fatal("hit before the encoder loaded")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := db.WaitForWarmup(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForWarmup while loading: %v", err)
+	}
+	if err := db.Save(filepath.Join(t.TempDir(), "early.snap")); err != nil {
+		t.Fatalf("Save while loading: %v", err)
+	}
+
+	close(release)
+	if err := db.WaitForWarmup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	db.Set("what is the capital of india", "Delhi")
+	if v, ok, _ := db.Get("capital city of india"); !ok || v != "Delhi" {
+		t.Fatalf("after warmup: %v, %v", v, ok)
+	}
+	if err := db.Close(context.Background()); err != nil || enc.closed != 1 {
+		t.Fatalf("Close: %v, encoder closed %d times", err, enc.closed)
+	}
+}
+
+func TestNewAsync_Warmup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.snap")
+	src := xordb.New()
+	src.Set("how do I reset my password", "Settings > Security")
+	if err := src.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// The built-in encoder is ready at once, so the fallback-free DB
+	// serves Sets while the snapshot loads.
+	db := xordb.NewAsync(xordb.WithWarmup(func(ctx context.Context, db *xordb.DB) error {
+		return db.Load(path)
+	}))
+	if err := db.WaitForWarmup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, _ := db.Get("how do i reset my password?"); !ok || v != "Settings > Security" {
+		t.Fatalf("got %v, %v", v, ok)
+	}
+
+	sync := xordb.New(xordb.WithWarmup(func(ctx context.Context, db *xordb.DB) error {
+		return db.Load(filepath.Join(t.TempDir(), "missing.snap"))
+	}))
+	if err := sync.WaitForWarmup(context.Background()); err == nil || !strings.HasPrefix(err.Error(), "xordb: warmup: ") {
+		t.Fatalf("New must run the warmup and keep its error, got %v", err)
+	}
+}
+
+func TestNewAsync_LoaderErrors(t *testing.T) {
+	for name, tt := range map[string]struct {
+		load func(context.Context) (hdc.Encoder, error)
+		want string
+	}{
+		"error": {func(context.Context) (hdc.Encoder, error) { return nil, errors.New("no model") }, "no model"},
+		"dims": {func(context.Context) (hdc.Encoder, error) {
+			cfg := hdc.DefaultConfig()
+			cfg.Dims = 512
+			return hdc.NewNGramEncoder(cfg), nil
+		}, "512 dims, want 10000"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := xordb.NewAsync(xordb.WithEncoderLoader(tt.load))
+			err := db.WaitForWarmup(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got %v, want %q", err, tt.want)
+			}
+			db.Set("key", 1)
+			if _, ok, _ := db.Get("key"); ok {
+				t.Fatal("a DB without an encoder must miss")
+			}
+		})
+	}
+}
+
+func TestNewAsync_FallbackWhileLoading(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	ngram := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	db := xordb.NewAsync(
+		xordb.WithThreshold(0.65),
+		xordb.WithEncodeBudget(time.Second, ngram),
+		xordb.WithEncoderLoader(func(ctx context.Context) (hdc.Encoder, error) {
+			select {
+			case <-release:
+				return ngram, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}))
+	db.Set("what is the capital of india", "Delhi")
+	if v, ok, _ := db.Get("capital city of india"); !ok || v != "Delhi" {
+		t.Fatalf("the fallback must serve while loading, got %v, %v", v, ok)
+	}
+	if err := db.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WaitForWarmup(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Close must cancel the load, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package xordb_test
+
+import (
+	"go/build"
+	"strings"
+	"testing"
+)
+
+// TestPortableImports keeps the core packages buildable for js/wasm,
+// wasip1 and TinyGo: standard library and hdc-go only, no cgo, and no
+// syscall or unsafe outside unix-only files (the mmap in cache).
+func TestPortableImports(t *testing.T) {
+	banned := map[string]bool{"C": true, "syscall": true, "unsafe": true, "os/exec": true, "plugin": true}
+	for _, goos := range []string{"js", "wasip1"} {
+		ctx := build.Default
+		ctx.GOOS, ctx.GOARCH, ctx.CgoEnabled = goos, "wasm", false
+		for _, dir := range []string{".", "cache", "hdcx", "lang", "selftest", "eval"} {
+			pkg, err := ctx.ImportDir(dir, 0)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", goos, dir, err)
+			}
+			for _, imp := range pkg.Imports {
+				external := strings.Contains(strings.Split(imp, "/")[0], ".")
+				switch {
+				case banned[imp]:
+					t.Errorf("%s/%s imports %s", goos, dir, imp)
+				case external && imp != "github.com/Amansingh-afk/hdc-go" && imp != "github.com/Amansingh-afk/xordb" && !strings.HasPrefix(imp, "github.com/Amansingh-afk/xordb/"):
+					t.Errorf("%s/%s imports %s; the core must stay dependency-free", goos, dir, imp)
+				case strings.HasPrefix(imp, "github.com/Amansingh-afk/xordb/embed"):
+					t.Errorf("%s/%s imports %s, which needs the ONNX runtime", goos, dir, imp)
+				}
+			}
+		}
+	}
+}
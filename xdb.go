@@ -6,47 +6,239 @@
 package xordb
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Amansingh-afk/hdc-go"
 	"github.com/Amansingh-afk/xordb/cache"
+	"github.com/Amansingh-afk/xordb/hdcx"
 )
 
 type Stats struct {
-	Entries       int
-	Hits          uint64
-	Misses        uint64
-	Sets          uint64
-	Expired       uint64
-	HitRate       float64
-	AvgSimOnHit   float64
-	LSHCandidates uint64
-	LSHFallbacks  uint64
+	Entries        int
+	Hits           uint64
+	ExactHits      uint64 // hits on the entry stored under the key looked up
+	Misses         uint64
+	Sets           uint64
+	Expired        uint64
+	Evictions      uint64 // entries evicted to make room
+	HitRate        float64
+	AvgSimOnHit    float64
+	LSHCandidates  uint64
+	LSHFallbacks   uint64
+	Pruned         uint64 // comparisons skipped by the popcount prefilter or WithCoarseFilter
+	Busy           uint64 // lookups turned away by WithMaxConcurrentScans
+	Ambiguous      uint64 // misses whose best match lacked WithMargin's lead
+	DefiniteMisses uint64 // misses WithMissFilter answered without a scan
+	SoftMisses     uint64 // misses that returned their best entry under WithSoftMisses
+	EarlyExits     uint64 // hits that ended their scan early under WithEarlyExit
+
+	// HitSimilarity counts hits by similarity in buckets of width 0.05:
+	// [i] covers [i/20, (i+1)/20), and the last bucket includes 1.
+	HitSimilarity [cache.NumSimBuckets]uint64
+
+	// EntryAllocs counts entries allocated fresh, EntryReuses entries
+	// recycled from evicted or deleted ones. A high-churn cache should be
+	// dominated by reuses.
+	EntryAllocs uint64
+	EntryReuses uint64
+
+	// DedupShared counts entries sharing an identical vector with another
+	// entry (WithVectorDedup), and DedupBytesSaved the memory that saves.
+	DedupShared     uint64
+	DedupBytesSaved uint64
+
+	// ValuesShared counts entries sharing an equal value with another
+	// entry (WithValueDedup) and ValueBytesSaved the memory that saves.
+	// ValueDedupRatio is the size of the values entries hold over the size
+	// stored: 3 means each answer is cached under three keys on average.
+	ValuesShared    uint64
+	ValueBytesSaved uint64
+	ValueDedupRatio float64
+
+	// EncodeTimeouts counts encodes that overran WithEncodeBudget.
+	EncodeTimeouts uint64
+
+	// EncodeCacheHits counts keys whose vector WithEncodeCache supplied.
+	EncodeCacheHits uint64
+
+	// EncodeErrors counts encoder failures contained by
+	// WithEncoderPanicRecovery; see DB.LastEncodeError.
+	EncodeErrors uint64
+
+	// Spooled counts values over WithMaxValueBytes spooled to WithBlobDir,
+	// Oversize those not cached.
+	Spooled  uint64
+	Oversize uint64
+
+	// Cold counts entries whose value WithColdTier moved to disk, Faults
+	// the hits that read one back.
+	Cold   int
+	Faults uint64
+
+	// Near misses handed to WithNearMissVerifier, dropped because its queue
+	// was full, and confirmed and aliased.
+	NearMissQueued    uint64
+	NearMissDropped   uint64
+	NearMissConfirmed uint64
+
+	// QueryLogErrors counts WithQueryLog records that failed to write.
+	QueryLogErrors uint64
+
+	// Labels recorded with Feedback. EstPrecision is the fraction of
+	// labeled hits that were correct (0 without feedback).
+	FeedbackCorrect uint64
+	FeedbackWrong   uint64
+	EstPrecision    float64
+
+	// Latency holds percentiles of the phases WithLatencySampling times:
+	// encoding keys, waiting for the cache lock and finding the best match.
+	// A slow Encode calls for a faster encoder or WithEncodeCache, a slow
+	// Scan for LSH, and a slow LockWait for fewer writers.
+	Latency struct{ Encode, LockWait, Scan Latency }
+
+	// PerLookup describes each lookup's work: Candidates are the entries
+	// LSH (or the scan) offered, Compared those the popcount prefilter let
+	// through to a full comparison. Candidates close to Entries means LSH
+	// is not narrowing the search; Compared close to Candidates means the
+	// prefilter is not pruning.
+	PerLookup struct{ Candidates, Compared Distribution }
+}
+
+// Distribution summarizes a per-lookup count; P99 is accurate to 12.5%.
+type Distribution struct {
+	Mean float64 `json:"mean"`
+	P99  uint64  `json:"p99"`
+}
+
+// Latency summarizes the sampled durations of one phase. Percentiles are
+// accurate to 12.5%.
+type Latency struct {
+	Samples uint64        `json:"samples"`
+	P50     time.Duration `json:"p50_ns"`
+	P95     time.Duration `json:"p95_ns"`
+	P99     time.Duration `json:"p99_ns"`
 }
 
 // DB is a semantic cache. Safe for concurrent use.
 type DB struct {
-	c *cache.Cache
+	c    *cache.Cache
+	fb   *feedback
+	vf   *verifier           // nil without WithNearMissVerifier
+	ql   *queryLog           // nil without WithQueryLog
+	norm func(string) string // nil = keys used as given
+	proj EmbeddingProjector  // the encoder, if it is one
+	emb  Embedder            // likewise
+	fe   *fetcher
+
+	parts partitions
+
+	closers  []io.Closer  // encoders to close on Close
+	onClose  string       // WithSaveOnClose path
+	compress bool         // WithSnapshotCompression
+	seed     uint64       // WithSeed, for Provenance
+	auditKey []byte       // WithAuditKey
+	cipher   *valueCipher // WithValueEncryption
+	closed   atomic.Bool
+
+	snapID atomic.Uint64 // ID of the last snapshot written, see SaveDelta
+
+	warming *warmup // nil without WithEncoderLoader and WithWarmup
 }
 
 type Option func(*dbOptions)
 
 type dbOptions struct {
 	dims             int
+	dimsSet          bool // WithDims given, checked against custom encoders
 	threshold        float64
 	capacity         int
 	ngram            int
 	seed             uint64
 	stripPunctuation bool
+	foldAccents      bool
 	ttl              time.Duration
+	ttlJitter        float64
+	slidingTTL       bool
+
+	invalid error // from an option that cannot hold its value, see validate
 
 	lshEnabled  *bool
 	lshK        int
 	lshL        int
 	lshFallback *bool
+	lshProbes   int
+
+	parallelScanMin int
+	scanWorkers     int
+	bitSliced       bool
+	missFilter      bool
+	coarseBits      int
+	lateTokens      int
+	fusion          float64
+	maxScans        int
+	maxQueuedScans  int
+	evictionSamples int
+	acceptedSources []string
+	tombstoneTTL    time.Duration
+	encodeBudget    time.Duration
+	encodeFallback  hdc.Encoder
+	encodeRecover   bool
+	encodeCache     int
+	latencyRate     float64
+	dedupVectors    bool
+	dedupValues     bool
+	keyArena        bool
+	indexShards     int
+	keyNormalizer   func(string) string
+	questions       bool
+	queryExpander   func(string) []string
+	margin          float64
+	softMiss        float64
+	earlyExit       float64
+	decayHalfLife   time.Duration
+	encoderLoader   func(context.Context) (hdc.Encoder, error)
+	warmup          func(context.Context, *DB) error
+	auditKey        []byte
+	cipher          *valueCipher
+	keyScrubber     func(string) string
+	valueScrubber   func(any) any
+	partitionBy     func(context.Context) string
+	quotas          map[string]PartitionQuota
+	maxValueBytes   int64
+	lowWatermark    int
+	bgEviction      bool
+	blobDir         string
+	hotEntries      int
+	coldDir         string
+	profile         *hdcx.Profile
+	stageTimer      hdcx.StageTimer
+	langRouting     bool
+	langEncoders    map[string]hdc.Encoder
+	similarity      func(a, b hdc.Vector) float64
+	faults          Faults
+
+	onEvent        func(Event)
+	adaptiveTarget float64
+	nearMissEps    float64
+	nearMissVerify func(query, candidate string) bool
+
+	queryLog       io.Writer
+	queryLogSample float64
+	queryLogMax    int64
+	queryLogRotate func(old io.Writer) (io.Writer, error)
+
+	earlyRefresh float64
+	freshStats   bool
+	saveOnClose  string
+
+	compressSnapshots bool
 }
 
 func defaultOptions() dbOptions {
@@ -55,28 +247,183 @@ func defaultOptions() dbOptions {
 		threshold: 0.75,
 		capacity:  1024,
 		ngram:     3,
+		fusion:    1,
+
+		queryLogSample: 1,
 	}
 }
 
 // WithDims sets the hypervector dimension (default 10000).
 // Higher values increase accuracy at the cost of memory and CPU.
-func WithDims(n int) Option { return func(o *dbOptions) { o.dims = n } }
+func WithDims(n int) Option { return func(o *dbOptions) { o.dims, o.dimsSet = n, true } }
 
 // WithThreshold sets the minimum similarity for a cache hit (default 0.75).
 // Must be in (0, 1]. Raise to require closer matches; lower to be more permissive.
-func WithThreshold(t float64) Option     { return func(o *dbOptions) { o.threshold = t } }
+func WithThreshold(t float64) Option { return func(o *dbOptions) { o.threshold = t } }
+
+// WithMargin makes a hit also lead the next best entry by at least m
+// similarity (default 0: off). In a cache of keys filled into one template —
+// "weather in Paris", "weather in Perth" — the right answer and its
+// neighbours all clear the threshold by a little; requiring a lead turns
+// those coin flips into misses, counted in Stats.Ambiguous. m must be in
+// [0, 1).
+func WithMargin(m float64) Option { return func(o *dbOptions) { o.margin = m } }
+
+// WithSoftMisses makes Lookup return the best entry of a miss that scored
+// at least floor, with Hit false and Result.SoftMiss set, instead of
+// discarding it: a caller can verify it with a stronger model, or pass its
+// answer to the LLM as context, without a second lookup. Misses lacking
+// WithMargin's lead count too. Get still reports a plain miss. Off by
+// default; floor must be in [0, 1].
+func WithSoftMisses(floor float64) Option { return func(o *dbOptions) { o.softMiss = floor } }
+
+// WithEarlyExit makes a lookup take the first hit scoring at least sim
+// rather than scan on for the best one, checking the most recently used
+// entries first. When users repeat what was just asked, the scan then
+// stops near the front of the cache, cutting the average lookup time; a
+// stopped scan is counted in Stats.EarlyExits. Scans become serial
+// (WithParallelScan no longer splits them), and a close paraphrase further
+// back may lose to a good enough recent one. Off by default; sim must be
+// in [0, 1], and WithMargin cannot be used with it.
+func WithEarlyExit(sim float64) Option { return func(o *dbOptions) { o.earlyExit = sim } }
+
+// WithSimilarityDecay makes answers age: an entry's similarity to a query
+// is multiplied by 2^(−age/halfLife), age being the time since it was set,
+// so the older an answer, the closer a question must be to get it. Unlike
+// a TTL, which serves an answer unchanged until it vanishes, this lets a
+// stale answer still serve near-verbatim repeats while paraphrases go to
+// the backend. At the default threshold of 0.75, even an exact repeat
+// misses after 0.415 half-lives; Result.Similarity is the decayed score.
+// Pinned entries do not decay. Off by default.
+func WithSimilarityDecay(halfLife time.Duration) Option {
+	return func(o *dbOptions) { o.decayHalfLife = halfLife }
+}
+
 func WithCapacity(n int) Option          { return func(o *dbOptions) { o.capacity = n } }
 func WithNGramSize(n int) Option         { return func(o *dbOptions) { o.ngram = n } }
-func WithSeed(s uint64) Option           { return func(o *dbOptions) { o.seed = s } }
 func WithStripPunctuation(v bool) Option { return func(o *dbOptions) { o.stripPunctuation = v } }
 
+// WithNormalizationProfile applies one of the hdcx presets —
+// hdcx.NaturalLanguage, Code, LogLine or Identifier — to the built-in
+// encoder: its n-gram size and punctuation setting, plus the rewriting it
+// does before encoding (digit collapsing, word splitting). Options after it
+// override individual settings. Only the encoding is affected; exact keys
+// are stored as given. Ignored by NewWithEncoder.
+func WithNormalizationProfile(p hdcx.Profile) Option {
+	return func(o *dbOptions) {
+		if p.NGramSize > 0 {
+			o.ngram = p.NGramSize
+		}
+		o.stripPunctuation = p.StripPunctuation
+		o.foldAccents = p.FoldAccents
+		o.profile = &p
+	}
+}
+
+// WithAccentFolding makes the built-in encoder ignore case and diacritics
+// the way MiniLM's tokenizer does (hdcx.FoldAccents), so "Café" matches
+// "cafe" and n-gram and MiniLM encoders in one setup see the same text.
+// Off by default; on in hdcx.NaturalLanguage. Changes the vectors, so
+// snapshots from DBs without it cannot be loaded.
+func WithAccentFolding(enabled bool) Option {
+	return func(o *dbOptions) { o.foldAccents = enabled }
+}
+
+// WithStageTimer times the built-in encoder's stages — hdcx.StageNormalize
+// (profile rewriting) and hdcx.StageBundle (n-gram encoding) — on a sampled
+// fraction of calls. Ignored by NewWithEncoder; MiniLM takes
+// embed.WithStageTimer.
+func WithStageTimer(t hdcx.StageTimer) Option {
+	return func(o *dbOptions) { o.stageTimer = t }
+}
+
+// WithSeed sets the namespace of the DB's vectors (default 0): two DBs
+// built alike but with different seeds produce unrelated vectors for the
+// same key, so neither can match the other's entries or snapshots — tenant
+// isolation by construction. The n-gram encoder derives its symbol table
+// from the seed; NewWithEncoder reseeds a Reseeder (embed.MiniLMEncoder
+// derives its projection) and binds any other encoder's vectors to a
+// seed-derived key. SetVec takes vectors as given, so they must already be
+// in the namespace.
+func WithSeed(s uint64) Option { return func(o *dbOptions) { o.seed = s } }
+
+// WithPersistentStats controls whether loading a snapshot restores the
+// lifetime counters saved in it — hits, misses, sets, expiries, evictions
+// and the hit-similarity histogram — so Stats keep counting across
+// restarts (the default). Counters are raised to the saved totals, never
+// lowered, so loading a base and its deltas counts nothing twice. Pass
+// false to count from zero in every process, e.g. on a replica that should
+// not adopt its primary's counters.
+func WithPersistentStats(enabled bool) Option {
+	return func(o *dbOptions) { o.freshStats = !enabled }
+}
+
+// WithSnapshotCompression makes Save, WriteSnapshot, SaveDelta and
+// SaveSharded store each vector as its difference from the snapshot's
+// majority vector when that is smaller (see cache.EncodeSnapshotCompressed).
+// Snapshots of templated keys shrink by about a third (500 keys that
+// differ in a number, n-gram encoder); exact and near duplicates shrink
+// severalfold; unrelated keys barely shrink.
+// Load reads either form, but compressed files cannot be opened with
+// OpenMapped or by releases before this option.
+func WithSnapshotCompression(enabled bool) Option {
+	return func(o *dbOptions) { o.compressSnapshots = enabled }
+}
+
 // WithTTL sets the default TTL for cache entries. Zero = no expiry.
 // Expired entries are lazily cleaned during Get scans.
 func WithTTL(d time.Duration) Option { return func(o *dbOptions) { o.ttl = d } }
 
+// WithTTLJitter randomizes each entry's TTL by up to fraction f either way
+// (f in [0, 1)), so entries cached in a burst do not all expire, and trigger
+// refreshes, at the same moment. Applies to per-entry TTLs too.
+func WithTTLJitter(f float64) Option { return func(o *dbOptions) { o.ttlJitter = f } }
+
+// WithSlidingTTL restarts an entry's TTL every time it is hit, so entries
+// expire only after going unused for a full TTL.
+func WithSlidingTTL(enabled bool) Option { return func(o *dbOptions) { o.slidingTTL = enabled } }
+
 // WithLSH enables or disables LSH indexing. Default: auto (enabled if capacity >= 256).
 func WithLSH(enabled bool) Option { return func(o *dbOptions) { o.lshEnabled = &enabled } }
 
+// Index selects how Get finds candidates.
+type Index int
+
+const (
+	IndexAuto   Index = iota // IndexLSH at capacity >= 256, IndexLinear below
+	IndexLinear              // compare against every entry: exact, O(n)
+	IndexLSH                 // bit-sampling LSH tables; see WithLSHParams, WithLSHProbes, WithLSHFallback
+)
+
+func (i Index) String() string {
+	switch i {
+	case IndexAuto:
+		return "auto"
+	case IndexLinear:
+		return "linear"
+	case IndexLSH:
+		return "lsh"
+	}
+	return fmt.Sprintf("Index(%d)", int(i))
+}
+
+// WithIndex selects the lookup index (default IndexAuto). Which one is
+// faster at equal recall depends on the corpus; eval.CompareIndexes measures
+// it on your data. Equivalent to WithLSH for the non-auto values.
+func WithIndex(i Index) Option {
+	return func(o *dbOptions) {
+		switch i {
+		case IndexAuto:
+			o.lshEnabled = nil
+		case IndexLinear, IndexLSH:
+			enabled := i == IndexLSH
+			o.lshEnabled = &enabled
+		default:
+			o.invalid = invalidOption("unknown index " + i.String())
+		}
+	}
+}
+
 // WithLSHParams overrides auto-computed LSH parameters.
 func WithLSHParams(k, l int) Option {
 	return func(o *dbOptions) { o.lshK = k; o.lshL = l }
@@ -88,61 +435,567 @@ func WithLSHFallback(fallback bool) Option {
 	return func(o *dbOptions) { o.lshFallback = &fallback }
 }
 
-// New creates a DB with the built-in n-gram encoder.
+// WithLSHProbes makes each LSH lookup also probe n neighbouring buckets per
+// table — those whose key differs in one sampled bit — trading a little
+// latency for recall without the memory of more tables. Default 0; values
+// above k are capped at k.
+func WithLSHProbes(n int) Option { return func(o *dbOptions) { o.lshProbes = n } }
+
+// WithParallelScan splits linear scans of at least minEntries entries across
+// workers goroutines (0 = GOMAXPROCS). Off by default; worth enabling for
+// caches of tens of thousands of entries without LSH, or with LSH fallback
+// scans. Lookups still hold the cache lock for the whole scan.
+func WithParallelScan(minEntries, workers int) Option {
+	return func(o *dbOptions) { o.parallelScanMin = minEntries; o.scanWorkers = workers }
+}
+
+// WithBitSlicedScan keeps a transposed copy of the stored vectors, so a
+// linear scan computes every entry's distance to the query in one
+// sequential pass without popcount instructions. It doubles vector memory,
+// replaces WithParallelScan, and cannot be combined with a custom
+// similarity. It pays off on CPUs without a fast popcount; elsewhere it is
+// about even with the default scan.
+func WithBitSlicedScan(on bool) Option { return func(o *dbOptions) { o.bitSliced = on } }
+
+// WithMissFilter counts entries by popcount so that a lookup whose
+// popcount rules out every entry — the popcount prefilter would skip them
+// all — misses without a scan, or with LSH without the fallback scan.
+// Such misses are counted in Stats.DefiniteMisses. It cannot be combined
+// with a custom similarity.
+func WithMissFilter(on bool) Option { return func(o *dbOptions) { o.missFilter = on } }
+
+// WithCoarseFilter keeps bits evenly spaced bits of every vector (a
+// multiple of 64, e.g. 1024 of 10000) and compares those first: entries
+// clearly too far from the query are skipped before the full comparison
+// and counted in Stats.Pruned. Scans of unrelated entries then read a
+// fraction of each vector, at bits/8 bytes per entry. A true hit is missed
+// with probability around 3·10⁻⁵. It cannot be combined with a custom
+// similarity.
+func WithCoarseFilter(bits int) Option { return func(o *dbOptions) { o.coarseBits = bits } }
+
+// WithLateInteraction keeps up to tokens token vectors (at most 32) per
+// entry besides the pooled one and scores lookups by MaxSim: each word of
+// the query is matched to its closest word of the entry and back, which
+// tells long keys apart better than one pooled vector. The encoder must
+// implement cache.TokenEncoder, as embed.MiniLMEncoder does. Scoring costs
+// up to tokens² comparisons per entry and turns off the popcount prefilter,
+// and NewWithEncoder panics if a full DB would hold over 1 GiB of token
+// vectors — lower the capacity or tokens. Entries restored from a snapshot
+// carry no tokens and are scored by their pooled vector until set again.
+func WithLateInteraction(tokens int) Option { return func(o *dbOptions) { o.lateTokens = tokens } }
+
+// WithScoreFusion scores lookups as alpha times the encoder's similarity
+// plus 1−alpha times the Jaccard overlap of the query's and the key's
+// words, so near-synonyms that share few words need more semantic
+// similarity to hit and rewordings that keep most words less. It needs no
+// encoder changes and costs 4 bytes per distinct word per entry. Thresholds
+// tuned without it need retuning. alpha must be in (0, 1]; 1 is the
+// default, semantic only.
+func WithScoreFusion(alpha float64) Option { return func(o *dbOptions) { o.fusion = alpha } }
+
+// scoreFusion returns the cache's Options.ScoreFusion, which is 0 for
+// semantic only.
+func (o *dbOptions) scoreFusion() float64 {
+	if o.fusion == 1 {
+		return 0
+	}
+	return o.fusion
+}
+
+// WithMaxConcurrentScans lets at most n lookups run at once, with up to
+// queue more waiting; beyond that a lookup fails fast — Get and Lookup
+// report a miss and TryLookup returns ErrBusy — so a traffic spike degrades
+// to cache misses instead of goroutines piling up behind the lock. Rejected
+// lookups are counted in Stats.Busy. n = 0 (the default) means no limit.
+func WithMaxConcurrentScans(n, queue int) Option {
+	return func(o *dbOptions) { o.maxScans = n; o.maxQueuedScans = queue }
+}
+
+// WithSampledEviction switches from exact LRU to Redis-style approximate
+// LRU: a hit only stamps the entry, and a full cache evicts the least
+// recently used of samples random entries (5 is a good start). Worth it for
+// large, read-heavy caches; 0 (the default) keeps exact LRU.
+func WithSampledEviction(samples int) Option {
+	return func(o *dbOptions) { o.evictionSamples = samples }
+}
+
+// WithEvictionWatermark makes a full cache evict down to low entries in
+// one batch, rather than one entry on every Set, so most Sets on a full
+// cache evict nothing. With background the batch runs off the Set path and
+// the cache may briefly hold up to capacity−low entries over capacity;
+// evict events then arrive on another goroutine. low must be in
+// [0, capacity); 0 (the default) keeps per-Set eviction.
+func WithEvictionWatermark(low int, background bool) Option {
+	return func(o *dbOptions) { o.lowWatermark, o.bgEviction = low, background }
+}
+
+// WithAcceptedSources limits lookups to entries stored by SetWithSource
+// with one of sources, so answers from a deprecated model or pipeline
+// version stop being served without purging them. Untagged entries have
+// source "". Change it later with DB.SetAcceptedSources.
+func WithAcceptedSources(sources ...string) Option {
+	return func(o *dbOptions) { o.acceptedSources = sources }
+}
+
+// WithTombstones remembers explicit deletes (Delete, DeletePrefix,
+// DeleteSource) for window and writes them into snapshots. Loading a
+// snapshot then removes older copies of deleted keys instead of
+// resurrecting them — e.g. restoring last night's file after a purge, or
+// merging a primary's snapshot into a replica. Off by default.
+func WithTombstones(window time.Duration) Option {
+	return func(o *dbOptions) { o.tombstoneTTL = window }
+}
+
+// WithEncodeBudget caps how long Set and Get wait for the encoder, to
+// protect tail latency when e.g. ONNX inference stalls. A key whose encode
+// overruns d is encoded with fallback instead, or, if fallback is nil, is
+// not cached (Set) or treated as a miss (Get). Overruns are counted in
+// Stats.EncodeTimeouts. fallback must map keys into the same vector space
+// as the main encoder — a cheaper model with the same projection, not an
+// unrelated n-gram encoder — or its hits are meaningless.
+func WithEncodeBudget(d time.Duration, fallback hdc.Encoder) Option {
+	return func(o *dbOptions) { o.encodeBudget, o.encodeFallback = d, fallback }
+}
+
+// WithEncodeCache remembers the vectors of the n most recently encoded keys
+// (after WithKeyNormalizer), so a Set followed by Gets of the same string,
+// or a popular query repeated, runs the encoder once. Each remembered key
+// costs a vector, dims/8 bytes. Hits are counted in Stats.EncodeCacheHits.
+// 0, the default, disables it.
+func WithEncodeCache(n int) Option { return func(o *dbOptions) { o.encodeCache = n } }
+
+// WithLatencySampling times a fraction rate (in [0, 1]; 1 = every call) of
+// encodes, lock waits and scans for the percentiles in Stats.Latency. Each
+// sampled phase costs two clock reads. 0, the default, disables it.
+func WithLatencySampling(rate float64) Option { return func(o *dbOptions) { o.latencyRate = rate } }
+
+// WithEncoderPanicRecovery keeps a buggy custom encoder from crashing the
+// host: if it panics on a key or returns a vector of the wrong dims, the
+// failure is counted in Stats.EncodeErrors and kept for LastEncodeError,
+// and the operation becomes a no-op (Set) or a miss (Get). Off by default,
+// so bugs surface in tests.
+func WithEncoderPanicRecovery(enabled bool) Option {
+	return func(o *dbOptions) { o.encodeRecover = enabled }
+}
+
+// WithVectorDedup stores one copy of a vector shared by every key that
+// encodes to it exactly, e.g. keys differing only in case or spacing, which
+// the encoders normalize away. Costs a hash per Set; savings are reported in
+// Stats.DedupBytesSaved. Off by default.
+func WithVectorDedup(enabled bool) Option { return func(o *dbOptions) { o.dedupVectors = enabled } }
+
+// WithValueDedup stores one copy of a string or []byte value shared by
+// every key that holds it, reference counted, so a completion cached under
+// dozens of paraphrased prompts costs its memory once. Costs a hash of the
+// value per Set; savings are reported in Stats.ValueBytesSaved and
+// Stats.ValueDedupRatio. A shared []byte must not be modified. Off by
+// default.
+func WithValueDedup(enabled bool) Option { return func(o *dbOptions) { o.dedupValues = enabled } }
+
+// WithShardedIndex splits the exact-key index into shards maps (rounded up
+// to a power of two), so growing it rehashes one shard at a time rather
+// than every key at once. Worth it for caches of millions of entries, where
+// a single map's resize stalls a Set for milliseconds. Default: one map.
+func WithShardedIndex(shards int) Option { return func(o *dbOptions) { o.indexShards = shards } }
+
+// WithKeyArena stores keys packed into shared 64 KiB chunks rather than as
+// the strings passed to Set: fewer allocations per entry, and a key cut
+// from a larger string (a request body, a prompt) no longer keeps all of
+// it alive. Space of removed keys is reclaimed by compacting the chunks
+// once it outweighs the live keys. Off by default.
+func WithKeyArena(enabled bool) Option { return func(o *dbOptions) { o.keyArena = enabled } }
+
+// WithSimilarity replaces the metric used to score a query against entries
+// (default hdc.Similarity), e.g. a masked or segment-weighted similarity. fn
+// must return values in [0, 1] comparable with the threshold, and be safe
+// for concurrent use. See cache.Options.Similarity for how it interacts with
+// LSH.
+func WithSimilarity(fn func(a, b hdc.Vector) float64) Option {
+	return func(o *dbOptions) { o.similarity = fn }
+}
+
+// WithKeyNormalizer rewrites every key before it is encoded or used as an
+// exact key, so Set, Get, Delete, Pin and the rest agree on one canonical
+// form — e.g. strings.TrimSpace, or strings.ToLower for case-insensitive
+// Deletes. It also applies to DeletePrefix's prefix, Explain, Feedback and
+// WarmFromJSONL, but not to snapshots, which store keys already normalized.
+// fn must be deterministic and safe for concurrent use.
+func WithKeyNormalizer(fn func(string) string) Option {
+	return func(o *dbOptions) { o.keyNormalizer = fn }
+}
+
+// WithQuestionNormalization rewrites question-style keys with
+// hdcx.NormalizeQuestion, after the WithKeyNormalizer function if any:
+// contractions are expanded, leading phrases like "what is" and "how do I"
+// dropped and named entities moved to the front, so questions built on
+// one template ("What is the population of Norway?", "... of Sweden?")
+// are told apart by what they ask about instead of matched on their
+// shared wording. Like WithKeyNormalizer it rewrites exact keys too and
+// works with any encoder.
+func WithQuestionNormalization(on bool) Option { return func(o *dbOptions) { o.questions = on } }
+
+// normalizer returns the key normalizer WithKeyScrubber, WithKeyNormalizer
+// and WithQuestionNormalization add up to, or nil.
+func (o *dbOptions) normalizer() func(string) string {
+	fn := o.keyNormalizer
+	if scrub := o.keyScrubber; scrub != nil {
+		if norm := fn; norm != nil {
+			fn = func(k string) string { return norm(scrub(k)) }
+		} else {
+			fn = scrub
+		}
+	}
+	switch {
+	case !o.questions:
+		return fn
+	case fn == nil:
+		return hdcx.NormalizeQuestion
+	}
+	return func(k string) string { return hdcx.NormalizeQuestion(fn(k)) }
+}
+
+// WithQueryExpander improves recall for synonyms the encoder cannot know,
+// such as "k8s" and "kubernetes": lookups also encode each alternative fn
+// returns for the key and take the best match of any. Set stores only the
+// key. fn receives keys after WithKeyNormalizer, its results are
+// normalized too, and it must be safe for concurrent use; each expansion
+// costs an encode per lookup.
+func WithQueryExpander(fn func(key string) []string) Option {
+	return func(o *dbOptions) { o.queryExpander = fn }
+}
+
+// New creates a DB with the built-in n-gram encoder, or the one
+// WithEncoderLoader loads, and runs WithWarmup before returning; see
+// NewAsync.
 func New(opts ...Option) *DB {
 	o := defaultOptions()
 	for _, opt := range opts {
 		opt(&o)
 	}
-	enc := hdc.NewNGramEncoder(hdc.Config{
+	return o.build(false)
+}
+
+// ngramEncoder builds the built-in encoder from the encoding options.
+func (o *dbOptions) ngramEncoder() hdc.Encoder {
+	cfg := hdc.Config{
 		Dims:             o.dims,
 		NGramSize:        o.ngram,
 		StripPunctuation: o.stripPunctuation,
 		LongTextThresh:   200,
 		ChunkSize:        128,
 		Seed:             o.seed,
-	})
-	return &DB{c: cache.New(enc, o.cacheOpts())}
+	}
+	if o.profile == nil && o.stageTimer.Func == nil && !o.foldAccents {
+		return hdc.NewNGramEncoder(cfg)
+	}
+	// cfg already carries the profile's settings, or the options that
+	// overrode them.
+	var p hdcx.Profile
+	if o.profile != nil {
+		p = *o.profile
+	}
+	p.NGramSize, p.StripPunctuation = cfg.NGramSize, cfg.StripPunctuation
+	p.FoldAccents = o.foldAccents
+	enc := hdcx.NewProfileEncoder(p, cfg)
+	enc.Timer = o.stageTimer
+	return enc
 }
 
 // NewWithEncoder — plug in any encoder (e.g. xordb/embed MiniLM).
-// Encoding-related options (Dims, NGramSize, Seed etc.) are ignored since
-// the encoder controls those.
+// Encoding-related options (NGramSize etc.) are ignored since the encoder
+// controls those, except WithSeed, which moves enc — and the
+// WithEncodeBudget fallback — into the seed's namespace.
+//
+// enc is probed up front: it must return vectors of one non-zero size,
+// matching its Dims method if it is a Dimensioner, the WithEncodeBudget
+// fallback, and WithDims if given. NewWithEncoder panics with a description
+// of the mismatch or of an invalid option; TryNewWithEncoder returns it.
 func NewWithEncoder(enc hdc.Encoder, opts ...Option) *DB {
 	if enc == nil {
 		panic("xordb: encoder must not be nil")
 	}
-	o := defaultOptions()
-	for _, opt := range opts {
-		opt(&o)
+	db, err := TryNewWithEncoder(enc, opts...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return db
+}
+
+// seededEncoders applies WithSeed to a caller's encoder and the fallback.
+func (o *dbOptions) seededEncoders(enc hdc.Encoder) hdc.Encoder {
+	o.encodeFallback = seeded(o.encodeFallback, o.seed)
+	return seeded(enc, o.seed)
+}
+
+// validate checks the options the cache does not; their errors wrap
+// ErrInvalidOption.
+func (o *dbOptions) validate() error {
+	switch {
+	case o.invalid != nil:
+		return o.invalid
+	case !(o.adaptiveTarget >= 0 && o.adaptiveTarget <= 1):
+		return invalidOption("adaptive threshold target must be in [0, 1]")
+	case !(o.fusion > 0 && o.fusion <= 1):
+		return invalidOption("score fusion alpha must be in (0, 1]")
+	case o.nearMissVerify != nil && !(o.nearMissEps > 0 && o.nearMissEps < 1):
+		return invalidOption("near-miss epsilon must be in (0, 1)")
+	case !(o.earlyRefresh >= 0):
+		return invalidOption("early refresh beta must not be negative")
+	case o.queryLog != nil && !(o.queryLogSample > 0 && o.queryLogSample <= 1):
+		return invalidOption("query log sample rate must be in (0, 1]")
+	case o.queryLog != nil && o.queryLogRotate != nil && o.queryLogMax <= 0:
+		return invalidOption("query log rotation size must be positive")
+	case o.indexShards < 0:
+		return invalidOption("sharded index shard count must not be negative")
+	case o.encoderLoader != nil && o.langRouting:
+		return invalidOption("WithEncoderLoader does not work with WithLanguageRouting")
+	}
+	return nil
+}
+
+// newDB is tryNewDB for options known to be valid, or that should panic.
+func newDB(enc hdc.Encoder, o dbOptions) *DB {
+	db, err := tryNewDB(enc, o)
+	if err != nil {
+		panic(err.Error())
+	}
+	return db
+}
+
+func tryNewDB(enc hdc.Encoder, o dbOptions) (*DB, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	enc = o.routed(enc)
+	opts := o.cacheOpts()
+	var vf *verifier
+	if o.nearMissVerify != nil {
+		vf = &verifier{eps: o.nearMissEps, verify: o.nearMissVerify}
+		opts.OnEvent = chainEvent(opts.OnEvent, vf.observe)
+	}
+	fe := newFetcher(o.earlyRefresh, o.capacity)
+	if fe.beta > 0 {
+		opts.OnEvent = chainEvent(opts.OnEvent, fe.observe)
+	}
+	ql := o.newQueryLog()
+	if ql != nil {
+		opts.OnEvent = chainEvent(opts.OnEvent, ql.observe)
+	}
+	c, err := cache.TryNew(enc, opts)
+	if err != nil {
+		return nil, fmt.Errorf("xordb: %w", err)
+	}
+	db := &DB{
+		c:    c,
+		fb:   &feedback{target: o.adaptiveTarget, base: o.threshold},
+		vf:   vf,
+		ql:   ql,
+		norm: o.normalizer(),
+		fe:   fe,
+
+		onClose:  o.saveOnClose,
+		compress: o.compressSnapshots,
+		auditKey: o.auditKey,
+		cipher:   o.cipher,
+		seed:     o.seed,
+	}
+	db.parts.by = o.partitionBy
+	db.parts.quotas = o.quotas
+	if db.parts.by == nil {
+		db.parts.by = PartitionFromContext
+	}
+	db.proj, _ = enc.(EmbeddingProjector)
+	db.emb, _ = enc.(Embedder)
+	for _, e := range []hdc.Encoder{enc, o.encodeFallback} {
+		if c, ok := e.(io.Closer); ok {
+			db.closers = append(db.closers, c)
+		}
+	}
+	if vf != nil {
+		vf.c = db.c
 	}
-	return &DB{c: cache.New(enc, o.cacheOpts())}
+	return db, nil
 }
 
-func (db *DB) Set(key string, value any) { db.c.Set(key, value) }
+// NormalizeKey returns key as the DB stores and looks it up, after
+// WithKeyScrubber, WithKeyNormalizer and WithQuestionNormalization: two
+// keys name the same entry if they normalize alike.
+func (db *DB) NormalizeKey(key string) string { return db.key(key) }
+
+// key applies the key normalizer, if any.
+func (db *DB) key(k string) string {
+	if db.norm == nil {
+		return k
+	}
+	return db.norm(k)
+}
+
+func (db *DB) Set(key string, value any) { db.c.Set(db.key(key), value) }
+
+// SetWithSource is Set with a source tag, e.g. the model name or pipeline
+// version that produced value. See WithAcceptedSources and DeleteSource.
+// Like pins, tags are not persisted in snapshots.
+func (db *DB) SetWithSource(key string, value any, source string) {
+	db.c.SetWithSource(db.key(key), value, source)
+}
+
+// SetAcceptedSources replaces the WithAcceptedSources filter; no sources
+// accepts every entry again.
+func (db *DB) SetAcceptedSources(sources ...string) { db.c.SetAcceptedSources(sources...) }
+
+// DeleteSource removes every entry tagged with source and returns the count.
+func (db *DB) DeleteSource(source string) int { return db.c.DeleteSource(source) }
 
 // SetWithTTL — per-entry TTL that overrides the default. Zero = never expires.
 func (db *DB) SetWithTTL(key string, value any, ttl time.Duration) {
-	db.c.SetWithTTL(key, value, ttl)
+	db.c.SetWithTTL(db.key(key), value, ttl)
+}
+
+// Item is one entry for SetMany.
+type Item struct {
+	Key   string
+	Value any
+}
+
+// SetMany stores items as if by Set in order, under one lock acquisition.
+// If they overflow the capacity, room is made with one batched eviction
+// rather than an eviction per item.
+func (db *DB) SetMany(items []Item) {
+	ci := make([]cache.Item, len(items))
+	for i, it := range items {
+		ci[i] = cache.Item{Key: db.key(it.Key), Value: it.Value}
+	}
+	db.c.SetMany(ci)
 }
 
 // Get returns (value, true, similarity) on hit, (nil, false, 0) on miss.
-func (db *DB) Get(key string) (any, bool, float64) { return db.c.Get(key) }
+func (db *DB) Get(key string) (any, bool, float64) { return db.c.Get(db.key(key)) }
+
+// Result is the outcome of Lookup. On a miss only Hit (false) is set.
+type Result struct {
+	Value      any
+	Hit        bool
+	Similarity float64
+	MatchedKey string        // key the matched entry was stored under
+	EntryAge   time.Duration // time since the matched entry was set
+	ExpiresIn  time.Duration // time until it expires, 0 if it has no TTL
+	Source     string        // how the match was found: "lsh" or "scan"
+
+	// Margin is how far Similarity leads the next best entry, or the
+	// threshold if no other entry reaches it; with LSH only candidates
+	// count. Confidence combines Margin and Similarity's lead over the
+	// threshold into [0, 1]: 1 for a lone exact match, at most 0.5 for a
+	// hit just over the threshold or tied with another entry. Use it to
+	// treat barely-hit answers differently from confident ones.
+	Margin     float64
+	Confidence float64
+
+	EntrySource string // the matched entry's SetWithSource tag, "" if none
+
+	// SoftMiss marks a miss that carries its best entry anyway, under
+	// WithSoftMisses: Value, Similarity, MatchedKey, EntryAge, ExpiresIn
+	// and EntrySource describe that entry, which was not promoted.
+	SoftMiss bool
+}
+
+// Lookup is Get with details about the match: which key it hit, how old
+// that entry is and which index path found it.
+func (db *DB) Lookup(key string) Result { return result(db.c.Lookup(db.key(key))) }
+
+// LookupWhere is Lookup among the entries whose stored key match accepts,
+// e.g. one tenant's prefix: the others are skipped as if absent, so a
+// closer entry elsewhere cannot hide the best accepted one. match sees keys
+// after the key normalizer, runs under the cache lock, must be safe for
+// concurrent use and must not call back into the DB.
+func (db *DB) LookupWhere(key string, match func(key string) bool) Result {
+	return result(db.c.LookupWhere(db.key(key), match))
+}
+
+// LastEncodeError returns the most recent encoder failure contained by
+// WithEncoderPanicRecovery, or nil. It wraps ErrEncoder.
+func (db *DB) LastEncodeError() error { return db.c.LastEncodeError() }
 
-func (db *DB) Delete(key string) bool { return db.c.Delete(key) }
+// ErrEncoder is wrapped by the errors LastEncodeError returns.
+var ErrEncoder = cache.ErrEncoder
+
+// ErrBusy is returned by TryLookup when WithMaxConcurrentScans turns a
+// lookup away.
+var ErrBusy = cache.ErrBusy
+
+// TryLookup is Lookup that returns ErrBusy instead of a miss when the
+// WithMaxConcurrentScans limit is reached, so callers can tell overload
+// from a genuine miss.
+func (db *DB) TryLookup(key string) (Result, error) {
+	r, err := db.c.TryLookup(db.key(key))
+	return result(r), err
+}
+
+func result(r cache.Result) Result {
+	return Result{
+		Value:      r.Value,
+		Hit:        r.Hit,
+		Similarity: r.Similarity,
+		MatchedKey: r.MatchedKey,
+		EntryAge:   r.EntryAge,
+		ExpiresIn:  r.ExpiresIn,
+		Source:     r.Source,
+		Margin:     r.Margin,
+		Confidence: r.Confidence,
+
+		EntrySource: r.EntrySource,
+		SoftMiss:    r.SoftMiss,
+	}
+}
+
+func (db *DB) Delete(key string) bool { return db.c.Delete(db.key(key)) }
 func (db *DB) Len() int               { return db.c.Len() }
 
+// DeletePrefix removes every entry whose key starts with prefix and returns
+// the count. An empty prefix clears the cache; stats are kept.
+func (db *DB) DeletePrefix(prefix string) int {
+	prefix = db.key(prefix)
+	return db.c.DeleteFunc(func(k string) bool { return strings.HasPrefix(k, prefix) })
+}
+
+// DeleteMatching removes every entry whose key satisfies match, e.g. all
+// keys of a retired product, and returns the count. Like DeletePrefix it
+// holds the lock once for the whole batch. match sees keys as stored,
+// after the key normalizer; it runs with the DB locked and must not call
+// back into it.
+func (db *DB) DeleteMatching(match func(key string) bool) int { return db.c.DeleteFunc(match) }
+
+// Pin keeps the entry stored under exactly key in the cache, exempt from LRU
+// eviction and TTL expiry, until Unpin. Returns false if key is not cached.
+// Pins are not persisted by Save.
+func (db *DB) Pin(key string) bool   { return db.c.Pin(db.key(key)) }
+func (db *DB) Unpin(key string) bool { return db.c.Unpin(db.key(key)) }
+
+// SetThreshold, SetCapacity and SetTTL retune a live DB; they panic on the
+// same values as the corresponding options. Shrinking capacity evicts LRU
+// entries immediately; a new TTL applies only to later Sets. With
+// WithAdaptiveThreshold, SetThreshold also sets the adaptation floor.
+func (db *DB) SetThreshold(t float64) {
+	db.c.SetThreshold(t)
+	db.fb.setBase(t)
+}
+
+func (db *DB) SetCapacity(n int)      { db.c.SetCapacity(n) }
+func (db *DB) SetTTL(d time.Duration) { db.c.SetTTL(d) }
+func (db *DB) Threshold() float64     { return db.c.Threshold() }
+func (db *DB) Capacity() int          { return db.c.Capacity() }
+func (db *DB) TTL() time.Duration     { return db.c.TTL() }
+
 // Save writes a snapshot of the cache to path using xordb binary format.
 // The write is atomic: data goes to a temp file, fsynced, then renamed.
 func (db *DB) Save(path string) error {
-	snap := db.c.Snapshot()
 	dir := filepath.Dir(path)
 	f, err := os.CreateTemp(dir, ".xrdb-*.tmp")
 	if err != nil {
 		return fmt.Errorf("xordb: save: %w", err)
 	}
 	tmp := f.Name()
-	if err := cache.EncodeSnapshot(f, snap); err != nil {
+	snap := db.c.Snapshot()
+	if err := db.encodeSnapshot(f, snap); err != nil {
 		f.Close()
 		os.Remove(tmp)
 		return fmt.Errorf("xordb: save: encode: %w", err)
@@ -160,6 +1013,7 @@ func (db *DB) Save(path string) error {
 		os.Remove(tmp)
 		return fmt.Errorf("xordb: save: %w", err)
 	}
+	db.snapID.Store(snap.ID)
 	return nil
 }
 
@@ -171,29 +1025,106 @@ func (db *DB) Load(path string) error {
 		return fmt.Errorf("xordb: load: %w", err)
 	}
 	defer f.Close()
-	snap, err := cache.DecodeSnapshot(f, db.c.Dims())
-	if err != nil {
+	if err := db.readSnapshot(f); err != nil {
 		return fmt.Errorf("xordb: load: %w", err)
 	}
-	if err := db.c.LoadSnapshot(snap); err != nil {
-		return fmt.Errorf("xordb: load: %w", err)
+	return nil
+}
+
+// WriteSnapshot streams a binary snapshot (same format as Save) to w.
+// Vectors are included, so the receiver doesn't re-encode keys.
+func (db *DB) WriteSnapshot(w io.Writer) error {
+	snap := db.c.Snapshot()
+	if err := db.encodeSnapshot(w, snap); err != nil {
+		return fmt.Errorf("xordb: write snapshot: %w", err)
 	}
+	db.snapID.Store(snap.ID)
 	return nil
 }
 
+// ReadSnapshot merges a binary snapshot from r into the cache, like Load.
+func (db *DB) ReadSnapshot(r io.Reader) error {
+	if err := db.readSnapshot(r); err != nil {
+		return fmt.Errorf("xordb: read snapshot: %w", err)
+	}
+	return nil
+}
+
+// encodeSnapshot writes snap in the format WithSnapshotCompression picks.
+func (db *DB) encodeSnapshot(w io.Writer, snap cache.Snapshot) error {
+	if db.cipher != nil {
+		var err error
+		if snap, err = db.cipher.sealSnapshot(snap); err != nil {
+			return err
+		}
+	}
+	if db.compress {
+		return cache.EncodeSnapshotCompressed(w, snap)
+	}
+	return cache.EncodeSnapshot(w, snap)
+}
+
+func (db *DB) readSnapshot(r io.Reader) error {
+	snap, err := cache.DecodeSnapshot(r, db.c.Dims())
+	if err != nil {
+		return err
+	}
+	if err := db.cipher.openSnapshot(&snap); err != nil {
+		return err
+	}
+	return db.c.LoadSnapshot(snap)
+}
+
 func (db *DB) Stats() Stats {
 	s := db.c.Stats()
-	return Stats{
-		Entries:       s.Entries,
-		Hits:          s.Hits,
-		Misses:        s.Misses,
-		Sets:          s.Sets,
-		Expired:       s.Expired,
-		HitRate:       s.HitRate,
-		AvgSimOnHit:   s.AvgSimOnHit,
-		LSHCandidates: s.LSHCandidates,
-		LSHFallbacks:  s.LSHFallbacks,
+	st := Stats{
+		Entries:        s.Entries,
+		Hits:           s.Hits,
+		ExactHits:      s.ExactHits,
+		Misses:         s.Misses,
+		Sets:           s.Sets,
+		Expired:        s.Expired,
+		Evictions:      s.Evictions,
+		HitRate:        s.HitRate,
+		AvgSimOnHit:    s.AvgSimOnHit,
+		LSHCandidates:  s.LSHCandidates,
+		LSHFallbacks:   s.LSHFallbacks,
+		Pruned:         s.Pruned,
+		Busy:           s.Busy,
+		Ambiguous:      s.Ambiguous,
+		DefiniteMisses: s.DefiniteMisses,
+		SoftMisses:     s.SoftMisses,
+		EarlyExits:     s.EarlyExits,
+		HitSimilarity:  s.HitSimilarity,
+		EntryAllocs:    s.EntryAllocs,
+		EntryReuses:    s.EntryReuses,
+
+		DedupShared:     s.DedupShared,
+		DedupBytesSaved: s.DedupBytesSaved,
+		ValuesShared:    s.ValuesShared,
+		ValueBytesSaved: s.ValueBytesSaved,
+		ValueDedupRatio: s.ValueDedupRatio,
+		EncodeTimeouts:  s.EncodeTimeouts,
+		EncodeErrors:    s.EncodeErrors,
+		EncodeCacheHits: s.EncodeCacheHits,
+		Spooled:         s.Spooled,
+		Oversize:        s.Oversize,
+		Cold:            s.Cold,
+		Faults:          s.Faults,
+	}
+	st.Latency.Encode = Latency(s.Latency.Encode)
+	st.Latency.LockWait = Latency(s.Latency.LockWait)
+	st.Latency.Scan = Latency(s.Latency.Scan)
+	st.PerLookup.Candidates = Distribution(s.PerLookup.Candidates)
+	st.PerLookup.Compared = Distribution(s.PerLookup.Compared)
+	db.fb.stats(&st)
+	if db.vf != nil {
+		db.vf.stats(&st)
+	}
+	if db.ql != nil {
+		st.QueryLogErrors = db.ql.errors.Load()
 	}
+	return st
 }
 
 func (o *dbOptions) cacheOpts() cache.Options {
@@ -201,10 +1132,83 @@ func (o *dbOptions) cacheOpts() cache.Options {
 		Threshold:   o.threshold,
 		Capacity:    o.capacity,
 		TTL:         o.ttl,
+		TTLJitter:   o.ttlJitter,
+		SlidingTTL:  o.slidingTTL,
 		LSHEnabled:  o.lshEnabled,
 		LSHK:        o.lshK,
 		LSHL:        o.lshL,
 		LSHFallback: o.lshFallback,
 		LSHSeed:     o.seed,
+		LSHProbes:   o.lshProbes,
+
+		ParallelScanMin: o.parallelScanMin,
+		ScanWorkers:     o.scanWorkers,
+		BitSliced:       o.bitSliced,
+		MissFilter:      o.missFilter,
+		CoarseBits:      o.coarseBits,
+		LateInteraction: o.lateTokens,
+		ScoreFusion:     o.scoreFusion(),
+		DedupVectors:    o.dedupVectors,
+		DedupValues:     o.dedupValues,
+		KeyArena:        o.keyArena,
+		Index:           o.index(),
+		Similarity:      o.similarity,
+
+		MaxConcurrentScans: o.maxScans,
+		MaxQueuedScans:     o.maxQueuedScans,
+		EvictionSamples:    o.evictionSamples,
+		AcceptedSources:    o.acceptedSources,
+		TombstoneTTL:       o.tombstoneTTL,
+		EncodeBudget:       o.encodeBudget,
+		FallbackEncoder:    o.encodeFallback,
+
+		RecoverEncoderPanics: o.encodeRecover,
+		EncodeCache:          o.encodeCache,
+		LatencySampleRate:    o.latencyRate,
+		QueryExpander:        o.expander(),
+		Margin:               o.margin,
+		SoftMiss:             o.softMiss,
+		EarlyExit:            o.earlyExit,
+		DecayHalfLife:        o.decayHalfLife,
+		MaxValueBytes:        o.maxValueBytes,
+		ValueScrubber:        o.valueScrubber,
+		LowWatermark:         o.lowWatermark,
+		BackgroundEviction:   o.bgEviction,
+		Spool:                o.spool(),
+		HotEntries:           o.hotEntries,
+		ColdStore:            o.coldStore(),
+
+		IgnoreSavedCounters: o.freshStats,
+		Faults:              o.faults,
+		GroupOf:             o.groupOf(),
+		Groups:              o.groups(),
+
+		OnEvent: o.cacheOnEvent(),
+	}
+}
+
+// index returns the cache's exact-key index for WithShardedIndex, or nil
+// for the default.
+func (o *dbOptions) index() cache.Index {
+	if o.indexShards == 0 {
+		return nil
+	}
+	return cache.NewShardedIndex(o.indexShards)
+}
+
+// expander returns the WithQueryExpander function with the key normalizer
+// applied to its results.
+func (o *dbOptions) expander() func(string) []string {
+	fn, norm := o.queryExpander, o.normalizer()
+	if fn == nil || norm == nil {
+		return fn
+	}
+	return func(key string) []string {
+		xs := fn(key)
+		out := make([]string, len(xs))
+		for i, x := range xs {
+			out[i] = norm(x)
+		}
+		return out
 	}
 }
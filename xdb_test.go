@@ -4,12 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/Amansingh-afk/hdc-go"
 	"github.com/Amansingh-afk/xordb"
+	"github.com/Amansingh-afk/xordb/hdcx"
 )
 
 // ── construction ──────────────────────────────────────────────────────────────
@@ -211,6 +214,25 @@ func TestDB_AnyValueType(t *testing.T) {
 	}
 }
 
+func TestDB_SetMany(t *testing.T) {
+	db := xordb.New(xordb.WithCapacity(2), xordb.WithKeyNormalizer(strings.ToLower))
+	db.SetMany([]xordb.Item{
+		{Key: "What is the capital of India", Value: "Delhi"},
+		{Key: "How do you bake a chocolate cake", Value: "oven"},
+		{Key: "Capital of France", Value: "Paris"},
+	})
+
+	if db.Len() != 2 {
+		t.Fatalf("want 2 entries at capacity, got %d", db.Len())
+	}
+	if v, ok, _ := db.Get("capital of france"); !ok || v != "Paris" {
+		t.Fatalf("last item must be stored under its normalized key, got %v %v", v, ok)
+	}
+	if db.Delete("what is the capital of india") {
+		t.Fatal("first item must have been evicted")
+	}
+}
+
 // ── Delete ────────────────────────────────────────────────────────────────────
 
 func TestDB_Delete_Existing(t *testing.T) {
@@ -310,6 +332,19 @@ func TestDB_LRU_Eviction(t *testing.T) {
 	}
 }
 
+func TestDB_WithSampledEviction(t *testing.T) {
+	db := xordb.New(xordb.WithCapacity(10), xordb.WithSampledEviction(3))
+	for i := 0; i < 25; i++ {
+		db.Set(fmt.Sprintf("distinct key %d", i), i)
+	}
+	if db.Len() != 10 {
+		t.Fatalf("sampled eviction must respect capacity, got %d", db.Len())
+	}
+	if v, ok, _ := db.Get("distinct key 24"); !ok || v != 24 {
+		t.Fatal("newest entry must be present")
+	}
+}
+
 // ── WithStripPunctuation ──────────────────────────────────────────────────────
 
 func TestDB_WithStripPunctuation(t *testing.T) {
@@ -325,6 +360,27 @@ func TestDB_WithStripPunctuation(t *testing.T) {
 	}
 }
 
+// ── WithNormalizationProfile ──────────────────────────────────────────────────
+
+func TestDB_WithNormalizationProfile(t *testing.T) {
+	db := xordb.New(xordb.WithNormalizationProfile(hdcx.LogLine), xordb.WithThreshold(0.99))
+	db.Set("worker 3 timed out after 5000ms", "restart")
+
+	if _, ok, sim := db.Get("worker 12 timed out after 30000ms"); !ok {
+		t.Fatalf("LogLine must ignore numbers, got sim %.3f", sim)
+	}
+	if db.Delete("worker 12 timed out after 30000ms") {
+		t.Fatal("the profile must not change exact keys")
+	}
+
+	// a later option overrides the profile's setting
+	db = xordb.New(xordb.WithNormalizationProfile(hdcx.Identifier), xordb.WithStripPunctuation(false))
+	db.Set("user.name", 1)
+	if _, ok, _ := db.Get("user name"); ok {
+		t.Fatal("WithStripPunctuation(false) after the profile must keep punctuation")
+	}
+}
+
 // ── WithSeed isolation ────────────────────────────────────────────────────────
 
 func TestDB_WithSeed_Independent(t *testing.T) {
@@ -426,6 +482,17 @@ func TestDB_WithTTL_Zero_NoExpiry(t *testing.T) {
 	}
 }
 
+func TestDB_SlidingTTLWithJitter(t *testing.T) {
+	db := xordb.New(xordb.WithTTL(100*time.Millisecond), xordb.WithTTLJitter(0.1), xordb.WithSlidingTTL(true))
+	db.Set("hello world", 1)
+	for i := 0; i < 3; i++ {
+		time.Sleep(50 * time.Millisecond)
+		if _, ok, _ := db.Get("hello world"); !ok {
+			t.Fatalf("hit %d: sliding TTL must keep a used entry alive", i)
+		}
+	}
+}
+
 // ── benchmarks ────────────────────────────────────────────────────────────────
 
 func BenchmarkDB_Set(b *testing.B) {
@@ -485,6 +552,65 @@ func TestDB_Save_Load_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestDB_Save_Load_Compressed(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.9), xordb.WithSnapshotCompression(true))
+	for i := 0; i < 50; i++ {
+		db.Set(fmt.Sprintf("what is the status of order number %d", i), i)
+	}
+	plain := xordb.New(xordb.WithThreshold(0.9))
+	for i := 0; i < 50; i++ {
+		plain.Set(fmt.Sprintf("what is the status of order number %d", i), i)
+	}
+	dir := t.TempDir()
+	if err := db.Save(dir + "/packed.xrdb"); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := plain.Save(dir + "/plain.xrdb"); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	packed, _ := os.Stat(dir + "/packed.xrdb")
+	raw, _ := os.Stat(dir + "/plain.xrdb")
+	if packed.Size() >= raw.Size() {
+		t.Errorf("compressed snapshot is %d bytes, uncompressed %d", packed.Size(), raw.Size())
+	}
+
+	db2 := xordb.New(xordb.WithThreshold(0.9))
+	if err := db2.Load(dir + "/packed.xrdb"); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if v, ok, _ := db2.Get("what is the status of order number 17"); !ok || v != float64(17) {
+		t.Errorf("got %v, %v after loading a compressed snapshot", v, ok)
+	}
+}
+
+func TestDB_Save_Load_Stats(t *testing.T) {
+	db := xordb.New(xordb.WithCapacity(2))
+	db.Set("alpha", 1)
+	db.Set("beta", 2)
+	db.Set("gamma", 3)
+	db.Get("beta")
+	path := t.TempDir() + "/cache.xrdb"
+	if err := db.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	db2 := xordb.New(xordb.WithCapacity(2))
+	if err := db2.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if s := db2.Stats(); s.Hits != 1 || s.Sets != 3 || s.Evictions != 1 {
+		t.Fatalf("lifetime counters must survive a restart: %+v", s)
+	}
+
+	db3 := xordb.New(xordb.WithCapacity(2), xordb.WithPersistentStats(false))
+	if err := db3.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if s := db3.Stats(); s.Hits != 0 || s.Sets != 0 {
+		t.Fatalf("WithPersistentStats(false) must start from zero: %+v", s)
+	}
+}
+
 func TestDB_Save_Load_ValuesPreserved(t *testing.T) {
 	db := xordb.New(xordb.WithThreshold(0.99))
 	db.Set("key", "hello world")
@@ -690,3 +816,477 @@ func BenchmarkDB_Get_10000Entries(b *testing.B) {
 		db.Get("benchmark entry number 5000")
 	}
 }
+
+func TestDB_WithTombstones_RestoreDoesNotResurrect(t *testing.T) {
+	path := t.TempDir() + "/cache.xrdb"
+	db := xordb.New(xordb.WithTombstones(time.Hour))
+	db.Set("user:1 profile", "a")
+	db.Set("user:2 profile", "b")
+	if err := db.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	db.DeletePrefix("user:1")
+	if err := db.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if db.Delete("user:1 profile") {
+		t.Fatal("loading an older snapshot must not resurrect a deleted key")
+	}
+	if db.Len() != 1 {
+		t.Fatalf("want 1 entry, got %d", db.Len())
+	}
+}
+
+// ── WithIndex ─────────────────────────────────────────────────────────────────
+
+func TestDB_WithIndex(t *testing.T) {
+	cases := []struct {
+		idx     xordb.Index
+		cap     int
+		wantLSH bool
+	}{
+		{xordb.IndexLinear, 1024, false},
+		{xordb.IndexLSH, 16, true},
+		{xordb.IndexAuto, 1024, true},
+		{xordb.IndexAuto, 16, false},
+	}
+	for _, tc := range cases {
+		db := xordb.New(xordb.WithThreshold(0.70), xordb.WithCapacity(tc.cap), xordb.WithIndex(tc.idx))
+		db.Set("what is the capital of india", "Delhi")
+		if _, ok, _ := db.Get("capital city of india"); !ok {
+			t.Fatalf("%v: expected hit", tc.idx)
+		}
+		s := db.Stats()
+		if gotLSH := s.LSHCandidates+s.LSHFallbacks > 0; gotLSH != tc.wantLSH {
+			t.Fatalf("%v at capacity %d: LSH used = %v", tc.idx, tc.cap, gotLSH)
+		}
+	}
+}
+
+func TestDB_WithIndex_Unknown_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	xordb.New(xordb.WithIndex(xordb.Index(9)))
+}
+
+// ── WithKeyNormalizer ─────────────────────────────────────────────────────────
+
+func TestDB_WithKeyNormalizer(t *testing.T) {
+	db := xordb.New(xordb.WithKeyNormalizer(func(k string) string {
+		return strings.ToLower(strings.TrimSpace(k))
+	}))
+	db.Set("  What Is The Capital Of India ", "Delhi")
+	db.Set("what is the capital of india", "New Delhi") // same canonical key: update
+
+	if db.Len() != 1 {
+		t.Fatalf("keys must collapse to one entry, got %d", db.Len())
+	}
+	if v, ok, sim := db.Get("WHAT IS THE CAPITAL OF INDIA"); !ok || sim != 1 || v != "New Delhi" {
+		t.Fatalf("got %v %v %f", v, ok, sim)
+	}
+	if !db.Pin("What is the capital of India") || !db.Unpin(" what is the capital of india") {
+		t.Fatal("Pin/Unpin must use the canonical key")
+	}
+	if !db.Delete("What is the capital of INDIA  ") {
+		t.Fatal("Delete must find the entry through the normalizer")
+	}
+
+	db.Set("User:42 likes", 1)
+	if n := db.DeletePrefix("USER:"); n != 1 {
+		t.Fatalf("DeletePrefix must normalize the prefix, removed %d", n)
+	}
+}
+
+func TestDB_WithQuestionNormalization(t *testing.T) {
+	plain := xordb.New()
+	db := xordb.New(xordb.WithQuestionNormalization(true), xordb.WithKeyNormalizer(strings.TrimSpace))
+	for _, d := range []*xordb.DB{plain, db} {
+		d.Set("What's the population of Norway?", 5.5)
+	}
+	if _, ok, sim := db.Get("  Tell me the population of Norway"); !ok || sim != 1 {
+		t.Fatalf("rewordings of one question must share a key, got %v %.3f", ok, sim)
+	}
+	ps := plain.MaxSimilarity("What's the population of Sweden?")
+	if qs := db.MaxSimilarity("What's the population of Sweden?"); qs >= ps {
+		t.Errorf("another country scores %.3f normalized, %.3f plain", qs, ps)
+	}
+}
+
+func TestDB_WithQueryExpander(t *testing.T) {
+	var seen string
+	db := xordb.New(
+		xordb.WithThreshold(0.9),
+		xordb.WithKeyNormalizer(func(k string) string { return strings.TrimPrefix(k, "q:") }),
+		xordb.WithQueryExpander(func(k string) []string {
+			seen = k
+			return []string{"q:" + strings.ReplaceAll(k, "k8s", "kubernetes")}
+		}),
+	)
+	db.Set("restart a kubernetes pod", "kubectl delete pod")
+	v, ok, sim := db.Get("q:restart a k8s pod")
+	if !ok || sim != 1 || v != "kubectl delete pod" {
+		t.Fatalf("got %v %v %f, want the expansion to hit exactly", v, ok, sim)
+	}
+	if seen != "restart a k8s pod" {
+		t.Errorf("expander saw %q, want the normalized key", seen)
+	}
+}
+
+// ── Lookup ────────────────────────────────────────────────────────────────────
+
+func TestDB_WithEvictionWatermark(t *testing.T) {
+	db := xordb.New(xordb.WithCapacity(10), xordb.WithEvictionWatermark(5, false))
+	for i := 0; i < 11; i++ {
+		db.Set(fmt.Sprintf("key number %d", i), i)
+	}
+	if db.Len() != 5 {
+		t.Fatalf("len %d, want evicted down to the watermark", db.Len())
+	}
+}
+
+func TestDB_DeleteMatching(t *testing.T) {
+	db := xordb.New(xordb.WithCapacity(512), xordb.WithKeyNormalizer(strings.ToLower)) // LSH on
+	for i := 0; i < 100; i++ {
+		db.Set(fmt.Sprintf("Product-%d question %d", i%4, i), i)
+	}
+	n := db.DeleteMatching(func(k string) bool { return strings.HasPrefix(k, "product-3 ") })
+	if n != 25 || db.Len() != 75 {
+		t.Fatalf("removed %d, %d left; want 25 and 75", n, db.Len())
+	}
+	if _, ok, sim := db.Get("product-3 question 7"); ok && sim == 1 {
+		t.Fatal("deleted key still hits exactly")
+	}
+	if _, ok, _ := db.Get("product-2 question 6"); !ok {
+		t.Fatal("kept key must still hit")
+	}
+}
+
+func TestDB_Lookup(t *testing.T) {
+	db := xordb.New(xordb.WithKeyNormalizer(strings.ToLower))
+	db.Set("What is the capital of India", "Delhi")
+
+	r := db.Lookup("WHAT IS THE CAPITAL OF INDIA")
+	if !r.Hit || r.Value != "Delhi" || r.Similarity != 1 {
+		t.Fatalf("want exact hit, got %+v", r)
+	}
+	if r.MatchedKey != "what is the capital of india" {
+		t.Fatalf("MatchedKey must be the stored key, got %q", r.MatchedKey)
+	}
+	if r.Source == "" || r.EntryAge < 0 {
+		t.Fatalf("hit must carry source and age, got %+v", r)
+	}
+	if r.Confidence != 1 || r.Margin != 0.25 {
+		t.Fatalf("lone exact hit must be fully confident, got %+v", r)
+	}
+	if r := db.Lookup("recipe for banana bread"); r.Hit || r.Value != nil || r.MatchedKey != "" {
+		t.Fatalf("want miss, got %+v", r)
+	}
+}
+
+// ── WithSimilarity ────────────────────────────────────────────────────────────
+
+func TestDB_WithSimilarity(t *testing.T) {
+	// Score only the first half of each vector.
+	half := func(a, b hdc.Vector) float64 {
+		n := len(a.RawData()) / 2
+		return hdc.Similarity(hdc.FromWords(n*64, a.RawData()[:n]), hdc.FromWords(n*64, b.RawData()[:n]))
+	}
+	db := xordb.New(xordb.WithSimilarity(half))
+	db.Set("what is the capital of india", "Delhi")
+
+	if _, ok, sim := db.Get("what is the capital of india"); !ok || sim != 1 {
+		t.Fatalf("exact key must still hit at 1, got ok=%v sim=%f", ok, sim)
+	}
+	want := half(hdc.NewNGramEncoder(hdc.DefaultConfig()).Encode("capital city of india"),
+		hdc.NewNGramEncoder(hdc.DefaultConfig()).Encode("what is the capital of india"))
+	if got := db.MaxSimilarity("capital city of india"); got != want {
+		t.Fatalf("MaxSimilarity = %f, want the custom metric's %f", got, want)
+	}
+}
+
+// ── WithMaxConcurrentScans ────────────────────────────────────────────────────
+
+func TestDB_TryLookup(t *testing.T) {
+	db := xordb.New(xordb.WithMaxConcurrentScans(2, 4))
+	db.Set("hello world", 1)
+
+	r, err := db.TryLookup("hello world")
+	if err != nil || !r.Hit || r.Value != 1 {
+		t.Fatalf("unloaded TryLookup must hit: %+v %v", r, err)
+	}
+	if s := db.Stats(); s.Busy != 0 {
+		t.Fatalf("no lookup should be turned away, busy=%d", s.Busy)
+	}
+}
+
+// ── sources ───────────────────────────────────────────────────────────────────
+
+func TestDB_WithAcceptedSources(t *testing.T) {
+	db := xordb.New(xordb.WithAcceptedSources("gpt-new"))
+	db.SetWithSource("what is the capital of india", "old answer", "gpt-old")
+	db.SetWithSource("capital of india", "new answer", "gpt-new")
+
+	r := db.Lookup("what is the capital of india")
+	if !r.Hit || r.Value != "new answer" || r.EntrySource != "gpt-new" {
+		t.Fatalf("deprecated source must not answer, got %+v", r)
+	}
+	if n := db.DeleteSource("gpt-old"); n != 1 || db.Len() != 1 {
+		t.Fatalf("DeleteSource removed %d, %d left", n, db.Len())
+	}
+	db.SetAcceptedSources("nothing")
+	if _, ok, _ := db.Get("capital of india"); ok {
+		t.Fatal("SetAcceptedSources must replace the filter")
+	}
+}
+
+// ── WithEncodeBudget ──────────────────────────────────────────────────────────
+
+type slowEncoder struct{ hdc.Encoder }
+
+func (e slowEncoder) Encode(key string) hdc.Vector {
+	time.Sleep(100 * time.Millisecond)
+	return e.Encoder.Encode(key)
+}
+
+func TestDB_WithEncodeBudget(t *testing.T) {
+	ngram := hdc.NewNGramEncoder(hdc.DefaultConfig())
+	db := xordb.NewWithEncoder(slowEncoder{ngram}, xordb.WithEncodeBudget(10*time.Millisecond, ngram))
+	db.Set("what is the capital of india", "Delhi")
+	if v, ok, _ := db.Get("what is the capital of india"); !ok || v != "Delhi" {
+		t.Fatalf("fallback encoder must serve the key, got %v %v", v, ok)
+	}
+	if s := db.Stats(); s.EncodeTimeouts != 2 {
+		t.Fatalf("want 2 encode timeouts, got %d", s.EncodeTimeouts)
+	}
+}
+
+// ── WithNearMissVerifier ──────────────────────────────────────────────────────
+
+func waitNearMiss(t *testing.T, db *xordb.DB, done func(xordb.Stats) bool) xordb.Stats {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s := db.Stats()
+		if done(s) {
+			return s
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("near miss not processed: %+v", s)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDB_WithNearMissVerifier(t *testing.T) {
+	var got [2]string
+	db := xordb.New(xordb.WithThreshold(0.75), xordb.WithNearMissVerifier(0.1, func(q, cand string) bool {
+		got = [2]string{q, cand}
+		return true
+	}))
+	db.Set("what is the capital of india", "Delhi")
+	if _, ok, _ := db.Get("capital city of india"); ok {
+		t.Fatal("paraphrase below the threshold must miss")
+	}
+	waitNearMiss(t, db, func(s xordb.Stats) bool { return s.NearMissConfirmed == 1 })
+	if got != [2]string{"capital city of india", "what is the capital of india"} {
+		t.Fatalf("verify called with %q", got)
+	}
+	if v, ok, sim := db.Get("capital city of india"); !ok || v != "Delhi" || sim != 1 {
+		t.Fatalf("confirmed paraphrase must hit exactly, got %v %v %v", v, ok, sim)
+	}
+}
+
+func TestDB_WithNearMissVerifier_Rejected(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.75), xordb.WithNearMissVerifier(0.1, func(q, cand string) bool { return false }))
+	db.Set("what is the capital of india", "Delhi")
+	db.Get("capital city of india")
+	db.Get("something unrelated entirely") // far below the threshold: not queued
+	s := waitNearMiss(t, db, func(s xordb.Stats) bool { return s.NearMissQueued == 1 })
+	if s.NearMissConfirmed != 0 {
+		t.Fatalf("rejected pair must not be aliased: %+v", s)
+	}
+	if _, ok, _ := db.Get("capital city of india"); ok {
+		t.Fatal("rejected paraphrase must still miss")
+	}
+}
+
+// ── WithEncoderPanicRecovery ──────────────────────────────────────────────────
+
+type panickyEncoder struct{ hdc.Encoder }
+
+func (e panickyEncoder) Encode(key string) hdc.Vector {
+	if key == "bad key" {
+		panic("encoder bug")
+	}
+	return e.Encoder.Encode(key)
+}
+
+func TestDB_WithEncoderPanicRecovery(t *testing.T) {
+	db := xordb.NewWithEncoder(panickyEncoder{hdc.NewNGramEncoder(hdc.DefaultConfig())}, xordb.WithEncoderPanicRecovery(true))
+	db.Set("bad key", 1)
+	if _, ok, _ := db.Get("bad key"); ok {
+		t.Fatal("a key the encoder panics on must miss")
+	}
+	if s := db.Stats(); s.EncodeErrors != 2 || db.Len() != 0 {
+		t.Fatalf("want 2 encode errors and no entries, got %d and %d", s.EncodeErrors, db.Len())
+	}
+	if err := db.LastEncodeError(); !errors.Is(err, xordb.ErrEncoder) {
+		t.Fatalf("LastEncodeError: %v", err)
+	}
+}
+
+// ── WithStageTimer ────────────────────────────────────────────────────────────
+
+func TestDB_WithStageTimer(t *testing.T) {
+	var mu sync.Mutex
+	n := map[hdcx.Stage]int{}
+	db := xordb.New(xordb.WithStageTimer(hdcx.StageTimer{Func: func(s hdcx.Stage, _ time.Duration) {
+		mu.Lock()
+		n[s]++
+		mu.Unlock()
+	}}))
+	db.Set("what is the capital of india", "Delhi")
+	path := filepath.Join(t.TempDir(), "plain.xrdb")
+	plain := xordb.New()
+	plain.Set("who wrote ramayana", "Valmiki")
+	if err := plain.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Load(path); err != nil {
+		t.Fatalf("timing must not change encoding: %v", err)
+	}
+	if _, ok, _ := db.Get("who wrote ramayana"); !ok {
+		t.Fatal("timed DB must hit entries encoded without timing")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if n[hdcx.StageNormalize] == 0 || n[hdcx.StageNormalize] != n[hdcx.StageBundle] {
+		t.Fatalf("stage counts: %v", n)
+	}
+}
+
+// ── WithAccentFolding ─────────────────────────────────────────────────────────
+
+func TestDB_WithAccentFolding(t *testing.T) {
+	db := xordb.New(xordb.WithAccentFolding(true))
+	db.Set("crème brûlée recipe", 1)
+	if _, ok, sim := db.Get("creme brulee recipe"); !ok || sim != 1 {
+		t.Fatalf("accents must be ignored, got %v %.3f", ok, sim)
+	}
+	plain := xordb.New()
+	plain.Set("crème brûlée recipe", 1)
+	if _, _, sim := plain.Get("creme brulee recipe"); sim == 1 {
+		t.Fatal("accents must count without folding")
+	}
+}
+
+// ── WithLateInteraction ───────────────────────────────────────────────────────
+
+// wordTokens is an n-gram encoder whose tokens are the words of the text.
+type wordTokens struct{ *hdc.NGramEncoder }
+
+func (w wordTokens) EncodeTokens(text string) []hdc.Vector {
+	var out []hdc.Vector
+	for _, word := range strings.Fields(text) {
+		out = append(out, w.Encode(word))
+	}
+	return out
+}
+
+func TestDB_WithLateInteraction(t *testing.T) {
+	enc := wordTokens{hdc.NewNGramEncoder(hdc.DefaultConfig())}
+	db := xordb.NewWithEncoder(enc, xordb.WithLateInteraction(8), xordb.WithThreshold(0.8))
+	db.Set("how do I rotate the signing keys of the staging cluster", 1)
+	if _, ok, sim := db.Get("for the staging cluster, how do I rotate the signing keys"); !ok {
+		t.Fatalf("reordered words must hit by MaxSim, got %.3f", sim)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("an encoder without EncodeTokens must panic")
+		}
+	}()
+	xordb.New(xordb.WithLateInteraction(8))
+}
+
+func TestDB_WithScoreFusion(t *testing.T) {
+	key, query := "rotate the signing keys of the staging cluster", "of the staging cluster: rotate the signing keys"
+	plain := xordb.New(xordb.WithThreshold(0.9))
+	fused := xordb.New(xordb.WithThreshold(0.9), xordb.WithScoreFusion(0.5))
+	plain.Set(key, 1)
+	fused.Set(key, 1)
+	_, _, ps := plain.Get(query)
+	if _, ok, fs := fused.Get(query); !ok || fs <= ps {
+		t.Fatalf("same words reordered: fused hit=%v %.3f, plain %.3f", ok, fs, ps)
+	}
+
+	if _, err := xordb.TryNewWithEncoder(hdc.NewNGramEncoder(hdc.DefaultConfig()), xordb.WithScoreFusion(0)); err == nil {
+		t.Fatal("alpha 0 must be rejected")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("alpha 0 must panic")
+		}
+	}()
+	xordb.New(xordb.WithScoreFusion(0))
+}
+
+func TestDB_WithSoftMisses(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.99), xordb.WithSoftMisses(0.6))
+	db.Set("what is the capital of india", "Delhi")
+	r := db.Lookup("capital city of india")
+	if r.Hit || !r.SoftMiss || r.Value != "Delhi" || r.Similarity < 0.6 {
+		t.Fatalf("want a soft miss on the india entry, got %+v", r)
+	}
+	if st := db.Stats(); st.SoftMisses != 1 {
+		t.Errorf("SoftMisses = %d", st.SoftMisses)
+	}
+}
+
+func TestDB_WithValueDedup(t *testing.T) {
+	db := xordb.New(xordb.WithValueDedup(true))
+	for _, k := range []string{"what is the capital of india", "capital city of india", "india capital"} {
+		db.Set(k, "New Delhi")
+	}
+	st := db.Stats()
+	if st.ValuesShared != 2 || st.ValueBytesSaved != 18 || st.ValueDedupRatio != 3 {
+		t.Fatalf("shared %d, saved %d, ratio %v", st.ValuesShared, st.ValueBytesSaved, st.ValueDedupRatio)
+	}
+	if !strings.Contains(st.String(), "value dedup: 3.00x") {
+		t.Errorf("String() lacks the ratio:\n%s", st)
+	}
+}
+
+func TestDB_WithEarlyExit(t *testing.T) {
+	db := xordb.New(xordb.WithThreshold(0.6), xordb.WithEarlyExit(0.97), xordb.WithLSH(false))
+	db.Set("how do I reset my password", "Settings > Security")
+	for i := 0; i < 20; i++ {
+		db.Set(fmt.Sprintf("question %d about shipping rates", i), i)
+	}
+	db.Set("how do i reset my password", "again") // most recent, identical vector
+	if r := db.Lookup("How do I reset my password"); r.Value != "again" {
+		t.Fatalf("got %+v", r)
+	}
+	if st := db.Stats(); st.EarlyExits != 1 || st.PerLookup.Compared.Mean != 1 {
+		t.Fatalf("early exits %d, compared %v", st.EarlyExits, st.PerLookup.Compared.Mean)
+	}
+}
+
+func TestDB_WithSimilarityDecay(t *testing.T) {
+	db := xordb.New(xordb.WithSimilarityDecay(200 * time.Millisecond))
+	db.Set("how do I reset my password", "Settings > Security")
+	if _, ok, _ := db.Get("how do I reset my password"); !ok {
+		t.Fatal("a fresh entry must hit")
+	}
+	time.Sleep(300 * time.Millisecond) // a factor of 0.35
+	if _, ok, sim := db.Get("how do I reset my password"); ok || sim != 0 {
+		t.Fatalf("an aged entry must miss: %v, %v", ok, sim)
+	}
+	if r := db.Explain("how do I reset my password", 1); r[0].Similarity > 0.4 {
+		t.Fatalf("Explain must report the decayed score, got %v", r[0].Similarity)
+	}
+}